@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// consoleMessenger prints conversation messages to the terminal. Updates of a message only
+// print the part that was added, so tool progress streams as it happens.
+type consoleMessenger struct {
+	out io.Writer
+
+	mu       sync.Mutex
+	nextID   int
+	messages map[string]string // message ID -> text printed so far
+}
+
+// newConsoleMessenger creates a messenger writing to out
+func newConsoleMessenger(out io.Writer) *consoleMessenger {
+	return &consoleMessenger{out: out, messages: map[string]string{}}
+}
+
+// PostMessage prints a new message and returns its ID
+func (m *consoleMessenger) PostMessage(_, _, text string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	id := strconv.Itoa(m.nextID)
+	m.messages[id] = text
+	_, err := fmt.Fprintf(m.out, "%s\n\n", text)
+	return id, err
+}
+
+// UpdateMessage prints what was added to a message since it was last printed
+func (m *consoleMessenger) UpdateMessage(_, messageID, text string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := m.messages[messageID]
+	m.messages[messageID] = text
+	if added, ok := strings.CutPrefix(text, previous); ok {
+		added = strings.TrimLeft(added, "\n")
+		if added == "" {
+			return nil
+		}
+		_, err := fmt.Fprintf(m.out, "%s\n\n", added)
+		return err
+	}
+	_, err := fmt.Fprintf(m.out, "%s\n\n", text)
+	return err
+}
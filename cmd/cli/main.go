@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"jira_helper/internal/handler"
+	"jira_helper/internal/logger"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+const (
+	// channelID routes the conversation's messages to the console
+	channelID = "cli/console"
+
+	// userID is the identity the local token is stored under
+	userID = "cli-user"
+
+	// queryTimeout bounds a single query like the Slack handlers do
+	queryTimeout = 5 * time.Minute
+)
+
+// staticTokenStore hands out the local Jira token for every user
+type staticTokenStore struct {
+	token string
+}
+
+// GetToken returns the local token
+func (s *staticTokenStore) GetToken(string) (string, error) {
+	return s.token, nil
+}
+
+// SetToken replaces the local token for the rest of the session
+func (s *staticTokenStore) SetToken(_, token string) error {
+	s.token = token
+	return nil
+}
+
+// DeleteToken forgets the local token for the rest of the session
+func (s *staticTokenStore) DeleteToken(string) error {
+	s.token = ""
+	return nil
+}
+
+// ListUsers returns no users, the local token belongs to nobody in particular
+func (s *staticTokenStore) ListUsers() ([]string, error) {
+	return nil, nil
+}
+
+func main() {
+	token := flag.String("token", os.Getenv("JIRA_API_TOKEN"), "personal Jira API token, defaults to $JIRA_API_TOKEN")
+	logLevel := flag.String("log-level", "error", "log level of the handler")
+	flag.Parse()
+
+	if err := logger.Init(*logLevel); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	for _, env := range []string{"AZURE_OPENAI_ENDPOINT", "AZURE_OPENAI_KEY", "AZURE_OPENAI_DEPLOYMENT", "JIRA_URL"} {
+		if os.Getenv(env) == "" {
+			log.Fatalf("missing required environment variable %s", env)
+		}
+	}
+	if *token == "" {
+		log.Fatal("a Jira token is required, pass -token or set JIRA_API_TOKEN")
+	}
+
+	// The MCP server reaches the same Jira as the bot
+	launcher := handler.DefaultMcpLauncher()
+	launcher.JiraURL = os.Getenv("JIRA_URL")
+
+	console := newConsoleMessenger(os.Stdout)
+	h, err := handler.NewSlackHandler(
+		"",
+		os.Getenv("AZURE_OPENAI_ENDPOINT"),
+		os.Getenv("AZURE_OPENAI_KEY"),
+		os.Getenv("AZURE_OPENAI_DEPLOYMENT"),
+		*token,
+		&staticTokenStore{token: *token},
+		handler.WithMessenger(channelID, console),
+		handler.WithMcpLauncher(launcher),
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize handler: %v", err)
+	}
+	defer h.Shutdown(context.Background())
+
+	fmt.Println("Jira helper CLI. Type a question, /reset to start a new conversation or /exit to quit.")
+	repl(h)
+}
+
+// repl reads queries from stdin until EOF or /exit, keeping the conversation history
+func repl(h *handler.SlackHandler) {
+	var history []handler.HistoryMessage
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for {
+		fmt.Print("> ")
+		if !scanner.Scan() {
+			fmt.Println()
+			return
+		}
+		query := strings.TrimSpace(scanner.Text())
+		switch query {
+		case "":
+			continue
+		case "/exit", "/quit":
+			return
+		case "/reset":
+			history = nil
+			fmt.Println("Started a new conversation.")
+			continue
+		}
+
+		// Ctrl-C cancels the running query instead of quitting
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+		result, err := h.Query(ctx, query, history, channelID, channelID, userID)
+		cancel()
+		stop()
+		if err != nil {
+			fmt.Printf("error: %v\n\n", err)
+			continue
+		}
+
+		fmt.Printf("%s\n\n", result.Answer)
+		history = append(history,
+			handler.HistoryMessage{Role: "user", Content: query},
+			handler.HistoryMessage{Role: "assistant", Content: result.Answer})
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"jira_helper/internal/fakejira"
+	"jira_helper/internal/handler"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/replay"
+	"jira_helper/internal/service/openai"
+	"jira_helper/internal/simulator"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mark3labs/mcp-go/client"
+)
+
+// replayTimeout bounds how long a replayed query may take to be answered
+const replayTimeout = 2 * time.Minute
+
+// devToken is the Jira token of every user, the fake Jira accepts any
+const devToken = "dev-token"
+
+// staticTokenStore hands out the development token for every user
+type staticTokenStore struct{}
+
+// GetToken returns the development token
+func (staticTokenStore) GetToken(string) (string, error) {
+	return devToken, nil
+}
+
+// SetToken accepts any token and keeps using the development token
+func (staticTokenStore) SetToken(string, string) error {
+	return nil
+}
+
+// DeleteToken does nothing, users always have the development token
+func (staticTokenStore) DeleteToken(string) error {
+	return nil
+}
+
+// ListUsers returns no users, the token belongs to nobody in particular
+func (staticTokenStore) ListUsers() ([]string, error) {
+	return nil, nil
+}
+
+func main() {
+	listen := flag.String("listen", ":3001", "address the app, the fake Slack API and the web chat listen on")
+	channel := flag.String("channel", "D0DEVSERVER", "channel the console chats in, channels starting with D are DMs")
+	logLevel := flag.String("log-level", "warn", "log level of the handler")
+	record := flag.String("record", "", "golden file to record the model and tool calls of the session to")
+	replayFile := flag.String("replay", "", "golden file to replay: sends its queries, answers from it and exits non-zero if the conversations diverge")
+	flag.Parse()
+
+	if err := logger.Init(*logLevel); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	// Listen before chatting, so the first message is not sent to a server that is not up yet. Port
+	// 0 picks a free one, e.g. to replay in CI.
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", *listen, err)
+	}
+	host, _, _ := net.SplitHostPort(*listen)
+	if host == "" {
+		host = "localhost"
+	}
+	baseURL := "http://" + net.JoinHostPort(host, strconv.Itoa(listener.Addr().(*net.TCPAddr).Port))
+
+	// Slack is the simulator's fake workspace, Jira the in-memory one behind an in-process MCP server
+	sim := simulator.New(baseURL+"/slack/events", "")
+	jiraServer := fakejira.NewServer(fakejira.NewStore())
+	newMcpClient := func(string) (replay.Client, error) {
+		return client.NewInProcessClient(jiraServer)
+	}
+
+	// The real model is used when its credentials are set, so prompts can be tried against it
+	model := "Azure OpenAI deployment " + os.Getenv("AZURE_OPENAI_DEPLOYMENT")
+	var aiProvider replay.Model = &scriptedModel{}
+	if os.Getenv("AZURE_OPENAI_ENDPOINT") == "" {
+		model = "scripted model, set AZURE_OPENAI_ENDPOINT, AZURE_OPENAI_KEY and AZURE_OPENAI_DEPLOYMENT for a real one"
+	} else {
+		azureClient, err := openai.NewClient(os.Getenv("AZURE_OPENAI_ENDPOINT"), os.Getenv("AZURE_OPENAI_KEY"), os.Getenv("AZURE_OPENAI_DEPLOYMENT"))
+		if err != nil {
+			log.Fatalf("Failed to create model client: %v", err)
+		}
+		aiProvider = azureClient
+	}
+
+	// Recording wraps the model and Jira, replaying stands in for both
+	var replayer *replay.Replayer
+	switch {
+	case *replayFile != "":
+		cassette, err := replay.Load(*replayFile)
+		if err != nil {
+			log.Fatalf("Failed to load replay: %v", err)
+		}
+		replayer = replay.NewReplayer(cassette)
+		model = "replay of " + *replayFile
+		aiProvider = replayer.Model()
+		newMcpClient = func(string) (replay.Client, error) {
+			return replayer.Client(), nil
+		}
+	case *record != "":
+		recorder := replay.NewRecorder(*record)
+		model += ", recorded to " + *record
+		aiProvider = recorder.Model(aiProvider)
+		startMcpClient := newMcpClient
+		newMcpClient = func(token string) (replay.Client, error) {
+			mcpClient, err := startMcpClient(token)
+			if err != nil {
+				return nil, err
+			}
+			return recorder.Client(mcpClient), nil
+		}
+	}
+
+	opts := []handler.Option{
+		handler.WithSlackAPIURL(baseURL + "/api/"),
+		handler.WithAIProvider(aiProvider),
+		handler.WithMCPClientFactory(func(token string) (handler.MCPClient, error) {
+			return newMcpClient(token)
+		}),
+	}
+
+	h, err := handler.NewSlackHandler(
+		"xoxb-devserver",
+		os.Getenv("AZURE_OPENAI_ENDPOINT"),
+		os.Getenv("AZURE_OPENAI_KEY"),
+		os.Getenv("AZURE_OPENAI_DEPLOYMENT"),
+		devToken,
+		staticTokenStore{},
+		opts...,
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize handler: %v", err)
+	}
+	defer h.Shutdown(context.Background())
+
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.POST("/slack/events", h.HandleRequest)
+	r.POST("/slack/interactions", h.HandleInteraction)
+	mux := http.NewServeMux()
+	mux.Handle("/slack/", r)
+	mux.Handle("/", sim.Handler())
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			log.Fatalf("dev server stopped: %v", err)
+		}
+	}()
+
+	fmt.Printf("Model: %s\n", model)
+	if replayer != nil {
+		if err := replayConversations(sim, *channel, replayer); err != nil {
+			h.Shutdown(context.Background())
+			log.Fatalf("Replay failed: %v", err)
+		}
+		fmt.Println("Replay matched the recording.")
+		return
+	}
+	fmt.Printf("Jira: in-memory, projects DEMO and OPS\n")
+	fmt.Printf("Web chat on %s/\n", baseURL)
+	fmt.Printf("Chatting in %s. /new starts a new thread, /exit quits.\n\n", *channel)
+	chat(sim, *channel)
+}
+
+// replayConversations sends the queries of the recorded conversations to the channel, each thread
+// after the previous one is answered, and checks that every recorded call was replayed
+func replayConversations(sim *simulator.Server, channel string, replayer *replay.Replayer) error {
+	for _, queries := range replayer.Threads() {
+		threadTS := ""
+		for _, query := range queries {
+			fmt.Printf("> %s\n", query)
+			ts, err := sim.Send(context.Background(), channel, threadTS, query)
+			if err != nil {
+				return fmt.Errorf("failed to send %q: %v", query, err)
+			}
+			if threadTS == "" {
+				threadTS = ts
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), replayTimeout)
+			err = replayer.Wait(ctx, queries[0], query)
+			cancel()
+			if err != nil {
+				for _, mismatch := range replayer.Mismatches() {
+					fmt.Printf("unexpected call: %s\n", mismatch)
+				}
+				return fmt.Errorf("query %q: %v", query, err)
+			}
+		}
+	}
+	if unused := replayer.Unused(); unused > 0 {
+		return fmt.Errorf("%d recorded calls were not made", unused)
+	}
+	return nil
+}
+
+// chat sends the lines typed in the console to the channel and prints the bot's messages there
+func chat(sim *simulator.Server, channel string) {
+	var mu sync.Mutex
+	printed := map[string]string{}
+	sim.OnMessage(func(msg simulator.Message) {
+		if msg.Channel != channel {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		previous := printed[msg.TS]
+		printed[msg.TS] = msg.Text
+		if text, ok := strings.CutPrefix(msg.Text, previous); ok && previous != "" {
+			// Progress messages grow line by line, print only the new part
+			fmt.Println(strings.TrimSpace(text))
+			return
+		}
+		fmt.Printf("🤖 %s\n", msg.Text)
+	})
+
+	threadTS := ""
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !scanner.Scan() {
+			return
+		}
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+			continue
+		case "/exit":
+			return
+		case "/new":
+			threadTS = ""
+			fmt.Println("Started a new thread.")
+			continue
+		}
+
+		ts, err := sim.Send(context.Background(), channel, threadTS, line)
+		if err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+		if threadTS == "" {
+			threadTS = ts
+		}
+	}
+}
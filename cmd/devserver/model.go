@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"jira_helper/internal/fakejira"
+	"jira_helper/internal/service/openai"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+)
+
+// maxAnswerLength shortens the tool results the scripted model quotes
+const maxAnswerLength = 1500
+
+// issueKeyPattern matches an issue key in a question
+var issueKeyPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-\d+\b`)
+
+// transitionWords map words of a question to the status the issue is moved to
+var transitionWords = []struct {
+	words  []string
+	status string
+}{
+	{[]string{"close", "done", "resolve", "finish"}, "Done"},
+	{[]string{"review"}, "In Review"},
+	{[]string{"start", "progress"}, "In Progress"},
+	{[]string{"reopen", "to do", "todo"}, "To Do"},
+}
+
+// scriptedModel stands in for the AI model when no credentials are set. It picks a tool from the
+// words of the question and answers with what the tool returned, which is enough to exercise the
+// conversation loop, tool progress, approvals and the Slack flow without a model.
+type scriptedModel struct {
+	calls atomic.Int64
+}
+
+// Chat answers requests outside the conversation loop, such as summaries, by echoing the request
+func (m *scriptedModel) Chat(_ context.Context, messages []azopenai.ChatRequestMessageClassification) (string, error) {
+	converted, err := openai.Messages(messages)
+	if err != nil || len(converted) == 0 {
+		return "", err
+	}
+	return "(scripted model) " + truncate(converted[len(converted)-1].Content), nil
+}
+
+// ChatWithTools calls a tool for a new question and answers once the tool has returned
+func (m *scriptedModel) ChatWithTools(_ context.Context, messages []azopenai.ChatRequestMessageClassification, tools []openai.Tool) (*openai.ChatResponse, error) {
+	converted, err := openai.Messages(messages)
+	if err != nil {
+		return nil, err
+	}
+	if len(converted) == 0 {
+		return &openai.ChatResponse{Content: "Ask me something about Jira.", IsComplete: true}, nil
+	}
+	last := converted[len(converted)-1]
+	if last.Role == "tool" {
+		return &openai.ChatResponse{
+			Content:    fmt.Sprintf("(scripted model) The tool returned:\n```%s```", truncate(last.Content)),
+			IsComplete: true,
+		}, nil
+	}
+
+	name, args := pickTool(last.Content)
+	if !offered(tools, name) {
+		return &openai.ChatResponse{
+			Content: "(scripted model) I can search issues, show, create, comment on and transition them, e.g. " +
+				"\"show DEMO-1\", \"create a bug: login fails\", \"comment on DEMO-2: looks good\", \"close DEMO-3\" or \"my issues\".",
+			IsComplete: true,
+		}, nil
+	}
+	call := openai.ToolCall{ID: fmt.Sprintf("call_%d", m.calls.Add(1)), Name: name, Args: args}
+	return &openai.ChatResponse{ToolCalls: []openai.ToolCall{call}}, nil
+}
+
+// pickTool chooses the Jira tool and arguments for the question
+func pickTool(question string) (string, map[string]interface{}) {
+	lower := strings.ToLower(question)
+	detail := question
+	if _, after, ok := strings.Cut(question, ":"); ok {
+		detail = strings.TrimSpace(after)
+	}
+
+	if key := issueKeyPattern.FindString(question); key != "" {
+		switch {
+		case strings.Contains(lower, "comment"):
+			return "jira_add_comment", map[string]interface{}{"issue_key": key, "comment": detail}
+		case strings.Contains(lower, "delete"):
+			return "jira_delete_issue", map[string]interface{}{"issue_key": key}
+		}
+		for _, transition := range transitionWords {
+			for _, word := range transition.words {
+				if strings.Contains(lower, word) {
+					return "jira_transition_issue", map[string]interface{}{"issue_key": key, "transition_id": transition.status}
+				}
+			}
+		}
+		return "jira_get_issue", map[string]interface{}{"issue_key": key}
+	}
+
+	project := "DEMO"
+	for key := range fakejira.Projects {
+		if strings.Contains(question, key) {
+			project = key
+		}
+	}
+	if strings.Contains(lower, "create") || strings.Contains(lower, "file") || strings.Contains(lower, "new ") {
+		issueType := "Task"
+		if strings.Contains(lower, "bug") {
+			issueType = "Bug"
+		}
+		return "jira_create_issue", map[string]interface{}{"project_key": project, "summary": detail, "issue_type": issueType}
+	}
+
+	var conditions []string
+	if strings.Contains(question, project) {
+		conditions = append(conditions, "project = "+project)
+	}
+	if strings.Contains(lower, "my ") || strings.Contains(lower, "mine") {
+		conditions = append(conditions, "assignee = currentUser()")
+	}
+	if strings.Contains(lower, "bug") {
+		conditions = append(conditions, "issuetype = Bug")
+	}
+	if strings.Contains(lower, "open") {
+		conditions = append(conditions, "status != Done")
+	}
+	return "jira_search", map[string]interface{}{"jql": strings.TrimSpace(strings.Join(conditions, " AND ") + " ORDER BY updated DESC")}
+}
+
+// offered reports whether the conversation offers the tool
+func offered(tools []openai.Tool, name string) bool {
+	for _, tool := range tools {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate shortens the text to about maxAnswerLength bytes, without cutting a character in half
+func truncate(text string) string {
+	if len(text) <= maxAnswerLength {
+		return text
+	}
+	cut := maxAnswerLength
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "…"
+}
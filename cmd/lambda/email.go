@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"jira_helper/internal/config"
+	"jira_helper/internal/email"
+	"jira_helper/internal/logger"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"go.uber.org/zap"
+)
+
+// parseSESEvent returns the received emails in the payload, if the function was invoked by an SES receipt rule
+func parseSESEvent(payload json.RawMessage) (events.SimpleEmailEvent, bool) {
+	var sesEvent events.SimpleEmailEvent
+	if err := json.Unmarshal(payload, &sesEvent); err != nil || len(sesEvent.Records) == 0 {
+		return sesEvent, false
+	}
+	return sesEvent, sesEvent.Records[0].EventSource == "aws:ses"
+}
+
+// handleSESEvent triages each received email. The receipt rule stores the raw email in S3
+// first, since the SES event only carries the headers.
+func handleSESEvent(ctx context.Context, sesEvent events.SimpleEmailEvent) error {
+	cfg := config.Get()
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %v", err)
+	}
+	s3Client := s3.NewFromConfig(awsCfg)
+
+	for _, record := range sesEvent.Records {
+		mail, receipt := record.SES.Mail, record.SES.Receipt
+		log := logger.GetLogger().With(zap.String("message_id", mail.MessageID))
+
+		if receipt.SpamVerdict.Status == "FAIL" || receipt.VirusVerdict.Status == "FAIL" {
+			log.Warn("dropped email that failed spam or virus checks", zap.String("from", mail.Source))
+			continue
+		}
+		recipient, route, ok := cfg.EmailRoutes.Match(receipt.Recipients)
+		if !ok {
+			log.Warn("dropped email without a route", zap.Strings("recipients", receipt.Recipients))
+			continue
+		}
+
+		raw, err := loadEmail(ctx, s3Client, cfg.EmailBucketName, cfg.EmailObjectPrefix+mail.MessageID)
+		if err != nil {
+			return err
+		}
+		msg, err := email.Parse(raw)
+		if err != nil {
+			log.Error("dropped unparsable email", zap.Error(err))
+			continue
+		}
+		if err := slackHandler.HandleInboundEmail(ctx, recipient, route, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadEmail reads the raw email stored by the receipt rule's S3 action
+func loadEmail(ctx context.Context, client *s3.Client, bucket, key string) ([]byte, error) {
+	result, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email %s from S3: %v", key, err)
+	}
+	defer result.Body.Close()
+
+	raw, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email %s: %v", key, err)
+	}
+	return raw, nil
+}
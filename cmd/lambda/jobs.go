@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"jira_helper/internal/app"
+	"jira_helper/internal/audit"
+	"jira_helper/internal/config"
+	"jira_helper/internal/digest"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/retention"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"go.uber.org/zap"
+)
+
+// ScheduledJob is the payload of an EventBridge schedule, configured as the rule's constant input
+// e.g. {"job": "retention-purge"}
+type ScheduledJob struct {
+	Job string `json:"job"`
+}
+
+// scheduledJobs maps job names to their implementations
+var scheduledJobs = map[string]func(ctx context.Context) error{
+	"retention-purge":  runRetentionPurge,
+	"daily-digest":     func(ctx context.Context) error { return slackHandler.RunDigests(ctx, digest.Daily) },
+	"weekly-digest":    func(ctx context.Context) error { return slackHandler.RunDigests(ctx, digest.Weekly) },
+	"similar-issues":   func(ctx context.Context) error { return slackHandler.RefreshSimilarIssues(ctx) },
+	"feedback-summary": func(ctx context.Context) error { return slackHandler.SummarizeFeedback(ctx) },
+	// One-off: re-encrypts tokens stored with the legacy static key
+	"migrate-token-encryption": func(ctx context.Context) error { return app.MigrateLegacyTokens(ctx, config.Get()) },
+}
+
+// parseScheduledJob returns the job named in the payload, if the payload is a scheduled job
+func parseScheduledJob(payload json.RawMessage) (ScheduledJob, bool) {
+	var job ScheduledJob
+	if err := json.Unmarshal(payload, &job); err != nil || job.Job == "" {
+		return job, false
+	}
+	return job, true
+}
+
+// runScheduledJob runs the named job
+func runScheduledJob(ctx context.Context, job ScheduledJob) error {
+	run, ok := scheduledJobs[job.Job]
+	if !ok {
+		return fmt.Errorf("unknown scheduled job %q", job.Job)
+	}
+	logger.GetLogger().Info("running scheduled job", zap.String("job", job.Job))
+	if err := run(ctx); err != nil {
+		logger.GetLogger().Error("scheduled job failed", zap.String("job", job.Job), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// runRetentionPurge deletes stored data older than the configured retention periods
+func runRetentionPurge(ctx context.Context) error {
+	cfg := config.Get()
+	policy := retention.Policy(cfg.RetentionDays)
+	if len(policy) == 0 {
+		logger.GetLogger().Info("no retention policy configured, skipping purge")
+		return nil
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if cfg.TokenBucketName == "" {
+		return fmt.Errorf("a retention policy requires TOKEN_BUCKET_NAME")
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %v", err)
+	}
+	s3Client := s3.NewFromConfig(awsCfg)
+	// Purging only touches the oldest entries, not the head, so the trail needs no head store
+	auditTrail := audit.NewS3Trail(s3Client, cfg.TokenBucketName, cfg.AuditObjectLockDays, nil, cfg.AuditCheckpointKey)
+	purger := retention.NewPurger(s3Client, cfg.TokenBucketName, policy, auditTrail)
+	deleted, err := purger.Purge(ctx)
+	logger.GetLogger().Info("retention purge finished", zap.Any("deleted", deleted))
+	return err
+}
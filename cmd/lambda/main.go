@@ -2,22 +2,22 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"jira_helper/internal/app"
+	"jira_helper/internal/auth"
 	"jira_helper/internal/config"
 	"jira_helper/internal/handler"
 	"jira_helper/internal/logger"
-	"jira_helper/internal/storage"
+	"jira_helper/internal/service/googlechat"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/service/pagerduty"
+	"jira_helper/internal/tracing"
 	"log"
 	"os"
 
-	"bytes"
-	"os/exec"
-	"time"
-
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	awsconfig "github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	ginadapter "github.com/awslabs/aws-lambda-go-api-proxy/gin"
 	"github.com/gin-gonic/gin"
 )
@@ -31,48 +31,101 @@ func main() {
 			log.Fatalf("Failed to initialize logger: %v", err)
 		}
 		defer logger.Sync()
+		initTracing()
 
 		if err := initSlackHandler(); err != nil {
 			log.Fatalf("Failed to initialize slack handler: %v", err)
 		}
+		if err := initKeyRing(); err != nil {
+			log.Fatalf("Failed to initialize API keys: %v", err)
+		}
+		// Start the MCP subprocess in the background instead of on the first request
+		go slackHandler.WarmUp(context.Background())
+		drainOnSignal(lambdaShutdownGrace)
+
 		r := RouterEngine()
 		ginLambda := ginadapter.New(r)
-		rawHandler := func(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+		rawHandler := func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+			// The process is frozen after the invocation, export its spans first
+			defer func() { _ = tracing.Flush(ctx) }()
+
+			// EventBridge schedules invoke the same function with a job payload
+			if job, ok := parseScheduledJob(payload); ok {
+				return nil, runScheduledJob(ctx, job)
+			}
+
+			// Step Functions invokes one conversation round per state
+			if round, ok := parseConversationRound(payload); ok {
+				return handleConversationRound(ctx, round)
+			}
+
+			// SES receipt rules deliver inbound emails
+			if sesEvent, ok := parseSESEvent(payload); ok {
+				return nil, handleSESEvent(ctx, sesEvent)
+			}
+
+			// The worker function is triggered by the event queue
+			if sqsEvent, ok := app.ParseSQSEvent(payload); ok {
+				return app.HandleSQSEvent(ctx, slackHandler, sqsEvent, config.Get().EventQueueMaxReceives)
+			}
+
+			var req events.LambdaFunctionURLRequest
+			if err := json.Unmarshal(payload, &req); err != nil {
+				return nil, fmt.Errorf("failed to decode function URL request: %v", err)
+			}
 			return ginLambda.ProxyFunctionURLWithContext(ctx, req)
 		}
 		lambda.Start(rawHandler)
+	} else if IsServerMode() {
+		runServer()
 	} else {
 		logger.GetLogger().Info("Running locally")
-		os.Setenv("SLACK_BOT_TOKEN", "xxx")
+		// Placeholders only fill in what is not set, so real values or secret references can be
+		// given in the environment
+		setEnvDefault("SLACK_BOT_TOKEN", "xxx")
 
-		os.Setenv("AZURE_OPENAI_ENDPOINT", "xxx")
-		os.Setenv("AZURE_OPENAI_KEY", "xxx")
-		os.Setenv("AZURE_OPENAI_DEPLOYMENT", "gpt-4o")
+		setEnvDefault("AZURE_OPENAI_ENDPOINT", "xxx")
+		setEnvDefault("AZURE_OPENAI_KEY", "xxx")
+		setEnvDefault("AZURE_OPENAI_DEPLOYMENT", "gpt-4o")
 
-		os.Setenv("TOKEN_BUCKET_NAME", "jira-helper-tokens")
-		os.Setenv("LOG_LEVEL", "DEBUG")
-		os.Setenv("DEFAULT_JIRA_TOKEN", "xxx")
+		setEnvDefault("TOKEN_BUCKET_NAME", "jira-helper-tokens")
+		setEnvDefault("LOG_LEVEL", "DEBUG")
+		setEnvDefault("DEFAULT_JIRA_TOKEN", "xxx")
 
 		// below are only needed for local testing, not in lambda
-		os.Setenv("AWS_REGION", "us-east-1")
-		os.Setenv("AWS_ACCESS_KEY_ID", "xxx")
-		os.Setenv("AWS_SECRET_ACCESS_KEY", "xx")
-		os.Setenv("AWS_SESSION_TOKEN", "xxx")
+		setEnvDefault("AWS_REGION", "us-east-1")
+		if os.Getenv("AWS_PROFILE") == "" {
+			setEnvDefault("AWS_ACCESS_KEY_ID", "xxx")
+			setEnvDefault("AWS_SECRET_ACCESS_KEY", "xx")
+			setEnvDefault("AWS_SESSION_TOKEN", "xxx")
+		}
 
 		initConfig()
 		if err := logger.Init(config.Get().LogLevel); err != nil {
 			log.Fatalf("Failed to initialize logger: %v", err)
 		}
 		defer logger.Sync()
+		initTracing()
 		if err := initSlackHandler(); err != nil {
 			log.Fatalf("Failed to initialize slack handler: %v", err)
 		}
+		if err := initKeyRing(); err != nil {
+			log.Fatalf("Failed to initialize API keys: %v", err)
+		}
+		// Start the MCP subprocess in the background instead of on the first request
+		go slackHandler.WarmUp(context.Background())
 
-		r := RouterEngine()
+		ctx, stop := shutdownSignals()
+		defer stop()
+		startSocketMode(ctx)
+		serve(ctx, ":3000", localShutdownGrace)
+	}
+}
 
-		if err := r.Run(":3000"); err != nil {
-			log.Fatal("Server is shutting down due to ", err)
-		}
+// setEnvDefault sets the environment variable unless it is already set
+func setEnvDefault(key, value string) {
+	if os.Getenv(key) == "" {
+		os.Setenv(key, value)
 	}
 }
 
@@ -83,119 +136,148 @@ func initConfig() {
 	}
 }
 
+// initTracing exports spans when a trace exporter is configured
+func initTracing() {
+	if err := tracing.Init(context.Background(), config.Get().TraceExporter, "jira-helper"); err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+}
+
 func RouterEngine() *gin.Engine {
 	r := gin.New()
 	r.Use(gin.Recovery())
+
+	// Health checks are registered before the other middleware so load balancers need no credentials
+	r.GET("/healthz", slackHandler.HandleHealth)
+	r.GET("/readyz", slackHandler.HandleReady)
+	// Usage totals only add up in a long-running process, Lambda uses the EMF metrics instead
+	if !IsInLambda() {
+		r.GET("/metrics", slackHandler.HandleMetrics)
+	}
+
+	r.Use(tracing.Middleware())
+
 	r.Use(handler.HandleSlackRetry())
 	r.Use(logger.GinLogMiddleware())
 
+	// Browsers follow the install link and Slack's redirect, neither is signed
+	if config.Get().SlackOAuthRedirectURL != "" {
+		r.GET("/slack/install", slackHandler.HandleSlackInstall)
+		r.GET("/slack/oauth_redirect", slackHandler.HandleSlackOAuthRedirect)
+	}
+
+	// Webhooks come from services that cannot sign with AWS credentials and rely on their own
+	// signature or token. With AWS_IAM auth Lambda rejects them before they reach us, so they need
+	// a second Function URL with NONE auth on an alias; registering them before the IAM check lets
+	// them through there while every other route on that URL still fails the check below.
+	webhooks := r.Group("/")
+
+	// Google Chat signs its requests with a bearer token for the app's project
+	if config.Get().GoogleChatCredentials != "" {
+		verifier := googlechat.NewVerifier(config.Get().GoogleChatProjectNumber)
+		webhooks.POST("/google-chat", googlechat.RequireChatToken(verifier), slackHandler.HandleGoogleChatEvent)
+	}
+
+	// GitHub signs its webhook deliveries with the shared secret, which the handler verifies
+	if config.Get().GitHubWebhookSecret != "" {
+		webhooks.POST("/github", slackHandler.HandleGitHubWebhook)
+	}
+
+	// Jira Cloud signs its webhook deliveries, Jira Server passes the secret in the URL
+	if secret := config.Get().JiraWebhookSecret; secret != "" {
+		webhooks.POST("/jira-webhook", jira.RequireWebhookSecret(secret), slackHandler.HandleJiraWebhook)
+	}
+
+	// PagerDuty signs its webhook deliveries with the subscription's secret
+	if secret := config.Get().PagerDutyWebhookSecret; secret != "" {
+		webhooks.POST("/pagerduty", pagerduty.RequireSignature(secret), slackHandler.HandlePagerDutyWebhook)
+	}
+
+	// With AWS_IAM auth on the Function URL, only accept the expected signing identities
+	if config.Get().FunctionURLAuth == auth.FunctionURLAuthIAM {
+		r.Use(auth.RequireIAMCaller(config.Get().IAMAllowedCallerARNs))
+	}
+
 	// Create a group for Slack endpoints with retry handling
 	slackGroup := r.Group("/")
+	if secret := config.Get().SlackSigningSecret; secret != "" {
+		slackGroup.Use(handler.VerifySlackSignature(secret))
+	}
+	slackGroup.Use(slackHandler.TrackWorkspace())
 
 	slackGroup.POST("/", slackHandler.HandleRequest)
-	slackGroup.POST("/setup-personal-token", slackHandler.HandleSetupPersonalToken)
-	slackGroup.POST("/shell", ShellHandler)
-
-	fmt.Println("Start create mcp client")
-	if _, err := slackHandler.CreateMcpClient("xxxx"); err != nil {
-		fmt.Println("error create mcp client", err.Error())
-		panic(err.Error())
+	// Interactions approve writes and RequireAdmin trusts the user_id form field, only the
+	// signature proves either came from Slack
+	if config.Get().SlackSigningSecret != "" {
+		slackGroup.POST("/interactions", slackHandler.HandleInteraction)
+		registerSlashCommands(slackGroup)
 	} else {
-		fmt.Println("create mcp client OK")
+		logger.GetLogger().Warn("SLACK_SIGNING_SECRET is not set, /interactions and the slash commands are not registered")
+	}
+
+	// Programmatic endpoints require an API key with the matching scope
+	if len(config.Get().ShellCommands) > 0 {
+		r.POST("/shell", keyRing.RequireScopeOrIAMCaller(auth.ScopeShell, config.Get().AdminIAMCallerARNs), slackHandler.HandleOpsCommand)
 	}
+	r.POST("/query", keyRing.RequireScope(auth.ScopeQuery), slackHandler.HandleQuery)
+
+	// Operators manage tokens and features with an admin API key or an allowed IAM identity
+	adminGroup := r.Group("/admin", keyRing.RequireScopeOrIAMCaller(auth.ScopeAdmin, config.Get().AdminIAMCallerARNs))
+	adminGroup.GET("/users", slackHandler.HandleAdminListUsers)
+	adminGroup.DELETE("/users/:user_id/token", slackHandler.HandleAdminRevokeToken)
+	adminGroup.GET("/users/:user_id/usage", slackHandler.HandleAdminUserUsage)
+	adminGroup.GET("/audit", slackHandler.HandleAdminAudit)
+	adminGroup.GET("/flags", slackHandler.HandleAdminListFlags)
+	adminGroup.PUT("/flags/:name", slackHandler.HandleAdminSetFlag)
+	adminGroup.GET("/access", slackHandler.HandleAdminGetAccess)
+	adminGroup.PUT("/access", slackHandler.HandleAdminSetAccess)
 
 	return r
 }
 
+// registerSlashCommands registers the routes of the slash commands, named after the commands.
+// Every command acts as the user_id of the form, so they must only be registered where the
+// requests are known to come from Slack.
+func registerSlashCommands(routes gin.IRoutes) {
+	routes.POST("/setup-token", slackHandler.HandleSetupToken)
+	routes.POST("/setup-personal-token", slackHandler.HandleSetupPersonalToken)
+	routes.POST("/remove-personal-token", slackHandler.HandleRemovePersonalToken)
+	routes.POST("/rotate-personal-token", slackHandler.HandleRotatePersonalToken)
+	routes.POST("/link-jira-account", slackHandler.HandleLinkJiraAccount)
+	routes.POST("/jira-admin", slackHandler.RequireAdmin(), slackHandler.HandleAdminCommand)
+	routes.POST("/jira", slackHandler.HandleJiraCommand)
+}
+
+// slashCommandRouter serves the slash commands received over Socket Mode. They come through the
+// app token's connection, so there is no request signature to verify.
+func slashCommandRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(logger.GinLogMiddleware())
+	r.Use(slackHandler.TrackWorkspace())
+	registerSlashCommands(r)
+	return r
+}
+
 func IsInLambda() bool {
 	return os.Getenv("AWS_LAMBDA_RUNTIME_API") != ""
 }
 
 var slackHandler *handler.SlackHandler
 
-// 32-byte key for AES-256 encryption
-var encryptionKey = []byte{
-	0x0f, 0x71, 0x11, 0xee, 0x50, 0x74, 0x08, 0x3f,
-	0x67, 0xe0, 0x0c, 0x23, 0xca, 0x6f, 0xe5, 0xde,
-	0x75, 0x23, 0x7a, 0x0e, 0x7b, 0x61, 0xf4, 0x89,
-	0xe3, 0x56, 0xed, 0x0f, 0x7a, 0x9d, 0xf4, 0x89,
-}
+var keyRing *auth.KeyRing
 
 func initSlackHandler() error {
-	cfg := config.Get()
-
-	// Initialize AWS config
-	awsCfg, err := awsconfig.LoadDefaultConfig(context.TODO())
-	if err != nil {
-		return fmt.Errorf("failed to load AWS config: %v", err)
-	}
+	var err error
+	slackHandler, err = app.NewSlackHandler(config.Get(), IsServerMode())
+	return err
+}
 
-	// Create S3 client
-	s3Client := s3.NewFromConfig(awsCfg)
-
-	// Create token store with direct 32-byte key
-	tokenStore := storage.NewS3TokenStore(
-		s3Client,
-		cfg.TokenBucketName,
-		encryptionKey,
-	)
-
-	slackHandler, err = handler.NewSlackHandler(
-		cfg.SlackBotToken,
-		cfg.AzureOpenAIEndpoint,
-		cfg.AzureOpenAIKey,
-		cfg.AzureOpenAIDeployment,
-		cfg.DefaultJiraToken,
-		tokenStore,
-	)
+func initKeyRing() error {
+	keys, err := auth.ParseKeys(config.Get().APIKeys)
 	if err != nil {
 		return err
 	}
-
+	keyRing = auth.NewKeyRing(keys)
 	return nil
 }
-
-// ShellHandler handles shell command execution
-func ShellHandler(c *gin.Context) {
-	// Only allow POST
-	if c.Request.Method != "POST" {
-		c.JSON(405, gin.H{"error": "Method not allowed, use POST"})
-		return
-	}
-
-	// Parse JSON body
-	var request struct {
-		Command string `json:"command" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request format, must be JSON with 'command' field"})
-		return
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "sh", "-c", request.Command)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			c.JSON(408, gin.H{
-				"error":   "Command execution timed out",
-				"stdout":  stdout.String(),
-				"stderr":  stderr.String(),
-				"partial": true,
-			})
-			return
-		}
-		c.JSON(500, gin.H{"error": err.Error(), "stderr": stderr.String()})
-		return
-	}
-
-	c.JSON(200, gin.H{
-		"stdout": stdout.String(),
-		"stderr": stderr.String(),
-	})
-}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"jira_helper/internal/handler"
+)
+
+// parseConversationRound returns the round in the payload, if the function was invoked by the conversation state machine
+func parseConversationRound(payload json.RawMessage) (handler.RoundPayload, bool) {
+	var round handler.RoundPayload
+	if err := json.Unmarshal(payload, &round); err != nil || round.Round == nil {
+		return round, false
+	}
+	return round, true
+}
+
+// handleConversationRound runs one round and returns the state for the next Choice state
+func handleConversationRound(ctx context.Context, payload handler.RoundPayload) (handler.RoundPayload, error) {
+	round, err := slackHandler.RunConversationRound(ctx, *payload.Round)
+	return handler.RoundPayload{Round: &round}, err
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"jira_helper/internal/config"
+	"jira_helper/internal/logger"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// serverShutdownGrace fits within the default 30 second stop timeout of ECS tasks
+const serverShutdownGrace = 25 * time.Second
+
+// IsServerMode reports whether the app runs as a persistent service, e.g. on ECS/Fargate
+func IsServerMode() bool {
+	return os.Getenv("RUN_MODE") == "server"
+}
+
+// runServer runs the app as a long-lived service. The MCP subprocess stays warm for the life of
+// the container, and Slack events arrive over Socket Mode or the public HTTPS routes.
+func runServer() {
+	logger.GetLogger().Info("Running as a persistent service")
+
+	initConfig()
+	cfg := config.Get()
+	if err := logger.Init(cfg.LogLevel); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	initTracing()
+
+	if err := initSlackHandler(); err != nil {
+		log.Fatalf("Failed to initialize slack handler: %v", err)
+	}
+	if err := initKeyRing(); err != nil {
+		log.Fatalf("Failed to initialize API keys: %v", err)
+	}
+	go slackHandler.WarmUp(context.Background())
+
+	ctx, stop := shutdownSignals()
+	defer stop()
+	ctx = watchSecrets(ctx)
+
+	startSocketMode(ctx)
+	serve(ctx, cfg.ListenAddr, serverShutdownGrace)
+}
+
+// startSocketMode receives Slack events, interactions and slash commands over Socket Mode in the
+// background when an app token is configured, until ctx is cancelled
+func startSocketMode(ctx context.Context) {
+	appToken := config.Get().SlackAppToken
+	if appToken == "" {
+		return
+	}
+	go func() {
+		if err := slackHandler.RunSocketMode(ctx, appToken, slashCommandRouter()); err != nil && !errors.Is(err, context.Canceled) {
+			logger.GetLogger().Error("slack socket mode stopped", zap.Error(err))
+		}
+	}()
+}
+
+// watchSecrets returns a context that is also cancelled once a secret the configuration refers to
+// is rotated, so the service drains and its orchestrator starts it again with the new value
+func watchSecrets(ctx context.Context) context.Context {
+	interval := config.SecretsCacheTTL()
+	if interval <= 0 {
+		return ctx
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			rotated, err := config.SecretsRotated(ctx)
+			if err != nil {
+				logger.GetLogger().Warn("failed to check secrets for rotation", zap.Error(err))
+				continue
+			}
+			if len(rotated) > 0 {
+				logger.GetLogger().Info("secrets were rotated, restarting", zap.Strings("settings", rotated))
+				cancel()
+				return
+			}
+		}
+	}()
+	return ctx
+}
+
+// serve runs the HTTP server until ctx is cancelled, then stops intake and drains conversations.
+// In-flight requests and conversations share one grace period, and serve returns once both are done.
+func serve(ctx context.Context, addr string, grace time.Duration) {
+	srv := &http.Server{Addr: addr, Handler: RouterEngine()}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		logger.GetLogger().Info("received shutdown signal, stopping intake")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+		defer cancel()
+		// Stop accepting connections and wait for in-flight requests, then for the conversations
+		// they started in the background
+		_ = srv.Shutdown(shutdownCtx)
+		drainUntil(shutdownCtx)
+	}()
+
+	logger.GetLogger().Info("listening", zap.String("addr", addr))
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal("Server is shutting down due to ", err)
+	}
+	<-stopped
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/tracing"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	// lambdaShutdownGrace fits within the time Lambda allows after SIGTERM.
+	// Lambda only sends SIGTERM when at least one extension is registered.
+	lambdaShutdownGrace = 400 * time.Millisecond
+	// localShutdownGrace lets conversations finish when the local server is stopped
+	localShutdownGrace = 30 * time.Second
+)
+
+// shutdownSignals returns a context that is cancelled on SIGTERM or SIGINT
+func shutdownSignals() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+}
+
+// drain stops intake on the handler, waits for active conversations within the grace period,
+// closes MCP subprocesses and flushes spans and logs
+func drain(grace time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	drainUntil(ctx)
+}
+
+// drainUntil drains like drain, until ctx is done
+func drainUntil(ctx context.Context) {
+	if slackHandler != nil {
+		slackHandler.Shutdown(ctx)
+	}
+	_ = tracing.Shutdown(ctx)
+	_ = logger.Sync()
+}
+
+// drainOnSignal drains and exits when the Lambda runtime is shut down
+func drainOnSignal(grace time.Duration) {
+	ctx, stop := shutdownSignals()
+	go func() {
+		defer stop()
+		<-ctx.Done()
+		logger.GetLogger().Info("received shutdown signal")
+		drain(grace)
+		os.Exit(0)
+	}()
+}
@@ -0,0 +1,24 @@
+// Command mcp-server is the built-in MCP server. It serves the Confluence tools over stdio,
+// acting with the token in CONFLUENCE_PERSONAL_TOKEN on the Confluence at CONFLUENCE_URL.
+// It is a separate binary for other MCP clients, the bot itself never starts it.
+// stdout carries the protocol, so errors are logged to stderr.
+package main
+
+import (
+	"log"
+	"os"
+
+	mcpserver "jira_helper/internal/service/mcp-server"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func main() {
+	client, err := mcpserver.NewClient(os.Getenv("CONFLUENCE_URL"), os.Getenv("CONFLUENCE_PERSONAL_TOKEN"))
+	if err != nil {
+		log.Fatalf("Failed to create Confluence client: %v", err)
+	}
+	if err := server.ServeStdio(mcpserver.NewServer(client)); err != nil {
+		log.Fatalf("MCP server stopped: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/sigproxy"
+	"log"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	ginadapter "github.com/awslabs/aws-lambda-go-api-proxy/gin"
+	"github.com/gin-gonic/gin"
+)
+
+// The signing proxy is deployed with a public Function URL in front of a bot whose Function URL
+// uses AWS_IAM auth. It only forwards requests carrying a valid Slack signature.
+func main() {
+	logLevel := os.Getenv("LOG_LEVEL")
+	if logLevel == "" {
+		logLevel = "INFO"
+	}
+	if err := logger.Init(logLevel); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		log.Fatalf("Failed to load AWS config: %v", err)
+	}
+
+	proxy, err := sigproxy.New(os.Getenv("SIGNING_PROXY_TARGET_URL"), os.Getenv("SLACK_SIGNING_SECRET"), awsCfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize signing proxy: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(logger.GinLogMiddleware())
+	r.NoRoute(proxy.Handle)
+
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" {
+		ginLambda := ginadapter.New(r)
+		lambda.Start(func(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+			return ginLambda.ProxyFunctionURLWithContext(ctx, req)
+		})
+		return
+	}
+
+	if err := r.Run(":3001"); err != nil {
+		log.Fatal("Server is shutting down due to ", err)
+	}
+}
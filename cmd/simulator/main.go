@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/simulator"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+func main() {
+	listen := flag.String("listen", ":3001", "address the fake Slack API and web chat listen on")
+	appURL := flag.String("app", "http://localhost:3000/", "Events API endpoint of the app")
+	channel := flag.String("channel", "D0SIMULATOR", "channel the console chats in, channels starting with D are DMs")
+	signingSecret := flag.String("signing-secret", os.Getenv("SLACK_SIGNING_SECRET"), "signs event deliveries, defaults to $SLACK_SIGNING_SECRET")
+	flag.Parse()
+
+	if err := logger.Init("info"); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+
+	sim := simulator.New(*appURL, *signingSecret)
+	go func() {
+		if err := http.ListenAndServe(*listen, sim.Handler()); err != nil {
+			log.Fatalf("simulator stopped: %v", err)
+		}
+	}()
+
+	fmt.Printf("Fake Slack API on http://localhost%s/api/ — start the app with SLACK_API_URL set to it.\n", *listen)
+	fmt.Printf("Web chat on http://localhost%s/\n", *listen)
+	fmt.Printf("Chatting in %s. /new starts a new thread, /exit quits.\n\n", *channel)
+
+	// Print the bot's messages in the console's channel as they are posted and updated
+	var mu sync.Mutex
+	printed := map[string]string{}
+	sim.OnMessage(func(msg simulator.Message) {
+		if msg.Channel != *channel {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		previous := printed[msg.TS]
+		printed[msg.TS] = msg.Text
+		if text, ok := strings.CutPrefix(msg.Text, previous); ok && previous != "" {
+			// Progress messages grow line by line, print only the new part
+			fmt.Println(strings.TrimSpace(text))
+			return
+		}
+		fmt.Printf("🤖 %s\n", msg.Text)
+	})
+
+	threadTS := ""
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !scanner.Scan() {
+			return
+		}
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+			continue
+		case "/exit":
+			return
+		case "/new":
+			threadTS = ""
+			fmt.Println("Started a new thread.")
+			continue
+		}
+
+		ts, err := sim.Send(context.Background(), *channel, threadTS, line)
+		if err != nil {
+			fmt.Printf("error: %v\n", err)
+		}
+		if threadTS == "" {
+			threadTS = ts
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Command worker is the Lambda function that consumes the event queue. The receiver function
+// behind the Function URL verifies Slack events, enqueues them and acknowledges them at once,
+// and this function runs their conversations, so Slack never waits on the AI/MCP loop.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"jira_helper/internal/app"
+	"jira_helper/internal/config"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/tracing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// shutdownGrace fits within the time Lambda allows after SIGTERM
+const shutdownGrace = 400 * time.Millisecond
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := logger.Init(cfg.LogLevel); err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Sync()
+	if err := tracing.Init(context.Background(), cfg.TraceExporter, "jira-helper-worker"); err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+
+	// Queued events are dispatched directly, long conversations still continue in Step Functions
+	slackHandler, err := app.NewSlackHandler(cfg, false)
+	if err != nil {
+		log.Fatalf("Failed to initialize slack handler: %v", err)
+	}
+	// Start the MCP subprocess in the background instead of on the first event
+	go slackHandler.WarmUp(context.Background())
+
+	// Let active conversations note the interruption in their thread when the runtime shuts down
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		defer stop()
+		<-ctx.Done()
+		logger.GetLogger().Info("received shutdown signal")
+		drainCtx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+		defer cancel()
+		slackHandler.Shutdown(drainCtx)
+		_ = tracing.Shutdown(drainCtx)
+		_ = logger.Sync()
+		os.Exit(0)
+	}()
+
+	lambda.Start(func(ctx context.Context, sqsEvent events.SQSEvent) (events.SQSEventResponse, error) {
+		// The process is frozen after the invocation, export its spans first
+		defer func() { _ = tracing.Flush(ctx) }()
+		return app.HandleSQSEvent(ctx, slackHandler, sqsEvent, cfg.EventQueueMaxReceives)
+	})
+}
@@ -0,0 +1,195 @@
+package anomaly
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"jira_helper/internal/storage"
+)
+
+// BurstDetector tracks the rate of write operations per user and per project.
+// When a subject exceeds the limit within the window, it is paused until an admin approves it again.
+// Pauses and approvals are kept in the store and checked on every write, so they hold on every
+// instance. The writes are counted in memory, so each warm Lambda instance counts its own.
+type BurstDetector struct {
+	limit  int
+	window time.Duration
+	pauses storage.WritePauseStore
+
+	mu     sync.Mutex
+	events map[string][]time.Time // subject -> timestamps of recent writes
+}
+
+// NewBurstDetector creates a new BurstDetector instance. A limit of 0 disables detection. Without
+// a store, pauses are kept in memory and only hold on the instance that detected the burst.
+func NewBurstDetector(limit int, window time.Duration, pauses storage.WritePauseStore) *BurstDetector {
+	if pauses == nil {
+		pauses = &memoryPauses{pauses: map[string]storage.WritePause{}}
+	}
+	return &BurstDetector{
+		limit:  limit,
+		window: window,
+		pauses: pauses,
+		events: map[string][]time.Time{},
+	}
+}
+
+// UserSubject returns the subject key for a Slack user
+func UserSubject(userID string) string {
+	return "user:" + userID
+}
+
+// ProjectSubject returns the subject key for a Jira project
+func ProjectSubject(projectKey string) string {
+	return "project:" + projectKey
+}
+
+// subjects returns the non-empty subject keys for a write
+func subjects(userID, projectKey string) []string {
+	var keys []string
+	if userID != "" {
+		keys = append(keys, UserSubject(userID))
+	}
+	if projectKey != "" {
+		keys = append(keys, ProjectSubject(projectKey))
+	}
+	return keys
+}
+
+// Check returns an error if writes by the user or to the project are paused, or if that cannot
+// be told because the store is unavailable
+func (d *BurstDetector) Check(ctx context.Context, userID, projectKey string) error {
+	if d == nil || d.limit <= 0 {
+		return nil
+	}
+	for _, subject := range subjects(userID, projectKey) {
+		pause, err := d.pauses.Load(ctx, subject)
+		if err != nil {
+			return fmt.Errorf("writes are refused because it cannot be checked whether they are paused: %v", err)
+		}
+		if !pause.PausedSince.IsZero() {
+			return fmt.Errorf("writes for %s are paused since %s after an unusual burst, an admin must approve them again", subject, pause.PausedSince.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// Observe records a write and returns the subjects that have just been paused because of it.
+// Writes before the subject was last approved do not count.
+func (d *BurstDetector) Observe(ctx context.Context, userID, projectKey string) ([]string, error) {
+	if d == nil || d.limit <= 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	var tripped []string
+	for _, subject := range subjects(userID, projectKey) {
+		pause, err := d.pauses.Load(ctx, subject)
+		if err != nil {
+			return tripped, err
+		}
+		if !pause.PausedSince.IsZero() || d.count(subject, now, pause.ApprovedAt) <= d.limit {
+			continue
+		}
+		pause.PausedSince = now
+		if err := d.pauses.Save(ctx, subject, pause); err != nil {
+			return tripped, err
+		}
+		tripped = append(tripped, subject)
+	}
+	return tripped, nil
+}
+
+// count records a write of the subject at now and returns its writes within the window since
+// the last approval
+func (d *BurstDetector) count(subject string, now, approvedAt time.Time) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cutoff := now.Add(-d.window)
+	if approvedAt.After(cutoff) {
+		cutoff = approvedAt
+	}
+	recent := d.events[subject][:0]
+	for _, t := range d.events[subject] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	d.events[subject] = recent
+	return len(recent)
+}
+
+// Approve lifts the pause on a subject and resets its counter. It reports whether the subject was paused.
+func (d *BurstDetector) Approve(ctx context.Context, subject string) (bool, error) {
+	if d == nil {
+		return false, nil
+	}
+	pause, err := d.pauses.Load(ctx, subject)
+	if err != nil || pause.PausedSince.IsZero() {
+		return false, err
+	}
+	if err := d.pauses.Save(ctx, subject, storage.WritePause{ApprovedAt: time.Now()}); err != nil {
+		return false, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.events, subject)
+	return true, nil
+}
+
+// Paused returns the currently paused subjects, sorted
+func (d *BurstDetector) Paused(ctx context.Context) ([]string, error) {
+	if d == nil {
+		return nil, nil
+	}
+	pauses, err := d.pauses.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var paused []string
+	for subject, pause := range pauses {
+		if !pause.PausedSince.IsZero() {
+			paused = append(paused, subject)
+		}
+	}
+	sort.Strings(paused)
+	return paused, nil
+}
+
+// memoryPauses keeps the pauses of a detector without a store
+type memoryPauses struct {
+	mu     sync.Mutex
+	pauses map[string]storage.WritePause
+}
+
+// Load returns the subject's state
+func (m *memoryPauses) Load(_ context.Context, subject string) (storage.WritePause, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pauses[subject], nil
+}
+
+// Save replaces the subject's state
+func (m *memoryPauses) Save(_ context.Context, subject string, pause storage.WritePause) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pauses[subject] = pause
+	return nil
+}
+
+// List returns the state of every subject
+func (m *memoryPauses) List(_ context.Context) (map[string]storage.WritePause, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pauses := make(map[string]storage.WritePause, len(m.pauses))
+	for subject, pause := range m.pauses {
+		pauses[subject] = pause
+	}
+	return pauses, nil
+}
@@ -0,0 +1,157 @@
+package anomaly
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"jira_helper/internal/storage"
+)
+
+// failingPauses is a pause store that is unavailable
+type failingPauses struct{}
+
+func (failingPauses) Load(context.Context, string) (storage.WritePause, error) {
+	return storage.WritePause{}, errors.New("store unavailable")
+}
+
+func (failingPauses) Save(context.Context, string, storage.WritePause) error {
+	return errors.New("store unavailable")
+}
+
+func (failingPauses) List(context.Context) (map[string]storage.WritePause, error) {
+	return nil, errors.New("store unavailable")
+}
+
+func TestObserveBurst(t *testing.T) {
+	type write struct {
+		userID, project string
+		wantTripped     []string
+	}
+	tests := []struct {
+		name   string
+		limit  int
+		writes []write
+	}{
+		{"within limit", 3, []write{{"U1", "A", nil}, {"U1", "A", nil}, {"U1", "A", nil}}},
+		{"user and project burst", 2, []write{
+			{"U1", "A", nil},
+			{"U1", "A", nil},
+			{"U1", "A", []string{"user:U1", "project:A"}},
+			{"U1", "A", nil}, // already paused
+		}},
+		{"project burst across users", 2, []write{
+			{"U1", "A", nil},
+			{"U2", "A", nil},
+			{"U3", "A", []string{"project:A"}},
+		}},
+		{"user burst across projects", 2, []write{
+			{"U1", "A", nil},
+			{"U1", "B", nil},
+			{"U1", "C", []string{"user:U1"}},
+		}},
+		{"disabled", 0, []write{{"U1", "A", nil}, {"U1", "A", nil}, {"U1", "A", nil}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewBurstDetector(tt.limit, time.Minute, nil)
+			for i, w := range tt.writes {
+				tripped, err := d.Observe(context.Background(), w.userID, w.project)
+				if err != nil {
+					t.Fatalf("write %d: Observe() error = %v", i, err)
+				}
+				if !reflect.DeepEqual(tripped, w.wantTripped) {
+					t.Errorf("write %d: Observe() = %v, want %v", i, tripped, w.wantTripped)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckAndApprove(t *testing.T) {
+	ctx := context.Background()
+	d := NewBurstDetector(1, time.Minute, nil)
+	d.Observe(ctx, "U1", "A")
+	if _, err := d.Observe(ctx, "U1", "A"); err != nil {
+		t.Fatalf("Observe() error = %v", err)
+	}
+
+	tests := []struct {
+		userID, project string
+		wantErr         bool
+	}{
+		{"U1", "", true},
+		{"", "A", true},
+		{"U2", "B", false},
+	}
+	for _, tt := range tests {
+		if err := d.Check(ctx, tt.userID, tt.project); (err != nil) != tt.wantErr {
+			t.Errorf("Check(%q, %q) error = %v, wantErr %v", tt.userID, tt.project, err, tt.wantErr)
+		}
+	}
+	if paused, _ := d.Paused(ctx); !reflect.DeepEqual(paused, []string{"project:A", "user:U1"}) {
+		t.Errorf("Paused() = %v", paused)
+	}
+
+	approved, err := d.Approve(ctx, UserSubject("U1"))
+	if err != nil || !approved {
+		t.Fatalf("Approve() = %v, %v", approved, err)
+	}
+	if err := d.Check(ctx, "U1", ""); err != nil {
+		t.Errorf("Check() after approval error = %v", err)
+	}
+	if approved, _ := d.Approve(ctx, UserSubject("U1")); approved {
+		t.Errorf("Approve() of a subject that is not paused = true")
+	}
+	// Writes before the approval no longer count
+	if tripped, _ := d.Observe(ctx, "U1", ""); tripped != nil {
+		t.Errorf("Observe() after approval = %v, want nil", tripped)
+	}
+}
+
+func TestPauseSharedAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryPauses{pauses: map[string]storage.WritePause{}}
+	first := NewBurstDetector(1, time.Minute, store)
+	second := NewBurstDetector(1, time.Minute, store)
+
+	first.Observe(ctx, "U1", "")
+	first.Observe(ctx, "U1", "")
+	if err := second.Check(ctx, "U1", ""); err == nil {
+		t.Errorf("Check() on another instance allowed a paused user")
+	}
+}
+
+func TestCheckFailsClosed(t *testing.T) {
+	d := NewBurstDetector(1, time.Minute, failingPauses{})
+	if err := d.Check(context.Background(), "U1", "A"); err == nil {
+		t.Errorf("Check() with an unavailable store allowed the write")
+	}
+	if _, err := d.Observe(context.Background(), "U1", "A"); err == nil {
+		t.Errorf("Observe() with an unavailable store did not fail")
+	}
+}
+
+func TestCountWindow(t *testing.T) {
+	d := NewBurstDetector(10, time.Minute, nil)
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		at         time.Time
+		approvedAt time.Time
+		want       int
+	}{
+		{"first", start, time.Time{}, 1},
+		{"within window", start.Add(30 * time.Second), time.Time{}, 2},
+		{"first expired", start.Add(70 * time.Second), time.Time{}, 2},
+		{"all expired", start.Add(5 * time.Minute), time.Time{}, 1},
+		{"after approval", start.Add(5*time.Minute + time.Second), start.Add(5 * time.Minute), 1},
+	}
+	for _, tt := range tests {
+		if got := d.count("user:U1", tt.at, tt.approvedAt); got != tt.want {
+			t.Errorf("%s: count() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
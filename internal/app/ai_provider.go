@@ -0,0 +1,52 @@
+package app
+
+import (
+	"fmt"
+
+	"jira_helper/internal/config"
+	"jira_helper/internal/handler"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/anthropic"
+	"jira_helper/internal/service/bedrock"
+	"jira_helper/internal/service/openai"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/zap"
+)
+
+// newAIProvider creates the chat model selected by AI_PROVIDER. It returns nil for Azure OpenAI,
+// which the handler creates from the Azure settings.
+func newAIProvider(cfg *config.Config, awsCfg aws.Config) (handler.AIProvider, error) {
+	logger.GetLogger().Info("using AI provider", zap.String("provider", cfg.AIProvider), zap.String("model", cfg.AIModel))
+	switch cfg.AIProvider {
+	case config.AIProviderOpenAI:
+		client, err := openai.NewOpenAIClient(cfg.AIBaseURL, cfg.AIAPIKey, cfg.AIModel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OpenAI client: %v", err)
+		}
+		return client, nil
+	case config.AIProviderAnthropic:
+		return anthropic.NewClient(cfg.AIBaseURL, cfg.AIAPIKey, cfg.AIModel), nil
+	case config.AIProviderBedrock:
+		return bedrock.NewClient(awsCfg, cfg.AIModel), nil
+	default:
+		return nil, nil
+	}
+}
+
+// newModelClient creates a client of another deployment (azure) or model (openai) than the chat
+// model's, such as EMBEDDINGS_MODEL or VISION_MODEL, with the credentials of its provider
+func newModelClient(cfg *config.Config, model string) (*openai.Client, error) {
+	if cfg.AIProvider == config.AIProviderOpenAI {
+		client, err := openai.NewOpenAIClient(cfg.AIBaseURL, cfg.AIAPIKey, model)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OpenAI client of %s: %v", model, err)
+		}
+		return client, nil
+	}
+	client, err := openai.NewClient(cfg.AzureOpenAIEndpoint, cfg.AzureOpenAIKey, model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure OpenAI client of %s: %v", model, err)
+	}
+	return client, nil
+}
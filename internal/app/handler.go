@@ -0,0 +1,378 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"jira_helper/internal/anomaly"
+	"jira_helper/internal/audit"
+	"jira_helper/internal/config"
+	"jira_helper/internal/embeddings"
+	"jira_helper/internal/github"
+	"jira_helper/internal/guardrail"
+	"jira_helper/internal/handler"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/metrics"
+	"jira_helper/internal/oncall"
+	"jira_helper/internal/policy"
+	"jira_helper/internal/prompts"
+	"jira_helper/internal/queue"
+	"jira_helper/internal/quota"
+	"jira_helper/internal/service/googlechat"
+	"jira_helper/internal/service/opsgenie"
+	"jira_helper/internal/service/pagerduty"
+	"jira_helper/internal/service/slacktoken"
+	"jira_helper/internal/service/stepfunctions"
+	"jira_helper/internal/storage"
+	"jira_helper/internal/workerpool"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"go.uber.org/zap"
+)
+
+// NewSlackHandler creates the handler with the clients and features enabled by the configuration.
+// A process that runs conversations in-process, like the persistent service, neither hands events to
+// the event queue nor conversations to Step Functions.
+func NewSlackHandler(cfg *config.Config, inProcess bool) (*handler.SlackHandler, error) {
+	start := time.Now()
+
+	// Initialize AWS config
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %v", err)
+	}
+
+	// Create S3 client
+	s3Client := s3.NewFromConfig(awsCfg)
+
+	// Create the token store of the configured backend
+	tokenStore, err := newTokenStore(cfg, awsCfg, s3Client)
+	if err != nil {
+		return nil, err
+	}
+
+	boundaries, err := policy.ParseBoundaries(cfg.ProjectSensitivity)
+	if err != nil {
+		return nil, err
+	}
+	toolPolicy, err := policy.ParseToolPolicy(cfg.ToolPolicy)
+	if err != nil {
+		return nil, err
+	}
+	accessPolicy, err := policy.ParseAccessPolicy(cfg.AccessPolicy)
+	if err != nil {
+		return nil, err
+	}
+	messagePolicy, err := policy.ParseMessagePolicy(cfg.MessagePolicy)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := policy.ParseLimitPolicy(cfg.ConversationLimits)
+	if err != nil {
+		return nil, err
+	}
+
+	// Templates edited in the bucket replace the configured ones without a deployment
+	var promptSource prompts.Source
+	if cfg.TokenBucketName != "" {
+		promptSource = storage.NewS3PromptStore(s3Client, cfg.TokenBucketName)
+	}
+	promptTemplates, err := prompts.NewTemplates(cfg.PromptTemplates, promptSource)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start or connect to the configured MCP server, by default mcp-atlassian for the configured Jira
+	launcher := handler.DefaultMcpLauncher()
+	if cfg.McpCommand != "" {
+		launcher.Command, launcher.Args = cfg.McpCommand, cfg.McpArgs
+	}
+	for key, value := range cfg.McpEnv {
+		launcher.Env[key] = value
+	}
+	launcher.Transport, launcher.ServerURL = cfg.McpTransport, cfg.McpServerURL
+	launcher.JiraURL = cfg.JiraURL
+	if cfg.JiraURL == "" && cfg.McpCommand == "" && cfg.McpTransport == handler.McpTransportStdio {
+		logger.GetLogger().Warn("JIRA_URL is not set, mcp-atlassian will not be able to reach Jira")
+	}
+
+	// Named Jira instances next to JIRA_URL, started with the same MCP server settings
+	jiraURLs := []string{cfg.JiraURL}
+	jiraInstances := make(map[string]handler.JiraInstance, len(cfg.JiraInstances))
+	for name, instance := range cfg.JiraInstances {
+		jiraInstances[name] = handler.JiraInstance{URL: instance.URL, Token: instance.Token, ServerURL: instance.McpServerURL}
+		jiraURLs = append(jiraURLs, instance.URL)
+	}
+
+	// Pause writes after a burst on every instance through the bucket, which is optional without the s3 token store
+	var writePauses storage.WritePauseStore
+	if cfg.TokenBucketName != "" {
+		writePauses = storage.NewS3WritePauseStore(s3Client, cfg.TokenBucketName)
+	}
+
+	opts := []handler.Option{
+		handler.WithMcpLauncher(launcher),
+		handler.WithMcpPool(cfg.McpPoolSize, cfg.McpIdleTimeout),
+		handler.WithAdminUserIDs(cfg.AdminUserIDs),
+		handler.WithBoundaries(boundaries),
+		handler.WithToolPolicy(toolPolicy),
+		handler.WithAccessPolicy(accessPolicy, nil),
+		handler.WithMessagePolicy(messagePolicy),
+		handler.WithLimits(limits),
+		handler.WithGuardrails(guardrail.New(jiraURLs, cfg.GuardrailInternalHosts,
+			[]string{cfg.TokenBucketName, cfg.TokenTableName, cfg.TokenSecretPrefix})),
+		handler.WithChannelProjects(cfg.ChannelProjects),
+		handler.WithPromptTemplates(promptTemplates, cfg.TeamName),
+		handler.WithResponseStyles(cfg.ResponseStyles, cfg.DefaultResponseStyle),
+		handler.WithBurstDetector(anomaly.NewBurstDetector(cfg.WriteBurstLimit, cfg.WriteBurstWindow, writePauses)),
+		handler.WithWorkerPool(workerpool.New(cfg.WorkerConcurrency)),
+		handler.WithJiraURL(cfg.JiraURL),
+		handler.WithJiraInstances(jiraInstances, cfg.ChannelJiraInstances),
+		handler.WithEmailUser(cfg.EmailUserID),
+		handler.WithGitHub(cfg.GitHubWebhookSecret, cfg.GitHubTransitionRules, cfg.GitHubUserID),
+		handler.WithSlackAPIURL(cfg.SlackAPIURL),
+		handler.WithStreaming(cfg.AIStreaming),
+		handler.WithDryRun(cfg.DryRun),
+		handler.WithContextWindow(cfg.AIContextTokens, cfg.AISummarizeHistory),
+		handler.WithOpsCommands(cfg.ShellCommands),
+		handler.WithAttachmentLimits(cfg.AttachmentMaxBytes, cfg.AttachmentTypes),
+	}
+	opts = append(opts, readinessChecks(cfg, s3Client)...)
+
+	// The audit trail, issue links, recent queries, feature flags, the access policy and failed events are kept in the bucket, which is optional without the s3 token store
+	if cfg.TokenBucketName != "" {
+		// Instances take turns appending to the audit chain through the head table
+		auditHeads := audit.NewDynamoDBHeadStore(dynamodb.NewFromConfig(awsCfg), cfg.AuditHeadTableName)
+		opts = append(opts,
+			handler.WithAuditTrail(audit.NewS3Trail(s3Client, cfg.TokenBucketName, cfg.AuditObjectLockDays, auditHeads, cfg.AuditCheckpointKey)),
+			handler.WithIssueLinks(storage.NewS3IssueLinkStore(s3Client, cfg.TokenBucketName)),
+			handler.WithActivityStore(storage.NewS3ActivityStore(s3Client, cfg.TokenBucketName)),
+			handler.WithFeatureFlags(storage.NewS3FlagStore(s3Client, cfg.TokenBucketName)),
+			handler.WithAccessPolicy(accessPolicy, storage.NewS3AccessPolicyStore(s3Client, cfg.TokenBucketName)),
+			handler.WithFailedEvents(queue.NewS3DeadLetters(s3Client, cfg.TokenBucketName)),
+			handler.WithIdentities(storage.NewS3IdentityStore(s3Client, cfg.TokenBucketName)),
+		)
+		if cfg.TokenKMSKeyID != "" {
+			envelope := storage.NewKMSEnvelope(kms.NewFromConfig(awsCfg), cfg.TokenKMSKeyID)
+			opts = append(opts, handler.WithTokenBackups(storage.NewS3TokenBackups(s3Client, cfg.TokenBucketName, envelope)))
+		}
+	} else {
+		logger.GetLogger().Warn("no TOKEN_BUCKET_NAME configured, audit trail, issue links, recent queries, feature flags, access policy changes, failed events and Jira account mapping are disabled")
+	}
+
+	// User preferences are kept in their own table, or in the bucket like the conversations
+	switch {
+	case cfg.PreferenceTableName != "":
+		opts = append(opts, handler.WithPreferences(storage.NewDynamoDBPreferenceStore(dynamodb.NewFromConfig(awsCfg), cfg.PreferenceTableName)))
+	case cfg.TokenBucketName != "":
+		opts = append(opts, handler.WithPreferences(storage.NewS3PreferenceStore(s3Client, cfg.TokenBucketName)))
+	default:
+		logger.GetLogger().Warn("no PREFERENCE_TABLE_NAME or TOKEN_BUCKET_NAME configured, user preferences are disabled")
+	}
+
+	// Acknowledge events immediately and let the worker run the conversation. A persistent
+	// service has no worker function consuming the queue and processes events in-process.
+	if cfg.EventQueueURL != "" && !inProcess {
+		sqsClient := sqs.NewFromConfig(awsCfg)
+		publisher := queue.NewSQSPublisher(sqsClient, cfg.EventQueueURL)
+		opts = append(opts, handler.WithEventQueue(publisher))
+		if cfg.EventDLQURL != "" {
+			opts = append(opts, handler.WithDeadLetterQueue(queue.NewDeadLetterQueue(sqsClient, cfg.EventDLQURL, publisher)))
+		}
+	}
+	// Skip events another instance already received, each instance also remembers its own
+	if cfg.EventDedupTableName != "" {
+		opts = append(opts, handler.WithEventLedger(storage.NewDynamoDBEventStore(dynamodb.NewFromConfig(awsCfg), cfg.EventDedupTableName)))
+	}
+
+	// Continue long conversations in Step Functions, one round per state. A persistent service
+	// is not bound by the Lambda time limit and keeps them in-process.
+	if cfg.StateMachineARN != "" && !inProcess {
+		opts = append(opts, handler.WithStepFunctions(
+			stepfunctions.NewClient(awsCfg),
+			cfg.StateMachineARN,
+			cfg.HandOffRounds,
+			storage.NewS3CheckpointStore(s3Client, cfg.TokenBucketName),
+		))
+	}
+
+	// Ask users to approve Jira writes, keeping the paused conversations with the checkpoints
+	if cfg.WriteApprovals {
+		opts = append(opts, handler.WithApprovals(storage.NewS3CheckpointStore(s3Client, cfg.TokenBucketName)))
+	}
+	// Preview changes to many issues at once, which needs the bucket to keep them until approved
+	if cfg.BulkThreshold > 0 && cfg.TokenBucketName != "" {
+		opts = append(opts, handler.WithBulkMode(cfg.BulkThreshold, storage.NewS3CheckpointStore(s3Client, cfg.TokenBucketName)))
+	}
+
+	// Offer find_similar_issues over the embeddings of the configured projects, kept in the bucket
+	if cfg.EmbeddingsModel != "" {
+		embedder, err := newModelClient(cfg, cfg.EmbeddingsModel)
+		if err != nil {
+			return nil, err
+		}
+		index := embeddings.NewIndex(storage.NewS3EmbeddingStore(s3Client, cfg.TokenBucketName), embedder, cfg.EmbeddingsModel, cfg.EmbeddingsDimensions)
+		opts = append(opts, handler.WithSimilarIssues(index, cfg.SimilarIssueProjects))
+	}
+
+	// Read the screenshots shared in direct messages with a vision-capable model
+	if cfg.VisionModel != "" {
+		vision, err := newModelClient(cfg, cfg.VisionModel)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, handler.WithVision(vision))
+	}
+
+	// Count each user's requests and AI tokens in DynamoDB, or in the bucket without a table
+	if cfg.RateLimitPerHour > 0 || cfg.DailyTokenBudget > 0 {
+		var counters storage.CounterStore = storage.NewS3CounterStore(s3Client, cfg.TokenBucketName)
+		if cfg.QuotaTableName != "" {
+			counters = storage.NewDynamoDBCounterStore(dynamodb.NewFromConfig(awsCfg), cfg.QuotaTableName)
+		}
+		opts = append(opts, handler.WithQuota(quota.NewLimiter(counters, cfg.RateLimitPerHour, cfg.DailyTokenBudget)))
+	}
+
+	// Keep the model messages of each thread in DynamoDB, or with the transcripts without a table
+	if cfg.ConversationMemory {
+		var conversations storage.ConversationStore = storage.NewS3ConversationStore(s3Client, cfg.TokenBucketName)
+		if cfg.ConversationTableName != "" {
+			conversations = storage.NewDynamoDBConversationStore(dynamodb.NewFromConfig(awsCfg), cfg.ConversationTableName)
+		}
+		opts = append(opts, handler.WithConversationStore(conversations))
+	}
+
+	// Reuse the results of read tools, shared between instances through DynamoDB when a table is set
+	if cfg.ToolCacheTTL > 0 {
+		var cacheStore storage.ToolCacheStore
+		if cfg.ToolCacheTableName != "" {
+			cacheStore = storage.NewDynamoDBToolCacheStore(dynamodb.NewFromConfig(awsCfg), cfg.ToolCacheTableName)
+		}
+		opts = append(opts, handler.WithToolCache(cfg.ToolCacheTTL, cacheStore))
+	}
+
+	// Bound slow tools and stop calling tools that keep failing
+	opts = append(opts, handler.WithToolTimeouts(cfg.ToolTimeout, cfg.ToolTimeouts))
+	if cfg.CircuitBreakerFailures > 0 {
+		opts = append(opts, handler.WithCircuitBreaker(cfg.CircuitBreakerFailures, cfg.CircuitBreakerCooldown))
+	}
+
+	// Record the usage of each request for cost attribution, totals are always kept for /metrics
+	registry := metrics.NewRegistry()
+	sinks := []metrics.Sink{registry}
+	if cfg.MetricsNamespace != "" {
+		sinks = append(sinks, metrics.NewEMFSink(cfg.MetricsNamespace))
+	}
+	if cfg.UsageLog {
+		sinks = append(sinks, metrics.NewS3Sink(s3Client, cfg.TokenBucketName))
+	}
+	opts = append(opts, handler.WithMetrics(metrics.NewRecorder(cfg.ChannelTeams, cfg.AITokenPrices, sinks...), registry))
+
+	// Ask for feedback on answers, kept with the query for prompt tuning
+	if cfg.AnswerFeedback {
+		opts = append(opts, handler.WithFeedback(storage.NewS3FeedbackStore(s3Client, cfg.TokenBucketName)))
+	}
+
+	// Notify subscribed channels about Jira webhook events, subscriptions are kept like the conversations
+	if cfg.JiraWebhookSecret != "" {
+		var subscriptions storage.SubscriptionStore = storage.NewS3SubscriptionStore(s3Client, cfg.TokenBucketName)
+		if cfg.SubscriptionTableName != "" {
+			subscriptions = storage.NewDynamoDBSubscriptionStore(dynamodb.NewFromConfig(awsCfg), cfg.SubscriptionTableName)
+		}
+		opts = append(opts, handler.WithSubscriptions(subscriptions))
+	}
+
+	// Serve Google Chat spaces with the same conversation engine
+	if cfg.GoogleChatCredentials != "" {
+		chatClient, err := googlechat.NewClient([]byte(cfg.GoogleChatCredentials))
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize google chat: %v", err)
+		}
+		opts = append(opts, handler.WithGoogleChat(chatClient))
+	}
+
+	if len(cfg.Digests) > 0 {
+		opts = append(opts, handler.WithDigests(cfg.Digests, cfg.DigestUserID))
+	}
+
+	// Search GitHub for the pull requests that mention issues
+	if cfg.GitHubToken != "" {
+		opts = append(opts, handler.WithGitHubSearch(github.NewClient(cfg.GitHubAPIURL, cfg.GitHubToken), cfg.GitHubSearchQualifiers))
+	}
+
+	// Bridge PagerDuty incidents to Jira tickets and Slack threads
+	if cfg.PagerDutyWebhookSecret != "" {
+		var pdClient *pagerduty.Client
+		if cfg.PagerDutyAPIToken != "" {
+			pdClient = pagerduty.NewClient(cfg.PagerDutyAPIToken, cfg.PagerDutyFromEmail)
+		}
+		opts = append(opts, handler.WithPagerDuty(
+			storage.NewS3IncidentStore(s3Client, cfg.TokenBucketName),
+			cfg.PagerDutyRoutes,
+			cfg.PagerDutyUserID,
+			pdClient,
+		))
+	}
+
+	// Look up and page the on-call of projects
+	if len(cfg.OnCallSchedules) > 0 {
+		var pdClient *pagerduty.Client
+		if cfg.PagerDutyAPIToken != "" {
+			pdClient = pagerduty.NewClient(cfg.PagerDutyAPIToken, cfg.PagerDutyFromEmail)
+		}
+		var ogClient *opsgenie.Client
+		if cfg.OpsgenieAPIKey != "" {
+			ogClient = opsgenie.NewClient(cfg.OpsgenieAPIURL, cfg.OpsgenieAPIKey)
+		}
+		opts = append(opts, handler.WithOnCall(oncall.NewDirectory(cfg.OnCallSchedules, pdClient, ogClient)))
+	}
+
+	// Rotate the Slack bot token when a refresh token is configured
+	if cfg.SlackRefreshToken != "" {
+		rotator, err := slacktoken.NewRotator(cfg.SlackClientID, cfg.SlackClientSecret, cfg.SlackRefreshToken, tokenStore)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize slack token rotation: %v", err)
+		}
+		opts = append(opts, handler.WithTokenRotator(rotator))
+	}
+
+	// Workspaces installed through OAuth keep their bot tokens encrypted like personal tokens
+	if cfg.SlackOAuthRedirectURL != "" {
+		opts = append(opts, handler.WithWorkspaces(
+			storage.NewTokenWorkspaceStore(tokenStore),
+			cfg.SlackClientID,
+			cfg.SlackClientSecret,
+			cfg.SlackOAuthRedirectURL,
+			cfg.SlackOAuthScopes,
+		))
+	}
+
+	provider, err := newAIProvider(cfg, awsCfg)
+	if err != nil {
+		return nil, err
+	}
+	if provider != nil {
+		opts = append(opts, handler.WithAIProvider(provider))
+	}
+
+	slackHandler, err := handler.NewSlackHandler(
+		cfg.SlackBotToken,
+		cfg.AzureOpenAIEndpoint,
+		cfg.AzureOpenAIKey,
+		cfg.AzureOpenAIDeployment,
+		cfg.DefaultJiraToken,
+		tokenStore,
+		opts...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.GetLogger().Info("slack handler initialized", zap.Duration("duration", time.Since(start)))
+	return slackHandler, nil
+}
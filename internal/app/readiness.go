@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"jira_helper/internal/config"
+	"jira_helper/internal/handler"
+	"jira_helper/internal/httpclient"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// defaultAIEndpoints are checked for the providers whose base URL is optional
+var defaultAIEndpoints = map[string]string{
+	config.AIProviderOpenAI:    "https://api.openai.com/v1",
+	config.AIProviderAnthropic: "https://api.anthropic.com",
+}
+
+// readinessChecks returns the dependency checks of /readyz for the configuration
+func readinessChecks(cfg *config.Config, s3Client *s3.Client) []handler.Option {
+	var opts []handler.Option
+	if cfg.TokenBucketName != "" {
+		opts = append(opts, handler.WithReadinessCheck("s3", func(ctx context.Context) error {
+			_, err := s3Client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(cfg.TokenBucketName)})
+			if err != nil {
+				return fmt.Errorf("cannot access bucket %s: %v", cfg.TokenBucketName, err)
+			}
+			return nil
+		}))
+	}
+
+	endpoint := cfg.AIBaseURL
+	switch {
+	case cfg.AIProvider == config.AIProviderAzure:
+		endpoint = cfg.AzureOpenAIEndpoint
+	case endpoint == "":
+		endpoint = defaultAIEndpoints[cfg.AIProvider]
+	}
+	if endpoint != "" {
+		opts = append(opts, handler.WithReadinessCheck("ai", func(ctx context.Context) error {
+			return reachable(ctx, endpoint)
+		}))
+	}
+	return opts
+}
+
+// reachable verifies that the endpoint answers HTTP requests. Any response counts, the check
+// runs without credentials and only catches wrong URLs and network problems.
+func reachable(ctx context.Context, endpoint string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint %s: %v", endpoint, err)
+	}
+	resp, err := httpclient.New(httpclient.AWSTimeout).Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot reach %s: %v", endpoint, err)
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"jira_helper/internal/config"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/storage"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"go.uber.org/zap"
+)
+
+// legacyEncryptionKey is the 32-byte AES-256 key tokens were encrypted with before KMS. It is only
+// used by MigrateLegacyTokens, tokens still encrypted with it cannot be read until they are migrated.
+var legacyEncryptionKey = []byte{
+	0x0f, 0x71, 0x11, 0xee, 0x50, 0x74, 0x08, 0x3f,
+	0x67, 0xe0, 0x0c, 0x23, 0xca, 0x6f, 0xe5, 0xde,
+	0x75, 0x23, 0x7a, 0x0e, 0x7b, 0x61, 0xf4, 0x89,
+	0xe3, 0x56, 0xed, 0x0f, 0x7a, 0x9d, 0xf4, 0x89,
+}
+
+// newTokenStore creates the store for personal tokens selected by TOKEN_STORE_BACKEND
+func newTokenStore(cfg *config.Config, awsCfg aws.Config, s3Client *s3.Client) (storage.TokenStore, error) {
+	logger.GetLogger().Info("using token store", zap.String("backend", cfg.TokenStoreBackend), zap.Bool("kms", cfg.TokenKMSKeyID != ""))
+
+	var envelope *storage.KMSEnvelope
+	if cfg.TokenKMSKeyID != "" {
+		envelope = storage.NewKMSEnvelope(kms.NewFromConfig(awsCfg), cfg.TokenKMSKeyID)
+	}
+
+	switch cfg.TokenStoreBackend {
+	case config.TokenStoreDynamoDB:
+		return storage.NewDynamoDBTokenStore(dynamodb.NewFromConfig(awsCfg), cfg.TokenTableName, envelope), nil
+	case config.TokenStoreSecretsManager:
+		return storage.NewSecretsManagerTokenStore(secretsmanager.NewFromConfig(awsCfg), cfg.TokenSecretPrefix), nil
+	default:
+		return storage.NewS3TokenStore(s3Client, cfg.TokenBucketName, envelope), nil
+	}
+}
+
+// MigrateLegacyTokens re-encrypts with the KMS key every token still encrypted with the legacy
+// static key, logging each migrated token
+func MigrateLegacyTokens(ctx context.Context, cfg *config.Config) error {
+	if cfg.TokenKMSKeyID == "" {
+		return fmt.Errorf("migrating tokens requires TOKEN_KMS_KEY_ID")
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %v", err)
+	}
+	store, err := newTokenStore(cfg, awsCfg, s3.NewFromConfig(awsCfg))
+	if err != nil {
+		return err
+	}
+	migrator, ok := store.(storage.LegacyTokenMigrator)
+	if !ok {
+		logger.GetLogger().Info("token store never used the legacy key, nothing to migrate", zap.String("backend", cfg.TokenStoreBackend))
+		return nil
+	}
+
+	migrated, err := migrator.MigrateLegacyTokens(ctx, legacyEncryptionKey)
+	for _, userID := range migrated {
+		logger.GetLogger().Info("re-encrypted legacy token with KMS", zap.String("user_id", userID))
+	}
+	logger.GetLogger().Info("legacy token migration finished", zap.Int("migrated", len(migrated)))
+	return err
+}
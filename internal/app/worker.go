@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+
+	"jira_helper/internal/handler"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/queue"
+
+	"github.com/aws/aws-lambda-go/events"
+	"go.uber.org/zap"
+)
+
+// ParseSQSEvent returns the SQS batch in the payload, if the function was invoked by an SQS trigger
+func ParseSQSEvent(payload json.RawMessage) (events.SQSEvent, bool) {
+	var sqsEvent events.SQSEvent
+	if err := json.Unmarshal(payload, &sqsEvent); err != nil || len(sqsEvent.Records) == 0 {
+		return sqsEvent, false
+	}
+	return sqsEvent, sqsEvent.Records[0].EventSource == "aws:sqs"
+}
+
+// HandleSQSEvent runs the queued Slack events and reports the ones that failed and may succeed when
+// retried, so only those are redelivered (requires ReportBatchItemFailures on the event source mapping)
+// Records are processed concurrently; the handler's worker pool bounds them and keeps one
+// conversation per Slack thread. maxReceives is the queue's maxReceiveCount.
+func HandleSQSEvent(ctx context.Context, slackHandler *handler.SlackHandler, sqsEvent events.SQSEvent, maxReceives int) (events.SQSEventResponse, error) {
+	var response events.SQSEventResponse
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, record := range sqsEvent.Records {
+		var event queue.Event
+		if err := json.Unmarshal([]byte(record.Body), &event); err != nil {
+			// A malformed message never succeeds, drop it instead of retrying
+			logger.GetLogger().Error("failed to decode queued event", zap.String("message_id", record.MessageId), zap.Error(err))
+			continue
+		}
+
+		wg.Add(1)
+		// After the queue's maxReceiveCount attempts, SQS moves the message to the dead-letter queue
+		attempt, _ := strconv.Atoi(record.Attributes["ApproximateReceiveCount"])
+		go func(messageID string, event queue.Event, attempt int) {
+			defer wg.Done()
+			err := slackHandler.ProcessQueuedEvent(ctx, event)
+			if err != nil && !handler.RetryableFailure(err) {
+				// Running it again would not help, acknowledge the message instead of redelivering it
+				logger.GetLogger().Info("acknowledging queued event that is not retried",
+					zap.String("message_id", messageID),
+					zap.String("event_id", event.EventID),
+					zap.Error(err))
+				return
+			}
+			if err != nil {
+				logger.GetLogger().Error("failed to process queued event",
+					zap.String("message_id", messageID),
+					zap.String("event_id", event.EventID),
+					zap.Int("attempt", attempt),
+					zap.Error(err))
+				slackHandler.NotifyFailedEvent(event, err, attempt, maxReceives)
+				mu.Lock()
+				response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+					ItemIdentifier: messageID,
+				})
+				mu.Unlock()
+			}
+		}(record.MessageId, event, attempt)
+	}
+	wg.Wait()
+	return response, nil
+}
@@ -0,0 +1,348 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// StatusSuccess marks an action that completed successfully
+	StatusSuccess = "success"
+	// StatusError marks an action that failed
+	StatusError = "error"
+
+	prefix = "audit/"
+	// headKey is where earlier versions kept the head, it only seeds an empty head store
+	headKey = prefix + "head.json"
+
+	// dayLayout partitions the entries by the day they were recorded
+	dayLayout = "2006/01/02"
+
+	// maxRecordAttempts bounds how often Record reads the head again after losing it to another writer
+	maxRecordAttempts = 10
+)
+
+// Entry is a single record in the audit trail. Each entry embeds the hash of the
+// previous one, so altering or removing a record breaks the chain.
+type Entry struct {
+	Sequence  uint64                 `json:"sequence"`
+	Timestamp time.Time              `json:"timestamp"`
+	UserID    string                 `json:"user_id"`
+	ChannelID string                 `json:"channel_id"`
+	Action    string                 `json:"action"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+	Status    string                 `json:"status"`
+	Error     string                 `json:"error,omitempty"`
+	PrevHash  string                 `json:"prev_hash"`
+	Hash      string                 `json:"hash"`
+}
+
+// computeHash returns the SHA-256 of the entry with its own hash left empty
+func (e Entry) computeHash() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Filter selects audit entries. Empty fields match every entry.
+type Filter struct {
+	UserID string
+	Action string    // Tool name or other action, e.g. jira_create_issue
+	From   time.Time // Earliest day, inclusive
+	To     time.Time // Latest day, inclusive
+	Limit  int       // Most entries returned, the latest ones, 0 for all
+}
+
+// matches reports whether the entry passes the user and action filters
+func (f Filter) matches(entry Entry) bool {
+	return (f.UserID == "" || entry.UserID == f.UserID) && (f.Action == "" || entry.Action == f.Action)
+}
+
+// Trail defines the interface for recording, querying and verifying audit entries
+type Trail interface {
+	Record(ctx context.Context, entry Entry) error
+	Query(ctx context.Context, filter Filter) ([]Entry, error)
+	Verify(ctx context.Context) (int, error)
+}
+
+// S3Trail implements Trail using AWS S3. Entries are written as individual objects,
+// optionally under S3 Object Lock so they cannot be overwritten or deleted.
+type S3Trail struct {
+	client     *s3.Client
+	bucketName string
+	lockDays   int // Object Lock retention in days, 0 disables it
+	heads      HeadStore
+
+	checkpointSecret []byte // Signs the checkpoint of purged entries, purging is refused without it
+}
+
+// NewS3Trail creates a new S3Trail instance. heads may only be nil for a trail that is purged,
+// recording and verifying need the head store.
+func NewS3Trail(client *s3.Client, bucketName string, lockDays int, heads HeadStore, checkpointSecret string) *S3Trail {
+	return &S3Trail{
+		client:           client,
+		bucketName:       bucketName,
+		lockDays:         lockDays,
+		heads:            heads,
+		checkpointSecret: []byte(checkpointSecret),
+	}
+}
+
+// Record appends the entry to the chain. The entry's sequence is claimed by advancing the head
+// before the entry is stored, so concurrent writers take turns instead of forking the chain.
+func (t *S3Trail) Record(ctx context.Context, entry Entry) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+
+	var last Head
+	var err error
+	claimed := false
+	for attempt := 0; attempt < maxRecordAttempts && !claimed; attempt++ {
+		last, err = t.readHead(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read audit head: %v", err)
+		}
+		entry.Sequence = last.Sequence + 1
+		entry.PrevHash = last.Hash
+		if entry.Hash, err = entry.computeHash(); err != nil {
+			return fmt.Errorf("failed to hash audit entry: %v", err)
+		}
+		switch err := t.heads.Advance(ctx, last, Head{Sequence: entry.Sequence, Hash: entry.Hash}); {
+		case err == nil:
+			claimed = true
+		case !errors.Is(err, ErrHeadMoved):
+			return err
+		}
+	}
+	if !claimed {
+		return fmt.Errorf("failed to append audit entry after %d attempts: %v", maxRecordAttempts, ErrHeadMoved)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %v", err)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(t.bucketName),
+		Key:    aws.String(entryKey(entry)),
+		Body:   bytes.NewReader(data),
+	}
+	if t.lockDays > 0 {
+		input.ObjectLockMode = types.ObjectLockModeCompliance
+		input.ObjectLockRetainUntilDate = aws.Time(entry.Timestamp.AddDate(0, 0, t.lockDays))
+	}
+	if _, err := t.client.PutObject(ctx, input); err != nil {
+		if releaseErr := t.releaseSequence(ctx, last, entry); releaseErr != nil {
+			return fmt.Errorf("failed to store audit entry %d in S3: %v, and to release its sequence: %v", entry.Sequence, err, releaseErr)
+		}
+		return fmt.Errorf("failed to store audit entry %d in S3: %v", entry.Sequence, err)
+	}
+	return nil
+}
+
+// Query returns the entries that match the filter in chain order. With both From and To set,
+// only the date partitions between them are read.
+func (t *S3Trail) Query(ctx context.Context, filter Filter) ([]Entry, error) {
+	keys, err := t.queryKeys(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	// Read from the latest entry so a limit keeps the most recent ones
+	for i := len(keys) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(entries) >= filter.Limit {
+			break
+		}
+		entry, err := t.readEntry(ctx, keys[i])
+		if err != nil {
+			return nil, err
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Sequence < entries[j].Sequence })
+	return entries, nil
+}
+
+// queryKeys returns the keys of the entries in the filter's days in chain order
+func (t *S3Trail) queryKeys(ctx context.Context, filter Filter) ([]string, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() {
+		var keys []string
+		for day := filter.From.UTC().Truncate(24 * time.Hour); !day.After(filter.To.UTC()); day = day.AddDate(0, 0, 1) {
+			dayKeys, err := t.listEntryKeys(ctx, prefix+day.Format(dayLayout)+"/")
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, dayKeys...)
+		}
+		return keys, nil
+	}
+
+	all, err := t.listEntryKeys(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, key := range all {
+		// Partitions sort like the days they hold
+		day := strings.TrimPrefix(key, prefix)
+		if len(day) < len(dayLayout) {
+			continue
+		}
+		day = day[:len(dayLayout)]
+		if (!filter.From.IsZero() && day < filter.From.UTC().Format(dayLayout)) || (!filter.To.IsZero() && day > filter.To.UTC().Format(dayLayout)) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Verify walks the whole chain and returns the number of valid entries.
+// It fails on the first entry whose hash or link to its predecessor does not match.
+// The chain starts at the first entry, or after the signed checkpoint once entries
+// older than the retention period were purged, so removing the oldest entries is detected.
+func (t *S3Trail) Verify(ctx context.Context) (int, error) {
+	checkpoint, err := t.verifiedCheckpoint(ctx)
+	if err != nil {
+		return 0, err
+	}
+	keys, err := t.listEntryKeys(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	entries := make([]Entry, 0, len(keys))
+	for _, key := range keys {
+		entry, err := t.readEntry(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		entries = append(entries, entry)
+	}
+	head, err := t.readHead(ctx)
+	if err != nil {
+		return 0, err
+	}
+	gaps, err := t.heads.Gaps(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return verifyChain(entries, checkpoint, gaps, head)
+}
+
+// verifyChain checks the entries in chain order from the checkpoint to the head and returns the
+// number of valid entries. Recorded gaps stand in for the entries that could not be stored.
+func verifyChain(entries []Entry, checkpoint *Checkpoint, gaps map[uint64]Gap, head Head) (int, error) {
+	var prevHash string
+	var prevSequence uint64
+	if checkpoint != nil {
+		prevHash, prevSequence = checkpoint.Hash, checkpoint.Sequence
+	}
+	// skipGaps moves past the recorded gaps that follow the last entry
+	skipGaps := func() {
+		for {
+			gap, ok := gaps[prevSequence+1]
+			if !ok || gap.PrevHash != prevHash {
+				return
+			}
+			prevHash, prevSequence = gap.Hash, gap.Sequence
+		}
+	}
+
+	verified := 0
+	for _, entry := range entries {
+		if checkpoint != nil && entry.Sequence <= checkpoint.Sequence {
+			// Purged, but could not be deleted yet
+			continue
+		}
+		skipGaps()
+		if entry.Sequence != prevSequence+1 {
+			return verified, fmt.Errorf("entry %d follows entry %d, expected %d", entry.Sequence, prevSequence, prevSequence+1)
+		}
+		if entry.PrevHash != prevHash {
+			return verified, fmt.Errorf("entry %d is not linked to its predecessor", entry.Sequence)
+		}
+		hash, err := entry.computeHash()
+		if err != nil {
+			return verified, err
+		}
+		if hash != entry.Hash {
+			return verified, fmt.Errorf("entry %d has been modified", entry.Sequence)
+		}
+		prevHash, prevSequence = entry.Hash, entry.Sequence
+		verified++
+	}
+
+	skipGaps()
+	if head.Hash != prevHash {
+		return verified, fmt.Errorf("audit head does not match the last entry, entries may have been removed")
+	}
+	return verified, nil
+}
+
+// listEntryKeys returns the keys of the audit entries under the prefix in chain order
+func (t *S3Trail) listEntryKeys(ctx context.Context, keyPrefix string) ([]string, error) {
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(t.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(t.bucketName),
+		Prefix: aws.String(keyPrefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list audit entries: %v", err)
+		}
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if key != headKey && key != checkpointObject && strings.HasSuffix(key, ".json") {
+				keys = append(keys, key)
+			}
+		}
+	}
+	// Keys are date partitioned with a zero-padded sequence, so sorting restores chain order
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// readEntry loads a single audit entry
+func (t *S3Trail) readEntry(ctx context.Context, key string) (Entry, error) {
+	var entry Entry
+	result, err := t.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(t.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return entry, fmt.Errorf("failed to get audit entry %s: %v", key, err)
+	}
+	defer result.Body.Close()
+
+	if err := json.NewDecoder(result.Body).Decode(&entry); err != nil {
+		return entry, fmt.Errorf("failed to decode audit entry %s: %v", key, err)
+	}
+	return entry, nil
+}
+
+// entryKey generates the date partitioned S3 key for an entry
+func entryKey(entry Entry) string {
+	return fmt.Sprintf("%s%s/%012d.json", prefix, entry.Timestamp.Format(dayLayout), entry.Sequence)
+}
@@ -0,0 +1,193 @@
+package audit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// chain returns n linked entries starting at sequence 1
+func chain(t *testing.T, n int) []Entry {
+	t.Helper()
+	entries := make([]Entry, 0, n)
+	prevHash := ""
+	for i := 1; i <= n; i++ {
+		entry := Entry{
+			Sequence:  uint64(i),
+			Timestamp: time.Date(2026, 1, 1, 0, 0, i, 0, time.UTC),
+			UserID:    "U1",
+			Action:    "jira_create_issue",
+			Args:      map[string]interface{}{"summary": fmt.Sprintf("issue %d", i)},
+			Status:    StatusSuccess,
+			PrevHash:  prevHash,
+		}
+		hash, err := entry.computeHash()
+		if err != nil {
+			t.Fatalf("computeHash() error = %v", err)
+		}
+		entry.Hash = hash
+		entries = append(entries, entry)
+		prevHash = hash
+	}
+	return entries
+}
+
+// headOf returns the head pointing at the last entry
+func headOf(entries []Entry) Head {
+	last := entries[len(entries)-1]
+	return Head{Sequence: last.Sequence, Hash: last.Hash}
+}
+
+func TestComputeHash(t *testing.T) {
+	entry := chain(t, 1)[0]
+	hash, err := entry.computeHash()
+	if err != nil {
+		t.Fatalf("computeHash() error = %v", err)
+	}
+	if hash != entry.Hash {
+		t.Errorf("computeHash() depends on the stored hash")
+	}
+	entry.Status = StatusError
+	if changed, _ := entry.computeHash(); changed == hash {
+		t.Errorf("computeHash() did not change with the status")
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	tests := []struct {
+		name       string
+		build      func(entries []Entry) ([]Entry, *Checkpoint, map[uint64]Gap, Head)
+		wantValid  int
+		wantErrMsg string
+	}{
+		{
+			name: "intact",
+			build: func(entries []Entry) ([]Entry, *Checkpoint, map[uint64]Gap, Head) {
+				return entries, nil, nil, headOf(entries)
+			},
+			wantValid: 5,
+		},
+		{
+			name: "empty",
+			build: func([]Entry) ([]Entry, *Checkpoint, map[uint64]Gap, Head) {
+				return nil, nil, nil, Head{}
+			},
+		},
+		{
+			name: "modified entry",
+			build: func(entries []Entry) ([]Entry, *Checkpoint, map[uint64]Gap, Head) {
+				entries[2].UserID = "U2"
+				return entries, nil, nil, headOf(entries)
+			},
+			wantValid:  2,
+			wantErrMsg: "entry 3 has been modified",
+		},
+		{
+			name: "removed entry",
+			build: func(entries []Entry) ([]Entry, *Checkpoint, map[uint64]Gap, Head) {
+				return append(entries[:2], entries[3:]...), nil, nil, headOf(entries)
+			},
+			wantValid:  2,
+			wantErrMsg: "entry 4 follows entry 2, expected 3",
+		},
+		{
+			name: "removed last entry",
+			build: func(entries []Entry) ([]Entry, *Checkpoint, map[uint64]Gap, Head) {
+				return entries[:4], nil, nil, headOf(entries)
+			},
+			wantValid:  4,
+			wantErrMsg: "audit head does not match the last entry, entries may have been removed",
+		},
+		{
+			name: "rehashed entry",
+			build: func(entries []Entry) ([]Entry, *Checkpoint, map[uint64]Gap, Head) {
+				// Recomputing the hash of an altered entry breaks the link of the next one
+				entries[1].UserID = "U2"
+				entries[1].Hash, _ = entries[1].computeHash()
+				return entries, nil, nil, headOf(entries)
+			},
+			wantValid:  2,
+			wantErrMsg: "entry 3 is not linked to its predecessor",
+		},
+		{
+			name: "after checkpoint",
+			build: func(entries []Entry) ([]Entry, *Checkpoint, map[uint64]Gap, Head) {
+				checkpoint := &Checkpoint{Sequence: 2, Hash: entries[1].Hash}
+				return entries, checkpoint, nil, headOf(entries)
+			},
+			wantValid: 3,
+		},
+		{
+			name: "purged entries",
+			build: func(entries []Entry) ([]Entry, *Checkpoint, map[uint64]Gap, Head) {
+				checkpoint := &Checkpoint{Sequence: 2, Hash: entries[1].Hash}
+				return entries[2:], checkpoint, nil, headOf(entries)
+			},
+			wantValid: 3,
+		},
+		{
+			name: "checkpoint not matching",
+			build: func(entries []Entry) ([]Entry, *Checkpoint, map[uint64]Gap, Head) {
+				checkpoint := &Checkpoint{Sequence: 2, Hash: "forged"}
+				return entries[2:], checkpoint, nil, headOf(entries)
+			},
+			wantErrMsg: "entry 3 is not linked to its predecessor",
+		},
+		{
+			name: "recorded gap",
+			build: func(entries []Entry) ([]Entry, *Checkpoint, map[uint64]Gap, Head) {
+				gaps := map[uint64]Gap{3: {Sequence: 3, PrevHash: entries[1].Hash, Hash: entries[2].Hash}}
+				return append(entries[:2], entries[3:]...), nil, gaps, headOf(entries)
+			},
+			wantValid: 4,
+		},
+		{
+			name: "recorded gap at the head",
+			build: func(entries []Entry) ([]Entry, *Checkpoint, map[uint64]Gap, Head) {
+				gaps := map[uint64]Gap{5: {Sequence: 5, PrevHash: entries[3].Hash, Hash: entries[4].Hash}}
+				return entries[:4], nil, gaps, headOf(entries)
+			},
+			wantValid: 4,
+		},
+		{
+			name: "gap not linked",
+			build: func(entries []Entry) ([]Entry, *Checkpoint, map[uint64]Gap, Head) {
+				gaps := map[uint64]Gap{3: {Sequence: 3, PrevHash: "other", Hash: entries[2].Hash}}
+				return append(entries[:2], entries[3:]...), nil, gaps, headOf(entries)
+			},
+			wantValid:  2,
+			wantErrMsg: "entry 4 follows entry 2, expected 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, checkpoint, gaps, head := tt.build(chain(t, 5))
+			valid, err := verifyChain(entries, checkpoint, gaps, head)
+			if valid != tt.wantValid {
+				t.Errorf("verifyChain() = %d valid entries, want %d", valid, tt.wantValid)
+			}
+			switch {
+			case tt.wantErrMsg == "" && err != nil:
+				t.Errorf("verifyChain() error = %v", err)
+			case tt.wantErrMsg != "" && (err == nil || err.Error() != tt.wantErrMsg):
+				t.Errorf("verifyChain() error = %v, want %q", err, tt.wantErrMsg)
+			}
+		})
+	}
+}
+
+func TestCheckpointSign(t *testing.T) {
+	checkpoint := Checkpoint{Sequence: 7, Hash: "abc", PurgedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	signature := checkpoint.sign([]byte("secret"))
+	if signature != checkpoint.sign([]byte("secret")) {
+		t.Fatalf("sign() is not deterministic")
+	}
+	if signature == checkpoint.sign([]byte("other")) {
+		t.Errorf("sign() does not depend on the secret")
+	}
+	moved := checkpoint
+	moved.Sequence = 8
+	if signature == moved.sign([]byte("secret")) {
+		t.Errorf("sign() does not depend on the sequence")
+	}
+}
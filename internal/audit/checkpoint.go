@@ -0,0 +1,159 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// checkpointObject records where the chain starts after older entries were purged
+const checkpointObject = prefix + "checkpoint.json"
+
+// maxDeleteBatch is the maximum number of keys S3 accepts in one DeleteObjects call
+const maxDeleteBatch = 1000
+
+// Checkpoint is the last entry removed by the retention purge. It is signed, so the oldest
+// remaining entry can only be accepted as the start of the chain if the purge put it there.
+type Checkpoint struct {
+	Sequence  uint64    `json:"sequence"`
+	Hash      string    `json:"hash"`
+	PurgedAt  time.Time `json:"purged_at"`
+	Signature string    `json:"signature"`
+}
+
+// sign returns the HMAC-SHA256 of the checkpoint's fields with the secret
+func (c Checkpoint) sign(secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d:%s:%s", c.Sequence, c.Hash, c.PurgedAt.UTC().Format(time.RFC3339Nano))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// PurgeBefore deletes the oldest entries recorded before the cutoff and returns how many were
+// deleted. Only a contiguous start of the chain is purged, and entries still under Object Lock are
+// kept. A signed checkpoint of the last purged entry is written first, which Verify anchors on.
+func (t *S3Trail) PurgeBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	if len(t.checkpointSecret) == 0 {
+		return 0, fmt.Errorf("audit entries can only be purged with a checkpoint secret")
+	}
+	if t.lockDays > 0 {
+		if locked := time.Now().AddDate(0, 0, -t.lockDays-1); locked.Before(cutoff) {
+			cutoff = locked
+		}
+	}
+
+	keys, err := t.listEntryKeys(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	cutoffDay := cutoff.UTC().Format(dayLayout)
+	expired := 0
+	for _, key := range keys {
+		day := strings.TrimPrefix(key, prefix)
+		if len(day) < len(dayLayout) || day[:len(dayLayout)] >= cutoffDay {
+			break
+		}
+		expired++
+	}
+	if expired == 0 {
+		return 0, nil
+	}
+
+	last, err := t.readEntry(ctx, keys[expired-1])
+	if err != nil {
+		return 0, err
+	}
+	previous, err := t.readCheckpoint(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if previous == nil || last.Sequence > previous.Sequence {
+		if err := t.writeCheckpoint(ctx, Checkpoint{Sequence: last.Sequence, Hash: last.Hash, PurgedAt: time.Now().UTC()}); err != nil {
+			return 0, err
+		}
+	}
+
+	deleted := 0
+	for start := 0; start < expired; start += maxDeleteBatch {
+		end := min(start+maxDeleteBatch, expired)
+		objects := make([]types.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			objects = append(objects, types.ObjectIdentifier{Key: aws.String(key)})
+		}
+		result, err := t.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(t.bucketName),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete audit entries: %v", err)
+		}
+		// Entries that could not be deleted stay behind the checkpoint, Verify skips them
+		deleted += end - start - len(result.Errors)
+	}
+	return deleted, nil
+}
+
+// readCheckpoint loads the checkpoint, nil if nothing was purged yet
+func (t *S3Trail) readCheckpoint(ctx context.Context) (*Checkpoint, error) {
+	result, err := t.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(t.bucketName),
+		Key:    aws.String(checkpointObject),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get audit checkpoint: %v", err)
+	}
+	defer result.Body.Close()
+
+	checkpoint := &Checkpoint{}
+	if err := json.NewDecoder(result.Body).Decode(checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to decode audit checkpoint: %v", err)
+	}
+	return checkpoint, nil
+}
+
+// writeCheckpoint signs and stores the checkpoint
+func (t *S3Trail) writeCheckpoint(ctx context.Context, checkpoint Checkpoint) error {
+	checkpoint.Signature = checkpoint.sign(t.checkpointSecret)
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	_, err = t.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(t.bucketName),
+		Key:    aws.String(checkpointObject),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store audit checkpoint in S3: %v", err)
+	}
+	return nil
+}
+
+// verifiedCheckpoint loads the checkpoint and checks its signature, nil if nothing was purged yet
+func (t *S3Trail) verifiedCheckpoint(ctx context.Context) (*Checkpoint, error) {
+	checkpoint, err := t.readCheckpoint(ctx)
+	if err != nil || checkpoint == nil {
+		return checkpoint, err
+	}
+	if len(t.checkpointSecret) == 0 {
+		return nil, fmt.Errorf("the audit checkpoint cannot be verified without the checkpoint secret")
+	}
+	if !hmac.Equal([]byte(checkpoint.Signature), []byte(checkpoint.sign(t.checkpointSecret))) {
+		return nil, fmt.Errorf("the audit checkpoint has an invalid signature")
+	}
+	return checkpoint, nil
+}
@@ -0,0 +1,225 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// headItemKey is the partition key of the head item in DynamoDB
+	headItemKey = "audit"
+
+	// gapItemPrefix starts the partition keys of the gap items in DynamoDB, followed by the sequence
+	gapItemPrefix = "gap#"
+)
+
+// ErrHeadMoved is returned by HeadStore.Advance when another writer advanced the head first
+var ErrHeadMoved = errors.New("audit head was advanced by another writer")
+
+// Head points at the latest entry of the chain
+type Head struct {
+	Sequence uint64 `json:"sequence"`
+	Hash     string `json:"hash"`
+}
+
+// Gap is a claimed sequence whose entry could not be stored. It links its predecessor to its
+// successor, so Verify can tell a failed write from a removed entry.
+type Gap struct {
+	Sequence uint64
+	PrevHash string
+	Hash     string
+}
+
+// HeadStore keeps the chain head. Writers claim the next sequence by advancing the head from the
+// one they read, so two writers cannot append the same sequence and fork the chain.
+type HeadStore interface {
+	// Read returns the head, an empty head for a new trail
+	Read(ctx context.Context) (Head, error)
+	// Advance replaces prev with next, returning ErrHeadMoved if the head is no longer prev
+	Advance(ctx context.Context, prev, next Head) error
+	// MarkGap records a claimed sequence whose entry could not be stored
+	MarkGap(ctx context.Context, gap Gap) error
+	// Gaps returns the recorded gaps by sequence
+	Gaps(ctx context.Context) (map[uint64]Gap, error)
+}
+
+// DynamoDBHeadStore implements HeadStore with a conditional write to a DynamoDB table whose
+// partition key is the string attribute head_key, so instances append to the chain in turn
+type DynamoDBHeadStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBHeadStore creates a new DynamoDBHeadStore instance
+func NewDynamoDBHeadStore(client *dynamodb.Client, tableName string) *DynamoDBHeadStore {
+	return &DynamoDBHeadStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+// Read retrieves the head
+func (s *DynamoDBHeadStore) Read(ctx context.Context) (Head, error) {
+	var h Head
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]dynamodbtypes.AttributeValue{
+			"head_key": &dynamodbtypes.AttributeValueMemberS{Value: headItemKey},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return h, fmt.Errorf("failed to get audit head from DynamoDB: %v", err)
+	}
+	if sequence, ok := result.Item["sequence"].(*dynamodbtypes.AttributeValueMemberN); ok {
+		if h.Sequence, err = strconv.ParseUint(sequence.Value, 10, 64); err != nil {
+			return h, fmt.Errorf("invalid audit head sequence %q: %v", sequence.Value, err)
+		}
+	}
+	if hash, ok := result.Item["hash"].(*dynamodbtypes.AttributeValueMemberS); ok {
+		h.Hash = hash.Value
+	}
+	return h, nil
+}
+
+// Advance writes next on condition that the stored head is still prev. The hash is compared too,
+// so a head that was rolled back and advanced again by another writer is not mistaken for prev.
+func (s *DynamoDBHeadStore) Advance(ctx context.Context, prev, next Head) error {
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item: map[string]dynamodbtypes.AttributeValue{
+			"head_key": &dynamodbtypes.AttributeValueMemberS{Value: headItemKey},
+			"sequence": &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatUint(next.Sequence, 10)},
+			"hash":     &dynamodbtypes.AttributeValueMemberS{Value: next.Hash},
+		},
+		ConditionExpression:      aws.String("#sequence = :prev AND #hash = :hash"),
+		ExpressionAttributeNames: map[string]string{"#sequence": "sequence", "#hash": "hash"},
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":prev": &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatUint(prev.Sequence, 10)},
+			":hash": &dynamodbtypes.AttributeValueMemberS{Value: prev.Hash},
+		},
+	}
+	if prev.Sequence == 0 {
+		// A new trail, or one whose only claimed sequence was rolled back
+		input.ConditionExpression = aws.String("attribute_not_exists(head_key) OR #sequence = :prev")
+		input.ExpressionAttributeNames = map[string]string{"#sequence": "sequence"}
+		delete(input.ExpressionAttributeValues, ":hash")
+	}
+	if _, err := s.client.PutItem(ctx, input); err != nil {
+		var conditionFailed *dynamodbtypes.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return ErrHeadMoved
+		}
+		return fmt.Errorf("failed to store audit head in DynamoDB: %v", err)
+	}
+	return nil
+}
+
+// MarkGap stores the gap as its own item
+func (s *DynamoDBHeadStore) MarkGap(ctx context.Context, gap Gap) error {
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item: map[string]dynamodbtypes.AttributeValue{
+			"head_key":  &dynamodbtypes.AttributeValueMemberS{Value: gapItemPrefix + strconv.FormatUint(gap.Sequence, 10)},
+			"sequence":  &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatUint(gap.Sequence, 10)},
+			"prev_hash": &dynamodbtypes.AttributeValueMemberS{Value: gap.PrevHash},
+			"hash":      &dynamodbtypes.AttributeValueMemberS{Value: gap.Hash},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store audit gap in DynamoDB: %v", err)
+	}
+	return nil
+}
+
+// Gaps scans the table for the gap items
+func (s *DynamoDBHeadStore) Gaps(ctx context.Context) (map[uint64]Gap, error) {
+	gaps := map[uint64]Gap{}
+	paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+		TableName:                 aws.String(s.tableName),
+		FilterExpression:          aws.String("begins_with(head_key, :gap)"),
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{":gap": &dynamodbtypes.AttributeValueMemberS{Value: gapItemPrefix}},
+		ConsistentRead:            aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit gaps in DynamoDB: %v", err)
+		}
+		for _, item := range page.Items {
+			var gap Gap
+			if sequence, ok := item["sequence"].(*dynamodbtypes.AttributeValueMemberN); ok {
+				gap.Sequence, _ = strconv.ParseUint(sequence.Value, 10, 64)
+			}
+			if hash, ok := item["prev_hash"].(*dynamodbtypes.AttributeValueMemberS); ok {
+				gap.PrevHash = hash.Value
+			}
+			if hash, ok := item["hash"].(*dynamodbtypes.AttributeValueMemberS); ok {
+				gap.Hash = hash.Value
+			}
+			gaps[gap.Sequence] = gap
+		}
+	}
+	return gaps, nil
+}
+
+// readLegacyHead returns the head earlier versions kept in the bucket, an empty head if there is none
+func (t *S3Trail) readLegacyHead(ctx context.Context) (Head, error) {
+	var h Head
+	result, err := t.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(t.bucketName),
+		Key:    aws.String(headKey),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return h, nil
+		}
+		return h, fmt.Errorf("failed to get audit head from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	if err := json.NewDecoder(result.Body).Decode(&h); err != nil {
+		return h, fmt.Errorf("failed to decode audit head: %v", err)
+	}
+	return h, nil
+}
+
+// readHead returns the head. An empty head store is first seeded with the head earlier versions
+// kept in the bucket, so upgrading continues the chain instead of starting a new one.
+func (t *S3Trail) readHead(ctx context.Context) (Head, error) {
+	if t.heads == nil {
+		return Head{}, fmt.Errorf("the audit trail has no head store")
+	}
+	h, err := t.heads.Read(ctx)
+	if err != nil || h.Sequence > 0 {
+		return h, err
+	}
+	legacy, err := t.readLegacyHead(ctx)
+	if err != nil || legacy.Sequence == 0 {
+		return h, err
+	}
+	if err := t.heads.Advance(ctx, Head{}, legacy); err != nil && !errors.Is(err, ErrHeadMoved) {
+		return h, err
+	}
+	return t.heads.Read(ctx)
+}
+
+// releaseSequence gives back a claimed sequence whose entry could not be stored. The head is
+// rolled back while no other writer advanced it, otherwise the gap is recorded for Verify.
+func (t *S3Trail) releaseSequence(ctx context.Context, prev Head, entry Entry) error {
+	err := t.heads.Advance(ctx, Head{Sequence: entry.Sequence, Hash: entry.Hash}, prev)
+	if err == nil || !errors.Is(err, ErrHeadMoved) {
+		return err
+	}
+	return t.heads.MarkGap(ctx, Gap{Sequence: entry.Sequence, PrevHash: entry.PrevHash, Hash: entry.Hash})
+}
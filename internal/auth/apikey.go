@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Scopes grant access to groups of programmatic endpoints
+const (
+	ScopeShell = "shell"
+	ScopeAdmin = "admin"
+	ScopeQuery = "query"
+)
+
+const (
+	// contextKeyAPIKey is the gin context key holding the authenticated key ID
+	contextKeyAPIKey = "api_key_id"
+
+	// contextKeyCaller is the gin context key holding the ARN of an authenticated IAM caller
+	contextKeyCaller = "iam_caller_arn"
+)
+
+// APIKey describes a key allowed to call programmatic endpoints.
+// Only the SHA-256 of the key is configured so the secret never sits in plain text.
+type APIKey struct {
+	ID        string    `json:"id"`
+	KeySHA256 string    `json:"key_sha256"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // Zero value means the key never expires
+}
+
+// expired reports whether the key is past its expiry
+func (k APIKey) expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && now.After(k.ExpiresAt)
+}
+
+// KeyRing holds the configured API keys. Several keys may be active at once,
+// so a key is rotated by adding its replacement before removing or expiring the old one.
+type KeyRing struct {
+	keys []APIKey
+}
+
+// ParseKeys parses the JSON list of API keys from configuration
+func ParseKeys(raw string) ([]APIKey, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var keys []APIKey
+	if err := json.Unmarshal([]byte(raw), &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse API keys: %v", err)
+	}
+	for _, key := range keys {
+		if key.ID == "" || len(key.KeySHA256) != sha256.Size*2 {
+			return nil, fmt.Errorf("API key %q must have an id and a hex encoded key_sha256", key.ID)
+		}
+	}
+	return keys, nil
+}
+
+// NewKeyRing creates a new KeyRing instance
+func NewKeyRing(keys []APIKey) *KeyRing {
+	return &KeyRing{keys: keys}
+}
+
+// Authenticate returns the key matching the raw secret, or an error if none is valid
+func (r *KeyRing) Authenticate(rawKey string) (*APIKey, error) {
+	if rawKey == "" {
+		return nil, fmt.Errorf("missing API key")
+	}
+	sum := sha256.Sum256([]byte(rawKey))
+	hash := hex.EncodeToString(sum[:])
+
+	now := time.Now()
+	for i := range r.keys {
+		key := &r.keys[i]
+		if subtle.ConstantTimeCompare([]byte(strings.ToLower(key.KeySHA256)), []byte(hash)) != 1 {
+			continue
+		}
+		if key.expired(now) {
+			return nil, fmt.Errorf("API key %s has expired", key.ID)
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("invalid API key")
+}
+
+// RequireScope is a middleware that only lets through requests carrying an API key with the given scope.
+// The key is read from "Authorization: Bearer <key>" or the X-API-Key header.
+func (r *KeyRing) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, err := r.Authenticate(requestKey(c))
+		if err != nil {
+			logger.GetLogger().Warn("API key authentication failed",
+				zap.String("path", c.FullPath()),
+				zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		if !slices.Contains(key.Scopes, scope) {
+			logger.GetLogger().Warn("API key missing scope",
+				zap.String("key_id", key.ID),
+				zap.String("scope", scope),
+				zap.String("path", c.FullPath()))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("scope %s required", scope)})
+			return
+		}
+
+		logger.GetLogger().Info("API key authenticated",
+			zap.String("key_id", key.ID),
+			zap.String("scope", scope),
+			zap.String("path", c.FullPath()))
+		c.Set(contextKeyAPIKey, key.ID)
+		c.Next()
+	}
+}
+
+// KeyID returns the ID of the API key that authenticated the request
+func KeyID(c *gin.Context) string {
+	return c.GetString(contextKeyAPIKey)
+}
+
+// Caller identifies who made the request, by API key ID or IAM caller ARN
+func Caller(c *gin.Context) string {
+	if arn := c.GetString(contextKeyCaller); arn != "" {
+		return arn
+	}
+	return KeyID(c)
+}
+
+// requestKey extracts the raw API key from the request headers
+func requestKey(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	}
+	return c.GetHeader("X-API-Key")
+}
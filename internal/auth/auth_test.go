@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/gin-gonic/gin"
+)
+
+// keyHash returns the configured form of a raw API key
+func keyHash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// serve runs the middleware in front of a handler that reports the caller
+func serve(t *testing.T, middleware gin.HandlerFunc, req *http.Request) (int, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/admin", middleware, func(c *gin.Context) {
+		c.String(http.StatusOK, Caller(c))
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code, w.Body.String()
+}
+
+// functionURLRequest returns a request as the Lambda adapter builds it for a Function URL call
+// signed by callerARN, or an unsigned one when callerARN is empty
+func functionURLRequest(t *testing.T, callerARN string) *http.Request {
+	t.Helper()
+	event := events.LambdaFunctionURLRequest{
+		RawPath: "/admin",
+		RequestContext: events.LambdaFunctionURLRequestContext{
+			DomainName: "example.lambda-url.us-east-1.on.aws",
+			HTTP:       events.LambdaFunctionURLRequestContextHTTPDescription{Method: http.MethodPost, Path: "/admin"},
+		},
+	}
+	if callerARN != "" {
+		event.RequestContext.Authorizer = &events.LambdaFunctionURLRequestContextAuthorizerDescription{
+			IAM: &events.LambdaFunctionURLRequestContextAuthorizerIAMDescription{UserARN: callerARN, AccountID: "123456789012"},
+		}
+	}
+	req, err := (&core.RequestAccessorFnURL{}).EventToRequestWithContext(context.Background(), event)
+	if err != nil {
+		t.Fatalf("EventToRequestWithContext() error = %v", err)
+	}
+	return req
+}
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{"empty", "", 0, false},
+		{"keys", `[{"id": "ci", "key_sha256": "` + keyHash("k") + `", "scopes": ["query"]}]`, 1, false},
+		{"missing id", `[{"key_sha256": "` + keyHash("k") + `"}]`, 0, true},
+		{"raw key instead of hash", `[{"id": "ci", "key_sha256": "k"}]`, 0, true},
+		{"invalid json", `{"id": "ci"}`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParseKeys(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(keys) != tt.want {
+				t.Errorf("ParseKeys() = %d keys, want %d", len(keys), tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	ring := NewKeyRing([]APIKey{
+		{ID: "admin", KeySHA256: keyHash("admin-key"), Scopes: []string{ScopeAdmin}},
+		{ID: "query", KeySHA256: keyHash("query-key"), Scopes: []string{ScopeQuery}},
+		{ID: "old", KeySHA256: keyHash("old-key"), Scopes: []string{ScopeAdmin}, ExpiresAt: time.Now().Add(-time.Hour)},
+	})
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		wantStatus int
+		wantCaller string
+	}{
+		{"bearer key with scope", "Authorization", "Bearer admin-key", http.StatusOK, "admin"},
+		{"x-api-key header", "X-API-Key", "admin-key", http.StatusOK, "admin"},
+		{"key without scope", "Authorization", "Bearer query-key", http.StatusForbidden, ""},
+		{"expired key", "Authorization", "Bearer old-key", http.StatusUnauthorized, ""},
+		{"unknown key", "Authorization", "Bearer other", http.StatusUnauthorized, ""},
+		{"hash instead of key", "Authorization", "Bearer " + keyHash("admin-key"), http.StatusUnauthorized, ""},
+		{"no key", "", "", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			status, caller := serve(t, ring.RequireScope(ScopeAdmin), req)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if status == http.StatusOK && caller != tt.wantCaller {
+				t.Errorf("caller = %q, want %q", caller, tt.wantCaller)
+			}
+		})
+	}
+}
+
+func TestRequireIAMCaller(t *testing.T) {
+	allowed := []string{"arn:aws:sts::123456789012:assumed-role/slack-proxy/*"}
+	tests := []struct {
+		name       string
+		callerARN  string
+		wantStatus int
+	}{
+		{"allowed role session", "arn:aws:sts::123456789012:assumed-role/slack-proxy/session-1", http.StatusOK},
+		{"other role", "arn:aws:sts::123456789012:assumed-role/other/session-1", http.StatusForbidden},
+		{"other account", "arn:aws:sts::999999999999:assumed-role/slack-proxy/session-1", http.StatusForbidden},
+		{"unsigned", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _ := serve(t, RequireIAMCaller(allowed), functionURLRequest(t, tt.callerARN))
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+
+	// A plain HTTP request carries no Function URL context, whatever its headers say
+	req := httptest.NewRequest(http.MethodPost, "/admin", nil)
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=forged")
+	if status, _ := serve(t, RequireIAMCaller(allowed), req); status != http.StatusForbidden {
+		t.Errorf("status without Function URL context = %d, want %d", status, http.StatusForbidden)
+	}
+}
+
+func TestRequireScopeOrIAMCaller(t *testing.T) {
+	ring := NewKeyRing([]APIKey{{ID: "admin", KeySHA256: keyHash("admin-key"), Scopes: []string{ScopeAdmin}}})
+	allowed := []string{"arn:aws:sts::123456789012:assumed-role/ops-admin/*"}
+	tests := []struct {
+		name       string
+		callerARN  string
+		key        string
+		wantStatus int
+		wantCaller string
+	}{
+		{"allowed iam caller", "arn:aws:sts::123456789012:assumed-role/ops-admin/alice", "", http.StatusOK, "arn:aws:sts::123456789012:assumed-role/ops-admin/alice"},
+		{"other iam caller with key", "arn:aws:sts::123456789012:assumed-role/slack-proxy/s", "admin-key", http.StatusOK, "admin"},
+		{"other iam caller without key", "arn:aws:sts::123456789012:assumed-role/slack-proxy/s", "", http.StatusUnauthorized, ""},
+		{"key only", "", "admin-key", http.StatusOK, "admin"},
+		{"neither", "", "", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := functionURLRequest(t, tt.callerARN)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			status, caller := serve(t, ring.RequireScopeOrIAMCaller(ScopeAdmin, allowed), req)
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if status == http.StatusOK && caller != tt.wantCaller {
+				t.Errorf("caller = %q, want %q", caller, tt.wantCaller)
+			}
+		})
+	}
+}
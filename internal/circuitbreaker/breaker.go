@@ -0,0 +1,106 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+)
+
+// circuit is the state of one key. A circuit without failures is not kept.
+type circuit struct {
+	failures int       // Failures in a row
+	openedAt time.Time // When the circuit opened, zero while closed
+	trialAt  time.Time // When the trial call of the half-open circuit started, zero if none did
+}
+
+// Breaker is a circuit breaker per key, e.g. per tool. A circuit opens after a number of failures
+// in a row and refuses calls for a cooldown. It then lets a single trial call through: success
+// closes the circuit, failure opens it for another cooldown. A trial call that reports neither,
+// e.g. because it was cancelled, is replaced by another one after a cooldown. State is kept in
+// memory, so each instance trips its circuits independently.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// New creates a breaker that opens a circuit after threshold failures in a row and keeps it open
+// for cooldown
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown, circuits: map[string]*circuit{}}
+}
+
+// Allow reports whether a call for the key may go ahead. Otherwise it reports how long until the
+// circuit lets a trial call through.
+func (b *Breaker) Allow(key string) (bool, time.Duration) {
+	if b == nil {
+		return true, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok || c.openedAt.IsZero() {
+		return true, 0
+	}
+	now := time.Now()
+	if wait := c.openedAt.Add(b.cooldown).Sub(now); wait > 0 {
+		return false, wait
+	}
+	if !c.trialAt.IsZero() {
+		if wait := c.trialAt.Add(b.cooldown).Sub(now); wait > 0 {
+			return false, wait
+		}
+	}
+	c.trialAt = now
+	return true, 0
+}
+
+// Success closes the key's circuit
+func (b *Breaker) Success(key string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.circuits, key)
+}
+
+// Failure counts a failed call for the key, reporting whether it opened the circuit
+func (b *Breaker) Failure(key string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	c.failures++
+	wasOpen := !c.openedAt.IsZero()
+	if wasOpen || c.failures >= b.threshold {
+		// A failed trial call opens the circuit again for a full cooldown
+		c.openedAt, c.trialAt = time.Now(), time.Time{}
+	}
+	return !wasOpen && !c.openedAt.IsZero()
+}
+
+// Open returns the keys whose circuit is open or half-open
+func (b *Breaker) Open() []string {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var keys []string
+	for key, c := range b.circuits {
+		if !c.openedAt.IsZero() {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -1,35 +1,276 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"jira_helper/internal/digest"
+	"jira_helper/internal/email"
+	"jira_helper/internal/metrics"
+	"jira_helper/internal/oncall"
+	"jira_helper/internal/prompts"
+	"jira_helper/internal/service/pagerduty"
 )
 
 // Environment represents the running environment of the application
 type Environment string
 
+// AI providers selectable with AI_PROVIDER
+const (
+	AIProviderAzure     = "azure"
+	AIProviderOpenAI    = "openai"
+	AIProviderAnthropic = "anthropic"
+	AIProviderBedrock   = "bedrock"
+)
+
+// Token store backends selectable with TOKEN_STORE_BACKEND
+const (
+	TokenStoreS3             = "s3"
+	TokenStoreDynamoDB       = "dynamodb"
+	TokenStoreSecretsManager = "secretsmanager"
+)
+
+// DefaultAttachmentTypes are the file extensions copied between Jira and Slack unless
+// ATTACHMENT_TYPES lists others
+var DefaultAttachmentTypes = []string{
+	"png", "jpg", "jpeg", "gif", "webp", "pdf", "txt", "log", "csv", "json", "xml", "yaml", "yml", "md",
+	"doc", "docx", "xls", "xlsx", "ppt", "pptx", "zip",
+}
+
+// DefaultSlackOAuthScopes are the bot scopes the app needs to answer mentions, thread replies,
+// direct messages and slash commands, and to collect feedback on answers
+var DefaultSlackOAuthScopes = []string{
+	"app_mentions:read",
+	"channels:history",
+	"groups:history",
+	"im:history",
+	"mpim:history",
+	"chat:write",
+	"commands",
+	"files:read",
+	"files:write",
+	"reactions:read",
+	"reactions:write",
+	"users:read",
+	"users:read.email",
+	"usergroups:read",
+}
+
+// JiraInstance is a named Jira connection next to JIRA_URL, e.g. a cloud sandbox. Personal tokens
+// belong to JIRA_URL, conversations on the instance act with its token.
+type JiraInstance struct {
+	URL          string `json:"url"`                      // Base URL, passed to the MCP server as JIRA_URL
+	Token        string `json:"token"`                    // Jira token conversations on the instance act with
+	McpServerURL string `json:"mcp_server_url,omitempty"` // Remote MCP server of the instance, for the sse and http transports
+}
+
 // Config holds all configuration for the application
 type Config struct {
 	// Environment is the current running environment (development, production, test)
 	Environment Environment
 
 	// Slack configuration
-	SlackBotToken string // Required: Slack bot user OAuth token
+	SlackBotToken         string   // Required unless token rotation or the OAuth install flow is enabled: Slack bot user OAuth token
+	SlackClientID         string   // Optional: Slack app client ID, required for token rotation and the OAuth install flow
+	SlackClientSecret     string   // Optional: Slack app client secret, required for token rotation and the OAuth install flow
+	SlackRefreshToken     string   // Optional: initial refresh token, enables token rotation
+	SlackSigningSecret    string   // Optional: verifies that requests were sent by Slack
+	SlackOAuthRedirectURL string   // Optional: public URL of /slack/oauth_redirect, enables installing the app into other workspaces
+	SlackOAuthScopes      []string // Bot scopes requested when a workspace installs the app
 
 	// Azure OpenAI configuration
-	AzureOpenAIKey        string // Required: Azure OpenAI API key
-	AzureOpenAIEndpoint   string // Required: Azure OpenAI endpoint URL
-	AzureOpenAIDeployment string // Required: Azure OpenAI model deployment name
+	AzureOpenAIKey        string // Required with the azure provider: Azure OpenAI API key
+	AzureOpenAIEndpoint   string // Required with the azure provider: Azure OpenAI endpoint URL
+	AzureOpenAIDeployment string // Required with the azure provider: Azure OpenAI model deployment name
+	AIStreaming           bool   // Optional: stream answers into the progress message while they are generated
+	AIContextTokens       int    // Optional: prompt budget in estimated tokens, defaults to 100000
+	AISummarizeHistory    bool   // Optional: summarize the messages that no longer fit in the prompt
+
+	// AI provider
+	AIProvider string // Optional: chat model provider, azure (default), openai, anthropic or bedrock
+	AIModel    string // Required with the other providers: model name, or model ID or ARN on Bedrock
+	AIAPIKey   string // Required with openai and anthropic: API key of the provider
+	AIBaseURL  string // Optional: endpoint of the openai or anthropic API, for proxies and compatible APIs
 
 	// S3 configuration for token storage
-	TokenBucketName string // Required: S3 bucket name for storing tokens
+	TokenBucketName string // Required with the s3 token store: S3 bucket name for storing tokens
+
+	// Token store
+	TokenStoreBackend string // Optional: where personal tokens are stored, s3 (default), dynamodb or secretsmanager
+	TokenTableName    string // Required with the dynamodb token store: table keyed by the string attribute user_id
+	TokenSecretPrefix string // Optional: name prefix of the per-user secrets, defaults to jira-helper/tokens/
+	TokenKMSKeyID     string // Required with the s3 and dynamodb token stores: KMS key ID or ARN for envelope encryption of tokens
 
 	// Jira configuration
 	DefaultJiraToken string //
 
 	// Log level
 	LogLevel string // Required: Log level
+
+	// Admin configuration
+	AdminUserIDs []string // Optional: Slack user IDs allowed to run admin commands
+
+	// Audit configuration
+	AuditObjectLockDays int    // Optional: S3 Object Lock retention for audit entries, 0 disables it
+	AuditHeadTableName  string // Required with TOKEN_BUCKET_NAME: DynamoDB table holding the chain head, so instances append to the chain in turn
+	AuditCheckpointKey  string // Optional: secret signing the checkpoint of purged entries, required to purge the audit category
+
+	// API key configuration for programmatic endpoints
+	APIKeys string // Optional: JSON list of API keys with their SHA-256 hashes and scopes
+
+	// Data boundary configuration
+	ProjectSensitivity     string   // Optional: JSON list of Jira project sensitivity levels and allowed channels
+	ToolPolicy             string   // Optional: JSON rules allowing or denying tools per channel and user
+	AccessPolicy           string   // Optional: JSON channels the bot answers in and Slack user group roles, until one is set through the admin API
+	MessagePolicy          string   // Optional: JSON visibility of progress and notices per channel
+	ConversationLimits     string   // Optional: JSON rounds, history page size, summarization threshold and message limit per channel
+	AnswerFeedback         bool     // Optional: ask for 👍/👎 on answers and keep the ratings in the bucket under feedback/
+	GuardrailInternalHosts []string // Optional: more host names, or suffixes starting with a dot, tool calls must not reference
+
+	// Channel scope configuration
+	ChannelProjects map[string][]string // Optional: Jira projects each Slack channel is restricted to
+
+	// Data retention configuration
+	RetentionDays map[string]int // Optional: days to keep each data category (audit, transcripts, feedback, usage)
+
+	// Function URL authentication
+	FunctionURLAuth      string   // Optional: NONE (default) or AWS_IAM
+	IAMAllowedCallerARNs []string // Optional: caller ARN patterns accepted when FunctionURLAuth is AWS_IAM
+	AdminIAMCallerARNs   []string // Optional: caller ARN patterns allowed on /admin and /shell without an API key
+
+	// Ops endpoint
+	ShellCommands map[string][]string // Optional: command name -> argument list the /shell endpoint may run, enables it
+
+	// Write burst detection
+	WriteBurstLimit  int           // Optional: writes allowed per user or project within the window, 0 disables detection
+	WriteBurstWindow time.Duration // Optional: sliding window for burst detection, defaults to 1m
+	WriteApprovals   bool          // Optional: ask users to approve each Jira write with Slack buttons
+	DryRun           bool          // Optional: show every Jira write to the user instead of sending it
+	BulkThreshold    int           // Issues one round may change before a preview must be approved, 0 disables bulk mode
+
+	// Per-user quotas
+	RateLimitPerHour int    // Optional: requests each user may make per hour, 0 disables the limit
+	DailyTokenBudget int    // Optional: AI tokens each user may use per day, 0 disables the budget
+	QuotaTableName   string // Optional: DynamoDB table counting usage, defaults to the token bucket
+
+	// Conversation memory
+	ConversationMemory    bool   // Optional: continue threads from the stored model messages instead of the thread history
+	ConversationTableName string // Optional: DynamoDB table storing them, defaults to the token bucket
+
+	// Usage metrics
+	MetricsNamespace string              // Optional: CloudWatch namespace of the EMF usage metrics, enables them
+	UsageLog         bool                // Optional: keep every request's usage in the bucket under usage/requests/
+	ChannelTeams     map[string]string   // Optional: channel ID -> team the channel's usage is attributed to
+	AITokenPrices    metrics.TokenPrices // Optional: USD per 1000 prompt and completion tokens, to estimate costs
+
+	// Tracing
+	TraceExporter string // Optional: "otlp" or "xray" to export OpenTelemetry spans, the endpoint is set with OTEL_EXPORTER_OTLP_ENDPOINT
+
+	// Tool result cache
+	ToolCacheTTL       time.Duration // Optional: how long results of read tools are reused, 0 disables the cache
+	ToolCacheTableName string        // Optional: DynamoDB table sharing cached results between instances
+
+	// Tool timeouts and circuit breaker
+	ToolTimeout            time.Duration            // Optional: how long a tool call may take, defaults to 2m, 0 disables the limit
+	ToolTimeouts           map[string]time.Duration // Optional: JSON object of tool name -> timeout such as "30s", overriding ToolTimeout
+	CircuitBreakerFailures int                      // Optional: failures in a row that make a tool unavailable, defaults to 5, 0 disables the breaker
+	CircuitBreakerCooldown time.Duration            // Optional: how long a tool stays unavailable before a trial call, defaults to 1m
+
+	// Asynchronous event processing
+	EventQueueURL         string // Optional: SQS queue URL, enables the async receiver/worker split
+	EventDLQURL           string // Optional: dead-letter queue of the event queue, enables replay
+	EventQueueMaxReceives int    // Optional: maxReceiveCount of the queue's redrive policy, defaults to 3
+	EventDedupTableName   string // Optional: DynamoDB table of processed event IDs, deduplicates across instances
+
+	// Concurrency
+	WorkerConcurrency int // Optional: conversations processed at once per instance, defaults to 4
+
+	// Step Functions execution mode
+	StateMachineARN string // Optional: state machine running one conversation round per state
+	HandOffRounds   int    // Optional: rounds run inline before handing off, defaults to 3
+
+	// Container deployment
+	ListenAddr    string // Optional: address the HTTP server listens on outside Lambda, defaults to :3000
+	SlackAppToken string // Optional: app-level token (xapp-...) that receives events over Socket Mode
+	SlackAPIURL   string // Optional: Slack Web API base URL, points the bot at the simulator's fake workspace
+
+	// Google Chat
+	GoogleChatCredentials   string // Optional: service account key JSON of the Chat app, enables Google Chat
+	GoogleChatProjectNumber string // Optional: Google Cloud project number, the audience of Chat requests
+	JiraURL                 string // Optional: Jira base URL, passed to the MCP server and used to link the issues an answer refers to
+
+	// MCP server
+	McpTransport string            // Optional: stdio (default), sse or http
+	McpServerURL string            // Optional: URL of a remote MCP server, required for the sse and http transports
+	McpCommand   string            // Optional: command starting the MCP server, defaults to uvx running mcp-atlassian
+	McpArgs      []string          // Optional: JSON array of arguments of McpCommand
+	McpEnv       map[string]string // Optional: JSON object of extra environment variables of the MCP server
+
+	McpPoolSize    int           // Optional: MCP clients of personal tokens kept started for reuse, 0 disables the pool
+	McpIdleTimeout time.Duration // Optional: how long a pooled MCP client may stay unused, defaults to 10m
+
+	// Jira instances besides JIRA_URL
+	JiraInstances        map[string]JiraInstance // Optional: JSON object of named Jira connections, e.g. a sandbox
+	ChannelJiraInstances map[string]string       // Optional: channel ID -> instance its conversations use unless the query picks one
+
+	// Attachments copied between Jira and Slack
+	AttachmentMaxBytes int64    // Largest file uploaded to Slack or attached to an issue, defaults to 10 MB
+	AttachmentTypes    []string // File extensions that may be copied
+
+	// Similar issue search
+	EmbeddingsModel      string   // Optional: embedding deployment (azure) or model (openai), enables find_similar_issues
+	EmbeddingsDimensions int      // Optional: length of the embedding vectors for models that can shorten them, 0 for the model's default
+	SimilarIssueProjects []string // Projects whose issues are indexed, required with EmbeddingsModel
+
+	// Screenshots
+	VisionModel string // Optional: vision-capable deployment (azure) or model (openai) reading screenshots shared in direct messages
+
+	// System prompt
+	PromptTemplates      map[string]string // Optional: Go templates of the system prompt, keyed by "default" or a channel ID
+	ResponseStyles       prompts.Styles    // Optional: response styles besides formal, terse and verbose, by name
+	DefaultResponseStyle string            // Optional: style of users who have not chosen one
+	PreferenceTableName  string            // Optional: DynamoDB table storing user preferences, defaults to the token bucket
+	TeamName             string            // Optional: team the bot works for, available to the templates as {{.TeamName}}
+
+	// Inbound email
+	EmailRoutes       email.Routes // Optional: recipient address -> Slack channel and Jira project
+	EmailBucketName   string       // Optional: bucket the SES receipt rule stores emails in, defaults to TokenBucketName
+	EmailObjectPrefix string       // Optional: object key prefix of the SES S3 action, defaults to inbound-email/
+	EmailUserID       string       // Optional: Slack user whose personal token creates and updates issues
+
+	// Scheduled digests
+	Digests      digest.Digests // Optional: reports the daily-digest and weekly-digest jobs post to channels
+	DigestUserID string         // Optional: Slack user whose personal token composes the digests
+
+	// GitHub
+	GitHubWebhookSecret    string            // Optional: secret of the GitHub webhook, enables the /github endpoint
+	GitHubTransitionRules  map[string]string // Optional: pull request outcome (opened, reopened, merged, closed) -> Jira status
+	GitHubUserID           string            // Optional: Slack user whose personal token transitions issues
+	GitHubToken            string            // Optional: token used to search pull requests that mention issues
+	GitHubAPIURL           string            // Optional: GitHub API, defaults to github.com
+	GitHubSearchQualifiers string            // Optional: narrows the pull request search, e.g. org:acme
+
+	// Jira webhooks
+	JiraWebhookSecret     string // Optional: secret of the Jira webhook, enables the /jira-webhook endpoint
+	SubscriptionTableName string // Optional: DynamoDB table storing the subscriptions, defaults to the token bucket
+
+	// PagerDuty
+	PagerDutyWebhookSecret string           // Optional: signing secret of the PagerDuty webhook subscription, enables /pagerduty
+	PagerDutyRoutes        pagerduty.Routes // Optional: service ID ("*" for any) -> Slack channel and Jira project
+	PagerDutyUserID        string           // Optional: Slack user whose personal token opens incident tickets
+	PagerDutyAPIToken      string           // Optional: REST API key used to link tickets back to incidents
+	PagerDutyFromEmail     string           // Optional: PagerDuty user email the incident notes are attributed to
+
+	// On-call escalation
+	OnCallSchedules oncall.Schedules // Optional: Jira project ("*" for any) -> PagerDuty or Opsgenie schedule
+	OpsgenieAPIKey  string           // Optional: API key used to read the Opsgenie schedules
+	OpsgenieAPIURL  string           // Optional: Opsgenie API, defaults to the US instance
 }
 
 var (
@@ -45,10 +286,24 @@ func Get() *Config {
 	return instance
 }
 
-// Load creates a new Config instance from environment variables
+// Load creates a new Config instance from environment variables, and the configuration file
+// CONFIG_FILE points to for the settings that are not set in the environment. Settings may refer
+// to SSM parameters or Secrets Manager secrets instead of holding their values.
 func Load() (*Config, error) {
 	cfg := &Config{}
 
+	fileValues = nil
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		values, err := loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		fileValues = values
+	}
+	if err := resolveSecrets(context.TODO()); err != nil {
+		return nil, err
+	}
+
 	// Load required values
 	requiredVars := map[string]*string{
 		"SLACK_BOT_TOKEN": &cfg.SlackBotToken,
@@ -64,20 +319,365 @@ func Load() (*Config, error) {
 		"LOG_LEVEL": &cfg.LogLevel,
 	}
 
+	// With token rotation the bot token is obtained from the refresh token instead
+	cfg.SlackRefreshToken = getEnv("SLACK_REFRESH_TOKEN")
+	if cfg.SlackRefreshToken != "" {
+		delete(requiredVars, "SLACK_BOT_TOKEN")
+		requiredVars["SLACK_CLIENT_ID"] = &cfg.SlackClientID
+		requiredVars["SLACK_CLIENT_SECRET"] = &cfg.SlackClientSecret
+	}
+
+	// With the OAuth install flow each workspace gets its own bot token, the configured one only
+	// serves the workspace the app was first created in
+	cfg.SlackOAuthRedirectURL = getEnv("SLACK_OAUTH_REDIRECT_URL")
+	if cfg.SlackOAuthRedirectURL != "" {
+		delete(requiredVars, "SLACK_BOT_TOKEN")
+		requiredVars["SLACK_CLIENT_ID"] = &cfg.SlackClientID
+		requiredVars["SLACK_CLIENT_SECRET"] = &cfg.SlackClientSecret
+	}
+
+	// Personal tokens can be kept outside S3, the bucket then only backs optional features
+	cfg.TokenStoreBackend = strings.ToLower(getEnv("TOKEN_STORE_BACKEND"))
+	switch cfg.TokenStoreBackend {
+	case "", TokenStoreS3:
+		cfg.TokenStoreBackend = TokenStoreS3
+	case TokenStoreDynamoDB:
+		delete(requiredVars, "TOKEN_BUCKET_NAME")
+		requiredVars["TOKEN_TABLE_NAME"] = &cfg.TokenTableName
+	case TokenStoreSecretsManager:
+		delete(requiredVars, "TOKEN_BUCKET_NAME")
+	default:
+		return nil, fmt.Errorf("unknown TOKEN_STORE_BACKEND %q, expected s3, dynamodb or secretsmanager", cfg.TokenStoreBackend)
+	}
+	cfg.TokenBucketName = getEnv("TOKEN_BUCKET_NAME")
+
+	// The Azure OpenAI settings are only needed by the azure provider
+	cfg.AIProvider = strings.ToLower(getEnv("AI_PROVIDER"))
+	switch cfg.AIProvider {
+	case "", AIProviderAzure:
+		cfg.AIProvider = AIProviderAzure
+	case AIProviderOpenAI, AIProviderAnthropic, AIProviderBedrock:
+		delete(requiredVars, "AZURE_OPENAI_KEY")
+		delete(requiredVars, "AZURE_OPENAI_ENDPOINT")
+		delete(requiredVars, "AZURE_OPENAI_DEPLOYMENT")
+		requiredVars["AI_MODEL"] = &cfg.AIModel
+		if cfg.AIProvider != AIProviderBedrock {
+			requiredVars["AI_API_KEY"] = &cfg.AIAPIKey
+		}
+	default:
+		return nil, fmt.Errorf("unknown AI_PROVIDER %q, expected azure, openai, anthropic or bedrock", cfg.AIProvider)
+	}
+	cfg.AIBaseURL = getEnv("AI_BASE_URL")
+	cfg.TokenSecretPrefix = getEnv("TOKEN_SECRET_PREFIX")
+	cfg.TokenKMSKeyID = getEnv("TOKEN_KMS_KEY_ID")
+	if cfg.TokenSecretPrefix == "" {
+		cfg.TokenSecretPrefix = "jira-helper/tokens/"
+	}
+
 	var missingVars []string
 	for env, ptr := range requiredVars {
-		*ptr = os.Getenv(env)
+		*ptr = getEnv(env)
 		if *ptr == "" {
 			missingVars = append(missingVars, env)
 		}
 	}
 
 	if len(missingVars) > 0 {
+		sort.Strings(missingVars)
 		return nil, fmt.Errorf("missing required environment variables: %s", strings.Join(missingVars, ", "))
 	}
 
+	// Load optional values
+	cfg.SlackSigningSecret = getEnv("SLACK_SIGNING_SECRET")
+	cfg.EventQueueURL = getEnv("EVENT_QUEUE_URL")
+	cfg.EventDLQURL = getEnv("EVENT_DLQ_URL")
+	cfg.EventDedupTableName = getEnv("EVENT_DEDUP_TABLE_NAME")
+	cfg.AuditHeadTableName = getEnv("AUDIT_HEAD_TABLE_NAME")
+	cfg.AuditCheckpointKey = getEnv("AUDIT_CHECKPOINT_KEY")
+	cfg.StateMachineARN = getEnv("STATE_MACHINE_ARN")
+	cfg.SlackAppToken = getEnv("SLACK_APP_TOKEN")
+	cfg.SlackAPIURL = getEnv("SLACK_API_URL")
+	cfg.SlackOAuthScopes = splitList(getEnv("SLACK_OAUTH_SCOPES"))
+	if len(cfg.SlackOAuthScopes) == 0 {
+		cfg.SlackOAuthScopes = DefaultSlackOAuthScopes
+	}
+	cfg.GoogleChatCredentials = getEnv("GOOGLE_CHAT_CREDENTIALS")
+	cfg.GoogleChatProjectNumber = getEnv("GOOGLE_CHAT_PROJECT_NUMBER")
+	cfg.JiraURL = getEnv("JIRA_URL")
+	cfg.McpCommand = getEnv("MCP_COMMAND")
+	if err := getEnvJSON("MCP_ARGS", &cfg.McpArgs); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("MCP_ENV", &cfg.McpEnv); err != nil {
+		return nil, err
+	}
+	cfg.McpTransport = strings.ToLower(getEnv("MCP_TRANSPORT"))
+	cfg.McpServerURL = getEnv("MCP_SERVER_URL")
+	if cfg.McpTransport == "" {
+		cfg.McpTransport = "stdio"
+	}
+	cfg.ListenAddr = getEnv("LISTEN_ADDR")
+	if cfg.ListenAddr == "" {
+		cfg.ListenAddr = ":3000"
+	}
+	cfg.AdminUserIDs = splitList(getEnv("ADMIN_USER_IDS"))
+	cfg.APIKeys = getEnvRaw("API_KEYS")
+	cfg.ProjectSensitivity = getEnvRaw("PROJECT_SENSITIVITY")
+	cfg.ToolPolicy = getEnvRaw("TOOL_POLICY")
+	cfg.AccessPolicy = getEnvRaw("ACCESS_POLICY")
+	cfg.MessagePolicy = getEnvRaw("MESSAGE_POLICY")
+	cfg.ConversationLimits = getEnvRaw("CONVERSATION_LIMITS")
+	cfg.GuardrailInternalHosts = splitList(getEnv("GUARDRAIL_INTERNAL_HOSTS"))
+	cfg.FunctionURLAuth = strings.ToUpper(getEnv("FUNCTION_URL_AUTH"))
+	cfg.IAMAllowedCallerARNs = splitList(getEnv("IAM_ALLOWED_CALLER_ARNS"))
+	cfg.AdminIAMCallerARNs = splitList(getEnv("ADMIN_IAM_CALLER_ARNS"))
+	if err := getEnvJSON("SHELL_COMMANDS", &cfg.ShellCommands); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("CHANNEL_PROJECTS", &cfg.ChannelProjects); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("JIRA_INSTANCES", &cfg.JiraInstances); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("CHANNEL_JIRA_INSTANCES", &cfg.ChannelJiraInstances); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("RETENTION_DAYS", &cfg.RetentionDays); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("EMAIL_ROUTES", &cfg.EmailRoutes); err != nil {
+		return nil, err
+	}
+	cfg.EmailUserID = getEnv("EMAIL_USER_ID")
+	cfg.EmailBucketName = getEnv("EMAIL_BUCKET_NAME")
+	if cfg.EmailBucketName == "" {
+		cfg.EmailBucketName = cfg.TokenBucketName
+	}
+	cfg.EmailObjectPrefix = getEnv("EMAIL_OBJECT_PREFIX")
+	if cfg.EmailObjectPrefix == "" {
+		cfg.EmailObjectPrefix = "inbound-email/"
+	}
+
+	if err := getEnvJSON("DIGESTS", &cfg.Digests); err != nil {
+		return nil, err
+	}
+	cfg.DigestUserID = getEnv("DIGEST_USER_ID")
+
+	cfg.GitHubWebhookSecret = getEnv("GITHUB_WEBHOOK_SECRET")
+	cfg.GitHubUserID = getEnv("GITHUB_USER_ID")
+	cfg.GitHubToken = getEnv("GITHUB_TOKEN")
+	cfg.GitHubAPIURL = getEnv("GITHUB_API_URL")
+	cfg.GitHubSearchQualifiers = getEnv("GITHUB_SEARCH_QUALIFIERS")
+	if err := getEnvJSON("GITHUB_TRANSITION_RULES", &cfg.GitHubTransitionRules); err != nil {
+		return nil, err
+	}
+
+	cfg.JiraWebhookSecret = getEnv("JIRA_WEBHOOK_SECRET")
+	cfg.SubscriptionTableName = getEnv("SUBSCRIPTION_TABLE_NAME")
+
+	cfg.PagerDutyWebhookSecret = getEnv("PAGERDUTY_WEBHOOK_SECRET")
+	cfg.PagerDutyUserID = getEnv("PAGERDUTY_USER_ID")
+	cfg.PagerDutyAPIToken = getEnv("PAGERDUTY_API_TOKEN")
+	cfg.PagerDutyFromEmail = getEnv("PAGERDUTY_FROM_EMAIL")
+	if err := getEnvJSON("PAGERDUTY_ROUTES", &cfg.PagerDutyRoutes); err != nil {
+		return nil, err
+	}
+
+	cfg.OpsgenieAPIKey = getEnv("OPSGENIE_API_KEY")
+	cfg.OpsgenieAPIURL = getEnv("OPSGENIE_API_URL")
+	if err := getEnvJSON("ONCALL_SCHEDULES", &cfg.OnCallSchedules); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if cfg.AuditObjectLockDays, err = getEnvInt("AUDIT_OBJECT_LOCK_DAYS", 0); err != nil {
+		return nil, err
+	}
+	if cfg.WriteBurstLimit, err = getEnvInt("WRITE_BURST_LIMIT", 0); err != nil {
+		return nil, err
+	}
+	if cfg.EventQueueMaxReceives, err = getEnvInt("EVENT_QUEUE_MAX_RECEIVES", 3); err != nil {
+		return nil, err
+	}
+	if cfg.HandOffRounds, err = getEnvInt("HAND_OFF_ROUNDS", 3); err != nil {
+		return nil, err
+	}
+	if cfg.WorkerConcurrency, err = getEnvInt("WORKER_CONCURRENCY", 4); err != nil {
+		return nil, err
+	}
+	if cfg.WriteBurstWindow, err = getEnvDuration("WRITE_BURST_WINDOW", time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.AIStreaming, err = getEnvBool("AI_STREAMING", false); err != nil {
+		return nil, err
+	}
+	if cfg.AIContextTokens, err = getEnvInt("AI_CONTEXT_TOKENS", 100000); err != nil {
+		return nil, err
+	}
+	if cfg.AISummarizeHistory, err = getEnvBool("AI_SUMMARIZE_HISTORY", false); err != nil {
+		return nil, err
+	}
+	if cfg.DryRun, err = getEnvBool("DRY_RUN", false); err != nil {
+		return nil, err
+	}
+	if cfg.WriteApprovals, err = getEnvBool("WRITE_APPROVALS", false); err != nil {
+		return nil, err
+	}
+	if cfg.BulkThreshold, err = getEnvInt("BULK_THRESHOLD", 5); err != nil {
+		return nil, err
+	}
+	if cfg.RateLimitPerHour, err = getEnvInt("RATE_LIMIT_PER_HOUR", 0); err != nil {
+		return nil, err
+	}
+	if cfg.DailyTokenBudget, err = getEnvInt("DAILY_TOKEN_BUDGET", 0); err != nil {
+		return nil, err
+	}
+	cfg.QuotaTableName = getEnv("QUOTA_TABLE_NAME")
+	if cfg.ConversationMemory, err = getEnvBool("CONVERSATION_MEMORY", false); err != nil {
+		return nil, err
+	}
+	cfg.ConversationTableName = getEnv("CONVERSATION_TABLE_NAME")
+	cfg.MetricsNamespace = getEnv("METRICS_NAMESPACE")
+	cfg.TraceExporter = getEnv("TRACE_EXPORTER")
+	if cfg.UsageLog, err = getEnvBool("USAGE_LOG", false); err != nil {
+		return nil, err
+	}
+	if cfg.AnswerFeedback, err = getEnvBool("ANSWER_FEEDBACK", false); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("CHANNEL_TEAMS", &cfg.ChannelTeams); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("AI_TOKEN_PRICES", &cfg.AITokenPrices); err != nil {
+		return nil, err
+	}
+	if cfg.ToolCacheTTL, err = getEnvDuration("TOOL_CACHE_TTL", 0); err != nil {
+		return nil, err
+	}
+	cfg.ToolCacheTableName = getEnv("TOOL_CACHE_TABLE_NAME")
+	if cfg.ToolTimeout, err = getEnvDuration("TOOL_TIMEOUT", 2*time.Minute); err != nil {
+		return nil, err
+	}
+	var toolTimeouts map[string]string
+	if err := getEnvJSON("TOOL_TIMEOUTS", &toolTimeouts); err != nil {
+		return nil, err
+	}
+	for tool, value := range toolTimeouts {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for TOOL_TIMEOUTS: %s: %v", tool, err)
+		}
+		if cfg.ToolTimeouts == nil {
+			cfg.ToolTimeouts = map[string]time.Duration{}
+		}
+		cfg.ToolTimeouts[tool] = timeout
+	}
+	if cfg.CircuitBreakerFailures, err = getEnvInt("CIRCUIT_BREAKER_FAILURES", 5); err != nil {
+		return nil, err
+	}
+	if cfg.CircuitBreakerCooldown, err = getEnvDuration("CIRCUIT_BREAKER_COOLDOWN", time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.McpPoolSize, err = getEnvInt("MCP_POOL_SIZE", 0); err != nil {
+		return nil, err
+	}
+	if cfg.McpIdleTimeout, err = getEnvDuration("MCP_IDLE_TIMEOUT", 10*time.Minute); err != nil {
+		return nil, err
+	}
+	attachmentMaxMB, err := getEnvInt("ATTACHMENT_MAX_MB", 10)
+	if err != nil {
+		return nil, err
+	}
+	cfg.AttachmentMaxBytes = int64(attachmentMaxMB) << 20
+	cfg.AttachmentTypes = splitList(strings.ToLower(getEnv("ATTACHMENT_TYPES")))
+	if len(cfg.AttachmentTypes) == 0 {
+		cfg.AttachmentTypes = DefaultAttachmentTypes
+	}
+	cfg.EmbeddingsModel = getEnv("EMBEDDINGS_MODEL")
+	if cfg.EmbeddingsDimensions, err = getEnvInt("EMBEDDINGS_DIMENSIONS", 0); err != nil {
+		return nil, err
+	}
+	cfg.SimilarIssueProjects = splitList(strings.ToUpper(getEnv("SIMILAR_ISSUE_PROJECTS")))
+	cfg.VisionModel = getEnv("VISION_MODEL")
+	if err := getEnvJSON("PROMPT_TEMPLATES", &cfg.PromptTemplates); err != nil {
+		return nil, err
+	}
+	if err := getEnvJSON("RESPONSE_STYLES", &cfg.ResponseStyles); err != nil {
+		return nil, err
+	}
+	cfg.DefaultResponseStyle = strings.ToLower(getEnv("DEFAULT_RESPONSE_STYLE"))
+	cfg.PreferenceTableName = getEnv("PREFERENCE_TABLE_NAME")
+	cfg.TeamName = getEnv("TEAM_NAME")
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Store the instance
 	instance = cfg
 
 	return cfg, nil
 }
+
+// splitList splits a comma separated environment value into trimmed, non-empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvInt reads an integer environment value, returning the fallback when unset
+func getEnvInt(env string, fallback int) (int, error) {
+	value := getEnv(env)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for %s: %v", env, err)
+	}
+	return n, nil
+}
+
+// getEnvBool reads a boolean environment value such as "true", returning the fallback when unset
+func getEnvBool(env string, fallback bool) (bool, error) {
+	value := getEnv(env)
+	if value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for %s: %v", env, err)
+	}
+	return b, nil
+}
+
+// getEnvJSON decodes a JSON environment value into target, leaving it untouched when unset
+func getEnvJSON(env string, target interface{}) error {
+	value := getEnvRaw(env)
+	if value == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(value), target); err != nil {
+		return fmt.Errorf("invalid value for %s: %v", env, err)
+	}
+	return nil
+}
+
+// getEnvDuration reads a duration environment value such as "90s", returning the fallback when unset
+func getEnvDuration(env string, fallback time.Duration) (time.Duration, error) {
+	value := getEnv(env)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for %s: %v", env, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretSuffixes mark the settings that must not be kept in the configuration file. Secrets stay
+// in the environment, e.g. injected from Secrets Manager, so the file can be committed.
+var secretSuffixes = []string{"_TOKEN", "_SECRET", "_KEY", "_KEYS", "_CREDENTIALS"}
+
+// fileValues are the settings of the configuration file, keyed by their environment variable
+var fileValues map[string]interface{}
+
+// loadFile reads the YAML or JSON configuration file at path. Its keys are the environment
+// variables of the settings, e.g. JIRA_URL, and environment variables that are not empty override
+// them. Lists and objects may be written as such instead of the JSON or comma separated strings
+// the environment variables take.
+func loadFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CONFIG_FILE: %v", err)
+	}
+
+	var raw map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("CONFIG_FILE must be a .yaml, .yml or .json file, got %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CONFIG_FILE: %v", err)
+	}
+
+	values := make(map[string]interface{}, len(raw))
+	var secrets []string
+	for key, value := range raw {
+		key = strings.ToUpper(key)
+		// References to secrets may be kept in the file, the secrets themselves may not
+		if ref, ok := value.(string); isSecret(key) && !(ok && IsSecretRef(ref)) {
+			secrets = append(secrets, key)
+			continue
+		}
+		values[key] = value
+	}
+	if len(secrets) > 0 {
+		sort.Strings(secrets)
+		return nil, fmt.Errorf("CONFIG_FILE must not contain secrets, set %s as environment variables or ssm:// or secretsmanager:// references", strings.Join(secrets, ", "))
+	}
+	return values, nil
+}
+
+// isSecret reports whether the setting holds a secret
+func isSecret(key string) bool {
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// getEnv returns the setting from the environment, or else from the configuration file. A list
+// in the file is joined with commas, and a reference to a secret is replaced by its value.
+func getEnv(env string) string {
+	if value, ok := secretValues[env]; ok {
+		return value
+	}
+	if value := os.Getenv(env); value != "" {
+		return value
+	}
+	switch value := fileValues[env].(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		data, _ := json.Marshal(value)
+		return string(data)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// getEnvRaw returns the setting from the environment, or else from the configuration file with
+// lists and objects encoded as JSON
+func getEnvRaw(env string) string {
+	if value, ok := secretValues[env]; ok {
+		return value
+	}
+	if value := os.Getenv(env); value != "" {
+		return value
+	}
+	switch value := fileValues[env].(type) {
+	case []interface{}, map[string]interface{}:
+		data, _ := json.Marshal(value)
+		return string(data)
+	default:
+		return getEnv(env)
+	}
+}
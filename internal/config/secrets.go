@@ -0,0 +1,272 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"jira_helper/internal/httpclient"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// Schemes of the references a setting may hold instead of its value, e.g.
+// SLACK_BOT_TOKEN=ssm:///jira-helper/slack-token or
+// SLACK_BOT_TOKEN=secretsmanager://jira-helper/app#SLACK_BOT_TOKEN, where the fragment picks a
+// key of a JSON secret
+const (
+	ssmScheme            = "ssm://"
+	secretsManagerScheme = "secretsmanager://"
+)
+
+// defaultSecretsCacheTTL is how long resolved secrets are reused before they are fetched again
+const defaultSecretsCacheTTL = 5 * time.Minute
+
+// secretRefs are the settings given as references, keyed by their environment variable
+var secretRefs map[string]string
+
+// secretValues are the resolved values of secretRefs
+var secretValues map[string]string
+
+// secrets resolves the references, created on the first one
+var secrets *SecretProvider
+
+// IsSecretRef reports whether the value refers to a secret instead of holding it
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, ssmScheme) || strings.HasPrefix(value, secretsManagerScheme)
+}
+
+// cachedSecret is a fetched secret and when it is fetched again
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// SecretProvider resolves references to SSM parameters and Secrets Manager secrets. Fetched
+// values are cached for the TTL, so rotated secrets are picked up once it passes.
+type SecretProvider struct {
+	awsCfg         aws.Config
+	ttl            time.Duration
+	secretsManager *secretsmanager.Client
+	signer         *v4.Signer
+	httpClient     *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedSecret // Keyed by the reference without its fragment
+}
+
+// NewSecretProvider creates a new SecretProvider instance
+func NewSecretProvider(awsCfg aws.Config, ttl time.Duration) *SecretProvider {
+	return &SecretProvider{
+		awsCfg:         awsCfg,
+		ttl:            ttl,
+		secretsManager: secretsmanager.NewFromConfig(awsCfg),
+		signer:         v4.NewSigner(),
+		httpClient:     httpclient.New(httpclient.AWSTimeout),
+		cache:          map[string]cachedSecret{},
+	}
+}
+
+// Resolve returns the value the reference points to
+func (p *SecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	source, key, _ := strings.Cut(ref, "#")
+	value, err := p.fetch(ctx, source)
+	if err != nil {
+		return "", err
+	}
+	if key == "" {
+		return value, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, drop #%s to use it whole", source, key)
+	}
+	field, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", source, key)
+	}
+	if s, ok := field.(string); ok {
+		return s, nil
+	}
+	data, _ := json.Marshal(field)
+	return string(data), nil
+}
+
+// fetch returns the cached value of the secret, or fetches it once the cache has expired
+func (p *SecretProvider) fetch(ctx context.Context, source string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cached, ok := p.cache[source]; ok && time.Now().Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	var value string
+	var err error
+	if name, ok := strings.CutPrefix(source, ssmScheme); ok {
+		value, err = p.getParameter(ctx, name)
+	} else {
+		value, err = p.getSecretValue(ctx, strings.TrimPrefix(source, secretsManagerScheme))
+	}
+	if err != nil {
+		return "", err
+	}
+	p.cache[source] = cachedSecret{value: value, expires: time.Now().Add(p.ttl)}
+	return value, nil
+}
+
+// getSecretValue reads the current version of a Secrets Manager secret
+func (p *SecretProvider) getSecretValue(ctx context.Context, id string) (string, error) {
+	result, err := p.secretsManager.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(id),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret %s: %v", id, err)
+	}
+	return aws.ToString(result.SecretString), nil
+}
+
+// getParameter reads an SSM parameter, decrypting SecureString parameters, through the AWS JSON API
+func (p *SecretProvider) getParameter(ctx context.Context, name string) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{"Name": name, "WithDecryption": true})
+	if err != nil {
+		return "", err
+	}
+	region := p.awsCfg.Region
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://ssm.%s.amazonaws.com/", region), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonSSM.GetParameter")
+
+	creds, err := p.awsCfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve AWS credentials: %v", err)
+	}
+	sum := sha256.Sum256(body)
+	if err := p.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "ssm", region, time.Now()); err != nil {
+		return "", fmt.Errorf("failed to sign request: %v", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get parameter %s: %v", name, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &apiErr)
+		return "", fmt.Errorf("failed to get parameter %s: ssm returned %d: %s %s", name, resp.StatusCode, apiErr.Type, apiErr.Message)
+	}
+
+	var result struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("failed to decode ssm response: %v", err)
+	}
+	return result.Parameter.Value, nil
+}
+
+// resolveSecrets resolves the settings given as references in the environment or the
+// configuration file, and reports every reference that cannot be resolved at once
+func resolveSecrets(ctx context.Context) error {
+	refs := map[string]string{}
+	for _, pair := range os.Environ() {
+		if env, value, ok := strings.Cut(pair, "="); ok && IsSecretRef(value) {
+			refs[env] = value
+		}
+	}
+	for env, value := range fileValues {
+		if s, ok := value.(string); ok && IsSecretRef(s) && os.Getenv(env) == "" {
+			refs[env] = s
+		}
+	}
+	secretRefs, secretValues = refs, map[string]string{}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	if secrets == nil {
+		ttl := defaultSecretsCacheTTL
+		if raw := getEnv("SECRETS_CACHE_TTL"); raw != "" {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil || parsed < 0 {
+				return fmt.Errorf("invalid SECRETS_CACHE_TTL %q, expected a duration such as 5m", raw)
+			}
+			ttl = parsed
+		}
+		awsCfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load AWS config to resolve secrets: %v", err)
+		}
+		secrets = NewSecretProvider(awsCfg, ttl)
+	}
+
+	var problems []string
+	for env, ref := range refs {
+		value, err := secrets.Resolve(ctx, ref)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", env, err))
+			continue
+		}
+		secretValues[env] = value
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("failed to resolve secrets:\n- %s", strings.Join(problems, "\n- "))
+	}
+	return nil
+}
+
+// SecretsRotated resolves the referenced settings again and reports the ones whose value changed
+// since the configuration was loaded. Values are fetched again once the cache TTL has passed.
+func SecretsRotated(ctx context.Context) ([]string, error) {
+	if secrets == nil {
+		return nil, nil
+	}
+	var rotated []string
+	for env, ref := range secretRefs {
+		value, err := secrets.Resolve(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %v", env, err)
+		}
+		if value != secretValues[env] {
+			rotated = append(rotated, env)
+		}
+	}
+	sort.Strings(rotated)
+	return rotated, nil
+}
+
+// SecretsCacheTTL returns how long resolved secrets are cached, 0 when no setting is a reference
+func SecretsCacheTTL() time.Duration {
+	if secrets == nil || len(secretRefs) == 0 {
+		return 0
+	}
+	return secrets.ttl
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"jira_helper/internal/github"
+	"jira_helper/internal/jql"
+	"jira_helper/internal/oncall"
+	"jira_helper/internal/prompts"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Validate checks the settings and how they depend on each other, and reports every problem at
+// once instead of stopping at the first
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	var level zapcore.Level
+	check(level.UnmarshalText([]byte(c.LogLevel)) == nil, "LOG_LEVEL %q is not a log level, use debug, info, warn or error", c.LogLevel)
+
+	// URLs
+	for env, value := range map[string]string{
+		"JIRA_URL":                 c.JiraURL,
+		"AZURE_OPENAI_ENDPOINT":    c.AzureOpenAIEndpoint,
+		"AI_BASE_URL":              c.AIBaseURL,
+		"MCP_SERVER_URL":           c.McpServerURL,
+		"SLACK_API_URL":            c.SlackAPIURL,
+		"SLACK_OAUTH_REDIRECT_URL": c.SlackOAuthRedirectURL,
+		"EVENT_QUEUE_URL":          c.EventQueueURL,
+		"EVENT_DLQ_URL":            c.EventDLQURL,
+	} {
+		if value != "" {
+			check(isHTTPURL(value), "%s must be an http or https URL, got %q", env, value)
+		}
+	}
+
+	// Ranges
+	check(c.WorkerConcurrency > 0, "WORKER_CONCURRENCY must be at least 1")
+	check(c.HandOffRounds > 0, "HAND_OFF_ROUNDS must be at least 1")
+	check(c.EventQueueMaxReceives > 0, "EVENT_QUEUE_MAX_RECEIVES must be at least 1")
+	check(c.AIContextTokens > 0, "AI_CONTEXT_TOKENS must be positive")
+	check(c.AttachmentMaxBytes > 0, "ATTACHMENT_MAX_MB must be positive")
+	check(c.WriteBurstWindow > 0, "WRITE_BURST_WINDOW must be positive")
+	for env, value := range map[string]int{
+		"AUDIT_OBJECT_LOCK_DAYS":   c.AuditObjectLockDays,
+		"WRITE_BURST_LIMIT":        c.WriteBurstLimit,
+		"BULK_THRESHOLD":           c.BulkThreshold,
+		"RATE_LIMIT_PER_HOUR":      c.RateLimitPerHour,
+		"DAILY_TOKEN_BUDGET":       c.DailyTokenBudget,
+		"MCP_POOL_SIZE":            c.McpPoolSize,
+		"EMBEDDINGS_DIMENSIONS":    c.EmbeddingsDimensions,
+		"CIRCUIT_BREAKER_FAILURES": c.CircuitBreakerFailures,
+	} {
+		check(value >= 0, "%s must not be negative", env)
+	}
+	check(c.ToolCacheTTL >= 0, "TOOL_CACHE_TTL must not be negative")
+	check(c.McpIdleTimeout >= 0, "MCP_IDLE_TIMEOUT must not be negative")
+	check(c.ToolTimeout >= 0, "TOOL_TIMEOUT must not be negative")
+	for tool, timeout := range c.ToolTimeouts {
+		check(timeout >= 0, "TOOL_TIMEOUTS of %s must not be negative", tool)
+	}
+	check(c.CircuitBreakerFailures == 0 || c.CircuitBreakerCooldown > 0,
+		"CIRCUIT_BREAKER_COOLDOWN must be positive when CIRCUIT_BREAKER_FAILURES is set")
+
+	// Choices
+	check(c.FunctionURLAuth == "" || c.FunctionURLAuth == "NONE" || c.FunctionURLAuth == "AWS_IAM",
+		"FUNCTION_URL_AUTH %q is unknown, expected NONE or AWS_IAM", c.FunctionURLAuth)
+	check(c.TraceExporter == "" || c.TraceExporter == "otlp" || c.TraceExporter == "xray",
+		"TRACE_EXPORTER %q is unknown, expected otlp or xray", c.TraceExporter)
+	switch c.McpTransport {
+	case "stdio":
+	case "sse", "http":
+		check(c.McpServerURL != "", "MCP_SERVER_URL is required when MCP_TRANSPORT is %s", c.McpTransport)
+	default:
+		problems = append(problems, fmt.Sprintf("MCP_TRANSPORT %q is unknown, expected stdio, sse or http", c.McpTransport))
+	}
+
+	// Settings that need others
+	check(len(c.McpArgs) == 0 || c.McpCommand != "", "MCP_COMMAND is required when MCP_ARGS is set")
+	check(c.GoogleChatCredentials == "" || c.GoogleChatProjectNumber != "", "GOOGLE_CHAT_PROJECT_NUMBER is required when GOOGLE_CHAT_CREDENTIALS is set")
+	for name, instance := range c.JiraInstances {
+		check(jiraInstanceName.MatchString(name), "JIRA_INSTANCES name %q must be lowercase letters, digits, - or _", name)
+		check(name != "primary", "JIRA_INSTANCES name primary is reserved for JIRA_URL")
+		check(isHTTPURL(instance.URL), "JIRA_INSTANCES entry %q needs an http or https url, got %q", name, instance.URL)
+		check(instance.Token != "", "JIRA_INSTANCES entry %q needs a token", name)
+		check(instance.McpServerURL != "" || c.McpTransport == "stdio", "JIRA_INSTANCES entry %q needs an mcp_server_url when MCP_TRANSPORT is %s", name, c.McpTransport)
+		check(instance.McpServerURL == "" || isHTTPURL(instance.McpServerURL), "JIRA_INSTANCES entry %q has an mcp_server_url that is not an http or https URL", name)
+	}
+	for channel, name := range c.ChannelJiraInstances {
+		_, ok := c.JiraInstances[name]
+		check(ok, "CHANNEL_JIRA_INSTANCES maps channel %s to %q, which is not in JIRA_INSTANCES", channel, name)
+	}
+	for channel, projects := range c.ChannelProjects {
+		for _, project := range projects {
+			err := jql.ValidateFragment(project)
+			check(err == nil, "CHANNEL_PROJECTS has an invalid project %q for channel %s: %v", project, channel, err)
+		}
+	}
+	for name, argv := range c.ShellCommands {
+		check(len(argv) > 0 && argv[0] != "", "SHELL_COMMANDS entry %q needs a program to run", name)
+	}
+	check(c.TokenStoreBackend == TokenStoreSecretsManager || c.TokenKMSKeyID != "", "TOKEN_KMS_KEY_ID is required when TOKEN_STORE_BACKEND is %s", c.TokenStoreBackend)
+	check(c.FunctionURLAuth != "AWS_IAM" || len(c.IAMAllowedCallerARNs) > 0, "IAM_ALLOWED_CALLER_ARNS is required when FUNCTION_URL_AUTH is AWS_IAM")
+	if err := c.Digests.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("invalid DIGESTS: %v", err))
+	}
+	for outcome := range c.GitHubTransitionRules {
+		check(github.IsOutcome(outcome), "GITHUB_TRANSITION_RULES has unknown pull request outcome %q", outcome)
+	}
+	check(len(c.GitHubTransitionRules) == 0 || c.JiraURL != "", "JIRA_URL is required when GITHUB_TRANSITION_RULES is set")
+	check(c.GitHubToken == "" || c.JiraURL != "", "JIRA_URL is required when GITHUB_TOKEN is set")
+	// Tool calls on restricted projects look up each issue's project in Jira
+	check(c.ProjectSensitivity == "" || c.JiraURL != "", "JIRA_URL is required when PROJECT_SENSITIVITY is set")
+	check(c.JiraWebhookSecret == "" || c.SubscriptionTableName != "" || c.TokenBucketName != "",
+		"SUBSCRIPTION_TABLE_NAME or TOKEN_BUCKET_NAME is required when JIRA_WEBHOOK_SECRET is set")
+	check(c.TokenBucketName == "" || c.AuditHeadTableName != "", "AUDIT_HEAD_TABLE_NAME is required when TOKEN_BUCKET_NAME is set, the audit trail keeps its chain head there")
+	_, purgesAudit := c.RetentionDays["audit"]
+	check(!purgesAudit || c.AuditCheckpointKey != "", "AUDIT_CHECKPOINT_KEY is required when RETENTION_DAYS purges audit")
+	check(c.PagerDutyAPIToken == "" || c.PagerDutyFromEmail != "", "PAGERDUTY_FROM_EMAIL is required when PAGERDUTY_API_TOKEN is set")
+	check(c.PagerDutyWebhookSecret == "" || len(c.PagerDutyRoutes) > 0, "PAGERDUTY_ROUTES is required when PAGERDUTY_WEBHOOK_SECRET is set")
+	if err := c.ResponseStyles.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("invalid RESPONSE_STYLES: %v", err))
+	}
+	if c.DefaultResponseStyle != "" {
+		styles := prompts.WithBuiltInStyles(c.ResponseStyles)
+		_, ok := styles[c.DefaultResponseStyle]
+		check(ok, "DEFAULT_RESPONSE_STYLE %q is not a style, use one of %s", c.DefaultResponseStyle, strings.Join(styles.Names(), ", "))
+	}
+	if err := c.OnCallSchedules.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("invalid ONCALL_SCHEDULES: %v", err))
+	}
+	check(!c.OnCallSchedules.Uses(oncall.ProviderPagerDuty) || c.PagerDutyAPIToken != "", "PAGERDUTY_API_TOKEN is required when ONCALL_SCHEDULES uses pagerduty")
+	check(!c.OnCallSchedules.Uses(oncall.ProviderOpsgenie) || c.OpsgenieAPIKey != "", "OPSGENIE_API_KEY is required when ONCALL_SCHEDULES uses opsgenie")
+	check(len(c.OnCallSchedules) == 0 || c.JiraURL != "", "JIRA_URL is required when ONCALL_SCHEDULES is set")
+	check((c.RateLimitPerHour == 0 && c.DailyTokenBudget == 0) || c.QuotaTableName != "" || c.TokenBucketName != "",
+		"QUOTA_TABLE_NAME or TOKEN_BUCKET_NAME is required when RATE_LIMIT_PER_HOUR or DAILY_TOKEN_BUDGET is set")
+	check(!c.ConversationMemory || c.ConversationTableName != "" || c.TokenBucketName != "",
+		"CONVERSATION_TABLE_NAME or TOKEN_BUCKET_NAME is required when CONVERSATION_MEMORY is set")
+	if c.EmbeddingsModel != "" {
+		check(c.AIProvider == AIProviderAzure || c.AIProvider == AIProviderOpenAI, "EMBEDDINGS_MODEL requires the azure or openai AI_PROVIDER")
+		check(len(c.SimilarIssueProjects) > 0 && c.TokenBucketName != "" && c.JiraURL != "",
+			"SIMILAR_ISSUE_PROJECTS, TOKEN_BUCKET_NAME and JIRA_URL are required when EMBEDDINGS_MODEL is set")
+	}
+	check(c.VisionModel == "" || c.AIProvider == AIProviderAzure || c.AIProvider == AIProviderOpenAI,
+		"VISION_MODEL requires the azure or openai AI_PROVIDER")
+
+	// These features keep their state in the bucket
+	if c.TokenBucketName == "" {
+		for _, feature := range []struct {
+			env     string
+			enabled bool
+		}{
+			{"STATE_MACHINE_ARN", c.StateMachineARN != ""},
+			{"PAGERDUTY_WEBHOOK_SECRET", c.PagerDutyWebhookSecret != ""},
+			{"EMAIL_ROUTES", len(c.EmailRoutes) > 0 && c.EmailBucketName == ""},
+			{"WRITE_APPROVALS", c.WriteApprovals},
+			{"USAGE_LOG", c.UsageLog},
+			{"ANSWER_FEEDBACK", c.AnswerFeedback},
+		} {
+			check(!feature.enabled, "TOKEN_BUCKET_NAME is required when %s is set", feature.env)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("invalid configuration:\n- %s", strings.Join(problems, "\n- "))
+}
+
+// jiraInstanceName matches the names of JIRA_INSTANCES, which queries pick with "on <name>:"
+var jiraInstanceName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// isHTTPURL reports whether value is an absolute http or https URL
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
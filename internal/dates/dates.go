@@ -0,0 +1,95 @@
+// Package dates renders the timestamps Jira returns in the time zone of the user reading them,
+// the same way in every message and tool result.
+package dates
+
+import (
+	"regexp"
+	"time"
+	_ "time/tzdata" // Time zones load without zoneinfo on the host
+)
+
+const (
+	// jiraLayout is how the Jira REST API formats timestamps
+	jiraLayout = "2006-01-02T15:04:05.000-0700"
+
+	// timeLayout is how timestamps are shown, with the offset so they cannot be misread
+	timeLayout = "2006-01-02 15:04"
+
+	// dateLayout is how dates are shown
+	dateLayout = "2006-01-02"
+)
+
+// timestampPattern matches timestamps with a UTC offset, as Jira and other APIs return them
+var timestampPattern = regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}T\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:Z|[+-]\d{2}:?\d{2})`)
+
+// Formatter renders timestamps in a time zone
+type Formatter struct {
+	location *time.Location
+}
+
+// In returns a Formatter for the IANA time zone, UTC if it is empty or unknown
+func In(timezone string) Formatter {
+	return Formatter{location: Location(timezone)}
+}
+
+// Location returns the IANA time zone, UTC if it is empty or unknown
+func Location(timezone string) *time.Location {
+	if timezone == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// Location returns the time zone timestamps are rendered in
+func (f Formatter) Location() *time.Location {
+	if f.location == nil {
+		return time.UTC
+	}
+	return f.location
+}
+
+// Time renders the timestamp, e.g. "2024-05-01 18:22 UTC+08:00"
+func (f Formatter) Time(t time.Time) string {
+	local := t.In(f.Location())
+	return local.Format(timeLayout) + " " + offset(local)
+}
+
+// Date renders the day of the timestamp in the time zone, e.g. "2024-05-01"
+func (f Formatter) Date(t time.Time) string {
+	return t.In(f.Location()).Format(dateLayout)
+}
+
+// Localize rewrites the timestamps in the text, e.g. a tool result, into the time zone. Dates
+// without a time, such as due dates, are calendar days and stay as they are.
+func (f Formatter) Localize(text string) string {
+	return timestampPattern.ReplaceAllStringFunc(text, func(match string) string {
+		t, ok := Parse(match)
+		if !ok {
+			return match
+		}
+		return f.Time(t)
+	})
+}
+
+// Parse reads a timestamp in the Jira REST API's format or RFC 3339
+func Parse(value string) (time.Time, bool) {
+	for _, layout := range []string{jiraLayout, time.RFC3339Nano, "2006-01-02T15:04:05-0700", "2006-01-02T15:04Z07:00", "2006-01-02T15:04-0700"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// offset renders the UTC offset of the time, e.g. "UTC+08:00", or "UTC"
+func offset(t time.Time) string {
+	zone := t.Format("-07:00")
+	if zone == "+00:00" {
+		return "UTC"
+	}
+	return "UTC" + zone
+}
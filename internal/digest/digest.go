@@ -0,0 +1,95 @@
+package digest
+
+import (
+	"fmt"
+	"strings"
+
+	"jira_helper/internal/jql"
+)
+
+const (
+	// Daily digests cover the last day
+	Daily = "daily"
+	// Weekly digests cover the last seven days
+	Weekly = "weekly"
+)
+
+// sectionPrompts asks the model for each section a digest may contain. %[1]s is the project and
+// %[2]s the period the digest covers, as a JQL relative date such as -1d.
+var sectionPrompts = map[string]string{
+	"updated": "Issues updated: search for issues of %[1]s updated since %[2]s (updated >= %[2]s) and list the notable ones grouped by status, with who worked on them.",
+	"sprint":  "Sprint burndown: find the active sprint of %[1]s and report how many issues and story points are done, in progress and to do, the days left in the sprint, and whether it is on track.",
+	"stale":   "Stale tickets: search for unresolved issues of %[1]s that are in progress or in review but were not updated in the last 7 days (updated <= -7d), and list them with their assignee.",
+}
+
+// defaultSections are the sections of a digest that does not list its own
+var defaultSections = []string{"updated", "sprint", "stale"}
+
+// Digest is a report posted to a channel on a schedule
+type Digest struct {
+	Channel   string   `json:"channel"`
+	Project   string   `json:"project"`
+	Frequency string   `json:"frequency"`          // daily or weekly
+	Sections  []string `json:"sections,omitempty"` // updated, sprint and stale, defaults to all of them
+}
+
+// Digests are the configured digest reports
+type Digests []Digest
+
+// Validate checks that every digest has a channel and project, a known frequency and known sections
+func (d Digests) Validate() error {
+	for i, digest := range d {
+		if digest.Channel == "" || digest.Project == "" {
+			return fmt.Errorf("digest %d needs a channel and a project", i)
+		}
+		// The project is written into the JQL the digest prompts ask for
+		if err := jql.ValidateFragment(digest.Project); err != nil {
+			return fmt.Errorf("digest %d has an invalid project %q: %v", i, digest.Project, err)
+		}
+		if digest.Frequency != Daily && digest.Frequency != Weekly {
+			return fmt.Errorf("digest %d has an unknown frequency %q, use %s or %s", i, digest.Frequency, Daily, Weekly)
+		}
+		for _, section := range digest.Sections {
+			if _, ok := sectionPrompts[section]; !ok {
+				return fmt.Errorf("digest %d has an unknown section %q", i, section)
+			}
+		}
+	}
+	return nil
+}
+
+// Due returns the digests of the frequency
+func (d Digests) Due(frequency string) Digests {
+	var due Digests
+	for _, digest := range d {
+		if digest.Frequency == frequency {
+			due = append(due, digest)
+		}
+	}
+	return due
+}
+
+// Title names the digest in the channel, e.g. "Daily digest"
+func (d Digest) Title() string {
+	return strings.ToUpper(d.Frequency[:1]) + d.Frequency[1:] + " digest"
+}
+
+// Prompt asks the model to compose the digest
+func (d Digest) Prompt() string {
+	since := "-1d"
+	if d.Frequency == Weekly {
+		since = "-7d"
+	}
+	sections := d.Sections
+	if len(sections) == 0 {
+		sections = defaultSections
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write the %s digest of the Jira project %s for its Slack channel. Use the Jira tools to gather the data, and write one short section per topic below, skipping a section only if its data cannot be found:\n", d.Frequency, d.Project)
+	for i, section := range sections {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, fmt.Sprintf(sectionPrompts[section], d.Project, since))
+	}
+	b.WriteString("Link every issue you mention, and keep the whole digest brief enough to read in a minute.")
+	return b.String()
+}
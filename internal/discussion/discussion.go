@@ -0,0 +1,167 @@
+package discussion
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"jira_helper/internal/dates"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/service/openai"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+)
+
+const (
+	// DefaultChunkTokens is the size of the parts a long discussion is summarized in
+	DefaultChunkTokens = 8000
+
+	// maxChunks bounds the parts summarized, the oldest comments beyond them are left out
+	maxChunks = 16
+
+	// maxReducePasses bounds how often partial summaries are summarized again
+	maxReducePasses = 3
+)
+
+// summaryPrompt instructs the model how to write the summary of the whole discussion
+const summaryPrompt = `You summarize the discussion on Jira issue %s for someone who has not followed it. The user gives you the issue and its comments, or notes on them.
+
+- Start with one or two sentences on where the issue stands now
+- Then write *Timeline* and one "• " line per notable step, oldest first: the date, who, and what they reported, proposed or decided
+- Then write *Decisions* and *Open questions* with "• " lines, and skip them if there are none
+- Use Slack formatting: *bold* for the headings, no Markdown headings or tables
+- Only describe what the comments say, do not invent anything`
+
+// partPrompt instructs the model how to take notes on one part of a long discussion
+const partPrompt = `You take notes on part %d of %d of the discussion on Jira issue %s, so it can be summarized as a whole later. Write one "• " line per notable comment or step, oldest first, with its date, who, and what they reported, proposed or decided. Keep issue keys, versions, numbers and names exactly. Reply with the notes only.`
+
+// Chatter completes a chat. The AI providers implement it.
+type Chatter interface {
+	Chat(ctx context.Context, messages []azopenai.ChatRequestMessageClassification) (string, error)
+}
+
+// Jira is the part of the Jira client the discussion is read with. *jira.Client implements it.
+type Jira interface {
+	GetIssue(ctx context.Context, key string, fields ...string) (*jira.Issue, error)
+	Comments(ctx context.Context, key string) ([]jira.Comment, error)
+}
+
+// Discussion is an issue with all of its comments
+type Discussion struct {
+	Key         string
+	Summary     string
+	Description string
+	Comments    []jira.Comment  // Oldest first
+	Dates       dates.Formatter // Renders the comment times in the reader's time zone, UTC by default
+}
+
+// Collect reads the issue and all of its comments
+func Collect(ctx context.Context, client Jira, key string) (*Discussion, error) {
+	issue, err := client.GetIssue(ctx, key, "summary", "description")
+	if err != nil {
+		return nil, err
+	}
+	comments, err := client.Comments(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Discussion{
+		Key:         issue.Key,
+		Summary:     issue.Fields.Summary,
+		Description: issue.Fields.Description,
+		Comments:    comments,
+	}, nil
+}
+
+// Summarize has the model write a timeline-style summary of the discussion. Discussions longer
+// than chunkTokens are summarized in parts first, and the notes on the parts are summarized as a
+// whole (map-reduce), so a discussion of any length fits the model's context window.
+func Summarize(ctx context.Context, chat Chatter, d *Discussion, chunkTokens int) (string, error) {
+	if chunkTokens <= 0 {
+		chunkTokens = DefaultChunkTokens
+	}
+	header := fmt.Sprintf("%s: %s\n\nDescription:\n%s", d.Key, d.Summary, truncate(d.Description, chunkTokens/4))
+
+	entries := make([]string, 0, len(d.Comments))
+	for _, comment := range d.Comments {
+		author := "Unknown"
+		if comment.Author != nil {
+			author = comment.Author.DisplayName
+		}
+		entry := fmt.Sprintf("[%s] %s:\n%s", d.Dates.Time(comment.Created.Time), author, strings.TrimSpace(comment.Body))
+		entries = append(entries, truncate(entry, chunkTokens))
+	}
+
+	chunks := split(entries, chunkTokens)
+	if len(chunks) <= 1 {
+		return complete(ctx, chat, fmt.Sprintf(summaryPrompt, d.Key), header+"\n\nComments:\n"+strings.Join(entries, "\n\n"))
+	}
+	omitted := ""
+	if len(chunks) > maxChunks {
+		omitted = fmt.Sprintf("\n\n(The oldest comments, about %d parts, were left out.)", len(chunks)-maxChunks)
+		chunks = chunks[len(chunks)-maxChunks:]
+	}
+
+	// Take notes on each part, then on the notes until they fit into one request
+	notes := chunks
+	for pass := 0; len(notes) > 1 && pass < maxReducePasses; pass++ {
+		partial := make([]string, 0, len(notes))
+		for i, chunk := range notes {
+			note, err := complete(ctx, chat, fmt.Sprintf(partPrompt, i+1, len(notes), d.Key), chunk)
+			if err != nil {
+				return "", err
+			}
+			partial = append(partial, note)
+		}
+		notes = split(partial, chunkTokens)
+	}
+	return complete(ctx, chat, fmt.Sprintf(summaryPrompt, d.Key), header+omitted+"\n\nNotes on the comments:\n"+strings.Join(notes, "\n"))
+}
+
+// complete runs one chat with the instructions and the content
+func complete(ctx context.Context, chat Chatter, instructions, content string) (string, error) {
+	answer, err := chat.Chat(ctx, []azopenai.ChatRequestMessageClassification{
+		&azopenai.ChatRequestSystemMessage{Content: azopenai.NewChatRequestSystemMessageContent(instructions)},
+		&azopenai.ChatRequestUserMessage{Content: azopenai.NewChatRequestUserMessageContent(content)},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize discussion: %v", err)
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return "", fmt.Errorf("the model returned an empty summary")
+	}
+	return answer, nil
+}
+
+// split groups the entries, in order, into chunks of at most chunkTokens estimated tokens
+func split(entries []string, chunkTokens int) []string {
+	var chunks []string
+	var current []string
+	size := 0
+	for _, entry := range entries {
+		tokens := openai.EstimateTokens(entry)
+		if len(current) > 0 && size+tokens > chunkTokens {
+			chunks = append(chunks, strings.Join(current, "\n\n"))
+			current, size = nil, 0
+		}
+		current = append(current, entry)
+		size += tokens
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, strings.Join(current, "\n\n"))
+	}
+	return chunks
+}
+
+// truncate shortens the text to about the tokens, marking the cut
+func truncate(text string, tokens int) string {
+	if openai.EstimateTokens(text) <= tokens {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && openai.EstimateTokens(string(runes)) > tokens {
+		runes = runes[:len(runes)*9/10]
+	}
+	return string(runes) + "…"
+}
@@ -0,0 +1,162 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// maxBodyLength caps the body handed to triage, long threads mostly repeat quoted replies
+const maxBodyLength = 8000
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`(?s)<(script|style).*?</(script|style)>|<[^>]+>`)
+	blankLinesPattern = regexp.MustCompile(`\n\s*\n\s*\n+`)
+)
+
+// Message is the part of an inbound email needed for triage
+type Message struct {
+	MessageID string
+	From      string
+	Subject   string
+	Text      string // Plain text body, HTML converted to text when there is no text part
+}
+
+// Parse reads a raw RFC 5322 email
+func Parse(raw []byte) (*Message, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email: %v", err)
+	}
+
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	from := msg.Header.Get("From")
+	if address, err := mail.ParseAddress(from); err == nil {
+		from = address.String()
+	}
+
+	text, err := readBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(text) > maxBodyLength {
+		text = text[:maxBodyLength] + "\n[truncated]"
+	}
+
+	return &Message{
+		MessageID: strings.Trim(msg.Header.Get("Message-Id"), "<>"),
+		From:      from,
+		Subject:   subject,
+		Text:      text,
+	}, nil
+}
+
+// readBody returns the text of a body, preferring text/plain over text/html in multipart emails
+func readBody(contentType, transferEncoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		return readMultipart(multipart.NewReader(body, params["boundary"]))
+	}
+
+	data, err := io.ReadAll(decodeTransfer(transferEncoding, body))
+	if err != nil {
+		return "", fmt.Errorf("failed to read email body: %v", err)
+	}
+	switch mediaType {
+	case "text/plain":
+		return strings.TrimSpace(string(data)), nil
+	case "text/html":
+		return htmlToText(string(data)), nil
+	default:
+		return "", nil
+	}
+}
+
+// readMultipart walks the parts and returns the first plain text, falling back to HTML
+func readMultipart(reader *multipart.Reader) (string, error) {
+	var htmlText string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read email part: %v", err)
+		}
+		// Skip attachments
+		if part.FileName() != "" {
+			continue
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		text, err := readBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+		if err != nil {
+			return "", err
+		}
+		if text == "" {
+			continue
+		}
+		if mediaType == "text/html" {
+			if htmlText == "" {
+				htmlText = text
+			}
+			continue
+		}
+		return text, nil
+	}
+	return htmlText, nil
+}
+
+// decodeTransfer undoes the content transfer encoding. multipart.Reader already decodes
+// quoted-printable parts and removes the header, so this only sees it on top-level bodies.
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &lineStripper{r: body})
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// htmlToText strips tags and entities from an HTML body
+func htmlToText(body string) string {
+	text := strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n\n", "</div>", "\n").Replace(body)
+	text = html.UnescapeString(htmlTagPattern.ReplaceAllString(text, ""))
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(text, "\n\n"))
+}
+
+// lineStripper drops line breaks so base64 bodies wrapped at 76 characters decode
+type lineStripper struct {
+	r io.Reader
+}
+
+// Read reads from the underlying reader without CR and LF bytes
+func (l *lineStripper) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	kept := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
@@ -0,0 +1,24 @@
+package email
+
+import "strings"
+
+// Route sends the emails of a recipient address to a Jira project and the Slack channel owning it
+type Route struct {
+	Channel string `json:"channel"`
+	Project string `json:"project"`
+}
+
+// Routes maps recipient addresses to their routes
+type Routes map[string]Route
+
+// Match returns the route of the first recipient that has one, comparing addresses case-insensitively
+func (r Routes) Match(recipients []string) (string, Route, bool) {
+	for _, recipient := range recipients {
+		for address, route := range r {
+			if strings.EqualFold(address, recipient) {
+				return address, route, true
+			}
+		}
+	}
+	return "", Route{}, false
+}
@@ -0,0 +1,242 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/storage"
+)
+
+const (
+	// batchSize is the number of texts embedded per request
+	batchSize = 16
+
+	// maxTextLength bounds the characters of an issue that are embedded, well within the
+	// models' input limit
+	maxTextLength = 4000
+
+	// maxIndexedIssues is the number of most recently updated issues kept per project
+	maxIndexedIssues = 5000
+
+	// cacheTTL is how long a loaded index is searched before it is loaded again
+	cacheTTL = 10 * time.Minute
+)
+
+// indexedFields are the issue fields a refresh reads
+var indexedFields = []string{"summary", "description", "status", "updated"}
+
+// Embedder computes embedding vectors. *openai.Client implements it.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string, dimensions int) ([][]float32, error)
+}
+
+// IssueSource searches issues. *jira.Client implements it.
+type IssueSource interface {
+	Search(ctx context.Context, jql string, opts jira.SearchOptions) ([]jira.Issue, int, error)
+}
+
+// Match is an indexed issue similar to a text
+type Match struct {
+	Key        string  `json:"key"`
+	Summary    string  `json:"summary"`
+	Status     string  `json:"status,omitempty"`
+	Similarity float64 `json:"similarity"` // Cosine similarity, 1 for the same meaning
+}
+
+// cachedIndex is a loaded index, nil if the project was not indexed
+type cachedIndex struct {
+	index    *storage.EmbeddingIndex
+	loadedAt time.Time
+}
+
+// Index finds issues similar to a text by comparing the embeddings of their summaries and
+// descriptions
+type Index struct {
+	store      storage.EmbeddingStore
+	embedder   Embedder
+	model      string // Identifies the vectors, indexes of another model or size are rebuilt
+	dimensions int
+
+	mu     sync.Mutex
+	loaded map[string]cachedIndex
+}
+
+// NewIndex creates a new Index instance. model names the embedding model, and dimensions
+// shortens its vectors when the model supports it, 0 keeps the model's default.
+func NewIndex(store storage.EmbeddingStore, embedder Embedder, model string, dimensions int) *Index {
+	return &Index{
+		store:      store,
+		embedder:   embedder,
+		model:      fmt.Sprintf("%s/%d", model, dimensions),
+		dimensions: dimensions,
+		loaded:     map[string]cachedIndex{},
+	}
+}
+
+// Refresh embeds the issues of the project changed since the last refresh and returns how many
+// were embedded. The first refresh embeds the most recently updated issues.
+func (x *Index) Refresh(ctx context.Context, source IssueSource, project string) (int, error) {
+	index, err := x.store.GetIndex(ctx, project)
+	if err != nil {
+		return 0, err
+	}
+	if index == nil || index.Model != x.model {
+		index = &storage.EmbeddingIndex{Project: project, Model: x.model}
+	}
+
+	// JQL dates are in the Jira user's time zone, a relative period avoids converting them
+	jql := fmt.Sprintf(`project = "%s" ORDER BY updated DESC`, project)
+	if !index.IndexedAt.IsZero() {
+		minutes := int(time.Since(index.IndexedAt).Minutes()) + 5
+		jql = fmt.Sprintf(`project = "%s" AND updated >= -%dm ORDER BY updated DESC`, project, minutes)
+	}
+	startedAt := time.Now()
+	issues, _, err := source.Search(ctx, jql, jira.SearchOptions{Fields: indexedFields, Limit: maxIndexedIssues})
+	if err != nil {
+		return 0, err
+	}
+
+	byKey := make(map[string]storage.IssueEmbedding, len(index.Issues))
+	for _, issue := range index.Issues {
+		byKey[issue.Key] = issue
+	}
+	var changed []jira.Issue
+	for _, issue := range issues {
+		if existing, ok := byKey[issue.Key]; ok && !issue.Fields.Updated.After(existing.Updated) {
+			continue
+		}
+		changed = append(changed, issue)
+	}
+
+	for start := 0; start < len(changed); start += batchSize {
+		batch := changed[start:min(start+batchSize, len(changed))]
+		texts := make([]string, len(batch))
+		for i, issue := range batch {
+			texts[i] = issueText(issue)
+		}
+		vectors, err := x.embedder.Embed(ctx, texts, x.dimensions)
+		if err != nil {
+			return start, err
+		}
+		for i, issue := range batch {
+			embedding := storage.IssueEmbedding{
+				Key:     issue.Key,
+				Summary: issue.Fields.Summary,
+				Updated: issue.Fields.Updated.Time,
+				Vector:  vectors[i],
+			}
+			if issue.Fields.Status != nil {
+				embedding.Status = issue.Fields.Status.Name
+			}
+			byKey[issue.Key] = embedding
+		}
+	}
+
+	index.Issues = index.Issues[:0]
+	for _, issue := range byKey {
+		index.Issues = append(index.Issues, issue)
+	}
+	sort.Slice(index.Issues, func(i, j int) bool { return index.Issues[i].Updated.After(index.Issues[j].Updated) })
+	if len(index.Issues) > maxIndexedIssues {
+		index.Issues = index.Issues[:maxIndexedIssues]
+	}
+	index.IndexedAt = startedAt
+	if err := x.store.SaveIndex(ctx, index); err != nil {
+		return len(changed), err
+	}
+
+	x.mu.Lock()
+	x.loaded[project] = cachedIndex{index: index, loadedAt: time.Now()}
+	x.mu.Unlock()
+	return len(changed), nil
+}
+
+// Similar returns the issues of the projects most similar to the text, the most similar first
+func (x *Index) Similar(ctx context.Context, text string, projects []string, limit int) ([]Match, error) {
+	vectors, err := x.embedder.Embed(ctx, []string{truncate(text)}, x.dimensions)
+	if err != nil {
+		return nil, err
+	}
+	query := vectors[0]
+
+	var matches []Match
+	for _, project := range projects {
+		index, err := x.load(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+		if index == nil {
+			continue
+		}
+		for _, issue := range index.Issues {
+			matches = append(matches, Match{
+				Key:        issue.Key,
+				Summary:    issue.Summary,
+				Status:     issue.Status,
+				Similarity: math.Round(cosine(query, issue.Vector)*1000) / 1000,
+			})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Similarity > matches[j].Similarity })
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// load returns the project's index, nil if it was not indexed with the current model
+func (x *Index) load(ctx context.Context, project string) (*storage.EmbeddingIndex, error) {
+	x.mu.Lock()
+	cached, ok := x.loaded[project]
+	x.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < cacheTTL {
+		return cached.index, nil
+	}
+
+	index, err := x.store.GetIndex(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	if index != nil && index.Model != x.model {
+		index = nil
+	}
+	x.mu.Lock()
+	x.loaded[project] = cachedIndex{index: index, loadedAt: time.Now()}
+	x.mu.Unlock()
+	return index, nil
+}
+
+// issueText is the text of an issue that is embedded
+func issueText(issue jira.Issue) string {
+	return truncate(issue.Fields.Summary + "\n\n" + issue.Fields.Description)
+}
+
+// truncate shortens the text to maxTextLength characters
+func truncate(text string) string {
+	if runes := []rune(text); len(runes) > maxTextLength {
+		return string(runes[:maxTextLength])
+	}
+	return text
+}
+
+// cosine returns the cosine similarity of two vectors, 0 if they cannot be compared
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
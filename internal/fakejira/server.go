@@ -0,0 +1,322 @@
+package fakejira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultLimit is how many issues a search returns unless it asks for another number
+const defaultLimit = 10
+
+// NewServer creates an MCP server with the Jira tools of mcp-atlassian, working on the store
+func NewServer(store *Store) *server.MCPServer {
+	s := server.NewMCPServer("fake-jira", "1.0.0", server.WithToolCapabilities(false))
+	t := tools{store: store}
+
+	s.AddTool(mcp.NewTool("jira_search",
+		mcp.WithDescription("Search Jira issues using JQL (Jira Query Language)"),
+		mcp.WithString("jql", mcp.Required(), mcp.Description("JQL query string")),
+		mcp.WithString("fields", mcp.Description("Comma-separated fields to return")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of results (1-50)")),
+		mcp.WithNumber("start_at", mcp.Description("Starting index for pagination")),
+	), t.search)
+	s.AddTool(mcp.NewTool("jira_get_issue",
+		mcp.WithDescription("Get details of a specific Jira issue including its comments"),
+		mcp.WithString("issue_key", mcp.Required(), mcp.Description("Jira issue key, e.g. PROJ-123")),
+		mcp.WithString("fields", mcp.Description("Comma-separated fields to return")),
+	), t.getIssue)
+	s.AddTool(mcp.NewTool("jira_get_project_issues",
+		mcp.WithDescription("Get all issues of a specific Jira project"),
+		mcp.WithString("project_key", mcp.Required(), mcp.Description("The project key")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of results (1-50)")),
+	), t.projectIssues)
+	s.AddTool(mcp.NewTool("jira_get_all_projects",
+		mcp.WithDescription("Get all Jira projects accessible to the current user"),
+	), t.projects)
+	s.AddTool(mcp.NewTool("jira_create_issue",
+		mcp.WithDescription("Create a new Jira issue"),
+		mcp.WithString("project_key", mcp.Required(), mcp.Description("The project key")),
+		mcp.WithString("summary", mcp.Required(), mcp.Description("Summary (title) of the issue")),
+		mcp.WithString("issue_type", mcp.Required(), mcp.Description("Issue type, e.g. Task, Bug, Story")),
+		mcp.WithString("assignee", mcp.Description("Assignee of the issue")),
+		mcp.WithString("description", mcp.Description("Issue description")),
+	), t.createIssue)
+	s.AddTool(mcp.NewTool("jira_update_issue",
+		mcp.WithDescription("Update an existing Jira issue"),
+		mcp.WithString("issue_key", mcp.Required(), mcp.Description("Jira issue key")),
+		mcp.WithObject("fields", mcp.Required(), mcp.Description("Fields to update: summary, description, priority, assignee, labels")),
+	), t.updateIssue)
+	s.AddTool(mcp.NewTool("jira_delete_issue",
+		mcp.WithDescription("Delete an existing Jira issue"),
+		mcp.WithString("issue_key", mcp.Required(), mcp.Description("Jira issue key")),
+	), t.deleteIssue)
+	s.AddTool(mcp.NewTool("jira_add_comment",
+		mcp.WithDescription("Add a comment to a Jira issue"),
+		mcp.WithString("issue_key", mcp.Required(), mcp.Description("Jira issue key")),
+		mcp.WithString("comment", mcp.Required(), mcp.Description("Comment text")),
+	), t.addComment)
+	s.AddTool(mcp.NewTool("jira_get_transitions",
+		mcp.WithDescription("Get the available status transitions of a Jira issue"),
+		mcp.WithString("issue_key", mcp.Required(), mcp.Description("Jira issue key")),
+	), t.transitions)
+	s.AddTool(mcp.NewTool("jira_transition_issue",
+		mcp.WithDescription("Transition a Jira issue to a new status"),
+		mcp.WithString("issue_key", mcp.Required(), mcp.Description("Jira issue key")),
+		mcp.WithString("transition_id", mcp.Required(), mcp.Description("ID of the transition, from jira_get_transitions")),
+		mcp.WithString("comment", mcp.Description("Comment added with the transition")),
+	), t.transitionIssue)
+	return s
+}
+
+// tools implements the tools on the store
+type tools struct {
+	store *Store
+}
+
+func (t tools) search(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jql, err := request.RequireString("jql")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return searchResult(t.store.Search(jql), request), nil
+}
+
+func (t tools) projectIssues(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	project, err := request.RequireString("project_key")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return searchResult(t.store.Search("project = "+project), request), nil
+}
+
+func (t tools) getIssue(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	key, err := request.RequireString("issue_key")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	issue, err := t.store.Get(key)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return jsonResult(simplified(issue, true)), nil
+}
+
+func (t tools) projects(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	keys := make([]string, 0, len(Projects))
+	for key := range Projects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	projects := make([]map[string]string, 0, len(keys))
+	for _, key := range keys {
+		projects = append(projects, map[string]string{"key": key, "name": Projects[key]})
+	}
+	return jsonResult(projects), nil
+}
+
+func (t tools) createIssue(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	issue, err := t.store.Create(request.GetString("project_key", ""), Issue{
+		Summary:     request.GetString("summary", ""),
+		Type:        request.GetString("issue_type", ""),
+		Assignee:    request.GetString("assignee", ""),
+		Description: request.GetString("description", ""),
+	})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return jsonResult(map[string]interface{}{"message": "Issue created successfully", "issue": simplified(issue, false)}), nil
+}
+
+func (t tools) updateIssue(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	key, err := request.RequireString("issue_key")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	// mcp-atlassian takes the fields as an object or as a JSON string of one
+	fields, ok := request.GetArguments()["fields"].(map[string]interface{})
+	if text, isText := request.GetArguments()["fields"].(string); isText {
+		ok = json.Unmarshal([]byte(text), &fields) == nil
+	}
+	if !ok {
+		return mcp.NewToolResultError("fields must be an object"), nil
+	}
+	issue, err := t.store.Update(key, func(issue *Issue) error {
+		for name, value := range fields {
+			text := fmt.Sprint(value)
+			switch name {
+			case "summary":
+				issue.Summary = text
+			case "description":
+				issue.Description = text
+			case "priority":
+				issue.Priority = nameOf(value)
+			case "assignee":
+				issue.Assignee = nameOf(value)
+			case "labels":
+				labels, _ := value.([]interface{})
+				issue.Labels = nil
+				for _, label := range labels {
+					issue.Labels = append(issue.Labels, fmt.Sprint(label))
+				}
+			default:
+				return fmt.Errorf("field %s cannot be updated in the fake Jira", name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return jsonResult(map[string]interface{}{"message": "Issue updated successfully", "issue": simplified(issue, false)}), nil
+}
+
+func (t tools) deleteIssue(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	key, err := request.RequireString("issue_key")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := t.store.Delete(key); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return jsonResult(map[string]string{"message": fmt.Sprintf("Issue %s has been deleted successfully.", strings.ToUpper(key))}), nil
+}
+
+func (t tools) addComment(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	key, err := request.RequireString("issue_key")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	body, err := request.RequireString("comment")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	comment := Comment{Author: CurrentUser, Body: body, Created: time.Now()}
+	if _, err := t.store.Update(key, func(issue *Issue) error {
+		issue.Comments = append(issue.Comments, comment)
+		return nil
+	}); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return jsonResult(simplifiedComment(comment)), nil
+}
+
+func (t tools) transitions(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	key, err := request.RequireString("issue_key")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	issue, err := t.store.Get(key)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var available []map[string]string
+	for _, status := range statuses {
+		if status.name != issue.Status {
+			available = append(available, map[string]string{"id": status.id, "name": status.name, "to_status": status.name})
+		}
+	}
+	return jsonResult(available), nil
+}
+
+func (t tools) transitionIssue(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	key, err := request.RequireString("issue_key")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	issue, err := t.store.Transition(key, fmt.Sprint(request.GetArguments()["transition_id"]))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if comment := request.GetString("comment", ""); comment != "" {
+		issue, _ = t.store.Update(key, func(issue *Issue) error {
+			issue.Comments = append(issue.Comments, Comment{Author: CurrentUser, Body: comment, Created: time.Now()})
+			return nil
+		})
+	}
+	return jsonResult(map[string]interface{}{"message": fmt.Sprintf("Issue %s transitioned successfully", issue.Key), "issue": simplified(issue, false)}), nil
+}
+
+// searchResult lists the issues like mcp-atlassian, up to the limit the request asks for
+func searchResult(issues []Issue, request mcp.CallToolRequest) *mcp.CallToolResult {
+	limit := defaultLimit
+	if value, ok := request.GetArguments()["limit"].(float64); ok && value > 0 {
+		limit = min(int(value), 50)
+	}
+	start := 0
+	if value, ok := request.GetArguments()["start_at"].(float64); ok && value > 0 {
+		start = min(int(value), len(issues))
+	}
+	page := issues[start:min(start+limit, len(issues))]
+	listed := make([]map[string]interface{}, 0, len(page))
+	for _, issue := range page {
+		listed = append(listed, simplified(issue, false))
+	}
+	return jsonResult(map[string]interface{}{"total": len(issues), "start_at": start, "max_results": limit, "issues": listed})
+}
+
+// simplified is the issue in mcp-atlassian's simplified form, with its comments if asked for
+func simplified(issue Issue, comments bool) map[string]interface{} {
+	category := ""
+	for _, status := range statuses {
+		if status.name == issue.Status {
+			category = status.category
+		}
+	}
+	result := map[string]interface{}{
+		"key":         issue.Key,
+		"summary":     issue.Summary,
+		"description": issue.Description,
+		"status":      map[string]string{"name": issue.Status, "category": category},
+		"issue_type":  map[string]string{"name": issue.Type},
+		"priority":    map[string]string{"name": issue.Priority},
+		"reporter":    map[string]string{"display_name": issue.Reporter},
+		"labels":      append([]string{}, issue.Labels...),
+		"created":     issue.Created.Format(timeLayout),
+		"updated":     issue.Updated.Format(timeLayout),
+		"assignee":    nil,
+	}
+	if issue.Assignee != "" {
+		result["assignee"] = map[string]string{"display_name": issue.Assignee}
+	}
+	if comments {
+		listed := make([]map[string]string, 0, len(issue.Comments))
+		for _, comment := range issue.Comments {
+			listed = append(listed, simplifiedComment(comment))
+		}
+		result["comments"] = listed
+	}
+	return result
+}
+
+// simplifiedComment is the comment in mcp-atlassian's simplified form
+func simplifiedComment(comment Comment) map[string]string {
+	return map[string]string{"author": comment.Author, "body": comment.Body, "created": comment.Created.Format(timeLayout)}
+}
+
+// nameOf reads a field value given as a name or as an object with one, e.g. {"name": "High"}
+func nameOf(value interface{}) string {
+	if object, ok := value.(map[string]interface{}); ok {
+		for _, key := range []string{"name", "displayName", "display_name"} {
+			if name, ok := object[key].(string); ok {
+				return name
+			}
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// jsonResult returns the value as indented JSON text, like mcp-atlassian
+func jsonResult(value interface{}) *mcp.CallToolResult {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	return mcp.NewToolResultText(string(data))
+}
@@ -0,0 +1,302 @@
+// Package fakejira is an in-memory Jira behind an MCP server with the tool names and result
+// shapes of mcp-atlassian, for local development and tests without a Jira instance.
+package fakejira
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CurrentUser is the account the fake Jira acts as, whatever the token
+const CurrentUser = "Dev User"
+
+// timeLayout is how Jira formats timestamps
+const timeLayout = "2006-01-02T15:04:05.000-0700"
+
+// Statuses of the workflow every issue follows, with their status category
+var statuses = []struct {
+	id       string // ID of the transition into the status
+	name     string
+	category string
+}{
+	{"11", "To Do", "To Do"},
+	{"21", "In Progress", "In Progress"},
+	{"31", "In Review", "In Progress"},
+	{"41", "Done", "Done"},
+}
+
+// Projects are the projects of the fake Jira, by key
+var Projects = map[string]string{
+	"DEMO": "Demo",
+	"OPS":  "Operations",
+}
+
+// Issue is an issue of the fake Jira
+type Issue struct {
+	Key         string
+	Summary     string
+	Description string
+	Type        string
+	Status      string
+	Priority    string
+	Assignee    string
+	Reporter    string
+	Labels      []string
+	Created     time.Time
+	Updated     time.Time
+	Comments    []Comment
+}
+
+// Comment is a comment on an issue
+type Comment struct {
+	Author  string
+	Body    string
+	Created time.Time
+}
+
+// Store holds the issues of the fake Jira. It is safe for concurrent use.
+type Store struct {
+	mu     sync.Mutex
+	issues map[string]*Issue
+	next   map[string]int // Project key -> number of its next issue
+}
+
+// NewStore creates a store with a few issues in each project to work with
+func NewStore() *Store {
+	s := &Store{issues: map[string]*Issue{}, next: map[string]int{}}
+	now := time.Now()
+	for i, seed := range []struct {
+		project string
+		issue   Issue
+	}{
+		{"DEMO", Issue{Summary: "Login page shows a blank screen on Safari", Type: "Bug", Status: "In Progress", Priority: "High", Assignee: CurrentUser, Labels: []string{"frontend"}}},
+		{"DEMO", Issue{Summary: "Add CSV export to the report page", Type: "Story", Status: "To Do", Priority: "Medium", Labels: []string{"reports"}}},
+		{"DEMO", Issue{Summary: "Upgrade the payment SDK", Type: "Task", Status: "In Review", Priority: "Medium", Assignee: "Alex Chen"}},
+		{"DEMO", Issue{Summary: "Password reset emails arrive twice", Type: "Bug", Status: "Done", Priority: "Low", Assignee: CurrentUser}},
+		{"OPS", Issue{Summary: "Rotate the database credentials", Type: "Task", Status: "To Do", Priority: "High", Assignee: "Sam Lee"}},
+		{"OPS", Issue{Summary: "Disk usage alert on the build agents", Type: "Incident", Status: "In Progress", Priority: "Highest", Assignee: CurrentUser}},
+	} {
+		issue := seed.issue
+		issue.Description = "Created by the fake Jira for local development."
+		issue.Reporter = CurrentUser
+		issue.Created = now.Add(-time.Duration(10-i) * 24 * time.Hour)
+		issue.Updated = now.Add(-time.Duration(6-i) * time.Hour)
+		s.add(seed.project, issue)
+	}
+	return s
+}
+
+// add files the issue under the next key of the project
+func (s *Store) add(project string, issue Issue) *Issue {
+	s.next[project]++
+	issue.Key = fmt.Sprintf("%s-%d", project, s.next[project])
+	s.issues[issue.Key] = &issue
+	return &issue
+}
+
+// Get returns a copy of the issue
+func (s *Store) Get(key string) (Issue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issue, ok := s.issues[strings.ToUpper(key)]
+	if !ok {
+		return Issue{}, fmt.Errorf("issue %s does not exist", key)
+	}
+	return *issue, nil
+}
+
+// Create files a new issue in the project
+func (s *Store) Create(project string, issue Issue) (Issue, error) {
+	project = strings.ToUpper(project)
+	if _, ok := Projects[project]; !ok {
+		return Issue{}, fmt.Errorf("project %s does not exist", project)
+	}
+	if issue.Summary == "" {
+		return Issue{}, fmt.Errorf("summary is required")
+	}
+	if issue.Type == "" {
+		issue.Type = "Task"
+	}
+	if issue.Priority == "" {
+		issue.Priority = "Medium"
+	}
+	issue.Status, issue.Reporter = statuses[0].name, CurrentUser
+	issue.Created, issue.Updated = time.Now(), time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.add(project, issue), nil
+}
+
+// Update changes the issue with fn
+func (s *Store) Update(key string, fn func(issue *Issue) error) (Issue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issue, ok := s.issues[strings.ToUpper(key)]
+	if !ok {
+		return Issue{}, fmt.Errorf("issue %s does not exist", key)
+	}
+	updated := *issue
+	if err := fn(&updated); err != nil {
+		return Issue{}, err
+	}
+	updated.Updated = time.Now()
+	*issue = updated
+	return updated, nil
+}
+
+// Delete removes the issue
+func (s *Store) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.issues[strings.ToUpper(key)]; !ok {
+		return fmt.Errorf("issue %s does not exist", key)
+	}
+	delete(s.issues, strings.ToUpper(key))
+	return nil
+}
+
+// Transition moves the issue into the status of the transition, given by ID or by name
+func (s *Store) Transition(key, transition string) (Issue, error) {
+	return s.Update(key, func(issue *Issue) error {
+		for _, status := range statuses {
+			if transition == status.id || strings.EqualFold(transition, status.name) {
+				issue.Status = status.name
+				return nil
+			}
+		}
+		return fmt.Errorf("transition %s is not valid for %s", transition, issue.Key)
+	})
+}
+
+// jqlClause matches a condition of a JQL query, e.g. status = "In Progress"
+var jqlClause = regexp.MustCompile(`(?i)^\s*(\w+)\s*(!=|=|~|is not|is|in)\s*(.+?)\s*$`)
+
+// jqlAnd splits a JQL query into its conditions
+var jqlAnd = regexp.MustCompile(`(?i)\s+and\s+`)
+
+// jqlOrderBy matches the ordering of a JQL query
+var jqlOrderBy = regexp.MustCompile(`(?i)\s*order\s+by\s+.*$`)
+
+// Search returns the issues matching the JQL query, most recently updated first. The fake Jira
+// understands AND-ed conditions on project, key, status, type, priority, assignee, reporter,
+// labels, summary and text, and ignores the others.
+func (s *Store) Search(jql string) []Issue {
+	var conditions [][]string
+	if where := strings.TrimSpace(jqlOrderBy.ReplaceAllString(jql, "")); where != "" {
+		for _, part := range jqlAnd.Split(where, -1) {
+			if match := jqlClause.FindStringSubmatch(trimParens(part)); match != nil {
+				conditions = append(conditions, []string{strings.ToLower(match[1]), strings.ToLower(match[2]), unquote(match[3])})
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found []Issue
+	for _, issue := range s.issues {
+		if matchesAll(*issue, conditions) {
+			found = append(found, *issue)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Updated.After(found[j].Updated) })
+	return found
+}
+
+// matchesAll reports whether the issue meets every condition
+func matchesAll(issue Issue, conditions [][]string) bool {
+	for _, condition := range conditions {
+		field, operator, value := condition[0], condition[1], condition[2]
+		var actual []string
+		switch field {
+		case "project":
+			actual = []string{strings.SplitN(issue.Key, "-", 2)[0]}
+		case "key", "issuekey", "id":
+			actual = []string{issue.Key}
+		case "status":
+			actual = []string{issue.Status}
+		case "type", "issuetype":
+			actual = []string{issue.Type}
+		case "priority":
+			actual = []string{issue.Priority}
+		case "assignee":
+			actual = []string{issue.Assignee}
+		case "reporter":
+			actual = []string{issue.Reporter}
+		case "labels":
+			actual = issue.Labels
+		case "summary":
+			actual = []string{issue.Summary}
+		case "text":
+			actual = []string{issue.Summary, issue.Description}
+		default:
+			continue
+		}
+		if !matches(actual, operator, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches applies the operator of a condition to the values of a field
+func matches(actual []string, operator, value string) bool {
+	if strings.EqualFold(value, "currentUser()") {
+		value = CurrentUser
+	}
+	empty := len(actual) == 0 || (len(actual) == 1 && actual[0] == "")
+	switch operator {
+	case "is":
+		return empty == isEmpty(value)
+	case "is not":
+		return empty != isEmpty(value)
+	case "~":
+		for _, v := range actual {
+			if strings.Contains(strings.ToLower(v), strings.ToLower(value)) {
+				return true
+			}
+		}
+		return false
+	case "in":
+		for _, option := range strings.Split(strings.Trim(value, "()"), ",") {
+			if matches(actual, "=", unquote(option)) {
+				return true
+			}
+		}
+		return false
+	}
+	equal := false
+	for _, v := range actual {
+		if strings.EqualFold(v, value) {
+			equal = true
+		}
+	}
+	return equal == (operator == "=")
+}
+
+// isEmpty reports whether the JQL value is EMPTY or NULL
+func isEmpty(value string) bool {
+	return strings.EqualFold(value, "empty") || strings.EqualFold(value, "null")
+}
+
+// trimParens removes the grouping parentheses around a condition, keeping those of its value,
+// e.g. (assignee = currentUser())
+func trimParens(condition string) string {
+	condition = strings.TrimSpace(condition)
+	for strings.HasPrefix(condition, "(") {
+		condition = strings.TrimSpace(condition[1:])
+	}
+	for strings.HasSuffix(condition, ")") && strings.Count(condition, ")") > strings.Count(condition, "(") {
+		condition = strings.TrimSpace(strings.TrimSuffix(condition, ")"))
+	}
+	return condition
+}
+
+// unquote removes the quotes around a JQL value
+func unquote(value string) string {
+	return strings.Trim(strings.TrimSpace(value), `"'`)
+}
@@ -0,0 +1,70 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Hosts of code changes
+const (
+	HostGitHub = "GitHub"
+	HostGitLab = "GitLab"
+)
+
+// Change is a GitHub pull request or GitLab merge request identified by its URL
+type Change struct {
+	Host       string // GitHub or GitLab
+	Repository string // owner/name on GitHub, the project's full path on GitLab
+	Number     int
+	URL        string
+}
+
+// Name returns the change's short reference, e.g. acme/api#12 or group/api!12
+func (c Change) Name() string {
+	if c.Host == HostGitLab {
+		return fmt.Sprintf("%s!%d", c.Repository, c.Number)
+	}
+	return fmt.Sprintf("%s#%d", c.Repository, c.Number)
+}
+
+// ParseChangeURL parses the URL of a pull request, https://<host>/<owner>/<name>/pull/<number>,
+// or a merge request, https://<host>/<group>/.../<project>/-/merge_requests/<number>. Hosts other
+// than github.com and gitlab.com, e.g. self-hosted instances, are recognized by the path.
+func ParseChangeURL(raw string) (Change, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return Change{}, fmt.Errorf("%q is not a pull or merge request URL", raw)
+	}
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+
+	// GitLab: the project path may have subgroups, the change follows "/-/merge_requests/"
+	for i := 1; i+2 < len(parts); i++ {
+		if parts[i] == "-" && parts[i+1] == "merge_requests" {
+			number, err := strconv.Atoi(parts[i+2])
+			if err != nil {
+				break
+			}
+			return Change{
+				Host:       HostGitLab,
+				Repository: strings.Join(parts[:i], "/"),
+				Number:     number,
+				URL:        fmt.Sprintf("%s://%s/%s/-/merge_requests/%d", parsed.Scheme, parsed.Host, strings.Join(parts[:i], "/"), number),
+			}, nil
+		}
+	}
+	// GitHub: /<owner>/<name>/pull/<number>, possibly followed by /files and the like
+	if len(parts) >= 4 && parts[2] == "pull" {
+		if number, err := strconv.Atoi(parts[3]); err == nil {
+			repository := parts[0] + "/" + parts[1]
+			return Change{
+				Host:       HostGitHub,
+				Repository: repository,
+				Number:     number,
+				URL:        fmt.Sprintf("%s://%s/%s/pull/%d", parsed.Scheme, parsed.Host, repository, number),
+			}, nil
+		}
+	}
+	return Change{}, fmt.Errorf("%q is not a pull or merge request URL", raw)
+}
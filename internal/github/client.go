@@ -0,0 +1,161 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"jira_helper/internal/httpclient"
+)
+
+// DefaultAPIURL is the base URL of the GitHub REST API, GitHub Enterprise Server serves it at
+// https://<host>/api/v3
+const DefaultAPIURL = "https://api.github.com"
+
+// maxSearchResults caps the pull requests a search returns
+const maxSearchResults = 20
+
+// Client calls the GitHub REST API
+type Client struct {
+	apiURL     string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new Client instance. apiURL defaults to DefaultAPIURL.
+func NewClient(apiURL, token string) *Client {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	return &Client{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		token:      token,
+		httpClient: httpclient.New(httpclient.GitHubTimeout),
+	}
+}
+
+// PullRequest is a pull request found through the REST API
+type PullRequest struct {
+	Repository string `json:"repository"`
+	Number     int    `json:"number"`
+	Title      string `json:"title"`
+	URL        string `json:"url"`
+	State      string `json:"state"` // One of the outcomes: opened, merged or closed
+	Author     string `json:"author"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// SearchPullRequests returns the pull requests that mention the text, typically an issue key,
+// most recently updated first. The qualifiers narrow the search, e.g. "org:acme".
+func (c *Client) SearchPullRequests(ctx context.Context, text, qualifiers string) ([]PullRequest, error) {
+	q := strings.TrimSpace(fmt.Sprintf("%q type:pr %s", text, qualifiers))
+	params := url.Values{"q": {q}, "sort": {"updated"}, "per_page": {strconv.Itoa(maxSearchResults)}}
+	var result struct {
+		Items []struct {
+			Number        int    `json:"number"`
+			Title         string `json:"title"`
+			HTMLURL       string `json:"html_url"`
+			State         string `json:"state"`
+			RepositoryURL string `json:"repository_url"`
+			UpdatedAt     string `json:"updated_at"`
+			User          struct {
+				Login string `json:"login"`
+			} `json:"user"`
+			PullRequest struct {
+				MergedAt string `json:"merged_at"`
+			} `json:"pull_request"`
+		} `json:"items"`
+	}
+	if err := c.call(ctx, "/search/issues?"+params.Encode(), &result); err != nil {
+		return nil, fmt.Errorf("failed to search pull requests mentioning %s: %v", text, err)
+	}
+
+	prs := make([]PullRequest, 0, len(result.Items))
+	for _, item := range result.Items {
+		prs = append(prs, PullRequest{
+			Repository: strings.TrimPrefix(item.RepositoryURL, c.apiURL+"/repos/"),
+			Number:     item.Number,
+			Title:      item.Title,
+			URL:        item.HTMLURL,
+			State:      pullRequestState(item.State, item.PullRequest.MergedAt != ""),
+			Author:     item.User.Login,
+			UpdatedAt:  item.UpdatedAt,
+		})
+	}
+	return prs, nil
+}
+
+// GetPullRequest returns the pull request of the repository, given as owner/name
+func (c *Client) GetPullRequest(ctx context.Context, repository string, number int) (*PullRequest, error) {
+	var result struct {
+		Title     string `json:"title"`
+		HTMLURL   string `json:"html_url"`
+		State     string `json:"state"`
+		Merged    bool   `json:"merged"`
+		UpdatedAt string `json:"updated_at"`
+		User      struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	}
+	if err := c.call(ctx, fmt.Sprintf("/repos/%s/pulls/%d", repository, number), &result); err != nil {
+		return nil, fmt.Errorf("failed to get pull request %s#%d: %v", repository, number, err)
+	}
+	return &PullRequest{
+		Repository: repository,
+		Number:     number,
+		Title:      result.Title,
+		URL:        result.HTMLURL,
+		State:      pullRequestState(result.State, result.Merged),
+		Author:     result.User.Login,
+		UpdatedAt:  result.UpdatedAt,
+	}, nil
+}
+
+// pullRequestState returns the outcome of a pull request in the given API state
+func pullRequestState(state string, merged bool) string {
+	switch {
+	case merged:
+		return OutcomeMerged
+	case state == "closed":
+		return OutcomeClosed
+	default:
+		return OutcomeOpened
+	}
+}
+
+// call sends an authorized GET request to the REST API and decodes the JSON response into out
+func (c *Client) call(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call github: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &apiErr)
+		return fmt.Errorf("github returned %d: %s", resp.StatusCode, apiErr.Message)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode github response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Outcomes of a pull request event that rules and notifications refer to
+const (
+	OutcomeOpened   = "opened"
+	OutcomeReopened = "reopened"
+	OutcomeMerged   = "merged"
+	OutcomeClosed   = "closed"
+)
+
+// issueKeyPattern matches Jira issue keys in titles, branch names and descriptions
+var issueKeyPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-\d+\b`)
+
+// PullRequestEvent is the payload of a pull_request webhook
+type PullRequestEvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+		Merged  bool   `json:"merged"`
+		Draft   bool   `json:"draft"`
+		Head    struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+}
+
+// IsOutcome reports whether outcome is one of the pull request outcomes
+func IsOutcome(outcome string) bool {
+	switch outcome {
+	case OutcomeOpened, OutcomeReopened, OutcomeMerged, OutcomeClosed:
+		return true
+	}
+	return false
+}
+
+// Outcome returns what happened to the pull request, or "" for actions that are not tracked
+func (e *PullRequestEvent) Outcome() string {
+	switch e.Action {
+	case "opened", "ready_for_review":
+		if e.PullRequest.Draft {
+			return ""
+		}
+		return OutcomeOpened
+	case "reopened":
+		return OutcomeReopened
+	case "closed":
+		if e.PullRequest.Merged {
+			return OutcomeMerged
+		}
+		return OutcomeClosed
+	default:
+		return ""
+	}
+}
+
+// IssueKeys returns the distinct Jira issue keys referenced by the title, branch and description
+func (e *PullRequestEvent) IssueKeys() []string {
+	var keys []string
+	seen := map[string]bool{}
+	text := strings.Join([]string{e.PullRequest.Title, e.PullRequest.Head.Ref, e.PullRequest.Body}, "\n")
+	for _, key := range issueKeyPattern.FindAllString(strings.ToUpper(text), -1) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// VerifySignature checks the X-Hub-Signature-256 header against the webhook secret
+func VerifySignature(secret string, body []byte, signature string) error {
+	expected, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return fmt.Errorf("missing sha256 signature")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	actual := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(actual), []byte(expected)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
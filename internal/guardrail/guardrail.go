@@ -0,0 +1,177 @@
+package guardrail
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// removedText replaces the prompt injection found in text the model reads
+const removedText = "[removed by guardrail]"
+
+// injectionPatterns match text that tries to override the bot's instructions or get at its
+// secrets, keyed by the name findings are reported with
+var injectionPatterns = map[string]*regexp.Regexp{
+	"ignore_instructions": regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\s+(all\s+|any\s+|the\s+|your\s+)*(previous|prior|above|earlier|system|original)\s+(instructions?|prompts?|rules|directions|messages)`),
+	"new_instructions":    regexp.MustCompile(`(?i)\b(new|updated|real)\s+(system\s+)?instructions?\s*:`),
+	"reveal_prompt":       regexp.MustCompile(`(?i)\b(reveal|print|show|repeat|output|leak)\b[^.\n]{0,40}\b(system\s+prompt|your\s+instructions|hidden\s+instructions)`),
+	"role_markers":        regexp.MustCompile(`(?im)(<\|?(im_start|im_end|system)\|?>|\[/?INST\]|^\s*system\s*:)`),
+	"impersonation":       regexp.MustCompile(`(?i)\b(you\s+are\s+now|act\s+as|pretend\s+to\s+be)\s+(an?\s+|the\s+)?(admin|administrator|root|system|developer\s+mode|jailbroken)\b`),
+	"token_store":         regexp.MustCompile(`(?i)\b(read|dump|list|print|show|get|fetch|access|export)\b[^.\n]{0,40}\b(token\s+store|tokens?\s+bucket|tokens/|(other|all)\s+users'?\s+(personal\s+)?tokens?|everyone'?s\s+tokens?)`),
+}
+
+// slackUserPattern matches Slack user IDs
+var slackUserPattern = regexp.MustCompile(`\b[UW][A-Z0-9]{8,11}\b`)
+
+// tokenWordPattern matches words that ask for a credential
+var tokenWordPattern = regexp.MustCompile(`(?i)(token|credential|password|secret|\bpat\b)`)
+
+// tokenKeyPattern matches the keys the token store keeps users' tokens under
+var tokenKeyPattern = regexp.MustCompile(`(?i)\btokens/[UW][A-Z0-9]{8,11}`)
+
+// urlPattern matches URLs and bare host names with a port in free text
+var urlPattern = regexp.MustCompile(`(?i)\b(https?|wss?|ftp|file)://[^\s"'<>]+|\b(localhost|\d{1,3}(\.\d{1,3}){3}|\[[0-9a-f:]+\])(:\d+)?\b`)
+
+// internalSuffixes are host name suffixes that only resolve inside the network
+var internalSuffixes = []string{"localhost", ".localhost", ".local", ".internal", ".svc", ".cluster.local", ".corp", ".lan"}
+
+// Guard scans the text the model reads for prompt injection, and blocks tool calls that reach for
+// other users' tokens or for internal endpoints
+type Guard struct {
+	allowedHosts  []string // Hosts tools may reach even though they are internal, e.g. Jira's
+	internalHosts []string // Host names, or suffixes starting with a dot, that are internal as well
+	tokenStores   []string // Names of the token bucket, table or secrets, which tools must not reference
+}
+
+// New creates a Guard. allowedHosts are the internal hosts, or URLs of them, tools legitimately
+// reach such as Jira's, internalHosts extend the built-in internal host names, and tokenStores name where the
+// users' tokens are kept.
+func New(allowedHosts, internalHosts, tokenStores []string) *Guard {
+	g := &Guard{}
+	for _, host := range allowedHosts {
+		if strings.Contains(host, "://") {
+			host = hostOf(host)
+		}
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			g.allowedHosts = append(g.allowedHosts, host)
+		}
+	}
+	for _, host := range internalHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			g.internalHosts = append(g.internalHosts, host)
+		}
+	}
+	for _, store := range tokenStores {
+		if store = strings.TrimSpace(store); store != "" {
+			g.tokenStores = append(g.tokenStores, store)
+		}
+	}
+	return g
+}
+
+// Scan removes prompt injection from the text and returns the cleaned text with the names of the
+// patterns found, sorted
+func (g *Guard) Scan(text string) (string, []string) {
+	if g == nil || text == "" {
+		return text, nil
+	}
+	var findings []string
+	for name, pattern := range injectionPatterns {
+		if pattern.MatchString(text) {
+			findings = append(findings, name)
+			text = pattern.ReplaceAllString(text, removedText)
+		}
+	}
+	sort.Strings(findings)
+	return text, findings
+}
+
+// CheckToolCall returns an error when the arguments reference another user's token, the token
+// store, or an internal endpoint. userID is the user the tool call is made for.
+func (g *Guard) CheckToolCall(userID string, args map[string]interface{}) error {
+	if g == nil {
+		return nil
+	}
+	var texts []string
+	collectStrings(args, &texts)
+	for _, text := range texts {
+		if err := g.checkText(userID, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkText checks one argument value
+func (g *Guard) checkText(userID, text string) error {
+	if tokenKeyPattern.MatchString(text) {
+		return fmt.Errorf("the tool call was blocked because it references the token store")
+	}
+	for _, store := range g.tokenStores {
+		if strings.Contains(text, store) {
+			return fmt.Errorf("the tool call was blocked because it references the token store")
+		}
+	}
+	if tokenWordPattern.MatchString(text) {
+		for _, id := range slackUserPattern.FindAllString(text, -1) {
+			if id != userID {
+				return fmt.Errorf("the tool call was blocked because it references another user's token")
+			}
+		}
+	}
+	for _, match := range urlPattern.FindAllString(text, -1) {
+		if host := hostOf(match); host != "" && g.internal(host) {
+			return fmt.Errorf("the tool call was blocked because it references the internal endpoint %s", host)
+		}
+	}
+	return nil
+}
+
+// internal reports whether the host is internal and not allowed
+func (g *Guard) internal(host string) bool {
+	for _, allowed := range g.allowedHosts {
+		if host == allowed {
+			return false
+		}
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+	}
+	for _, suffix := range append(internalSuffixes, g.internalHosts...) {
+		if host == strings.TrimPrefix(suffix, ".") || (strings.HasPrefix(suffix, ".") && strings.HasSuffix(host, suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostOf returns the lower-case host of a URL or host name with an optional port
+func hostOf(match string) string {
+	if !strings.Contains(match, "://") {
+		match = "//" + match
+	}
+	u, err := url.Parse(match)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// collectStrings gathers the string values of nested tool arguments
+func collectStrings(value interface{}, texts *[]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, item := range v {
+			collectStrings(item, texts)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectStrings(item, texts)
+		}
+	case string:
+		*texts = append(*texts, v)
+	}
+}
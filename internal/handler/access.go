@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/policy"
+
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// accessPolicyCacheTTL is how long the access policy is cached, so a change through the admin
+	// API reaches every instance within it
+	accessPolicyCacheTTL = 30 * time.Second
+	// userGroupsCacheTTL is how long a user's Slack user groups are cached
+	userGroupsCacheTTL = 5 * time.Minute
+	// userGroupsTimeout bounds the lookup of a user's groups
+	userGroupsTimeout = 10 * time.Second
+)
+
+// cachedUserGroups are the Slack user groups of a user, by ID and handle
+type cachedUserGroups struct {
+	groups   []string
+	loadedAt time.Time
+}
+
+// currentAccessPolicy returns the access policy in force: the one set through the admin API, else
+// the configured one. A policy that cannot be loaded keeps the previous one in force.
+func (h *SlackHandler) currentAccessPolicy(ctx context.Context) *policy.AccessPolicy {
+	if h.accessStore == nil {
+		return h.accessPolicy
+	}
+	h.accessMu.Lock()
+	defer h.accessMu.Unlock()
+	if h.accessLoadedAt.IsZero() || time.Since(h.accessLoadedAt) > accessPolicyCacheTTL {
+		if stored, err := h.loadAccessPolicy(ctx); err != nil {
+			logger.GetLogger().Warn("failed to load access policy, keeping the previous one", zap.Error(err))
+		} else {
+			h.accessCache = stored
+		}
+		h.accessLoadedAt = time.Now()
+	}
+	if h.accessCache != nil {
+		return h.accessCache
+	}
+	return h.accessPolicy
+}
+
+// loadAccessPolicy reads the policy set through the admin API, nil if none was set
+func (h *SlackHandler) loadAccessPolicy(ctx context.Context) (*policy.AccessPolicy, error) {
+	data, err := h.accessStore.Load(ctx)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return policy.ParseAccessPolicy(string(data))
+}
+
+// setAccessPolicy stores the policy for every instance
+func (h *SlackHandler) setAccessPolicy(ctx context.Context, accessPolicy *policy.AccessPolicy) error {
+	if h.accessStore == nil {
+		return fmt.Errorf("the access policy can only be changed with a bucket to keep it in")
+	}
+	if err := accessPolicy.Validate(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(accessPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to encode access policy: %v", err)
+	}
+	h.accessMu.Lock()
+	defer h.accessMu.Unlock()
+	if err := h.accessStore.Save(ctx, data); err != nil {
+		return err
+	}
+	h.accessCache, h.accessLoadedAt = accessPolicy, time.Now()
+	return nil
+}
+
+// channelAllowed reports whether the bot answers in the channel
+func (h *SlackHandler) channelAllowed(ctx context.Context, channelID string) bool {
+	if h.currentAccessPolicy(ctx).ChannelAllowed(channelID) {
+		return true
+	}
+	logger.GetLogger().Info("ignoring event in a channel the access policy excludes", zap.String("channel_id", channelID))
+	return false
+}
+
+// userGroups returns the IDs and handles of the Slack user groups the user is in. Groups that
+// cannot be looked up count as none, so the user gets the default role.
+func (h *SlackHandler) userGroups(ctx context.Context, userID string) []string {
+	h.userGroupsMu.Lock()
+	cached, ok := h.userGroupCache[userID]
+	h.userGroupsMu.Unlock()
+	if ok && time.Since(cached.loadedAt) < userGroupsCacheTTL {
+		return cached.groups
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, userGroupsTimeout)
+	defer cancel()
+	all, err := h.slackClient(userID).GetUserGroupsContext(ctx, slack.GetUserGroupsOptionIncludeUsers(true))
+	if err != nil {
+		logger.GetLogger().Warn("failed to look up user groups", zap.String("user_id", userID), zap.Error(err))
+		return cached.groups
+	}
+	var groups []string
+	for _, group := range all {
+		for _, member := range group.Users {
+			if member == userID {
+				groups = append(groups, group.ID, group.Handle)
+				break
+			}
+		}
+	}
+
+	h.userGroupsMu.Lock()
+	defer h.userGroupsMu.Unlock()
+	h.userGroupCache[userID] = cachedUserGroups{groups: groups, loadedAt: time.Now()}
+	return groups
+}
+
+// roleOf returns the role the user's groups give them, "" when tools are not restricted by role
+func (h *SlackHandler) roleOf(ctx context.Context, userID string) string {
+	accessPolicy := h.currentAccessPolicy(ctx)
+	if !accessPolicy.UsesRoles() {
+		return ""
+	}
+	return accessPolicy.RoleOf(h.userGroups(ctx, userID))
+}
+
+// admitByRole refuses tools the user's role does not allow
+func (h *SlackHandler) admitByRole(ctx context.Context, call *ToolCall) error {
+	accessPolicy := h.currentAccessPolicy(ctx)
+	if !accessPolicy.UsesRoles() {
+		return nil
+	}
+	info := call.Conversation
+	role := accessPolicy.RoleOf(h.userGroups(ctx, info.UserID))
+	if err := accessPolicy.CheckTool(role, call.Call.Name, call.Write); err != nil {
+		logger.GetLogger().Info("tool call denied by role",
+			zap.String("tool", call.Call.Name),
+			zap.String("role", role),
+			zap.String("user_id", info.UserID))
+		return &Refusal{Notice: fmt.Sprintf("🔒 %s", err.Error()), Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"jira_helper/internal/config"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/policy"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// adminCommand is a subcommand of the /jira-admin slash command
+type adminCommand struct {
+	description string
+	run         func(c *gin.Context, args []string) (string, error)
+}
+
+// adminCommands returns the subcommands available through /jira-admin
+func (h *SlackHandler) adminCommands() map[string]adminCommand {
+	return map[string]adminCommand{
+		"config": {
+			description: "Show the non-secret bot configuration",
+			run:         h.adminShowConfig,
+		},
+		"audit-verify": {
+			description: "Verify the hash chain of the audit trail",
+			run:         h.adminVerifyAudit,
+		},
+		"approve-writes": {
+			description: "List paused write subjects or approve one again (user:U123 or project:PROJ)",
+			run:         h.adminApproveWrites,
+		},
+		"prompts": {
+			description: "Reload the system prompt templates and list the ones in use",
+			run:         h.adminReloadPrompts,
+		},
+		"dlq": {
+			description: "List events that failed after all retries or in-process",
+			run:         h.adminListDeadLetters,
+		},
+		"dlq-replay": {
+			description: "Send failed events back for processing: a count (default 10) or an event ID",
+			run:         h.adminReplayDeadLetters,
+		},
+		"stats": {
+			description: "Show how many users set up a token, and the paused writes and unavailable tools",
+			run:         h.adminStats,
+		},
+		"delete-user-data": {
+			description: "Delete the token, Jira account, preferences and recent queries of a user",
+			run:         h.adminDeleteUserData,
+		},
+		"token-backup": {
+			description: "Export the personal tokens to a KMS-encrypted backup in S3",
+			run:         h.adminBackupTokens,
+		},
+		"token-restore": {
+			description: "Store the personal tokens of a backup again, replacing the current ones",
+			run:         h.adminRestoreTokens,
+		},
+	}
+}
+
+// isAdmin reports whether the Slack user is configured as an admin, or has the admin role through
+// their user groups
+func (h *SlackHandler) isAdmin(userID string) bool {
+	if userID == "" {
+		return false
+	}
+	return slices.Contains(h.adminUserIDs, userID) || h.roleOf(context.Background(), userID) == policy.RoleAdmin
+}
+
+// authorizeAdmin checks that the user may run the admin action and records the attempt in the audit log
+func (h *SlackHandler) authorizeAdmin(userID, action string) error {
+	allowed := h.isAdmin(userID)
+	auditAdminAction(userID, action, allowed)
+	if !allowed {
+		return fmt.Errorf("user %s is not allowed to run %s", userID, action)
+	}
+	return nil
+}
+
+// RequireAdmin is a middleware that restricts a slash command or endpoint to admins.
+// The Slack user ID is read from the user_id form field sent with slash commands, which anyone
+// can forge, so it must only be used behind the Slack signature check.
+func (h *SlackHandler) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.PostForm("user_id")
+		action := c.FullPath()
+		if fields := strings.Fields(c.PostForm("text")); len(fields) > 0 {
+			action += " " + fields[0]
+		}
+
+		if err := h.authorizeAdmin(userID, action); err != nil {
+			c.JSON(http.StatusOK, gin.H{"text": "⛔ This command is restricted to admins"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// HandleAdminCommand handles the /jira-admin slash command
+func (h *SlackHandler) HandleAdminCommand(c *gin.Context) {
+	args := strings.Fields(c.PostForm("text"))
+	if len(args) == 0 {
+		c.JSON(http.StatusOK, gin.H{"text": h.adminHelp()})
+		return
+	}
+
+	cmd, ok := h.adminCommands()[args[0]]
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"text": fmt.Sprintf("Unknown admin command `%s`\n%s", args[0], h.adminHelp())})
+		return
+	}
+
+	text, err := cmd.run(c, args[1:])
+	if err != nil {
+		logger.GetLogger().Error("admin command failed", zap.String("command", args[0]), zap.Error(err))
+		c.JSON(http.StatusOK, gin.H{"text": fmt.Sprintf("❌ `%s` failed: %s", args[0], err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"text": text})
+}
+
+// adminHelp lists the available admin commands
+func (h *SlackHandler) adminHelp() string {
+	commands := h.adminCommands()
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{"Available admin commands:"}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("• `%s` - %s", name, commands[name].description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// adminShowConfig shows the configuration values that are safe to display
+func (h *SlackHandler) adminShowConfig(_ *gin.Context, _ []string) (string, error) {
+	cfg := config.Get()
+	lines := []string{
+		fmt.Sprintf("Environment: %s", cfg.Environment),
+		fmt.Sprintf("Azure OpenAI endpoint: %s", cfg.AzureOpenAIEndpoint),
+		fmt.Sprintf("Azure OpenAI deployment: %s", cfg.AzureOpenAIDeployment),
+		fmt.Sprintf("Token bucket: %s", cfg.TokenBucketName),
+		fmt.Sprintf("Log level: %s", cfg.LogLevel),
+		fmt.Sprintf("Admins: %s", strings.Join(cfg.AdminUserIDs, ", ")),
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// auditAdminAction writes an audit log entry for an admin action attempt
+func auditAdminAction(userID, action string, allowed bool) {
+	logger.GetLogger().Info("admin action",
+		zap.String("audit", "admin"),
+		zap.String("user_id", userID),
+		zap.String("action", action),
+		zap.Bool("allowed", allowed))
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"jira_helper/internal/audit"
+	"jira_helper/internal/auth"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/policy"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Entries the audit query returns by default and at most
+const (
+	auditQueryLimit    = 100
+	auditQueryMaxLimit = 1000
+)
+
+// featureFlag is a feature in the responses of the admin API
+type featureFlag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// HandleAdminListUsers lists the users with a stored personal token
+func (h *SlackHandler) HandleAdminListUsers(c *gin.Context) {
+	users, err := h.tokenStore.ListUsers()
+	if err != nil {
+		logger.GetLogger().Error("failed to list users", zap.String("caller", auth.Caller(c)), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if users == nil {
+		users = []string{}
+	}
+	sort.Strings(users)
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// HandleAdminRevokeToken deletes the personal token of a user, who has to set it up again
+func (h *SlackHandler) HandleAdminRevokeToken(c *gin.Context) {
+	userID := c.Param("user_id")
+	err := h.tokenStore.DeleteToken(userID)
+	h.auditAdminAPI(c, userID, "revoke_personal_token", nil, err)
+	if err != nil {
+		logger.GetLogger().Error("failed to revoke token", zap.String("user_id", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revoked": userID})
+}
+
+// HandleAdminUserUsage shows a user's quota usage and recent queries
+func (h *SlackHandler) HandleAdminUserUsage(c *gin.Context) {
+	userID := c.Param("user_id")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), homeTimeout)
+	defer cancel()
+
+	response := gin.H{"user_id": userID}
+	if h.quota != nil {
+		usage, err := h.quota.Usage(ctx, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		response["usage"] = usage
+	}
+	if h.activity != nil {
+		queries, err := h.activity.RecentQueries(ctx, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		response["recent_queries"] = queries
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// HandleAdminAudit lists audit entries, filtered by the user, tool, from and to (YYYY-MM-DD,
+// inclusive) query parameters, e.g. GET /admin/audit?user=U123&tool=jira_update_issue&from=2024-05-01
+func (h *SlackHandler) HandleAdminAudit(c *gin.Context) {
+	if h.auditTrail == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "audit trail is not configured"})
+		return
+	}
+	filter := audit.Filter{UserID: c.Query("user"), Action: c.Query("tool"), Limit: auditQueryLimit}
+	for param, day := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "'" + param + "' must be a date like 2024-05-01"})
+			return
+		}
+		*day = parsed
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > auditQueryMaxLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "'limit' must be between 1 and " + strconv.Itoa(auditQueryMaxLimit)})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.auditTrail.Query(c.Request.Context(), filter)
+	if err != nil {
+		logger.GetLogger().Error("failed to query audit trail", zap.String("caller", auth.Caller(c)), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+// HandleAdminListFlags lists the features that can be toggled and whether they are on
+func (h *SlackHandler) HandleAdminListFlags(c *gin.Context) {
+	flags := make([]featureFlag, 0, len(features))
+	for name, description := range features {
+		flags = append(flags, featureFlag{Name: name, Description: description, Enabled: h.featureEnabled(c.Request.Context(), name)})
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	c.JSON(http.StatusOK, gin.H{"flags": flags})
+}
+
+// HandleAdminSetFlag turns a feature on or off, e.g. PUT /admin/flags/streaming {"enabled": false}
+func (h *SlackHandler) HandleAdminSetFlag(c *gin.Context) {
+	if h.flags == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "feature flags are not configured"})
+		return
+	}
+	var request struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format, must be JSON with 'enabled' field"})
+		return
+	}
+	name := c.Param("name")
+	if _, ok := features[name]; !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown feature " + name})
+		return
+	}
+
+	err := h.setFeature(c.Request.Context(), name, *request.Enabled)
+	h.auditAdminAPI(c, "", "set_feature_flag", map[string]interface{}{"feature": name, "enabled": *request.Enabled}, err)
+	if err != nil {
+		logger.GetLogger().Error("failed to set feature flag", zap.String("feature", name), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, featureFlag{Name: name, Description: features[name], Enabled: *request.Enabled})
+}
+
+// HandleAdminGetAccess shows the access policy in force
+func (h *SlackHandler) HandleAdminGetAccess(c *gin.Context) {
+	accessPolicy := h.currentAccessPolicy(c.Request.Context())
+	if accessPolicy == nil {
+		accessPolicy = &policy.AccessPolicy{}
+	}
+	c.JSON(http.StatusOK, accessPolicy)
+}
+
+// HandleAdminSetAccess replaces the access policy, e.g. PUT /admin/access
+// {"allowed_channels": ["C0123", "dm"], "group_roles": {"S0456": "editor", "jira-admins": "admin"}}
+func (h *SlackHandler) HandleAdminSetAccess(c *gin.Context) {
+	if h.accessStore == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "access policy storage is not configured"})
+		return
+	}
+	var accessPolicy policy.AccessPolicy
+	if err := c.ShouldBindJSON(&accessPolicy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format, must be a JSON access policy"})
+		return
+	}
+	if err := accessPolicy.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.setAccessPolicy(c.Request.Context(), &accessPolicy)
+	h.auditAdminAPI(c, "", "set_access_policy", map[string]interface{}{
+		"allowed_channels": accessPolicy.AllowedChannels,
+		"denied_channels":  accessPolicy.DeniedChannels,
+		"group_roles":      accessPolicy.GroupRoles,
+		"default_role":     accessPolicy.DefaultRole,
+		"admin_tools":      accessPolicy.AdminTools,
+	}, err)
+	if err != nil {
+		logger.GetLogger().Error("failed to set access policy", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, accessPolicy)
+}
+
+// auditAdminAPI records a change made through the admin API in the audit trail, attributed to
+// the API key or IAM identity that made it
+func (h *SlackHandler) auditAdminAPI(c *gin.Context, userID, action string, args map[string]interface{}, changeErr error) {
+	caller := auth.Caller(c)
+	logger.GetLogger().Info("admin API action",
+		zap.String("audit", "admin"),
+		zap.String("caller", caller),
+		zap.String("user_id", userID),
+		zap.String("action", action),
+		zap.Bool("succeeded", changeErr == nil))
+	if h.auditTrail == nil {
+		return
+	}
+
+	entry := audit.Entry{
+		UserID: userID,
+		Action: "admin_api:" + action,
+		Args:   map[string]interface{}{"caller": caller},
+		Status: audit.StatusSuccess,
+	}
+	for name, value := range args {
+		entry.Args[name] = value
+	}
+	if changeErr != nil {
+		entry.Status = audit.StatusError
+		entry.Error = changeErr.Error()
+	}
+	if err := h.auditTrail.Record(c.Request.Context(), entry); err != nil {
+		logger.GetLogger().Error("failed to record audit entry", zap.String("action", action), zap.Error(err))
+	}
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"jira_helper/internal/audit"
+	"jira_helper/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// adminStats shows how many users set up a token and what is currently held back
+func (h *SlackHandler) adminStats(c *gin.Context, _ []string) (string, error) {
+	users, err := h.tokenStore.ListUsers()
+	if err != nil {
+		return "", err
+	}
+	paused, err := h.burstDetector.Paused(c.Request.Context())
+	if err != nil {
+		return "", err
+	}
+	circuits := h.openCircuits()
+	lines := []string{
+		fmt.Sprintf("Users with a personal token: %d", len(users)),
+		fmt.Sprintf("Paused writes: %s", joinOrNone(paused)),
+		fmt.Sprintf("Unavailable tools: %s", joinOrNone(circuits)),
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// adminDeleteUserData deletes everything kept about a Slack user: their personal token, Jira
+// account, preferences and recent queries. Audit entries are kept, they cannot be altered.
+func (h *SlackHandler) adminDeleteUserData(c *gin.Context, args []string) (string, error) {
+	if len(args) != 1 {
+		return "Usage: `delete-user-data <user_id>`", nil
+	}
+	// Accept a mention, which Slack sends as <@U123|name>
+	userID, _, _ := strings.Cut(strings.Trim(args[0], "<@>"), "|")
+	ctx := c.Request.Context()
+
+	deleted := []string{}
+	err := h.tokenStore.DeleteToken(userID)
+	if err == nil {
+		deleted = append(deleted, "personal token")
+	}
+	if err == nil && h.identities != nil {
+		if err = h.identities.DeleteIdentity(ctx, userID); err == nil {
+			deleted = append(deleted, "Jira account")
+		}
+	}
+	if err == nil && h.preferences != nil {
+		if err = h.preferences.DeletePreferences(ctx, userID); err == nil {
+			deleted = append(deleted, "preferences")
+		}
+	}
+	if err == nil && h.activity != nil {
+		if err = h.activity.DeleteQueries(ctx, userID); err == nil {
+			deleted = append(deleted, "recent queries")
+		}
+	}
+	h.auditUserDataDeletion(c, userID, deleted, err)
+	if err != nil {
+		return "", fmt.Errorf("deleted %s of <@%s> before failing: %v", joinOrNone(deleted), userID, err)
+	}
+	return fmt.Sprintf("🗑️ Deleted the %s of <@%s>", strings.Join(deleted, ", "), userID), nil
+}
+
+// adminBackupTokens exports the personal tokens to an encrypted backup. The tokens never pass
+// through Slack, the reply only names the backup.
+func (h *SlackHandler) adminBackupTokens(c *gin.Context, _ []string) (string, error) {
+	if h.tokenBackups == nil {
+		return "", fmt.Errorf("token backups need TOKEN_BUCKET_NAME and TOKEN_KMS_KEY_ID")
+	}
+	key, count, err := h.tokenBackups.Backup(c.Request.Context(), h.tokenStore, c.PostForm("user_id"))
+	h.auditAdminChange(c, "admin:token_backup", map[string]interface{}{"backup": key, "tokens": count}, err)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("🔐 Backed up %d personal tokens to `%s`, each encrypted with the KMS key. Restore them with `/jira-admin token-restore %s`.", count, key, key), nil
+}
+
+// adminRestoreTokens stores the personal tokens of a backup again
+func (h *SlackHandler) adminRestoreTokens(c *gin.Context, args []string) (string, error) {
+	if len(args) != 1 {
+		return "Usage: `token-restore <backup>`", nil
+	}
+	if h.tokenBackups == nil {
+		return "", fmt.Errorf("token backups need TOKEN_BUCKET_NAME and TOKEN_KMS_KEY_ID")
+	}
+	count, err := h.tokenBackups.Restore(c.Request.Context(), h.tokenStore, args[0])
+	h.auditAdminChange(c, "admin:token_restore", map[string]interface{}{"backup": args[0], "tokens": count}, err)
+	if err != nil {
+		return "", fmt.Errorf("restored %d tokens before failing: %v", count, err)
+	}
+	return fmt.Sprintf("🔐 Restored %d personal tokens from `%s`", count, args[0]), nil
+}
+
+// auditUserDataDeletion records the deletion of a user's data in the audit trail
+func (h *SlackHandler) auditUserDataDeletion(c *gin.Context, userID string, deleted []string, deleteErr error) {
+	h.auditAdminChange(c, "admin:delete_user_data", map[string]interface{}{"user_id": userID, "deleted": deleted}, deleteErr)
+}
+
+// auditAdminChange records an admin command that changed or exported data in the audit trail
+func (h *SlackHandler) auditAdminChange(c *gin.Context, action string, args map[string]interface{}, changeErr error) {
+	if h.auditTrail == nil {
+		return
+	}
+	entry := audit.Entry{
+		UserID: c.PostForm("user_id"),
+		Action: action,
+		Args:   args,
+		Status: audit.StatusSuccess,
+	}
+	if changeErr != nil {
+		entry.Status = audit.StatusError
+		entry.Error = changeErr.Error()
+	}
+	if err := h.auditTrail.Record(c.Request.Context(), entry); err != nil {
+		logger.GetLogger().Error("failed to record audit entry", zap.String("action", entry.Action), zap.Error(err))
+	}
+}
+
+// joinOrNone joins the items, or says none for an empty list
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"jira_helper/internal/i18n"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/openai"
+
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// approveActionID and cancelActionID are the buttons of an approval request
+	approveActionID = "approve_write"
+	cancelActionID  = "cancel_write"
+
+	// approvalSuffix keeps paused conversations apart from Step Functions checkpoints
+	approvalSuffix = "-approval"
+
+	// approvalTimeout bounds the rest of a conversation resumed by an approval like the Slack handlers do
+	approvalTimeout = 5 * time.Minute
+)
+
+// needsApproval reports whether writes in the channel wait for the user's approval
+func (h *SlackHandler) needsApproval(ctx context.Context, channelID string) bool {
+	return h.approveWrites && h.canPause(ctx, channelID)
+}
+
+// canPause reports whether a conversation in the channel can wait for the user's approval. Only
+// Slack has the buttons, and Query API callers cannot click them.
+func (h *SlackHandler) canPause(ctx context.Context, channelID string) bool {
+	return h.approvals != nil && h.messengerFor(channelID) == nil && toolTraceFrom(ctx) == nil
+}
+
+// requestApproval pauses the conversation before its next write and asks the user to approve it.
+// calls are the write and the tool calls after it, they run once the user approves. A bulk
+// approval covers all the calls, and previews all their changes.
+func (h *SlackHandler) requestApproval(ctx context.Context, conv *conversation, calls []openai.ToolCall, bulk bool, userToken string) error {
+	conv.PendingCalls, conv.PendingBulk = calls, bulk
+	data, err := encodeConversation(conv)
+	if err != nil {
+		return err
+	}
+	id := conv.ID + approvalSuffix
+	if err := h.approvals.Save(ctx, id, data); err != nil {
+		_, _ = h.sendMarkdownMessage(conv.ChannelID, errorMessage(ctx, err), conv.ThreadTS)
+		return fmt.Errorf("failed to save pending approval: %v", err)
+	}
+
+	description := h.pendingDescription(conv)
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, description, false, false), nil, nil),
+	}
+	approve := slack.NewButtonBlockElement(approveActionID, id, slack.NewTextBlockObject(slack.PlainTextType, "Approve", false, false))
+	approve.Style = slack.StylePrimary
+	cancelLabel := "Cancel"
+	if bulk {
+		cancelLabel = "Reject"
+	}
+	cancel := slack.NewButtonBlockElement(cancelActionID, id, slack.NewTextBlockObject(slack.PlainTextType, cancelLabel, false, false))
+	cancel.Style = slack.StyleDanger
+	elements := []slack.BlockElement{approve, cancel}
+	if userToken == "" {
+		// Writes run with the user's own token, which can be set before approving
+		blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType,
+			"🔑 This runs with your personal Jira token. Set it first, then approve.", false, false)))
+		elements = append(elements, slack.NewButtonBlockElement(tokenModalActionID, "", slack.NewTextBlockObject(slack.PlainTextType, "Set personal token", false, false)))
+	}
+	blocks = append(blocks, slack.NewActionBlock("", elements...))
+
+	_, _, err = h.slackClient(conv.ChannelID).PostMessage(
+		conv.ChannelID,
+		slack.MsgOptionText(description, false),
+		slack.MsgOptionBlocks(blocks...),
+		slack.MsgOptionTS(conv.ThreadTS))
+	if err != nil {
+		logger.GetLogger().Error("failed to post approval request", zap.Error(err))
+		return err
+	}
+
+	logger.GetLogger().Info("write waiting for approval",
+		zap.String("conversation_id", conv.ID),
+		zap.String("tool", calls[0].Name),
+		zap.Bool("bulk", bulk),
+		zap.String("user_id", conv.UserID))
+	return nil
+}
+
+// handleApprovalAction approves or cancels a paused write. Only the user who asked may decide.
+// An approved write runs with the user's personal token, and the conversation continues after it.
+func (h *SlackHandler) handleApprovalAction(callback slack.InteractionCallback, action *slack.BlockAction) {
+	if h.approvals == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), approvalTimeout)
+	defer cancel()
+
+	channelID, messageTS, userID := callback.Container.ChannelID, callback.Container.MessageTs, callback.User.ID
+	data, err := h.approvals.Load(ctx, action.Value)
+	if err != nil {
+		h.sendEphemeral(channelID, userID, "This request is no longer pending, it was already handled.")
+		return
+	}
+	conv, err := decodeConversation(data)
+	if err != nil || len(conv.PendingCalls) == 0 {
+		logger.GetLogger().Error("invalid pending approval", zap.String("id", action.Value), zap.Error(err))
+		return
+	}
+	if userID != conv.UserID {
+		h.sendEphemeral(channelID, userID, fmt.Sprintf("Only <@%s> can approve or cancel this.", conv.UserID))
+		return
+	}
+
+	description := h.pendingDescription(conv)
+	if action.ActionID == cancelActionID {
+		if err := h.approvals.Delete(ctx, action.Value); err != nil {
+			logger.GetLogger().Warn("failed to delete pending approval", zap.String("id", action.Value), zap.Error(err))
+		}
+		h.resolveApprovalMessage(channelID, messageTS, description, fmt.Sprintf("🚫 Cancelled by <@%s>", userID))
+		return
+	}
+
+	userToken, err := h.jiraToken(conv.JiraInstance, userID)
+	if err != nil || userToken == "" {
+		h.sendEphemeral(channelID, userID, "Set your personal Jira token with the *Set personal token* button first, then approve again.")
+		return
+	}
+
+	// Remove the approval before running it, so a second click cannot run the write twice
+	if err := h.approvals.Delete(ctx, action.Value); err != nil {
+		logger.GetLogger().Error("failed to delete pending approval", zap.String("id", action.Value), zap.Error(err))
+		return
+	}
+	h.resolveApprovalMessage(channelID, messageTS, description, fmt.Sprintf("✅ Approved by <@%s>", userID))
+
+	answer, err := h.resumeConversation(ctx, conv, userToken)
+	h.completeConversation(ctx, conv, answer, err)
+	if err != nil {
+		logger.GetLogger().Error("failed to resume approved conversation", zap.String("conversation_id", conv.ID), zap.Error(err))
+		return
+	}
+	h.postAnswer(ctx, conv.ChannelID, conv.ThreadTS, conv.UserID, conv.Query, answer, conv.renderedAnswer())
+}
+
+// resumeConversation runs the approved tool call and the calls after it, then continues the
+// conversation until the model answers
+func (h *SlackHandler) resumeConversation(ctx context.Context, conv *conversation, userToken string) (answer string, err error) {
+	ctx, end, err := h.beginConversation(i18n.WithLang(ctx, conv.Language), conv.ChannelID, conv.ThreadTS, conv.UserID)
+	if err != nil {
+		_, _ = h.sendMarkdownMessage(conv.ChannelID, i18n.T(conv.Language, i18n.ShuttingDown), conv.ThreadTS)
+		return "", err
+	}
+	defer end()
+
+	ctx, recordUsage := h.trackUsage(ctx, conv)
+	defer func() { recordUsage(err) }()
+	defer func() {
+		if summary, stopped := stoppedAnswer(ctx, conv); stopped {
+			answer, err = summary, nil
+		}
+	}()
+
+	openAITools, err := h.availableTools(ctx)
+	if err != nil {
+		_, _ = h.sendMarkdownMessage(conv.ChannelID, errorMessage(ctx, err), conv.ThreadTS)
+		return "", err
+	}
+	mcpClient, cleanup, err := h.mcpClientOf(conv.JiraInstance, userToken)
+	if err != nil {
+		_, _ = h.sendMarkdownMessage(conv.ChannelID, errorMessage(ctx, err), conv.ThreadTS)
+		return "", fmt.Errorf("failed to get MCP client: %v", err)
+	}
+	defer cleanup()
+
+	// Progress continues in a new message below the approval
+	calls, approved := conv.PendingCalls, 1
+	if conv.PendingBulk {
+		approved = len(calls)
+	}
+	conv.PendingCalls, conv.PendingBulk = nil, false
+	conv.Timestamp, conv.SlackMessageLines = "", nil
+
+	progress := h.newProgressMessage(conv)
+	paused, err := h.runToolCalls(ctx, conv, calls, mcpClient, userToken, progress, approved)
+	progress.Close()
+	if paused || err != nil {
+		return "", err
+	}
+	answer, done, err := h.finishRound(conv, "")
+	if err != nil || done {
+		return answer, err
+	}
+	return h.continueConversation(ctx, conv, openAITools, mcpClient, userToken)
+}
+
+// resolveApprovalMessage replaces the buttons of an approval request with its outcome
+func (h *SlackHandler) resolveApprovalMessage(channelID, messageTS, description, outcome string) {
+	_, _, _, err := h.slackClient(channelID).UpdateMessage(
+		channelID,
+		messageTS,
+		slack.MsgOptionText(description+"\n"+outcome, false),
+		slack.MsgOptionBlocks(
+			slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, description, false, false), nil, nil),
+			slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, outcome, false, false)),
+		))
+	if err != nil {
+		logger.GetLogger().Error("failed to update approval request", zap.Error(err))
+	}
+}
+
+// sendEphemeral shows a message only to the user
+func (h *SlackHandler) sendEphemeral(channelID, userID, message string) {
+	if _, err := h.slackClient(channelID).PostEphemeral(channelID, userID, slack.MsgOptionText(message, false)); err != nil {
+		logger.GetLogger().Error("failed to post ephemeral message", zap.Error(err))
+	}
+}
+
+// pendingDescription describes the calls of a paused conversation in its approval request
+func (h *SlackHandler) pendingDescription(conv *conversation) string {
+	if conv.PendingBulk {
+		return h.bulkPreview(conv.UserID, conv.PendingCalls)
+	}
+	return approvalDescription(conv.UserID, conv.PendingCalls[0])
+}
+
+// approvalDescription asks the user to approve the tool call, masking any credentials in its arguments
+func approvalDescription(userID string, toolCall openai.ToolCall) string {
+	description := fmt.Sprintf("✋ <@%s>, I'd like to run `%s`. Approve to continue.", userID, toolCall.Name)
+	if len(toolCall.Args) > 0 {
+		description += fmt.Sprintf("\n>%s", printJSON(sanitizeArgs(toolCall.Args)))
+	}
+	return description
+}
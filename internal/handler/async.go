@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"jira_helper/internal/i18n"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/queue"
+	"jira_helper/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-go/slack/slackevents"
+	"go.uber.org/zap"
+)
+
+const (
+	// eventDedupTTL is how long an event ID is remembered, longer than Slack retries a delivery
+	eventDedupTTL = time.Hour
+
+	// eventDedupSize bounds the event IDs remembered in memory
+	eventDedupSize = 10000
+)
+
+// newQueuedEvent wraps a verified event callback for the worker or the dead-letter store
+func newQueuedEvent(ctx context.Context, body []byte) (queue.Event, error) {
+	var callback struct {
+		EventID string `json:"event_id"`
+		Event   struct {
+			Channel  string `json:"channel"`
+			ThreadTS string `json:"thread_ts"`
+			TS       string `json:"ts"`
+		} `json:"event"`
+	}
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return queue.Event{}, fmt.Errorf("failed to decode event callback: %v", err)
+	}
+	if callback.Event.ThreadTS == "" {
+		callback.Event.ThreadTS = callback.Event.TS
+	}
+	return queue.Event{
+		EventID:    callback.EventID,
+		ChannelID:  callback.Event.Channel,
+		ThreadTS:   callback.Event.ThreadTS,
+		ReceivedAt: time.Now().UTC(),
+		Payload:    body,
+		Trace:      tracing.Inject(ctx),
+	}, nil
+}
+
+// duplicateEvent reports whether the event was already received, by this instance or, with an
+// event ledger, by any instance. Duplicates are logged and counted on /metrics.
+func (h *SlackHandler) duplicateEvent(ctx context.Context, event queue.Event) bool {
+	duplicate, source, err := h.eventDedup.Seen(ctx, event.EventID)
+	if err != nil {
+		logger.GetLogger().Warn("failed to check event ledger, deduplicating in memory only",
+			zap.String("event_id", event.EventID), zap.Error(err))
+	}
+	if !duplicate {
+		return false
+	}
+	logger.GetLogger().Info("duplicate slack event skipped",
+		zap.String("event_id", event.EventID),
+		zap.String("channel", event.ChannelID),
+		zap.String("source", source))
+	if h.metricsRegistry != nil {
+		h.metricsRegistry.RecordDuplicateEvent(source)
+	}
+	return true
+}
+
+// enqueueEvent hands the event to the worker
+func (h *SlackHandler) enqueueEvent(ctx context.Context, event queue.Event) error {
+	if err := h.eventQueue.Publish(ctx, event); err != nil {
+		return err
+	}
+
+	logger.GetLogger().Info("slack event enqueued",
+		zap.String("event_id", event.EventID),
+		zap.String("channel", event.ChannelID))
+	return nil
+}
+
+// ProcessQueuedEvent runs the full conversation for an event received from the queue
+func (h *SlackHandler) ProcessQueuedEvent(ctx context.Context, event queue.Event) error {
+	eventsAPIEvent, err := slackevents.ParseEvent(event.Payload, slackevents.OptionNoVerifyToken())
+	if err != nil {
+		return fmt.Errorf("failed to parse queued slack event: %v", err)
+	}
+
+	logger.GetLogger().Info("processing queued slack event",
+		zap.String("event_id", event.EventID),
+		zap.Duration("queue_delay", time.Since(event.ReceivedAt)))
+	// Continue the trace of the request that enqueued the event
+	return h.dispatchCallbackEvent(tracing.Extract(ctx, event.Trace), eventsAPIEvent)
+}
+
+// NotifyFailedEvent tells the originating thread that its message failed. Before the last
+// attempt the queue retries it; afterwards it sits in the dead-letter queue and admins are alerted.
+// Failures that are not retried are not reported.
+func (h *SlackHandler) NotifyFailedEvent(event queue.Event, err error, attempt, maxAttempts int) {
+	if event.ChannelID == "" || !RetryableFailure(err) {
+		return
+	}
+
+	lang := eventLanguage(event)
+	if attempt < maxAttempts {
+		_, _ = h.sendMarkdownMessage(event.ChannelID, i18n.T(lang, i18n.EventRetrying, attempt, maxAttempts, event.EventID), event.ThreadTS)
+		return
+	}
+
+	_, _ = h.sendMarkdownMessage(event.ChannelID, i18n.T(lang, i18n.EventKept, event.EventID), event.ThreadTS)
+	h.alertAdmins(fmt.Sprintf("📮 Event `%s` from <#%s> failed %d times and was moved to the dead-letter queue. Use `/jira-admin dlq` to inspect and `/jira-admin dlq-replay` to retry.", event.EventID, event.ChannelID, attempt))
+}
+
+// adminListDeadLetters shows the events waiting in the dead-letter queue and the failures stored
+// by the instances that received them
+func (h *SlackHandler) adminListDeadLetters(c *gin.Context, _ []string) (string, error) {
+	if h.deadLetters == nil && h.failedEvents == nil {
+		return "No dead-letter queue is configured", nil
+	}
+	var letters []queue.DeadLetter
+	if h.deadLetters != nil {
+		queued, err := h.deadLetters.Peek(c.Request.Context(), 20)
+		if err != nil {
+			return "", err
+		}
+		letters = append(letters, queued...)
+	}
+	if h.failedEvents != nil {
+		stored, err := h.failedEvents.Peek(c.Request.Context(), 20)
+		if err != nil {
+			return "", err
+		}
+		letters = append(letters, stored...)
+	}
+	if len(letters) == 0 {
+		return "✅ The dead-letter queue is empty", nil
+	}
+
+	lines := []string{fmt.Sprintf("%d failed event(s):", len(letters))}
+	for _, letter := range letters {
+		if letter.Err != nil {
+			lines = append(lines, fmt.Sprintf("• `%s` %s", letter.MessageID, letter.Err.Error()))
+			continue
+		}
+		line := fmt.Sprintf("• `%s` in <#%s>, received %s, replayed %d time(s)",
+			letter.Event.EventID, letter.Event.ChannelID, letter.Event.ReceivedAt.Format(time.RFC3339), letter.Event.Replays)
+		if letter.Reason != "" {
+			line += fmt.Sprintf(": %s", letter.Reason)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// adminReplayDeadLetters sends failed events back for processing, e.g. `dlq-replay 5`, or the
+// stored failure of one event by the reference the user was given, e.g. `dlq-replay Ev0123ABC`
+func (h *SlackHandler) adminReplayDeadLetters(c *gin.Context, args []string) (string, error) {
+	if h.deadLetters == nil && h.failedEvents == nil {
+		return "No dead-letter queue is configured", nil
+	}
+	ctx := c.Request.Context()
+	count := 10
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		switch {
+		case err == nil && n >= 1:
+			count = n
+		case eventIDPattern.MatchString(args[0]) && h.failedEvents != nil:
+			found, err := h.failedEvents.ReplayEvent(ctx, args[0], h.replayTarget())
+			if err != nil {
+				return "", err
+			}
+			if !found {
+				return fmt.Sprintf("No stored failure of event `%s`", args[0]), nil
+			}
+			return fmt.Sprintf("🔁 Replayed event `%s`", args[0]), nil
+		default:
+			return "Usage: `dlq-replay [count | event ID]`", nil
+		}
+	}
+
+	replayed := 0
+	if h.deadLetters != nil {
+		n, err := h.deadLetters.Replay(ctx, count)
+		replayed += n
+		if err != nil {
+			return "", fmt.Errorf("replayed %d event(s) before failing: %v", replayed, err)
+		}
+	}
+	if h.failedEvents != nil && replayed < count {
+		n, err := h.failedEvents.Replay(ctx, count-replayed, h.replayTarget())
+		replayed += n
+		if err != nil {
+			return "", fmt.Errorf("replayed %d event(s) before failing: %v", replayed, err)
+		}
+	}
+	return fmt.Sprintf("🔁 Replayed %d event(s)", replayed), nil
+}
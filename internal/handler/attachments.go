@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/service/openai"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	downloadAttachmentsTool = "jira_download_attachments"
+
+	// addAttachmentAction is the audited action of files attached from Slack
+	addAttachmentAction = "jira_add_attachment"
+)
+
+// attachCommandPattern matches messages asking to attach their files to an issue, e.g.
+// "attach to PROJ-123"
+var attachCommandPattern = regexp.MustCompile(`(?i)\battach\b.*?\b([A-Z][A-Z0-9_]+-\d+)\b`)
+
+// attachmentPolicy limits the files copied between Jira and Slack
+type attachmentPolicy struct {
+	maxBytes int64    // Largest file copied, 0 for no limit
+	types    []string // Allowed file extensions without the dot, any type when empty
+}
+
+// check returns why the file may not be copied, or nil if it may
+func (p attachmentPolicy) check(name string, size int64) error {
+	if p.maxBytes > 0 && size > p.maxBytes {
+		return fmt.Errorf("%s is larger than %d MB", name, p.maxBytes>>20)
+	}
+	extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	if len(p.types) > 0 && !slices.Contains(p.types, extension) {
+		return fmt.Errorf("%s is not an allowed file type", name)
+	}
+	return nil
+}
+
+// downloadedAttachments is the result of jira_download_attachments
+type downloadedAttachments struct {
+	Downloaded []struct {
+		Filename string `json:"filename"`
+		Path     string `json:"path"`
+	} `json:"downloaded"`
+}
+
+// downloadedAttachmentPaths returns the local paths of the files a jira_download_attachments
+// call saved
+func downloadedAttachmentPaths(toolCall openai.ToolCall, result *mcp.CallToolResult) []string {
+	var parsed downloadedAttachments
+	if err := json.Unmarshal([]byte(printToolResult(result)), &parsed); err != nil {
+		return nil
+	}
+	targetDir, _ := toolCall.Args["target_dir"].(string)
+	var paths []string
+	for _, file := range parsed.Downloaded {
+		switch {
+		case file.Path != "":
+			paths = append(paths, file.Path)
+		case file.Filename != "" && targetDir != "":
+			paths = append(paths, filepath.Join(targetDir, file.Filename))
+		}
+	}
+	return paths
+}
+
+// shareDownloadedAttachments uploads the files a jira_download_attachments call saved to the
+// thread, so the user gets them instead of only the paths on the server
+func (h *SlackHandler) shareDownloadedAttachments(ctx context.Context, conv *conversation, toolCall openai.ToolCall, result *mcp.CallToolResult) {
+	if h.messengerFor(conv.ChannelID) != nil {
+		return
+	}
+	var skipped []string
+	for _, path := range downloadedAttachmentPaths(toolCall, result) {
+		// An MCP server on another host saves the files where they cannot be read
+		info, err := os.Stat(path)
+		if err != nil {
+			logger.GetLogger().Debug("downloaded attachment is not readable", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		if err := h.attachments.check(info.Name(), info.Size()); err != nil {
+			skipped = append(skipped, err.Error())
+			continue
+		}
+		if err := h.uploadToThread(ctx, conv.ChannelID, conv.ThreadTS, path, info.Size()); err != nil {
+			logger.GetLogger().Error("failed to upload attachment", zap.String("path", path), zap.Error(err))
+			skipped = append(skipped, fmt.Sprintf("%s could not be uploaded", info.Name()))
+		}
+	}
+	if len(skipped) > 0 {
+		_, _ = h.sendMarkdownMessage(conv.ChannelID, "⚠️ Some attachments were not shared:\n• "+strings.Join(skipped, "\n• "), conv.ThreadTS)
+	}
+}
+
+// uploadToThread uploads the local file to the thread
+func (h *SlackHandler) uploadToThread(ctx context.Context, channelID, threadTS, path string, size int64) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	name := filepath.Base(path)
+	_, err = h.slackClient(channelID).UploadFileV2Context(ctx, slack.UploadFileV2Parameters{
+		Reader:          file,
+		FileSize:        int(size),
+		Filename:        name,
+		Title:           name,
+		Channel:         channelID,
+		ThreadTimestamp: threadTS,
+	})
+	return err
+}
+
+// handleAttachRequest attaches the files shared with a message like "attach to PROJ-123" to the
+// issue, and reports whether the message was such a request. Messages without files are left
+// to the model.
+func (h *SlackHandler) handleAttachRequest(ctx context.Context, channelID, threadTS, ts, userID, text string) bool {
+	match := attachCommandPattern.FindStringSubmatch(text)
+	if match == nil || h.messengerFor(channelID) != nil {
+		return false
+	}
+	files, err := h.sharedFiles(channelID, threadTS, ts)
+	if err != nil {
+		logger.GetLogger().Warn("failed to get shared files", zap.String("channel", channelID), zap.Error(err))
+		return false
+	}
+	if len(files) == 0 {
+		return false
+	}
+
+	if threadTS == "" {
+		threadTS = ts
+	}
+	reply := h.attachSharedFiles(ctx, channelID, userID, strings.ToUpper(match[1]), files)
+	_, _ = h.sendMarkdownMessage(channelID, reply, threadTS)
+	return true
+}
+
+// sharedFiles returns the files shared with the message. Events do not carry them in the same
+// shape across API versions, so the message is read back.
+func (h *SlackHandler) sharedFiles(channelID, threadTS, ts string) ([]slack.File, error) {
+	root := threadTS
+	if root == "" {
+		root = ts
+	}
+	messages, _, _, err := h.slackClient(channelID).GetConversationReplies(&slack.GetConversationRepliesParameters{
+		ChannelID: channelID,
+		Timestamp: root,
+		Oldest:    ts,
+		Latest:    ts,
+		Inclusive: true,
+		Limit:     2, // The thread's root message comes first
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, message := range messages {
+		if message.Timestamp == ts {
+			return message.Files, nil
+		}
+	}
+	return nil, nil
+}
+
+// attachSharedFiles copies the files to the issue with the user's personal token and describes
+// the outcome
+func (h *SlackHandler) attachSharedFiles(ctx context.Context, channelID, userID, key string, files []slack.File) string {
+	if h.jiraURL == "" {
+		return "❌ Attaching files requires `JIRA_URL` to be configured."
+	}
+	token, err := h.getUserPersonalToken(userID)
+	if err != nil || token == "" {
+		return "❌ Set your personal token first with `/setup-token` to attach files."
+	}
+	if err := h.checkCommandScope(channelID, projectOfToolCall(map[string]interface{}{"issue_key": key})); err != nil {
+		return fmt.Sprintf("❌ %s", err.Error())
+	}
+	client, err := jira.NewClient(h.jiraURL, token)
+	if err != nil {
+		return fmt.Sprintf("❌ %s", err.Error())
+	}
+
+	var lines []string
+	for _, file := range files {
+		if err := h.attachments.check(file.Name, int64(file.Size)); err != nil {
+			lines = append(lines, fmt.Sprintf("⚠️ Skipped %s", err.Error()))
+			continue
+		}
+		// Attachments are paused, audited and counted like the write tools
+		toolCall := openai.ToolCall{Name: addAttachmentAction, Args: map[string]interface{}{"issue_key": key, "filename": file.Name}}
+		if err := h.checkWriteBurst(ctx, userID, toolCall); err != nil {
+			lines = append(lines, fmt.Sprintf("⏸️ %s", err.Error()))
+			break
+		}
+
+		var content bytes.Buffer
+		if err := h.slackClient(channelID).GetFileContext(ctx, file.URLPrivateDownload, &content); err != nil {
+			logger.GetLogger().Error("failed to download shared file", zap.String("file_id", file.ID), zap.Error(err))
+			lines = append(lines, fmt.Sprintf("❌ Failed to download %s from Slack", file.Name))
+			continue
+		}
+		_, err := client.AddAttachment(ctx, key, file.Name, &content)
+		h.recordAudit(ctx, userID, channelID, toolCall, nil, err)
+		h.observeWrite(ctx, userID, channelID, toolCall)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("❌ Failed to attach %s: %s", file.Name, jiraErrorMessage(err)))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("📎 Attached %s to %s", file.Name, h.issueLink(key)))
+	}
+	return strings.Join(lines, "\n")
+}
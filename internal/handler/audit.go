@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"jira_helper/internal/audit"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/openai"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// recordAudit appends a Jira write operation to the audit trail.
+// Failures are logged rather than returned so auditing never interrupts the conversation.
+func (h *SlackHandler) recordAudit(ctx context.Context, userID, channelID string, toolCall openai.ToolCall, result *mcp.CallToolResult, callErr error) {
+	if h.auditTrail == nil {
+		return
+	}
+
+	entry := audit.Entry{
+		UserID:    userID,
+		ChannelID: channelID,
+		Action:    toolCall.Name,
+		Args:      sanitizeArgs(toolCall.Args),
+		Status:    audit.StatusSuccess,
+	}
+	if callErr != nil {
+		entry.Status = audit.StatusError
+		entry.Error = callErr.Error()
+	} else if result != nil && result.IsError {
+		entry.Status = audit.StatusError
+		entry.Error = printToolResult(result)
+	}
+
+	if err := h.auditTrail.Record(ctx, entry); err != nil {
+		logger.GetLogger().Error("failed to record audit entry",
+			zap.String("tool", toolCall.Name),
+			zap.String("user_id", userID),
+			zap.Error(err))
+	}
+}
+
+// auditTokenChange records the removal or rotation of a personal token in the audit trail
+func (h *SlackHandler) auditTokenChange(ctx context.Context, userID, action string, changeErr error) {
+	if h.auditTrail == nil {
+		return
+	}
+	entry := audit.Entry{UserID: userID, Action: action, Status: audit.StatusSuccess}
+	if changeErr != nil {
+		entry.Status = audit.StatusError
+		entry.Error = changeErr.Error()
+	}
+	if err := h.auditTrail.Record(ctx, entry); err != nil {
+		logger.GetLogger().Error("failed to record audit entry", zap.String("action", action), zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// adminVerifyAudit verifies the integrity of the audit trail
+func (h *SlackHandler) adminVerifyAudit(c *gin.Context, _ []string) (string, error) {
+	if h.auditTrail == nil {
+		return "Audit trail is not configured", nil
+	}
+	count, err := h.auditTrail.Verify(c.Request.Context())
+	if err != nil {
+		return "", fmt.Errorf("audit trail is broken after %d valid entries: %v", count, err)
+	}
+	return fmt.Sprintf("✅ Audit trail intact, %d entries verified", count), nil
+}
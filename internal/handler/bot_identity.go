@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// botIdentityTTL is how long the bot user ID from AuthTest is reused before it is refreshed
+const botIdentityTTL = time.Hour
+
+// botUserID returns the bot's Slack user ID in the workspace of the channel. For the workspace of
+// the configured bot token, AuthTest is only called when the cached value is missing or stale, and
+// a stale value is kept if the refresh fails.
+func (h *SlackHandler) botUserID(channelID string) (string, error) {
+	if userID := h.workspaces.botUserIDFor(channelID); userID != "" {
+		return userID, nil
+	}
+
+	h.botMu.RLock()
+	userID, resolvedAt := h.botID, h.botIDResolvedAt
+	h.botMu.RUnlock()
+
+	if userID != "" && time.Since(resolvedAt) < botIdentityTTL {
+		return userID, nil
+	}
+
+	refreshed, err := h.resolveBotIdentity()
+	if err != nil {
+		if userID != "" {
+			logger.GetLogger().Warn("failed to refresh bot identity, using cached value", zap.Error(err))
+			return userID, nil
+		}
+		return "", err
+	}
+	return refreshed, nil
+}
+
+// resolveBotIdentity calls AuthTest and caches the bot user ID
+func (h *SlackHandler) resolveBotIdentity() (string, error) {
+	botInfo, err := h.slackClient("").AuthTest()
+	if err != nil {
+		return "", fmt.Errorf("failed to get bot info: %v", err)
+	}
+
+	h.botMu.Lock()
+	h.botID = botInfo.UserID
+	h.botIDResolvedAt = time.Now()
+	h.botMu.Unlock()
+
+	logger.GetLogger().Info("resolved bot identity", zap.String("bot_user_id", botInfo.UserID))
+	return botInfo.UserID, nil
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"jira_helper/internal/service/jira"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// issueKeyArgs lists the tool arguments that name an issue the tool reads, changes or links to
+var issueKeyArgs = []string{"issue_key", "epic_key", "parent", "parent_key", "inward_issue_key", "outward_issue_key"}
+
+// nestedFieldArgs lists the tool arguments that carry issue fields, as an object or a JSON string
+var nestedFieldArgs = []string{"fields", "additional_fields"}
+
+// issueKeysOf returns the issues named in the arguments, including parents set in nested fields, sorted
+func issueKeysOf(args map[string]interface{}) []string {
+	keys := map[string]bool{}
+	collectIssueKeys(args, keys)
+
+	var sorted []string
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// collectIssueKeys adds the issues named in the arguments to keys
+func collectIssueKeys(args map[string]interface{}, keys map[string]bool) {
+	for name, value := range args {
+		if slices.Contains(issueKeyArgs, name) {
+			switch v := value.(type) {
+			case string:
+				if key := strings.ToUpper(strings.TrimSpace(v)); key != "" {
+					keys[key] = true
+				}
+			case map[string]interface{}:
+				// e.g. "parent": {"key": "PROJ-1"}
+				collectIssueKeys(map[string]interface{}{name: v["key"]}, keys)
+			}
+			continue
+		}
+		if !slices.Contains(nestedFieldArgs, name) {
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			collectIssueKeys(v, keys)
+		case string:
+			var fields map[string]interface{}
+			if json.Unmarshal([]byte(v), &fields) == nil {
+				collectIssueKeys(fields, keys)
+			}
+		}
+	}
+}
+
+// boundaryMiddleware refuses tool calls on issues of restricted projects the channel may not see
+func (h *SlackHandler) boundaryMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, call *ToolCall) (*mcp.CallToolResult, error) {
+		if err := h.checkIssueBoundaries(ctx, call); err != nil {
+			return nil, &Refusal{Notice: fmt.Sprintf("🔒 %s", err.Error()), Err: err}
+		}
+		return next(ctx, call)
+	}
+}
+
+// checkIssueBoundaries checks the project of every issue the tool call names. The project is
+// looked up in Jira instead of taken from the key, since an issue moved to another project still
+// answers to its old key.
+func (h *SlackHandler) checkIssueBoundaries(ctx context.Context, call *ToolCall) error {
+	channelID := call.Conversation.ChannelID
+	if len(h.boundaries.Restricted(channelID)) == 0 {
+		return nil
+	}
+	keys := issueKeysOf(call.Call.Args)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	token := call.userToken
+	if token == "" {
+		token = h.defaultJiraToken
+	}
+	client, err := jira.NewClient(h.jiraURLOf(call.conv.JiraInstance), token)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		issue, err := client.GetIssue(ctx, key, "project")
+		if err != nil {
+			return fmt.Errorf("cannot tell which project %s belongs to: %v", key, jiraErrorMessage(err))
+		}
+		if issue.Fields.Project == nil {
+			return fmt.Errorf("cannot tell which project %s belongs to", key)
+		}
+		project := issue.Fields.Project.Key
+		if violations := h.boundaries.Violations(channelID, "", project); len(violations) > 0 {
+			return fmt.Errorf("%s belongs to restricted project %s, which cannot be used in this channel", key, project)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"jira_helper/internal/policy"
+	"jira_helper/internal/service/openai"
+)
+
+func TestIssueKeysOf(t *testing.T) {
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want []string
+	}{
+		{"none", map[string]interface{}{"summary": "SEC-1 in text is not an argument"}, nil},
+		{"issue key", map[string]interface{}{"issue_key": " sec-1 "}, []string{"SEC-1"}},
+		{"links", map[string]interface{}{"inward_issue_key": "A-1", "outward_issue_key": "SEC-2"}, []string{"A-1", "SEC-2"}},
+		{"parent object", map[string]interface{}{"fields": map[string]interface{}{"parent": map[string]interface{}{"key": "SEC-3"}}}, []string{"SEC-3"}},
+		{"fields as json", map[string]interface{}{"additional_fields": `{"parent": "SEC-4", "epic_key": "A-2"}`}, []string{"A-2", "SEC-4"}},
+		{"fields not json", map[string]interface{}{"additional_fields": "parent SEC-5"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := issueKeysOf(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("issueKeysOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopeToolCallBoundaries(t *testing.T) {
+	boundaries, err := policy.ParseBoundaries(`[{"project": "SEC", "level": "restricted", "allowed_channels": ["C0SEC"]}]`)
+	if err != nil {
+		t.Fatalf("ParseBoundaries() error = %v", err)
+	}
+	h := &SlackHandler{boundaries: boundaries, channelProjects: map[string][]string{"C1": {"PROJ"}}}
+
+	tests := []struct {
+		name      string
+		channelID string
+		toolCall  openai.ToolCall
+		wantJQL   interface{}
+		wantErr   bool
+	}{
+		{"search excludes restricted", "C0GEN", openai.ToolCall{Name: "jira_search", Args: map[string]interface{}{"jql": "project = SEC OR status = Done"}},
+			`project not in ("SEC") AND (project = SEC OR status = Done)`, false},
+		{"search in allowed channel", "C0SEC", openai.ToolCall{Name: "jira_search", Args: map[string]interface{}{"jql": "project = SEC"}},
+			"project = SEC", false},
+		{"scoped channel", "C1", openai.ToolCall{Name: "jira_search", Args: map[string]interface{}{"jql": "key = SEC-1 ORDER BY created"}},
+			`project not in ("SEC") AND (project in ("PROJ") AND (key = SEC-1)) ORDER BY created`, false},
+		{"restricted project key", "C0GEN", openai.ToolCall{Name: "jira_create_issue", Args: map[string]interface{}{"project_key": "sec"}}, nil, true},
+		{"project outside scope", "C1", openai.ToolCall{Name: "jira_create_issue", Args: map[string]interface{}{"project_key": "OTHER"}}, nil, true},
+		{"escaping jql", "C0GEN", openai.ToolCall{Name: "jira_search", Args: map[string]interface{}{"jql": "status = Done) OR (project = SEC"}}, "status = Done) OR (project = SEC", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toolCall := tt.toolCall
+			err := h.scopeToolCall(tt.channelID, &toolCall)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("scopeToolCall() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := toolCall.Args["jql"]; got != tt.wantJQL {
+				t.Errorf("jql = %v, want %v", got, tt.wantJQL)
+			}
+		})
+	}
+}
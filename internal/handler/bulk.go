@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"jira_helper/internal/service/openai"
+)
+
+// maxPreviewRows bounds the rows of a bulk preview, Slack truncates long messages
+const maxPreviewRows = 30
+
+// bulkListArgs are the arguments that hold the issues a single call changes
+var bulkListArgs = []string{"issues", "issue_keys"}
+
+// needsBulkApproval reports whether the tool calls change more issues than the bulk threshold,
+// in which case they only run once the user approves a preview of all of them
+func (h *SlackHandler) needsBulkApproval(ctx context.Context, channelID string, toolCalls []openai.ToolCall) bool {
+	return h.bulkThreshold > 0 && h.canPause(ctx, channelID) && h.issuesModified(toolCalls) > h.bulkThreshold
+}
+
+// issuesModified estimates how many Jira issues the write calls change
+func (h *SlackHandler) issuesModified(toolCalls []openai.ToolCall) int {
+	count := 0
+	for _, toolCall := range toolCalls {
+		if strings.HasPrefix(toolCall.Name, "jira_") && h.toolPolicy.IsWrite(toolCall.Name) {
+			count += max(len(bulkItems(toolCall)), 1)
+		}
+	}
+	return count
+}
+
+// bulkItems returns the issues a batch call changes, e.g. the issues of jira_batch_create_issues.
+// They may be a list, a JSON encoded list or comma separated keys.
+func bulkItems(toolCall openai.ToolCall) []interface{} {
+	for _, arg := range bulkListArgs {
+		switch value := toolCall.Args[arg].(type) {
+		case []interface{}:
+			return value
+		case string:
+			var items []interface{}
+			if err := json.Unmarshal([]byte(value), &items); err == nil {
+				return items
+			}
+			for _, key := range splitKeys(value) {
+				items = append(items, key)
+			}
+			return items
+		}
+	}
+	return nil
+}
+
+// splitKeys splits comma separated issue keys
+func splitKeys(value string) []string {
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// bulkPreview describes the changes of the write calls as a table, one row per issue
+func (h *SlackHandler) bulkPreview(userID string, toolCalls []openai.ToolCall) string {
+	var rows [][3]string
+	for _, toolCall := range toolCalls {
+		if !h.toolPolicy.IsWrite(toolCall.Name) {
+			continue
+		}
+		items := bulkItems(toolCall)
+		if len(items) == 0 {
+			issue, _ := toolCall.Args["issue_key"].(string)
+			if issue == "" {
+				issue = projectKeyArg(toolCall.Args)
+			}
+			rows = append(rows, [3]string{toolCall.Name, issue, changeSummary(toolCall.Args, "issue_key")})
+			continue
+		}
+		for _, item := range items {
+			switch item := item.(type) {
+			case map[string]interface{}:
+				issue, _ := item["issue_key"].(string)
+				if issue == "" {
+					issue = projectKeyArg(item) + " (new)"
+				}
+				rows = append(rows, [3]string{toolCall.Name, issue, changeSummary(item, "issue_key", "project_key")})
+			default:
+				rows = append(rows, [3]string{toolCall.Name, fmt.Sprint(item), changeSummary(toolCall.Args, bulkListArgs...)})
+			}
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "✋ <@%s>, this changes %d issues. Nothing runs until you approve:\n```\n", userID, len(rows))
+	table := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "#\tTool\tIssue\tChange")
+	for i, row := range rows {
+		if i == maxPreviewRows {
+			fmt.Fprintf(table, "…\t%d more\t\t\n", len(rows)-maxPreviewRows)
+			break
+		}
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\n", i+1, row[0], row[1], row[2])
+	}
+	_ = table.Flush()
+	b.WriteString("```")
+	return b.String()
+}
+
+// changeSummary renders the arguments of a change, without the ones identifying the issue, on
+// a single line
+func changeSummary(args map[string]interface{}, skip ...string) string {
+	sanitized := sanitizeArgs(args)
+	names := make([]string, 0, len(sanitized))
+	for name := range sanitized {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		if slices.Contains(skip, name) {
+			continue
+		}
+		value, ok := sanitized[name].(string)
+		if !ok {
+			value = printJSON(sanitized[name])
+		}
+		parts = append(parts, fmt.Sprintf("%s=%s", name, strings.Join(strings.Fields(value), " ")))
+	}
+	summary := strings.Join(parts, ", ")
+	if runes := []rune(summary); len(runes) > 60 {
+		summary = string(runes[:59]) + "…"
+	}
+	return summary
+}
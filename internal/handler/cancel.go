@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"jira_helper/internal/i18n"
+	"jira_helper/internal/logger"
+
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// stopConversationActionID is the button that stops the running conversation of a thread
+	stopConversationActionID = "stop_conversation"
+)
+
+// errStoppedByUser is the cancellation cause of conversations the user stopped
+var errStoppedByUser = errors.New("conversation stopped by the user")
+
+// stopWords are the messages that stop the user's running conversation
+var stopWords = []string{"stop", "cancel", "停止", "取消"}
+
+// isStopCommand reports whether the message, without mentions, is a stop word
+func isStopCommand(text string) bool {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		if !strings.HasPrefix(word, "<@") {
+			words = append(words, word)
+		}
+	}
+	command := strings.ToLower(strings.TrimRight(strings.Join(words, " "), ".!。！"))
+	return slices.Contains(stopWords, command)
+}
+
+// handleStopRequest stops the user's running conversation when the message asks for it, and
+// reports whether the message was a stop request. Replies in a thread stop that thread's
+// conversation; messages addressed to the bot outside a thread stop the user's conversations
+// in the channel.
+func (h *SlackHandler) handleStopRequest(channelID, threadTS, userID, text string, addressed bool) bool {
+	if !isStopCommand(text) || (threadTS == "" && !addressed) {
+		return false
+	}
+	if !h.stopConversation(channelID, threadTS, userID) {
+		logger.GetLogger().Info("no running conversation to stop",
+			zap.String("channel", channelID),
+			zap.String("thread_ts", threadTS),
+			zap.String("user_id", userID))
+	}
+	return true
+}
+
+// stopConversation cancels the user's running conversations in the thread, or in the whole
+// channel without a thread, and reports whether there was one. Only the user who started a
+// conversation may stop it.
+func (h *SlackHandler) stopConversation(channelID, threadTS, userID string) bool {
+	h.activeMu.Lock()
+	defer h.activeMu.Unlock()
+
+	stopped := false
+	for conversation := range h.active {
+		if conversation.channelID != channelID || conversation.userID != userID {
+			continue
+		}
+		if threadTS != "" && conversation.threadTS != threadTS {
+			continue
+		}
+		logger.GetLogger().Info("stopping conversation",
+			zap.String("channel", conversation.channelID),
+			zap.String("thread_ts", conversation.threadTS),
+			zap.String("user_id", userID))
+		conversation.cancel(errStoppedByUser)
+		stopped = true
+	}
+	return stopped
+}
+
+// stopRequested reports whether the user stopped the conversation running with ctx
+func stopRequested(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errStoppedByUser)
+}
+
+// stoppedAnswer ends a conversation the user stopped. Its answer is the summary of the steps
+// completed so far, and its unfinished messages are not remembered.
+func stoppedAnswer(ctx context.Context, conv *conversation) (string, bool) {
+	if !stopRequested(ctx) {
+		return "", false
+	}
+	conv.Stopped = true
+	return stoppedSummary(conv), true
+}
+
+// stoppedSummary describes what a stopped conversation did before it was stopped
+func stoppedSummary(conv *conversation) string {
+	if len(conv.Steps) == 0 {
+		return "🛑 Stopped before any Jira tools were called."
+	}
+	var b strings.Builder
+	b.WriteString("🛑 Stopped. Completed before stopping:")
+	for _, step := range conv.Steps {
+		b.WriteString("\n• " + step)
+	}
+	b.WriteString("\n\nReply in this thread to continue from here.")
+	return b.String()
+}
+
+// completedStep is the summary line of a completed tool call
+func completedStep(toolCall string, isWrite bool) string {
+	if isWrite {
+		return fmt.Sprintf("`%s` (change applied)", toolCall)
+	}
+	return fmt.Sprintf("`%s`", toolCall)
+}
+
+// postStopButton posts a button that stops the conversation of the thread and returns its
+// timestamp. Only Slack threads get one.
+func (h *SlackHandler) postStopButton(lang i18n.Lang, channelID, threadTS string) string {
+	if threadTS == "" || h.messengerFor(channelID) != nil {
+		return ""
+	}
+	stop := slack.NewButtonBlockElement(stopConversationActionID, threadTS, slack.NewTextBlockObject(slack.PlainTextType, i18n.T(lang, i18n.StopButton), false, false))
+	stop.Style = slack.StyleDanger
+	_, timestamp, err := h.slackClient(channelID).PostMessage(
+		channelID,
+		slack.MsgOptionText(i18n.T(lang, i18n.StopPrompt), false),
+		slack.MsgOptionBlocks(slack.NewSectionBlock(
+			slack.NewTextBlockObject(slack.MarkdownType, i18n.T(lang, i18n.StopPrompt), false, false), nil, slack.NewAccessory(stop))),
+		slack.MsgOptionTS(threadTS))
+	if err != nil {
+		logger.GetLogger().Warn("failed to post stop button", zap.String("channel", channelID), zap.Error(err))
+		return ""
+	}
+	return timestamp
+}
+
+// removeStopButton deletes the stop button once the conversation is over
+func (h *SlackHandler) removeStopButton(channelID, timestamp string) {
+	if timestamp == "" {
+		return
+	}
+	if _, _, err := h.slackClient(channelID).DeleteMessage(channelID, timestamp); err != nil {
+		logger.GetLogger().Warn("failed to remove stop button", zap.String("channel", channelID), zap.Error(err))
+	}
+}
+
+// handleStopAction stops the conversation of the button's thread
+func (h *SlackHandler) handleStopAction(callback slack.InteractionCallback, action *slack.BlockAction) {
+	channelID, userID := callback.Container.ChannelID, callback.User.ID
+	if !h.stopConversation(channelID, action.Value, userID) {
+		h.sendEphemeral(channelID, userID, "Nothing to stop: the request has already finished, or it was started by someone else.")
+	}
+}
@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"jira_helper/internal/github"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/service/openai"
+	"jira_helper/internal/storage"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// linkedCodeTool and linkPullRequestTool are served by the handler next to the MCP server's tools.
+	// linkPullRequestTool is a write tool, so it needs the user's token and follows the write policy.
+	linkedCodeTool      = "jira_get_linked_code"
+	linkPullRequestTool = "jira_link_pull_request"
+
+	// maxLinkedCommits caps the commits a jira_get_linked_code result lists
+	maxLinkedCommits = 30
+)
+
+// linkedCodeDefinition describes jira_get_linked_code to the model
+var linkedCodeDefinition = openai.Tool{
+	Name: linkedCodeTool,
+	Description: "Find the code changed for an issue: the pull/merge requests and commits linked to it in Jira's " +
+		"development panel, pull requests on GitHub that mention its key, and those linked by the GitHub webhook. " +
+		"Use it to answer what code changed for an issue or whether its fix is merged.",
+	Parameters: `{
+	"type": "object",
+	"properties": {
+		"issue_key": {"type": "string", "description": "Key of the issue, e.g. PROJ-123"}
+	},
+	"required": ["issue_key"]
+}`,
+}
+
+// linkPullRequestDefinition describes jira_link_pull_request to the model
+var linkPullRequestDefinition = openai.Tool{
+	Name: linkPullRequestTool,
+	Description: "Link a GitHub pull request or GitLab merge request to an issue, adding it to the issue's links " +
+		"in Jira. Linking the same URL again updates the existing link.",
+	Parameters: `{
+	"type": "object",
+	"properties": {
+		"issue_key": {"type": "string", "description": "Key of the issue, e.g. PROJ-123"},
+		"url": {"type": "string", "description": "URL of the pull or merge request, e.g. https://github.com/acme/api/pull/12"},
+		"title": {"type": "string", "description": "Title of the link, defaults to the pull request's title or reference"}
+	},
+	"required": ["issue_key", "url"]
+}`,
+}
+
+// linkedChange is a pull or merge request linked to an issue, from whichever source found it
+type linkedChange struct {
+	Source     string `json:"source"` // jira, github or webhook
+	Repository string `json:"repository,omitempty"`
+	ID         string `json:"id,omitempty"`
+	Title      string `json:"title"`
+	URL        string `json:"url"`
+	State      string `json:"state"`
+	Author     string `json:"author,omitempty"`
+	Branch     string `json:"branch,omitempty"`
+	Updated    string `json:"updated,omitempty"`
+}
+
+// linkedCommit is a commit that references an issue
+type linkedCommit struct {
+	Repository string `json:"repository"`
+	ID         string `json:"id"`
+	Message    string `json:"message"`
+	URL        string `json:"url,omitempty"`
+	Author     string `json:"author,omitempty"`
+	Date       string `json:"date,omitempty"`
+}
+
+// linkedCode runs a jira_get_linked_code call. Sources that fail are reported next to the
+// changes the others found.
+func (h *SlackHandler) linkedCode(ctx context.Context, conv *conversation, toolCall openai.ToolCall, userToken string) (*mcp.CallToolResult, error) {
+	key, _ := toolCall.Args["issue_key"].(string)
+	key = strings.ToUpper(strings.TrimSpace(key))
+	if !issueKeyPattern.MatchString(key) {
+		return mcp.NewToolResultError("issue_key must be an issue key such as PROJ-123"), nil
+	}
+
+	token := userToken
+	if token == "" {
+		token = h.defaultJiraToken
+	}
+	client, err := jira.NewClient(h.jiraURLOf(conv.JiraInstance), token)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	issue, err := client.GetIssue(ctx, key, "summary")
+	if err != nil {
+		return mcp.NewToolResultError(jiraErrorMessage(err)), nil
+	}
+
+	var (
+		changes  []linkedChange
+		commits  []linkedCommit
+		problems []string
+		seen     = map[string]bool{}
+	)
+	add := func(change linkedChange) {
+		if change.URL != "" && seen[change.URL] {
+			return
+		}
+		seen[change.URL] = true
+		changes = append(changes, change)
+	}
+
+	status, err := client.DevStatus(ctx, issue.ID)
+	if err != nil {
+		logger.GetLogger().Warn("failed to get development status", zap.String("issue", key), zap.Error(err))
+		problems = append(problems, "Jira development panel: "+jiraErrorMessage(err))
+	} else {
+		for _, pr := range status.PullRequests {
+			add(linkedChange{
+				Source:     "jira",
+				Repository: pr.Source.Repository.Name,
+				ID:         pr.ID,
+				Title:      pr.Name,
+				URL:        pr.URL,
+				State:      strings.ToLower(pr.Status),
+				Author:     pr.Author.Name,
+				Branch:     pr.Source.Branch,
+				Updated:    pr.LastUpdate,
+			})
+		}
+		for _, repository := range status.Repositories {
+			for _, commit := range repository.Commits {
+				if len(commits) == maxLinkedCommits {
+					break
+				}
+				id := commit.DisplayID
+				if id == "" {
+					id = commit.ID
+				}
+				commits = append(commits, linkedCommit{
+					Repository: repository.Name,
+					ID:         id,
+					Message:    excerpt(commit.Message, 200),
+					URL:        commit.URL,
+					Author:     commit.Author.Name,
+					Date:       commit.AuthorTimestamp,
+				})
+			}
+		}
+	}
+
+	if h.githubClient != nil {
+		prs, err := h.githubClient.SearchPullRequests(ctx, key, h.githubSearchQualifiers)
+		if err != nil {
+			logger.GetLogger().Warn("failed to search pull requests", zap.String("issue", key), zap.Error(err))
+			problems = append(problems, "GitHub search: "+err.Error())
+		}
+		for _, pr := range prs {
+			add(linkedChange{
+				Source:     "github",
+				Repository: pr.Repository,
+				ID:         fmt.Sprintf("#%d", pr.Number),
+				Title:      pr.Title,
+				URL:        pr.URL,
+				State:      pr.State,
+				Author:     pr.Author,
+				Updated:    pr.UpdatedAt,
+			})
+		}
+	}
+
+	if h.issueLinks != nil {
+		links, err := h.issueLinks.Load(ctx, key)
+		if err != nil {
+			logger.GetLogger().Warn("failed to load issue links", zap.String("issue", key), zap.Error(err))
+		}
+		for _, pr := range links.PullRequests {
+			add(linkedChange{
+				Source:     "webhook",
+				Repository: pr.Repository,
+				ID:         fmt.Sprintf("#%d", pr.Number),
+				Title:      pr.Title,
+				URL:        pr.URL,
+				State:      pr.State,
+				Updated:    pr.UpdatedAt.Format(time.RFC3339),
+			})
+		}
+	}
+
+	result := map[string]interface{}{
+		"issue_key":     key,
+		"summary":       issue.Fields.Summary,
+		"pull_requests": changes,
+		"commits":       commits,
+	}
+	if len(problems) > 0 {
+		result["unavailable"] = problems
+	}
+	return mcp.NewToolResultText(printJSON(result)), nil
+}
+
+// linkPullRequestToIssue runs a jira_link_pull_request call with the user's token
+func (h *SlackHandler) linkPullRequestToIssue(ctx context.Context, conv *conversation, toolCall openai.ToolCall, userToken string) (*mcp.CallToolResult, error) {
+	key, _ := toolCall.Args["issue_key"].(string)
+	key = strings.ToUpper(strings.TrimSpace(key))
+	if !issueKeyPattern.MatchString(key) {
+		return mcp.NewToolResultError("issue_key must be an issue key such as PROJ-123"), nil
+	}
+	rawURL, _ := toolCall.Args["url"].(string)
+	change, err := github.ParseChangeURL(rawURL)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	title, _ := toolCall.Args["title"].(string)
+	title = strings.TrimSpace(title)
+
+	// Look up GitHub pull requests, so the link is titled and the webhook's record knows its state
+	var pr *github.PullRequest
+	if change.Host == github.HostGitHub && h.githubClient != nil {
+		if pr, err = h.githubClient.GetPullRequest(ctx, change.Repository, change.Number); err != nil {
+			logger.GetLogger().Warn("failed to get pull request", zap.String("url", change.URL), zap.Error(err))
+		}
+	}
+	if title == "" && pr != nil {
+		title = pr.Title
+	}
+	linkTitle := change.Name()
+	if title != "" {
+		linkTitle += ": " + title
+	}
+
+	client, err := jira.NewClient(h.jiraURLOf(conv.JiraInstance), userToken)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	_, err = client.AddRemoteLink(ctx, key, jira.RemoteLink{
+		GlobalID:     change.URL,
+		Application:  change.Host,
+		Relationship: "mentioned in",
+		URL:          change.URL,
+		Title:        linkTitle,
+	})
+	if err != nil {
+		logger.GetLogger().Warn("failed to link pull request", zap.String("issue", key), zap.String("url", change.URL), zap.Error(err))
+		return mcp.NewToolResultError(jiraErrorMessage(err)), nil
+	}
+
+	if pr != nil && h.issueLinks != nil {
+		ref := storage.PullRequestRef{
+			Repository: pr.Repository,
+			Number:     pr.Number,
+			Title:      pr.Title,
+			URL:        change.URL,
+			State:      pr.State,
+			UpdatedAt:  time.Now().UTC(),
+		}
+		if _, err := h.linkPullRequest(ctx, key, ref); err != nil {
+			logger.GetLogger().Warn("failed to record pull request", zap.String("issue", key), zap.Error(err))
+		}
+	}
+	return mcp.NewToolResultText(printJSON(map[string]interface{}{
+		"issue_key": key,
+		"linked":    change.Name(),
+		"url":       change.URL,
+		"title":     linkTitle,
+	})), nil
+}
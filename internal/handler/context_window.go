@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/openai"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultContextTokens is the prompt budget when none is configured, leaving room for the
+	// completion in a 128k context window
+	defaultContextTokens = 100000
+
+	// messageOverheadTokens is what the chat format adds to every message
+	messageOverheadTokens = 4
+
+	// maxSummarizedResult shortens tool results in the history sent for summarization
+	maxSummarizedResult = 2000
+
+	// summaryPrefix starts the message holding the summary of evicted history
+	summaryPrefix = "Summary of the earlier conversation:\n"
+)
+
+// summarizePrompt asks the model to condense the history that no longer fits
+const summarizePrompt = `Summarize the following earlier part of a conversation between a user and a Jira assistant, so the assistant can continue it without the full history. Keep the issue keys, decisions, open questions and the results of tool calls that may still matter. Reply with the summary only.
+
+%s`
+
+// estimateMessageTokens estimates the tokens a message takes in the prompt
+func estimateMessageTokens(message azopenai.ChatRequestMessageClassification) int {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return messageOverheadTokens
+	}
+	return openai.EstimateTokens(string(data)) + messageOverheadTokens
+}
+
+// estimateToolTokens estimates the tokens the tool definitions take in the prompt
+func estimateToolTokens(tools []openai.Tool) int {
+	total := 0
+	for _, tool := range tools {
+		total += openai.EstimateTokens(tool.Name+tool.Description+tool.Parameters) + messageOverheadTokens
+	}
+	return total
+}
+
+// messageGroups splits the messages after the system prompt into groups that are kept or evicted
+// together: an assistant message with tool calls and the tool results answering it, or a single
+// message. Tool results whose call is gone already are dropped, the model rejects them alone.
+func messageGroups(messages []azopenai.ChatRequestMessageClassification) [][]azopenai.ChatRequestMessageClassification {
+	var groups [][]azopenai.ChatRequestMessageClassification
+	for _, message := range messages {
+		if _, ok := message.(*azopenai.ChatRequestToolMessage); ok {
+			if n := len(groups); n > 0 {
+				if _, ok := groups[n-1][0].(*azopenai.ChatRequestAssistantMessage); ok {
+					groups[n-1] = append(groups[n-1], message)
+				}
+			}
+			continue
+		}
+		groups = append(groups, []azopenai.ChatRequestMessageClassification{message})
+	}
+	return groups
+}
+
+// fitContext keeps the messages within the prompt budget. The system prompt and the most recent
+// messages are kept, and a tool call is never separated from its results. With summarization
+// enabled, the evicted messages are replaced by a summary of them.
+func (h *SlackHandler) fitContext(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []openai.Tool) []azopenai.ChatRequestMessageClassification {
+	if len(messages) < 2 {
+		return messages
+	}
+	budget := h.contextTokens
+	if budget <= 0 {
+		budget = defaultContextTokens
+	}
+	budget -= estimateMessageTokens(messages[0]) + estimateToolTokens(tools)
+
+	groups := messageGroups(messages[1:])
+	total := 0
+	groupTokens := make([]int, len(groups))
+	for i, group := range groups {
+		for _, message := range group {
+			groupTokens[i] += estimateMessageTokens(message)
+		}
+		total += groupTokens[i]
+	}
+
+	// Evict the oldest groups, always keeping the latest one
+	evict := 0
+	for evict < len(groups)-1 && total > budget {
+		total -= groupTokens[evict]
+		evict++
+	}
+	if total > budget {
+		logger.GetLogger().Warn("latest messages exceed the context budget", zap.Int("estimated_tokens", total), zap.Int("budget", budget))
+	}
+	if evict == 0 {
+		return messages
+	}
+
+	kept := []azopenai.ChatRequestMessageClassification{messages[0]}
+	if h.summarizeHistory {
+		if summary := h.summarizeEvicted(ctx, groups[:evict]); summary != nil {
+			kept = append(kept, summary)
+		}
+	}
+	for _, group := range groups[evict:] {
+		kept = append(kept, group...)
+	}
+	logger.GetLogger().Info("trimmed conversation to the context budget",
+		zap.Int("evicted_messages", len(messages)-len(kept)),
+		zap.Int("estimated_tokens", total),
+		zap.Int("budget", budget))
+	return kept
+}
+
+// summarizeEvicted asks the model to summarize the evicted messages, returning nil when it fails
+func (h *SlackHandler) summarizeEvicted(ctx context.Context, groups [][]azopenai.ChatRequestMessageClassification) azopenai.ChatRequestMessageClassification {
+	var transcript []string
+	for _, group := range groups {
+		for _, message := range group {
+			if line := transcriptLine(message); line != "" {
+				transcript = append(transcript, line)
+			}
+		}
+	}
+
+	summary, err := h.aiClient.Chat(ctx, []azopenai.ChatRequestMessageClassification{
+		&azopenai.ChatRequestUserMessage{
+			Content: azopenai.NewChatRequestUserMessageContent(fmt.Sprintf(summarizePrompt, strings.Join(transcript, "\n"))),
+		},
+	})
+	if err != nil || summary == "" {
+		logger.GetLogger().Warn("failed to summarize evicted history, dropping it", zap.Error(err))
+		return nil
+	}
+	return &azopenai.ChatRequestUserMessage{
+		Content: azopenai.NewChatRequestUserMessageContent(summaryPrefix + summary),
+	}
+}
+
+// transcriptLine renders a message for the summarization prompt. An earlier summary is passed on
+// as it is, so summaries build on each other.
+func transcriptLine(message azopenai.ChatRequestMessageClassification) string {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return ""
+	}
+	var msg checkpointMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return ""
+	}
+	switch msg.Role {
+	case "assistant":
+		var calls []string
+		for _, call := range msg.ToolCalls {
+			calls = append(calls, fmt.Sprintf("%s(%s)", call.Function.Name, call.Function.Arguments))
+		}
+		if len(calls) > 0 {
+			return fmt.Sprintf("assistant called %s %s", strings.Join(calls, ", "), msg.Content)
+		}
+		return "assistant: " + msg.Content
+	case "tool":
+		content := msg.Content
+		if runes := []rune(content); len(runes) > maxSummarizedResult {
+			content = string(runes[:maxSummarizedResult]) + "…"
+		}
+		return "tool result: " + content
+	default:
+		return msg.Role + ": " + msg.Content
+	}
+}
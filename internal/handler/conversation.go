@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"jira_helper/internal/i18n"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/openai"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"go.uber.org/zap"
+)
+
+// conversation holds the state carried from one round to the next. It can be checkpointed,
+// so a conversation may continue in a different invocation.
+type conversation struct {
+	ID                string          `json:"id"`
+	ChannelID         string          `json:"channel_id"`
+	ThreadTS          string          `json:"thread_ts"`
+	UserID            string          `json:"user_id"`
+	Query             string          `json:"query,omitempty"`           // Question the conversation answers, stored with the answer for feedback
+	TeamID            string          `json:"team_id,omitempty"`         // Workspace installed through OAuth, rounds on other instances post with its token
+	Language          i18n.Lang       `json:"language,omitempty"`        // Language detected in the thread, replies and messages use it
+	JiraUser          string          `json:"jira_user,omitempty"`       // Jira username of the user, if their account is known
+	JiraName          string          `json:"jira_name,omitempty"`       // Display name of the user's Jira account
+	Style             string          `json:"style,omitempty"`           // Response style of the user, the prompt asks for it
+	DefaultProject    string          `json:"default_project,omitempty"` // Project the user works in, assumed when they name none
+	DefaultBoard      int             `json:"default_board,omitempty"`   // Agile board the user works with, assumed when they name none
+	Timezone          string          `json:"timezone,omitempty"`        // Time zone of the user, the prompt gives the time there
+	DryRun            bool            `json:"dry_run,omitempty"`         // Writes are shown to the user instead of being sent to Jira
+	JiraInstance      string          `json:"jira_instance,omitempty"`   // Named Jira instance the conversation works with, "" for the primary one
+	Timestamp         string          `json:"timestamp"`                 // Progress message that is being updated
+	SlackMessageLines []string        `json:"slack_message_lines"`
+	Round             int             `json:"round"`
+	AuthGuidanceSent  bool            `json:"auth_guidance_sent"`
+	HistoryTS         string          `json:"history_ts,omitempty"` // Newest thread message the conversation has seen
+	Wrote             bool            `json:"wrote,omitempty"`      // A write tool was called, cached reads may be stale
+	Steps             []string        `json:"steps,omitempty"`      // Completed tool calls, summarized if the user stops the conversation
+	Rendered          *renderedResult `json:"rendered,omitempty"`   // Last tool result, rendered under the answer when it is structured
+	Stopped           bool            `json:"-"`                    // The user stopped the conversation, its messages are incomplete
+
+	PendingCalls []openai.ToolCall `json:"pending_calls,omitempty"` // Tool calls waiting for the user's approval
+	PendingBulk  bool              `json:"pending_bulk,omitempty"`  // The approval covers all pending calls, not only the first
+
+	Messages []azopenai.ChatRequestMessageClassification `json:"-"`
+}
+
+// checkpointMessage is the stored form of a chat message
+type checkpointMessage struct {
+	Role       string `json:"role"`
+	Content    string `json:"content,omitempty"`
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	ToolCalls  []struct {
+		ID       string `json:"id"`
+		Function struct {
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+		} `json:"function"`
+	} `json:"tool_calls,omitempty"`
+}
+
+// newConversationID returns an ID that is also a valid Step Functions execution name
+func newConversationID(channelID, threadTS string) string {
+	return fmt.Sprintf("%s-%s-%d", channelID, strings.ReplaceAll(threadTS, ".", ""), time.Now().UnixNano())
+}
+
+// encodeConversation serializes the conversation including its chat messages
+func encodeConversation(conv *conversation) ([]byte, error) {
+	messages, err := json.Marshal(conv.Messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal messages: %v", err)
+	}
+	return json.Marshal(struct {
+		*conversation
+		Messages json.RawMessage `json:"messages"`
+	}{conv, messages})
+}
+
+// decodeConversation restores a conversation written by encodeConversation
+func decodeConversation(data []byte) (*conversation, error) {
+	var stored struct {
+		conversation
+		Messages []checkpointMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation: %v", err)
+	}
+
+	conv := stored.conversation
+	messages, err := decodeMessages(stored.Messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode conversation %s: %v", conv.ID, err)
+	}
+	conv.Messages = messages
+	return &conv, nil
+}
+
+// decodeMessages restores chat messages from their stored form
+func decodeMessages(stored []checkpointMessage) ([]azopenai.ChatRequestMessageClassification, error) {
+	var messages []azopenai.ChatRequestMessageClassification
+	for _, msg := range stored {
+		switch msg.Role {
+		case "system":
+			messages = append(messages, systemMessage)
+		case "user":
+			messages = append(messages, &azopenai.ChatRequestUserMessage{
+				Content: azopenai.NewChatRequestUserMessageContent(msg.Content),
+			})
+		case "assistant":
+			assistant := &azopenai.ChatRequestAssistantMessage{
+				Content: azopenai.NewChatRequestAssistantMessageContent(msg.Content),
+			}
+			for _, call := range msg.ToolCalls {
+				assistant.ToolCalls = append(assistant.ToolCalls, &azopenai.ChatCompletionsFunctionToolCall{
+					ID:   to.Ptr(call.ID),
+					Type: to.Ptr("function"),
+					Function: &azopenai.FunctionCall{
+						Name:      to.Ptr(call.Function.Name),
+						Arguments: to.Ptr(call.Function.Arguments),
+					},
+				})
+			}
+			messages = append(messages, assistant)
+		case "tool":
+			messages = append(messages, &azopenai.ChatRequestToolMessage{
+				ToolCallID: to.Ptr(msg.ToolCallID),
+				Content:    azopenai.NewChatRequestToolMessageContent(msg.Content),
+			})
+		default:
+			return nil, fmt.Errorf("unsupported message role %q", msg.Role)
+		}
+	}
+	return messages, nil
+}
+
+// shouldHandOff reports whether the conversation has run long enough to continue in Step Functions.
+// Queries whose caller waits for the answer always run inline.
+func (h *SlackHandler) shouldHandOff(ctx context.Context, conv *conversation) bool {
+	return h.stepFunctions != nil && conv.Round >= h.handOffRounds && toolTraceFrom(ctx) == nil
+}
+
+// handOff checkpoints the conversation and starts a Step Functions execution that runs the
+// remaining rounds, one state per round
+func (h *SlackHandler) handOff(ctx context.Context, conv *conversation) error {
+	if err := h.saveConversation(ctx, conv); err != nil {
+		return err
+	}
+
+	executionARN, err := h.stepFunctions.StartExecution(ctx, h.stateMachineARN, conv.ID, RoundPayload{
+		Round: &ConversationRound{ConversationID: conv.ID},
+	})
+	if err != nil {
+		_, _ = h.sendMarkdownMessage(conv.ChannelID, errorMessage(ctx, err), conv.ThreadTS)
+		return fmt.Errorf("failed to start conversation execution: %v", err)
+	}
+
+	logger.GetLogger().Info("conversation handed off to step functions",
+		zap.String("conversation_id", conv.ID),
+		zap.Int("round", conv.Round),
+		zap.String("execution_arn", executionARN))
+	return nil
+}
+
+// saveConversation checkpoints the conversation
+func (h *SlackHandler) saveConversation(ctx context.Context, conv *conversation) error {
+	data, err := encodeConversation(conv)
+	if err != nil {
+		return err
+	}
+	if err := h.checkpoints.Save(ctx, conv.ID, data); err != nil {
+		return fmt.Errorf("failed to checkpoint conversation: %v", err)
+	}
+	return nil
+}
+
+// RoundPayload is the state passed between Step Functions states,
+// e.g. {"conversation_round": {"conversation_id": "...", "done": false}}
+type RoundPayload struct {
+	Round *ConversationRound `json:"conversation_round"`
+}
+
+// ConversationRound identifies a checkpointed conversation and whether it has finished
+type ConversationRound struct {
+	ConversationID string `json:"conversation_id"`
+	Done           bool   `json:"done"`
+}
+
+// RunConversationRound loads a checkpointed conversation, runs one round and checkpoints it again.
+// The final response is posted to the thread once the conversation is done.
+func (h *SlackHandler) RunConversationRound(ctx context.Context, round ConversationRound) (ConversationRound, error) {
+	if h.checkpoints == nil {
+		return round, fmt.Errorf("step functions execution mode is not configured")
+	}
+
+	data, err := h.checkpoints.Load(ctx, round.ConversationID)
+	if err != nil {
+		return round, fmt.Errorf("failed to load conversation checkpoint: %v", err)
+	}
+	conv, err := decodeConversation(data)
+	if err != nil {
+		return round, err
+	}
+	h.workspaces.remember(conv.TeamID, conv.ChannelID, conv.UserID)
+
+	// Returning an error lets Step Functions retry the round on another instance
+	ctx, end, err := h.beginConversation(i18n.WithLang(ctx, conv.Language), conv.ChannelID, conv.ThreadTS, conv.UserID)
+	if err != nil {
+		return round, err
+	}
+	defer end()
+
+	userToken, err := h.jiraToken(conv.JiraInstance, conv.UserID)
+	if err != nil {
+		return round, fmt.Errorf("failed to get user personal token: %v", err)
+	}
+	openAITools, err := h.availableTools(ctx)
+	if err != nil {
+		return round, err
+	}
+	mcpClient, cleanup, err := h.mcpClientOf(conv.JiraInstance, userToken)
+	if err != nil {
+		return round, fmt.Errorf("failed to get MCP client: %v", err)
+	}
+	defer cleanup()
+
+	ctx, recordUsage := h.trackUsage(ctx, conv)
+	response, done, err := h.runRound(ctx, conv, openAITools, mcpClient, userToken)
+	if summary, stopped := stoppedAnswer(ctx, conv); stopped {
+		response, done, err = summary, true, nil
+	}
+	recordUsage(err)
+	if err != nil || done {
+		h.completeConversation(ctx, conv, response, err)
+		// Errors have already been reported in the thread, retrying would repeat them
+		if err != nil {
+			logger.GetLogger().Error("conversation round failed", zap.String("conversation_id", conv.ID), zap.Error(err))
+		}
+		if err == nil {
+			h.postAnswer(ctx, conv.ChannelID, conv.ThreadTS, conv.UserID, conv.Query, response, conv.renderedAnswer())
+		} else {
+			_, _ = h.sendMarkdownMessage(conv.ChannelID, response, conv.ThreadTS)
+		}
+		if err := h.checkpoints.Delete(ctx, conv.ID); err != nil {
+			logger.GetLogger().Warn("failed to delete conversation checkpoint", zap.String("conversation_id", conv.ID), zap.Error(err))
+		}
+		round.Done = true
+		return round, nil
+	}
+
+	return round, h.saveConversation(ctx, conv)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+
+	"jira_helper/internal/dates"
+	"jira_helper/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// userTimezone returns the time zone the user's dates are rendered in: the one they chose with
+// `/jira prefs`, or the one of their Slack profile. Empty when neither is known, dates are then
+// rendered in UTC.
+func (h *SlackHandler) userTimezone(ctx context.Context, channelID, userID string) string {
+	if timezone := h.userPreferences(ctx, userID).Timezone; timezone != "" {
+		return timezone
+	}
+	return h.profileTimezone(ctx, channelID, userID)
+}
+
+// profileTimezone returns the time zone of the user's Slack profile, remembered for the life of
+// the process as it rarely changes. Users of other messengers and the API have none.
+func (h *SlackHandler) profileTimezone(ctx context.Context, channelID, userID string) string {
+	if userID == "" || h.messengerFor(channelID) != nil {
+		return ""
+	}
+	h.timezoneMu.Lock()
+	timezone, ok := h.profileTimezones[userID]
+	h.timezoneMu.Unlock()
+	if ok {
+		return timezone
+	}
+
+	profile, err := h.slackClient(channelID).GetUserInfoContext(ctx, userID)
+	if err != nil {
+		logger.GetLogger().Debug("failed to get the time zone of the Slack profile", zap.String("user_id", userID), zap.Error(err))
+		return ""
+	}
+	timezone = profile.TZ
+	h.timezoneMu.Lock()
+	if h.profileTimezones == nil {
+		h.profileTimezones = map[string]string{}
+	}
+	h.profileTimezones[userID] = timezone
+	h.timezoneMu.Unlock()
+	return timezone
+}
+
+// datesOf returns the formatter of the conversation's dates
+func datesOf(conv *conversation) dates.Formatter {
+	return dates.In(conv.Timezone)
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"io"
+
+	"jira_helper/internal/service/openai"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+)
+
+// SlackAPI is the part of the Slack Web API the handler uses. *slack.Client implements it.
+type SlackAPI interface {
+	AuthTest() (*slack.AuthTestResponse, error)
+	AddReaction(name string, item slack.ItemRef) error
+	PostMessage(channelID string, options ...slack.MsgOption) (string, string, error)
+	PostEphemeral(channelID, userID string, options ...slack.MsgOption) (string, error)
+	UpdateMessage(channelID, timestamp string, options ...slack.MsgOption) (string, string, string, error)
+	DeleteMessage(channelID, messageTimestamp string) (string, string, error)
+	GetConversationReplies(params *slack.GetConversationRepliesParameters) ([]slack.Message, bool, string, error)
+	GetPermalink(params *slack.PermalinkParameters) (string, error)
+	GetUserInfoContext(ctx context.Context, user string) (*slack.User, error)
+	GetUserByEmailContext(ctx context.Context, email string) (*slack.User, error)
+	GetUserGroupsContext(ctx context.Context, options ...slack.GetUserGroupsOption) ([]slack.UserGroup, error)
+	OpenView(triggerID string, view slack.ModalViewRequest) (*slack.ViewResponse, error)
+	UpdateView(view slack.ModalViewRequest, externalID, hash, viewID string) (*slack.ViewResponse, error)
+	PublishView(userID string, view slack.HomeTabViewRequest, hash string) (*slack.ViewResponse, error)
+	UploadFileV2Context(ctx context.Context, params slack.UploadFileV2Parameters) (*slack.FileSummary, error)
+	GetFileContext(ctx context.Context, downloadURL string, writer io.Writer) error
+}
+
+// AIProvider is the chat model that drives the conversation. *openai.Client, *anthropic.Client
+// and *bedrock.Client implement it.
+type AIProvider interface {
+	Chat(ctx context.Context, messages []azopenai.ChatRequestMessageClassification) (string, error)
+	ChatWithTools(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []openai.Tool) (*openai.ChatResponse, error)
+}
+
+// StreamingAIProvider is an AIProvider that can stream its completions. onContent receives the
+// text generated so far whenever the model adds to it. *openai.Client and *anthropic.Client implement it.
+type StreamingAIProvider interface {
+	AIProvider
+	ChatWithToolsStream(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []openai.Tool, onContent func(content string)) (*openai.ChatResponse, error)
+}
+
+// ImageReader is a model that reads images, used to understand screenshots. *openai.Client
+// implements it.
+type ImageReader interface {
+	DescribeImage(ctx context.Context, instruction, mimeType string, image []byte) (string, error)
+}
+
+// ToolCaller is the part of an MCP client needed to run tool calls
+type ToolCaller interface {
+	CallTool(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+}
+
+// MCPClient is a connection to an MCP server. *client.Client implements it.
+type MCPClient interface {
+	ToolCaller
+	Initialize(context.Context, mcp.InitializeRequest) (*mcp.InitializeResult, error)
+	ListTools(context.Context, mcp.ListToolsRequest) (*mcp.ListToolsResult, error)
+	Close() error
+}
+
+// MCPClientFactory starts an MCP client that acts with the given Jira token
+type MCPClientFactory func(token string) (MCPClient, error)
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"jira_helper/internal/digest"
+	"jira_helper/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// digestTimeout bounds the composition of one digest
+const digestTimeout = 5 * time.Minute
+
+// RunDigests composes the digests of the frequency and posts them to their channels. A digest
+// that fails does not stop the others, the failures are returned together.
+func (h *SlackHandler) RunDigests(ctx context.Context, frequency string) error {
+	if !h.featureEnabled(ctx, FeatureDigests) {
+		logger.GetLogger().Info("digests are turned off, skipping", zap.String("frequency", frequency))
+		return nil
+	}
+	due := h.digests.Due(frequency)
+	if len(due) == 0 {
+		logger.GetLogger().Info("no digests configured", zap.String("frequency", frequency))
+		return nil
+	}
+
+	var failed int
+	for _, d := range due {
+		if err := h.postDigest(ctx, d); err != nil {
+			failed++
+			logger.GetLogger().Error("failed to post digest",
+				zap.String("channel", d.Channel),
+				zap.String("project", d.Project),
+				zap.Error(err))
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d %s digests failed", failed, len(due), frequency)
+	}
+	return nil
+}
+
+// postDigest composes a digest with the AI and MCP pipeline and posts it to its channel
+func (h *SlackHandler) postDigest(ctx context.Context, d digest.Digest) error {
+	ctx, cancel := context.WithTimeout(ctx, digestTimeout)
+	defer cancel()
+
+	result, err := h.Query(ctx, d.Prompt(), nil, d.Channel, "", h.digestUserID)
+	if err != nil {
+		return fmt.Errorf("failed to compose digest: %v", err)
+	}
+	header := fmt.Sprintf("🗞️ *%s for %s* — %s", d.Title(), d.Project, time.Now().UTC().Format("Mon, Jan 2"))
+	if _, err := h.sendMarkdownMessage(d.Channel, header+"\n\n"+result.Answer, ""); err != nil {
+		return fmt.Errorf("failed to post digest: %v", err)
+	}
+
+	logger.GetLogger().Info("posted digest",
+		zap.String("channel", d.Channel),
+		zap.String("project", d.Project),
+		zap.String("frequency", d.Frequency),
+		zap.Int("issues", len(result.Citations)))
+	return nil
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"jira_helper/internal/dates"
+	"jira_helper/internal/discussion"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/service/openai"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// summarizeDiscussionTool is served by the handler next to the MCP server's tools
+	summarizeDiscussionTool = "summarize_issue_discussion"
+
+	// discussionSummaryTimeout bounds summarizing the discussion of one issue
+	discussionSummaryTimeout = 3 * time.Minute
+)
+
+// summarizeDiscussionDefinition describes summarize_issue_discussion to the model
+var summarizeDiscussionDefinition = openai.Tool{
+	Name: summarizeDiscussionTool,
+	Description: "Summarize all comments on an issue as a timeline with the decisions and open questions. " +
+		"Use it instead of reading the comments when an issue has a long discussion or the user asks what happened on it.",
+	Parameters: `{
+	"type": "object",
+	"properties": {
+		"issue_key": {"type": "string", "description": "Key of the issue, e.g. PROJ-123"}
+	},
+	"required": ["issue_key"]
+}`,
+}
+
+// summarizeDiscussion runs a summarize_issue_discussion call with the user's token
+func (h *SlackHandler) summarizeDiscussion(ctx context.Context, conv *conversation, toolCall openai.ToolCall, userToken string) (*mcp.CallToolResult, error) {
+	key, _ := toolCall.Args["issue_key"].(string)
+	key = strings.ToUpper(strings.TrimSpace(key))
+	if !issueKeyPattern.MatchString(key) {
+		return mcp.NewToolResultError("issue_key must be an issue key such as PROJ-123"), nil
+	}
+
+	token := userToken
+	if token == "" {
+		token = h.defaultJiraToken
+	}
+	client, err := jira.NewClient(h.jiraURLOf(conv.JiraInstance), token)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	d, err := discussion.Collect(ctx, client, key)
+	if err != nil {
+		return mcp.NewToolResultError(jiraErrorMessage(err)), nil
+	}
+	if len(d.Comments) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("%s has no comments", key)), nil
+	}
+	d.Dates = datesOf(conv)
+	summary, err := discussion.Summarize(ctx, h.aiClient, d, h.discussionChunkTokens())
+	if err != nil {
+		logger.GetLogger().Warn("failed to summarize discussion", zap.String("issue", key), zap.Error(err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(printJSON(map[string]interface{}{
+		"issue_key": key,
+		"comments":  len(d.Comments),
+		"summary":   summary,
+	})), nil
+}
+
+// jiraSummarizeCommand summarizes the discussion on an issue, e.g. `/jira summarize PROJ-123`.
+// The comments are read right away, the summary is written in the background and posted to the channel.
+func (h *SlackHandler) jiraSummarizeCommand(ctx context.Context, client *jira.Client, req jiraCommandRequest) (string, error) {
+	key := strings.ToUpper(req.Args)
+	if !issueKeyPattern.MatchString(key) {
+		return "", fmt.Errorf("usage: `/jira summarize PROJ-123`")
+	}
+	if err := h.checkCommandScope(req.ChannelID, projectOfToolCall(map[string]interface{}{"issue_key": key})); err != nil {
+		return "", err
+	}
+
+	d, err := discussion.Collect(ctx, client, key)
+	if err != nil {
+		return "", err
+	}
+	if len(d.Comments) == 0 {
+		return fmt.Sprintf("%s has no comments to summarize.", h.issueLink(key)), nil
+	}
+	d.Dates = dates.In(h.userTimezone(ctx, req.ChannelID, req.UserID))
+
+	convCtx, done, err := h.beginConversation(context.Background(), req.ChannelID, "", req.UserID)
+	if err != nil {
+		return "", err
+	}
+	go func() {
+		defer done()
+		h.postDiscussionSummary(convCtx, req, d)
+	}()
+	return fmt.Sprintf("🧵 Summarizing %d comments on %s, the summary will be posted in this channel.", len(d.Comments), h.issueLink(key)), nil
+}
+
+// postDiscussionSummary summarizes the discussion and posts it to the channel. Failures are
+// reported in the channel, as the command already returned.
+func (h *SlackHandler) postDiscussionSummary(ctx context.Context, req jiraCommandRequest, d *discussion.Discussion) {
+	ctx, cancel := context.WithTimeout(ctx, discussionSummaryTimeout)
+	defer cancel()
+
+	summary, err := discussion.Summarize(ctx, h.aiClient, d, h.discussionChunkTokens())
+	if err != nil {
+		logger.GetLogger().Error("failed to summarize discussion", zap.String("issue", d.Key), zap.Error(err))
+		_, _ = h.sendMarkdownMessage(req.ChannelID, fmt.Sprintf("❌ <@%s> failed to summarize the discussion on %s: %v", req.UserID, d.Key, err), "")
+		return
+	}
+	message := fmt.Sprintf("🧵 *Discussion on %s* (%d comments), requested by <@%s>\n_%s_\n\n%s",
+		h.issueLink(d.Key), len(d.Comments), req.UserID, d.Summary, summary)
+	_, _ = h.sendMarkdownMessage(req.ChannelID, message, "")
+}
+
+// discussionChunkTokens returns the size of the parts long discussions are summarized in, half
+// the prompt budget so the instructions and the answer fit as well
+func (h *SlackHandler) discussionChunkTokens() int {
+	budget := h.contextTokens
+	if budget <= 0 {
+		budget = defaultContextTokens
+	}
+	return min(budget/2, discussion.DefaultChunkTokens)
+}
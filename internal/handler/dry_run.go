@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"jira_helper/internal/logger"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// dryRunInstruction tells the model its writes only describe what would change
+	dryRunInstruction = "\n\nThis is a dry run: write tools are not sent to Jira, their results only describe what would have changed."
+
+	// dryRunResult tells the model the write was not sent, so it does not claim it was
+	dryRunResult = "DRY RUN: %s was not sent to Jira and nothing changed. It would have been called with %s. " +
+		"Continue as if it had succeeded where you can, and tell the user this was a dry run and what would have changed."
+
+	// dryRunNotice shows the user the call that was held back
+	dryRunNotice = "🧪 *Dry run:* `%s` was not sent to Jira. It would have been called with:\n```%s```"
+)
+
+// dryRunFlag asks for a dry run of a single request, e.g. "close PROJ-1 --dry-run"
+var dryRunFlag = regexp.MustCompile(`(?i)(^|\s)--dry-run(\s|$)`)
+
+type dryRunKey struct{}
+
+// withDryRun returns a context whose conversation shows its writes instead of sending them to Jira
+func withDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// cutDryRunFlag removes the --dry-run flag from the query, reporting whether it was there
+func cutDryRunFlag(query string) (string, bool) {
+	if !dryRunFlag.MatchString(query) {
+		return query, false
+	}
+	return strings.TrimSpace(dryRunFlag.ReplaceAllString(query, " ")), true
+}
+
+// dryRun reports whether the conversation should only show its writes: for every conversation
+// when the handler runs in dry-run mode, or when the request asked for it
+func (h *SlackHandler) dryRun(ctx context.Context) bool {
+	requested, _ := ctx.Value(dryRunKey{}).(bool)
+	return h.dryRunAll || requested
+}
+
+// dryRunMiddleware holds back the writes of dry-run conversations. The user sees the payload the
+// tool would have been called with, the model is told nothing changed.
+func (h *SlackHandler) dryRunMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, call *ToolCall) (*mcp.CallToolResult, error) {
+		if !call.Write || !call.Conversation.DryRun {
+			return next(ctx, call)
+		}
+		payload := printJSON(sanitizeArgs(call.Call.Args))
+		logger.GetLogger().Info("held back write of dry run",
+			zap.String("tool", call.Call.Name),
+			zap.String("conversation_id", call.Conversation.ID))
+		h.sendNotice(call.conv, fmt.Sprintf(dryRunNotice, call.Call.Name, payload))
+		return mcp.NewToolResultText(fmt.Sprintf(dryRunResult, call.Call.Name, payload)), nil
+	}
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"jira_helper/internal/email"
+	"jira_helper/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// emailTriageTimeout bounds the triage of one email like the Slack handlers do
+const emailTriageTimeout = 5 * time.Minute
+
+// emailTriagePrompt asks the model to file the email in Jira
+const emailTriagePrompt = `An email was sent to %s, which is handled by the Jira project %s. Triage it:
+- If it refers to an existing issue%s, add a comment to that issue summarizing the new information, and raise its priority if the email indicates more urgency.
+- Otherwise search the project for an open issue describing the same problem and comment on it, or create a new issue with a concise summary, a description quoting the relevant parts of the email including the sender, and a priority matching its urgency.
+Reply with the issue key and a one-line summary of what you did.
+
+From: %s
+Subject: %s
+
+%s`
+
+// HandleInboundEmail triages an inbound email into Jira. The owning channel gets a message about
+// the email, and the triage runs in its thread so the team can follow up there.
+func (h *SlackHandler) HandleInboundEmail(ctx context.Context, recipient string, route email.Route, msg *email.Message) error {
+	ctx, cancel := context.WithTimeout(ctx, emailTriageTimeout)
+	defer cancel()
+
+	notice := fmt.Sprintf("📧 *New email to %s*\n*From:* %s\n*Subject:* %s", recipient, msg.From, msg.Subject)
+	threadTS, err := h.sendMarkdownMessage(route.Channel, notice, "")
+	if err != nil {
+		return fmt.Errorf("failed to notify channel %s: %v", route.Channel, err)
+	}
+
+	referenced := ""
+	if keys := issueKeysIn(msg.Subject + "\n" + msg.Text); len(keys) > 0 {
+		referenced = " (it mentions " + strings.Join(keys, ", ") + ")"
+	}
+	query := fmt.Sprintf(emailTriagePrompt, recipient, route.Project, referenced, msg.From, msg.Subject, msg.Text)
+
+	result, err := h.Query(ctx, query, nil, route.Channel, threadTS, h.emailUserID)
+	if err != nil {
+		return fmt.Errorf("failed to triage email: %v", err)
+	}
+	_, _ = h.sendMarkdownMessage(route.Channel, result.Answer, threadTS)
+
+	// Link the issues in the channel message, so the outcome is visible without opening the thread
+	var links []string
+	for _, citation := range result.Citations {
+		if citation.URL != "" {
+			links = append(links, fmt.Sprintf("<%s|%s>", citation.URL, citation.IssueKey))
+		} else {
+			links = append(links, citation.IssueKey)
+		}
+	}
+	if len(links) > 0 {
+		_ = h.updateMessage(route.Channel, threadTS, notice+"\n*Jira:* "+strings.Join(links, ", "))
+	}
+
+	logger.GetLogger().Info("triaged inbound email",
+		zap.String("message_id", msg.MessageID),
+		zap.String("recipient", recipient),
+		zap.String("channel", route.Channel),
+		zap.Strings("issues", issueKeysIn(result.Answer)))
+	return nil
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/service/openai"
+	"jira_helper/internal/workflow"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// epicProgressTool is served by the handler next to the MCP server's tools
+	epicProgressTool = "jira_epic_progress"
+
+	// progressBarWidth is the number of blocks in a progress bar
+	progressBarWidth = 20
+)
+
+// epicProgressDefinition describes jira_epic_progress to the model
+var epicProgressDefinition = openai.Tool{
+	Name: epicProgressTool,
+	Description: "Report the progress of an epic: its issues counted by status, and the story points done " +
+		"and remaining. Includes a text progress bar that can be shown to the user as is.",
+	Parameters: `{
+	"type": "object",
+	"properties": {
+		"issue_key": {"type": "string", "description": "Key of the epic, e.g. PROJ-123"}
+	},
+	"required": ["issue_key"]
+}`,
+}
+
+// jiraEpicCommand shows the progress of an epic, e.g. `/jira epic PROJ-123`
+func (h *SlackHandler) jiraEpicCommand(ctx context.Context, client *jira.Client, req jiraCommandRequest) (string, error) {
+	key := strings.ToUpper(req.Args)
+	if !issueKeyPattern.MatchString(key) {
+		return "", fmt.Errorf("usage: `/jira epic PROJ-123`")
+	}
+	if err := h.checkCommandScope(req.ChannelID, projectOfToolCall(map[string]interface{}{"issue_key": key})); err != nil {
+		return "", err
+	}
+	progress, err := workflow.EpicReport(ctx, client, key)
+	if err != nil {
+		return "", err
+	}
+	return h.formatEpicProgress(progress), nil
+}
+
+// epicProgress runs a jira_epic_progress call with the user's token
+func (h *SlackHandler) epicProgress(ctx context.Context, conv *conversation, toolCall openai.ToolCall, userToken string) (*mcp.CallToolResult, error) {
+	key, _ := toolCall.Args["issue_key"].(string)
+	key = strings.ToUpper(strings.TrimSpace(key))
+	if !issueKeyPattern.MatchString(key) {
+		return mcp.NewToolResultError("issue_key must be an issue key such as PROJ-123"), nil
+	}
+
+	token := userToken
+	if token == "" {
+		token = h.defaultJiraToken
+	}
+	client, err := jira.NewClient(h.jiraURLOf(conv.JiraInstance), token)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	progress, err := workflow.EpicReport(ctx, client, key)
+	if err != nil {
+		logger.GetLogger().Warn("failed to report epic progress", zap.String("epic", key), zap.Error(err))
+		return mcp.NewToolResultError(jiraErrorMessage(err)), nil
+	}
+	return mcp.NewToolResultText(printJSON(map[string]interface{}{
+		"epic":         progress,
+		"remaining":    progress.Remaining(),
+		"progress_bar": workflow.ProgressBar(progress.Done(), progressBarWidth),
+	})), nil
+}
+
+// formatEpicProgress renders the progress bar, the work done and remaining, and the issues per status
+func (h *SlackHandler) formatEpicProgress(progress *workflow.EpicProgress) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📈 *%s %s*", h.issueLink(progress.Key), progress.Summary)
+	if progress.Status != "" {
+		fmt.Fprintf(&b, " — %s", progress.Status)
+	}
+	if progress.Issues == 0 {
+		b.WriteString("\nThe epic has no issues yet.")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "\n`%s`", workflow.ProgressBar(progress.Done(), progressBarWidth))
+	if progress.Unit == workflow.UnitPoints {
+		fmt.Fprintf(&b, "\n*Points:* %s done, %s remaining of %s", formatAmount(progress.DonePoints), formatAmount(progress.Remaining()), formatAmount(progress.Points))
+	}
+	fmt.Fprintf(&b, "\n*Issues:* %d done of %d", progress.DoneIssues, progress.Issues)
+	for _, status := range progress.Statuses {
+		fmt.Fprintf(&b, "\n• %s: %d", status.Name, status.Issues)
+		if progress.Unit == workflow.UnitPoints {
+			fmt.Fprintf(&b, " (%s pts)", formatAmount(status.Points))
+		}
+	}
+	if progress.Unestimated > 0 {
+		fmt.Fprintf(&b, "\n⚠️ %d issues have no estimate and count as 0 points.", progress.Unestimated)
+	}
+	if progress.Counted < progress.Issues {
+		fmt.Fprintf(&b, "\nOnly the first %d issues were counted.", progress.Counted)
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime/debug"
+
+	"jira_helper/internal/i18n"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/queue"
+	"jira_helper/internal/quota"
+	"jira_helper/internal/tracing"
+
+	"github.com/slack-go/slack/slackevents"
+	"go.uber.org/zap"
+)
+
+// eventIDPattern matches the Slack event IDs users are given as a reference
+var eventIDPattern = regexp.MustCompile(`^Ev[0-9A-Za-z]+$`)
+
+// recoverPanic wraps fn so a panic is logged with its stack and returned as an error
+func recoverPanic(fn func() error) func() error {
+	return func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.GetLogger().Error("recovered from panic", zap.Any("panic", r), zap.ByteString("stack", debug.Stack()))
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return fn()
+	}
+}
+
+// processEvent runs an event in-process. If it fails after Slack was acknowledged, the event is
+// kept for replay and the user is told, instead of the thread going silent.
+func (h *SlackHandler) processEvent(ctx context.Context, event queue.Event, eventsAPIEvent slackevents.EventsAPIEvent) error {
+	err := h.dispatchCallbackEvent(ctx, eventsAPIEvent)
+	if err != nil {
+		h.deadLetterEvent(ctx, event, err)
+	}
+	return err
+}
+
+// RetryableFailure reports whether running the event again may succeed. Exceeded quotas were
+// already explained to the user, and stopped or interrupted conversations were ended on purpose.
+func RetryableFailure(err error) bool {
+	var exceeded *quota.ExceededError
+	return !errors.As(err, &exceeded) && !errors.Is(err, errShuttingDown) && !errors.Is(err, context.Canceled)
+}
+
+// deadLetterEvent stores a failed event and tells its thread, quoting the event ID as the
+// reference admins replay it by. Failures the user was already told about are not stored.
+func (h *SlackHandler) deadLetterEvent(ctx context.Context, event queue.Event, err error) {
+	if !RetryableFailure(err) {
+		return
+	}
+	logger.GetLogger().Error("slack event failed",
+		zap.String("correlation_id", event.EventID),
+		zap.String("channel", event.ChannelID),
+		zap.Error(err))
+	if event.ChannelID == "" {
+		return
+	}
+
+	message := i18n.EventFailed
+	if h.failedEvents != nil {
+		if storeErr := h.failedEvents.Add(context.WithoutCancel(ctx), event, err.Error()); storeErr != nil {
+			logger.GetLogger().Error("failed to store failed event", zap.String("correlation_id", event.EventID), zap.Error(storeErr))
+		} else {
+			message = i18n.EventKept
+		}
+	}
+	_, _ = h.sendMarkdownMessage(event.ChannelID, i18n.T(eventLanguage(event), message, event.EventID), event.ThreadTS)
+	if message == i18n.EventKept {
+		h.alertAdmins(fmt.Sprintf("📮 Event `%s` from <#%s> failed and was kept for replay: %v. Use `/jira-admin dlq` to inspect and `/jira-admin dlq-replay %s` to retry.", event.EventID, event.ChannelID, err, event.EventID))
+	}
+}
+
+// eventLanguage returns the language of the event's message, English if it cannot be told
+func eventLanguage(event queue.Event) i18n.Lang {
+	var callback struct {
+		Event struct {
+			Text string `json:"text"`
+		} `json:"event"`
+	}
+	if err := json.Unmarshal(event.Payload, &callback); err != nil {
+		return i18n.English
+	}
+	lang, _ := i18n.Detect(callback.Event.Text)
+	return lang
+}
+
+// inProcessPublisher replays events on this instance, for deployments without an event queue
+type inProcessPublisher struct {
+	h *SlackHandler
+}
+
+// Publish runs the event in the background, so a replay does not wait for its conversation
+func (p inProcessPublisher) Publish(ctx context.Context, event queue.Event) error {
+	eventsAPIEvent, err := slackevents.ParseEvent(event.Payload, slackevents.OptionNoVerifyToken())
+	if err != nil {
+		return fmt.Errorf("failed to parse stored slack event: %v", err)
+	}
+	ctx = tracing.Extract(context.WithoutCancel(ctx), event.Trace)
+	go func() {
+		_ = p.h.processEvent(ctx, event, eventsAPIEvent)
+	}()
+	return nil
+}
+
+// replayTarget is where replayed events are sent: the event queue, or this instance without one
+func (h *SlackHandler) replayTarget() queue.Publisher {
+	if h.eventQueue != nil {
+		return h.eventQueue
+	}
+	return inProcessPublisher{h: h}
+}
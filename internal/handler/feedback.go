@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/storage"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"go.uber.org/zap"
+)
+
+const (
+	// Reactions users rate answers with, the bot adds both to every answer as a prompt
+	reactionUp   = "+1"
+	reactionDown = "-1"
+
+	// feedbackSummaryPeriod is the period the weekly feedback summary covers
+	feedbackSummaryPeriod = 7 * 24 * time.Hour
+
+	// feedbackSummaryExamples bounds the poorly rated answers the summary lists
+	feedbackSummaryExamples = 5
+
+	// feedbackExcerptLength shortens the queries the summary quotes
+	feedbackExcerptLength = 200
+)
+
+// postAnswer posts the final answer of a conversation in the thread. With feedback enabled, the
+// answer is stored with its query and the bot adds 👍 and 👎 for users to rate it with.
+// rendered is the structured tool result shown below the answer, if any.
+func (h *SlackHandler) postAnswer(ctx context.Context, channelID, threadTS, userID, query, answer string, rendered *renderedResult) {
+	ts, err := h.sendAnswerMessage(channelID, threadTS, answer, rendered)
+	if err != nil || ts == "" || h.feedback == nil || h.messengerFor(channelID) != nil {
+		return
+	}
+
+	record := storage.Answer{
+		ChannelID: channelID,
+		MessageTS: ts,
+		ThreadTS:  threadTS,
+		UserID:    userID,
+		Query:     query,
+		Answer:    answer,
+		PostedAt:  time.Now().UTC(),
+	}
+	if err := h.feedback.SaveAnswer(ctx, record); err != nil {
+		logger.GetLogger().Error("failed to store answer for feedback", zap.String("channel", channelID), zap.Error(err))
+		return
+	}
+	ref := slack.NewRefToMessage(channelID, ts)
+	for _, reaction := range []string{reactionUp, reactionDown} {
+		if err := h.slackClient(channelID).AddReaction(reaction, ref); err != nil {
+			logger.GetLogger().Warn("failed to add feedback reaction", zap.String("channel", channelID), zap.String("reaction", reaction), zap.Error(err))
+			return
+		}
+	}
+}
+
+// handleReactionAdded records a 👍 or 👎 on an answer as feedback, with the answer's query
+func (h *SlackHandler) handleReactionAdded(ctx context.Context, event *slackevents.ReactionAddedEvent) {
+	if h.feedback == nil || event.Item.Type != "message" {
+		return
+	}
+	var rating string
+	// Skin tones are added to the name, e.g. +1::skin-tone-2
+	switch strings.SplitN(event.Reaction, "::", 2)[0] {
+	case reactionUp:
+		rating = storage.RatingUp
+	case reactionDown:
+		rating = storage.RatingDown
+	default:
+		return
+	}
+	// The bot's own reactions are the prompt, not feedback
+	if botUserID, err := h.botUserID(event.Item.Channel); err != nil || event.User == botUserID {
+		return
+	}
+
+	answer, err := h.feedback.GetAnswer(ctx, event.Item.Channel, event.Item.Timestamp)
+	if err != nil {
+		logger.GetLogger().Error("failed to load answer for feedback", zap.String("channel", event.Item.Channel), zap.Error(err))
+		return
+	}
+	if answer == nil {
+		return
+	}
+	feedback := storage.Feedback{Answer: *answer, Rating: rating, RatedBy: event.User, RatedAt: time.Now().UTC()}
+	if err := h.feedback.SaveFeedback(ctx, feedback); err != nil {
+		logger.GetLogger().Error("failed to store feedback", zap.String("channel", answer.ChannelID), zap.Error(err))
+		return
+	}
+	logger.GetLogger().Info("recorded answer feedback",
+		zap.String("channel", answer.ChannelID),
+		zap.String("message_ts", answer.MessageTS),
+		zap.String("user_id", event.User),
+		zap.String("rating", rating))
+}
+
+// SummarizeFeedback sends the admins the ratings of the past week's answers per channel, and the
+// poorly rated queries to tune the prompts with. It is run as a scheduled job.
+func (h *SlackHandler) SummarizeFeedback(ctx context.Context) error {
+	if h.feedback == nil {
+		return nil
+	}
+	if len(h.adminUserIDs) == 0 {
+		logger.GetLogger().Info("no admins to send the feedback summary to, skipping")
+		return nil
+	}
+	feedback, err := h.feedback.ListFeedback(ctx, time.Now().Add(-feedbackSummaryPeriod))
+	if err != nil {
+		return err
+	}
+	h.alertAdmins(h.formatFeedbackSummary(feedback))
+	return nil
+}
+
+// formatFeedbackSummary renders the ratings per channel and the latest poorly rated answers
+func (h *SlackHandler) formatFeedbackSummary(feedback []storage.Feedback) string {
+	var b strings.Builder
+	b.WriteString("📊 *Answer feedback of the past week*")
+	if len(feedback) == 0 {
+		b.WriteString("\nNo answers were rated.")
+		return b.String()
+	}
+
+	type tally struct{ up, down int }
+	channels := map[string]*tally{}
+	var total tally
+	var down []storage.Feedback
+	for _, f := range feedback {
+		t, ok := channels[f.ChannelID]
+		if !ok {
+			t = &tally{}
+			channels[f.ChannelID] = t
+		}
+		if f.Rating == storage.RatingUp {
+			t.up++
+			total.up++
+		} else {
+			t.down++
+			total.down++
+			down = append(down, f)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n👍 %d  👎 %d — %d%% positive", total.up, total.down, total.up*100/(total.up+total.down))
+	ids := make([]string, 0, len(channels))
+	for id := range channels {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Fprintf(&b, "\n• <#%s>: 👍 %d  👎 %d", id, channels[id].up, channels[id].down)
+	}
+
+	if len(down) == 0 {
+		return b.String()
+	}
+	sort.Slice(down, func(i, j int) bool { return down[i].RatedAt.After(down[j].RatedAt) })
+	if len(down) > feedbackSummaryExamples {
+		down = down[:feedbackSummaryExamples]
+	}
+	b.WriteString("\n\n*Latest poorly rated answers:*")
+	for _, f := range down {
+		link := fmt.Sprintf("<#%s>", f.ChannelID)
+		if permalink, err := h.slackClient(f.ChannelID).GetPermalink(&slack.PermalinkParameters{Channel: f.ChannelID, Ts: f.MessageTS}); err == nil {
+			link = fmt.Sprintf("<%s|answer>", permalink)
+		}
+		fmt.Fprintf(&b, "\n• %s to <@%s>: _%s_", link, f.UserID, excerpt(f.Query, feedbackExcerptLength))
+	}
+	return b.String()
+}
+
+// excerpt shortens the text to at most n characters on one line
+func excerpt(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > n {
+		return string(runes[:n]) + "…"
+	}
+	return text
+}
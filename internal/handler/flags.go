@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// flagsCacheTTL is how long the feature flags are cached, so a toggle reaches every instance
+// within it without loading the flags on each message
+const flagsCacheTTL = 30 * time.Second
+
+// Feature flags turn off configured features at runtime. A feature without a flag stays on.
+const (
+	FeatureStreaming          = "streaming"
+	FeatureConversationMemory = "conversation_memory"
+	FeatureJiraNotifications  = "jira_notifications"
+	FeatureDigests            = "digests"
+)
+
+// features describes the features that can be toggled
+var features = map[string]string{
+	FeatureStreaming:          "Stream answers into the progress message",
+	FeatureConversationMemory: "Remember the model messages of each thread",
+	FeatureJiraNotifications:  "Notify subscribed channels about Jira webhook events",
+	FeatureDigests:            "Post the scheduled digests",
+}
+
+// featureEnabled reports whether the feature is on. Features stay on when the flags cannot be
+// loaded, so a storage outage does not turn them off.
+func (h *SlackHandler) featureEnabled(ctx context.Context, name string) bool {
+	if h.flags == nil {
+		return true
+	}
+	h.flagsMu.Lock()
+	defer h.flagsMu.Unlock()
+	if h.flagCache == nil || time.Since(h.flagsLoadedAt) > flagsCacheTTL {
+		flags, err := h.flags.Load(ctx)
+		if err != nil {
+			logger.GetLogger().Warn("failed to load feature flags, keeping the previous ones", zap.Error(err))
+		} else {
+			h.flagCache = flags
+		}
+		h.flagsLoadedAt = time.Now()
+	}
+	enabled, ok := h.flagCache[name]
+	return !ok || enabled
+}
+
+// setFeature turns a feature on or off for every instance
+func (h *SlackHandler) setFeature(ctx context.Context, name string, enabled bool) error {
+	if _, ok := features[name]; !ok {
+		return fmt.Errorf("unknown feature %q", name)
+	}
+	h.flagsMu.Lock()
+	defer h.flagsMu.Unlock()
+	flags, err := h.flags.Load(ctx)
+	if err != nil {
+		return err
+	}
+	flags[name] = enabled
+	if err := h.flags.Save(ctx, flags); err != nil {
+		return err
+	}
+	h.flagCache, h.flagsLoadedAt = flags, time.Now()
+	return nil
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"jira_helper/internal/github"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/service/openai"
+	"jira_helper/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// githubWebhookTimeout bounds the processing of one webhook delivery
+	githubWebhookTimeout = time.Minute
+
+	// maxLinkedThreads caps the Slack threads notified about an issue, the oldest are dropped first
+	maxLinkedThreads = 20
+
+	// maxPullRequestContext caps the issues whose pull requests are added to a query
+	maxPullRequestContext = 5
+)
+
+// HandleGitHubWebhook handles a GitHub webhook delivery. Pull requests that reference issue keys
+// are linked to the issues, the Slack threads that discussed them are notified, and the issues
+// are transitioned according to the configured rules.
+func (h *SlackHandler) HandleGitHubWebhook(c *gin.Context) {
+	if h.githubSecret == "" || h.issueLinks == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "github integration is not configured"})
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+	if err := github.VerifySignature(h.githubSecret, body, c.GetHeader("X-Hub-Signature-256")); err != nil {
+		logger.GetLogger().Warn("rejected github webhook", zap.Error(err))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+		return
+	}
+
+	switch c.GetHeader("X-GitHub-Event") {
+	case "ping":
+		c.JSON(http.StatusOK, gin.H{"message": "pong"})
+	case "pull_request":
+		var event github.PullRequestEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), githubWebhookTimeout)
+		defer cancel()
+
+		issues := h.handlePullRequestEvent(ctx, &event)
+		c.JSON(http.StatusOK, gin.H{"issues": issues})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "event ignored"})
+	}
+}
+
+// handlePullRequestEvent applies a pull request event to every issue it references and returns those issues
+func (h *SlackHandler) handlePullRequestEvent(ctx context.Context, event *github.PullRequestEvent) []string {
+	outcome := event.Outcome()
+	keys := event.IssueKeys()
+	if outcome == "" || len(keys) == 0 {
+		return []string{}
+	}
+
+	pr := storage.PullRequestRef{
+		Repository: event.Repository.FullName,
+		Number:     event.Number,
+		Title:      event.PullRequest.Title,
+		URL:        event.PullRequest.HTMLURL,
+		State:      outcome,
+		UpdatedAt:  time.Now().UTC(),
+	}
+	for _, key := range keys {
+		links, err := h.linkPullRequest(ctx, key, pr)
+		if err != nil {
+			logger.GetLogger().Error("failed to link pull request", zap.String("issue", key), zap.Error(err))
+		}
+		h.notifyLinkedThreads(key, links.Threads, pullRequestNotice(key, pr, event.PullRequest.User.Login))
+		if status, ok := h.githubRules[outcome]; ok {
+			h.transitionIssue(ctx, key, status)
+		}
+	}
+
+	logger.GetLogger().Info("processed pull request event",
+		zap.String("repository", pr.Repository),
+		zap.Int("number", pr.Number),
+		zap.String("outcome", outcome),
+		zap.Strings("issues", keys))
+	return keys
+}
+
+// linkPullRequest records the pull request's latest state on the issue
+func (h *SlackHandler) linkPullRequest(ctx context.Context, issueKey string, pr storage.PullRequestRef) (storage.IssueLinks, error) {
+	links, err := h.issueLinks.Load(ctx, issueKey)
+	if err != nil {
+		return links, err
+	}
+
+	replaced := false
+	for i, existing := range links.PullRequests {
+		if existing.Repository == pr.Repository && existing.Number == pr.Number {
+			links.PullRequests[i] = pr
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		links.PullRequests = append(links.PullRequests, pr)
+	}
+	return links, h.issueLinks.Save(ctx, issueKey, links)
+}
+
+// pullRequestNotice describes a pull request event for the threads that discussed the issue
+func pullRequestNotice(issueKey string, pr storage.PullRequestRef, author string) string {
+	return fmt.Sprintf("🔀 <%s|%s#%d> %s was *%s* by %s (%s)", pr.URL, pr.Repository, pr.Number, pr.Title, pr.State, author, issueKey)
+}
+
+// notifyLinkedThreads posts the notice to every Slack thread linked to the issue
+func (h *SlackHandler) notifyLinkedThreads(issueKey string, threads []storage.ThreadRef, notice string) {
+	for _, thread := range threads {
+		if _, err := h.sendMarkdownMessage(thread.ChannelID, notice, thread.ThreadTS); err != nil {
+			logger.GetLogger().Warn("failed to notify linked thread",
+				zap.String("issue", issueKey),
+				zap.String("channel", thread.ChannelID),
+				zap.Error(err))
+		}
+	}
+}
+
+// transitionIssue moves the issue to the status through the matching Jira transition, using the
+// personal token of the GitHub integration's user. Failures are logged, the webhook still succeeds.
+func (h *SlackHandler) transitionIssue(ctx context.Context, issueKey, status string) {
+	userToken, err := h.getUserPersonalToken(h.githubUserID)
+	if err != nil {
+		logger.GetLogger().Error("failed to get github user token", zap.Error(err))
+		return
+	}
+	client, err := jira.NewClient(h.jiraURL, userToken)
+	if err != nil {
+		logger.GetLogger().Error("failed to create Jira client", zap.Error(err))
+		return
+	}
+
+	transitions, err := client.Transitions(ctx, issueKey)
+	if err != nil {
+		logger.GetLogger().Error("failed to get transitions", zap.String("issue", issueKey), zap.Error(err))
+		return
+	}
+	match := jira.FindTransition(transitions, status)
+	if match == nil {
+		logger.GetLogger().Info("no transition to status", zap.String("issue", issueKey), zap.String("status", status))
+		return
+	}
+
+	transition := openai.ToolCall{Name: "jira_transition_issue", Args: map[string]interface{}{
+		"issue_key":     issueKey,
+		"transition_id": match.ID,
+	}}
+	err = client.TransitionIssue(ctx, issueKey, match.ID, "")
+	h.recordAudit(ctx, h.githubUserID, "github", transition, nil, err)
+	if err != nil {
+		logger.GetLogger().Error("failed to transition issue", zap.String("issue", issueKey), zap.String("status", status), zap.Error(err))
+		return
+	}
+	logger.GetLogger().Info("transitioned issue", zap.String("issue", issueKey), zap.String("status", status))
+}
+
+// linkThread subscribes the Slack thread to the issues discussed in it, so it hears about their pull requests
+func (h *SlackHandler) linkThread(ctx context.Context, channelID, threadTS string, text string) {
+	if h.issueLinks == nil || h.messengerFor(channelID) != nil {
+		return
+	}
+	for _, key := range issueKeysIn(text) {
+		links, err := h.issueLinks.Load(ctx, key)
+		if err != nil {
+			logger.GetLogger().Warn("failed to load issue links", zap.String("issue", key), zap.Error(err))
+			continue
+		}
+		if linkedThread(links.Threads, channelID, threadTS) {
+			continue
+		}
+		links.Threads = append(links.Threads, storage.ThreadRef{ChannelID: channelID, ThreadTS: threadTS, LinkedAt: time.Now().UTC()})
+		if len(links.Threads) > maxLinkedThreads {
+			links.Threads = links.Threads[len(links.Threads)-maxLinkedThreads:]
+		}
+		if err := h.issueLinks.Save(ctx, key, links); err != nil {
+			logger.GetLogger().Warn("failed to link thread", zap.String("issue", key), zap.Error(err))
+		}
+	}
+}
+
+// linkedThread reports whether the thread is already linked
+func linkedThread(threads []storage.ThreadRef, channelID, threadTS string) bool {
+	for _, thread := range threads {
+		if thread.ChannelID == channelID && thread.ThreadTS == threadTS {
+			return true
+		}
+	}
+	return false
+}
+
+// withPullRequestContext appends the state of the pull requests linked to the issues in the query,
+// so answers about an issue can tell whether its code is in review or merged
+func (h *SlackHandler) withPullRequestContext(ctx context.Context, query string) string {
+	if h.issueLinks == nil {
+		return query
+	}
+
+	var lines []string
+	for i, key := range issueKeysIn(query) {
+		if i == maxPullRequestContext {
+			break
+		}
+		links, err := h.issueLinks.Load(ctx, key)
+		if err != nil {
+			logger.GetLogger().Warn("failed to load issue links", zap.String("issue", key), zap.Error(err))
+			continue
+		}
+		for _, pr := range links.PullRequests {
+			lines = append(lines, fmt.Sprintf("- %s: %s#%d %q is %s (%s, updated %s)",
+				key, pr.Repository, pr.Number, pr.Title, pr.State, pr.URL, pr.UpdatedAt.Format(time.RFC3339)))
+		}
+	}
+	if len(lines) == 0 {
+		return query
+	}
+	return query + "\n\nLinked GitHub pull requests:\n" + strings.Join(lines, "\n")
+}
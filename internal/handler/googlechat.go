@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/googlechat"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// googleChatSpacePrefix starts every Google Chat space name, which serve as channel IDs
+	googleChatSpacePrefix = "spaces/"
+
+	// maxIssueCardIssues caps the number of issues rendered in an answer's card
+	maxIssueCardIssues = 10
+
+	googleChatWelcomeMessage = "👋 Thanks for adding me! Mention me in a thread or send me a direct message to ask about Jira issues."
+)
+
+// HandleGoogleChatEvent handles an interaction event from a Google Chat app. Messages run
+// through the same conversation engine as Slack; progress and answers are posted to the
+// space's thread through the Chat API.
+func (h *SlackHandler) HandleGoogleChatEvent(c *gin.Context) {
+	if h.googleChat == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "google chat is not configured"})
+		return
+	}
+
+	var event googlechat.Event
+	if err := json.NewDecoder(c.Request.Body).Decode(&event); err != nil {
+		logger.GetLogger().Error("failed to parse google chat event", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event"})
+		return
+	}
+
+	switch event.Type {
+	case "ADDED_TO_SPACE":
+		c.JSON(http.StatusOK, googlechat.Message{Text: googleChatWelcomeMessage})
+	case "MESSAGE":
+		if err := h.handleGoogleChatMessage(event); err != nil {
+			logger.GetLogger().Error("failed to handle google chat message", zap.Error(err))
+		}
+		// The answer has already been posted to the thread
+		c.JSON(http.StatusOK, gin.H{})
+	default:
+		c.JSON(http.StatusOK, gin.H{})
+	}
+}
+
+// handleGoogleChatMessage answers a message sent to the app in a space or direct message
+func (h *SlackHandler) handleGoogleChatMessage(event googlechat.Event) error {
+	// Ignore messages from bots to prevent loops
+	if event.User.Type == "BOT" {
+		return nil
+	}
+
+	// argumentText is the message without the app mention
+	text := strings.TrimSpace(event.Message.ArgumentText)
+	if text == "" {
+		text = strings.TrimSpace(event.Message.Text)
+	}
+	if text == "" {
+		return nil
+	}
+
+	space, thread := event.Space.Name, event.Message.Thread.Name
+	return h.runInThread(space, thread, event.Message.Name, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		response, _, err := h.processQuery(ctx, text, nil, space, thread, event.User.Name)
+		if err != nil {
+			return fmt.Errorf("failed to process query: %v", err)
+		}
+
+		message := googlechat.Message{Text: response, Thread: &googlechat.Thread{Name: thread}}
+		if card, ok := h.issueCard(response); ok {
+			message.CardsV2 = []googlechat.CardWithID{card}
+		}
+		if _, err := h.googleChat.CreateMessage(ctx, space, message); err != nil {
+			return fmt.Errorf("failed to post answer: %v", err)
+		}
+		return nil
+	})
+}
+
+// issueCard renders the issues mentioned in an answer as a card with links to Jira
+func (h *SlackHandler) issueCard(answer string) (googlechat.CardWithID, bool) {
+	var widgets []googlechat.Widget
+	for _, key := range issueKeysIn(answer) {
+		if len(widgets) == maxIssueCardIssues {
+			break
+		}
+
+		item := &googlechat.DecoratedText{TopLabel: "Issue", Text: key}
+		if url := h.issueURL(key); url != "" {
+			item.Button = &googlechat.Button{
+				Text:    "Open",
+				OnClick: googlechat.OnClick{OpenLink: &googlechat.OpenLink{URL: url}},
+			}
+		}
+		widgets = append(widgets, googlechat.Widget{DecoratedText: item})
+	}
+	if len(widgets) == 0 {
+		return googlechat.CardWithID{}, false
+	}
+
+	return googlechat.CardWithID{
+		CardID: "issues",
+		Card: googlechat.Card{
+			Header:   &googlechat.CardHeader{Title: "Referenced issues"},
+			Sections: []googlechat.Section{{Widgets: widgets}},
+		},
+	}, true
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"fmt"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/openai"
+
+	"go.uber.org/zap"
+)
+
+// guardText removes prompt injection from text the model is about to read. source names where the
+// text came from in the log, e.g. "query" or the tool that returned it.
+func (h *SlackHandler) guardText(channelID, source, text string) string {
+	cleaned, findings := h.guard.Scan(text)
+	if len(findings) > 0 {
+		logger.GetLogger().Warn("removed possible prompt injection",
+			zap.String("channel", channelID),
+			zap.String("source", source),
+			zap.Strings("patterns", findings))
+	}
+	return cleaned
+}
+
+// guardHistory removes prompt injection from the thread history the model is about to read
+func (h *SlackHandler) guardHistory(channelID string, history []HistoryMessage) []HistoryMessage {
+	guarded := make([]HistoryMessage, len(history))
+	for i, msg := range history {
+		msg.Content = h.guardText(channelID, "history", msg.Content)
+		guarded[i] = msg
+	}
+	return guarded
+}
+
+// guardToolCall blocks a tool call whose arguments reach for another user's token, the token
+// store or an internal endpoint, and alerts the admins since it likely comes from an injection
+func (h *SlackHandler) guardToolCall(conv *conversation, toolCall openai.ToolCall) error {
+	err := h.guard.CheckToolCall(conv.UserID, toolCall.Args)
+	if err == nil {
+		return nil
+	}
+	logger.GetLogger().Warn("tool call blocked by guardrail",
+		zap.String("tool", toolCall.Name),
+		zap.String("channel_id", conv.ChannelID),
+		zap.String("user_id", conv.UserID),
+		zap.Error(err))
+	h.alertAdmins(fmt.Sprintf("🛡️ Blocked a `%s` call in <#%s> for <@%s>: %s\n>_%s_",
+		toolCall.Name, conv.ChannelID, conv.UserID, err.Error(), printJSON(sanitizeArgs(toolCall.Args))))
+	return err
+}
@@ -10,14 +10,18 @@ import (
 )
 
 // handleAppMentionEvent handles app mention events
-func (h *SlackHandler) handleAppMentionEvent(ev *slackevents.AppMentionEvent) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+func (h *SlackHandler) handleAppMentionEvent(ctx context.Context, ev *slackevents.AppMentionEvent) error {
+	// The conversation outlives the request that delivered the event, only its trace carries over
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Minute)
 	defer cancel()
 
 	// Ignore messages from bots to prevent loops
 	if ev.BotID != "" {
 		return nil
 	}
+	if h.handleAttachRequest(ctx, ev.Channel, ev.ThreadTimeStamp, ev.TimeStamp, ev.User, ev.Text) {
+		return nil
+	}
 
 	var history []HistoryMessage
 	var err error
@@ -31,20 +35,20 @@ func (h *SlackHandler) handleAppMentionEvent(ev *slackevents.AppMentionEvent) er
 		// If the message is in a thread, get the thread history
 		history, err = h.getThreadHistory(ev.Channel, threadTS)
 		if err != nil {
-			_, _ = h.sendMarkdownMessage(ev.Channel, fmt.Sprintf(defaultErrorMessage, err.Error()), threadTS)
+			_, _ = h.sendMarkdownMessage(ev.Channel, errorMessage(ctx, err), threadTS)
 			logger.GetLogger().Error(fmt.Sprintf("failed to get thread history: %v", err))
 			return fmt.Errorf("failed to get thread history: %v", err)
 		}
 	}
 
 	// Process the query with context
-	response, err := h.processQuery(ctx, ev.Text, history, ev.Channel, threadTS, ev.User)
+	response, rendered, err := h.processQuery(ctx, ev.Text, history, ev.Channel, threadTS, ev.User)
 	if err != nil {
-		return fmt.Errorf("failed to process query: %v", err)
+		return fmt.Errorf("failed to process query: %w", err)
 	}
 
 	// Post the response in the thread
-	_, _ = h.sendMarkdownMessage(ev.Channel, response, threadTS)
+	h.postAnswer(ctx, ev.Channel, threadTS, ev.User, ev.Text, response, rendered)
 
 	return nil
 }
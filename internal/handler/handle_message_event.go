@@ -11,22 +11,22 @@ import (
 )
 
 // handleMessageEvent handles direct messages and channel messages that mention the bot
-func (h *SlackHandler) handleMessageEvent(ev *slackevents.MessageEvent) error {
+func (h *SlackHandler) handleMessageEvent(ctx context.Context, ev *slackevents.MessageEvent) error {
 	// Ignore messages from bots to prevent loops
 	if ev.BotID != "" || ev.SubType == "bot_message" || ev.SubType == "message_changed" {
 		return nil
 	}
 
 	// Only handle direct messages (DMs) or messages that mention the bot
-	botInfo, err := h.api.AuthTest()
+	botUserID, err := h.botUserID(ev.Channel)
 	if err != nil {
-		return fmt.Errorf("failed to get bot info: %v", err)
+		return err
 	}
 
 	// Check if this is a direct message (including multi-person IMs)
 	isDM := ev.ChannelType == "im" || ev.ChannelType == "mpim"
 	// Check if the message mentions the bot
-	isBotMention := strings.Contains(ev.Text, fmt.Sprintf("<@%s>", botInfo.UserID))
+	isBotMention := strings.Contains(ev.Text, fmt.Sprintf("<@%s>", botUserID))
 
 	// Skip if not a DM and not mentioning the bot
 	if !isDM && !isBotMention {
@@ -36,13 +36,18 @@ func (h *SlackHandler) handleMessageEvent(ev *slackevents.MessageEvent) error {
 	// For non-DM channels, remove the bot mention from the text to clean up the query
 	text := ev.Text
 	if !isDM && isBotMention {
-		text = strings.ReplaceAll(text, fmt.Sprintf("<@%s>", botInfo.UserID), "")
+		text = strings.ReplaceAll(text, fmt.Sprintf("<@%s>", botUserID), "")
 		text = strings.TrimSpace(text)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	// The conversation outlives the request that delivered the event, only its trace carries over
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Minute)
 	defer cancel()
 
+	if h.handleAttachRequest(ctx, ev.Channel, ev.ThreadTimeStamp, ev.TimeStamp, ev.User, text) {
+		return nil
+	}
+
 	var history []HistoryMessage
 	// If this is a message in a thread, get the thread history
 	threadTS := ev.ThreadTimeStamp
@@ -53,19 +58,34 @@ func (h *SlackHandler) handleMessageEvent(ev *slackevents.MessageEvent) error {
 		// If the message is in a thread, get the thread history
 		history, err = h.getThreadHistory(ev.Channel, threadTS)
 		if err != nil {
-			_, _ = h.sendMarkdownMessage(ev.Channel, fmt.Sprintf(defaultErrorMessage, err.Error()), threadTS)
+			_, _ = h.sendMarkdownMessage(ev.Channel, errorMessage(ctx, err), threadTS)
 			logger.GetLogger().Error(fmt.Sprintf("failed to get thread history: %v", err))
 			return fmt.Errorf("failed to get thread history: %v", err)
 		}
 	}
 
+	// Screenshots shared in a direct message are read, so "file a bug about this" can refer to them
+	query := text
+	if isDM {
+		query = h.withScreenshots(ctx, text, ev.Channel, ev.ThreadTimeStamp, ev.TimeStamp)
+	}
+
 	// Process the query with context
-	response, err := h.processQuery(ctx, text, history, ev.Channel, threadTS, ev.User)
+	response, rendered, err := h.processQuery(ctx, query, history, ev.Channel, threadTS, ev.User)
 	if err != nil {
-		return fmt.Errorf("failed to process query: %v", err)
+		return fmt.Errorf("failed to process query: %w", err)
 	}
 	// Post the response in the thread
-	_, _ = h.sendMarkdownMessage(ev.Channel, response, threadTS)
+	h.postAnswer(ctx, ev.Channel, threadTS, ev.User, text, response, rendered)
 
 	return nil
 }
+
+// addressedToBot reports whether the message is a direct message or mentions the bot
+func (h *SlackHandler) addressedToBot(ev *slackevents.MessageEvent) bool {
+	if ev.ChannelType == "im" || ev.ChannelType == "mpim" {
+		return true
+	}
+	botUserID, err := h.botUserID(ev.Channel)
+	return err == nil && strings.Contains(ev.Text, fmt.Sprintf("<@%s>", botUserID))
+}
@@ -3,9 +3,11 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"jira_helper/internal/i18n"
 	"jira_helper/internal/logger"
-	"slices"
+	"jira_helper/internal/policy"
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
@@ -17,8 +19,10 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
+	"go.opentelemetry.io/otel/attribute"
 
 	"jira_helper/internal/service/openai"
+	"jira_helper/internal/tracing"
 )
 
 func (h *SlackHandler) HandleRequest(c *gin.Context) {
@@ -58,22 +62,10 @@ func (h *SlackHandler) HandleRequest(c *gin.Context) {
 
 	// Handle event callbacks
 	if eventsAPIEvent.Type == slackevents.CallbackEvent {
-		innerEvent := eventsAPIEvent.InnerEvent
-		switch event := innerEvent.Data.(type) {
-		case *slackevents.MessageEvent:
-			if err := h.handleMessageEvent(event); err != nil {
-				logger.Error("failed to handle message event", zap.Error(err))
-				c.JSON(200, gin.H{"error": "failed to handle message event"})
-				return
-			}
-		case *slackevents.AppMentionEvent:
-			if err := h.handleAppMentionEvent(event); err != nil {
-				logger.Error("failed to handle message event", zap.Error(err))
-				c.JSON(200, gin.H{"error": "failed to handle message event"})
-				return
-			}
-		default:
-			logger.Warn("unsupported event type", zap.String("event_type", fmt.Sprintf("%T", innerEvent.Data)))
+		if err := h.handleCallbackEvent(c.Request.Context(), body, eventsAPIEvent); err != nil {
+			logger.Error("failed to handle message event", zap.Error(err))
+			c.JSON(200, gin.H{"error": "failed to handle message event"})
+			return
 		}
 	}
 
@@ -81,6 +73,91 @@ func (h *SlackHandler) HandleRequest(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "ok"})
 }
 
+// handleCallbackEvent hands the event to the worker when an event queue is configured, so Slack
+// is acknowledged within its 3 second limit, and otherwise processes it directly
+func (h *SlackHandler) handleCallbackEvent(ctx context.Context, body []byte, eventsAPIEvent slackevents.EventsAPIEvent) error {
+	event, err := newQueuedEvent(ctx, body)
+	if err != nil {
+		return err
+	}
+	if h.duplicateEvent(ctx, event) {
+		return nil
+	}
+	if h.eventQueue != nil {
+		err := h.enqueueEvent(ctx, event)
+		if err == nil {
+			return nil
+		}
+		logger.GetLogger().Error("failed to enqueue slack event, processing synchronously", zap.Error(err))
+	}
+	return h.processEvent(ctx, event, eventsAPIEvent)
+}
+
+// dispatchCallbackEvent routes an event callback to its handler. With a worker pool, events run
+// concurrently up to the pool size and one at a time per Slack thread.
+func (h *SlackHandler) dispatchCallbackEvent(ctx context.Context, eventsAPIEvent slackevents.EventsAPIEvent) error {
+	innerEvent := eventsAPIEvent.InnerEvent
+	switch event := innerEvent.Data.(type) {
+	case *slackevents.MessageEvent:
+		h.workspaces.remember(eventsAPIEvent.TeamID, event.Channel, event.User)
+		if !h.channelAllowed(ctx, event.Channel) {
+			return nil
+		}
+		// A stop request must not wait behind the conversation it stops
+		if event.BotID == "" && h.handleStopRequest(event.Channel, event.ThreadTimeStamp, event.User, event.Text, h.addressedToBot(event)) {
+			return nil
+		}
+		return h.runInThread(event.Channel, event.ThreadTimeStamp, event.TimeStamp, func() error {
+			return h.handleMessageEvent(ctx, event)
+		})
+	case *slackevents.AppMentionEvent:
+		h.workspaces.remember(eventsAPIEvent.TeamID, event.Channel, event.User)
+		if !h.channelAllowed(ctx, event.Channel) {
+			// Tell whoever asked, so the silence is not mistaken for an outage
+			if event.BotID == "" {
+				_ = h.sendEphemeralSlackMessage(event.Channel, event.User, "🔒 I'm not enabled in this channel.", event.ThreadTimeStamp)
+			}
+			return nil
+		}
+		if event.BotID == "" && h.handleStopRequest(event.Channel, event.ThreadTimeStamp, event.User, event.Text, true) {
+			return nil
+		}
+		return h.runInThread(event.Channel, event.ThreadTimeStamp, event.TimeStamp, func() error {
+			return h.handleAppMentionEvent(ctx, event)
+		})
+	case *slackevents.AppHomeOpenedEvent:
+		h.workspaces.remember(eventsAPIEvent.TeamID, event.Channel, event.User)
+		h.handleAppHomeOpened(event)
+		return nil
+	case *slackevents.AppUninstalledEvent:
+		h.handleAppUninstalled(eventsAPIEvent.TeamID)
+		return nil
+	case *slackevents.ReactionAddedEvent:
+		h.workspaces.remember(eventsAPIEvent.TeamID, event.Item.Channel, event.User)
+		if !h.channelAllowed(ctx, event.Item.Channel) {
+			return nil
+		}
+		h.handleReactionAdded(ctx, event)
+		return nil
+	default:
+		logger.GetLogger().Warn("unsupported event type", zap.String("event_type", fmt.Sprintf("%T", innerEvent.Data)))
+		return nil
+	}
+}
+
+// runInThread runs fn through the worker pool, serialized with other work on the same thread.
+// A panic is returned as an error, so it fails the event instead of the process.
+func (h *SlackHandler) runInThread(channelID, threadTS, ts string, fn func() error) error {
+	fn = recoverPanic(fn)
+	if h.workerPool == nil {
+		return fn()
+	}
+	if threadTS == "" {
+		threadTS = ts
+	}
+	return h.workerPool.Do(context.Background(), channelID+"/"+threadTS, fn)
+}
+
 // getUserPersonalToken retrieves the user's personal token from the token store.
 func (h *SlackHandler) getUserPersonalToken(userID string) (string, error) {
 	if userID == "" {
@@ -89,53 +166,152 @@ func (h *SlackHandler) getUserPersonalToken(userID string) (string, error) {
 	return h.tokenStore.GetToken(userID)
 }
 
-// processQuery handles the main conversation flow with the AI model
-func (h *SlackHandler) processQuery(ctx context.Context, query string, history []HistoryMessage, channelID string, threadTS string, userID string) (string, error) {
-	// Initialize and send progress message
-	initialMessage := "⏳ Analyzing your request to determine the best way to help you..."
-	timestamp, _ := h.sendMarkdownMessage(channelID, initialMessage, threadTS)
+// processQuery handles the main conversation flow with the AI model. Besides the answer, it
+// returns the structured tool result the answer is rendered with, if there is one.
+func (h *SlackHandler) processQuery(ctx context.Context, query string, history []HistoryMessage, channelID string, threadTS string, userID string) (answer string, rendered *renderedResult, err error) {
+	ctx, span := tracing.Start(ctx, "process_query",
+		attribute.String("slack.channel", channelID),
+		attribute.String("slack.thread_ts", threadTS),
+		attribute.String("slack.user", userID))
+	defer func() { tracing.End(span, err) }()
+
+	// A --dry-run flag shows the request's writes instead of sending them to Jira
+	if stripped, ok := cutDryRunFlag(query); ok {
+		query, ctx = stripped, withDryRun(ctx)
+	}
+	// "on <instance>: ..." works with another Jira instance than the channel's
+	if stripped, instance, ok := h.cutInstanceSelector(query); ok {
+		query, ctx = stripped, withJiraInstance(ctx, instance)
+	}
+	instance := h.jiraInstanceOf(ctx, channelID)
+
+	// Reply in the language the user chose, or the one the thread is written in
+	preferences := h.userPreferences(ctx, userID)
+	lang := preferredLanguage(preferences, detectLanguage(query, history))
+	ctx = i18n.WithLang(ctx, lang)
+
+	// Register the conversation so a shutdown waits for it
+	ctx, end, err := h.beginConversation(ctx, channelID, threadTS, userID)
+	if err != nil {
+		_, _ = h.sendMarkdownMessage(channelID, i18n.T(lang, i18n.ShuttingDown), threadTS)
+		return "", nil, err
+	}
+	defer end()
+
+	// Refuse the query once the user has used up their quota
+	if err := h.checkQuota(ctx, userID); err != nil {
+		_, _ = h.sendMarkdownMessage(channelID, quotaMessage(ctx, err), threadTS)
+		return "", nil, err
+	}
+
+	h.recordQuery(ctx, userID, channelID, query)
+
+	// Initialize and send progress message, unless the channel keeps progress out of the thread
+	initialMessage := i18n.T(lang, i18n.Analyzing)
+	var timestamp string
+	switch h.messageRule(channelID).Progress {
+	case policy.VisibilityThread:
+		timestamp, _ = h.sendMarkdownMessage(channelID, initialMessage, threadTS)
+	case policy.VisibilityEphemeral:
+		_ = h.sendEphemeralSlackMessage(channelID, userID, initialMessage, threadTS)
+	}
 	slackMessageLines := []string{initialMessage}
 
+	// Let the user stop the conversation, the button goes away once it is over
+	stopButton := h.postStopButton(lang, channelID, threadTS)
+	defer h.removeStopButton(channelID, stopButton)
+
 	// Prepare tools and messages
-	openAITools, messages, err := h.prepareConversation(ctx, query, history)
+	openAITools, messages, err := h.prepareConversation(ctx, query, history, channelID, threadTS)
 	if err != nil {
-		_, _ = h.sendMarkdownMessage(channelID, fmt.Sprintf(defaultErrorMessage, err.Error()), threadTS)
-		return "", err
+		_, _ = h.sendMarkdownMessage(channelID, errorMessage(ctx, err), threadTS)
+		return "", nil, err
 	}
 
-	// Fetch user's personal token if available
-	userToken, err := h.getUserPersonalToken(userID)
+	// Fetch user's personal token if available, or the token of the instance
+	userToken, err := h.jiraToken(instance, userID)
 	if err != nil {
-		_, _ = h.sendMarkdownMessage(channelID, fmt.Sprintf(defaultErrorMessage, err.Error()), threadTS)
-		return "", fmt.Errorf("failed to get user personal token: %v", err)
+		_, _ = h.sendMarkdownMessage(channelID, errorMessage(ctx, err), threadTS)
+		return "", nil, fmt.Errorf("failed to get user personal token: %v", err)
 	}
 
+	conv := &conversation{
+		ID:                newConversationID(channelID, threadTS),
+		ChannelID:         channelID,
+		ThreadTS:          threadTS,
+		UserID:            userID,
+		Query:             query,
+		TeamID:            h.workspaces.teamOf(channelID),
+		Language:          lang,
+		Timestamp:         timestamp,
+		SlackMessageLines: slackMessageLines,
+		HistoryTS:         latestTS(history),
+		Messages:          messages,
+		DryRun:            h.dryRun(ctx),
+		JiraInstance:      instance,
+	}
+	if identity := h.jiraIdentity(ctx, channelID, userID); identity != nil {
+		conv.JiraUser, conv.JiraName = identity.JiraUsername, identity.DisplayName
+	}
+	h.applyPreferences(ctx, conv, preferences)
+
 	// Run the conversation loop with the user token
-	return h.runConversationLoop(ctx, channelID, threadTS, timestamp, messages, openAITools, slackMessageLines, userToken)
+	ctx, recordUsage := h.trackUsage(ctx, conv)
+	answer, err = h.runConversationLoop(ctx, conv, openAITools, userToken)
+	if summary, stopped := stoppedAnswer(ctx, conv); stopped {
+		answer, err = summary, nil
+	}
+	recordUsage(err)
+	h.completeConversation(ctx, conv, answer, err)
+	return answer, conv.renderedAnswer(), err
 }
 
 // prepareConversation sets up the tools and initial messages for the conversation
-func (h *SlackHandler) prepareConversation(ctx context.Context, query string, history []HistoryMessage) ([]openai.Tool, []azopenai.ChatRequestMessageClassification, error) {
+func (h *SlackHandler) prepareConversation(ctx context.Context, query string, history []HistoryMessage, channelID, threadTS string) ([]openai.Tool, []azopenai.ChatRequestMessageClassification, error) {
+	// Get available tools in OpenAI format
+	openAITools, err := h.availableTools(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Create initial messages, with the state of the pull requests linked to the queried issues.
+	// Threads with a memory continue from it, tool calls and results included.
+	query = h.withPullRequestContext(ctx, query)
+	query = h.guardText(channelID, "query", query)
+	history = h.guardHistory(channelID, history)
+	messages, ok := h.recallConversation(ctx, channelID, threadTS, query, history)
+	if !ok {
+		messages = h.createInitialMessages(query, history)
+	}
+
+	return openAITools, messages, nil
+}
+
+// availableTools returns the MCP server's tools in OpenAI format. The tool list does not
+// change while the server runs, so it is fetched once and reused.
+func (h *SlackHandler) availableTools(ctx context.Context) ([]openai.Tool, error) {
+	h.toolsMu.Lock()
+	defer h.toolsMu.Unlock()
+	if h.tools != nil {
+		return h.tools, nil
+	}
+
 	// Ensure defaultMcpClient is initialized (lazy load)
 	if err := h.ensureDefaultMcpClient(); err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize MCP client: %v", err)
+		return nil, fmt.Errorf("failed to initialize MCP client: %v", err)
 	}
 
-	// Get available tools
 	tools, err := h.defaultMcpClient.ListTools(ctx, mcp.ListToolsRequest{})
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to list tools: %v", err)
+		return nil, fmt.Errorf("failed to list tools: %v", err)
 	}
 
-	//logger.GetLogger().Info("Available tools", zap.Any("tools", tools.Tools))
-
-	// Convert tools to OpenAI format
-	openAITools := h.convertToolsToOpenAIFormat(tools.Tools)
-
-	// Create initial messages
-	messages := h.createInitialMessages(query, history)
-
-	return openAITools, messages, nil
+	h.tools = h.convertToolsToOpenAIFormat(tools.Tools)
+	for _, tool := range h.localTools() {
+		h.tools = append(h.tools, tool.definition)
+	}
+	h.toolsReady.Store(true)
+	return h.tools, nil
 }
 
 // convertToolsToOpenAIFormat converts MCP tools to OpenAI tool format
@@ -152,114 +328,183 @@ func (h *SlackHandler) convertToolsToOpenAIFormat(tools []mcp.Tool) []openai.Too
 	return openAITools
 }
 
-var writableJiraTools = []string{
-	"jira_create_issue",
-	"jira_batch_create_issues",
-	"jira_update_issue",
-	"jira_delete_issue",
-	"jira_add_comment",
-	"jira_add_worklog",
-	"jira_link_to_epic",
-	"jira_create_issue_link",
-	"jira_remove_issue_link",
-	"jira_transition_issue",
-	"jira_create_sprint",
-	"jira_update_sprint",
-	"confluence_add_label",
-	"confluence_create_page",
-	"confluence_update_page",
-	"confluence_delete_page",
-}
-
 // runConversationLoop handles the main conversation loop with the AI model
-func (h *SlackHandler) runConversationLoop(ctx context.Context, channelID, threadTS, timestamp string, messages []azopenai.ChatRequestMessageClassification, openAITools []openai.Tool, slackMessageLines []string, userToken string) (string, error) {
-	maxRounds := 20
-	currentRound := 0
-
+func (h *SlackHandler) runConversationLoop(ctx context.Context, conv *conversation, openAITools []openai.Tool, userToken string) (string, error) {
 	// Get the appropriate MCP client for this user
-	mcpClient, cleanup, err := h.getMcpClient(userToken)
+	mcpClient, cleanup, err := h.mcpClientOf(conv.JiraInstance, userToken)
 	if err != nil {
-		_, _ = h.sendMarkdownMessage(channelID, fmt.Sprintf(defaultErrorMessage, err.Error()), threadTS)
+		_, _ = h.sendMarkdownMessage(conv.ChannelID, errorMessage(ctx, err), conv.ThreadTS)
 		return "", fmt.Errorf("failed to get MCP client: %v", err)
 	}
 	defer cleanup()
 
-	for currentRound < maxRounds {
-		// Trim messages if needed
-		messages = h.trimMessages(messages)
+	return h.continueConversation(ctx, conv, openAITools, mcpClient, userToken)
+}
 
-		// Get AI response
-		response, err := h.aiClient.ChatWithTools(ctx, messages, openAITools)
-		if err != nil {
-			_, _ = h.sendMarkdownMessage(channelID, fmt.Sprintf(defaultErrorMessage, err.Error()), threadTS)
-			return "", fmt.Errorf("failed to get chat completion: %v", err)
+// continueConversation runs rounds until the model answers, the conversation is handed off or
+// paused for an approval, or the round limit is reached
+func (h *SlackHandler) continueConversation(ctx context.Context, conv *conversation, openAITools []openai.Tool, mcpClient ToolCaller, userToken string) (string, error) {
+	maxRounds := h.limits.For(conv.ChannelID).MaxRounds
+	for conv.Round < maxRounds {
+		// Long conversations continue in Step Functions so they are not cut off by Lambda limits
+		if h.shouldHandOff(ctx, conv) {
+			return "", h.handOff(ctx, conv)
 		}
 
-		// Handle complete response
-		if response.IsComplete {
-			return response.Content, nil
+		response, done, err := h.runRound(ctx, conv, openAITools, mcpClient, userToken)
+		if err != nil || done {
+			return response, err
 		}
+	}
+
+	return "", nil
+}
+
+// runRound runs a single AI/tool round of the conversation. It reports done together with the
+// final response once the model has answered or the round limit is reached.
+func (h *SlackHandler) runRound(ctx context.Context, conv *conversation, openAITools []openai.Tool, mcpClient ToolCaller, userToken string) (string, bool, error) {
+	channelID, threadTS := conv.ChannelID, conv.ThreadTS
+	ctx, span := tracing.Start(ctx, "conversation_round", attribute.String("conversation.id", conv.ID))
+	defer span.End()
+
+	// Progress updates are batched and sent to Slack at most once per second
+	progress := h.newProgressMessage(conv)
+	defer progress.Close()
+
+	// Keep the prompt within the model's context window
+	conv.Messages = h.fitContext(ctx, conv.Messages, openAITools)
 
-		// Update progress with AI response
-		if response.Content != "" {
-			slackMessageLines = append(slackMessageLines, response.Content)
-			_ = h.updateMessage(channelID, timestamp, strings.Join(slackMessageLines, "\n\n"))
+	// Get AI response
+	response, err := h.chat(ctx, conv, openAITools, progress)
+	if err != nil {
+		if !stopRequested(ctx) {
+			_, _ = h.sendMarkdownMessage(channelID, errorMessage(ctx, err), threadTS)
 		}
+		return "", true, fmt.Errorf("failed to get chat completion: %v", err)
+	}
 
-		// Handle tool calls
-		for _, toolCall := range response.ToolCalls {
-			// If the tool is in below list and userToken is empty, should not call and return error
-			if slices.Contains(writableJiraTools, toolCall.Name) && userToken == "" {
-				_, _ = h.sendMarkdownMessage(channelID, fmt.Sprintf("❌ Permission denied. You should set your personal token first to use `%s`", toolCall.Name), threadTS)
-				return "", fmt.Errorf("you don't have permission to use this tool")
-			}
+	// Handle complete response, the caller posts it so it no longer needs a preview
+	if response.IsComplete {
+		progress.SetDraft("")
+		return response.Content, true, nil
+	}
 
-			// Add tool call to messages
-			messages = h.addToolCallToMessages(messages, toolCall)
+	// Update progress with AI response
+	progress.Append(response.Content)
 
-			// Update progress with current tool
-			slackMessage := fmt.Sprintf("🔄 _Calling Tool *%s*_", toolCall.Name)
-			if len(toolCall.Args) > 0 {
-				slackMessage += fmt.Sprintf("\n>_%s_", printJSON(toolCall.Args))
-			}
+	// Handle tool calls, a write waiting for approval ends the conversation until it is approved
+	paused, err := h.runToolCalls(ctx, conv, response.ToolCalls, mcpClient, userToken, progress, 0)
+	if paused || err != nil {
+		return "", true, err
+	}
+	return h.finishRound(conv, response.Content)
+}
 
-			slackMessageLines = append(slackMessageLines, slackMessage)
-			_ = h.updateMessage(channelID, timestamp, strings.Join(slackMessageLines, "\n\n"))
-
-			// Execute tool and handle response
-			toolResult, err := h.executeToolWithClient(ctx, toolCall, mcpClient)
-			if err != nil {
-				messages = append(messages, &azopenai.ChatRequestToolMessage{
-					ToolCallID: &toolCall.ID,
-					Content:    azopenai.NewChatRequestToolMessageContent(err.Error()),
-				})
-				continue
-			}
+// runToolCalls executes the model's tool calls in order. When a write needs the user's approval,
+// the remaining calls are set aside and paused is reported. approved is the number of calls at
+// the start the user already approved.
+func (h *SlackHandler) runToolCalls(ctx context.Context, conv *conversation, toolCalls []openai.ToolCall, mcpClient ToolCaller, userToken string, progress *progressMessage, approved int) (bool, error) {
+	channelID := conv.ChannelID
+
+	// Changes to many issues at once only run after the user approves a preview of all of them
+	if approved == 0 && !conv.DryRun && h.needsBulkApproval(ctx, channelID, toolCalls) {
+		return true, h.requestApproval(ctx, conv, toolCalls, true, userToken)
+	}
 
-			// Process successful tool result
-			messages, timestamp, slackMessageLines = h.processToolResult(ctx, channelID, timestamp, threadTS, slackMessageLines, toolCall, toolResult, messages)
+	for i, toolCall := range toolCalls {
+		// Run no further tools once the conversation is stopped or interrupted
+		if ctx.Err() != nil {
+			return false, context.Cause(ctx)
+		}
+		isWrite := h.toolPolicy.IsWrite(toolCall.Name)
+		changesJira := isWrite && !conv.DryRun // Dry runs show writes instead of sending them
+		conv.Rendered = nil
+
+		// Refuse tools the policy or guardrails deny, and tell the model why
+		call := &ToolCall{Conversation: conv.info(), Call: toolCall, Write: isWrite, conv: conv, client: mcpClient, userToken: userToken, progress: progress}
+		if err := h.admitToolCall(ctx, call); err != nil {
+			h.sendNotice(conv, refusalNotice(err))
+			conv.Messages = h.addToolCallToMessages(conv.Messages, toolCall)
+			conv.Messages = appendToolError(conv.Messages, toolCall, err)
+			continue
 		}
 
-		currentRound++
+		// Ask before writing, unless the call is already approved or cannot run in this channel anyway
+		if changesJira && i >= approved && h.needsApproval(ctx, channelID) && h.scopeToolCall(channelID, &toolCall) == nil {
+			return true, h.requestApproval(ctx, conv, toolCalls[i:], false, userToken)
+		}
 
-		// Check for maximum rounds
-		if currentRound >= maxRounds {
-			return h.handleMaxRoundsReached(channelID, threadTS, response.Content)
+		// If the tool is in below list and userToken is empty, should not call and return error
+		if changesJira && userToken == "" {
+			h.sendNotice(conv, i18n.T(conv.Language, i18n.SetTokenFirst, toolCall.Name))
+			return false, fmt.Errorf("you don't have permission to use this tool")
 		}
+
+		// Restrict the tool call to the channel's projects before it is recorded
+		scopeErr := h.scopeToolCall(channelID, &toolCall)
+
+		// Add tool call to messages
+		conv.Messages = h.addToolCallToMessages(conv.Messages, toolCall)
+		if scopeErr != nil {
+			conv.Messages = appendToolError(conv.Messages, toolCall, scopeErr)
+			continue
+		}
+
+		// Execute the tool through the middleware, which may still refuse it
+		call.Call = toolCall
+		toolResult, err := h.toolChain(ctx, call)
+		var refusal *Refusal
+		if errors.As(err, &refusal) {
+			h.sendNotice(conv, refusal.Notice)
+			conv.Messages = appendToolError(conv.Messages, toolCall, err)
+			continue
+		}
+		if changesJira {
+			conv.Wrote = true
+		}
+		// Only explain a Jira permission problem once per conversation
+		if failure := jiraAuthFailureOf(toolResult, err); failure != jiraAuthOK && !conv.AuthGuidanceSent {
+			h.sendJiraAuthGuidance(conv, failure, userToken != "", toolCall.Name)
+			conv.AuthGuidanceSent = true
+		}
+		if err != nil {
+			conv.Messages = appendToolError(conv.Messages, toolCall, err)
+			continue
+		}
+
+		// Process successful tool result
+		if toolResult != nil && !toolResult.IsError {
+			conv.Steps = append(conv.Steps, completedStep(toolCall.Name, changesJira))
+			if toolCall.Name == downloadAttachmentsTool {
+				h.shareDownloadedAttachments(ctx, conv, toolCall, toolResult)
+			}
+		}
+		conv.Messages = h.processToolResult(ctx, conv, progress, toolCall, toolResult, conv.Messages)
+		conv.Rendered = h.renderableResult(conv, toolCall, toolResult)
 	}
+	return false, nil
+}
 
-	return "", nil
+// finishRound counts the round, and ends the conversation with a partial response at the round limit
+func (h *SlackHandler) finishRound(conv *conversation, lastResponse string) (string, bool, error) {
+	conv.Round++
+
+	// Check for maximum rounds
+	if conv.Round >= h.limits.For(conv.ChannelID).MaxRounds {
+		finalResponse, err := h.handleMaxRoundsReached(conv, lastResponse)
+		return finalResponse, true, err
+	}
+	return "", false, nil
 }
 
-// trimMessages ensures messages array doesn't exceed maximum size
-func (h *SlackHandler) trimMessages(messages []azopenai.ChatRequestMessageClassification) []azopenai.ChatRequestMessageClassification {
-	maxMessages := 21 // 1 system prompt + 20 recent messages
-	if len(messages) > maxMessages {
-		systemPrompt := messages[0:1]
-		rest := messages[len(messages)-20:]
-		return append(systemPrompt, rest...)
+// chatWithTools gets the model's next response. With streaming enabled, the text is previewed in
+// the progress message while it is being generated.
+func (h *SlackHandler) chatWithTools(ctx context.Context, req *ChatRequest) (*openai.ChatResponse, error) {
+	streamer, ok := h.aiClient.(StreamingAIProvider)
+	if !h.streaming || !ok || !h.featureEnabled(ctx, FeatureStreaming) {
+		return h.aiClient.ChatWithTools(ctx, req.Messages, req.Tools)
 	}
-	return messages
+	return streamer.ChatWithToolsStream(ctx, req.Messages, req.Tools, req.progress.SetDraft)
 }
 
 // addToolCallToMessages adds a tool call to the messages array
@@ -279,22 +524,41 @@ func (h *SlackHandler) addToolCallToMessages(messages []azopenai.ChatRequestMess
 	})
 }
 
-// executeToolWithClient executes a tool call using the provided MCP client.
-func (h *SlackHandler) executeToolWithClient(ctx context.Context, toolCall openai.ToolCall, mcpClient interface {
-	CallTool(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
-}) (*mcp.CallToolResult, error) {
+// appendToolError answers a tool call with an error so the model can react to it
+func appendToolError(messages []azopenai.ChatRequestMessageClassification, toolCall openai.ToolCall, err error) []azopenai.ChatRequestMessageClassification {
+	return append(messages, &azopenai.ChatRequestToolMessage{
+		ToolCallID: to.Ptr(toolCall.ID),
+		Content:    azopenai.NewChatRequestToolMessageContent(err.Error()),
+	})
+}
+
+// executeToolWithClient executes a tool call using the provided MCP client. Transient failures are
+// retried, so only failures that persist reach the conversation.
+func (h *SlackHandler) executeToolWithClient(ctx context.Context, toolCall openai.ToolCall, mcpClient ToolCaller) (*mcp.CallToolResult, error) {
 	request := mcp.CallToolRequest{}
 	request.Params.Name = toolCall.Name
 	request.Params.Arguments = toolCall.Args
 
-	return mcpClient.CallTool(ctx, request)
+	return callToolWithRetry(ctx, mcpClient, request, h.toolPolicy.IsWrite(toolCall.Name))
 }
 
 // processToolResult handles a successful tool execution result
-func (h *SlackHandler) processToolResult(ctx context.Context, channelID, timestamp string, threadTS string, slackMessageLines []string, toolCall openai.ToolCall, result *mcp.CallToolResult, messages []azopenai.ChatRequestMessageClassification) ([]azopenai.ChatRequestMessageClassification, string, []string) {
-	// Format and summarize tool result
-	toolResultStr := printToolResult(result)
-	toolResultStr, _ = h.summarizeIfTooLong(ctx, toolResultStr)
+func (h *SlackHandler) processToolResult(ctx context.Context, conv *conversation, progress *progressMessage, toolCall openai.ToolCall, result *mcp.CallToolResult, messages []azopenai.ChatRequestMessageClassification) []azopenai.ChatRequestMessageClassification {
+	channelID := conv.ChannelID
+
+	// Format the tool result and withhold it if it exposes projects restricted from this channel.
+	// Timestamps are given in the user's time zone, so the model does not convert them itself.
+	toolResultStr := h.guardText(channelID, toolCall.Name, datesOf(conv).Localize(printToolResult(result)))
+	if violations := h.boundaries.Violations(channelID, toolResultStr, projectKeyArg(toolCall.Args)); len(violations) > 0 {
+		logger.GetLogger().Warn("withheld restricted project data",
+			zap.String("channel", channelID),
+			zap.String("tool", toolCall.Name),
+			zap.Strings("projects", violations))
+		toolResultStr = fmt.Sprintf(restrictedDataMessage, strings.Join(violations, ", "))
+	}
+
+	// Summarize tool result
+	toolResultStr, _ = h.summarizeIfTooLong(ctx, toolResultStr, h.limits.For(channelID).SummarizeThreshold)
 
 	// Add tool response to messages
 	messages = append(messages, &azopenai.ChatRequestToolMessage{
@@ -302,91 +566,23 @@ func (h *SlackHandler) processToolResult(ctx context.Context, channelID, timesta
 		Content:    azopenai.NewChatRequestToolMessageContent(toolResultStr),
 	})
 
-	// Update progress message
-	title := h.formatToolCallMessage(toolCall.Name, toolCall.Args, nil)
-	slackMessage := h.createCollapsibleBlocks(title, formatCallToolResult(toolResultStr), false)
-
-	if h.shouldCreateNewMessage(slackMessageLines, slackMessage) {
-		timestamp, _ = h.sendMarkdownMessage(channelID, slackMessage, threadTS)
-		slackMessageLines = []string{}
-	} else {
-		slackMessageLines = append(slackMessageLines, slackMessage)
-		_ = h.updateMessage(channelID, timestamp, strings.Join(slackMessageLines, "\n\n"))
-	}
-	slackMessageLines = append(slackMessageLines, slackMessage)
+	// Update progress message, masking any credentials before posting to Slack
+	title := h.formatToolCallMessage(toolCall.Name, sanitizeArgs(toolCall.Args), nil)
+	progress.Append(h.createCollapsibleBlocks(title, formatCallToolResult(sanitizeText(toolResultStr)), false))
 
-	return messages, timestamp, slackMessageLines
+	return messages
 }
 
 // handleMaxRoundsReached handles the case when maximum conversation rounds are reached
-func (h *SlackHandler) handleMaxRoundsReached(channelID, threadTS, lastResponse string) (string, error) {
-	warningMsg := "⚠️ Reached maximum number of steps. Providing partial response based on current progress..."
-	_, _ = h.sendMarkdownMessage(channelID, warningMsg, threadTS)
+func (h *SlackHandler) handleMaxRoundsReached(conv *conversation, lastResponse string) (string, error) {
+	_, _ = h.sendMarkdownMessage(conv.ChannelID, i18n.T(conv.Language, i18n.MaxRounds), conv.ThreadTS)
 	return fmt.Sprintf("Reached maximum conversation rounds. Last response: %s. \nDo you want me to continue?", lastResponse), nil
 }
 
 // createInitialMessages creates the initial message list
 func (h *SlackHandler) createInitialMessages(query string, history []HistoryMessage) []azopenai.ChatRequestMessageClassification {
-	// Create system message
-	systemMessage := &azopenai.ChatRequestSystemMessage{
-		Content: azopenai.NewChatRequestSystemMessageContent(
-			`You are a Jira assistant that helps users manage Jira issues, projects, and workflows using tools provided by the MCP server.
-
-Your main tasks:
-- Create, update, and search for Jira issues
-- Manage epics and link issues to epics
-- Guide users through issue transitions and workflows
-- Retrieve and summarize issue details, comments, and worklogs
-- If users ask for similar issues, you should only search for issues in the same project
-- Use Slack-supported markdown (e.g. *bold*, > quote), but avoid unsupported formatting (like headers #, tables, or HTML)
-
-When using Jira MCP APIs:
-- When using tool jira_get_issue, you should always use 'fields: *all' as parameter
-- When using the search tool, try to use pagination to avoid too many results
-- If batch operations is involved, you should use the batch tool first
-
-Communication guidelines:
-- Be professional and clear
-- Use Slack markdown for formatting
-- Always include clickable Jira issue keys
-- Explain your actions before performing them
-- Ask for clarification if a request is unclear
-- Provide context for search results
-
-Thinking process:
-1. Always explain your thought process before taking any action
-2. When planning to use tools:
-   - Explain why you need to use each tool
-   - Describe what information you expect to get
-   - Outline your plan for using the results
-3. When encountering errors:
-   - Explain what went wrong
-   - Suggest possible solutions
-   - Ask for clarification if needed
-4. When making decisions:
-   - Explain your reasoning
-   - Consider alternatives
-   - Justify your choices
-
-When displaying Jira issue details:
-- Use clean, easy-to-read Slack markdown
-- Make issue keys and URLs clickable
-- Group related information together
-- Highlight important fields like Status and Priority using * instead of **
-- Avoid unnecessary markdown and images
-- Use emojis sparingly for emphasis
-- Show dates in a human-readable format
-
-Error handling:
-- If unsure, gather more information or ask the user
-- Prefer finding answers yourself before asking the user
-- For epics, "Epic Link" refers to the epic an issue is linked to (customfield_10006)
-
-Start by understanding the user's needs, then use the appropriate tools to help them.`),
-	}
-
 	// Create message array
-	var messages []azopenai.ChatRequestMessageClassification
+	messages := make([]azopenai.ChatRequestMessageClassification, 0, len(history)+2)
 	messages = append(messages, systemMessage)
 
 	// Add history messages
@@ -417,12 +613,12 @@ func (h *SlackHandler) getThreadHistory(channelID, threadTS string) ([]HistoryMe
 	params := &slack.GetConversationRepliesParameters{
 		ChannelID: channelID,
 		Timestamp: threadTS,
-		Limit:     20,   // messages per page
-		Inclusive: true, // Include the message with the specified timestamp
+		Limit:     h.limits.For(channelID).HistoryPageSize, // messages per page
+		Inclusive: true,                                    // Include the message with the specified timestamp
 	}
 
 	for {
-		messages, hasMore, nextCursor, err := h.api.GetConversationReplies(params)
+		messages, hasMore, nextCursor, err := h.slackClient(channelID).GetConversationReplies(params)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch thread history: %v", err)
 		}
@@ -445,6 +641,7 @@ func (h *SlackHandler) getThreadHistory(channelID, threadTS string) ([]HistoryMe
 		historyMessages = append(historyMessages, HistoryMessage{
 			Role:    role,
 			Content: msg.Text,
+			ts:      msg.Timestamp,
 		})
 	}
 
@@ -468,16 +665,15 @@ func (h *SlackHandler) createCollapsibleBlocks(title string, content string, isE
 	return fmt.Sprintf("%s _%s_\n%s", emoji, title, content)
 }
 
-// summarizeIfTooLong checks if the content is too long and summarizes it using the AI model if necessary.
-func (h *SlackHandler) summarizeIfTooLong(ctx context.Context, content string) (string, error) {
-	maxLen := 2000 // character threshold for summarization
+// summarizeIfTooLong summarizes the content using the AI model if it is longer than maxLen characters
+func (h *SlackHandler) summarizeIfTooLong(ctx context.Context, content string, maxLen int) (string, error) {
 	if len(content) <= maxLen {
 		return content, nil
 	}
 
 	summaryPrompt := []azopenai.ChatRequestMessageClassification{
 		&azopenai.ChatRequestSystemMessage{
-			Content: azopenai.NewChatRequestSystemMessageContent(`
+			Content: azopenai.NewChatRequestSystemMessageContent(fmt.Sprintf(`
 You are a summarization assistant. Your job is to condense lengthy tool results into plain-text key information that is easy to read in Slack.
 
 Guidelines:
@@ -485,11 +681,11 @@ Guidelines:
 - Keep formatting plain and simple. For example, use "Status: IN PROGRESS", not "**Status**: IN PROGRESS".
 - Remove all characters used for formatting or decoration.
 - Group or summarize if content is too long, and note if anything is omitted.
-- Always keep the summary under 2000 characters.
+- Always keep the summary under %d characters.
 
 Output the result as plain text, suitable for direct posting in Slack *without* markdown.
 
-			`),
+			`, maxLen)),
 		},
 		&azopenai.ChatRequestUserMessage{
 			Content: azopenai.NewChatRequestUserMessageContent(content),
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// readinessTimeout bounds each dependency check
+	readinessTimeout = 3 * time.Second
+
+	// readinessCacheTTL is how long dependency results are reused, so frequent load balancer
+	// checks do not call Slack and the AI provider every time
+	readinessCacheTTL = 30 * time.Second
+)
+
+// errNotWarmedUp is returned by Ready until the MCP tools have been loaded
+var errNotWarmedUp = errors.New("MCP client is not warmed up yet")
+
+// readinessCheck verifies that a dependency can be reached
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// checkResult is the outcome of a check as reported by /readyz
+type checkResult struct {
+	Status    string `json:"status"` // "ok" or "error"
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// readinessReport caches the dependency results
+type readinessReport struct {
+	mu        sync.Mutex
+	results   map[string]checkResult
+	checkedAt time.Time
+}
+
+// Ready returns an error while the handler cannot serve conversations: before the MCP tools
+// are loaded and after shutdown has begun
+func (h *SlackHandler) Ready() error {
+	h.activeMu.Lock()
+	draining := h.draining
+	h.activeMu.Unlock()
+
+	if draining {
+		return errShuttingDown
+	}
+	if !h.toolsReady.Load() {
+		return errNotWarmedUp
+	}
+	return nil
+}
+
+// HandleHealth reports that the process is alive
+func (h *SlackHandler) HandleHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// HandleReady reports whether the process can take traffic, for load balancer health checks. The
+// response lists the result of every check, so a misconfigured dependency is easy to spot.
+func (h *SlackHandler) HandleReady(c *gin.Context) {
+	checks := h.checkDependencies(c.Request.Context())
+	mcp := checkResult{Status: "ok"}
+	if err := h.Ready(); err != nil {
+		mcp = checkResult{Status: "error", Error: err.Error()}
+	}
+	checks["mcp"] = mcp
+
+	status, code := "ready", http.StatusOK
+	for _, result := range checks {
+		if result.Status != "ok" {
+			status, code = "unavailable", http.StatusServiceUnavailable
+			break
+		}
+	}
+	response := gin.H{"status": status, "checks": checks}
+	// Open circuits leave the other tools working, so they are reported without failing the check
+	if open := h.openCircuits(); len(open) > 0 {
+		response["open_circuits"] = open
+	}
+	c.JSON(code, response)
+}
+
+// checkDependencies runs the dependency checks concurrently, or returns the results of the last
+// run if they are recent
+func (h *SlackHandler) checkDependencies(ctx context.Context) map[string]checkResult {
+	h.readiness.mu.Lock()
+	defer h.readiness.mu.Unlock()
+	if h.readiness.results != nil && time.Since(h.readiness.checkedAt) < readinessCacheTTL {
+		return copyResults(h.readiness.results)
+	}
+
+	checks := append([]readinessCheck{{name: "slack", check: h.checkSlack}}, h.readinessChecks...)
+	results := make(map[string]checkResult, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check readinessCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+			defer cancel()
+			start := time.Now()
+			result := checkResult{Status: "ok"}
+			if err := check.check(ctx); err != nil {
+				result = checkResult{Status: "error", Error: err.Error()}
+			}
+			result.LatencyMS = time.Since(start).Milliseconds()
+			mu.Lock()
+			results[check.name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	h.readiness.results, h.readiness.checkedAt = results, time.Now()
+	return copyResults(results)
+}
+
+// checkSlack verifies the bot token with Slack, giving up when the context ends
+func (h *SlackHandler) checkSlack(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := h.api.AuthTest()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// copyResults returns a copy the caller may add to
+func copyResults(results map[string]checkResult) map[string]checkResult {
+	copied := make(map[string]checkResult, len(results)+1)
+	for name, result := range results {
+		copied[name] = result
+	}
+	return copied
+}
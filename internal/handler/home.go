@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/storage"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"go.uber.org/zap"
+)
+
+const (
+	// myIssuesActionID is the Home tab button listing the user's open issues
+	myIssuesActionID = "my_open_issues"
+
+	// myIssuesJQL finds the issues assigned to the token's owner that are not resolved yet
+	myIssuesJQL = "assignee = currentUser() AND resolution = Unresolved ORDER BY updated DESC"
+
+	// homeTimeout bounds the lookups behind the Home tab
+	homeTimeout = 10 * time.Second
+
+	// maxHomeQueryLength shortens long queries in the Home tab
+	maxHomeQueryLength = 120
+)
+
+// handleAppHomeOpened publishes the Home tab when the user opens it
+func (h *SlackHandler) handleAppHomeOpened(event *slackevents.AppHomeOpenedEvent) {
+	if event.Tab != "home" {
+		return
+	}
+	h.publishHome(event.User)
+}
+
+// publishHome shows the user their token status, quota usage, recent queries and quick actions
+func (h *SlackHandler) publishHome(userID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), homeTimeout)
+	defer cancel()
+
+	view := slack.HomeTabViewRequest{
+		Type:   slack.VTHomeTab,
+		Blocks: slack.Blocks{BlockSet: h.homeBlocks(ctx, userID)},
+	}
+	if _, err := h.slackClient(userID).PublishView(userID, view, ""); err != nil {
+		logger.GetLogger().Error("failed to publish home tab", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// homeBlocks builds the Home tab of the user. Sections whose data cannot be loaded are left out.
+func (h *SlackHandler) homeBlocks(ctx context.Context, userID string) []slack.Block {
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Jira Helper", false, false)),
+		markdownSection("Ask me about Jira in a DM or mention me in a channel. `/jira` runs quick lookups without the AI."),
+		slack.NewDividerBlock(),
+	}
+
+	token, err := h.getUserPersonalToken(userID)
+	switch {
+	case err != nil:
+		logger.GetLogger().Warn("failed to check personal token", zap.String("user_id", userID), zap.Error(err))
+	case token == "":
+		blocks = append(blocks, markdownSection("🔑 *Personal token:* not set. Reads use the shared token, set yours to create and update issues."))
+	default:
+		blocks = append(blocks, markdownSection("🔑 *Personal token:* set. Jira actions run as you."))
+	}
+
+	if h.quota != nil && !h.isAdmin(userID) {
+		usage, err := h.quota.Usage(ctx, userID)
+		if err != nil {
+			logger.GetLogger().Warn("failed to load quota usage", zap.String("user_id", userID), zap.Error(err))
+		} else {
+			var lines []string
+			if usage.RequestsPerHour > 0 {
+				lines = append(lines, fmt.Sprintf("• %d of %d requests this hour", usage.Requests, usage.RequestsPerHour))
+			}
+			if usage.DailyTokens > 0 {
+				lines = append(lines, fmt.Sprintf("• %d of %d AI tokens today", usage.Tokens, usage.DailyTokens))
+			}
+			blocks = append(blocks, markdownSection("📊 *Usage*\n"+strings.Join(lines, "\n")))
+		}
+	}
+
+	if h.activity != nil {
+		queries, err := h.activity.RecentQueries(ctx, userID)
+		if err != nil {
+			logger.GetLogger().Warn("failed to load recent queries", zap.String("user_id", userID), zap.Error(err))
+		} else {
+			blocks = append(blocks, markdownSection("🕘 *Recent queries*\n"+formatRecentQueries(queries)))
+		}
+	}
+
+	setToken := slack.NewButtonBlockElement(tokenModalActionID, "", slack.NewTextBlockObject(slack.PlainTextType, "Set up token", false, false))
+	if token == "" {
+		setToken.Style = slack.StylePrimary
+	}
+	elements := []slack.BlockElement{setToken}
+	if h.jiraURL != "" {
+		elements = append(elements, slack.NewButtonBlockElement(myIssuesActionID, "", slack.NewTextBlockObject(slack.PlainTextType, "My open issues", false, false)))
+	}
+	return append(blocks, slack.NewDividerBlock(), slack.NewActionBlock("", elements...))
+}
+
+// formatRecentQueries lists queries with the time they were asked
+func formatRecentQueries(queries []storage.QueryRecord) string {
+	if len(queries) == 0 {
+		return "_No queries yet._"
+	}
+	lines := make([]string, 0, len(queries))
+	for _, query := range queries {
+		text := strings.Join(strings.Fields(query.Text), " ")
+		if runes := []rune(text); len(runes) > maxHomeQueryLength {
+			text = string(runes[:maxHomeQueryLength]) + "…"
+		}
+		lines = append(lines, fmt.Sprintf("• %s — <!date^%d^{date_short_pretty} {time}|%s>", text, query.AskedAt.Unix(), query.AskedAt.UTC().Format(time.RFC822)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// recordQuery keeps a Slack user's query for their Home tab
+func (h *SlackHandler) recordQuery(ctx context.Context, userID, channelID, query string) {
+	if h.activity == nil || userID == "" || h.messengerFor(channelID) != nil {
+		return
+	}
+	record := storage.QueryRecord{Text: query, ChannelID: channelID, AskedAt: time.Now()}
+	if err := h.activity.AddQuery(ctx, userID, record); err != nil {
+		logger.GetLogger().Warn("failed to record query", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// sendMyOpenIssues sends the user a DM listing the unresolved issues assigned to them
+func (h *SlackHandler) sendMyOpenIssues(userID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), jiraCommandTimeout)
+	defer cancel()
+
+	text, err := h.myOpenIssues(ctx, userID)
+	if err != nil {
+		logger.GetLogger().Warn("failed to list open issues", zap.String("user_id", userID), zap.Error(err))
+		text = fmt.Sprintf("❌ Failed to list your open issues: %s", jiraErrorMessage(err))
+	}
+	// Posting to a user ID sends the message to the user's DM with the app
+	if _, _, err := h.slackClient(userID).PostMessage(userID, slack.MsgOptionText(text, false)); err != nil {
+		logger.GetLogger().Error("failed to send open issues", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// myOpenIssues lists the unresolved issues assigned to the owner of the user's personal token
+func (h *SlackHandler) myOpenIssues(ctx context.Context, userID string) (string, error) {
+	token, err := h.getUserPersonalToken(userID)
+	if err != nil {
+		return "", err
+	}
+	if token == "" {
+		return "", fmt.Errorf("set your personal token first with the *Set up token* button, it tells me who you are in Jira")
+	}
+	client, err := jira.NewClient(h.jiraURL, token)
+	if err != nil {
+		return "", err
+	}
+	issues, total, err := client.Search(ctx, myIssuesJQL, jira.SearchOptions{Fields: summaryFields, Limit: maxSearchResults})
+	if err != nil {
+		return "", err
+	}
+	if len(issues) == 0 {
+		return "🎉 You have no open issues.", nil
+	}
+
+	lines := []string{fmt.Sprintf("*Your open issues* (%d of %d)", len(issues), total)}
+	for _, issue := range issues {
+		lines = append(lines, "• "+h.formatIssueLine(issue))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// markdownSection is a section block with markdown text
+func markdownSection(text string) *slack.SectionBlock {
+	return slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil)
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// identityRetryAfter is how long a user whose Jira account could not be found is not looked up again
+const identityRetryAfter = time.Hour
+
+// identityInstruction tells the model which Jira account the user has
+const identityInstruction = `
+
+The user is %s in Jira, with the username %s. When they refer to themselves, e.g. "my open tickets" or "assign it to me", use this username, e.g. assignee = "%s", instead of asking who they are.`
+
+// jiraIdentity returns the Jira account of the Slack user, nil if it is unknown. Users without a
+// stored identity are looked up by their personal token, or else by the email address of their
+// Slack profile, and the account found is stored. channelID selects the workspace the user is in.
+func (h *SlackHandler) jiraIdentity(ctx context.Context, channelID, userID string) *storage.Identity {
+	if h.identities == nil || userID == "" {
+		return nil
+	}
+	identity, err := h.identities.GetIdentity(ctx, userID)
+	if err != nil {
+		logger.GetLogger().Error("failed to load identity", zap.String("user_id", userID), zap.Error(err))
+		return nil
+	}
+	if identity != nil || h.jiraURL == "" || h.recentIdentityMiss(userID) {
+		return identity
+	}
+
+	identity, err = h.discoverIdentity(ctx, channelID, userID)
+	if err != nil || identity == nil {
+		if err != nil {
+			logger.GetLogger().Warn("failed to look up Jira account", zap.String("user_id", userID), zap.Error(err))
+		}
+		h.identityMu.Lock()
+		if h.identityMisses == nil {
+			h.identityMisses = map[string]time.Time{}
+		}
+		h.identityMisses[userID] = time.Now()
+		h.identityMu.Unlock()
+		return nil
+	}
+	if err := h.identities.SaveIdentity(ctx, *identity); err != nil {
+		logger.GetLogger().Error("failed to store identity", zap.String("user_id", userID), zap.Error(err))
+	}
+	logger.GetLogger().Info("mapped Slack user to Jira account",
+		zap.String("user_id", userID),
+		zap.String("jira_username", identity.JiraUsername),
+		zap.String("source", identity.Source))
+	return identity
+}
+
+// recentIdentityMiss reports whether the user's Jira account was looked up in vain recently
+func (h *SlackHandler) recentIdentityMiss(userID string) bool {
+	h.identityMu.Lock()
+	defer h.identityMu.Unlock()
+	missed, ok := h.identityMisses[userID]
+	return ok && time.Since(missed) < identityRetryAfter
+}
+
+// discoverIdentity finds the Jira account of the user through their personal token, or the email
+// address of their Slack profile when it matches exactly one Jira account
+func (h *SlackHandler) discoverIdentity(ctx context.Context, channelID, userID string) (*storage.Identity, error) {
+	if token, err := h.getUserPersonalToken(userID); err == nil && token != "" {
+		client, err := jira.NewClient(h.jiraURL, token)
+		if err != nil {
+			return nil, err
+		}
+		user, err := client.Myself(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return newIdentity(userID, *user, storage.IdentitySourceToken), nil
+	}
+
+	if h.defaultJiraToken == "" {
+		return nil, nil
+	}
+	profile, err := h.slackClient(channelID).GetUserInfoContext(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Slack profile: %v", err)
+	}
+	email := profile.Profile.Email
+	if email == "" {
+		return nil, nil
+	}
+	client, err := jira.NewClient(h.jiraURL, h.defaultJiraToken)
+	if err != nil {
+		return nil, err
+	}
+	users, err := client.FindUsers(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	var matches []jira.User
+	for _, user := range users {
+		if strings.EqualFold(user.EmailAddress, email) {
+			matches = append(matches, user)
+		}
+	}
+	if len(matches) != 1 {
+		return nil, nil
+	}
+	return newIdentity(userID, matches[0], storage.IdentitySourceEmail), nil
+}
+
+// HandleLinkJiraAccount handles the /link-jira-account slash command. Without arguments it links
+// the account of the user's personal token, or shows the linked account; with a Jira username or
+// email address it links that account, and `remove` unlinks it.
+func (h *SlackHandler) HandleLinkJiraAccount(c *gin.Context) {
+	respond := func(text string) {
+		c.JSON(http.StatusOK, slashResponse{ResponseType: "ephemeral", Text: text})
+	}
+	if h.identities == nil || h.jiraURL == "" {
+		respond("❌ Linking Jira accounts is not available, TOKEN_BUCKET_NAME and JIRA_URL must be configured")
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), jiraCommandTimeout)
+	defer cancel()
+
+	userID, arg := c.PostForm("user_id"), strings.TrimSpace(c.PostForm("text"))
+	text, err := h.linkJiraAccount(ctx, userID, arg)
+	if err != nil {
+		logger.GetLogger().Warn("failed to link Jira account", zap.String("user_id", userID), zap.Error(err))
+		respond(fmt.Sprintf("❌ %s", jiraErrorMessage(err)))
+		return
+	}
+	respond(text)
+}
+
+// linkJiraAccount runs /link-jira-account for the user
+func (h *SlackHandler) linkJiraAccount(ctx context.Context, userID, arg string) (string, error) {
+	if strings.EqualFold(arg, "remove") {
+		if err := h.identities.DeleteIdentity(ctx, userID); err != nil {
+			return "", err
+		}
+		return "🗑️ Unlinked your Jira account. It will be looked up by your email address again.", nil
+	}
+
+	if arg == "" {
+		token, err := h.getUserPersonalToken(userID)
+		if err != nil || token == "" {
+			identity, err := h.identities.GetIdentity(ctx, userID)
+			if err != nil {
+				return "", err
+			}
+			if identity == nil {
+				return "No Jira account is linked to you. Link one with `/link-jira-account <Jira username or email>`, or set your personal token with `/setup-token`.", nil
+			}
+			return fmt.Sprintf("You are linked to Jira account %s (%s), found by %s. Change it with `/link-jira-account <Jira username or email>`.", identity.DisplayName, identity.JiraUsername, identity.Source), nil
+		}
+		client, err := jira.NewClient(h.jiraURL, token)
+		if err != nil {
+			return "", err
+		}
+		user, err := client.Myself(ctx)
+		if err != nil {
+			return "", err
+		}
+		return h.saveIdentity(ctx, newIdentity(userID, *user, storage.IdentitySourceToken))
+	}
+
+	token, err := h.getUserPersonalToken(userID)
+	if err != nil || token == "" {
+		token = h.defaultJiraToken
+	}
+	client, err := jira.NewClient(h.jiraURL, token)
+	if err != nil {
+		return "", err
+	}
+	users, err := client.FindUsers(ctx, arg)
+	if err != nil {
+		return "", err
+	}
+	user, ok := exactUser(users, arg)
+	if !ok {
+		if len(users) == 0 {
+			return "", fmt.Errorf("no Jira account matches %s", arg)
+		}
+		names := make([]string, len(users))
+		for i, u := range users {
+			names[i] = fmt.Sprintf("%s (%s)", u.DisplayName, u.Name)
+		}
+		return "", fmt.Errorf("no Jira account is exactly %s, did you mean %s?", arg, strings.Join(names, ", "))
+	}
+	return h.saveIdentity(ctx, newIdentity(userID, user, storage.IdentitySourceLinked))
+}
+
+// saveIdentity stores the identity and confirms it to the user
+func (h *SlackHandler) saveIdentity(ctx context.Context, identity *storage.Identity) (string, error) {
+	if err := h.identities.SaveIdentity(ctx, *identity); err != nil {
+		return "", err
+	}
+	h.identityMu.Lock()
+	delete(h.identityMisses, identity.SlackUserID)
+	h.identityMu.Unlock()
+	logger.GetLogger().Info("linked Jira account",
+		zap.String("user_id", identity.SlackUserID),
+		zap.String("jira_username", identity.JiraUsername),
+		zap.String("source", identity.Source))
+	return fmt.Sprintf("🔗 Linked you to Jira account %s (%s). Questions about your issues will use it.", identity.DisplayName, identity.JiraUsername), nil
+}
+
+// exactUser returns the user whose username or email address is the query, as Jira also returns
+// users whose names only start with it
+func exactUser(users []jira.User, query string) (jira.User, bool) {
+	for _, user := range users {
+		if strings.EqualFold(user.Name, query) || strings.EqualFold(user.EmailAddress, query) {
+			return user, true
+		}
+	}
+	return jira.User{}, false
+}
+
+// newIdentity maps the Slack user to the Jira user
+func newIdentity(userID string, user jira.User, source string) *storage.Identity {
+	return &storage.Identity{
+		SlackUserID:  userID,
+		JiraUsername: user.Name,
+		JiraKey:      user.Key,
+		AccountID:    user.AccountID,
+		DisplayName:  user.DisplayName,
+		Source:       source,
+		LinkedAt:     time.Now().UTC(),
+	}
+}
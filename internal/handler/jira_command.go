@@ -0,0 +1,409 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"jira_helper/internal/dates"
+	"jira_helper/internal/httpclient"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/service/openai"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// jiraCommandTimeout bounds the Jira calls of one slash command
+	jiraCommandTimeout = 20 * time.Second
+
+	// maxSearchResults caps the issues listed by /jira search
+	maxSearchResults = 20
+
+	// defaultIssueType is the type of issues created without an explicit one
+	defaultIssueType = "Task"
+)
+
+// summaryFields are the issue fields the slash commands display
+var summaryFields = []string{"summary", "status", "priority", "assignee", "issuetype", "updated"}
+
+// jiraCommandRequest is the invocation of a /jira subcommand
+type jiraCommandRequest struct {
+	UserID    string
+	ChannelID string
+	Args      string
+}
+
+// jiraCommand is a subcommand of the /jira slash command. Subcommands call Jira directly,
+// without the AI model, so they are fast and do not use any OpenAI tokens.
+type jiraCommand struct {
+	usage       string
+	description string
+	write       bool // Requires the user's personal token, audited like write tools
+	offline     bool // Does not call Jira, runs without a client
+	inChannel   bool // The response is visible to the whole channel
+	run         func(ctx context.Context, client *jira.Client, req jiraCommandRequest) (string, error)
+	// runBlocks replaces run for subcommands that answer with buttons, e.g. to confirm a change
+	runBlocks func(ctx context.Context, client *jira.Client, req jiraCommandRequest) (string, []slack.Block, error)
+}
+
+// slashResponse is a message sent in response to a slash command
+type slashResponse struct {
+	ResponseType string        `json:"response_type"` // ephemeral or in_channel
+	Text         string        `json:"text"`
+	Blocks       []slack.Block `json:"blocks,omitempty"`
+}
+
+// jiraCommands returns the subcommands available through /jira
+func (h *SlackHandler) jiraCommands() map[string]jiraCommand {
+	commands := map[string]jiraCommand{
+		"get": {
+			usage:       "get PROJ-123",
+			description: "Show an issue",
+			run:         h.jiraGetCommand,
+		},
+		"search": {
+			usage:       "search <jql>",
+			description: fmt.Sprintf("List up to %d issues matching a JQL query", maxSearchResults),
+			run:         h.jiraSearchCommand,
+		},
+		"epic": {
+			usage:       "epic PROJ-123",
+			description: "Show the progress of an epic, its issues by status and the story points done",
+			run:         h.jiraEpicCommand,
+		},
+		"plan-sprint": {
+			usage:       "plan-sprint <board ID> [capacity]",
+			description: "Propose the next sprint from the backlog and the velocity of past sprints, and create it once you confirm",
+			runBlocks:   h.jiraPlanSprintCommand,
+		},
+		"release-notes": {
+			usage:       "release-notes PROJ <version> [confluence:SPACE]",
+			description: "Draft release notes from the issues resolved in a fix version, optionally published to Confluence",
+			run:         h.jiraReleaseNotesCommand,
+		},
+		"summarize": {
+			usage:       "summarize PROJ-123",
+			description: "Summarize the comments on an issue as a timeline, posted in this channel",
+			run:         h.jiraSummarizeCommand,
+		},
+		"timesheet": {
+			usage:       "timesheet [me | user1,user2 | group:<name>] [from [to]] [csv]",
+			description: "Hours logged per day, this week unless dates are given, optionally posted with a CSV export",
+			run:         h.jiraTimesheetCommand,
+		},
+		"create": {
+			usage:       "create PROJ[/Type] <summary> [| description]",
+			description: fmt.Sprintf("Create an issue, of type %s unless given", defaultIssueType),
+			write:       true,
+			inChannel:   true,
+			run:         h.jiraCreateCommand,
+		},
+	}
+	if h.preferences != nil {
+		commands["set-style"] = jiraCommand{
+			usage:       "set-style [<style> | default]",
+			description: "Choose how I answer you, or list the styles",
+			offline:     true,
+			run:         h.jiraSetStyleCommand,
+		}
+		commands["prefs"] = jiraCommand{
+			usage:       "prefs [project | board | timezone | language | notifications <value | clear>]",
+			description: "Show your preferences, or set your default project, board, time zone, language or notifications",
+			offline:     true,
+			run:         h.jiraPrefsCommand,
+		}
+	}
+	if h.subscriptions != nil {
+		commands["subscribe"] = jiraCommand{
+			usage:       "subscribe PROJ|PROJ-123 [created,updated,commented]",
+			description: "Notify this conversation about a project or issue",
+			inChannel:   true,
+			run:         h.jiraSubscribeCommand,
+		}
+		commands["unsubscribe"] = jiraCommand{
+			usage:       "unsubscribe PROJ|PROJ-123",
+			description: "Stop the notifications about a project or issue",
+			inChannel:   true,
+			run:         h.jiraUnsubscribeCommand,
+		}
+		commands["subscriptions"] = jiraCommand{
+			usage:       "subscriptions",
+			description: "List the subscriptions of this conversation",
+			run:         h.jiraSubscriptionsCommand,
+		}
+	}
+	return commands
+}
+
+// HandleJiraCommand handles the /jira slash command. The result is sent to the command's
+// response_url, or returned directly when Slack did not provide one.
+func (h *SlackHandler) HandleJiraCommand(c *gin.Context) {
+	name, args, _ := strings.Cut(strings.TrimSpace(c.PostForm("text")), " ")
+	cmd, ok := h.jiraCommands()[name]
+	if !ok {
+		c.JSON(http.StatusOK, slashResponse{ResponseType: "ephemeral", Text: h.jiraHelp()})
+		return
+	}
+	if h.jiraURL == "" {
+		c.JSON(http.StatusOK, slashResponse{ResponseType: "ephemeral", Text: "❌ `/jira` is not available, JIRA_URL is not configured"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), jiraCommandTimeout)
+	defer cancel()
+
+	req := jiraCommandRequest{UserID: c.PostForm("user_id"), ChannelID: c.PostForm("channel_id"), Args: strings.TrimSpace(args)}
+	response := slashResponse{ResponseType: "ephemeral"}
+	text, blocks, err := h.runJiraCommand(ctx, cmd, req)
+	if err != nil {
+		logger.GetLogger().Warn("jira command failed", zap.String("command", name), zap.String("user_id", req.UserID), zap.Error(err))
+		response.Text = fmt.Sprintf("❌ `/jira %s` failed: %s", name, jiraErrorMessage(err))
+	} else {
+		response.Text, response.Blocks = text, blocks
+		if cmd.inChannel {
+			response.ResponseType = "in_channel"
+		}
+	}
+
+	responseURL := c.PostForm("response_url")
+	if responseURL == "" {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	if err := postSlashResponse(ctx, responseURL, response); err != nil {
+		logger.GetLogger().Error("failed to send slash command response", zap.Error(err))
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// runJiraCommand runs the subcommand with the user's personal token, falling back to the
+// default token for read-only subcommands
+func (h *SlackHandler) runJiraCommand(ctx context.Context, cmd jiraCommand, req jiraCommandRequest) (string, []slack.Block, error) {
+	if cmd.offline {
+		text, err := cmd.run(ctx, nil, req)
+		return text, nil, err
+	}
+	token, err := h.getUserPersonalToken(req.UserID)
+	if err != nil || token == "" {
+		if cmd.write {
+			return "", nil, fmt.Errorf("set your personal token first with `/setup-token`")
+		}
+		token = h.defaultJiraToken
+	}
+	client, err := jira.NewClient(h.jiraURL, token)
+	if err != nil {
+		return "", nil, err
+	}
+	if cmd.runBlocks != nil {
+		return cmd.runBlocks(ctx, client, req)
+	}
+	text, err := cmd.run(ctx, client, req)
+	return text, nil, err
+}
+
+// jiraGetCommand shows an issue, e.g. `/jira get PROJ-123`
+func (h *SlackHandler) jiraGetCommand(ctx context.Context, client *jira.Client, req jiraCommandRequest) (string, error) {
+	key := strings.ToUpper(req.Args)
+	if !issueKeyPattern.MatchString(key) {
+		return "", fmt.Errorf("usage: `/jira get PROJ-123`")
+	}
+	if err := h.checkCommandScope(req.ChannelID, projectOfToolCall(map[string]interface{}{"issue_key": key})); err != nil {
+		return "", err
+	}
+
+	issue, err := client.GetIssue(ctx, key, append(summaryFields, "description", "reporter", "labels", "updated", "project")...)
+	if err != nil {
+		return "", err
+	}
+	// An issue moved to another project still answers to its old key
+	if issue.Fields.Project != nil {
+		if err := h.checkCommandScope(req.ChannelID, issue.Fields.Project.Key); err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(h.formatIssueLine(*issue))
+	if issue.Fields.Reporter != nil {
+		fmt.Fprintf(&b, "\n*Reporter:* %s", issue.Fields.Reporter.DisplayName)
+	}
+	if len(issue.Fields.Labels) > 0 {
+		fmt.Fprintf(&b, "\n*Labels:* %s", strings.Join(issue.Fields.Labels, ", "))
+	}
+	if !issue.Fields.Updated.IsZero() {
+		fmt.Fprintf(&b, "\n*Updated:* %s", dates.In(h.userTimezone(ctx, req.ChannelID, req.UserID)).Time(issue.Fields.Updated.Time))
+	}
+	if description := strings.TrimSpace(issue.Fields.Description); description != "" {
+		if len(description) > 1000 {
+			description = description[:1000] + "…"
+		}
+		fmt.Fprintf(&b, "\n>%s", strings.ReplaceAll(description, "\n", "\n>"))
+	}
+	return b.String(), nil
+}
+
+// jiraSearchCommand lists the issues matching a JQL query, e.g. `/jira search assignee = currentUser()`
+func (h *SlackHandler) jiraSearchCommand(ctx context.Context, client *jira.Client, req jiraCommandRequest) (string, error) {
+	if req.Args == "" {
+		return "", fmt.Errorf("usage: `/jira search <jql>`")
+	}
+	query, err := h.scopeJQL(req.ChannelID, req.Args)
+	if err != nil {
+		return "", fmt.Errorf("invalid JQL: %v", err)
+	}
+
+	issues, total, err := client.Search(ctx, query, jira.SearchOptions{Fields: summaryFields, Limit: maxSearchResults})
+	if err != nil {
+		return "", err
+	}
+	if len(issues) == 0 {
+		return fmt.Sprintf("No issues match `%s`", req.Args), nil
+	}
+
+	lines := []string{fmt.Sprintf("Showing %d of %d issues matching `%s`", len(issues), total, req.Args)}
+	for _, issue := range issues {
+		lines = append(lines, "• "+h.formatIssueLine(issue))
+	}
+	text := strings.Join(lines, "\n")
+	if violations := h.boundaries.Violations(req.ChannelID, text); len(violations) > 0 {
+		return "", fmt.Errorf("the results include restricted projects (%s) that cannot be shown in this channel", strings.Join(violations, ", "))
+	}
+	return text, nil
+}
+
+// jiraCreateCommand creates an issue, e.g. `/jira create OPS/Bug Login fails | Steps to reproduce...`
+func (h *SlackHandler) jiraCreateCommand(ctx context.Context, client *jira.Client, req jiraCommandRequest) (string, error) {
+	target, rest, _ := strings.Cut(req.Args, " ")
+	summary, description, _ := strings.Cut(rest, "|")
+	summary, description = strings.TrimSpace(summary), strings.TrimSpace(description)
+	if target == "" || summary == "" {
+		return "", fmt.Errorf("usage: `/jira create PROJ[/Type] <summary> [| description]`")
+	}
+	project, issueType, _ := strings.Cut(target, "/")
+	project = strings.ToUpper(project)
+	if issueType == "" {
+		issueType = defaultIssueType
+	}
+	if err := h.checkCommandScope(req.ChannelID, project); err != nil {
+		return "", err
+	}
+
+	// Writes are paused, audited and counted like the create tool
+	toolCall := openai.ToolCall{Name: "jira_create_issue", Args: map[string]interface{}{
+		"project_key": project,
+		"issue_type":  issueType,
+		"summary":     summary,
+		"description": description,
+	}}
+	if err := h.checkWriteBurst(ctx, req.UserID, toolCall); err != nil {
+		return "", err
+	}
+	key, err := client.CreateIssue(ctx, jira.NewIssue{ProjectKey: project, IssueType: issueType, Summary: summary, Description: description})
+	h.recordAudit(ctx, req.UserID, req.ChannelID, toolCall, nil, err)
+	h.observeWrite(ctx, req.UserID, req.ChannelID, toolCall)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✅ <@%s> created %s: %s", req.UserID, h.issueLink(key), summary), nil
+}
+
+// checkCommandScope rejects projects outside the channel's scope or sensitivity boundaries
+func (h *SlackHandler) checkCommandScope(channelID, project string) error {
+	toolCall := openai.ToolCall{Args: map[string]interface{}{"project_key": project}}
+	return h.scopeToolCall(channelID, &toolCall)
+}
+
+// formatIssueLine renders an issue as a single line with a link, status and assignee
+func (h *SlackHandler) formatIssueLine(issue jira.Issue) string {
+	details := []string{}
+	if issue.Fields.IssueType != nil {
+		details = append(details, issue.Fields.IssueType.Name)
+	}
+	if issue.Fields.Status != nil {
+		details = append(details, "*"+issue.Fields.Status.Name+"*")
+	}
+	if issue.Fields.Priority != nil {
+		details = append(details, issue.Fields.Priority.Name)
+	}
+	assignee := "Unassigned"
+	if issue.Fields.Assignee != nil {
+		assignee = issue.Fields.Assignee.DisplayName
+	}
+	details = append(details, assignee)
+	return fmt.Sprintf("%s %s — %s", h.issueLink(issue.Key), issue.Fields.Summary, strings.Join(details, " · "))
+}
+
+// issueLink renders the issue key as a Slack link to the issue
+func (h *SlackHandler) issueLink(key string) string {
+	if url := h.issueURL(key); url != "" {
+		return fmt.Sprintf("<%s|%s>", url, key)
+	}
+	return key
+}
+
+// jiraErrorMessage explains the common Jira errors in terms of what the user can do
+func jiraErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, jira.ErrUnauthorized):
+		return "your Jira token is invalid or expired, set a new one with `/setup-token`"
+	case errors.Is(err, jira.ErrForbidden):
+		return "you don't have permission to do this in Jira"
+	case errors.Is(err, jira.ErrNotFound):
+		return "not found, or you don't have permission to see it"
+	case errors.Is(err, jira.ErrRateLimited):
+		return "Jira is rate limiting requests, try again in a minute"
+	default:
+		return err.Error()
+	}
+}
+
+// jiraHelp lists the available /jira subcommands
+func (h *SlackHandler) jiraHelp() string {
+	commands := h.jiraCommands()
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{"Available commands:"}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("• `/jira %s` - %s", commands[name].usage, commands[name].description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// postSlashResponse sends a message to a slash command's response_url
+func postSlashResponse(ctx context.Context, responseURL string, response slashResponse) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpclient.New(httpclient.SlackTimeout).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to response_url: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response_url returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// primaryInstance picks the Jira instance of JIRA_URL in a channel that defaults to another one
+const primaryInstance = "primary"
+
+// instanceInstruction tells the model which Jira instance the conversation works with
+const instanceInstruction = "\n\nThis conversation works with the Jira instance %q at %s, not the usual one: look up, change and link issues there."
+
+// instanceSelector picks the Jira instance of a query, e.g. "on sandbox: create a test bug",
+// after the bot's mention if there is one
+var instanceSelector = regexp.MustCompile(`(?i)^(\s*(?:<@[A-Z0-9]+>\s*)?)on\s+([a-z0-9_-]+)\s*:\s*`)
+
+// JiraInstance is a named Jira connection next to the primary one, e.g. a cloud sandbox.
+// Conversations on it act with its token, personal tokens belong to the primary instance.
+type JiraInstance struct {
+	URL       string // Base URL, passed to the MCP server as JIRA_URL
+	Token     string // Jira token conversations on the instance act with
+	ServerURL string // Remote MCP server of the instance, for the sse and http transports
+}
+
+type jiraInstanceKey struct{}
+
+// cutInstanceSelector removes the selector of a configured instance from the query, returning the
+// instance it names. Queries on the primary instance return "".
+func (h *SlackHandler) cutInstanceSelector(query string) (string, string, bool) {
+	match := instanceSelector.FindStringSubmatch(query)
+	if match == nil || len(h.jiraInstances) == 0 {
+		return query, "", false
+	}
+	name := strings.ToLower(match[2])
+	if _, ok := h.jiraInstances[name]; !ok && name != primaryInstance {
+		return query, "", false
+	}
+	if name == primaryInstance {
+		name = ""
+	}
+	return match[1] + query[len(match[0]):], name, true
+}
+
+// withJiraInstance returns a context whose conversation works with the named instance, the
+// primary one when name is ""
+func withJiraInstance(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, jiraInstanceKey{}, name)
+}
+
+// jiraInstanceOf returns the instance a conversation in the channel works with: the one the query
+// picked, else the channel's default, "" for the primary instance
+func (h *SlackHandler) jiraInstanceOf(ctx context.Context, channelID string) string {
+	if name, ok := ctx.Value(jiraInstanceKey{}).(string); ok {
+		return name
+	}
+	return h.channelJiraInstances[channelID]
+}
+
+// jiraToken returns the token a conversation on the instance acts with: the instance's own, or
+// the user's personal token on the primary instance
+func (h *SlackHandler) jiraToken(instance, userID string) (string, error) {
+	if instance == "" {
+		return h.getUserPersonalToken(userID)
+	}
+	jiraInstance, ok := h.jiraInstances[instance]
+	if !ok {
+		return "", fmt.Errorf("Jira instance %q is not configured", instance)
+	}
+	return jiraInstance.Token, nil
+}
+
+// jiraURLOf returns the base URL of the Jira instance, the primary one for ""
+func (h *SlackHandler) jiraURLOf(name string) string {
+	if instance, ok := h.jiraInstances[name]; ok {
+		return strings.TrimSuffix(instance.URL, "/")
+	}
+	return h.jiraURL
+}
+
+// mcpClientOf returns an MCP client of the instance. The primary instance uses the client of the
+// token, other instances share one client acting with their token.
+func (h *SlackHandler) mcpClientOf(instance, userToken string) (MCPClient, func(), error) {
+	if instance == "" {
+		return h.getMcpClient(userToken)
+	}
+	client, err := h.instanceMcpClient(instance)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, func() {}, nil
+}
+
+// instanceMcpClient returns the started MCP client of the instance, starting it on first use. A
+// client that failed to start is tried again by the next conversation.
+func (h *SlackHandler) instanceMcpClient(name string) (MCPClient, error) {
+	h.instanceMu.Lock()
+	defer h.instanceMu.Unlock()
+	if client, ok := h.instanceClients[name]; ok {
+		return client, nil
+	}
+	instance, ok := h.jiraInstances[name]
+	if !ok {
+		return nil, fmt.Errorf("Jira instance %q is not configured", name)
+	}
+
+	client, err := h.mcpLauncher.For(instance).Launch(instance.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MCP client of Jira instance %s: %v", name, err)
+	}
+	if err := h.initializeMcpClient(client, 2*time.Minute); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	logger.GetLogger().Info("MCP client of Jira instance started", zap.String("instance", name), zap.String("jira_url", instance.URL))
+	h.instanceClients[name] = client
+	return client, nil
+}
+
+// closeInstanceClients closes the MCP clients of the instances
+func (h *SlackHandler) closeInstanceClients() {
+	h.instanceMu.Lock()
+	defer h.instanceMu.Unlock()
+	for name, client := range h.instanceClients {
+		if err := client.Close(); err != nil {
+			logger.GetLogger().Error("failed to close MCP client of Jira instance", zap.String("instance", name), zap.Error(err))
+		}
+		delete(h.instanceClients, name)
+	}
+}
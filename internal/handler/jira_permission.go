@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"regexp"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/policy"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+var (
+	// jiraUnauthorizedPattern matches Jira responses for a missing, expired or revoked token
+	jiraUnauthorizedPattern = regexp.MustCompile(`(?i)` + httpStatusPattern("401") + `|\bunauthori[sz]ed\b|authentication (failed|required)|invalid (api )?token`)
+	// jiraForbiddenPattern matches Jira responses for a token without permission on the resource
+	jiraForbiddenPattern = regexp.MustCompile(`(?i)` + httpStatusPattern("403") + `|\bforbidden\b|(do(es)? not have|no|insufficient) permissions?\b|\bnot allowed to\b`)
+)
+
+// httpStatusPattern matches HTTP status codes, e.g. 401|403, where errors name them: "status 401",
+// "status code: 403", "HTTP 503" or "401 Client Error". Issue keys such as PROJ-401 and other
+// numbers in a message do not match.
+func httpStatusPattern(codes string) string {
+	return `(\b(status([ _]code)?|http(/[\d.]+)?|response code)\b[:=]?\s*(` + codes + `)\b|\b(` + codes + `) (client|server) error\b)`
+}
+
+// jiraAuthFailure classifies why Jira rejected a tool call
+type jiraAuthFailure int
+
+const (
+	jiraAuthOK jiraAuthFailure = iota
+	jiraAuthUnauthorized
+	jiraAuthForbidden
+)
+
+// classifyJiraAuthFailure detects 401/403 responses from Jira in a tool result or error
+func classifyJiraAuthFailure(text string) jiraAuthFailure {
+	switch {
+	case jiraUnauthorizedPattern.MatchString(text):
+		return jiraAuthUnauthorized
+	case jiraForbiddenPattern.MatchString(text):
+		return jiraAuthForbidden
+	default:
+		return jiraAuthOK
+	}
+}
+
+// jiraAuthFailureOf classifies a failed tool call, ignoring successful results
+func jiraAuthFailureOf(result *mcp.CallToolResult, err error) jiraAuthFailure {
+	if err != nil {
+		return classifyJiraAuthFailure(err.Error())
+	}
+	if result != nil && result.IsError {
+		return classifyJiraAuthFailure(printToolResult(result))
+	}
+	return jiraAuthOK
+}
+
+// jiraAuthGuidance returns a remediation message depending on whose token Jira rejected
+func jiraAuthGuidance(failure jiraAuthFailure, usingPersonalToken bool, toolName string) string {
+	switch {
+	case !usingPersonalToken:
+		return "🔑 Jira denied `" + toolName + "` for the shared default token, which only has read access to public projects. Set your personal Jira token so the bot can act with your own permissions."
+	case failure == jiraAuthUnauthorized:
+		return "🔑 Jira rejected your personal token for `" + toolName + "`. It has probably expired or been revoked — create a new personal access token in your Jira profile and set it again."
+	default:
+		return "🚫 Your personal Jira token does not have permission for `" + toolName + "` on this project. Ask a Jira project admin to grant you access; updating the token will not help."
+	}
+}
+
+// sendJiraAuthGuidance posts the remediation message, with a button to open the token setup modal when a new token would help
+func (h *SlackHandler) sendJiraAuthGuidance(conv *conversation, failure jiraAuthFailure, usingPersonalToken bool, toolName string) {
+	channelID, threadTS := conv.ChannelID, conv.ThreadTS
+	message := jiraAuthGuidance(failure, usingPersonalToken, toolName)
+	// Other platforms have no token modal, so they only get the explanation
+	if h.messengerFor(channelID) != nil {
+		_, _ = h.sendMarkdownMessage(channelID, message, threadTS)
+		return
+	}
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, message, false, false), nil, nil),
+	}
+	if !usingPersonalToken || failure == jiraAuthUnauthorized {
+		button := slack.NewButtonBlockElement(tokenModalActionID, "", slack.NewTextBlockObject(slack.PlainTextType, "Set personal token", false, false))
+		button.Style = slack.StylePrimary
+		blocks = append(blocks, slack.NewActionBlock("", button))
+	}
+
+	if h.messageRule(channelID).Notices == policy.VisibilityEphemeral {
+		_ = h.sendEphemeralSlackMessage(channelID, conv.UserID, message, threadTS, slack.MsgOptionBlocks(blocks...))
+		return
+	}
+	_, _, err := h.slackClient(channelID).PostMessage(
+		channelID,
+		slack.MsgOptionText(message, false),
+		slack.MsgOptionBlocks(blocks...),
+		slack.MsgOptionTS(threadTS))
+	if err != nil {
+		logger.GetLogger().Error("failed to post jira permission guidance", zap.Error(err))
+	}
+}
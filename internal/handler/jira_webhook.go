@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"jira_helper/internal/jql"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// jiraWebhookTimeout bounds the processing of one webhook delivery
+	jiraWebhookTimeout = time.Minute
+
+	// maxNotifiedChanges caps the changed fields listed in an update notification
+	maxNotifiedChanges = 5
+
+	// maxNotifiedComment shortens long comments in notifications
+	maxNotifiedComment = 500
+)
+
+// Events a subscription can be limited to
+const (
+	notifyCreated   = "created"
+	notifyUpdated   = "updated"
+	notifyCommented = "commented"
+)
+
+// notifyEvents are the events a subscription can be limited to
+var notifyEvents = []string{notifyCreated, notifyUpdated, notifyCommented}
+
+// HandleJiraWebhook posts notifications about created, updated and commented issues to the Slack
+// channels and users subscribed to the issue or its project
+func (h *SlackHandler) HandleJiraWebhook(c *gin.Context) {
+	if h.subscriptions == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "jira webhooks are not configured"})
+		return
+	}
+
+	var event jira.WebhookEvent
+	if err := json.NewDecoder(c.Request.Body).Decode(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event"})
+		return
+	}
+	kind := notificationEvent(event)
+	if kind == "" || event.Issue == nil || !h.featureEnabled(c.Request.Context(), FeatureJiraNotifications) {
+		c.JSON(http.StatusOK, gin.H{"message": "event ignored"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), jiraWebhookTimeout)
+	defer cancel()
+
+	issue := event.Issue
+	project := projectOfToolCall(map[string]interface{}{"issue_key": issue.Key})
+	subscriptions, err := h.subscriptions.Find(ctx, issue.Key, project)
+	if err != nil {
+		logger.GetLogger().Error("failed to load subscriptions", zap.String("issue", issue.Key), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	text := h.jiraNotification(kind, event)
+	notified := []string{}
+	for _, subscription := range subscriptions {
+		// A target subscribed to both the issue and its project is notified once
+		if !subscription.Notifies(kind) || slices.Contains(notified, subscription.Target) {
+			continue
+		}
+		if h.notificationsMuted(ctx, subscription) {
+			logger.GetLogger().Debug("skipped muted notification", zap.String("target", subscription.Target))
+			continue
+		}
+		if violations := h.boundaries.Violations(subscription.Target, text, project); len(violations) > 0 {
+			logger.GetLogger().Warn("withheld notification about restricted project",
+				zap.String("target", subscription.Target),
+				zap.Strings("projects", violations))
+			continue
+		}
+		if _, err := h.sendMarkdownMessage(subscription.Target, text, ""); err != nil {
+			logger.GetLogger().Error("failed to send jira notification", zap.String("target", subscription.Target), zap.Error(err))
+			continue
+		}
+		notified = append(notified, subscription.Target)
+	}
+
+	logger.GetLogger().Info("processed jira webhook",
+		zap.String("event", event.WebhookEvent),
+		zap.String("issue", issue.Key),
+		zap.Strings("notified", notified))
+	c.JSON(http.StatusOK, gin.H{"notified": notified})
+}
+
+// notificationEvent maps a webhook event to the event subscriptions choose from, or "" when it
+// is not notified. Jira also reports comments as issue updates, those are left to comment_created.
+func notificationEvent(event jira.WebhookEvent) string {
+	switch event.WebhookEvent {
+	case jira.WebhookIssueCreated:
+		return notifyCreated
+	case jira.WebhookCommentCreated:
+		return notifyCommented
+	case jira.WebhookIssueUpdated:
+		if event.Changelog == nil || len(event.Changelog.Items) == 0 {
+			return ""
+		}
+		return notifyUpdated
+	default:
+		return ""
+	}
+}
+
+// jiraNotification formats the notification of an event
+func (h *SlackHandler) jiraNotification(kind string, event jira.WebhookEvent) string {
+	actor := "Someone"
+	if event.User != nil && event.User.DisplayName != "" {
+		actor = event.User.DisplayName
+	}
+	if event.Comment != nil && event.Comment.Author != nil && event.Comment.Author.DisplayName != "" {
+		actor = event.Comment.Author.DisplayName
+	}
+	issueLine := h.formatIssueLine(*event.Issue)
+
+	switch kind {
+	case notifyCreated:
+		return fmt.Sprintf("🆕 *%s* created %s", actor, issueLine)
+	case notifyCommented:
+		text := fmt.Sprintf("💬 *%s* commented on %s", actor, issueLine)
+		if event.Comment != nil {
+			body := strings.TrimSpace(event.Comment.Body)
+			if runes := []rune(body); len(runes) > maxNotifiedComment {
+				body = string(runes[:maxNotifiedComment]) + "…"
+			}
+			text += "\n>" + strings.ReplaceAll(body, "\n", "\n>")
+		}
+		return text
+	default:
+		lines := []string{fmt.Sprintf("✏️ *%s* updated %s", actor, issueLine)}
+		for i, item := range event.Changelog.Items {
+			if i == maxNotifiedChanges {
+				lines = append(lines, fmt.Sprintf("• …and %d more changes", len(event.Changelog.Items)-i))
+				break
+			}
+			lines = append(lines, formatChange(item))
+		}
+		return strings.Join(lines, "\n")
+	}
+}
+
+// formatChange renders the change of one field. Long values, like descriptions, are left out.
+func formatChange(item jira.ChangelogItem) string {
+	from, to := item.FromString, item.ToString
+	if len(from) > 80 || len(to) > 80 || strings.Contains(from+to, "\n") {
+		return fmt.Sprintf("• *%s* changed", item.Field)
+	}
+	if from == "" {
+		from = "_none_"
+	}
+	if to == "" {
+		to = "_none_"
+	}
+	return fmt.Sprintf("• *%s:* %s → %s", item.Field, from, to)
+}
+
+// jiraSubscribeCommand subscribes the channel to a project or issue,
+// e.g. `/jira subscribe PROJ created,commented`
+func (h *SlackHandler) jiraSubscribeCommand(ctx context.Context, _ *jira.Client, req jiraCommandRequest) (string, error) {
+	fields := strings.Fields(req.Args)
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", fmt.Errorf("usage: `/jira subscribe PROJ|PROJ-123 [%s]`", strings.Join(notifyEvents, ","))
+	}
+	scope, err := h.subscriptionScope(req.ChannelID, fields[0])
+	if err != nil {
+		return "", err
+	}
+	var events []string
+	if len(fields) == 2 {
+		for _, event := range strings.Split(strings.ToLower(fields[1]), ",") {
+			if !slices.Contains(notifyEvents, event) {
+				return "", fmt.Errorf("unknown event %q, expected %s", event, strings.Join(notifyEvents, ", "))
+			}
+			events = append(events, event)
+		}
+	}
+
+	subscription := storage.Subscription{Scope: scope, Target: req.ChannelID, Events: events, CreatedBy: req.UserID, CreatedAt: time.Now().UTC()}
+	if err := h.subscriptions.Add(ctx, subscription); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("🔔 <@%s> subscribed this conversation to %s (%s)", req.UserID, scope, subscriptionEvents(subscription)), nil
+}
+
+// jiraUnsubscribeCommand removes the channel's subscription, e.g. `/jira unsubscribe PROJ`
+func (h *SlackHandler) jiraUnsubscribeCommand(ctx context.Context, _ *jira.Client, req jiraCommandRequest) (string, error) {
+	scope := strings.ToUpper(req.Args)
+	if scope == "" {
+		return "", fmt.Errorf("usage: `/jira unsubscribe PROJ|PROJ-123`")
+	}
+	removed, err := h.subscriptions.Remove(ctx, scope, req.ChannelID)
+	if err != nil {
+		return "", err
+	}
+	if !removed {
+		return fmt.Sprintf("This conversation is not subscribed to %s", scope), nil
+	}
+	return fmt.Sprintf("🔕 <@%s> unsubscribed this conversation from %s", req.UserID, scope), nil
+}
+
+// jiraSubscriptionsCommand lists the channel's subscriptions
+func (h *SlackHandler) jiraSubscriptionsCommand(ctx context.Context, _ *jira.Client, req jiraCommandRequest) (string, error) {
+	subscriptions, err := h.subscriptions.List(ctx, req.ChannelID)
+	if err != nil {
+		return "", err
+	}
+	if len(subscriptions) == 0 {
+		return "This conversation has no subscriptions. Add one with `/jira subscribe PROJ`.", nil
+	}
+	lines := []string{"This conversation is notified about:"}
+	for _, subscription := range subscriptions {
+		lines = append(lines, fmt.Sprintf("• %s (%s), added by <@%s>", subscription.Scope, subscriptionEvents(subscription), subscription.CreatedBy))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// subscriptionScope validates a project or issue key and checks the channel may see its project
+func (h *SlackHandler) subscriptionScope(channelID, key string) (string, error) {
+	scope := strings.ToUpper(key)
+	if err := jql.ValidateFragment(scope); err != nil {
+		return "", fmt.Errorf("%s is neither a project key nor an issue key: %v", key, err)
+	}
+	project := scope
+	if issueKeyPattern.MatchString(scope) {
+		project = projectOfToolCall(map[string]interface{}{"issue_key": scope})
+	} else if strings.Contains(scope, "-") {
+		return "", fmt.Errorf("%s is neither a project key nor an issue key", key)
+	}
+	if err := h.checkCommandScope(channelID, project); err != nil {
+		return "", err
+	}
+	return scope, nil
+}
+
+// subscriptionEvents describes the events a subscription notifies
+func subscriptionEvents(subscription storage.Subscription) string {
+	if len(subscription.Events) == 0 {
+		return strings.Join(notifyEvents, ", ")
+	}
+	return strings.Join(subscription.Events, ", ")
+}
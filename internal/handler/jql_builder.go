@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"jira_helper/internal/jqlbuilder"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/service/openai"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// buildJQLTool is served by the handler next to the MCP server's tools
+const buildJQLTool = "build_jql"
+
+// buildJQLDefinition describes build_jql to the model
+var buildJQLDefinition = openai.Tool{
+	Name: buildJQLTool,
+	Description: "Convert natural-language search criteria into JQL that is checked against Jira's fields " +
+		"and validated by Jira. Returns the JQL and an explanation of what it matches. Use it before searching " +
+		"with custom fields, relative dates or criteria you are unsure how to express.",
+	Parameters: `{
+	"type": "object",
+	"properties": {
+		"criteria": {"type": "string", "description": "The issues to find, in the user's words"},
+		"project_key": {"type": "string", "description": "Project the issues belong to, if known"}
+	},
+	"required": ["criteria"]
+}`,
+}
+
+// buildJQL runs a build_jql call with the user's token, so the query is validated against the
+// fields and projects the user can see
+func (h *SlackHandler) buildJQL(ctx context.Context, conv *conversation, toolCall openai.ToolCall, userToken string) (*mcp.CallToolResult, error) {
+	criteria, _ := toolCall.Args["criteria"].(string)
+	if strings.TrimSpace(criteria) == "" {
+		return mcp.NewToolResultError("criteria is required"), nil
+	}
+	projects := h.channelProjects[conv.ChannelID]
+	if project := projectKeyArg(toolCall.Args); project != "" {
+		projects = []string{project}
+	}
+	var hint string
+	if len(projects) > 0 {
+		hint = fmt.Sprintf("The issues belong to the projects %s.", strings.Join(projects, ", "))
+	}
+
+	token := userToken
+	if token == "" {
+		token = h.defaultJiraToken
+	}
+	client, err := jira.NewClient(h.jiraURLOf(conv.JiraInstance), token)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	result, err := jqlbuilder.NewBuilder(h.aiClient).Build(ctx, client, criteria, hint)
+	if err != nil {
+		logger.GetLogger().Warn("failed to build JQL", zap.String("criteria", criteria), zap.Error(err))
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(printJSON(result)), nil
+}
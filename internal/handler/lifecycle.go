@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"jira_helper/internal/i18n"
+	"jira_helper/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// errShuttingDown is returned for conversations started after shutdown began
+var errShuttingDown = errors.New("handler is shutting down")
+
+// activeConversation is a conversation that is still running
+type activeConversation struct {
+	channelID string
+	threadTS  string
+	userID    string
+	lang      i18n.Lang // Language the interruption is explained in
+	cancel    context.CancelCauseFunc
+}
+
+// beginConversation registers a conversation so shutdown can wait for it, and returns a
+// context that is cancelled if it has to be interrupted. ctx carries the conversation's language.
+func (h *SlackHandler) beginConversation(ctx context.Context, channelID, threadTS, userID string) (context.Context, func(), error) {
+	h.activeMu.Lock()
+	defer h.activeMu.Unlock()
+
+	if h.draining {
+		return nil, nil, errShuttingDown
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	conversation := &activeConversation{channelID: channelID, threadTS: threadTS, userID: userID, lang: i18n.FromContext(ctx), cancel: cancel}
+	h.active[conversation] = struct{}{}
+	h.inFlight.Add(1)
+
+	return ctx, func() {
+		h.activeMu.Lock()
+		delete(h.active, conversation)
+		h.activeMu.Unlock()
+		cancel(nil)
+		h.inFlight.Done()
+	}, nil
+}
+
+// Shutdown stops accepting new conversations and waits for the active ones until ctx expires.
+// Conversations still running then are interrupted with a note in their thread, so users know
+// where to pick up. Finally the MCP subprocesses are closed.
+func (h *SlackHandler) Shutdown(ctx context.Context) {
+	h.activeMu.Lock()
+	h.draining = true
+	remaining := len(h.active)
+	h.activeMu.Unlock()
+
+	logger.GetLogger().Info("shutting down, draining conversations", zap.Int("active", remaining))
+
+	drained := make(chan struct{})
+	go func() {
+		h.inFlight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		logger.GetLogger().Info("all conversations finished")
+	case <-ctx.Done():
+		h.interruptConversations()
+	}
+
+	if h.mcpPool != nil {
+		h.mcpPool.Close()
+	}
+	h.closeInstanceClients()
+	if h.defaultMcpClient != nil {
+		if err := h.defaultMcpClient.Close(); err != nil {
+			logger.GetLogger().Error("failed to close default MCP client", zap.Error(err))
+		}
+	}
+}
+
+// interruptConversations checkpoints and cancels every conversation that is still running
+func (h *SlackHandler) interruptConversations() {
+	h.activeMu.Lock()
+	defer h.activeMu.Unlock()
+
+	for conversation := range h.active {
+		logger.GetLogger().Warn("interrupting conversation",
+			zap.String("channel", conversation.channelID),
+			zap.String("thread_ts", conversation.threadTS))
+		_, _ = h.sendMarkdownMessage(conversation.channelID, i18n.T(conversation.lang, i18n.Interrupted), conversation.threadTS)
+		conversation.cancel(errShuttingDown)
+	}
+}
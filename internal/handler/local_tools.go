@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+
+	"jira_helper/internal/service/openai"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// localTool is a tool the handler serves itself, next to the MCP server's tools
+type localTool struct {
+	definition openai.Tool
+	call       func(ctx context.Context, conv *conversation, toolCall openai.ToolCall, userToken string) (*mcp.CallToolResult, error)
+}
+
+// localTools returns the tools the handler's configuration enables
+func (h *SlackHandler) localTools() []localTool {
+	var tools []localTool
+	if h.similarIssues != nil {
+		tools = append(tools, localTool{definition: findSimilarIssuesDefinition, call: h.findSimilarIssues})
+	}
+	if h.jiraURL != "" {
+		tools = append(tools, localTool{definition: buildJQLDefinition, call: h.buildJQL})
+		tools = append(tools, localTool{definition: epicProgressDefinition, call: h.epicProgress})
+		tools = append(tools, localTool{definition: linkedCodeDefinition, call: h.linkedCode})
+		tools = append(tools, localTool{definition: summarizeDiscussionDefinition, call: h.summarizeDiscussion})
+		tools = append(tools, localTool{definition: linkPullRequestDefinition, call: h.linkPullRequestToIssue})
+	}
+	if h.onCall != nil {
+		tools = append(tools, localTool{definition: getOnCallDefinition, call: h.getOnCall})
+		tools = append(tools, localTool{definition: pageOnCallDefinition, call: h.pageOnCall})
+	}
+	return tools
+}
+
+// localTool returns the local tool with the name, if it is enabled
+func (h *SlackHandler) localTool(name string) (localTool, bool) {
+	for _, tool := range h.localTools() {
+		if tool.definition.Name == name {
+			return tool, true
+		}
+	}
+	return localTool{}, false
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+// MCP transports
+const (
+	McpTransportStdio = "stdio" // Subprocess started with Command
+	McpTransportSSE   = "sse"   // Remote server at ServerURL, over server-sent events
+	McpTransportHTTP  = "http"  // Remote server at ServerURL, over streamable HTTP
+)
+
+// McpLauncher describes how the MCP server is reached. A stdio server is started as a subprocess
+// and gets the Jira token and URL in the environment as JIRA_API_TOKEN and JIRA_URL, next to Env.
+// A remote server gets the token in the Authorization header of each request.
+type McpLauncher struct {
+	Transport string
+	Command   string
+	Args      []string
+	Env       map[string]string
+	JiraURL   string
+	ServerURL string
+}
+
+// DefaultMcpLauncher runs mcp-atlassian with uvx, keeping its caches in the writable /tmp
+func DefaultMcpLauncher() McpLauncher {
+	return McpLauncher{
+		Transport: McpTransportStdio,
+		Command:   "uvx",
+		Args:      []string{"run", "mcp-atlassian"},
+		Env: map[string]string{
+			"UV_TOOL_DIR":  "/tmp/uvx-tool",
+			"UV_CACHE_DIR": "/tmp/uvx-cache",
+		},
+	}
+}
+
+// Launch starts or connects to the MCP server acting with the given Jira token
+func (l McpLauncher) Launch(token string) (MCPClient, error) {
+	headers := map[string]string{"Authorization": "Token " + token}
+	switch l.Transport {
+	case McpTransportSSE:
+		mcpClient, err := client.NewSSEMCPClient(l.ServerURL, transport.WithHeaders(headers))
+		if err != nil {
+			return nil, err
+		}
+		// The event stream stays open for the lifetime of the client
+		if err := mcpClient.Start(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to connect to MCP server: %v", err)
+		}
+		return mcpClient, nil
+	case McpTransportHTTP:
+		return client.NewStreamableHttpClient(l.ServerURL, transport.WithHTTPHeaders(headers))
+	default:
+		return client.NewStdioMCPClient(l.Command, l.environ(token), l.Args...)
+	}
+}
+
+// For returns the launcher of a Jira instance: the same server reaching the instance's URL, or
+// the instance's own remote server
+func (l McpLauncher) For(instance JiraInstance) McpLauncher {
+	l.JiraURL = instance.URL
+	if instance.ServerURL != "" {
+		l.ServerURL = instance.ServerURL
+	}
+	return l
+}
+
+// environ lists the environment of the subprocess in a stable order
+func (l McpLauncher) environ(token string) []string {
+	env := make([]string, 0, len(l.Env)+2)
+	for key, value := range l.Env {
+		env = append(env, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(env)
+	if l.JiraURL != "" {
+		env = append(env, fmt.Sprintf("JIRA_URL=%s", l.JiraURL))
+	}
+	return append(env, fmt.Sprintf("JIRA_API_TOKEN=%s", token))
+}
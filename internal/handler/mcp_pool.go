@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// mcpHealthCheckAfter is how long a pooled client may sit unused before it is pinged on reuse
+const mcpHealthCheckAfter = 30 * time.Second
+
+// pinger is implemented by MCP clients that can check their server is still responding
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// mcpPool keeps initialized MCP clients of personal tokens for reuse, so a user's messages do
+// not each start a new MCP subprocess. Clients are keyed by a hash of the token, the least
+// recently used idle client is closed when the pool is full, and clients unused for longer
+// than idleTimeout are closed.
+type mcpPool struct {
+	start       func(token string) (MCPClient, error) // Creates and initializes a client
+	size        int
+	idleTimeout time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // Token hash -> element of lru holding a *pooledClient
+	lru     *list.List               // Most recently used at the front
+	stop    chan struct{}
+}
+
+// pooledClient is a client in the pool. ready is closed once the client has started or failed to.
+type pooledClient struct {
+	key      string
+	client   MCPClient
+	err      error
+	ready    chan struct{}
+	inUse    int
+	lastUsed time.Time
+	removed  bool // Dropped from the pool, closed by its last user
+}
+
+// newMcpPool creates a pool of at most size clients and starts closing idle ones
+func newMcpPool(size int, idleTimeout time.Duration, start func(token string) (MCPClient, error)) *mcpPool {
+	p := &mcpPool{
+		start:       start,
+		size:        size,
+		idleTimeout: idleTimeout,
+		entries:     map[string]*list.Element{},
+		lru:         list.New(),
+		stop:        make(chan struct{}),
+	}
+	go p.evictIdleLoop()
+	return p
+}
+
+// acquire returns a started client of the token, reusing a pooled one when it is still healthy.
+// release must be called once the caller is done with the client.
+func (p *mcpPool) acquire(token string) (MCPClient, func(), error) {
+	key := tokenHash(token)
+
+	p.mu.Lock()
+	for {
+		elem, ok := p.entries[key]
+		if !ok {
+			break
+		}
+		entry := elem.Value.(*pooledClient)
+		entry.inUse++
+		p.lru.MoveToFront(elem)
+		p.mu.Unlock()
+
+		<-entry.ready
+		if entry.err == nil && p.healthy(entry) {
+			return entry.client, func() { p.release(entry) }, nil
+		}
+		// The client failed to start or its server stopped responding, replace it
+		p.remove(entry)
+		p.mu.Lock()
+	}
+
+	entry := &pooledClient{key: key, ready: make(chan struct{}), inUse: 1, lastUsed: time.Now()}
+	p.entries[key] = p.lru.PushFront(entry)
+	evicted := p.evictLocked()
+	p.mu.Unlock()
+	closeClients(evicted)
+
+	client, err := p.start(token)
+	p.mu.Lock()
+	entry.client, entry.err = client, err
+	p.mu.Unlock()
+	close(entry.ready)
+	if entry.err != nil {
+		p.remove(entry)
+		return nil, nil, entry.err
+	}
+	return entry.client, func() { p.release(entry) }, nil
+}
+
+// healthy pings a client that has been idle for a while
+func (p *mcpPool) healthy(entry *pooledClient) bool {
+	p.mu.Lock()
+	idle := time.Since(entry.lastUsed)
+	p.mu.Unlock()
+	client, ok := entry.client.(pinger)
+	if !ok || idle < mcpHealthCheckAfter {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx); err != nil {
+		logger.GetLogger().Warn("pooled MCP client is not responding, starting a new one", zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// release returns a client to the pool
+func (p *mcpPool) release(entry *pooledClient) {
+	p.mu.Lock()
+	entry.inUse--
+	entry.lastUsed = time.Now()
+	evicted := p.evictLocked()
+	if entry.removed && entry.inUse == 0 {
+		evicted = append(evicted, entry.client)
+	}
+	p.mu.Unlock()
+	closeClients(evicted)
+}
+
+// remove drops a client from the pool and closes it once nobody uses it any more
+func (p *mcpPool) remove(entry *pooledClient) {
+	p.mu.Lock()
+	entry.inUse--
+	if elem, ok := p.entries[entry.key]; ok && elem.Value == entry {
+		p.lru.Remove(elem)
+		delete(p.entries, entry.key)
+	}
+	entry.removed = true
+	client := entry.client
+	idle := entry.inUse == 0
+	p.mu.Unlock()
+	if idle && client != nil {
+		closeClients([]MCPClient{client})
+	}
+}
+
+// evictLocked removes idle clients past the idle timeout and, while the pool is over its size,
+// the least recently used idle ones. The caller closes the returned clients outside the lock.
+func (p *mcpPool) evictLocked() []MCPClient {
+	var evicted []MCPClient
+	for elem := p.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		entry := elem.Value.(*pooledClient)
+		expired := time.Since(entry.lastUsed) > p.idleTimeout
+		if entry.inUse == 0 && (p.lru.Len() > p.size || expired) {
+			p.lru.Remove(elem)
+			delete(p.entries, entry.key)
+			if entry.client != nil {
+				evicted = append(evicted, entry.client)
+			}
+		}
+		elem = prev
+	}
+	return evicted
+}
+
+// evictIdleLoop closes idle clients periodically, so their subprocesses do not outlive their use
+func (p *mcpPool) evictIdleLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.mu.Lock()
+			evicted := p.evictLocked()
+			p.mu.Unlock()
+			closeClients(evicted)
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// Close closes every pooled client
+func (p *mcpPool) Close() {
+	p.mu.Lock()
+	var clients []MCPClient
+	for elem := p.lru.Front(); elem != nil; elem = elem.Next() {
+		if entry := elem.Value.(*pooledClient); entry.client != nil {
+			clients = append(clients, entry.client)
+		}
+	}
+	p.entries = map[string]*list.Element{}
+	p.lru.Init()
+	p.mu.Unlock()
+
+	close(p.stop)
+	closeClients(clients)
+}
+
+// closeClients stops the subprocesses of the clients
+func closeClients(clients []MCPClient) {
+	for _, client := range clients {
+		if err := client.Close(); err != nil {
+			logger.GetLogger().Error("failed to close MCP client", zap.Error(err))
+		}
+	}
+}
+
+// tokenHash keys pooled clients without keeping the token itself
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"jira_helper/internal/logger"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+	"go.uber.org/zap"
+)
+
+// memoryMessages is how many messages after the system prompt a thread's memory keeps
+const memoryMessages = 20
+
+// threadMemory is the stored form of the messages exchanged with the model in a thread.
+// LastTS is the newest thread message the memory has seen.
+type threadMemory struct {
+	LastTS   string          `json:"last_ts"`
+	Messages json.RawMessage `json:"messages"`
+}
+
+// usesMemory reports whether the conversation keeps its messages in the thread's memory.
+// Query API callers send their own history.
+func (h *SlackHandler) usesMemory(ctx context.Context, threadTS string) bool {
+	return h.conversations != nil && threadTS != "" && toolTraceFrom(ctx) == nil && h.featureEnabled(ctx, FeatureConversationMemory)
+}
+
+// recallConversation builds the messages of a new turn from the thread's memory, including the
+// tool calls and results of earlier turns. Messages posted in the thread since the last turn are
+// added from history. It reports false when the thread has no memory yet.
+func (h *SlackHandler) recallConversation(ctx context.Context, channelID, threadTS, query string, history []HistoryMessage) ([]azopenai.ChatRequestMessageClassification, bool) {
+	if !h.usesMemory(ctx, threadTS) {
+		return nil, false
+	}
+	data, err := h.conversations.Load(ctx, channelID, threadTS)
+	if err != nil {
+		logger.GetLogger().Warn("failed to load conversation memory, falling back to thread history", zap.Error(err))
+		return nil, false
+	}
+	if data == nil {
+		return nil, false
+	}
+	var memory threadMemory
+	if err := json.Unmarshal(data, &memory); err != nil {
+		logger.GetLogger().Warn("invalid conversation memory, falling back to thread history", zap.Error(err))
+		return nil, false
+	}
+	var stored []checkpointMessage
+	if err := json.Unmarshal(memory.Messages, &stored); err != nil {
+		logger.GetLogger().Warn("invalid conversation memory, falling back to thread history", zap.Error(err))
+		return nil, false
+	}
+	messages, err := decodeMessages(stored)
+	if err != nil {
+		logger.GetLogger().Warn("invalid conversation memory, falling back to thread history", zap.Error(err))
+		return nil, false
+	}
+
+	// The last message of the history is the query itself
+	if n := len(history); n > 0 && history[n-1].Role == "user" {
+		history = history[:n-1]
+	}
+	// Replies from the bot are in memory already, only what others wrote since is new
+	for _, msg := range history {
+		if msg.Role == "user" && msg.ts > memory.LastTS {
+			messages = append(messages, &azopenai.ChatRequestUserMessage{
+				Content: azopenai.NewChatRequestUserMessageContent(msg.Content),
+			})
+		}
+	}
+	messages = append(messages, &azopenai.ChatRequestUserMessage{
+		Content: azopenai.NewChatRequestUserMessageContent(query),
+	})
+	return messages, true
+}
+
+// rememberConversation stores the conversation and its answer as the thread's memory, keeping
+// the system prompt and the most recent messages
+func (h *SlackHandler) rememberConversation(ctx context.Context, conv *conversation, answer string) {
+	if !h.usesMemory(ctx, conv.ThreadTS) || answer == "" || conv.Stopped {
+		return
+	}
+	messages := append(slices.Clip(conv.Messages), &azopenai.ChatRequestAssistantMessage{
+		Content: azopenai.NewChatRequestAssistantMessageContent(answer),
+	})
+	encoded, err := json.Marshal(trimMemory(messages))
+	if err != nil {
+		logger.GetLogger().Warn("failed to marshal conversation memory", zap.Error(err))
+		return
+	}
+	data, err := json.Marshal(threadMemory{LastTS: conv.HistoryTS, Messages: encoded})
+	if err != nil {
+		logger.GetLogger().Warn("failed to marshal conversation memory", zap.Error(err))
+		return
+	}
+	if err := h.conversations.Save(ctx, conv.ChannelID, conv.ThreadTS, data); err != nil {
+		logger.GetLogger().Warn("failed to save conversation memory", zap.String("conversation_id", conv.ID), zap.Error(err))
+	}
+}
+
+// trimMemory keeps the system prompt and the last memoryMessages messages. Tool results whose
+// call was trimmed are dropped too, the model rejects them without it.
+func trimMemory(messages []azopenai.ChatRequestMessageClassification) []azopenai.ChatRequestMessageClassification {
+	if len(messages) <= memoryMessages+1 {
+		return messages
+	}
+	rest := messages[len(messages)-memoryMessages:]
+	for len(rest) > 0 {
+		if _, ok := rest[0].(*azopenai.ChatRequestToolMessage); !ok {
+			break
+		}
+		rest = rest[1:]
+	}
+	return append([]azopenai.ChatRequestMessageClassification{messages[0]}, rest...)
+}
+
+// latestTS returns the timestamp of the newest history message
+func latestTS(history []HistoryMessage) string {
+	var latest string
+	for _, msg := range history {
+		if msg.ts > latest {
+			latest = msg.ts
+		}
+	}
+	return latest
+}
@@ -5,33 +5,63 @@ import (
 	"strings"
 
 	"jira_helper/internal/logger"
+	"jira_helper/internal/policy"
 
 	"github.com/slack-go/slack"
 	"go.uber.org/zap"
 )
 
-// sendEphemeralSlackMessage sends an ephemeral message to Slack
-func (h *SlackHandler) sendEphemeralSlackMessage(channel string, message string, threadTS string) error {
+// sendEphemeralSlackMessage sends a message only the user sees. Platforms without ephemeral
+// messages get a normal one.
+func (h *SlackHandler) sendEphemeralSlackMessage(channel, userID, message, threadTS string, options ...slack.MsgOption) error {
 	if message == "" {
 		return nil
 	}
-	_, _, err := h.api.PostMessage(
-		channel,
-		slack.MsgOptionText(message, false),
-		slack.MsgOptionTS(threadTS))
+	if m := h.messengerFor(channel); m != nil {
+		_, err := m.PostMessage(channel, threadTS, message)
+		return err
+	}
+	options = append([]slack.MsgOption{slack.MsgOptionText(message, false), slack.MsgOptionTS(threadTS)}, options...)
+	_, err := h.slackClient(channel).PostEphemeral(channel, userID, options...)
 	if err != nil {
-		logger.GetLogger().Error(fmt.Sprintf("failed to post message due to %s", err))
+		logger.GetLogger().Error(fmt.Sprintf("failed to post ephemeral message due to %s", err))
 	}
 	return err
 }
 
+// messageRule returns who sees the progress and notices of conversations in the channel. Other
+// platforms have no ephemeral messages, so they keep everything in the thread.
+func (h *SlackHandler) messageRule(channelID string) policy.MessageRule {
+	if h.messengerFor(channelID) != nil {
+		return policy.MessageRule{Progress: policy.VisibilityThread, Notices: policy.VisibilityThread}
+	}
+	return h.messagePolicy.For(channelID)
+}
+
+// sendNotice tells the user of the conversation about a denied tool, a paused write and the like,
+// in the thread or only to them as the channel's message policy says
+func (h *SlackHandler) sendNotice(conv *conversation, message string) {
+	if h.messageRule(conv.ChannelID).Notices == policy.VisibilityEphemeral {
+		_ = h.sendEphemeralSlackMessage(conv.ChannelID, conv.UserID, message, conv.ThreadTS)
+		return
+	}
+	_, _ = h.sendMarkdownMessage(conv.ChannelID, message, conv.ThreadTS)
+}
+
 // sendMarkdownMessage sends a message to Slack with Markdown formatting enabled
 func (h *SlackHandler) sendMarkdownMessage(channel string, message string, threadTS string) (string, error) {
 	if message == "" {
 		return "", nil
 	}
+	if m := h.messengerFor(channel); m != nil {
+		timestamp, err := m.PostMessage(channel, threadTS, message)
+		if err != nil {
+			logger.GetLogger().Error("failed to post message", zap.String("channel", channel), zap.Error(err))
+		}
+		return timestamp, err
+	}
 
-	_, timestamp, err := h.api.PostMessage(
+	_, timestamp, err := h.slackClient(channel).PostMessage(
 		channel,
 		slack.MsgOptionText(message, false),
 		slack.MsgOptionTS(threadTS))
@@ -43,8 +73,16 @@ func (h *SlackHandler) sendMarkdownMessage(channel string, message string, threa
 
 // updateMessage updates an existing Slack message with new content and returns the message timestamp
 func (h *SlackHandler) updateMessage(channel string, timestamp string, message string) error {
+	if m := h.messengerFor(channel); m != nil {
+		err := m.UpdateMessage(channel, timestamp, message)
+		if err != nil {
+			logger.GetLogger().Error("failed to update message", zap.Error(err))
+		}
+		return err
+	}
+
 	// Update the existing message with all content
-	_, _, _, err := h.api.UpdateMessage(
+	_, _, _, err := h.slackClient(channel).UpdateMessage(
 		channel,
 		timestamp,
 		slack.MsgOptionText(message, false),
@@ -52,19 +90,17 @@ func (h *SlackHandler) updateMessage(channel string, timestamp string, message s
 	if err != nil {
 		logger.GetLogger().Error("failed to update message", zap.Error(err))
 	}
-	return nil
+	return err
 }
 
-// shouldCreateNewMessage determines if a new message should be created instead of updating the existing one
-func (h *SlackHandler) shouldCreateNewMessage(existingLines []string, newLine string) bool {
+// shouldCreateNewMessage determines if a new message should be created instead of updating the
+// existing one, as it would exceed limit characters
+func (h *SlackHandler) shouldCreateNewMessage(existingLines []string, newLine string, limit int) bool {
 	// Add new line to existing lines
 	combinedLines := append(existingLines, newLine)
-	message := strings.Join(combinedLines, "\n\n")
+	message := strings.Join(combinedLines, progressLineSeparator)
 
-	// Slack message length limit (approximately 40,000 characters)
-	const maxMessageLength = 40000
-
-	return len(message) > maxMessageLength
+	return len(message) > limit
 }
 
 // createNewMessage creates a new message in a thread and returns its timestamp
@@ -72,7 +108,10 @@ func (h *SlackHandler) createNewMessage(channel string, threadTS string, message
 	if message == "" {
 		return "", nil
 	}
-	_, timestamp, err := h.api.PostMessage(
+	if m := h.messengerFor(channel); m != nil {
+		return m.PostMessage(channel, threadTS, message)
+	}
+	_, timestamp, err := h.slackClient(channel).PostMessage(
 		channel,
 		slack.MsgOptionText(message, false),
 		slack.MsgOptionTS(threadTS))
@@ -0,0 +1,39 @@
+package handler
+
+import "strings"
+
+// Messenger posts and updates conversation messages on a chat platform other than Slack.
+// Channel and thread IDs are the platform's own identifiers.
+type Messenger interface {
+	PostMessage(channelID, threadID, text string) (string, error)
+	UpdateMessage(channelID, messageID, text string) error
+}
+
+// routedMessenger serves the channels whose ID starts with prefix
+type routedMessenger struct {
+	prefix    string
+	messenger Messenger
+}
+
+// messengerFor returns the messenger serving the channel, or nil for Slack channels
+func (h *SlackHandler) messengerFor(channelID string) Messenger {
+	for _, routed := range h.messengers {
+		if strings.HasPrefix(channelID, routed.prefix) {
+			return routed.messenger
+		}
+	}
+	return nil
+}
+
+// discardMessenger drops every message, for callers that only want the final answer
+type discardMessenger struct{}
+
+// PostMessage discards the message
+func (discardMessenger) PostMessage(_, _, _ string) (string, error) {
+	return "", nil
+}
+
+// UpdateMessage discards the update
+func (discardMessenger) UpdateMessage(_, _, _ string) error {
+	return nil
+}
@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"bytes"
+	"io"
 	"jira_helper/internal/logger"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/slack-go/slack"
 	"go.uber.org/zap"
 )
 
@@ -25,3 +28,28 @@ func HandleSlackRetry() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// VerifySlackSignature is a middleware that rejects requests without a valid Slack signature
+func VerifySlackSignature(signingSecret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		// Restore the body for the handlers
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		verifier, err := slack.NewSecretsVerifier(c.Request.Header, signingSecret)
+		if err == nil {
+			_, _ = verifier.Write(body)
+			err = verifier.Ensure()
+		}
+		if err != nil {
+			logger.GetLogger().Warn("rejected request with invalid slack signature", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid slack signature"})
+			return
+		}
+		c.Next()
+	}
+}
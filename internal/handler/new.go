@@ -3,55 +3,740 @@ package handler
 import (
 	"context"
 	"fmt"
+	"jira_helper/internal/anomaly"
+	"jira_helper/internal/audit"
+	"jira_helper/internal/circuitbreaker"
+	"jira_helper/internal/digest"
+	"jira_helper/internal/embeddings"
+	"jira_helper/internal/github"
+	"jira_helper/internal/guardrail"
+	"jira_helper/internal/httpclient"
 	"jira_helper/internal/logger"
+	"jira_helper/internal/metrics"
+	"jira_helper/internal/oncall"
+	"jira_helper/internal/policy"
+	"jira_helper/internal/prompts"
+	"jira_helper/internal/queue"
+	"jira_helper/internal/quota"
+	"jira_helper/internal/ratelimit"
+	"jira_helper/internal/render"
+	"jira_helper/internal/service/googlechat"
 	"jira_helper/internal/service/openai"
+	"jira_helper/internal/service/pagerduty"
+	"jira_helper/internal/service/slacktoken"
+	"jira_helper/internal/service/stepfunctions"
 	"jira_helper/internal/storage"
+	"jira_helper/internal/workerpool"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 
-	"github.com/mark3labs/mcp-go/client"
 	"github.com/slack-go/slack"
 	"go.uber.org/zap"
 )
 
 type SlackHandler struct {
-	api              *slack.Client
-	defaultMcpClient *client.Client // MCP client with default token
-	aiClient         *openai.Client
-	tokenStore       storage.TokenStore
-	msgFormatter     *ToolMessageFormatter
-	defaultJiraToken string // Default Jira token
+	api                    SlackAPI
+	defaultMcpClient       MCPClient // MCP client with default token
+	newMcpClient           MCPClientFactory
+	mcpLauncher            McpLauncher // How CreateMcpClient starts the MCP server
+	mcpPoolSize            int         // Personal token MCP clients kept for reuse, 0 starts one per conversation
+	mcpIdleTimeout         time.Duration
+	mcpPool                *mcpPool
+	jiraInstances          map[string]JiraInstance // Jira connections besides the primary one, by name
+	channelJiraInstances   map[string]string       // Channel ID -> instance its conversations use by default
+	aiClient               AIProvider
+	tokenStore             storage.TokenStore
+	msgFormatter           *ToolMessageFormatter
+	defaultJiraToken       string // Default Jira token
+	adminUserIDs           []string
+	auditTrail             audit.Trail // Optional: records Jira write operations
+	boundaries             *policy.Boundaries
+	toolPolicy             *policy.ToolPolicy        // Optional: tools allowed or denied per channel and user
+	accessPolicy           *policy.AccessPolicy      // Optional: channels the bot answers in and tools by role, unless the admin API set one
+	accessStore            storage.AccessPolicyStore // Optional: access policy set through the admin API
+	messagePolicy          *policy.MessagePolicy     // Optional: who sees the progress and notices of conversations per channel
+	limits                 *policy.LimitPolicy       // Optional: rounds, history and message sizes per channel, defaults otherwise
+	feedback               storage.FeedbackStore     // Optional: answers and the 👍/👎 feedback given on them
+	channelProjects        map[string][]string       // Jira projects each channel is scoped to
+	tokenRotator           *slacktoken.Rotator       // Optional: rotates the Slack bot token
+	burstDetector          *anomaly.BurstDetector
+	eventQueue             queue.Publisher           // Optional: hands events to the async worker
+	eventDedup             *queue.Deduplicator       // Drops repeated deliveries before they are processed
+	guard                  *guardrail.Guard          // Removes prompt injection and blocks tool calls reaching for tokens or internal endpoints
+	editLimiter            *ratelimit.Limiter        // Shares Slack's chat.update rate limit between the progress messages of a workspace
+	renderer               *render.Renderer          // Renders structured tool results under answers with Block Kit templates
+	eventLedger            queue.EventLedger         // Optional: shares the event IDs seen between instances
+	deadLetters            *queue.DeadLetterQueue    // Optional: events that failed after all retries
+	failedEvents           *queue.S3DeadLetters      // Optional: events that failed in-process, kept for replay
+	workerPool             *workerpool.Pool          // Optional: bounds concurrent conversations, one per thread
+	stepFunctions          *stepfunctions.Client     // Optional: runs long conversations as Step Functions executions
+	stateMachineARN        string                    // State machine that runs one round per state
+	handOffRounds          int                       // Rounds run inline before handing off to Step Functions
+	checkpoints            storage.CheckpointStore   // Conversation state carried between rounds
+	messengers             []routedMessenger         // Chat platforms other than Slack, by channel ID prefix
+	googleChat             *googlechat.Client        // Optional: serves Google Chat spaces
+	jiraURL                string                    // Optional: base URL used to link the issues an answer refers to
+	emailUserID            string                    // User whose personal token files inbound emails
+	issueLinks             storage.IssueLinkStore    // Optional: Slack threads and pull requests linked to issues
+	githubSecret           string                    // Secret GitHub signs webhook deliveries with
+	githubRules            map[string]string         // Pull request outcome -> Jira status the issue moves to
+	githubUserID           string                    // User whose personal token transitions issues
+	githubClient           *github.Client            // Optional: searches pull requests that mention issues
+	githubSearchQualifiers string                    // Narrows the pull request search, e.g. org:acme
+	slackAPIURL            string                    // Optional: Slack Web API base URL, set to use a fake workspace
+	incidents              storage.IncidentStore     // Optional: PagerDuty incidents bridged to Jira
+	subscriptions          storage.SubscriptionStore // Optional: channels and users notified by Jira webhooks
+	incidentRoutes         pagerduty.Routes          // PagerDuty service -> Slack channel and Jira project
+	incidentUserID         string                    // User whose personal token opens incident tickets
+	pagerDuty              *pagerduty.Client         // Optional: links tickets back to incidents
+	onCall                 *oncall.Directory         // Optional: on-call schedules of the projects, served by get_oncall and page_oncall
+	streaming              bool                      // Stream answers into the progress message as they are generated
+	contextTokens          int                       // Prompt budget in estimated tokens, 0 for the default
+	summarizeHistory       bool                      // Summarize the messages evicted from the prompt instead of dropping them
+	toolCache              *toolCache                // Optional: recent results of read tools
+	toolTimeout            time.Duration             // How long a tool call may take, 0 for no limit
+	toolTimeouts           map[string]time.Duration  // Tool name -> timeout overriding toolTimeout
+	toolBreaker            *circuitbreaker.Breaker   // Optional: refuses tools that failed repeatedly
+	customMiddleware       []Middleware              // Hooks added to the conversation loop
+	dryRunAll              bool                      // Writes of every conversation are shown instead of sent to Jira
+	chatChain              ChatHandler               // Calls to the model, wrapped by the middleware
+	toolChain              ToolHandler               // Tool calls, wrapped by the middleware
+	metrics                *metrics.Recorder         // Optional: records tokens, tool calls and latency per request
+	metricsRegistry        *metrics.Registry         // Optional: totals served by /metrics
+	approvals              storage.CheckpointStore   // Optional: conversations paused until the user approves a write
+	approveWrites          bool                      // Ask the user to approve each write
+	bulkThreshold          int                       // Issues a round may change before the user approves a preview, 0 disables bulk mode
+	quota                  *quota.Limiter            // Optional: per-user request and AI token quotas
+	conversations          storage.ConversationStore // Optional: messages exchanged with the model per thread
+	activity               storage.ActivityStore     // Optional: recent queries shown in the Home tab
+	digests                digest.Digests            // Reports posted to channels by the scheduled digest jobs
+	digestUserID           string                    // User whose personal token composes the digests
+	flags                  storage.FlagStore         // Optional: feature flags toggled through the admin API
+	opsCommands            map[string][]string       // Commands the ops endpoint may run, by name
+	workspaces             *workspaceRegistry        // Optional: workspaces installed through OAuth, each with its own bot token
+	oauth                  slackOAuth                // Client credentials and scopes of the OAuth install flow
+	attachments            attachmentPolicy          // Size and type limits of files copied between Jira and Slack
+	similarIssues          *embeddings.Index         // Optional: embeddings index searched by find_similar_issues
+	similarProjects        []string                  // Projects kept in the embeddings index
+	vision                 ImageReader               // Optional: model reading the screenshots shared in direct messages
+	prompts                *prompts.Templates        // System prompt templates, the built-in one if none are configured
+	teamName               string                    // Optional: team the bot works for, used by the prompt templates
+	styles                 prompts.Styles            // Response styles users can choose from
+	defaultStyle           string                    // Optional: style of users who have not chosen one
+	preferences            storage.PreferenceStore   // Optional: the settings each Slack user chose, e.g. their style
+	identities             storage.IdentityStore     // Optional: the Jira account of each Slack user
+	tokenBackups           storage.TokenBackupStore  // Optional: encrypted exports of the personal tokens
+
+	// Dependencies checked by /readyz, with their last results
+	readinessChecks []readinessCheck
+	readiness       readinessReport
+
+	// Users whose Jira account was not found, by when they were looked up
+	identityMu     sync.Mutex
+	identityMisses map[string]time.Time
+
+	// Time zones of the users' Slack profiles
+	timezoneMu       sync.Mutex
+	profileTimezones map[string]string
+
+	// Started MCP clients of the Jira instances, by name
+	instanceMu      sync.Mutex
+	instanceClients map[string]MCPClient
+
+	// Cached access policy of the admin API and the users' Slack user groups
+	accessMu       sync.Mutex
+	accessCache    *policy.AccessPolicy
+	accessLoadedAt time.Time
+	userGroupsMu   sync.Mutex
+	userGroupCache map[string]cachedUserGroups
+
+	// Cached feature flags
+	flagsMu       sync.Mutex
+	flagCache     map[string]bool
+	flagsLoadedAt time.Time
 
 	mcpInitOnce sync.Once
 	mcpInitErr  error
+	toolsMu     sync.Mutex
+	tools       []openai.Tool // Cached tool list of the default MCP client
+	toolsReady  atomic.Bool   // Set once the tool list has been loaded, read by health checks
+
+	// Cached bot identity from AuthTest
+	botMu           sync.RWMutex
+	botID           string
+	botIDResolvedAt time.Time
+
+	// Conversation tracking for graceful shutdown
+	activeMu sync.Mutex
+	active   map[*activeConversation]struct{}
+	draining bool
+	inFlight sync.WaitGroup
 }
 
 // HistoryMessage represents a message in the conversation history
 type HistoryMessage struct {
-	Role    string // "user" or "assistant"
-	Content string
+	Role    string `json:"role"` // "user" or "assistant"
+	Content string `json:"content"`
+
+	ts string // Slack timestamp of the message, set for thread history
 }
 
-func NewSlackHandler(token string, aiEndpoint string, aiKey string, aiDeployment string, defaultJiraToken string, tokenStore storage.TokenStore) (*SlackHandler, error) {
-	logger.GetLogger().Info("Starting uvx client with debug logging enabled")
-	aiClient, err := openai.NewClient(aiEndpoint, aiKey, aiDeployment)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create OpenAI client: %v", err)
+// Option configures optional SlackHandler behaviour
+type Option func(*SlackHandler)
+
+// WithAdminUserIDs sets the Slack user IDs allowed to run admin commands
+func WithAdminUserIDs(userIDs []string) Option {
+	return func(h *SlackHandler) {
+		h.adminUserIDs = userIDs
+	}
+}
+
+// WithAuditTrail sets the audit trail used to record Jira write operations
+func WithAuditTrail(trail audit.Trail) Option {
+	return func(h *SlackHandler) {
+		h.auditTrail = trail
+	}
+}
+
+// WithGuardrails replaces the built-in guardrails, e.g. to allow the Jira host or name the token store
+func WithGuardrails(guard *guardrail.Guard) Option {
+	return func(h *SlackHandler) {
+		h.guard = guard
+	}
+}
+
+// WithFeedback asks users to rate answers with 👍 or 👎 and records their ratings
+func WithFeedback(feedback storage.FeedbackStore) Option {
+	return func(h *SlackHandler) {
+		h.feedback = feedback
+	}
+}
+
+// WithLimits sets the conversation limits of each channel
+func WithLimits(limits *policy.LimitPolicy) Option {
+	return func(h *SlackHandler) {
+		h.limits = limits
+	}
+}
+
+// WithMessagePolicy sets who sees the progress and notices of conversations in each channel
+func WithMessagePolicy(messagePolicy *policy.MessagePolicy) Option {
+	return func(h *SlackHandler) {
+		h.messagePolicy = messagePolicy
+	}
+}
+
+// WithToolPolicy sets the rules deciding which tools users may call in which channels, and which
+// tools count as writes
+func WithToolPolicy(toolPolicy *policy.ToolPolicy) Option {
+	return func(h *SlackHandler) {
+		h.toolPolicy = toolPolicy
 	}
+}
+
+// WithBoundaries sets the project sensitivity rules enforced per channel
+func WithBoundaries(boundaries *policy.Boundaries) Option {
+	return func(h *SlackHandler) {
+		h.boundaries = boundaries
+	}
+}
 
-	return &SlackHandler{
-		api:              slack.New(token),
+// WithChannelProjects restricts the Jira projects each channel may query
+func WithChannelProjects(channelProjects map[string][]string) Option {
+	return func(h *SlackHandler) {
+		h.channelProjects = channelProjects
+	}
+}
+
+// WithTokenRotator makes the handler use a rotating Slack bot token
+func WithTokenRotator(rotator *slacktoken.Rotator) Option {
+	return func(h *SlackHandler) {
+		h.tokenRotator = rotator
+	}
+}
+
+// WithBurstDetector pauses writes when a user or project exceeds the write burst threshold
+func WithBurstDetector(detector *anomaly.BurstDetector) Option {
+	return func(h *SlackHandler) {
+		h.burstDetector = detector
+	}
+}
+
+// WithEventQueue acknowledges Slack events immediately and processes them asynchronously
+func WithEventQueue(publisher queue.Publisher) Option {
+	return func(h *SlackHandler) {
+		h.eventQueue = publisher
+	}
+}
+
+// WithEventLedger deduplicates Slack events across instances and restarts by recording their IDs
+func WithEventLedger(ledger queue.EventLedger) Option {
+	return func(h *SlackHandler) {
+		h.eventLedger = ledger
+	}
+}
+
+// WithDeadLetterQueue enables the admin commands to inspect and replay failed events
+func WithDeadLetterQueue(deadLetters *queue.DeadLetterQueue) Option {
+	return func(h *SlackHandler) {
+		h.deadLetters = deadLetters
+	}
+}
+
+// WithFailedEvents keeps events that fail in-process for admins to replay
+func WithFailedEvents(store *queue.S3DeadLetters) Option {
+	return func(h *SlackHandler) {
+		h.failedEvents = store
+	}
+}
+
+// WithPromptTemplates renders the system prompt of each channel from the templates
+func WithPromptTemplates(templates *prompts.Templates, teamName string) Option {
+	return func(h *SlackHandler) {
+		h.prompts = templates
+		h.teamName = teamName
+	}
+}
+
+// WithResponseStyles sets the styles users can choose from, besides the built-in ones, and the
+// style of users who have not chosen one
+func WithResponseStyles(styles prompts.Styles, defaultStyle string) Option {
+	return func(h *SlackHandler) {
+		h.styles = prompts.WithBuiltInStyles(styles)
+		h.defaultStyle = defaultStyle
+	}
+}
+
+// WithPreferences keeps the settings each Slack user chose, enabling `/jira set-style`
+func WithPreferences(store storage.PreferenceStore) Option {
+	return func(h *SlackHandler) {
+		h.preferences = store
+	}
+}
+
+// WithReadinessCheck adds a dependency /readyz verifies, e.g. access to the bucket
+func WithReadinessCheck(name string, check func(ctx context.Context) error) Option {
+	return func(h *SlackHandler) {
+		h.readinessChecks = append(h.readinessChecks, readinessCheck{name: name, check: check})
+	}
+}
+
+// WithWorkerPool processes events concurrently through the pool, one conversation per Slack thread
+func WithWorkerPool(pool *workerpool.Pool) Option {
+	return func(h *SlackHandler) {
+		h.workerPool = pool
+	}
+}
+
+// WithStepFunctions hands conversations that exceed handOffRounds to the state machine,
+// which runs each further round as a separate state from a checkpoint
+func WithStepFunctions(client *stepfunctions.Client, stateMachineARN string, handOffRounds int, checkpoints storage.CheckpointStore) Option {
+	return func(h *SlackHandler) {
+		h.stepFunctions = client
+		h.stateMachineARN = stateMachineARN
+		h.handOffRounds = handOffRounds
+		h.checkpoints = checkpoints
+	}
+}
+
+// WithMessenger routes the messages of channels whose ID starts with prefix to the messenger,
+// so the conversation engine can serve other platforms unchanged
+func WithMessenger(prefix string, messenger Messenger) Option {
+	return func(h *SlackHandler) {
+		h.messengers = append(h.messengers, routedMessenger{prefix: prefix, messenger: messenger})
+	}
+}
+
+// WithGoogleChat serves Google Chat spaces through the client
+func WithGoogleChat(client *googlechat.Client) Option {
+	return func(h *SlackHandler) {
+		h.googleChat = client
+		h.messengers = append(h.messengers, routedMessenger{prefix: googleChatSpacePrefix, messenger: client})
+	}
+}
+
+// WithJiraURL sets the Jira base URL used to link the issues an answer refers to
+func WithJiraURL(jiraURL string) Option {
+	return func(h *SlackHandler) {
+		h.jiraURL = strings.TrimSuffix(jiraURL, "/")
+	}
+}
+
+// WithEmailUser sets the user whose personal Jira token is used to file inbound emails.
+// Without it, email triage can only search Jira and not create or update issues.
+func WithEmailUser(userID string) Option {
+	return func(h *SlackHandler) {
+		h.emailUserID = userID
+	}
+}
+
+// WithIssueLinks links the Slack threads that discuss an issue to it, and adds the state of the
+// issue's pull requests to queries about it
+func WithIssueLinks(store storage.IssueLinkStore) Option {
+	return func(h *SlackHandler) {
+		h.issueLinks = store
+	}
+}
+
+// WithIdentities maps Slack users to their Jira accounts, so questions about "my issues" need no
+// personal token
+func WithIdentities(store storage.IdentityStore) Option {
+	return func(h *SlackHandler) {
+		h.identities = store
+	}
+}
+
+// WithTokenBackups lets admins export the personal tokens to encrypted backups and restore them
+func WithTokenBackups(store storage.TokenBackupStore) Option {
+	return func(h *SlackHandler) {
+		h.tokenBackups = store
+	}
+}
+
+// WithGitHub accepts GitHub webhooks signed with secret. Issues referenced by a pull request are
+// moved to the status rules maps its outcome to, using the personal token of userID.
+func WithGitHub(secret string, rules map[string]string, userID string) Option {
+	return func(h *SlackHandler) {
+		h.githubSecret = secret
+		h.githubRules = rules
+		h.githubUserID = userID
+	}
+}
+
+// WithGitHubSearch lets jira_get_linked_code search GitHub for pull requests that mention an
+// issue, narrowed by the search qualifiers, e.g. "org:acme"
+func WithGitHubSearch(client *github.Client, qualifiers string) Option {
+	return func(h *SlackHandler) {
+		h.githubClient = client
+		h.githubSearchQualifiers = qualifiers
+	}
+}
+
+// WithSlackAPI replaces the Slack Web API client, for other chat backends and tests
+func WithSlackAPI(api SlackAPI) Option {
+	return func(h *SlackHandler) {
+		h.api = api
+	}
+}
+
+// WithAIProvider replaces the Azure OpenAI client with another chat model
+func WithAIProvider(provider AIProvider) Option {
+	return func(h *SlackHandler) {
+		h.aiClient = provider
+	}
+}
+
+// WithMCPClientFactory replaces how MCP clients are started, such as with an in-process server
+func WithMCPClientFactory(factory MCPClientFactory) Option {
+	return func(h *SlackHandler) {
+		h.newMcpClient = factory
+	}
+}
+
+// WithMcpLauncher sets the command, arguments and environment the MCP server is started with
+func WithMcpLauncher(launcher McpLauncher) Option {
+	return func(h *SlackHandler) {
+		h.mcpLauncher = launcher
+	}
+}
+
+// WithMcpPool keeps up to size MCP clients of personal tokens started, so users' follow-up
+// messages reuse them. Clients unused for idleTimeout are closed.
+func WithMcpPool(size int, idleTimeout time.Duration) Option {
+	return func(h *SlackHandler) {
+		h.mcpPoolSize = size
+		h.mcpIdleTimeout = idleTimeout
+	}
+}
+
+// WithSlackAPIURL sends Slack Web API calls to apiURL instead of slack.com, such as the simulator's
+// fake workspace
+func WithSlackAPIURL(apiURL string) Option {
+	return func(h *SlackHandler) {
+		h.slackAPIURL = apiURL
+	}
+}
+
+// WithSubscriptions notifies subscribed channels and users about the issues reported by Jira
+// webhooks, and adds the subscribe subcommands to /jira
+func WithSubscriptions(store storage.SubscriptionStore) Option {
+	return func(h *SlackHandler) {
+		h.subscriptions = store
+	}
+}
+
+// WithPagerDuty bridges the incidents of the routed services to Jira, opening tickets with the
+// personal token of userID. With a client, the ticket and Slack thread are noted on the incident.
+func WithPagerDuty(incidents storage.IncidentStore, routes pagerduty.Routes, userID string, client *pagerduty.Client) Option {
+	return func(h *SlackHandler) {
+		h.incidents = incidents
+		h.incidentRoutes = routes
+		h.incidentUserID = userID
+		h.pagerDuty = client
+	}
+}
+
+// WithOnCall serves get_oncall and page_oncall, looking up the on-call of projects in the directory
+func WithOnCall(directory *oncall.Directory) Option {
+	return func(h *SlackHandler) {
+		h.onCall = directory
+	}
+}
+
+// WithDigests sets the reports the scheduled digest jobs post, composed with the personal token
+// of userID or the default token without one
+func WithDigests(digests digest.Digests, userID string) Option {
+	return func(h *SlackHandler) {
+		h.digests = digests
+		h.digestUserID = userID
+	}
+}
+
+// WithAccessPolicy restricts the channels the bot answers in and the tools of each role. A store
+// lets admins replace the policy at runtime through the admin API.
+func WithAccessPolicy(accessPolicy *policy.AccessPolicy, store storage.AccessPolicyStore) Option {
+	return func(h *SlackHandler) {
+		h.accessPolicy, h.accessStore = accessPolicy, store
+	}
+}
+
+// WithFeatureFlags lets admins turn features off at runtime through the admin API
+func WithFeatureFlags(store storage.FlagStore) Option {
+	return func(h *SlackHandler) {
+		h.flags = store
+	}
+}
+
+// WithOpsCommands sets the commands the ops endpoint may run, as argument lists by name
+func WithOpsCommands(commands map[string][]string) Option {
+	return func(h *SlackHandler) {
+		h.opsCommands = commands
+	}
+}
+
+// WithContextWindow sets the prompt budget in estimated tokens. With summarize, messages that no
+// longer fit are replaced by a summary the model writes of them.
+func WithContextWindow(tokens int, summarize bool) Option {
+	return func(h *SlackHandler) {
+		h.contextTokens = tokens
+		h.summarizeHistory = summarize
+	}
+}
+
+// WithToolCache caches the results of read tools for ttl, in memory and in the store if one is
+// given, so repeated lookups do not reach Jira again
+func WithToolCache(ttl time.Duration, store storage.ToolCacheStore) Option {
+	return func(h *SlackHandler) {
+		h.toolCache = newToolCache(ttl, store)
+	}
+}
+
+// WithToolTimeouts bounds how long a tool call may take, by default and per tool name. A timeout of
+// 0 means no limit.
+func WithToolTimeouts(timeout time.Duration, perTool map[string]time.Duration) Option {
+	return func(h *SlackHandler) {
+		h.toolTimeout, h.toolTimeouts = timeout, perTool
+	}
+}
+
+// WithCircuitBreaker makes a tool unavailable for cooldown after it failed failures times in a
+// row, then lets a trial call decide whether it is back
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return func(h *SlackHandler) {
+		h.toolBreaker = circuitbreaker.New(failures, cooldown)
+	}
+}
+
+// WithDryRun shows the writes of every conversation to the user instead of sending them to Jira,
+// e.g. for demos or to try the bot on a new project. Single requests ask for it with --dry-run.
+func WithDryRun(enabled bool) Option {
+	return func(h *SlackHandler) {
+		h.dryRunAll = enabled
+	}
+}
+
+// WithJiraInstances adds named Jira connections queries pick with "on <name>: ...", and the
+// instance each channel's conversations use when the query picks none
+func WithJiraInstances(instances map[string]JiraInstance, channels map[string]string) Option {
+	return func(h *SlackHandler) {
+		h.jiraInstances = instances
+		h.channelJiraInstances = channels
+	}
+}
+
+// WithMiddleware adds hooks to the conversation loop. They run after the built-in policy checks,
+// metrics and audit, in the order given.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(h *SlackHandler) {
+		h.customMiddleware = append(h.customMiddleware, middleware...)
+	}
+}
+
+// WithMetrics records the usage of every conversation run. registry may be nil, it serves the
+// totals of the process on /metrics.
+func WithMetrics(recorder *metrics.Recorder, registry *metrics.Registry) Option {
+	return func(h *SlackHandler) {
+		h.metrics = recorder
+		h.metricsRegistry = registry
+	}
+}
+
+// WithStreaming shows the model's answers in the progress message while they are generated,
+// if the AI provider supports streaming
+func WithStreaming(enabled bool) Option {
+	return func(h *SlackHandler) {
+		h.streaming = enabled
+	}
+}
+
+// WithApprovals asks the user to approve each Jira write in Slack before it runs. The paused
+// conversation is kept in store and resumes when the user clicks Approve.
+func WithApprovals(store storage.CheckpointStore) Option {
+	return func(h *SlackHandler) {
+		h.approvals = store
+		h.approveWrites = true
+	}
+}
+
+// WithBulkMode asks the user to approve a preview before tool calls change more than threshold
+// issues in one round. The paused conversation is kept in store like with WithApprovals.
+func WithBulkMode(threshold int, store storage.CheckpointStore) Option {
+	return func(h *SlackHandler) {
+		h.bulkThreshold = threshold
+		h.approvals = store
+	}
+}
+
+// WithQuota limits how many requests and AI tokens each user may use. Admins are exempt.
+func WithQuota(limiter *quota.Limiter) Option {
+	return func(h *SlackHandler) {
+		h.quota = limiter
+	}
+}
+
+// WithConversationStore keeps the messages exchanged with the model per thread, including tool
+// calls and results, so follow-ups continue from them instead of the thread history
+func WithConversationStore(store storage.ConversationStore) Option {
+	return func(h *SlackHandler) {
+		h.conversations = store
+	}
+}
+
+// WithActivityStore keeps each user's recent queries for their Home tab
+func WithActivityStore(store storage.ActivityStore) Option {
+	return func(h *SlackHandler) {
+		h.activity = store
+	}
+}
+
+// WithWorkspaces enables installing the app into other workspaces through OAuth. Their bot tokens
+// are kept in the store and used for the channels and users of each workspace.
+func WithWorkspaces(store storage.WorkspaceStore, clientID, clientSecret, redirectURL string, scopes []string) Option {
+	return func(h *SlackHandler) {
+		h.workspaces = newWorkspaceRegistry(store, nil)
+		h.oauth = slackOAuth{clientID: clientID, clientSecret: clientSecret, redirectURL: redirectURL, scopes: scopes}
+	}
+}
+
+// WithAttachmentLimits limits the files copied between Jira and Slack by size and file extension
+func WithAttachmentLimits(maxBytes int64, types []string) Option {
+	return func(h *SlackHandler) {
+		h.attachments = attachmentPolicy{maxBytes: maxBytes, types: types}
+	}
+}
+
+// WithVision reads the screenshots shared in direct messages with the model, so their text and
+// what they show become part of the query
+func WithVision(reader ImageReader) Option {
+	return func(h *SlackHandler) {
+		h.vision = reader
+	}
+}
+
+// WithSimilarIssues offers the find_similar_issues tool, which searches the embeddings index of
+// the projects
+func WithSimilarIssues(index *embeddings.Index, projects []string) Option {
+	return func(h *SlackHandler) {
+		h.similarIssues = index
+		h.similarProjects = projects
+	}
+}
+
+// NewSlackHandler creates a handler. The Slack, AI and MCP clients are built from the given
+// settings unless replaced through WithSlackAPI, WithAIProvider and WithMCPClientFactory.
+func NewSlackHandler(token string, aiEndpoint string, aiKey string, aiDeployment string, defaultJiraToken string, tokenStore storage.TokenStore, opts ...Option) (*SlackHandler, error) {
+	h := &SlackHandler{
 		defaultMcpClient: nil, // 延迟初始化
-		aiClient:         aiClient,
+		mcpLauncher:      DefaultMcpLauncher(),
 		tokenStore:       tokenStore,
 		defaultJiraToken: defaultJiraToken,
-	}, nil
+		active:           map[*activeConversation]struct{}{},
+		instanceClients:  map[string]MCPClient{},
+		userGroupCache:   map[string]cachedUserGroups{},
+		messengers:       []routedMessenger{{prefix: apiChannelPrefix, messenger: discardMessenger{}}},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.eventDedup = queue.NewDeduplicator(eventDedupTTL, eventDedupSize, h.eventLedger)
+	if h.guard == nil {
+		h.guard = guardrail.New(nil, nil, nil)
+	}
+	h.editLimiter = ratelimit.NewLimiter(progressEditsPerMinute, time.Minute, progressEditBurst)
+	h.renderer = render.New(h.jiraURL)
+	if h.prompts == nil {
+		h.prompts, _ = prompts.NewTemplates(nil, nil)
+	}
+	if h.styles == nil {
+		h.styles = prompts.WithBuiltInStyles(nil)
+	}
+	if h.aiClient == nil {
+		aiClient, err := openai.NewClient(aiEndpoint, aiKey, aiDeployment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OpenAI client: %v", err)
+		}
+		h.aiClient = aiClient
+	}
+	options := []slack.Option{slack.OptionHTTPClient(httpclient.New(httpclient.SlackTimeout))}
+	if h.slackAPIURL != "" {
+		options = append(options, slack.OptionAPIURL(h.slackAPIURL))
+	}
+	if h.api == nil {
+		h.api = slack.New(token, options...)
+	}
+	if h.workspaces != nil {
+		h.workspaces.options = options
+	}
+	if h.newMcpClient == nil {
+		logger.GetLogger().Info("MCP server configured", zap.String("transport", h.mcpLauncher.Transport), zap.String("command", h.mcpLauncher.Command), zap.String("server_url", h.mcpLauncher.ServerURL))
+		h.newMcpClient = h.CreateMcpClient
+	}
+	if h.mcpPoolSize > 0 {
+		h.mcpPool = newMcpPool(h.mcpPoolSize, h.mcpIdleTimeout, h.startMcpClient)
+	}
+	h.buildChains()
+	return h, nil
+}
+
+// slackClient returns the Slack client for the bot token of the workspace the channel or user ID
+// belongs to, the configured bot token unless the workspace was installed through OAuth
+func (h *SlackHandler) slackClient(id string) SlackAPI {
+	if client := h.workspaces.clientFor(id); client != nil {
+		return client
+	}
+	if h.tokenRotator != nil {
+		return h.tokenRotator.Client()
+	}
+	return h.api
 }
 
 // initializeMcpClient handles the common initialization logic for MCP clients
-func (h *SlackHandler) initializeMcpClient(mcpClient *client.Client, timeout time.Duration) error {
+func (h *SlackHandler) initializeMcpClient(mcpClient MCPClient, timeout time.Duration) error {
 	initRequest := mcp.InitializeRequest{}
 	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
 	initRequest.Params.ClientInfo = mcp.Implementation{
@@ -64,7 +749,7 @@ func (h *SlackHandler) initializeMcpClient(mcpClient *client.Client, timeout tim
 	logger.GetLogger().Info("Initializing MCP client")
 	initResult, err := mcpClient.Initialize(ctx, initRequest)
 	if err != nil {
-		logger.GetLogger().Fatal("MCP client initialization failed", zap.Error(err))
+		logger.GetLogger().Error("MCP client initialization failed", zap.Error(err))
 		return fmt.Errorf("failed to initialize MCP client: %v", err)
 	}
 
@@ -73,23 +758,13 @@ func (h *SlackHandler) initializeMcpClient(mcpClient *client.Client, timeout tim
 }
 
 // CreateMcpClient creates a new MCP client with the given token
-func (h *SlackHandler) CreateMcpClient(token string) (*client.Client, error) {
-	return client.NewStdioMCPClient(
-		"uvx",
-		[]string{
-			"UV_TOOL_DIR=/tmp/uvx-tool",
-			"UV_CACHE_DIR=/tmp/uvx-cache",
-			fmt.Sprintf("JIRA_API_TOKEN=%s", token),
-			"JIRA_URL=https://jira.com", // TODO add your jira server link
-		},
-		"run",
-		"mcp-atlassian",
-	)
+func (h *SlackHandler) CreateMcpClient(token string) (MCPClient, error) {
+	return h.mcpLauncher.Launch(token)
 }
 
 func (h *SlackHandler) ensureDefaultMcpClient() error {
 	h.mcpInitOnce.Do(func() {
-		defaultMcpClient, err := h.CreateMcpClient(h.defaultJiraToken)
+		defaultMcpClient, err := h.newMcpClient(h.defaultJiraToken)
 		if err != nil {
 			h.mcpInitErr = fmt.Errorf("failed to create default MCP client: %v", err)
 			return
@@ -105,7 +780,23 @@ func (h *SlackHandler) ensureDefaultMcpClient() error {
 	return h.mcpInitErr
 }
 
-func (h *SlackHandler) getMcpClient(userToken string) (*client.Client, func(), error) {
+// WarmUp resolves the bot identity, starts the default MCP subprocess and loads its tools, so
+// the first conversation does not pay for it. Conversations started meanwhile wait for the
+// same initialization.
+func (h *SlackHandler) WarmUp(ctx context.Context) {
+	if _, err := h.resolveBotIdentity(); err != nil {
+		logger.GetLogger().Error("failed to resolve bot identity", zap.Error(err))
+	}
+
+	start := time.Now()
+	if _, err := h.availableTools(ctx); err != nil {
+		logger.GetLogger().Error("failed to warm up MCP client", zap.Error(err))
+		return
+	}
+	logger.GetLogger().Info("MCP client warmed up", zap.Duration("duration", time.Since(start)))
+}
+
+func (h *SlackHandler) getMcpClient(userToken string) (MCPClient, func(), error) {
 	if userToken == "" {
 		if err := h.ensureDefaultMcpClient(); err != nil {
 			return nil, nil, err
@@ -114,13 +805,14 @@ func (h *SlackHandler) getMcpClient(userToken string) (*client.Client, func(), e
 		return h.defaultMcpClient, func() {}, nil
 	}
 
-	// Create a new MCP client with the user-supplied token
-	mcpClient, err := h.CreateMcpClient(userToken)
-	if err != nil {
-		return nil, nil, err
+	// Reuse a started client of the user's token
+	if h.mcpPool != nil {
+		return h.mcpPool.acquire(userToken)
 	}
 
-	if err := h.initializeMcpClient(mcpClient, 2*time.Minute); err != nil {
+	// Create a new MCP client with the user-supplied token
+	mcpClient, err := h.startMcpClient(userToken)
+	if err != nil {
 		return nil, nil, err
 	}
 
@@ -130,3 +822,17 @@ func (h *SlackHandler) getMcpClient(userToken string) (*client.Client, func(), e
 		}
 	}, nil
 }
+
+// startMcpClient creates and initializes an MCP client acting with the user's token
+func (h *SlackHandler) startMcpClient(userToken string) (MCPClient, error) {
+	mcpClient, err := h.newMcpClient(userToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.initializeMcpClient(mcpClient, 2*time.Minute); err != nil {
+		_ = mcpClient.Close()
+		return nil, err
+	}
+	return mcpClient, nil
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"jira_helper/internal/httpclient"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	slackAuthorizeURL = "https://slack.com/oauth/v2/authorize"
+
+	// oauthStateCookie binds the state of an install to the browser that started it
+	oauthStateCookie = "slack_oauth_state"
+	// oauthStateTTL is how long an install may take from the install link to the redirect
+	oauthStateTTL = 10 * time.Minute
+)
+
+// slackOAuth holds the settings of the OAuth install flow
+type slackOAuth struct {
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+}
+
+// HandleSlackInstall handles GET /slack/install. It sends the browser to Slack's consent page
+// for installing the app into a workspace.
+func (h *SlackHandler) HandleSlackInstall(c *gin.Context) {
+	state, err := h.newOAuthState(time.Now())
+	if err != nil {
+		logger.GetLogger().Error("failed to create oauth state", zap.Error(err))
+		c.String(http.StatusInternalServerError, "Failed to start the installation, please try again.")
+		return
+	}
+	query := url.Values{
+		"client_id":    {h.oauth.clientID},
+		"scope":        {strings.Join(h.oauth.scopes, ",")},
+		"redirect_uri": {h.oauth.redirectURL},
+		"state":        {state},
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, state, int(oauthStateTTL.Seconds()), "", "", true, true)
+	c.Redirect(http.StatusFound, slackAuthorizeURL+"?"+query.Encode())
+}
+
+// HandleSlackOAuthRedirect handles GET /slack/oauth_redirect. It exchanges the code Slack
+// redirected with for the workspace's bot token and stores the installation.
+func (h *SlackHandler) HandleSlackOAuthRedirect(c *gin.Context) {
+	if reason := c.Query("error"); reason != "" {
+		c.String(http.StatusOK, "The installation was cancelled: %s", reason)
+		return
+	}
+	cookie, _ := c.Cookie(oauthStateCookie)
+	state := c.Query("state")
+	if state == "" || state != cookie || !h.validOAuthState(state, time.Now()) {
+		c.String(http.StatusBadRequest, "The installation link has expired or was opened in another browser, please start again.")
+		return
+	}
+	c.SetCookie(oauthStateCookie, "", -1, "", "", true, true)
+
+	resp, err := slack.GetOAuthV2ResponseContext(c.Request.Context(), httpclient.New(httpclient.SlackTimeout),
+		h.oauth.clientID, h.oauth.clientSecret, c.Query("code"), h.oauth.redirectURL)
+	if err != nil {
+		logger.GetLogger().Error("failed to exchange oauth code", zap.Error(err))
+		c.String(http.StatusBadGateway, "Slack did not accept the installation, please try again.")
+		return
+	}
+	if resp.IsEnterpriseInstall || resp.Team.ID == "" {
+		c.String(http.StatusBadRequest, "Org-wide installations are not supported, install the app into a workspace instead.")
+		return
+	}
+
+	workspace := &storage.Workspace{
+		TeamID:      resp.Team.ID,
+		TeamName:    resp.Team.Name,
+		BotToken:    resp.AccessToken,
+		BotUserID:   resp.BotUserID,
+		InstalledBy: resp.AuthedUser.ID,
+		InstalledAt: time.Now(),
+	}
+	if err := h.workspaces.install(workspace); err != nil {
+		logger.GetLogger().Error("failed to save workspace", zap.String("team_id", workspace.TeamID), zap.Error(err))
+		c.String(http.StatusInternalServerError, "Failed to save the installation, please try again.")
+		return
+	}
+	logger.GetLogger().Info("app installed into workspace",
+		zap.String("team_id", workspace.TeamID),
+		zap.String("team_name", workspace.TeamName),
+		zap.String("installed_by", workspace.InstalledBy))
+	c.String(http.StatusOK, "Jira Helper is installed in %s. Mention the bot in a channel or send it a direct message to get started.", workspace.TeamName)
+}
+
+// newOAuthState returns a random state signed with the client secret, so only installs started
+// by HandleSlackInstall can complete
+func (h *SlackHandler) newOAuthState(now time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	payload := hex.EncodeToString(nonce) + "." + strconv.FormatInt(now.Unix(), 10)
+	return payload + "." + h.signOAuthState(payload), nil
+}
+
+// validOAuthState reports whether the state is signed and was issued within oauthStateTTL
+func (h *SlackHandler) validOAuthState(state string, now time.Time) bool {
+	i := strings.LastIndex(state, ".")
+	if i < 0 {
+		return false
+	}
+	payload, signature := state[:i], state[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(h.signOAuthState(payload))) {
+		return false
+	}
+	_, issued, _ := strings.Cut(payload, ".")
+	seconds, err := strconv.ParseInt(issued, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	return age >= 0 && age <= oauthStateTTL
+}
+
+// signOAuthState signs the payload of a state with the client secret
+func (h *SlackHandler) signOAuthState(payload string) string {
+	mac := hmac.New(sha256.New, []byte(h.oauth.clientSecret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
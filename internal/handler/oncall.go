@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/oncall"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/service/openai"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// getOnCallTool and pageOnCallTool are served by the handler next to the MCP server's tools.
+	// pageOnCallTool is a write tool, so it needs the user's token and follows the write policy.
+	getOnCallTool  = "get_oncall"
+	pageOnCallTool = "page_oncall"
+
+	// Actions of page_oncall
+	pageActionMention = "mention"
+	pageActionAssign  = "assign"
+	pageActionBoth    = "both"
+)
+
+// getOnCallDefinition describes get_oncall to the model
+var getOnCallDefinition = openai.Tool{
+	Name: getOnCallTool,
+	Description: "Look up who is currently on call for a Jira project in PagerDuty or Opsgenie, e.g. for ESC tickets. " +
+		"Returns the on-call people, the primary first, with their Slack user IDs when known.",
+	Parameters: `{
+	"type": "object",
+	"properties": {
+		"project_key": {"type": "string", "description": "Key of the Jira project, e.g. ESC"},
+		"issue_key": {"type": "string", "description": "Key of an issue of the project, e.g. ESC-123, instead of project_key"}
+	}
+}`,
+}
+
+// pageOnCallDefinition describes page_oncall to the model
+var pageOnCallDefinition = openai.Tool{
+	Name: pageOnCallTool,
+	Description: "Escalate an issue to the primary on-call of its project: mention them in this Slack thread, " +
+		"assign the issue to them in Jira, or both. Only use it when the user asks to escalate or page.",
+	Parameters: `{
+	"type": "object",
+	"properties": {
+		"issue_key": {"type": "string", "description": "Key of the issue to escalate, e.g. ESC-123"},
+		"action": {"type": "string", "enum": ["mention", "assign", "both"], "description": "Mention the on-call in Slack, assign the issue to them, or both. Defaults to mention."},
+		"message": {"type": "string", "description": "Short note for the on-call on what is needed"}
+	},
+	"required": ["issue_key"]
+}`,
+}
+
+// onCallPerson is an on-call responder with their Slack user, if one has their email
+type onCallPerson struct {
+	oncall.Responder
+	SlackUserID string `json:"slack_user_id,omitempty"`
+}
+
+// getOnCall runs a get_oncall call
+func (h *SlackHandler) getOnCall(ctx context.Context, conv *conversation, toolCall openai.ToolCall, userToken string) (*mcp.CallToolResult, error) {
+	project, _ := toolCall.Args["project_key"].(string)
+	if project == "" {
+		project = projectOfToolCall(toolCall.Args)
+	}
+	project = strings.ToUpper(strings.TrimSpace(project))
+	if project == "" {
+		return mcp.NewToolResultError("project_key or issue_key is required"), nil
+	}
+
+	schedule, people, err := h.onCallPeople(ctx, conv.ChannelID, project)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return mcp.NewToolResultText(printJSON(map[string]interface{}{
+		"project":  project,
+		"provider": schedule.Provider,
+		"schedule": schedule.Schedule,
+		"on_call":  people,
+	})), nil
+}
+
+// pageOnCall runs a page_oncall call, mentioning the primary on-call in the thread and assigning
+// the issue to them as asked
+func (h *SlackHandler) pageOnCall(ctx context.Context, conv *conversation, toolCall openai.ToolCall, userToken string) (*mcp.CallToolResult, error) {
+	key, _ := toolCall.Args["issue_key"].(string)
+	key = strings.ToUpper(strings.TrimSpace(key))
+	if !issueKeyPattern.MatchString(key) {
+		return mcp.NewToolResultError("issue_key must be an issue key such as ESC-123"), nil
+	}
+	action, _ := toolCall.Args["action"].(string)
+	if action == "" {
+		action = pageActionMention
+	}
+	if action != pageActionMention && action != pageActionAssign && action != pageActionBoth {
+		return mcp.NewToolResultError("action must be mention, assign or both"), nil
+	}
+	message, _ := toolCall.Args["message"].(string)
+
+	schedule, people, err := h.onCallPeople(ctx, conv.ChannelID, projectOfToolCall(map[string]interface{}{"issue_key": key}))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	primary := people[0]
+	result := map[string]interface{}{"issue_key": key, "provider": schedule.Provider, "on_call": primary}
+
+	if action == pageActionAssign || action == pageActionBoth {
+		assignee, err := h.assignToOnCall(ctx, userToken, key, primary.Email)
+		if err != nil {
+			logger.GetLogger().Warn("failed to assign issue to on-call", zap.String("issue", key), zap.Error(err))
+			return mcp.NewToolResultError(fmt.Sprintf("failed to assign %s to the on-call: %s", key, jiraErrorMessage(err))), nil
+		}
+		result["assigned_to"] = assignee
+	}
+	if action == pageActionMention || action == pageActionBoth {
+		who := primary.Name
+		if primary.SlackUserID != "" {
+			who = fmt.Sprintf("<@%s>", primary.SlackUserID)
+		} else if who == "" {
+			who = primary.Email
+		}
+		text := fmt.Sprintf("🚨 %s, you are on call for %s and <@%s> needs your help", who, h.issueLink(key), conv.UserID)
+		if message = strings.TrimSpace(message); message != "" {
+			text += ": " + message
+		}
+		if _, err := h.sendMarkdownMessage(conv.ChannelID, text, conv.ThreadTS); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to mention the on-call: %v", err)), nil
+		}
+		result["mentioned"] = true
+	}
+	return mcp.NewToolResultText(printJSON(result)), nil
+}
+
+// onCallPeople looks up who is on call for the project, with their Slack users
+func (h *SlackHandler) onCallPeople(ctx context.Context, channelID, project string) (oncall.Schedule, []onCallPerson, error) {
+	schedule, responders, err := h.onCall.OnCall(ctx, project)
+	if err != nil {
+		logger.GetLogger().Warn("failed to look up on-call", zap.String("project", project), zap.Error(err))
+		return schedule, nil, err
+	}
+	if len(responders) == 0 {
+		return schedule, nil, fmt.Errorf("nobody is on call for %s right now", project)
+	}
+	people := make([]onCallPerson, 0, len(responders))
+	for _, responder := range responders {
+		person := onCallPerson{Responder: responder}
+		if responder.Email != "" && h.messengerFor(channelID) == nil {
+			if user, err := h.slackClient(channelID).GetUserByEmailContext(ctx, responder.Email); err == nil {
+				person.SlackUserID = user.ID
+				if person.Name == "" {
+					person.Name = user.RealName
+				}
+			}
+		}
+		people = append(people, person)
+	}
+	return schedule, people, nil
+}
+
+// assignToOnCall assigns the issue to the Jira account with the on-call's email, and returns its name
+func (h *SlackHandler) assignToOnCall(ctx context.Context, userToken, key, email string) (string, error) {
+	if email == "" {
+		return "", fmt.Errorf("the on-call has no email address to find their Jira account with")
+	}
+	client, err := jira.NewClient(h.jiraURL, userToken)
+	if err != nil {
+		return "", err
+	}
+	users, err := client.FindUsers(ctx, email)
+	if err != nil {
+		return "", err
+	}
+	// Jira Cloud may hide email addresses, a single match is taken to be the on-call
+	var match *jira.User
+	for i, user := range users {
+		if user.Active && (strings.EqualFold(user.EmailAddress, email) || len(users) == 1) {
+			match = &users[i]
+			break
+		}
+	}
+	if match == nil {
+		return "", fmt.Errorf("no Jira account has the email %s", email)
+	}
+	if err := client.AssignIssue(ctx, key, *match); err != nil {
+		return "", err
+	}
+	return match.DisplayName, nil
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"jira_helper/internal/auth"
+	"jira_helper/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// opsCommandTimeout bounds an ops command
+	opsCommandTimeout = 60 * time.Second
+
+	// maxOpsOutput is how much of a command's stdout and stderr is returned
+	maxOpsOutput = 64 * 1024
+)
+
+// HandleOpsCommand runs one of the configured ops commands by name, e.g. {"command": "disk-usage"}.
+// Commands run without a shell, so a request cannot change what runs, and every run is audited.
+func (h *SlackHandler) HandleOpsCommand(c *gin.Context) {
+	var request struct {
+		Command string `json:"command" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format, must be JSON with 'command' field"})
+		return
+	}
+	argv, ok := h.opsCommands[request.Command]
+	if !ok || len(argv) == 0 {
+		h.auditAdminAPI(c, "", "ops_command", map[string]interface{}{"command": request.Command}, errors.New("command not allowed"))
+		c.JSON(http.StatusForbidden, gin.H{"error": "command not allowed"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), opsCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	h.auditAdminAPI(c, "", "ops_command", map[string]interface{}{"command": request.Command}, err)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		c.JSON(http.StatusRequestTimeout, gin.H{
+			"error":   "Command execution timed out",
+			"stdout":  truncateOutput(stdout.String()),
+			"stderr":  truncateOutput(stderr.String()),
+			"partial": true,
+		})
+		return
+	}
+	if err != nil {
+		logger.GetLogger().Warn("ops command failed",
+			zap.String("command", request.Command),
+			zap.String("caller", auth.Caller(c)),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "stderr": truncateOutput(stderr.String())})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"stdout": truncateOutput(stdout.String()),
+		"stderr": truncateOutput(stderr.String()),
+	})
+}
+
+// truncateOutput keeps the start of long command output
+func truncateOutput(output string) string {
+	if len(output) <= maxOpsOutput {
+		return output
+	}
+	return output[:maxOpsOutput] + "\n[truncated]"
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/pagerduty"
+	"jira_helper/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+// incidentTimeout bounds the handling of one incident event like the Slack handlers do
+const incidentTimeout = 5 * time.Minute
+
+// incidentTicketPrompt asks the model to open the Jira ticket of a new incident
+const incidentTicketPrompt = `PagerDuty triggered incident #%d. Create a Jira issue in project %s to track it:
+- Summary: "Incident #%d: %s"
+- Description: the alert context below, including the link to the PagerDuty incident
+- Priority matching the urgency and priority below
+Reply with the issue key and a one-line summary.
+
+Title: %s
+Service: %s
+Urgency: %s
+Priority: %s
+Triggered at: %s
+PagerDuty: %s`
+
+// incidentPostmortemPrompt asks the model to close out an incident from its Slack thread
+const incidentPostmortemPrompt = `PagerDuty incident #%d ("%s") was resolved, it is tracked in Jira as %s. Based on this thread:
+- Add a comment to %s summarizing the timeline, the cause and the resolution as far as the thread tells.
+- For each follow-up or postmortem action item discussed in the thread, create a task in project %s that refers to %s in its description.
+Reply with the issue keys of the created tasks and a one-line summary of each.`
+
+// HandlePagerDutyWebhook bridges PagerDuty incidents to Jira. A triggered incident gets a Slack
+// thread in the owning channel and a Jira ticket, which are linked back to the incident. The thread
+// follows the incident, and once it is resolved its action items are filed in Jira.
+func (h *SlackHandler) HandlePagerDutyWebhook(c *gin.Context) {
+	if h.incidents == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pagerduty is not configured"})
+		return
+	}
+
+	var payload pagerduty.WebhookPayload
+	if err := json.NewDecoder(c.Request.Body).Decode(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event"})
+		return
+	}
+	event := payload.Event
+	if event.ResourceType != "incident" {
+		c.JSON(http.StatusOK, gin.H{"message": "event ignored"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), incidentTimeout)
+	defer cancel()
+
+	var err error
+	switch event.EventType {
+	case pagerduty.EventIncidentTriggered:
+		err = h.openIncident(ctx, event)
+	case pagerduty.EventIncidentAcknowledged:
+		err = h.acknowledgeIncident(ctx, event)
+	case pagerduty.EventIncidentResolved:
+		err = h.resolveIncident(ctx, event)
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "event ignored"})
+		return
+	}
+	if err != nil {
+		logger.GetLogger().Error("failed to handle pagerduty event",
+			zap.String("event_type", event.EventType),
+			zap.String("incident", event.Data.ID),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+// openIncident starts the Slack thread of a new incident and opens its Jira ticket
+func (h *SlackHandler) openIncident(ctx context.Context, event pagerduty.Event) error {
+	inc := event.Data
+	existing, err := h.incidents.Load(ctx, inc.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		// PagerDuty redelivers events it did not see acknowledged in time
+		return nil
+	}
+	route, ok := h.incidentRoutes.Match(inc.Service.ID)
+	if !ok {
+		logger.GetLogger().Warn("dropped incident without a route", zap.String("service", inc.Service.ID))
+		return nil
+	}
+
+	notice := incidentNotice(inc)
+	threadTS, err := h.sendMarkdownMessage(route.Channel, notice, "")
+	if err != nil {
+		return fmt.Errorf("failed to notify channel %s: %v", route.Channel, err)
+	}
+
+	// Record the incident before the ticket is opened, so a redelivery does not open a second one
+	record := &storage.Incident{IncidentID: inc.ID, ChannelID: route.Channel, ThreadTS: threadTS, Project: route.Project, Status: inc.Status}
+	if err := h.incidents.Save(ctx, record); err != nil {
+		return err
+	}
+
+	priority := "none"
+	if inc.Priority != nil {
+		priority = inc.Priority.Summary
+	}
+	query := fmt.Sprintf(incidentTicketPrompt, inc.Number, route.Project, inc.Number, inc.Title,
+		inc.Title, inc.Service.Summary, inc.Urgency, priority, inc.CreatedAt.Format(time.RFC3339), inc.HTMLURL)
+	result, err := h.Query(ctx, query, nil, route.Channel, threadTS, h.incidentUserID)
+	if err != nil {
+		return fmt.Errorf("failed to open incident ticket: %v", err)
+	}
+	_, _ = h.sendMarkdownMessage(route.Channel, result.Answer, threadTS)
+	if len(result.Citations) == 0 {
+		return nil
+	}
+
+	record.IssueKey = result.Citations[0].IssueKey
+	if err := h.incidents.Save(ctx, record); err != nil {
+		return err
+	}
+
+	issueLink := record.IssueKey
+	if url := h.issueURL(record.IssueKey); url != "" {
+		issueLink = fmt.Sprintf("<%s|%s>", url, record.IssueKey)
+	}
+	_ = h.updateMessage(route.Channel, threadTS, notice+"\n*Jira:* "+issueLink)
+
+	// Link the ticket and the thread back to the incident
+	if h.pagerDuty != nil {
+		note := "Jira: " + record.IssueKey
+		if url := h.issueURL(record.IssueKey); url != "" {
+			note += " " + url
+		}
+		if permalink, err := h.slackClient(route.Channel).GetPermalink(&slack.PermalinkParameters{Channel: route.Channel, Ts: threadTS}); err == nil {
+			note += "\nSlack: " + permalink
+		}
+		if err := h.pagerDuty.AddNote(ctx, inc.ID, note); err != nil {
+			logger.GetLogger().Warn("failed to link incident", zap.String("incident", inc.ID), zap.Error(err))
+		}
+	}
+
+	logger.GetLogger().Info("opened incident ticket",
+		zap.String("incident", inc.ID),
+		zap.String("issue", record.IssueKey),
+		zap.String("channel", route.Channel))
+	return nil
+}
+
+// acknowledgeIncident tells the incident's thread who is on it
+func (h *SlackHandler) acknowledgeIncident(ctx context.Context, event pagerduty.Event) error {
+	record, err := h.incidents.Load(ctx, event.Data.ID)
+	if err != nil || record == nil {
+		return err
+	}
+	_, err = h.sendMarkdownMessage(record.ChannelID, "👀 Acknowledged by "+incidentResponder(event), record.ThreadTS)
+	return err
+}
+
+// resolveIncident files the action items discussed in the incident's thread in Jira
+func (h *SlackHandler) resolveIncident(ctx context.Context, event pagerduty.Event) error {
+	inc := event.Data
+	record, err := h.incidents.Load(ctx, inc.ID)
+	if err != nil || record == nil {
+		return err
+	}
+	if record.Status == "resolved" {
+		return nil
+	}
+	record.Status = "resolved"
+	if err := h.incidents.Save(ctx, record); err != nil {
+		return err
+	}
+
+	_, _ = h.sendMarkdownMessage(record.ChannelID, "✅ Resolved by "+incidentResponder(event), record.ThreadTS)
+	if record.IssueKey == "" {
+		return nil
+	}
+
+	history, err := h.getThreadHistory(record.ChannelID, record.ThreadTS)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(incidentPostmortemPrompt, inc.Number, inc.Title, record.IssueKey, record.IssueKey, record.Project, record.IssueKey)
+	result, err := h.Query(ctx, query, history, record.ChannelID, record.ThreadTS, h.incidentUserID)
+	if err != nil {
+		return fmt.Errorf("failed to file postmortem action items: %v", err)
+	}
+	_, _ = h.sendMarkdownMessage(record.ChannelID, result.Answer, record.ThreadTS)
+	return nil
+}
+
+// incidentNotice describes a new incident for the owning channel
+func incidentNotice(inc pagerduty.Incident) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🚨 *<%s|Incident #%d>: %s*\n", inc.HTMLURL, inc.Number, inc.Title)
+	fmt.Fprintf(&b, "*Service:* %s  *Urgency:* %s", inc.Service.Summary, inc.Urgency)
+	if inc.Priority != nil {
+		fmt.Fprintf(&b, "  *Priority:* %s", inc.Priority.Summary)
+	}
+	b.WriteString("\nReply in this thread with follow-ups and action items, mention me to update the ticket.")
+	return b.String()
+}
+
+// incidentResponder names who changed the incident
+func incidentResponder(event pagerduty.Event) string {
+	if event.Agent != nil && event.Agent.Summary != "" {
+		return event.Agent.Summary
+	}
+	return "PagerDuty"
+}
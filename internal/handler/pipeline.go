@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"jira_helper/internal/i18n"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/openai"
+	"jira_helper/internal/tracing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// ConversationInfo describes the conversation a hook runs in
+type ConversationInfo struct {
+	ID        string
+	ChannelID string
+	ThreadTS  string
+	UserID    string
+	Round     int
+	DryRun    bool // Writes are shown to the user instead of being sent to Jira
+}
+
+// ChatRequest is a call to the AI model passing through the chat middleware
+type ChatRequest struct {
+	Conversation ConversationInfo
+	Messages     []azopenai.ChatRequestMessageClassification
+	Tools        []openai.Tool
+
+	progress *progressMessage
+}
+
+// ToolCall is a tool call passing through the tool middleware
+type ToolCall struct {
+	Conversation ConversationInfo
+	Call         openai.ToolCall
+	Write        bool // The tool changes Jira or Confluence
+
+	conv      *conversation
+	client    ToolCaller
+	userToken string
+	progress  *progressMessage
+}
+
+// Completion is the end of a conversation, with its answer or the error it failed with
+type Completion struct {
+	Conversation ConversationInfo
+	Query        string
+	Answer       string
+	Err          error
+
+	conv *conversation
+}
+
+// ChatHandler gets the model's next response
+type ChatHandler func(ctx context.Context, req *ChatRequest) (*openai.ChatResponse, error)
+
+// ToolHandler runs a tool call
+type ToolHandler func(ctx context.Context, call *ToolCall) (*mcp.CallToolResult, error)
+
+// ChatMiddleware wraps the calls to the AI model, running code before and after next
+type ChatMiddleware func(next ChatHandler) ChatHandler
+
+// ToolMiddleware wraps the tool calls, running code before and after next. Returning a *Refusal
+// without calling next refuses the call.
+type ToolMiddleware func(next ToolHandler) ToolHandler
+
+// Middleware is a cross-cutting feature of the conversation loop. Every hook is optional.
+type Middleware struct {
+	Name string
+
+	// Admit decides whether a tool call may run at all, before it is approved, scoped to the
+	// channel's projects or recorded in the conversation. An error refuses the call.
+	Admit func(ctx context.Context, call *ToolCall) error
+
+	Chat     ChatMiddleware
+	Tool     ToolMiddleware
+	Complete func(ctx context.Context, completion *Completion)
+}
+
+// Refusal refuses a tool call. The notice is shown in the thread, the error is the answer the
+// model gets instead of a result.
+type Refusal struct {
+	Notice string
+	Err    error
+}
+
+// Error returns the reason the model is told
+func (r *Refusal) Error() string {
+	return r.Err.Error()
+}
+
+// Unwrap returns the reason of the refusal
+func (r *Refusal) Unwrap() error {
+	return r.Err
+}
+
+// builtInMiddleware returns the handler's own features, in the order they wrap the calls. Custom
+// middleware runs after them, closest to the call itself.
+func (h *SlackHandler) builtInMiddleware() []Middleware {
+	return []Middleware{
+		{Name: "access", Admit: h.admitByRole},
+		{Name: "policy", Admit: h.admitByPolicy},
+		{Name: "guardrail", Admit: h.admitByGuardrail},
+		{Name: "boundary", Tool: h.boundaryMiddleware},
+		{Name: "write_burst", Tool: h.writeBurstMiddleware},
+		{Name: "tool_breaker", Tool: h.toolBreakerMiddleware},
+		{Name: "progress", Tool: h.progressMiddleware},
+		{Name: "dry_run", Tool: h.dryRunMiddleware},
+		{Name: "metrics", Chat: h.chatMetricsMiddleware, Tool: h.toolMetricsMiddleware},
+		{Name: "audit", Tool: h.auditMiddleware},
+		{Name: "memory", Complete: h.rememberCompletion},
+	}
+}
+
+// middleware returns the built-in and custom middleware
+func (h *SlackHandler) middleware() []Middleware {
+	return append(h.builtInMiddleware(), h.customMiddleware...)
+}
+
+// buildChains composes the chat and tool middleware around the calls to the model and the tools.
+// The tool cache is the innermost tool middleware, so every other middleware sees cache hits too.
+func (h *SlackHandler) buildChains() {
+	chat := ChatHandler(h.chatWithTools)
+	tool := h.toolCacheMiddleware(h.callTool)
+	middleware := h.middleware()
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if middleware[i].Chat != nil {
+			chat = middleware[i].Chat(chat)
+		}
+		if middleware[i].Tool != nil {
+			tool = middleware[i].Tool(tool)
+		}
+	}
+	h.chatChain, h.toolChain = chat, tool
+}
+
+// info describes the conversation to hooks
+func (conv *conversation) info() ConversationInfo {
+	return ConversationInfo{ID: conv.ID, ChannelID: conv.ChannelID, ThreadTS: conv.ThreadTS, UserID: conv.UserID, Round: conv.Round, DryRun: conv.DryRun}
+}
+
+// chat gets the model's next response through the chat middleware
+func (h *SlackHandler) chat(ctx context.Context, conv *conversation, tools []openai.Tool, progress *progressMessage) (*openai.ChatResponse, error) {
+	return h.chatChain(ctx, &ChatRequest{
+		Conversation: conv.info(),
+		Messages:     h.withSystemPrompt(ctx, conv),
+		Tools:        tools,
+		progress:     progress,
+	})
+}
+
+// admitToolCall runs the Admit hooks until one refuses the call
+func (h *SlackHandler) admitToolCall(ctx context.Context, call *ToolCall) error {
+	for _, m := range h.middleware() {
+		if m.Admit == nil {
+			continue
+		}
+		if err := m.Admit(ctx, call); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// completeConversation runs the Complete hooks once the conversation has ended
+func (h *SlackHandler) completeConversation(ctx context.Context, conv *conversation, answer string, err error) {
+	completion := &Completion{Conversation: conv.info(), Query: conv.Query, Answer: answer, Err: err, conv: conv}
+	for _, m := range h.middleware() {
+		if m.Complete != nil {
+			m.Complete(ctx, completion)
+		}
+	}
+}
+
+// refusalNotice returns what the thread is told about a refused tool call
+func refusalNotice(err error) string {
+	var refusal *Refusal
+	if errors.As(err, &refusal) {
+		return refusal.Notice
+	}
+	return fmt.Sprintf("🚫 %s", err.Error())
+}
+
+// admitByPolicy refuses tools the policy denies to the user or channel
+func (h *SlackHandler) admitByPolicy(_ context.Context, call *ToolCall) error {
+	info := call.Conversation
+	if err := h.toolPolicy.Check(info.ChannelID, info.UserID, call.Call.Name, call.Call.Args); err != nil {
+		logger.GetLogger().Info("tool call denied by policy",
+			zap.String("tool", call.Call.Name),
+			zap.String("channel_id", info.ChannelID),
+			zap.String("user_id", info.UserID))
+		return &Refusal{Notice: fmt.Sprintf("🚫 %s", err.Error()), Err: err}
+	}
+	return nil
+}
+
+// admitByGuardrail refuses tool calls that reach for other users' tokens or internal endpoints
+func (h *SlackHandler) admitByGuardrail(_ context.Context, call *ToolCall) error {
+	if err := h.guardToolCall(call.conv, call.Call); err != nil {
+		return &Refusal{Notice: fmt.Sprintf("🛡️ %s", err.Error()), Err: err}
+	}
+	return nil
+}
+
+// writeBurstMiddleware refuses writes while the user or project is paused after a write burst
+func (h *SlackHandler) writeBurstMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, call *ToolCall) (*mcp.CallToolResult, error) {
+		if call.Write {
+			if err := h.checkWriteBurst(ctx, call.Conversation.UserID, call.Call); err != nil {
+				return nil, &Refusal{Notice: fmt.Sprintf("⏸️ %s", err.Error()), Err: err}
+			}
+		}
+		return next(ctx, call)
+	}
+}
+
+// progressMiddleware shows the tool being called in the progress message, masking any
+// credentials in the arguments
+func (h *SlackHandler) progressMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, call *ToolCall) (*mcp.CallToolResult, error) {
+		if call.progress != nil {
+			message := i18n.T(call.conv.Language, i18n.CallingTool, call.Call.Name)
+			if len(call.Call.Args) > 0 {
+				message += fmt.Sprintf("\n>_%s_", printJSON(sanitizeArgs(call.Call.Args)))
+			}
+			call.progress.Append(message)
+		}
+		return next(ctx, call)
+	}
+}
+
+// chatMetricsMiddleware counts the tokens of each response against the user's quota and the
+// conversation's usage
+func (h *SlackHandler) chatMetricsMiddleware(next ChatHandler) ChatHandler {
+	return func(ctx context.Context, req *ChatRequest) (*openai.ChatResponse, error) {
+		response, err := next(ctx, req)
+		if err == nil {
+			h.recordTokenUsage(ctx, req.Conversation.UserID, response)
+			usageFrom(ctx).addResponse(response)
+		}
+		return response, err
+	}
+}
+
+// toolMetricsMiddleware traces each tool call and counts it in the conversation's usage
+func (h *SlackHandler) toolMetricsMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, call *ToolCall) (result *mcp.CallToolResult, err error) {
+		ctx, span := tracing.Start(ctx, "mcp.call_tool",
+			attribute.String("mcp.tool", call.Call.Name),
+			attribute.String("conversation.id", call.Conversation.ID))
+		started := time.Now()
+		defer func() {
+			if result != nil && result.IsError {
+				span.SetStatus(codes.Error, "tool returned an error")
+			}
+			tracing.End(span, err)
+			recordToolCall(ctx, call.Call, result, err, time.Since(started))
+			usageFrom(ctx).addToolCall(err != nil || (result != nil && result.IsError))
+		}()
+		return next(ctx, call)
+	}
+}
+
+// auditMiddleware records every write in the audit trail and watches for write bursts
+func (h *SlackHandler) auditMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, call *ToolCall) (*mcp.CallToolResult, error) {
+		result, err := next(ctx, call)
+		if call.Write {
+			h.recordAudit(ctx, call.Conversation.UserID, call.Conversation.ChannelID, call.Call, result, err)
+			h.observeWrite(ctx, call.Conversation.UserID, call.Conversation.ChannelID, call.Call)
+		}
+		return result, err
+	}
+}
+
+// rememberCompletion links the thread to the issues it discussed and keeps the conversation's
+// memory, unless it failed or was stopped
+func (h *SlackHandler) rememberCompletion(ctx context.Context, completion *Completion) {
+	conv := completion.conv
+	if completion.Err != nil || conv.Stopped {
+		return
+	}
+	h.linkThread(ctx, conv.ChannelID, conv.ThreadTS, conv.Query+"\n"+completion.Answer)
+	h.rememberConversation(ctx, conv, completion.Answer)
+}
+
+// callTool runs the tool call, with the handler's local tools or the MCP server
+func (h *SlackHandler) callTool(ctx context.Context, call *ToolCall) (*mcp.CallToolResult, error) {
+	if tool, ok := h.localTool(call.Call.Name); ok {
+		return tool.call(ctx, call.conv, call.Call, call.userToken)
+	}
+	return h.executeToolWithClient(ctx, call.Call, call.client)
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"jira_helper/internal/i18n"
+	"jira_helper/internal/jql"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultsInstruction appends the user's defaults to the system prompt
+	defaultsInstruction = "\n\nThe user's defaults, assume them whenever the request leaves them open: %s."
+
+	// prefsClear resets a preference
+	prefsClear = "clear"
+)
+
+// projectKeyPattern matches a Jira project key
+var projectKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]+$`)
+
+// preferenceSettings are the preferences `/jira prefs` sets, in the order they are listed
+var preferenceSettings = []string{"project", "board", "timezone", "language", "notifications"}
+
+// userPreferences returns the preferences of the user, empty if they have not set any or they
+// cannot be read
+func (h *SlackHandler) userPreferences(ctx context.Context, userID string) storage.Preferences {
+	if h.preferences == nil || userID == "" {
+		return storage.Preferences{}
+	}
+	preferences, err := h.preferences.GetPreferences(ctx, userID)
+	if err != nil {
+		logger.GetLogger().Warn("failed to get preferences", zap.String("user_id", userID), zap.Error(err))
+		return storage.Preferences{}
+	}
+	if preferences == nil {
+		return storage.Preferences{}
+	}
+	return *preferences
+}
+
+// applyPreferences sets the user's defaults on a new conversation. Without a time zone of their
+// own, the one of their Slack profile is used.
+func (h *SlackHandler) applyPreferences(ctx context.Context, conv *conversation, preferences storage.Preferences) {
+	conv.Style = h.responseStyle(preferences)
+	conv.DefaultProject = preferences.DefaultProject
+	conv.DefaultBoard = preferences.DefaultBoard
+	conv.Timezone = preferences.Timezone
+	if conv.Timezone == "" {
+		conv.Timezone = h.profileTimezone(ctx, conv.ChannelID, conv.UserID)
+	}
+}
+
+// preferredLanguage returns the language the user chose to be answered in, or the detected one
+func preferredLanguage(preferences storage.Preferences, detected i18n.Lang) i18n.Lang {
+	if lang, ok := i18n.Parse(preferences.Language); ok {
+		return lang
+	}
+	return detected
+}
+
+// defaultsInstructionOf returns the system prompt addition of the conversation's defaults, empty
+// without any
+func defaultsInstructionOf(conv *conversation) string {
+	var defaults []string
+	if conv.DefaultProject != "" {
+		defaults = append(defaults, fmt.Sprintf("project %s", conv.DefaultProject))
+	}
+	if conv.DefaultBoard > 0 {
+		defaults = append(defaults, fmt.Sprintf("agile board %d", conv.DefaultBoard))
+	}
+	if conv.Timezone != "" {
+		defaults = append(defaults, fmt.Sprintf("time zone %s, where it is now %s", conv.Timezone, datesOf(conv).Time(time.Now())))
+	}
+	if len(defaults) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(defaultsInstruction, strings.Join(defaults, "; "))
+}
+
+// jiraPrefsCommand shows the user's preferences, or sets one, e.g. `/jira prefs project PROJ` or
+// `/jira prefs timezone clear`
+func (h *SlackHandler) jiraPrefsCommand(ctx context.Context, _ *jira.Client, req jiraCommandRequest) (string, error) {
+	fields := strings.Fields(req.Args)
+	if len(fields) == 0 {
+		return h.describePreferences(h.userPreferences(ctx, req.UserID)), nil
+	}
+	if len(fields) != 2 {
+		return "", fmt.Errorf("usage: `/jira prefs [%s] <value | %s>`", strings.Join(preferenceSettings, " | "), prefsClear)
+	}
+
+	preferences, err := h.preferences.GetPreferences(ctx, req.UserID)
+	if err != nil {
+		return "", err
+	}
+	if preferences == nil {
+		preferences = &storage.Preferences{SlackUserID: req.UserID}
+	}
+	setting, value := strings.ToLower(fields[0]), fields[1]
+	if err := setPreference(preferences, setting, value); err != nil {
+		return "", err
+	}
+	if setting == "project" && preferences.DefaultProject != "" {
+		if err := h.checkCommandScope(req.ChannelID, preferences.DefaultProject); err != nil {
+			return "", err
+		}
+	}
+	preferences.UpdatedAt = time.Now().UTC()
+	if err := h.preferences.SavePreferences(ctx, *preferences); err != nil {
+		return "", err
+	}
+	logger.GetLogger().Info("set preference", zap.String("user_id", req.UserID), zap.String("setting", setting))
+	return "⚙️ Saved.\n" + h.describePreferences(*preferences), nil
+}
+
+// setPreference validates the value and sets the preference, clearing it with prefsClear
+func setPreference(preferences *storage.Preferences, setting, value string) error {
+	clear := strings.EqualFold(value, prefsClear)
+	switch setting {
+	case "project":
+		project := strings.ToUpper(value)
+		if clear {
+			project = ""
+		} else if err := jql.ValidateFragment(project); err != nil {
+			return fmt.Errorf("%s is not a project key: %v", value, err)
+		} else if !projectKeyPattern.MatchString(project) {
+			return fmt.Errorf("%s is not a project key", value)
+		}
+		preferences.DefaultProject = project
+	case "board":
+		board := 0
+		if !clear {
+			id, err := strconv.Atoi(value)
+			if err != nil || id < 1 {
+				return fmt.Errorf("%s is not a board ID", value)
+			}
+			board = id
+		}
+		preferences.DefaultBoard = board
+	case "timezone":
+		timezone := ""
+		if !clear {
+			location, err := time.LoadLocation(value)
+			if err != nil || value == "Local" {
+				return fmt.Errorf("unknown time zone %q, use a name such as Europe/Berlin or Asia/Shanghai", value)
+			}
+			timezone = location.String()
+		}
+		preferences.Timezone = timezone
+	case "language":
+		language := ""
+		if !clear {
+			lang, ok := i18n.Parse(value)
+			if !ok {
+				return fmt.Errorf("unsupported language %q, use %s or %s", value, i18n.English, i18n.Chinese)
+			}
+			language = string(lang)
+		}
+		preferences.Language = language
+	case "notifications":
+		switch strings.ToLower(value) {
+		case "on", prefsClear:
+			preferences.MuteNotifications = false
+		case "off":
+			preferences.MuteNotifications = true
+		default:
+			return fmt.Errorf("notifications are `on` or `off`")
+		}
+	default:
+		return fmt.Errorf("unknown preference %q, use one of %s", setting, strings.Join(preferenceSettings, ", "))
+	}
+	return nil
+}
+
+// describePreferences lists the user's preferences and how to change them
+func (h *SlackHandler) describePreferences(preferences storage.Preferences) string {
+	orDefault := func(value, fallback string) string {
+		if value == "" {
+			return fallback
+		}
+		return "`" + value + "`"
+	}
+	board := ""
+	if preferences.DefaultBoard > 0 {
+		board = strconv.Itoa(preferences.DefaultBoard)
+	}
+	notifications := "on"
+	if preferences.MuteNotifications {
+		notifications = "off"
+	}
+	lines := []string{
+		"Your preferences:",
+		fmt.Sprintf("• project – %s", orDefault(preferences.DefaultProject, "_none_")),
+		fmt.Sprintf("• board – %s", orDefault(board, "_none_")),
+		fmt.Sprintf("• timezone – %s", orDefault(preferences.Timezone, "_UTC_")),
+		fmt.Sprintf("• language – %s", orDefault(preferences.Language, "_the language you write in_")),
+		fmt.Sprintf("• notifications – `%s`, Jira notifications in your direct messages", notifications),
+		fmt.Sprintf("• style – %s, change it with `/jira set-style`", orDefault(h.responseStyle(preferences), "_default_")),
+		fmt.Sprintf("Change one with `/jira prefs <setting> <value>`, or reset it with `/jira prefs <setting> %s`.", prefsClear),
+	}
+	return strings.Join(lines, "\n")
+}
+
+// notificationsMuted reports whether the subscription notifies the direct messages of a user who
+// paused their notifications
+func (h *SlackHandler) notificationsMuted(ctx context.Context, subscription storage.Subscription) bool {
+	if !strings.HasPrefix(subscription.Target, "D") {
+		return false
+	}
+	return h.userPreferences(ctx, subscription.CreatedBy).MuteNotifications
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/policy"
+
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// progressFlushInterval is the minimum time between two updates of a progress message
+	progressFlushInterval = time.Second
+
+	// progressLineSeparator separates the lines of a progress message
+	progressLineSeparator = "\n\n"
+
+	// draftCursor marks the end of an answer that is still being generated
+	draftCursor = " ▍"
+
+	// Slack allows about 50 chat.update calls per minute in a workspace, the progress messages of
+	// all conversations share them
+	progressEditsPerMinute = 50
+	progressEditBurst      = 10
+)
+
+// progressMessage batches the progress lines of a conversation into one Slack message.
+// Updates are debounced so Slack sees at most one update per progressFlushInterval, and the
+// updates of a workspace are rate limited together, holding lines back while it is exhausted.
+// A new message is started once the current one would exceed Slack's size limit, or when Slack
+// throttles the edits anyway.
+// The message timestamp and lines live on the conversation so checkpoints stay accurate.
+// Channels whose message policy keeps progress out of the thread get each line ephemerally,
+// as ephemeral messages cannot be updated, or not at all.
+type progressMessage struct {
+	h          *SlackHandler
+	conv       *conversation
+	visibility string
+	posted     []string // Lines sent ephemerally
+	shown      int      // Lines of the current message Slack shows
+
+	mu        sync.Mutex
+	draft     string // Text the model is still generating, shown after the lines
+	dirty     bool
+	lastFlush time.Time
+	timer     *time.Timer
+}
+
+// newProgressMessage creates a progress updater for the conversation's current progress message
+func (h *SlackHandler) newProgressMessage(conv *conversation) *progressMessage {
+	return &progressMessage{h: h, conv: conv, visibility: h.messageRule(conv.ChannelID).Progress, shown: len(conv.SlackMessageLines)}
+}
+
+// Append adds a line to the progress message, skipping lines the message already shows
+func (p *progressMessage) Append(line string) {
+	if line == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch p.visibility {
+	case policy.VisibilityHidden:
+		return
+	case policy.VisibilityEphemeral:
+		if !slices.Contains(p.posted, line) {
+			p.posted = append(p.posted, line)
+			_ = p.h.sendEphemeralSlackMessage(p.conv.ChannelID, p.conv.UserID, line, p.conv.ThreadTS)
+		}
+		return
+	}
+
+	// The line replaces the draft it was streamed as
+	if p.draft != "" {
+		p.draft = ""
+		p.dirty = true
+	}
+	if slices.Contains(p.conv.SlackMessageLines, line) {
+		p.scheduleLocked()
+		return
+	}
+
+	// Start a new message only when the line no longer fits into the current one
+	if p.conv.Timestamp != "" && p.h.shouldCreateNewMessage(p.conv.SlackMessageLines, line, p.h.limits.For(p.conv.ChannelID).MessageLimit) {
+		p.flushLocked(true)
+		p.startNewMessageLocked(line)
+		return
+	}
+
+	p.conv.SlackMessageLines = append(p.conv.SlackMessageLines, line)
+	p.dirty = true
+	p.scheduleLocked()
+}
+
+// SetDraft shows the text the model has generated so far below the progress lines.
+// An empty draft removes it again. Drafts are only shown in the thread.
+func (p *progressMessage) SetDraft(draft string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if draft == p.draft || p.visibility != policy.VisibilityThread {
+		return
+	}
+	p.draft = draft
+	p.dirty = true
+	p.scheduleLocked()
+}
+
+// Close flushes any pending lines, even when the rate limit is exhausted, and stops the debounce timer
+func (p *progressMessage) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	p.flushLocked(true)
+}
+
+// scheduleLocked flushes now if the last update is old enough, otherwise once the interval has passed
+func (p *progressMessage) scheduleLocked() {
+	wait := progressFlushInterval - time.Since(p.lastFlush)
+	if wait <= 0 {
+		p.flushLocked(false)
+		return
+	}
+	p.retryLocked(wait)
+}
+
+// retryLocked flushes once wait has passed, unless a flush is already scheduled
+func (p *progressMessage) retryLocked(wait time.Duration) {
+	if p.timer != nil {
+		return
+	}
+	p.timer = time.AfterFunc(wait, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.timer = nil
+		p.flushLocked(false)
+	})
+}
+
+// flushLocked sends the pending lines to Slack. Unless forced, an update waits for the
+// workspace's rate limit and the lines stay pending until then.
+func (p *progressMessage) flushLocked(force bool) {
+	if !p.dirty {
+		return
+	}
+	editKey := p.h.workspaces.teamOf(p.conv.ChannelID)
+	if p.conv.Timestamp != "" && !force {
+		if ok, wait := p.h.editLimiter.Reserve(editKey); !ok {
+			p.retryLocked(wait)
+			return
+		}
+	}
+	p.dirty = false
+	p.lastFlush = time.Now()
+
+	message := strings.Join(p.conv.SlackMessageLines, progressLineSeparator)
+	if p.draft != "" {
+		message += progressLineSeparator + truncateDraft(p.draft, p.h.limits.For(p.conv.ChannelID).MessageLimit-len(message)-len(progressLineSeparator)-len(draftCursor)) + draftCursor
+	}
+	if p.conv.Timestamp == "" {
+		// The progress message was never posted, so post it now
+		p.conv.Timestamp, _ = p.h.sendMarkdownMessage(p.conv.ChannelID, message, p.conv.ThreadTS)
+		p.shown = len(p.conv.SlackMessageLines)
+		return
+	}
+	err := p.h.updateMessage(p.conv.ChannelID, p.conv.Timestamp, message)
+	var rateLimited *slack.RateLimitedError
+	switch {
+	case err == nil:
+		p.shown = len(p.conv.SlackMessageLines)
+	case errors.As(err, &rateLimited):
+		// Edits are throttled, so hold them back and continue with the lines the message is
+		// missing in a new one
+		p.h.editLimiter.Penalize(editKey, rateLimited.RetryAfter)
+		if pending := p.conv.SlackMessageLines[min(p.shown, len(p.conv.SlackMessageLines)):]; len(pending) > 0 {
+			p.startNewMessageLocked(pending...)
+		}
+	case strings.Contains(err.Error(), "msg_too_long"):
+		// Slack counts some characters differently, so move the last line to a new message
+		last := p.conv.SlackMessageLines[len(p.conv.SlackMessageLines)-1]
+		p.conv.SlackMessageLines = p.conv.SlackMessageLines[:len(p.conv.SlackMessageLines)-1]
+		p.startNewMessageLocked(last)
+	}
+}
+
+// startNewMessageLocked posts a new progress message containing the lines
+func (p *progressMessage) startNewMessageLocked(lines ...string) {
+	timestamp, err := p.h.createNewMessage(p.conv.ChannelID, p.conv.ThreadTS, strings.Join(lines, progressLineSeparator))
+	if err != nil {
+		logger.GetLogger().Warn("failed to start new progress message", zap.String("channel", p.conv.ChannelID), zap.Error(err))
+	}
+	p.conv.Timestamp = timestamp
+	p.conv.SlackMessageLines = lines
+	p.shown = len(lines)
+	p.lastFlush = time.Now()
+}
+
+// truncateDraft keeps the end of the draft, which is the part still being written, within limit bytes
+func truncateDraft(draft string, limit int) string {
+	if len(draft) <= limit {
+		return draft
+	}
+	if limit <= len("…") {
+		return ""
+	}
+	cut := len(draft) - limit + len("…")
+	for cut < len(draft) && !utf8.RuneStart(draft[cut]) {
+		cut++
+	}
+	return "…" + draft[cut:]
+}
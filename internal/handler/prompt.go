@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"jira_helper/internal/i18n"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/prompts"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// systemMessage stands for the system prompt in the conversation's messages. It is shared by all
+// conversations and never modified, each round replaces it with the prompt of the conversation's
+// channel and language, so edited templates apply to running threads as well.
+var systemMessage = &azopenai.ChatRequestSystemMessage{
+	Content: azopenai.NewChatRequestSystemMessageContent(prompts.BuiltIn()),
+}
+
+// languageInstruction tells the model which language the user writes in
+const languageInstruction = `
+
+The user writes in %s. Reply in that language, but keep Jira issue keys, field names, statuses and values as they are in Jira.`
+
+// detectLanguage returns the language of the query, or of the latest message in the thread whose
+// language is clear when the query is too short to tell, e.g. only an issue key
+func detectLanguage(query string, history []HistoryMessage) i18n.Lang {
+	if lang, ok := i18n.Detect(query); ok {
+		return lang
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Role != "user" {
+			continue
+		}
+		if lang, ok := i18n.Detect(history[i].Content); ok {
+			return lang
+		}
+	}
+	return i18n.English
+}
+
+// withSystemPrompt returns the conversation's messages with its rendered system prompt
+func (h *SlackHandler) withSystemPrompt(ctx context.Context, conv *conversation) []azopenai.ChatRequestMessageClassification {
+	messages := conv.Messages
+	if len(messages) == 0 || messages[0] != systemMessage {
+		return messages
+	}
+	prompt := h.systemPrompt(ctx, conv.ChannelID)
+	if conv.Language != "" && conv.Language != i18n.English {
+		prompt += fmt.Sprintf(languageInstruction, conv.Language.Name())
+	}
+	if conv.JiraUser != "" {
+		prompt += fmt.Sprintf(identityInstruction, conv.JiraName, conv.JiraUser, conv.JiraUser)
+	}
+	prompt += defaultsInstructionOf(conv)
+	if conv.DryRun {
+		prompt += dryRunInstruction
+	}
+	if conv.JiraInstance != "" {
+		prompt += fmt.Sprintf(instanceInstruction, conv.JiraInstance, h.jiraURLOf(conv.JiraInstance))
+	}
+	prompt += h.styleInstructionOf(conv.Style)
+	system := &azopenai.ChatRequestSystemMessage{Content: azopenai.NewChatRequestSystemMessageContent(prompt)}
+	return append([]azopenai.ChatRequestMessageClassification{system}, messages[1:]...)
+}
+
+// systemPrompt renders the system prompt template of the channel
+func (h *SlackHandler) systemPrompt(ctx context.Context, channelID string) string {
+	prompt, err := h.prompts.Render(ctx, channelID, prompts.Vars{
+		JiraURL:   h.jiraURL,
+		TeamName:  h.teamName,
+		ChannelID: channelID,
+		Projects:  h.channelProjects[channelID],
+		Date:      time.Now().Format("2006-01-02 Monday"),
+	})
+	if err != nil {
+		logger.GetLogger().Error("failed to render system prompt", zap.String("channel", channelID), zap.Error(err))
+		if prompt == "" {
+			prompt = prompts.BuiltIn()
+		}
+	}
+	return prompt
+}
+
+// adminReloadPrompts loads the prompt templates from the bucket now, instead of within a minute
+func (h *SlackHandler) adminReloadPrompts(c *gin.Context, _ []string) (string, error) {
+	names, problems, err := h.prompts.Reload(c.Request.Context())
+	if err != nil {
+		return "", err
+	}
+	lines := []string{"🔄 Prompt templates reloaded"}
+	if len(names) == 0 {
+		lines = append(lines, "Using the built-in prompt in every channel")
+	} else {
+		lines = append(lines, fmt.Sprintf("In use: %s", strings.Join(names, ", ")))
+	}
+	for _, problem := range problems {
+		lines = append(lines, fmt.Sprintf("⚠️ Skipped %s", problem))
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"jira_helper/internal/service/openai"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// issueKeyPattern matches Jira issue keys mentioned in answers and tool results
+var issueKeyPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-\d+\b`)
+
+// ToolCallTrace records a single tool call made while answering a query
+type ToolCallTrace struct {
+	Tool       string                 `json:"tool"`
+	Arguments  map[string]interface{} `json:"arguments,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	DurationMs int64                  `json:"duration_ms"`
+
+	result string // Tool output, used to attribute citations
+}
+
+// Citation is a Jira issue the answer refers to
+type Citation struct {
+	IssueKey string `json:"issue_key"`
+	URL      string `json:"url,omitempty"`
+	Tool     string `json:"tool,omitempty"` // Tool whose result contained the issue
+}
+
+// QueryResult is the answer to a query together with how it was produced
+type QueryResult struct {
+	Answer    string          `json:"answer"`
+	ToolTrace []ToolCallTrace `json:"tool_trace"`
+	Citations []Citation      `json:"citations"`
+}
+
+// toolTrace collects the tool calls of one query
+type toolTrace struct {
+	mu    sync.Mutex
+	calls []ToolCallTrace
+}
+
+type toolTraceKey struct{}
+
+// toolTraceFrom returns the trace collecting the query's tool calls, if the caller asked for one
+func toolTraceFrom(ctx context.Context) *toolTrace {
+	trace, _ := ctx.Value(toolTraceKey{}).(*toolTrace)
+	return trace
+}
+
+// recordToolCall adds an executed tool call to the query's trace
+func recordToolCall(ctx context.Context, toolCall openai.ToolCall, result *mcp.CallToolResult, err error, duration time.Duration) {
+	trace := toolTraceFrom(ctx)
+	if trace == nil {
+		return
+	}
+	call := ToolCallTrace{
+		Tool:       toolCall.Name,
+		Arguments:  sanitizeArgs(toolCall.Args),
+		DurationMs: duration.Milliseconds(),
+	}
+	if err != nil {
+		call.Error = sanitizeText(err.Error())
+	} else if result != nil {
+		call.result = printToolResult(result)
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.calls = append(trace.calls, call)
+}
+
+// Query runs a query through the conversation engine and returns the answer with its tool trace
+// and citations. Progress messages are posted to channelID, so callers outside Slack register a
+// Messenger for it. The caller waits for the answer, so the query always runs inline.
+func (h *SlackHandler) Query(ctx context.Context, query string, history []HistoryMessage, channelID, threadID, userID string) (*QueryResult, error) {
+	trace := &toolTrace{}
+	ctx = context.WithValue(ctx, toolTraceKey{}, trace)
+	if stripped, instance, ok := h.cutInstanceSelector(query); ok {
+		query, ctx = stripped, withJiraInstance(ctx, instance)
+	}
+
+	answer, _, err := h.processQuery(ctx, query, history, channelID, threadID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	return &QueryResult{
+		Answer:    answer,
+		ToolTrace: trace.calls,
+		Citations: h.citations(answer, trace.calls, h.jiraURLOf(h.jiraInstanceOf(ctx, channelID))),
+	}, nil
+}
+
+// citations lists the issues mentioned in the answer, attributed to the first tool that returned
+// them and linked to the Jira instance at jiraURL
+func (h *SlackHandler) citations(answer string, calls []ToolCallTrace, jiraURL string) []Citation {
+	citations := []Citation{}
+	for _, key := range issueKeysIn(answer) {
+		citation := Citation{IssueKey: key}
+		if jiraURL != "" {
+			citation.URL = jiraURL + "/browse/" + key
+		}
+		for _, call := range calls {
+			if strings.Contains(call.result, key) {
+				citation.Tool = call.Tool
+				break
+			}
+		}
+		citations = append(citations, citation)
+	}
+	return citations
+}
+
+// issueKeysIn returns the distinct issue keys in the text, in order of appearance
+func issueKeysIn(text string) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, key := range issueKeyPattern.FindAllString(text, -1) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// issueURL links to the issue in Jira, or returns "" when no Jira URL is configured
+func (h *SlackHandler) issueURL(key string) string {
+	if h.jiraURL == "" {
+		return ""
+	}
+	return h.jiraURL + "/browse/" + key
+}
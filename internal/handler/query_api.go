@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"jira_helper/internal/auth"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/quota"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// apiChannelPrefix marks the channels of programmatic queries, whose progress is discarded
+	apiChannelPrefix = "api/"
+
+	// apiQueryTimeout bounds a programmatic query like the Slack handlers do
+	apiQueryTimeout = 5 * time.Minute
+)
+
+// QueryRequest is the body of a programmatic query
+type QueryRequest struct {
+	Query        string           `json:"query" binding:"required"`
+	UserID       string           `json:"user_id"`       // Optional: Slack user whose personal Jira token is used
+	History      []HistoryMessage `json:"history"`       // Optional: earlier turns of the conversation
+	DryRun       bool             `json:"dry_run"`       // Optional: show the writes in the answer instead of sending them to Jira
+	JiraInstance string           `json:"jira_instance"` // Optional: named Jira instance to work with, like "on <name>:" in the query
+}
+
+// HandleQuery answers a natural-language query for other services and scripts. It responds
+// with the answer, the tool calls made and the issues the answer cites.
+func (h *SlackHandler) HandleQuery(c *gin.Context) {
+	var request QueryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format, must be JSON with 'query' field"})
+		return
+	}
+	if _, ok := h.jiraInstances[request.JiraInstance]; request.JiraInstance != "" && request.JiraInstance != primaryInstance && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown Jira instance %q", request.JiraInstance)})
+		return
+	}
+	for _, message := range request.History {
+		if message.Role != "user" && message.Role != "assistant" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid history role %q", message.Role)})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), apiQueryTimeout)
+	defer cancel()
+	if request.DryRun {
+		ctx = withDryRun(ctx)
+	}
+	if request.JiraInstance == primaryInstance {
+		ctx = withJiraInstance(ctx, "")
+	} else if request.JiraInstance != "" {
+		ctx = withJiraInstance(ctx, request.JiraInstance)
+	}
+
+	channelID := apiChannelPrefix + auth.KeyID(c)
+	threadID := fmt.Sprintf("%d", time.Now().UnixNano())
+	result, err := h.Query(ctx, strings.TrimSpace(request.Query), request.History, channelID, threadID, request.UserID)
+	var exceeded *quota.ExceededError
+	if errors.As(err, &exceeded) {
+		c.Header("Retry-After", strconv.Itoa(int(time.Until(exceeded.ResetAt).Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.GetLogger().Error("failed to answer query", zap.String("key_id", auth.KeyID(c)), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"jira_helper/internal/i18n"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/quota"
+	"jira_helper/internal/service/openai"
+
+	"go.uber.org/zap"
+)
+
+// checkQuota counts a request by the user and returns a *quota.ExceededError once a quota is used up.
+// Admins are exempt, and requests are let through when the usage store is unavailable.
+func (h *SlackHandler) checkQuota(ctx context.Context, userID string) error {
+	if h.quota == nil || h.isAdmin(userID) {
+		return nil
+	}
+	err := h.quota.Allow(ctx, userID)
+	var exceeded *quota.ExceededError
+	if err != nil && !errors.As(err, &exceeded) {
+		logger.GetLogger().Warn("failed to check quota, allowing the request", zap.String("user_id", userID), zap.Error(err))
+		return nil
+	}
+	if exceeded != nil {
+		logger.GetLogger().Info("quota exceeded", zap.String("user_id", userID), zap.String("quota", exceeded.Quota))
+	}
+	return err
+}
+
+// recordTokenUsage counts the AI tokens of a response towards the user's daily budget
+func (h *SlackHandler) recordTokenUsage(ctx context.Context, userID string, response *openai.ChatResponse) {
+	if h.quota == nil || h.isAdmin(userID) {
+		return
+	}
+	if err := h.quota.AddTokens(ctx, userID, response.PromptTokens+response.CompletionTokens); err != nil {
+		logger.GetLogger().Warn("failed to record token usage", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// quotaMessage tells the user which quota they hit and when they can ask again
+func quotaMessage(ctx context.Context, err error) string {
+	var exceeded *quota.ExceededError
+	if !errors.As(err, &exceeded) {
+		return errorMessage(ctx, err)
+	}
+	wait := time.Until(exceeded.ResetAt).Round(time.Minute)
+	if wait < time.Minute {
+		wait = time.Minute
+	}
+	return i18n.T(i18n.FromContext(ctx), i18n.QuotaExceeded, exceeded.Quota, formatWait(wait))
+}
+
+// formatWait renders a wait time such as "2h 5m" or "12m"
+func formatWait(wait time.Duration) string {
+	hours, minutes := int(wait.Hours()), int(wait.Minutes())%60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/releasenotes"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/service/openai"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// releaseNotesTimeout bounds drafting and publishing the notes of one release
+	releaseNotesTimeout = 3 * time.Minute
+
+	// confluenceCreatePageTool is the MCP tool release notes are published with
+	confluenceCreatePageTool = "confluence_create_page"
+)
+
+// jiraReleaseNotesCommand drafts the release notes of a fix version, e.g. `/jira release-notes PROJ 1.4.0`
+// or, to publish them to a Confluence space as well, `/jira release-notes PROJ 1.4.0 confluence:DOCS`.
+// The issues are read right away, the notes are drafted in the background and posted to the channel.
+func (h *SlackHandler) jiraReleaseNotesCommand(ctx context.Context, client *jira.Client, req jiraCommandRequest) (string, error) {
+	args := strings.Fields(req.Args)
+	var space string
+	usage := fmt.Errorf("usage: `/jira release-notes PROJ <version> [confluence:SPACE]`")
+	if len(args) > 0 && strings.HasPrefix(strings.ToLower(args[len(args)-1]), "confluence:") {
+		space = strings.ToUpper(args[len(args)-1][len("confluence:"):])
+		args = args[:len(args)-1]
+		if space == "" {
+			return "", usage
+		}
+	}
+	if len(args) < 2 {
+		return "", usage
+	}
+	project, version := strings.ToUpper(args[0]), strings.Join(args[1:], " ")
+	if err := h.checkCommandScope(req.ChannelID, project); err != nil {
+		return "", err
+	}
+
+	// Publishing writes to Confluence, so it needs the user's own token
+	var token string
+	if space != "" {
+		var err error
+		if token, err = h.getUserPersonalToken(req.UserID); err != nil || token == "" {
+			return "", fmt.Errorf("set your personal token first with `/setup-token` to publish to Confluence")
+		}
+	}
+
+	release, err := releasenotes.Collect(ctx, client, project, version)
+	if err != nil {
+		return "", err
+	}
+	if release.Issues == 0 {
+		return fmt.Sprintf("No resolved issues in %s %s.", project, version), nil
+	}
+
+	convCtx, done, err := h.beginConversation(context.Background(), req.ChannelID, "", req.UserID)
+	if err != nil {
+		return "", err
+	}
+	go func() {
+		defer done()
+		h.postReleaseNotes(convCtx, req, release, space, token)
+	}()
+
+	text := fmt.Sprintf("📝 Drafting release notes for %s %s from %d resolved issues, they will be posted in this channel.", project, version, release.Issues)
+	if space != "" {
+		text += fmt.Sprintf(" They will be published to Confluence space %s too.", space)
+	}
+	return text, nil
+}
+
+// postReleaseNotes drafts the notes, posts them to the channel and publishes them to Confluence
+// if a space is given. Failures are reported in the channel, as the command already returned.
+func (h *SlackHandler) postReleaseNotes(ctx context.Context, req jiraCommandRequest, release *releasenotes.Release, space, token string) {
+	ctx, cancel := context.WithTimeout(ctx, releaseNotesTimeout)
+	defer cancel()
+
+	title := fmt.Sprintf("%s %s release notes", release.Project, release.Version)
+	notes, err := releasenotes.Draft(ctx, h.aiClient, release)
+	if err != nil {
+		logger.GetLogger().Error("failed to draft release notes", zap.String("project", release.Project), zap.String("version", release.Version), zap.Error(err))
+		_, _ = h.sendMarkdownMessage(req.ChannelID, fmt.Sprintf("❌ <@%s> failed to draft the %s: %v", req.UserID, title, err), "")
+		return
+	}
+
+	message := fmt.Sprintf("📦 *%s*, requested by <@%s>\n\n%s", title, req.UserID, releasenotes.Slack(notes, h.issueLink))
+	if release.Total > release.Issues {
+		message += fmt.Sprintf("\n\n_Drafted from the first %d of %d resolved issues._", release.Issues, release.Total)
+	}
+	ts, err := h.sendMarkdownMessage(req.ChannelID, message, "")
+	if err != nil || space == "" {
+		return
+	}
+
+	outcome := fmt.Sprintf("📚 Published to Confluence space %s as \"%s\".", space, title)
+	if err := h.publishReleaseNotes(ctx, req, token, space, title, notes); err != nil {
+		logger.GetLogger().Error("failed to publish release notes", zap.String("space", space), zap.Error(err))
+		outcome = fmt.Sprintf("❌ Failed to publish to Confluence space %s: %v", space, err)
+	}
+	_, _ = h.sendMarkdownMessage(req.ChannelID, outcome, ts)
+}
+
+// publishReleaseNotes creates a Confluence page with the notes through the MCP server, acting with
+// the user's token. Like other writes, it is checked against the tool policy, paused by bursts
+// and audited.
+func (h *SlackHandler) publishReleaseNotes(ctx context.Context, req jiraCommandRequest, token, space, title, notes string) error {
+	toolCall := openai.ToolCall{Name: confluenceCreatePageTool, Args: map[string]interface{}{
+		"space_key": space,
+		"title":     title,
+		"content":   notes,
+	}}
+	if err := h.toolPolicy.Check(req.ChannelID, req.UserID, toolCall.Name, toolCall.Args); err != nil {
+		return err
+	}
+	if err := h.checkWriteBurst(ctx, req.UserID, toolCall); err != nil {
+		return err
+	}
+
+	mcpClient, release, err := h.getMcpClient(token)
+	if err != nil {
+		return fmt.Errorf("failed to start MCP client: %v", err)
+	}
+	defer release()
+
+	result, err := h.executeToolWithClient(ctx, toolCall, mcpClient)
+	h.recordAudit(ctx, req.UserID, req.ChannelID, toolCall, result, err)
+	h.observeWrite(ctx, req.UserID, req.ChannelID, toolCall)
+	if err != nil {
+		return err
+	}
+	if result.IsError {
+		return fmt.Errorf("%s", printToolResult(result))
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/render"
+	"jira_helper/internal/service/openai"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxMessageBlocks is the most blocks Slack accepts in a message
+	maxMessageBlocks = 50
+
+	// maxSectionText is the most text Slack accepts in a section block
+	maxSectionText = 3000
+)
+
+// renderedResult is a structured tool result that the answer is rendered with
+type renderedResult struct {
+	Tool     string                 `json:"tool"`
+	Args     map[string]interface{} `json:"args,omitempty"`
+	Result   string                 `json:"result"`
+	Timezone string                 `json:"timezone,omitempty"` // Time zone of the user, dates are rendered in it
+	JiraURL  string                 `json:"jira_url,omitempty"` // Jira instance issues link to, the primary one when empty
+}
+
+// renderableResult keeps the tool's result when a template renders it, unless it exposes projects
+// restricted from the channel
+func (h *SlackHandler) renderableResult(conv *conversation, toolCall openai.ToolCall, result *mcp.CallToolResult) *renderedResult {
+	if result == nil || result.IsError || !render.Supports(toolCall.Name) {
+		return nil
+	}
+	text := printToolResult(result)
+	if violations := h.boundaries.Violations(conv.ChannelID, text, projectKeyArg(toolCall.Args)); len(violations) > 0 {
+		return nil
+	}
+	rendered := &renderedResult{Tool: toolCall.Name, Args: toolCall.Args, Result: text, Timezone: conv.Timezone}
+	if conv.JiraInstance != "" {
+		rendered.JiraURL = h.jiraURLOf(conv.JiraInstance)
+	}
+	return rendered
+}
+
+// renderedAnswer returns the tool result the answer is rendered with, none when the conversation
+// was stopped before it finished
+func (conv *conversation) renderedAnswer() *renderedResult {
+	if conv.Stopped {
+		return nil
+	}
+	return conv.Rendered
+}
+
+// sendAnswerMessage posts the answer with the structured tool result rendered below it. Free-form
+// answers, and results the template cannot read, are posted as the model formatted them.
+func (h *SlackHandler) sendAnswerMessage(channelID, threadTS, answer string, rendered *renderedResult) (string, error) {
+	if rendered == nil || answer == "" || h.messengerFor(channelID) != nil {
+		return h.sendMarkdownMessage(channelID, answer, threadTS)
+	}
+	renderer := h.renderer
+	if rendered.JiraURL != "" {
+		renderer = render.New(rendered.JiraURL)
+	}
+	templateBlocks, ok := renderer.In(rendered.Timezone).ToolResult(rendered.Tool, rendered.Args, rendered.Result)
+	if !ok {
+		return h.sendMarkdownMessage(channelID, answer, threadTS)
+	}
+
+	var blocks []slack.Block
+	for _, chunk := range splitMessage(answer, maxSectionText) {
+		blocks = append(blocks, markdownSection(chunk))
+	}
+	blocks = append(blocks, slack.NewDividerBlock())
+	blocks = append(blocks, templateBlocks...)
+	if len(blocks) > maxMessageBlocks {
+		return h.sendMarkdownMessage(channelID, answer, threadTS)
+	}
+
+	_, timestamp, err := h.slackClient(channelID).PostMessage(channelID,
+		slack.MsgOptionText(answer, false),
+		slack.MsgOptionBlocks(blocks...),
+		slack.MsgOptionTS(threadTS))
+	if err != nil {
+		// Fall back to the plain answer, e.g. when Slack rejects a block
+		logger.GetLogger().Warn("failed to post rendered answer", zap.String("channel", channelID), zap.String("tool", rendered.Tool), zap.Error(err))
+		return h.sendMarkdownMessage(channelID, answer, threadTS)
+	}
+	return timestamp, nil
+}
+
+// splitMessage splits the text into chunks of at most limit bytes, at line breaks where possible
+func splitMessage(text string, limit int) []string {
+	var chunks []string
+	for len(text) > limit {
+		cut := strings.LastIndex(text[:limit], "\n")
+		if cut <= 0 {
+			// Do not cut a multi-byte character in half
+			cut = limit
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		chunks = append(chunks, text[:cut])
+		text = strings.TrimPrefix(text[cut:], "\n")
+	}
+	return append(chunks, text)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"regexp"
+)
+
+const maskedValue = "******"
+
+// sensitiveKeyPattern matches whole argument names that carry credentials, e.g. password,
+// clientSecret or api_key. Names that only contain such a word, like secret_santa, do not.
+var sensitiveKeyPattern = regexp.MustCompile(`(?i)^([a-z]+[_-]?)?(password|passwd|secret)$|^(api|private)[_-]?key$|^authorization$|^credentials?$`)
+
+// tokenKeyPattern matches argument names ending in a token word, e.g. token, csrf_token or
+// githubToken. Counts like max_tokens end in tokens and do not match.
+var tokenKeyPattern = regexp.MustCompile(`(?i)(^|[_-])token$|(?-i:[a-z0-9]Token$)`)
+
+// pageTokenPattern matches the paging cursors that are named like tokens but are not credentials
+var pageTokenPattern = regexp.MustCompile(`(?i)^(next[_-]?)?page[_-]?token$`)
+
+// sensitiveKey reports whether the argument name carries a credential
+func sensitiveKey(key string) bool {
+	if tokenKeyPattern.MatchString(key) {
+		return !pageTokenPattern.MatchString(key)
+	}
+	return sensitiveKeyPattern.MatchString(key)
+}
+
+// sensitiveInlinePattern matches credentials embedded in free text, e.g. "password=hunter2"
+var sensitiveInlinePattern = regexp.MustCompile(`(?i)\b(token|password|passwd|secret|api[_-]?key)(\s*[:=]\s*)([^\s,;&"']+)`)
+
+// sanitizeArgs returns a copy of the tool arguments with credential-like values masked.
+// The original map is left untouched because it is still sent to the MCP server.
+func sanitizeArgs(args map[string]interface{}) map[string]interface{} {
+	if args == nil {
+		return nil
+	}
+	sanitized := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		if sensitiveKey(key) {
+			sanitized[key] = maskedValue
+			continue
+		}
+		sanitized[key] = sanitizeValue(value)
+	}
+	return sanitized
+}
+
+// sanitizeValue masks credentials inside nested argument values
+func sanitizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return sanitizeArgs(v)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = sanitizeValue(item)
+		}
+		return items
+	case string:
+		return sanitizeText(v)
+	default:
+		return v
+	}
+}
+
+// sanitizeText masks credentials embedded in free text
+func sanitizeText(text string) string {
+	return sensitiveInlinePattern.ReplaceAllString(text, "${1}${2}"+maskedValue)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSensitiveKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"token", true},
+		{"TOKEN", true},
+		{"api_token", true},
+		{"csrf_token", true},
+		{"webhook-token", true},
+		{"githubToken", true},
+		{"accessToken", true},
+		{"x2Token", true},
+		{"password", true},
+		{"clientSecret", true},
+		{"api_key", true},
+		{"Authorization", true},
+		{"credentials", true},
+		{"next_page_token", false},
+		{"page_token", false},
+		{"nextPageToken", false},
+		{"pageToken", false},
+		{"max_tokens", false},
+		{"prompt_tokens", false},
+		{"maxTokens", false},
+		{"tokenizer", false},
+		{"Tokenize", false},
+		{"keyToken2", false},
+		{"summary", false},
+		{"issue_key", false},
+		{"secret_santa", false},
+	}
+	for _, tt := range tests {
+		if got := sensitiveKey(tt.key); got != tt.want {
+			t.Errorf("sensitiveKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeArgs(t *testing.T) {
+	args := map[string]interface{}{
+		"jql":             "project = A",
+		"next_page_token": "cursor-1",
+		"max_tokens":      float64(100),
+		"auth": map[string]interface{}{
+			"sessionToken": "s3cr3t",
+			"user":         "alice",
+		},
+		"comment":   []interface{}{"deploy with password=hunter2 and api_key: abc123"},
+		"jiraToken": "pat",
+	}
+	want := map[string]interface{}{
+		"jql":             "project = A",
+		"next_page_token": "cursor-1",
+		"max_tokens":      float64(100),
+		"auth": map[string]interface{}{
+			"sessionToken": maskedValue,
+			"user":         "alice",
+		},
+		"comment":   []interface{}{"deploy with password=" + maskedValue + " and api_key: " + maskedValue},
+		"jiraToken": maskedValue,
+	}
+
+	got := sanitizeArgs(args)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sanitizeArgs() = %v, want %v", got, want)
+	}
+	if args["jiraToken"] != "pat" {
+		t.Errorf("sanitizeArgs() modified the original arguments")
+	}
+	if sanitizeArgs(nil) != nil {
+		t.Errorf("sanitizeArgs(nil) is not nil")
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"jira_helper/internal/jql"
+	"jira_helper/internal/service/openai"
+)
+
+// jqlArgTools lists the tools whose jql argument is restricted to the channel's projects
+var jqlArgTools = []string{
+	"jira_search",
+	"jira_get_board_issues",
+}
+
+// scopeToolCall restricts a tool call to the Jira projects configured for the channel and keeps it
+// away from restricted projects the channel may not see. JQL queries are wrapped in project clauses,
+// and explicit project keys outside the channel's scope or boundaries are rejected. Channels without
+// a configured scope or restricted projects are left untouched.
+func (h *SlackHandler) scopeToolCall(channelID string, toolCall *openai.ToolCall) error {
+	projects := h.channelProjects[channelID]
+	if len(projects) == 0 && len(h.boundaries.Restricted(channelID)) == 0 {
+		return nil
+	}
+
+	if projectKey := projectKeyArg(toolCall.Args); projectKey != "" {
+		if len(projects) > 0 && !slices.ContainsFunc(projects, func(p string) bool {
+			return strings.EqualFold(p, projectKey)
+		}) {
+			return fmt.Errorf("project %s is outside this channel's scope (%s)", projectKey, strings.Join(projects, ", "))
+		}
+		if violations := h.boundaries.Violations(channelID, "", projectKey); len(violations) > 0 {
+			return fmt.Errorf("project %s is restricted and cannot be used in this channel", strings.ToUpper(projectKey))
+		}
+	}
+
+	if !slices.Contains(jqlArgTools, toolCall.Name) {
+		return nil
+	}
+	query, _ := toolCall.Args["jql"].(string)
+	scoped, err := h.scopeJQL(channelID, query)
+	if err != nil {
+		return fmt.Errorf("invalid JQL: %v", err)
+	}
+	if toolCall.Args == nil {
+		// The model may call a search without arguments, which the scope still applies to
+		toolCall.Args = map[string]interface{}{}
+	}
+	toolCall.Args["jql"] = scoped
+	return nil
+}
+
+// scopeJQL restricts a JQL query to the channel's projects and excludes the restricted projects
+// the channel may not see, so no clause of the query can reach them
+func (h *SlackHandler) scopeJQL(channelID, query string) (string, error) {
+	scoped, err := jql.Scope(query, h.channelProjects[channelID])
+	if err != nil {
+		return "", err
+	}
+	return jql.Exclude(scoped, h.boundaries.Restricted(channelID))
+}
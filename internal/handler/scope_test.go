@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"testing"
+
+	"jira_helper/internal/service/openai"
+)
+
+func TestScopeToolCallWithoutArgs(t *testing.T) {
+	h := &SlackHandler{channelProjects: map[string][]string{"C1": {"PROJ"}}}
+	toolCall := &openai.ToolCall{Name: "jira_search"}
+
+	if err := h.scopeToolCall("C1", toolCall); err != nil {
+		t.Fatalf("scopeToolCall() error = %v", err)
+	}
+	if got, want := toolCall.Args["jql"], `project in ("PROJ")`; got != want {
+		t.Errorf("jql = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// screenshotInstruction asks the vision model for what the assistant needs to act on a screenshot
+	screenshotInstruction = "This screenshot was shared with a request to a Jira assistant, for example to file a bug about it. " +
+		"Transcribe every visible error message, error code, title and stack trace exactly. " +
+		"Then describe in a few sentences what the screen shows: the application, the dialog or page, and its state. " +
+		"Only report what is visible, do not guess causes."
+
+	// screenshotContext appends what a screenshot shows to the query
+	screenshotContext = "\n\n[Screenshot %s, as read by an image model]\n%s"
+
+	// maxScreenshots bounds the screenshots read for one message
+	maxScreenshots = 3
+
+	// maxScreenshotBytes skips images too large to be screenshots
+	maxScreenshotBytes = 10 << 20
+
+	// screenshotTimeout bounds reading a single screenshot
+	screenshotTimeout = time.Minute
+)
+
+// screenshotTypes are the image types the vision model reads
+var screenshotTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// withScreenshots appends what the screenshots shared with the message show to the query. Without
+// a vision model, screenshots, or when they cannot be read, the query is returned as it is.
+func (h *SlackHandler) withScreenshots(ctx context.Context, query, channelID, threadTS, ts string) string {
+	if h.vision == nil || h.messengerFor(channelID) != nil {
+		return query
+	}
+	files, err := h.sharedFiles(channelID, threadTS, ts)
+	if err != nil {
+		logger.GetLogger().Warn("failed to get shared files", zap.String("channel", channelID), zap.Error(err))
+		return query
+	}
+
+	read := 0
+	for _, file := range files {
+		if !screenshotTypes[file.Mimetype] || file.Size > maxScreenshotBytes {
+			continue
+		}
+		if read == maxScreenshots {
+			break
+		}
+		description, err := h.readScreenshot(ctx, channelID, file)
+		if err != nil {
+			logger.GetLogger().Warn("failed to read screenshot", zap.String("file_id", file.ID), zap.Error(err))
+			continue
+		}
+		read++
+		query += fmt.Sprintf(screenshotContext, file.Name, strings.TrimSpace(description))
+	}
+	if read > 0 {
+		logger.GetLogger().Info("read screenshots", zap.String("channel", channelID), zap.Int("count", read))
+	}
+	return query
+}
+
+// readScreenshot downloads the image from Slack and has the vision model describe it
+func (h *SlackHandler) readScreenshot(ctx context.Context, channelID string, file slack.File) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, screenshotTimeout)
+	defer cancel()
+
+	var content bytes.Buffer
+	if err := h.slackClient(channelID).GetFileContext(ctx, file.URLPrivateDownload, &content); err != nil {
+		return "", fmt.Errorf("failed to download %s from Slack: %v", file.Name, err)
+	}
+	return h.vision.DescribeImage(ctx, screenshotInstruction, file.Mimetype, content.Bytes())
+}
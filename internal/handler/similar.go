@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/service/openai"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// findSimilarIssuesTool is served by the handler next to the MCP server's tools
+	findSimilarIssuesTool = "find_similar_issues"
+
+	defaultSimilarLimit = 5
+	maxSimilarLimit     = 20
+)
+
+// findSimilarIssuesDefinition describes find_similar_issues to the model
+var findSimilarIssuesDefinition = openai.Tool{
+	Name: findSimilarIssuesTool,
+	Description: "Find existing issues whose summary and description are similar in meaning to a text, " +
+		"e.g. to detect duplicates before creating an issue. Results are ranked by similarity from 0 to 1.",
+	Parameters: `{
+	"type": "object",
+	"properties": {
+		"text": {"type": "string", "description": "Summary and description to compare issues with"},
+		"project_key": {"type": "string", "description": "Project to search, all indexed projects when omitted"},
+		"limit": {"type": "integer", "description": "Maximum number of issues to return (default 5, max 20)"}
+	},
+	"required": ["text"]
+}`,
+}
+
+// findSimilarIssues runs a find_similar_issues call. The projects searched are the requested one,
+// the channel's projects, or every indexed project.
+func (h *SlackHandler) findSimilarIssues(ctx context.Context, conv *conversation, toolCall openai.ToolCall, _ string) (*mcp.CallToolResult, error) {
+	if conv.JiraInstance != "" {
+		return mcp.NewToolResultError("similar issues are only indexed for the primary Jira instance"), nil
+	}
+	text, _ := toolCall.Args["text"].(string)
+	if text == "" {
+		return mcp.NewToolResultError("text is required"), nil
+	}
+	limit := defaultSimilarLimit
+	if value, ok := toolCall.Args["limit"].(float64); ok && value > 0 {
+		limit = min(int(value), maxSimilarLimit)
+	}
+	projects := h.similarProjects
+	if scoped := h.channelProjects[conv.ChannelID]; len(scoped) > 0 {
+		projects = scoped
+	}
+	if project := projectKeyArg(toolCall.Args); project != "" {
+		projects = []string{project}
+	}
+
+	matches, err := h.similarIssues.Similar(ctx, text, projects, limit)
+	if err != nil {
+		logger.GetLogger().Error("failed to find similar issues", zap.Strings("projects", projects), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("failed to find similar issues: %v", err)), nil
+	}
+	if len(matches) == 0 {
+		return mcp.NewToolResultText("No similar issues found. The projects may not be indexed yet, use jira_search instead."), nil
+	}
+	return mcp.NewToolResultText(printJSON(map[string]interface{}{"issues": matches})), nil
+}
+
+// RefreshSimilarIssues embeds the issues of the indexed projects that changed since the last
+// refresh. It is run as a scheduled job.
+func (h *SlackHandler) RefreshSimilarIssues(ctx context.Context) error {
+	if h.similarIssues == nil {
+		return nil
+	}
+	if h.jiraURL == "" || h.defaultJiraToken == "" {
+		return fmt.Errorf("indexing similar issues requires JIRA_URL and a default Jira token")
+	}
+	client, err := jira.NewClient(h.jiraURL, h.defaultJiraToken)
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, project := range h.similarProjects {
+		count, err := h.similarIssues.Refresh(ctx, client, project)
+		if err != nil {
+			failed++
+			logger.GetLogger().Error("failed to index project", zap.String("project", project), zap.Error(err))
+			continue
+		}
+		logger.GetLogger().Info("indexed project for similar issues", zap.String("project", project), zap.Int("embedded", count))
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to index %d of %d projects", failed, len(h.similarProjects))
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"jira_helper/internal/httpclient"
+	"jira_helper/internal/logger"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/socketmode"
+	"go.uber.org/zap"
+)
+
+// RunSocketMode receives events, interactions and slash commands over a Slack Socket Mode
+// connection until ctx is cancelled, so the bot can run without a public HTTPS endpoint. Slash
+// commands are passed to commands as the form posts Slack would otherwise send to their routes.
+func (h *SlackHandler) RunSocketMode(ctx context.Context, appToken string, commands http.Handler) error {
+	api := slack.New("",
+		slack.OptionAppLevelToken(appToken),
+		slack.OptionHTTPClient(httpclient.New(httpclient.SlackTimeout)))
+	client := socketmode.New(api)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt := <-client.Events:
+				h.handleSocketModeEvent(ctx, client, evt, commands)
+			}
+		}
+	}()
+
+	return client.RunContext(ctx)
+}
+
+// handleSocketModeEvent acknowledges a Socket Mode event and processes it
+func (h *SlackHandler) handleSocketModeEvent(ctx context.Context, client *socketmode.Client, evt socketmode.Event, commands http.Handler) {
+	switch evt.Type {
+	case socketmode.EventTypeConnecting:
+		logger.GetLogger().Info("connecting to slack socket mode")
+	case socketmode.EventTypeConnected:
+		logger.GetLogger().Info("connected to slack socket mode")
+	case socketmode.EventTypeConnectionError:
+		logger.GetLogger().Warn("slack socket mode connection error", zap.Any("data", evt.Data))
+	case socketmode.EventTypeEventsAPI:
+		eventsAPIEvent, ok := evt.Data.(slackevents.EventsAPIEvent)
+		if !ok {
+			return
+		}
+		// Acknowledge first, Slack redelivers events that are not acknowledged within 3 seconds
+		client.Ack(*evt.Request)
+		if eventsAPIEvent.Type != slackevents.CallbackEvent {
+			return
+		}
+		go func() {
+			if err := h.handleCallbackEvent(ctx, evt.Request.Payload, eventsAPIEvent); err != nil {
+				logger.GetLogger().Error("failed to handle message event", zap.Error(err))
+			}
+		}()
+	case socketmode.EventTypeInteractive:
+		callback, ok := evt.Data.(slack.InteractionCallback)
+		if !ok {
+			return
+		}
+		// Block actions have no response, acknowledge them first as they may resume a conversation
+		if callback.Type == slack.InteractionTypeBlockActions {
+			client.Ack(*evt.Request)
+			go h.handleInteractionCallback(ctx, callback)
+			return
+		}
+		if response := h.handleInteractionCallback(ctx, callback); response != nil {
+			client.Ack(*evt.Request, response)
+			return
+		}
+		client.Ack(*evt.Request)
+	case socketmode.EventTypeSlashCommand:
+		cmd, ok := evt.Data.(slack.SlashCommand)
+		if !ok {
+			return
+		}
+		if response := runSlashCommand(commands, cmd); response != nil {
+			client.Ack(*evt.Request, response)
+			return
+		}
+		client.Ack(*evt.Request)
+	default:
+		logger.GetLogger().Debug("ignored socket mode event", zap.String("type", fmt.Sprint(evt.Type)))
+	}
+}
+
+// runSlashCommand posts the command to its route, e.g. /jira, like Slack does without Socket Mode,
+// and returns the response to acknowledge it with, nil when there is none
+func runSlashCommand(commands http.Handler, cmd slack.SlashCommand) interface{} {
+	if commands == nil {
+		return slashResponse{ResponseType: "ephemeral", Text: fmt.Sprintf("❌ `%s` is not available over Socket Mode", cmd.Command)}
+	}
+	form := url.Values{
+		"command":      {cmd.Command},
+		"text":         {cmd.Text},
+		"team_id":      {cmd.TeamID},
+		"team_domain":  {cmd.TeamDomain},
+		"channel_id":   {cmd.ChannelID},
+		"channel_name": {cmd.ChannelName},
+		"user_id":      {cmd.UserID},
+		"user_name":    {cmd.UserName},
+		"response_url": {cmd.ResponseURL},
+		"trigger_id":   {cmd.TriggerID},
+		"api_app_id":   {cmd.APIAppID},
+	}
+	req := httptest.NewRequest(http.MethodPost, cmd.Command, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	commands.ServeHTTP(recorder, req)
+
+	body := strings.TrimSpace(recorder.Body.String())
+	switch {
+	case recorder.Code >= http.StatusBadRequest:
+		logger.GetLogger().Warn("slash command failed", zap.String("command", cmd.Command), zap.Int("status", recorder.Code), zap.String("body", body))
+		return slashResponse{ResponseType: "ephemeral", Text: fmt.Sprintf("❌ `%s` failed", cmd.Command)}
+	case body == "":
+		return nil
+	case json.Valid([]byte(body)):
+		return json.RawMessage(body)
+	default:
+		return slashResponse{ResponseType: "ephemeral", Text: body}
+	}
+}
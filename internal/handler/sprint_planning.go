@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/service/openai"
+	"jira_helper/internal/workflow"
+
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// createSprintActionID and discardSprintActionID are the buttons confirming a sprint plan
+	createSprintActionID  = "create_sprint"
+	discardSprintActionID = "discard_sprint"
+
+	// maxButtonValue is the longest value Slack accepts for a button
+	maxButtonValue = 2000
+
+	// maxPlanLines bounds the issues listed in a proposal, a section block holds 3000 characters
+	maxPlanLines = 20
+)
+
+// sprintPlanValue is the plan a confirmation button carries, so any instance can apply it
+type sprintPlanValue struct {
+	BoardID int      `json:"b"`
+	Name    string   `json:"n"`
+	Issues  []string `json:"i"`
+}
+
+// jiraPlanSprintCommand proposes the next sprint of a board, e.g. `/jira plan-sprint 12` or with
+// the capacity in story points `/jira plan-sprint 12 30`. Nothing changes until the user confirms.
+func (h *SlackHandler) jiraPlanSprintCommand(ctx context.Context, client *jira.Client, req jiraCommandRequest) (string, []slack.Block, error) {
+	args := strings.Fields(req.Args)
+	usage := fmt.Errorf("usage: `/jira plan-sprint <board ID> [capacity]`")
+	if board := h.userPreferences(ctx, req.UserID).DefaultBoard; len(args) == 0 && board > 0 {
+		args = []string{strconv.Itoa(board)}
+	}
+	if len(args) == 0 || len(args) > 2 {
+		return "", nil, usage
+	}
+	boardID, err := strconv.Atoi(args[0])
+	if err != nil || boardID < 1 {
+		return "", nil, usage
+	}
+	capacity := 0.0
+	if len(args) == 2 {
+		if capacity, err = strconv.ParseFloat(args[1], 64); err != nil || capacity <= 0 {
+			return "", nil, usage
+		}
+	}
+
+	plan, err := workflow.PlanSprint(ctx, client, boardID, capacity)
+	if err != nil {
+		return "", nil, err
+	}
+	if plan.ProjectKey != "" {
+		if err := h.checkCommandScope(req.ChannelID, plan.ProjectKey); err != nil {
+			return "", nil, err
+		}
+	}
+
+	text := h.formatSprintPlan(plan)
+	blocks := []slack.Block{markdownSection(text)}
+	if len(plan.Scope) == 0 {
+		return text, blocks, nil
+	}
+	value, err := json.Marshal(sprintPlanValue{BoardID: plan.BoardID, Name: plan.SprintName, Issues: plan.Keys()})
+	if err != nil {
+		return "", nil, err
+	}
+	if len(value) > maxButtonValue {
+		return "", nil, fmt.Errorf("the proposed sprint has too many issues (%d) to confirm here, plan it with a lower capacity", len(plan.Scope))
+	}
+	create := slack.NewButtonBlockElement(createSprintActionID, string(value), slack.NewTextBlockObject(slack.PlainTextType, "Create sprint", false, false))
+	create.Style = slack.StylePrimary
+	discard := slack.NewButtonBlockElement(discardSprintActionID, "", slack.NewTextBlockObject(slack.PlainTextType, "Discard", false, false))
+	return text, append(blocks, slack.NewActionBlock("", create, discard)), nil
+}
+
+// formatSprintPlan describes the proposed sprint and how its capacity was measured
+func (h *SlackHandler) formatSprintPlan(plan *workflow.SprintPlan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🗓️ *Proposed sprint: %s* on board %s", plan.SprintName, plan.BoardName)
+	if plan.ProjectKey != "" {
+		fmt.Fprintf(&b, " (%s)", plan.ProjectKey)
+	}
+
+	fmt.Fprintf(&b, "\n*Capacity:* %s %s", formatAmount(plan.Capacity), plan.Unit)
+	if len(plan.Velocity) > 0 {
+		sprints := make([]string, len(plan.Velocity))
+		for i, sprint := range plan.Velocity {
+			sprints[i] = fmt.Sprintf("%s (%s)", sprint.Name, formatAmount(sprint.Completed))
+		}
+		fmt.Fprintf(&b, ", completed on average in %s", strings.Join(sprints, ", "))
+	}
+
+	if len(plan.Scope) == 0 {
+		b.WriteString("\nNo backlog issue fits the capacity.")
+	} else {
+		fmt.Fprintf(&b, "\n*Planned:* %d issues", len(plan.Scope))
+		if plan.Unit == workflow.UnitPoints {
+			fmt.Fprintf(&b, ", %s of %s points", formatAmount(plan.Planned), formatAmount(plan.Capacity))
+		}
+		for i, issue := range plan.Scope {
+			if i == maxPlanLines {
+				fmt.Fprintf(&b, "\n• …and %d more", len(plan.Scope)-maxPlanLines)
+				break
+			}
+			fmt.Fprintf(&b, "\n• %s %s", h.issueLink(issue.Key), issue.Summary)
+			if plan.Unit == workflow.UnitPoints {
+				fmt.Fprintf(&b, " — %s pts", formatAmount(issue.Estimate))
+			}
+		}
+	}
+
+	if len(plan.Unestimated) > 0 {
+		var keys []string
+		for _, issue := range plan.Unestimated[:min(len(plan.Unestimated), maxPlanLines)] {
+			keys = append(keys, issue.Key)
+		}
+		if len(plan.Unestimated) > maxPlanLines {
+			keys = append(keys, fmt.Sprintf("and %d more", len(plan.Unestimated)-maxPlanLines))
+		}
+		fmt.Fprintf(&b, "\n⚠️ Left out without an estimate: %s", strings.Join(keys, ", "))
+	}
+	if plan.Remaining > 0 {
+		fmt.Fprintf(&b, "\n%d more issues stay in the backlog.", plan.Remaining)
+	}
+	return b.String()
+}
+
+// handleSprintPlanAction creates the confirmed sprint with the user's personal token, and replaces
+// the proposal with the outcome
+func (h *SlackHandler) handleSprintPlanAction(callback slack.InteractionCallback, action *slack.BlockAction) {
+	ctx, cancel := context.WithTimeout(context.Background(), jiraCommandTimeout)
+	defer cancel()
+
+	outcome := "👍 Discarded the proposed sprint."
+	if action.ActionID == createSprintActionID {
+		var err error
+		outcome, err = h.createPlannedSprint(ctx, callback.User.ID, callback.Channel.ID, action.Value)
+		if err != nil {
+			logger.GetLogger().Warn("failed to create planned sprint", zap.String("user_id", callback.User.ID), zap.Error(err))
+			outcome = fmt.Sprintf("❌ Failed to create the sprint: %s", jiraErrorMessage(err))
+		}
+	}
+
+	if callback.ResponseURL == "" {
+		return
+	}
+	if err := slack.PostWebhookContext(ctx, callback.ResponseURL, &slack.WebhookMessage{Text: outcome, ReplaceOriginal: true}); err != nil {
+		logger.GetLogger().Error("failed to update sprint proposal", zap.Error(err))
+	}
+}
+
+// createPlannedSprint applies the plan carried by the button. Like other writes, it is paused by
+// bursts and audited.
+func (h *SlackHandler) createPlannedSprint(ctx context.Context, userID, channelID, value string) (string, error) {
+	var plan sprintPlanValue
+	if err := json.Unmarshal([]byte(value), &plan); err != nil {
+		return "", fmt.Errorf("invalid sprint plan: %v", err)
+	}
+	token, err := h.getUserPersonalToken(userID)
+	if err != nil || token == "" {
+		return "", fmt.Errorf("set your personal token first with `/setup-token`")
+	}
+	client, err := jira.NewClient(h.jiraURL, token)
+	if err != nil {
+		return "", err
+	}
+
+	toolCall := openai.ToolCall{Name: "jira_create_sprint", Args: map[string]interface{}{
+		"board_id":    plan.BoardID,
+		"sprint_name": plan.Name,
+		"issue_keys":  strings.Join(plan.Issues, ","),
+	}}
+	if err := h.checkWriteBurst(ctx, userID, toolCall); err != nil {
+		return "", err
+	}
+	sprint, err := workflow.ApplySprintPlan(ctx, client, plan.BoardID, plan.Name, plan.Issues)
+	h.recordAudit(ctx, userID, channelID, toolCall, nil, err)
+	h.observeWrite(ctx, userID, channelID, toolCall)
+	if err != nil {
+		if sprint != nil {
+			return "", fmt.Errorf("created sprint %s, but %v", sprint.Name, err)
+		}
+		return "", err
+	}
+	return fmt.Sprintf("✅ <@%s> created sprint *%s* with %d issues. Start it from the board when the team is ready.", userID, sprint.Name, len(plan.Issues)), nil
+}
+
+// formatAmount renders points without trailing zeros, e.g. 21 or 2.5
+func formatAmount(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// styleInstruction appends the response style of the conversation to the system prompt
+	styleInstruction = "\n\nAnswer in the %s style: %s"
+
+	// styleDefault resets the user's style to the team's default
+	styleDefault = "default"
+)
+
+// responseStyle returns the response style of the user's conversations: the one they chose, or
+// the team's default. Styles that are no longer configured are ignored.
+func (h *SlackHandler) responseStyle(preferences storage.Preferences) string {
+	style := h.defaultStyle
+	if preferences.Style != "" {
+		style = preferences.Style
+	}
+	if _, ok := h.styles[style]; !ok {
+		return ""
+	}
+	return style
+}
+
+// styleInstructionOf returns the system prompt addition of the style, empty without one
+func (h *SlackHandler) styleInstructionOf(style string) string {
+	if s, ok := h.styles[style]; ok && style != "" {
+		return fmt.Sprintf(styleInstruction, style, s.Instruction)
+	}
+	return ""
+}
+
+// jiraSetStyleCommand sets the user's response style, e.g. `/jira set-style terse`, or lists the
+// styles without one
+func (h *SlackHandler) jiraSetStyleCommand(ctx context.Context, _ *jira.Client, req jiraCommandRequest) (string, error) {
+	name := strings.ToLower(req.Args)
+	if name == "" {
+		current := h.responseStyle(h.userPreferences(ctx, req.UserID))
+		lines := []string{"Choose how I answer you with `/jira set-style <style>`:"}
+		for _, style := range h.styles.Names() {
+			marker := ""
+			if style == current {
+				marker = " ✅"
+			}
+			lines = append(lines, fmt.Sprintf("• `%s` – %s%s", style, h.styles[style].Description, marker))
+		}
+		lines = append(lines, fmt.Sprintf("• `%s` – The team's default", styleDefault))
+		return strings.Join(lines, "\n"), nil
+	}
+	if _, ok := h.styles[name]; !ok && name != styleDefault {
+		return "", fmt.Errorf("unknown style %q, use one of %s or %s", name, strings.Join(h.styles.Names(), ", "), styleDefault)
+	}
+
+	preferences, err := h.preferences.GetPreferences(ctx, req.UserID)
+	if err != nil {
+		return "", err
+	}
+	if preferences == nil {
+		preferences = &storage.Preferences{SlackUserID: req.UserID}
+	}
+	preferences.Style = name
+	if name == styleDefault {
+		preferences.Style = ""
+	}
+	preferences.UpdatedAt = time.Now().UTC()
+	if err := h.preferences.SavePreferences(ctx, *preferences); err != nil {
+		return "", err
+	}
+	logger.GetLogger().Info("set response style", zap.String("user_id", req.UserID), zap.String("style", name))
+
+	if preferences.Style == "" {
+		if h.defaultStyle != "" {
+			return fmt.Sprintf("🎨 I will answer you in the team's default `%s` style", h.defaultStyle), nil
+		}
+		return "🎨 I will answer you in the team's default style", nil
+	}
+	return fmt.Sprintf("🎨 I will answer you in the `%s` style: %s", name, h.styles[name].Description), nil
+}
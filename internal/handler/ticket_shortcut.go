@@ -0,0 +1,387 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/openai"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// createTicketCallbackID is the callback ID of the "Create Jira ticket" message shortcut,
+	// configured in the Slack app
+	createTicketCallbackID = "create_jira_ticket"
+	// ticketOptionsCallbackID identifies submissions of the modal asking whether to include the thread
+	ticketOptionsCallbackID = "create_ticket_options"
+	// ticketFormCallbackID identifies submissions of the prefilled ticket modal
+	ticketFormCallbackID = "create_ticket_form"
+
+	ticketThreadBlockID      = "ticket_thread"
+	ticketThreadActionID     = "ticket_thread_input"
+	ticketProjectBlockID     = "ticket_project"
+	ticketProjectActionID    = "ticket_project_input"
+	ticketTypeBlockID        = "ticket_type"
+	ticketTypeActionID       = "ticket_type_input"
+	ticketSummaryBlockID     = "ticket_summary"
+	ticketSummaryActionID    = "ticket_summary_input"
+	ticketDescriptionBlockID = "ticket_description"
+	ticketDescActionID       = "ticket_description_input"
+	ticketLabelsBlockID      = "ticket_labels"
+	ticketLabelsActionID     = "ticket_labels_input"
+
+	// ticketTimeout bounds drafting the ticket, and creating it once submitted
+	ticketTimeout = 2 * time.Minute
+
+	// maxTicketSourceLength bounds the message text carried in the modal's private metadata,
+	// which Slack limits to 3000 characters
+	maxTicketSourceLength = 2000
+
+	// maxTicketSummaryLength is the longest summary Jira accepts
+	maxTicketSummaryLength = 255
+
+	// maxTicketDescriptionLength is the longest text Slack prefills a modal input with
+	maxTicketDescriptionLength = 3000
+)
+
+// ticketDraftPrompt asks the model to draft a ticket from a Slack message or thread
+const ticketDraftPrompt = `Draft a Jira ticket from the Slack conversation the user sends.
+Reply with only a JSON object with these keys:
+- "summary": a one-line title of at most 120 characters
+- "description": what happened or is asked for, with the relevant details, steps and people, in Jira wiki markup
+- "labels": up to 3 short lowercase labels without spaces
+Do not invent details that are not in the conversation.`
+
+// ticketSource is the message a ticket is created from, carried in the modals' private metadata
+type ticketSource struct {
+	ChannelID string `json:"channel_id"`
+	MessageTS string `json:"message_ts"`
+	ThreadTS  string `json:"thread_ts,omitempty"` // Thread the message belongs to or starts
+	Text      string `json:"text"`
+}
+
+// ticketDraft is the ticket the model drafted
+type ticketDraft struct {
+	Summary     string   `json:"summary"`
+	Description string   `json:"description"`
+	Labels      []string `json:"labels"`
+}
+
+// handleCreateTicketShortcut starts creating a ticket from the message the shortcut was used on.
+// Messages in a thread first ask whether to draft from the whole thread.
+func (h *SlackHandler) handleCreateTicketShortcut(callback slack.InteractionCallback) {
+	message := callback.Message
+	source := ticketSource{
+		ChannelID: callback.Channel.ID,
+		MessageTS: message.Timestamp,
+		ThreadTS:  message.ThreadTimestamp,
+		Text:      excerpt(message.Text, maxTicketSourceLength),
+	}
+	if source.ThreadTS == "" && message.ReplyCount > 0 {
+		source.ThreadTS = message.Timestamp
+	}
+
+	if source.ThreadTS != "" {
+		_, _ = h.openTicketModal(callback.TriggerID, source.ChannelID, ticketOptionsView(source))
+		return
+	}
+	viewID, err := h.openTicketModal(callback.TriggerID, source.ChannelID, ticketStatusView(source, "⏳ Drafting the ticket from the message…"))
+	if err != nil {
+		return
+	}
+	h.startTicketDraft(callback.User.ID, viewID, source, false)
+}
+
+// handleTicketOptionsSubmission drafts the ticket from the message, or its whole thread if the
+// user chose to, and shows the draft in the same modal once it is ready
+func (h *SlackHandler) handleTicketOptionsSubmission(callback slack.InteractionCallback) *slack.ViewSubmissionResponse {
+	var source ticketSource
+	if err := json.Unmarshal([]byte(callback.View.PrivateMetadata), &source); err != nil {
+		logger.GetLogger().Error("invalid ticket modal metadata", zap.Error(err))
+		return nil
+	}
+	includeThread := len(callback.View.State.Values[ticketThreadBlockID][ticketThreadActionID].SelectedOptions) > 0
+	status := "⏳ Drafting the ticket from the message…"
+	if includeThread {
+		status = "⏳ Drafting the ticket from the thread…"
+	}
+	h.startTicketDraft(callback.User.ID, callback.View.ID, source, includeThread)
+	view := ticketStatusView(source, status)
+	return slack.NewUpdateViewSubmissionResponse(&view)
+}
+
+// startTicketDraft drafts the ticket in the background, as Slack expects a response to the
+// interaction within seconds, and fills in the modal with the draft
+func (h *SlackHandler) startTicketDraft(userID, viewID string, source ticketSource, includeThread bool) {
+	ctx, done, err := h.beginConversation(context.Background(), source.ChannelID, source.ThreadTS, userID)
+	if err != nil {
+		h.updateTicketModal(source.ChannelID, viewID, ticketStatusView(source, "❌ The bot is restarting, try again in a moment."))
+		return
+	}
+	go func() {
+		defer done()
+		ctx, cancel := context.WithTimeout(ctx, ticketTimeout)
+		defer cancel()
+
+		draft, err := h.draftTicket(ctx, source, includeThread)
+		if err != nil {
+			logger.GetLogger().Error("failed to draft ticket", zap.String("channel", source.ChannelID), zap.Error(err))
+			h.updateTicketModal(source.ChannelID, viewID, ticketStatusView(source, fmt.Sprintf("❌ Failed to draft the ticket: %v", err)))
+			return
+		}
+		h.updateTicketModal(source.ChannelID, viewID, h.ticketFormView(source, draft))
+	}()
+}
+
+// draftTicket asks the model for a summary, description and labels of the message or thread
+func (h *SlackHandler) draftTicket(ctx context.Context, source ticketSource, includeThread bool) (*ticketDraft, error) {
+	transcript := source.Text
+	if includeThread {
+		history, err := h.getThreadHistory(source.ChannelID, source.ThreadTS)
+		if err != nil {
+			return nil, err
+		}
+		lines := make([]string, 0, len(history))
+		for _, msg := range history {
+			lines = append(lines, fmt.Sprintf("%s: %s", msg.Role, msg.Content))
+		}
+		transcript = strings.Join(lines, "\n")
+	}
+	transcript = h.guardText(source.ChannelID, "ticket_source", transcript)
+
+	reply, err := h.aiClient.Chat(ctx, []azopenai.ChatRequestMessageClassification{
+		&azopenai.ChatRequestSystemMessage{Content: azopenai.NewChatRequestSystemMessageContent(ticketDraftPrompt)},
+		&azopenai.ChatRequestUserMessage{Content: azopenai.NewChatRequestUserMessageContent(transcript)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to draft ticket: %v", err)
+	}
+
+	// Models sometimes wrap the JSON in a code block
+	reply = strings.TrimSpace(reply)
+	if start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}"); start >= 0 && end > start {
+		reply = reply[start : end+1]
+	}
+	var draft ticketDraft
+	if err := json.Unmarshal([]byte(reply), &draft); err != nil || strings.TrimSpace(draft.Summary) == "" {
+		return nil, fmt.Errorf("the model did not return a ticket draft")
+	}
+	return &draft, nil
+}
+
+// handleTicketFormSubmission checks the edited ticket, and creates it in the background through
+// the MCP server with the user's personal token. The new key is posted in the message's thread.
+func (h *SlackHandler) handleTicketFormSubmission(callback slack.InteractionCallback) *slack.ViewSubmissionResponse {
+	var source ticketSource
+	if err := json.Unmarshal([]byte(callback.View.PrivateMetadata), &source); err != nil {
+		logger.GetLogger().Error("invalid ticket modal metadata", zap.Error(err))
+		return nil
+	}
+	values := callback.View.State.Values
+	project := strings.ToUpper(strings.TrimSpace(values[ticketProjectBlockID][ticketProjectActionID].Value))
+	issueType := strings.TrimSpace(values[ticketTypeBlockID][ticketTypeActionID].Value)
+	if issueType == "" {
+		issueType = defaultIssueType
+	}
+	summary := strings.TrimSpace(values[ticketSummaryBlockID][ticketSummaryActionID].Value)
+	description := strings.TrimSpace(values[ticketDescriptionBlockID][ticketDescActionID].Value)
+	labels := splitKeys(values[ticketLabelsBlockID][ticketLabelsActionID].Value)
+
+	userID := callback.User.ID
+	if err := h.checkCommandScope(source.ChannelID, project); err != nil {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{ticketProjectBlockID: err.Error()})
+	}
+	token, err := h.getUserPersonalToken(userID)
+	if err != nil || token == "" {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{
+			ticketProjectBlockID: "Set your personal Jira token with /setup-personal-token first",
+		})
+	}
+
+	args := map[string]interface{}{
+		"project_key": project,
+		"issue_type":  issueType,
+		"summary":     summary,
+		"description": description,
+	}
+	if len(labels) > 0 {
+		args["additional_fields"] = map[string]interface{}{"labels": labels}
+	}
+	toolCall := openai.ToolCall{ID: "create_ticket_shortcut", Name: "jira_create_issue", Args: args}
+	if err := h.checkWriteBurst(context.Background(), userID, toolCall); err != nil {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{ticketSummaryBlockID: err.Error()})
+	}
+
+	ctx, done, err := h.beginConversation(context.Background(), source.ChannelID, source.ThreadTS, userID)
+	if err != nil {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{ticketSummaryBlockID: "The bot is restarting, try again in a moment"})
+	}
+	go func() {
+		defer done()
+		ctx, cancel := context.WithTimeout(ctx, ticketTimeout)
+		defer cancel()
+		h.createTicket(ctx, userID, token, source, toolCall)
+	}()
+	return nil
+}
+
+// createTicket creates the issue and posts its key in the thread of the message, or tells the
+// user why it failed
+func (h *SlackHandler) createTicket(ctx context.Context, userID, token string, source ticketSource, toolCall openai.ToolCall) {
+	mcpClient, cleanup, err := h.getMcpClient(token)
+	if err != nil {
+		h.sendEphemeral(source.ChannelID, userID, fmt.Sprintf("❌ Failed to create the ticket: %v", err))
+		return
+	}
+	defer cleanup()
+
+	result, err := h.executeToolWithClient(ctx, toolCall, mcpClient)
+	h.recordAudit(ctx, userID, source.ChannelID, toolCall, result, err)
+	h.observeWrite(ctx, userID, source.ChannelID, toolCall)
+	if err == nil && result != nil && result.IsError {
+		err = fmt.Errorf("%s", printToolResult(result))
+	}
+	if err != nil {
+		logger.GetLogger().Error("failed to create ticket from message", zap.String("channel", source.ChannelID), zap.Error(err))
+		h.sendEphemeral(source.ChannelID, userID, fmt.Sprintf("❌ Failed to create the ticket: %v", err))
+		return
+	}
+
+	project, _ := toolCall.Args["project_key"].(string)
+	key := createdIssueKey(printToolResult(result), project)
+	if key == "" {
+		key = "the ticket"
+	} else {
+		key = h.issueLink(key)
+	}
+	threadTS := source.ThreadTS
+	if threadTS == "" {
+		threadTS = source.MessageTS
+	}
+	summary, _ := toolCall.Args["summary"].(string)
+	message := fmt.Sprintf("🎫 <@%s> created %s from this conversation: %s", userID, key, summary)
+	if _, err := h.sendMarkdownMessage(source.ChannelID, message, threadTS); err == nil {
+		h.linkThread(ctx, source.ChannelID, threadTS, message)
+	}
+}
+
+// createdIssueKey finds the key of the created issue in the tool result, the first key of the
+// project
+func createdIssueKey(result, project string) string {
+	var created struct {
+		Issue struct {
+			Key string `json:"key"`
+		} `json:"issue"`
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal([]byte(result), &created); err == nil {
+		if created.Issue.Key != "" {
+			return created.Issue.Key
+		}
+		if created.Key != "" {
+			return created.Key
+		}
+	}
+	for _, key := range issueKeysIn(result) {
+		if strings.HasPrefix(key, project+"-") {
+			return key
+		}
+	}
+	return ""
+}
+
+// openTicketModal opens one of the ticket modals and returns its view ID
+func (h *SlackHandler) openTicketModal(triggerID, channelID string, view slack.ModalViewRequest) (string, error) {
+	response, err := h.slackClient(channelID).OpenView(triggerID, view)
+	if err != nil {
+		logger.GetLogger().Error("failed to open ticket modal", zap.Error(err))
+		return "", err
+	}
+	return response.ID, nil
+}
+
+// updateTicketModal replaces the content of an open ticket modal
+func (h *SlackHandler) updateTicketModal(channelID, viewID string, view slack.ModalViewRequest) {
+	if _, err := h.slackClient(channelID).UpdateView(view, "", "", viewID); err != nil {
+		logger.GetLogger().Error("failed to update ticket modal", zap.String("view_id", viewID), zap.Error(err))
+	}
+}
+
+// ticketOptionsView asks whether to draft the ticket from the message only or the whole thread
+func ticketOptionsView(source ticketSource) slack.ModalViewRequest {
+	option := slack.NewOptionBlockObject("thread",
+		slack.NewTextBlockObject(slack.PlainTextType, "Include the whole thread", false, false),
+		slack.NewTextBlockObject(slack.PlainTextType, "Draft from every message of the thread, not only this one", false, false))
+	input := slack.NewInputBlock(ticketThreadBlockID,
+		slack.NewTextBlockObject(slack.PlainTextType, "Source", false, false), nil,
+		slack.NewCheckboxGroupsBlockElement(ticketThreadActionID, option))
+	input.Optional = true
+
+	return ticketModal(ticketOptionsCallbackID, source, "Draft", markdownSection("> "+source.Text), input)
+}
+
+// ticketFormView is the ticket drafted by the model, for the user to edit before it is created
+func (h *SlackHandler) ticketFormView(source ticketSource, draft *ticketDraft) slack.ModalViewRequest {
+	project := ""
+	if projects := h.channelProjects[source.ChannelID]; len(projects) > 0 {
+		project = projects[0]
+	}
+	summary, description := draft.Summary, draft.Description
+	if runes := []rune(summary); len(runes) > maxTicketSummaryLength {
+		summary = string(runes[:maxTicketSummaryLength])
+	}
+	if runes := []rune(description); len(runes) > maxTicketDescriptionLength {
+		description = string(runes[:maxTicketDescriptionLength])
+	}
+	return ticketModal(ticketFormCallbackID, source, "Create",
+		ticketTextInput(ticketProjectBlockID, ticketProjectActionID, "Project key", project, false, false),
+		ticketTextInput(ticketTypeBlockID, ticketTypeActionID, "Issue type", defaultIssueType, false, true),
+		ticketTextInput(ticketSummaryBlockID, ticketSummaryActionID, "Summary", summary, false, false),
+		ticketTextInput(ticketDescriptionBlockID, ticketDescActionID, "Description", description, true, true),
+		ticketTextInput(ticketLabelsBlockID, ticketLabelsActionID, "Labels (comma-separated)", strings.Join(draft.Labels, ", "), false, true),
+	)
+}
+
+// ticketStatusView shows the progress or failure of drafting in place of the form
+func ticketStatusView(source ticketSource, status string) slack.ModalViewRequest {
+	return ticketModal("", source, "", markdownSection(status))
+}
+
+// ticketModal is a ticket modal carrying the source message. Modals without a submit label
+// cannot be submitted.
+func ticketModal(callbackID string, source ticketSource, submit string, blocks ...slack.Block) slack.ModalViewRequest {
+	// Keep mentions and links as they are, escaping them would take more of the metadata's limit
+	var metadata strings.Builder
+	encoder := json.NewEncoder(&metadata)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(source)
+	view := slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      callbackID,
+		Title:           slack.NewTextBlockObject(slack.PlainTextType, "Create Jira ticket", false, false),
+		Close:           slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false),
+		PrivateMetadata: strings.TrimSpace(metadata.String()),
+		Blocks:          slack.Blocks{BlockSet: blocks},
+	}
+	if submit != "" {
+		view.Submit = slack.NewTextBlockObject(slack.PlainTextType, submit, false, false)
+	}
+	return view
+}
+
+// ticketTextInput is a prefilled text input of the ticket form
+func ticketTextInput(blockID, actionID, label, value string, multiline, optional bool) *slack.InputBlock {
+	element := slack.NewPlainTextInputBlockElement(nil, actionID)
+	element.InitialValue = value
+	element.Multiline = multiline
+	input := slack.NewInputBlock(blockID, slack.NewTextBlockObject(slack.PlainTextType, label, false, false), nil, element)
+	input.Optional = optional
+	return input
+}
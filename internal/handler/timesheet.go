@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/timesheet"
+
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxTimesheetAuthors bounds the users of a report, e.g. the members of a large group
+	maxTimesheetAuthors = 50
+
+	// timesheetUsage explains the arguments of /jira timesheet
+	timesheetUsage = "usage: `/jira timesheet [me | user1,user2 | group:<name>] [from [to]] [csv]`, dates as YYYY-MM-DD"
+)
+
+// timesheetRequest is the parsed arguments of /jira timesheet
+type timesheetRequest struct {
+	who      string
+	from, to time.Time
+	csv      bool
+}
+
+// jiraTimesheetCommand reports the hours logged per day, e.g. `/jira timesheet`,
+// `/jira timesheet group:platform-team 2024-05-01 2024-05-31 csv`. Without dates it covers the
+// current week. With csv the table is posted to the channel with the export in its thread.
+func (h *SlackHandler) jiraTimesheetCommand(ctx context.Context, client *jira.Client, req jiraCommandRequest) (string, error) {
+	args, err := parseTimesheetArgs(req.Args, time.Now())
+	if err != nil {
+		return "", err
+	}
+	authors, err := h.timesheetAuthors(ctx, client, req.UserID, args.who)
+	if err != nil {
+		return "", err
+	}
+
+	report, err := timesheet.Build(ctx, client, authors, args.from, args.to)
+	if err != nil {
+		return "", err
+	}
+	title := fmt.Sprintf("🕒 *Timesheet %s to %s*", args.from.Format("2006-01-02"), args.to.Format("2006-01-02"))
+	if len(report.Rows) == 0 {
+		return fmt.Sprintf("%s\nNo time logged by %s.", title, strings.Join(authors, ", ")), nil
+	}
+	text := fmt.Sprintf("%s: %s on %d issues\n```\n%s```", title, timesheet.Hours(report.Total), report.Issues, report.Table())
+	if !args.csv {
+		return text, nil
+	}
+
+	data, err := report.CSV()
+	if err != nil {
+		return "", err
+	}
+	ts, err := h.sendMarkdownMessage(req.ChannelID, fmt.Sprintf("%s, requested by <@%s>", text, req.UserID), "")
+	if err != nil {
+		return "", fmt.Errorf("failed to post the timesheet: %v", err)
+	}
+	name := fmt.Sprintf("timesheet-%s-%s.csv", args.from.Format("20060102"), args.to.Format("20060102"))
+	if _, err := h.slackClient(req.ChannelID).UploadFileV2Context(ctx, slack.UploadFileV2Parameters{
+		Reader:          bytes.NewReader(data),
+		FileSize:        len(data),
+		Filename:        name,
+		Title:           name,
+		Channel:         req.ChannelID,
+		ThreadTimestamp: ts,
+	}); err != nil {
+		logger.GetLogger().Error("failed to upload timesheet", zap.String("channel", req.ChannelID), zap.Error(err))
+		return "", fmt.Errorf("posted the timesheet, but failed to upload the CSV: %v", err)
+	}
+	return "📎 Posted the timesheet with a CSV export in this channel.", nil
+}
+
+// timesheetAuthors resolves who the report covers to Jira usernames
+func (h *SlackHandler) timesheetAuthors(ctx context.Context, client *jira.Client, userID, who string) ([]string, error) {
+	switch {
+	case who == "" || who == "me":
+		// The default token belongs to someone else, so "me" needs the user's own token
+		if token, err := h.getUserPersonalToken(userID); err != nil || token == "" {
+			return nil, fmt.Errorf("set your personal token with `/setup-token` to report your own time, or name the Jira users")
+		}
+		user, err := client.Myself(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []string{user.Name}, nil
+	case strings.HasPrefix(who, "group:"):
+		members, err := client.GroupMembers(ctx, strings.TrimPrefix(who, "group:"), maxTimesheetAuthors)
+		if err != nil {
+			return nil, err
+		}
+		if len(members) == 0 {
+			return nil, fmt.Errorf("group %s has no active members", strings.TrimPrefix(who, "group:"))
+		}
+		authors := make([]string, len(members))
+		for i, member := range members {
+			authors[i] = member.Name
+		}
+		return authors, nil
+	default:
+		authors := splitKeys(who)
+		if len(authors) > maxTimesheetAuthors {
+			return nil, fmt.Errorf("a timesheet covers at most %d users", maxTimesheetAuthors)
+		}
+		return authors, nil
+	}
+}
+
+// parseTimesheetArgs reads who, the dates and the csv flag in any order. Without dates the report
+// covers the current week up to today, with one date it covers that day.
+func parseTimesheetArgs(args string, now time.Time) (timesheetRequest, error) {
+	var req timesheetRequest
+	var dates []time.Time
+	for _, arg := range strings.Fields(args) {
+		if date, err := time.Parse("2006-01-02", arg); err == nil {
+			dates = append(dates, date)
+			continue
+		}
+		switch {
+		case strings.EqualFold(arg, "csv"):
+			req.csv = true
+		case req.who == "":
+			req.who = arg
+		default:
+			return req, fmt.Errorf(timesheetUsage)
+		}
+	}
+
+	switch len(dates) {
+	case 0:
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		// Weeks start on Monday
+		req.from, req.to = today.AddDate(0, 0, -(int(today.Weekday())+6)%7), today
+	case 1:
+		req.from, req.to = dates[0], dates[0]
+	case 2:
+		req.from, req.to = dates[0], dates[1]
+	default:
+		return req, fmt.Errorf(timesheetUsage)
+	}
+	return req, nil
+}
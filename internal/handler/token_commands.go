@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"jira_helper/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// removeTokenActionID and keepTokenActionID are the buttons confirming a token removal
+	removeTokenActionID = "remove_token"
+	keepTokenActionID   = "keep_token"
+
+	// rotateTokenMetadata marks the token modal opened by /rotate-personal-token
+	rotateTokenMetadata = "rotate"
+)
+
+// HandleRemovePersonalToken handles the /remove-personal-token slash command. The token is
+// deleted once the user confirms with the button in the response.
+func (h *SlackHandler) HandleRemovePersonalToken(c *gin.Context) {
+	token, err := h.getUserPersonalToken(c.PostForm("user_id"))
+	if err != nil {
+		logger.GetLogger().Error("failed to check personal token", zap.Error(err))
+		c.JSON(http.StatusOK, slashResponse{ResponseType: "ephemeral", Text: fmt.Sprintf("❌ Failed to check your token: %s", err.Error())})
+		return
+	}
+	if token == "" {
+		c.JSON(http.StatusOK, slashResponse{ResponseType: "ephemeral", Text: "You don't have a personal Jira token stored."})
+		return
+	}
+
+	text := "⚠️ Remove your personal Jira token? Writes will be refused until you set a new one."
+	remove := slack.NewButtonBlockElement(removeTokenActionID, "", slack.NewTextBlockObject(slack.PlainTextType, "Remove token", false, false))
+	remove.Style = slack.StyleDanger
+	keep := slack.NewButtonBlockElement(keepTokenActionID, "", slack.NewTextBlockObject(slack.PlainTextType, "Keep it", false, false))
+	c.JSON(http.StatusOK, slack.Msg{
+		ResponseType: slack.ResponseTypeEphemeral,
+		Text:         text,
+		Blocks: slack.Blocks{BlockSet: []slack.Block{
+			markdownSection(text),
+			slack.NewActionBlock("", remove, keep),
+		}},
+	})
+}
+
+// HandleRotatePersonalToken handles the /rotate-personal-token slash command. It opens the token
+// modal, and the new token replaces the stored one once it has been verified.
+func (h *SlackHandler) HandleRotatePersonalToken(c *gin.Context) {
+	if err := h.openTokenModal(c.PostForm("trigger_id"), c.PostForm("user_id"), true); err != nil {
+		c.JSON(http.StatusOK, slashResponse{ResponseType: "ephemeral", Text: "❌ Failed to open the token dialog, please try again."})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// handleRemoveTokenAction deletes the user's token once they confirm, and replaces the
+// confirmation with the outcome
+func (h *SlackHandler) handleRemoveTokenAction(callback slack.InteractionCallback, action *slack.BlockAction) {
+	ctx, cancel := context.WithTimeout(context.Background(), jiraCommandTimeout)
+	defer cancel()
+
+	userID := callback.User.ID
+	outcome := "👍 Kept your personal Jira token."
+	if action.ActionID == removeTokenActionID {
+		err := h.tokenStore.DeleteToken(userID)
+		h.auditTokenChange(ctx, userID, "remove_personal_token", err)
+		if err != nil {
+			logger.GetLogger().Error("failed to delete token", zap.String("user_id", userID), zap.Error(err))
+			outcome = fmt.Sprintf("❌ Failed to remove your token: %s", err.Error())
+		} else {
+			logger.GetLogger().Info("personal token removed", zap.String("user_id", userID))
+			outcome = "🗑️ Your personal Jira token was removed. If it may have been exposed, revoke it in Jira as well."
+		}
+	}
+
+	if callback.ResponseURL == "" {
+		return
+	}
+	if err := slack.PostWebhookContext(ctx, callback.ResponseURL, &slack.WebhookMessage{Text: outcome, ReplaceOriginal: true}); err != nil {
+		logger.GetLogger().Error("failed to update token removal confirmation", zap.Error(err))
+	}
+}
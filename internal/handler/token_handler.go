@@ -1,12 +1,19 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"jira_helper/internal/logger"
+	"jira_helper/internal/service/jira"
 
 	"github.com/gin-gonic/gin"
+	"github.com/slack-go/slack"
 	"go.uber.org/zap"
 )
 
@@ -16,12 +23,28 @@ type TokenRequest struct {
 	ChannelID string `json:"channel_id" binding:"required"`
 }
 
-// HandleSetupPersonalToken handles the POST request to /setup-personal-token
+// HandleSetupToken handles the /setup-token slash command. It opens the token modal, so the
+// token never appears in a message or the command history.
+func (h *SlackHandler) HandleSetupToken(c *gin.Context) {
+	if err := h.openTokenModal(c.PostForm("trigger_id"), c.PostForm("user_id"), false); err != nil {
+		c.JSON(http.StatusOK, slashResponse{ResponseType: "ephemeral", Text: "❌ Failed to open the token setup dialog, please try again."})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// HandleSetupPersonalToken handles the POST request to /setup-personal-token. Without a token
+// it opens the token modal like /setup-token.
 func (h *SlackHandler) HandleSetupPersonalToken(c *gin.Context) {
 	userID := c.PostForm("user_id")
 	text := c.PostForm("text")
 	channelID := c.PostForm("channel_id")
 
+	if text == "" && c.PostForm("trigger_id") != "" {
+		h.HandleSetupToken(c)
+		return
+	}
+
 	if userID == "" || text == "" || channelID == "" {
 		logger.GetLogger().Error("missing required fields")
 		// _ = h.sendEphemeralSlackMessage(channelID, "Missing required fields", "")
@@ -29,7 +52,8 @@ func (h *SlackHandler) HandleSetupPersonalToken(c *gin.Context) {
 		return
 	}
 
-	if err := h.validateToken(text); err != nil {
+	displayName, err := h.validateToken(c.Request.Context(), text)
+	if err != nil {
 		logger.GetLogger().Error("invalid token", zap.Error(err))
 		// _ = h.sendEphemeralSlackMessage(channelID, err.Error(), "")
 		c.JSON(http.StatusOK, gin.H{"error": fmt.Sprintf("Validation failed due to %s", err.Error())})
@@ -48,17 +72,234 @@ func (h *SlackHandler) HandleSetupPersonalToken(c *gin.Context) {
 
 	// Return success response
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Token successfully stored",
+		"message": tokenStoredMessage(displayName) + ". Next time use /setup-token, which keeps the token out of your message history.",
 	})
 }
 
-func (h *SlackHandler) validateToken(token string) error {
+// validateToken checks the token with Jira and returns the display name of the account it
+// belongs to. Without JIRA_URL only the format is checked.
+func (h *SlackHandler) validateToken(ctx context.Context, token string) (string, error) {
 	// Validate token format
 	if len(token) < 8 {
-		logger.GetLogger().Error("token too short")
-		//_ = h.sendEphemeralSlackMessage(channelID, "Token must be at least 8 characters long", "")
+		return "", fmt.Errorf("token too short")
+	}
+	if h.jiraURL == "" {
+		return "", nil
+	}
+
+	client, err := jira.NewClient(h.jiraURL, token)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, jiraCommandTimeout)
+	defer cancel()
+	user, err := client.Myself(ctx)
+	if errors.Is(err, jira.ErrUnauthorized) {
+		return "", fmt.Errorf("Jira rejected the token, check that it is correct and has not expired")
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not verify the token with Jira: %v", err)
+	}
+	return user.DisplayName, nil
+}
 
-		return fmt.Errorf("token too short")
+// tokenStoredMessage confirms a stored token, naming the Jira account it was linked to
+func tokenStoredMessage(displayName string) string {
+	if displayName == "" {
+		return "Token successfully stored"
+	}
+	return fmt.Sprintf("Token successfully stored, linked to Jira account %s", displayName)
+}
+
+const (
+	// tokenModalActionID is the action of the button that opens the token setup modal
+	tokenModalActionID = "open_token_modal"
+	// tokenModalCallbackID identifies submissions of the token setup modal
+	tokenModalCallbackID = "setup_personal_token"
+
+	tokenInputBlockID  = "token_block"
+	tokenInputActionID = "token_input"
+
+	// tokenModalValidationTimeout leaves time to respond within the 3 seconds Slack waits for a
+	// view submission
+	tokenModalValidationTimeout = 2 * time.Second
+)
+
+// HandleInteraction handles the POST request from Slack interactive components
+func (h *SlackHandler) HandleInteraction(c *gin.Context) {
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal([]byte(c.PostForm("payload")), &callback); err != nil {
+		logger.GetLogger().Error("failed to parse interaction payload", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid interaction payload"})
+		return
+	}
+
+	if response := h.handleInteractionCallback(c.Request.Context(), callback); response != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// handleInteractionCallback handles an interactive component callback and returns the response
+// payload for Slack, or nil when there is nothing to send back
+func (h *SlackHandler) handleInteractionCallback(ctx context.Context, callback slack.InteractionCallback) *slack.ViewSubmissionResponse {
+	h.workspaces.remember(callback.Team.ID, callback.Channel.ID, callback.Container.ChannelID, callback.User.ID)
+	switch callback.Type {
+	case slack.InteractionTypeBlockActions:
+		for _, action := range callback.ActionCallback.BlockActions {
+			switch action.ActionID {
+			case tokenModalActionID:
+				_ = h.openTokenModal(callback.TriggerID, callback.User.ID, false)
+			case removeTokenActionID, keepTokenActionID:
+				h.handleRemoveTokenAction(callback, action)
+			case approveActionID, cancelActionID:
+				h.handleApprovalAction(callback, action)
+			case stopConversationActionID:
+				h.handleStopAction(callback, action)
+			case createSprintActionID, discardSprintActionID:
+				h.handleSprintPlanAction(callback, action)
+			case myIssuesActionID:
+				h.sendMyOpenIssues(callback.User.ID)
+			}
+		}
+	case slack.InteractionTypeMessageAction:
+		if callback.CallbackID == createTicketCallbackID {
+			h.handleCreateTicketShortcut(callback)
+		}
+	case slack.InteractionTypeViewSubmission:
+		switch callback.View.CallbackID {
+		case tokenModalCallbackID:
+			return h.handleTokenModalSubmission(ctx, callback)
+		case ticketOptionsCallbackID:
+			return h.handleTicketOptionsSubmission(callback)
+		case ticketFormCallbackID:
+			return h.handleTicketFormSubmission(callback)
+		}
 	}
 	return nil
 }
+
+// openTokenModal opens the modal for entering a personal Jira token. rotate marks the new token
+// as the replacement of a compromised one, which is audited.
+func (h *SlackHandler) openTokenModal(triggerID, userID string, rotate bool) error {
+	input := slack.NewPlainTextInputBlockElement(
+		slack.NewTextBlockObject(slack.PlainTextType, "Paste your Jira personal access token", false, false), tokenInputActionID)
+	title, metadata := "Personal Jira token", ""
+	if rotate {
+		title, metadata = "Rotate Jira token", rotateTokenMetadata
+	}
+	view := slack.ModalViewRequest{
+		Type:            slack.VTModal,
+		CallbackID:      tokenModalCallbackID,
+		Title:           slack.NewTextBlockObject(slack.PlainTextType, title, false, false),
+		Submit:          slack.NewTextBlockObject(slack.PlainTextType, "Save", false, false),
+		Close:           slack.NewTextBlockObject(slack.PlainTextType, "Cancel", false, false),
+		PrivateMetadata: metadata,
+		Blocks: slack.Blocks{BlockSet: []slack.Block{
+			slack.NewInputBlock(tokenInputBlockID,
+				slack.NewTextBlockObject(slack.PlainTextType, "Personal access token", false, false),
+				slack.NewTextBlockObject(slack.PlainTextType, h.tokenHint(), false, false),
+				input),
+		}},
+	}
+	if _, err := h.slackClient(userID).OpenView(triggerID, view); err != nil {
+		logger.GetLogger().Error("failed to open token modal", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// tokenHint tells the user where to create the personal access token the bot sends as a Bearer token
+func (h *SlackHandler) tokenHint() string {
+	if h.jiraURL == "" {
+		return "Create one in Jira under Profile > Personal Access Tokens. It is stored encrypted."
+	}
+	return fmt.Sprintf("Create one at %s/secure/ViewProfile.jspa?selectedTab=com.atlassian.pats.pats-plugin:jira-user-personal-access-tokens. It is stored encrypted.",
+		strings.TrimSuffix(h.jiraURL, "/"))
+}
+
+// handleTokenModalSubmission validates and stores the token entered in the modal. Slack waits
+// only 3 seconds for the response, so when Jira is slower the token is checked and stored in
+// the background and the user is told the outcome in a DM.
+func (h *SlackHandler) handleTokenModalSubmission(ctx context.Context, callback slack.InteractionCallback) *slack.ViewSubmissionResponse {
+	userID := callback.User.ID
+	token := callback.View.State.Values[tokenInputBlockID][tokenInputActionID].Value
+	rotate := callback.View.PrivateMetadata == rotateTokenMetadata
+
+	validateCtx, cancel := context.WithTimeout(ctx, tokenModalValidationTimeout)
+	defer cancel()
+	displayName, err := h.validateToken(validateCtx, token)
+	if err != nil && validateCtx.Err() == context.DeadlineExceeded {
+		logger.GetLogger().Info("validating token in the background", zap.String("user_id", userID))
+		go h.setTokenInBackground(userID, token, rotate)
+		return tokenModalResponse("⏳ Jira is taking a while to confirm the token. I will send you a message once it is checked and stored.")
+	}
+	if err != nil {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{
+			tokenInputBlockID: fmt.Sprintf("Validation failed due to %s", err.Error()),
+		})
+	}
+
+	if err := h.storeModalToken(ctx, userID, token, rotate); err != nil {
+		return slack.NewErrorsViewSubmissionResponse(map[string]string{
+			tokenInputBlockID: fmt.Sprintf("Failed to store token due to %s", err.Error()),
+		})
+	}
+	// Show which account was linked in place of the form
+	return tokenModalResponse(tokenModalMessage(displayName, rotate))
+}
+
+// setTokenInBackground validates and stores a token whose validation did not fit in the modal
+// response, and sends the user the outcome
+func (h *SlackHandler) setTokenInBackground(userID, token string, rotate bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), jiraCommandTimeout)
+	defer cancel()
+
+	displayName, err := h.validateToken(ctx, token)
+	text := tokenModalMessage(displayName, rotate)
+	if err != nil {
+		text = fmt.Sprintf("❌ Your Jira token was not stored, validation failed due to %s", err.Error())
+	} else if err := h.storeModalToken(ctx, userID, token, rotate); err != nil {
+		text = fmt.Sprintf("❌ Failed to store your Jira token due to %s", err.Error())
+	}
+	// Posting to a user ID sends the message to the user's DM with the app
+	if _, _, err := h.slackClient(userID).PostMessage(userID, slack.MsgOptionText(text, false)); err != nil {
+		logger.GetLogger().Error("failed to send token setup result", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// storeModalToken stores a validated token from the modal, auditing rotations
+func (h *SlackHandler) storeModalToken(ctx context.Context, userID, token string, rotate bool) error {
+	err := h.tokenStore.SetToken(userID, token)
+	if rotate {
+		h.auditTokenChange(ctx, userID, "rotate_personal_token", err)
+	}
+	if err != nil {
+		logger.GetLogger().Error("failed to store token", zap.Error(err))
+		return err
+	}
+	logger.GetLogger().Info("personal token stored from modal", zap.String("user_id", userID), zap.Bool("rotated", rotate))
+	return nil
+}
+
+// tokenModalMessage confirms a token stored from the modal
+func tokenModalMessage(displayName string, rotate bool) string {
+	message := "✅ " + tokenStoredMessage(displayName) + "."
+	if rotate {
+		message += "\nThe previous token is no longer used. Revoke it in Jira if it may have been exposed."
+	}
+	return message
+}
+
+// tokenModalResponse replaces the token form with the message
+func tokenModalResponse(message string) *slack.ViewSubmissionResponse {
+	return slack.NewUpdateViewSubmissionResponse(&slack.ModalViewRequest{
+		Type:  slack.VTModal,
+		Title: slack.NewTextBlockObject(slack.PlainTextType, "Personal Jira token", false, false),
+		Close: slack.NewTextBlockObject(slack.PlainTextType, "Done", false, false),
+		Blocks: slack.Blocks{BlockSet: []slack.Block{
+			markdownSection(message),
+		}},
+	})
+}
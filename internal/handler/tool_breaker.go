@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// toolTimeoutOf returns how long a call of the tool may take, 0 for no limit
+func (h *SlackHandler) toolTimeoutOf(name string) time.Duration {
+	if timeout, ok := h.toolTimeouts[name]; ok {
+		return timeout
+	}
+	return h.toolTimeout
+}
+
+// circuitKey identifies the circuit of a tool. Instances have their own circuits, a failing
+// sandbox does not make the tool unavailable on the primary Jira.
+func circuitKey(instance, tool string) string {
+	if instance == "" {
+		return tool
+	}
+	return instance + "/" + tool
+}
+
+// toolBreakerMiddleware bounds each tool call by its timeout and refuses calls of tools that
+// failed repeatedly, so a hanging or broken tool cannot use up the conversation. Only failures that
+// may go away by themselves count, such as timeouts and unavailable servers; a missing issue says
+// nothing about the tool's health.
+func (h *SlackHandler) toolBreakerMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, call *ToolCall) (*mcp.CallToolResult, error) {
+		name := call.Call.Name
+		key := circuitKey(call.conv.JiraInstance, name)
+		if ok, wait := h.toolBreaker.Allow(key); !ok {
+			err := fmt.Errorf("the tool %s is temporarily unavailable after repeated failures, it is tried again in %s. "+
+				"Do not call it again in this conversation: use another tool or tell the user it is unavailable", name, wait.Round(time.Second))
+			return nil, &Refusal{Notice: fmt.Sprintf("⚡ %s is temporarily unavailable", name), Err: err}
+		}
+
+		callCtx := ctx
+		timeout := h.toolTimeoutOf(name)
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			callCtx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		result, err := next(callCtx, call)
+
+		var refusal *Refusal
+		switch {
+		case ctx.Err() != nil || errors.As(err, &refusal):
+			// Cancelled or refused calls say nothing about the tool
+			return result, err
+		case callCtx.Err() != nil:
+			err = fmt.Errorf("%s timed out after %s", name, timeout)
+			if call.Write {
+				err = fmt.Errorf("%v, the change may or may not have been made: check before trying again", err)
+			}
+			result = nil
+		}
+
+		if (err != nil || (result != nil && result.IsError)) && classifyToolFailure(result, err) != toolFailurePermanent {
+			if h.toolBreaker.Failure(key) {
+				logger.GetLogger().Warn("tool circuit opened after repeated failures",
+					zap.String("tool", name),
+					zap.String("jira_instance", call.conv.JiraInstance),
+					zap.Error(toolCallError(result, err)))
+			}
+		} else {
+			h.toolBreaker.Success(key)
+		}
+		return result, err
+	}
+}
+
+// openCircuits returns the tools whose circuit is open, for the readiness report
+func (h *SlackHandler) openCircuits() []string {
+	keys := h.toolBreaker.Open()
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/openai"
+	"jira_helper/internal/storage"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// maxCachedResults bounds the in-memory tool cache
+const maxCachedResults = 500
+
+// cacheableToolPrefixes are the read tools whose results are cached
+var cacheableToolPrefixes = []string{"jira_get_", "jira_search", "confluence_get_", "confluence_search"}
+
+// toolCache keeps the results of read tool calls for a short time, in memory and optionally in a
+// store shared by every instance. Results are keyed by the token, so users only see what they may.
+type toolCache struct {
+	ttl   time.Duration
+	store storage.ToolCacheStore // Optional: shared between instances
+
+	mu      sync.Mutex
+	entries map[string]cachedToolResult
+}
+
+// cachedToolResult is a tool result in the in-memory cache
+type cachedToolResult struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// newToolCache creates a cache keeping results for ttl
+func newToolCache(ttl time.Duration, store storage.ToolCacheStore) *toolCache {
+	return &toolCache{ttl: ttl, store: store, entries: map[string]cachedToolResult{}}
+}
+
+// toolCacheKey identifies a tool call by the token, the tool and its arguments. Arguments are
+// marshaled with sorted keys, so the same arguments in another order share the entry.
+func toolCacheKey(token string, toolCall openai.ToolCall) (string, bool) {
+	args, err := json.Marshal(toolCall.Args)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(tokenHash(token) + "\x00" + toolCall.Name + "\x00" + string(args)))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// get returns the cached result of the key, looking in the shared store on a local miss
+func (c *toolCache) get(ctx context.Context, key string) (*mcp.CallToolResult, string) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return decodeToolResult(entry.data), "memory"
+	}
+	if c.store == nil {
+		return nil, ""
+	}
+	data, err := c.store.Get(ctx, key)
+	if err != nil {
+		logger.GetLogger().Warn("failed to read the tool cache", zap.Error(err))
+		return nil, ""
+	}
+	if data == nil {
+		return nil, ""
+	}
+	c.remember(key, data)
+	return decodeToolResult(data), "store"
+}
+
+// put caches a result in memory and in the shared store
+func (c *toolCache) put(ctx context.Context, key string, result *mcp.CallToolResult) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	c.remember(key, data)
+	if c.store != nil {
+		if err := c.store.Put(ctx, key, data, c.ttl); err != nil {
+			logger.GetLogger().Warn("failed to write the tool cache", zap.Error(err))
+		}
+	}
+}
+
+// remember keeps a result in memory, dropping expired results when the cache is full
+func (c *toolCache) remember(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCachedResults {
+		now := time.Now()
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) || len(c.entries) >= maxCachedResults {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[key] = cachedToolResult{data: data, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// decodeToolResult restores a cached result, nil if it cannot be read
+func decodeToolResult(data []byte) *mcp.CallToolResult {
+	raw := json.RawMessage(data)
+	result, err := mcp.ParseCallToolResult(&raw)
+	if err != nil {
+		return nil
+	}
+	return result
+}
+
+// cacheableTool reports whether the tool only reads data and its results may be cached
+func (h *SlackHandler) cacheableTool(name string) bool {
+	if h.toolPolicy.IsWrite(name) {
+		return false
+	}
+	for _, prefix := range cacheableToolPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// toolCacheMiddleware answers read tools from the cache when possible. Once the conversation has
+// written to Jira, reads go to Jira again so the model sees its changes.
+func (h *SlackHandler) toolCacheMiddleware(next ToolHandler) ToolHandler {
+	return func(ctx context.Context, call *ToolCall) (*mcp.CallToolResult, error) {
+		if _, local := h.localTool(call.Call.Name); local || h.toolCache == nil || !h.cacheableTool(call.Call.Name) {
+			return next(ctx, call)
+		}
+		key, ok := toolCacheKey(call.userToken, call.Call)
+		if !ok {
+			return next(ctx, call)
+		}
+
+		if !call.conv.Wrote {
+			if cached, source := h.toolCache.get(ctx, key); cached != nil {
+				logger.GetLogger().Debug("tool cache hit", zap.String("tool", call.Call.Name), zap.String("source", source))
+				trace.SpanFromContext(ctx).SetAttributes(attribute.String("mcp.cache", source))
+				return cached, nil
+			}
+		}
+		logger.GetLogger().Debug("tool cache miss", zap.String("tool", call.Call.Name), zap.Bool("after_write", call.conv.Wrote))
+
+		result, err := next(ctx, call)
+		if err == nil && result != nil && !result.IsError {
+			h.toolCache.put(ctx, key, result)
+		}
+		return result, err
+	}
+}
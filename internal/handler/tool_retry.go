@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"regexp"
+	"syscall"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+const (
+	// toolCallAttempts is how often a tool call is tried before its failure reaches the model
+	toolCallAttempts = 3
+	// toolRetryBaseDelay is the wait before the first retry, doubled for each further one
+	toolRetryBaseDelay = 500 * time.Millisecond
+	// toolRetryMaxDelay caps the wait between retries
+	toolRetryMaxDelay = 5 * time.Second
+)
+
+var (
+	// toolRejectedPattern matches failures where Jira or the MCP server refused the call before
+	// running it, so it is safe to retry even writes
+	toolRejectedPattern = regexp.MustCompile(`(?i)` + httpStatusPattern("429|503") + `|too many requests|rate.?limit|service unavailable`)
+	// toolTransientPattern matches failures that may go away by themselves but leave it unknown
+	// whether the call took effect
+	toolTransientPattern = regexp.MustCompile(`(?i)` + httpStatusPattern("502|504") + `|bad gateway|gateway time-?out|timed? ?out|connection (reset|refused)|broken pipe`)
+)
+
+// toolFailure classifies a failed tool call for retrying
+type toolFailure int
+
+const (
+	toolFailurePermanent toolFailure = iota // Retrying gives the same result
+	toolFailureRejected                     // Refused before it ran, safe to retry
+	toolFailureTransient                    // May have run, safe to retry only when it does not write
+)
+
+// classifyToolFailure tells whether a failed tool call may succeed when tried again. Results
+// reporting an error are classified by their text, as the MCP server passes on Jira's response.
+func classifyToolFailure(result *mcp.CallToolResult, err error) toolFailure {
+	var text string
+	switch {
+	case err != nil:
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, io.ErrUnexpectedEOF) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return toolFailureTransient
+		}
+		text = err.Error()
+	case result != nil && result.IsError:
+		text = printToolResult(result)
+	default:
+		return toolFailurePermanent
+	}
+
+	switch {
+	case toolRejectedPattern.MatchString(text):
+		return toolFailureRejected
+	case toolTransientPattern.MatchString(text):
+		return toolFailureTransient
+	default:
+		return toolFailurePermanent
+	}
+}
+
+// callToolWithRetry calls the tool and retries transient failures with exponential backoff and
+// jitter. Writes are only retried when the call was refused, a timed out write may have been
+// applied already. The last failure is returned once the attempts are used up.
+func callToolWithRetry(ctx context.Context, mcpClient ToolCaller, request mcp.CallToolRequest, isWrite bool) (*mcp.CallToolResult, error) {
+	delay := toolRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		result, err := mcpClient.CallTool(ctx, request)
+		if err == nil && (result == nil || !result.IsError) {
+			return result, nil
+		}
+
+		failure := classifyToolFailure(result, err)
+		retryable := failure == toolFailureRejected || (failure == toolFailureTransient && !isWrite)
+		// The conversation's own deadline or cancellation is not something to wait out
+		if !retryable || attempt == toolCallAttempts || ctx.Err() != nil {
+			return result, err
+		}
+
+		// Wait between half and all of the delay, so parallel conversations do not retry in step
+		wait := delay/2 + rand.N(delay/2)
+		logger.GetLogger().Warn("tool call failed, retrying",
+			zap.String("tool", request.Params.Name),
+			zap.Int("attempt", attempt),
+			zap.Duration("wait", wait),
+			zap.Error(toolCallError(result, err)))
+
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(wait):
+		}
+		delay = min(delay*2, toolRetryMaxDelay)
+	}
+}
+
+// toolCallError describes a failed tool call for logging
+func toolCallError(result *mcp.CallToolResult, err error) error {
+	if err != nil {
+		return err
+	}
+	return errors.New(printToolResult(result))
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"jira_helper/internal/metrics"
+	"jira_helper/internal/service/openai"
+
+	"github.com/gin-gonic/gin"
+)
+
+// usageKey is the context key of the usage counted for the running conversation
+type usageKey struct{}
+
+// requestUsage counts the AI tokens and tool calls of a conversation run
+type requestUsage struct {
+	mu               sync.Mutex
+	promptTokens     int
+	completionTokens int
+	responses        int
+	toolCalls        int
+	toolErrors       int
+}
+
+// usageFrom returns the usage counted for the running conversation, or nil outside of one
+func usageFrom(ctx context.Context) *requestUsage {
+	usage, _ := ctx.Value(usageKey{}).(*requestUsage)
+	return usage
+}
+
+// addResponse counts a model response and its tokens
+func (u *requestUsage) addResponse(response *openai.ChatResponse) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.responses++
+	u.promptTokens += response.PromptTokens
+	u.completionTokens += response.CompletionTokens
+}
+
+// addToolCall counts a tool call and whether it failed
+func (u *requestUsage) addToolCall(failed bool) {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.toolCalls++
+	if failed {
+		u.toolErrors++
+	}
+}
+
+// trackUsage counts the usage of a conversation run. The returned function records it with the
+// run's outcome once the run is over.
+func (h *SlackHandler) trackUsage(ctx context.Context, conv *conversation) (context.Context, func(err error)) {
+	if h.metrics == nil {
+		return ctx, func(error) {}
+	}
+	usage := &requestUsage{}
+	started := time.Now()
+	return context.WithValue(ctx, usageKey{}, usage), func(err error) {
+		usage.mu.Lock()
+		request := metrics.Request{
+			ConversationID:   conv.ID,
+			UserID:           conv.UserID,
+			ChannelID:        conv.ChannelID,
+			PromptTokens:     usage.promptTokens,
+			CompletionTokens: usage.completionTokens,
+			ToolCalls:        usage.toolCalls,
+			ToolErrors:       usage.toolErrors,
+			Rounds:           usage.responses,
+			LatencyMs:        time.Since(started).Milliseconds(),
+			Failed:           err != nil,
+		}
+		usage.mu.Unlock()
+		// The request context may be done already, the metrics are still worth keeping
+		h.metrics.Record(context.WithoutCancel(ctx), request)
+	}
+}
+
+// HandleMetrics serves the usage totals of this process in the Prometheus text format
+func (h *SlackHandler) HandleMetrics(c *gin.Context) {
+	if h.metricsRegistry == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "metrics are not enabled"})
+		return
+	}
+	c.Header("Content-Type", "text/plain; version=0.0.4")
+	c.Status(http.StatusOK)
+	_ = h.metricsRegistry.WriteText(c.Writer)
+}
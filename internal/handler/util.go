@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"jira_helper/internal/i18n"
 	"jira_helper/internal/logger"
 	"strings"
 
@@ -65,5 +67,16 @@ func printToolResult(result *mcp.CallToolResult) string {
 	return ""
 }
 
-// add error emoji to the error message
-const defaultErrorMessage = "❌ Something went wrong while processing your request. Please try again later or contact <@U0ZGB1ZLP> for help. ```Error: %s```"
+// projectKeyArg returns the project_key argument of a tool call, if any
+func projectKeyArg(args map[string]interface{}) string {
+	projectKey, _ := args["project_key"].(string)
+	return projectKey
+}
+
+// restrictedDataMessage replaces tool results that contain data from restricted projects
+const restrictedDataMessage = "🔒 This result contains data from restricted Jira project(s) %s, which cannot be shown in this channel. Tell the user to ask in an approved channel instead."
+
+// errorMessage tells the user in the conversation's language that their request failed
+func errorMessage(ctx context.Context, err error) string {
+	return i18n.T(i18n.FromContext(ctx), i18n.Error, err.Error())
+}
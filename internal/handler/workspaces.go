@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"sync"
+	"time"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+// workspaceCacheTTL is how long a loaded installation, or the lack of one, is reused before the
+// store is asked again, so installations made through another instance are picked up
+const workspaceCacheTTL = 5 * time.Minute
+
+// cachedWorkspace is a loaded installation, nil if the team has none
+type cachedWorkspace struct {
+	workspace *storage.Workspace
+	client    *slack.Client
+	loadedAt  time.Time
+}
+
+// workspaceRegistry hands out the Slack client of the workspace a channel or user belongs to.
+// Slack IDs are unique across workspaces, so the team of each channel and user seen in an
+// event, interaction or command is remembered and later calls use that team's bot token.
+type workspaceRegistry struct {
+	store   storage.WorkspaceStore
+	options []slack.Option
+
+	mu     sync.RWMutex
+	teams  map[string]string          // Channel or user ID -> team ID
+	loaded map[string]cachedWorkspace // Team ID -> installation
+}
+
+// newWorkspaceRegistry creates a registry for the workspaces in the store
+func newWorkspaceRegistry(store storage.WorkspaceStore, options []slack.Option) *workspaceRegistry {
+	return &workspaceRegistry{
+		store:   store,
+		options: options,
+		teams:   map[string]string{},
+		loaded:  map[string]cachedWorkspace{},
+	}
+}
+
+// remember records the team of the channel and user IDs
+func (r *workspaceRegistry) remember(teamID string, ids ...string) {
+	if r == nil || teamID == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		if id != "" {
+			r.teams[id] = teamID
+		}
+	}
+}
+
+// teamOf returns the team of a channel or user ID, or "" if none was seen
+func (r *workspaceRegistry) teamOf(id string) string {
+	if r == nil {
+		return ""
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.teams[id]
+}
+
+// load returns the cached installation of the team, loading it from the store when it is
+// missing or stale
+func (r *workspaceRegistry) load(teamID string) cachedWorkspace {
+	if r == nil || teamID == "" {
+		return cachedWorkspace{}
+	}
+	r.mu.RLock()
+	cached, ok := r.loaded[teamID]
+	r.mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < workspaceCacheTTL {
+		return cached
+	}
+
+	workspace, err := r.store.GetWorkspace(teamID)
+	if err != nil {
+		// Keep using what was loaded before, the next call tries again
+		logger.GetLogger().Error("failed to load workspace", zap.String("team_id", teamID), zap.Error(err))
+		return cached
+	}
+	return r.cache(teamID, workspace)
+}
+
+// cache keeps the installation of the team together with a client for its bot token
+func (r *workspaceRegistry) cache(teamID string, workspace *storage.Workspace) cachedWorkspace {
+	cached := cachedWorkspace{workspace: workspace, loadedAt: time.Now()}
+	if workspace != nil {
+		cached.client = slack.New(workspace.BotToken, r.options...)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loaded[teamID] = cached
+	return cached
+}
+
+// clientFor returns the client of the workspace of a channel or user ID, or nil when the ID
+// belongs to the workspace of the configured bot token
+func (r *workspaceRegistry) clientFor(id string) *slack.Client {
+	return r.load(r.teamOf(id)).client
+}
+
+// botUserIDFor returns the bot's user ID in the workspace of a channel, or "" when the channel
+// belongs to the workspace of the configured bot token
+func (r *workspaceRegistry) botUserIDFor(channelID string) string {
+	if workspace := r.load(r.teamOf(channelID)).workspace; workspace != nil {
+		return workspace.BotUserID
+	}
+	return ""
+}
+
+// install stores a new or renewed installation and uses it right away
+func (r *workspaceRegistry) install(workspace *storage.Workspace) error {
+	if err := r.store.SaveWorkspace(workspace); err != nil {
+		return err
+	}
+	r.cache(workspace.TeamID, workspace)
+	return nil
+}
+
+// uninstall forgets a workspace the app was removed from
+func (r *workspaceRegistry) uninstall(teamID string) error {
+	if r == nil {
+		return nil
+	}
+	if err := r.store.DeleteWorkspace(teamID); err != nil {
+		return err
+	}
+	r.cache(teamID, nil)
+	return nil
+}
+
+// TrackWorkspace remembers the workspace of the user and channel of a slash command, so the
+// command is answered with that workspace's bot token
+func (h *SlackHandler) TrackWorkspace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.workspaces != nil && c.ContentType() == gin.MIMEPOSTForm {
+			h.workspaces.remember(c.PostForm("team_id"), c.PostForm("channel_id"), c.PostForm("user_id"))
+		}
+		c.Next()
+	}
+}
+
+// handleAppUninstalled removes the bot token of a workspace the app was uninstalled from
+func (h *SlackHandler) handleAppUninstalled(teamID string) {
+	if err := h.workspaces.uninstall(teamID); err != nil {
+		logger.GetLogger().Error("failed to remove uninstalled workspace", zap.String("team_id", teamID), zap.Error(err))
+		return
+	}
+	logger.GetLogger().Info("app uninstalled from workspace", zap.String("team_id", teamID))
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"jira_helper/internal/anomaly"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/openai"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// projectOfToolCall returns the Jira project a tool call targets, from its project_key
+// argument or the prefix of its issue_key argument
+func projectOfToolCall(args map[string]interface{}) string {
+	if projectKey := projectKeyArg(args); projectKey != "" {
+		return strings.ToUpper(projectKey)
+	}
+	issueKey, _ := args["issue_key"].(string)
+	if i := strings.LastIndex(issueKey, "-"); i > 0 {
+		return strings.ToUpper(issueKey[:i])
+	}
+	return ""
+}
+
+// checkWriteBurst returns an error if writes by the user or to the tool call's project are paused
+func (h *SlackHandler) checkWriteBurst(ctx context.Context, userID string, toolCall openai.ToolCall) error {
+	return h.burstDetector.Check(ctx, userID, projectOfToolCall(toolCall.Args))
+}
+
+// observeWrite records a write operation and alerts admins when it trips the burst threshold
+func (h *SlackHandler) observeWrite(ctx context.Context, userID, channelID string, toolCall openai.ToolCall) {
+	tripped, err := h.burstDetector.Observe(ctx, userID, projectOfToolCall(toolCall.Args))
+	if err != nil {
+		logger.GetLogger().Error("failed to record write for burst detection", zap.String("user_id", userID), zap.Error(err))
+	}
+	if len(tripped) == 0 {
+		return
+	}
+
+	logger.GetLogger().Warn("write burst detected, pausing writes",
+		zap.Strings("subjects", tripped),
+		zap.String("user_id", userID),
+		zap.String("channel", channelID),
+		zap.String("tool", toolCall.Name))
+
+	alert := fmt.Sprintf("🚨 Unusual burst of Jira writes detected for %s (last action `%s` by <@%s> in <#%s>). Further writes are paused until approved with `/jira-admin approve-writes <subject>`.",
+		strings.Join(tripped, ", "), toolCall.Name, userID, channelID)
+	h.alertAdmins(alert)
+}
+
+// alertAdmins sends a direct message to every configured admin
+func (h *SlackHandler) alertAdmins(message string) {
+	for _, adminID := range h.adminUserIDs {
+		if _, err := h.sendMarkdownMessage(adminID, message, ""); err != nil {
+			logger.GetLogger().Error("failed to alert admin", zap.String("admin_id", adminID), zap.Error(err))
+		}
+	}
+}
+
+// adminApproveWrites lifts a write pause, e.g. `approve-writes user:U123` or `approve-writes project:PROJ`
+func (h *SlackHandler) adminApproveWrites(c *gin.Context, args []string) (string, error) {
+	ctx := c.Request.Context()
+	if len(args) == 0 {
+		paused, err := h.burstDetector.Paused(ctx)
+		if err != nil {
+			return "", err
+		}
+		if len(paused) == 0 {
+			return "No writes are currently paused", nil
+		}
+		return fmt.Sprintf("Paused: %s\nUsage: `approve-writes <subject>`", strings.Join(paused, ", ")), nil
+	}
+
+	subject := args[0]
+	if !strings.HasPrefix(subject, "user:") && !strings.HasPrefix(subject, "project:") {
+		// Accept bare project keys for convenience
+		subject = anomaly.ProjectSubject(strings.ToUpper(subject))
+	}
+	approved, err := h.burstDetector.Approve(ctx, subject)
+	if err != nil {
+		return "", err
+	}
+	if !approved {
+		return fmt.Sprintf("%s is not paused", subject), nil
+	}
+	return fmt.Sprintf("✅ Writes for %s approved again", subject), nil
+}
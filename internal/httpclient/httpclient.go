@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// SlackTimeout bounds a single Slack Web API call
+	SlackTimeout = 30 * time.Second
+	// AITimeout bounds a single chat completion, which can take a while for long tool conversations
+	AITimeout = 2 * time.Minute
+	// AWSTimeout bounds direct calls to AWS APIs
+	AWSTimeout = 10 * time.Second
+	// JiraTimeout bounds a single Jira REST API call, searches over large projects can be slow
+	JiraTimeout = 30 * time.Second
+	// GitHubTimeout bounds a single GitHub REST API call
+	GitHubTimeout = 15 * time.Second
+	// PagerDutyTimeout bounds a single PagerDuty REST API call
+	PagerDutyTimeout = 15 * time.Second
+	// OpsgenieTimeout bounds a single Opsgenie REST API call
+	OpsgenieTimeout = 15 * time.Second
+	// GoogleChatTimeout bounds a single Google Chat API call or certificate fetch
+	GoogleChatTimeout = 15 * time.Second
+	// ConfluenceTimeout bounds a single Confluence REST API call
+	ConfluenceTimeout = 30 * time.Second
+)
+
+var (
+	// transports are shared by all clients with the same timeout, so connections to the same host
+	// are reused across them
+	transports   = map[time.Duration]*http.Transport{}
+	transportsMu sync.Mutex
+)
+
+// transportFor returns the pooled transport that waits at most timeout for response headers
+func transportFor(timeout time.Duration) *http.Transport {
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	if transport, ok := transports[timeout]; ok {
+		return transport
+	}
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment, // HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ResponseHeaderTimeout: timeout,
+	}
+	transports[timeout] = transport
+	return transport
+}
+
+// New returns a client on a shared pooled transport whose requests, including reading the
+// body, must complete within timeout
+func New(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: transportFor(timeout),
+		Timeout:   timeout,
+	}
+}
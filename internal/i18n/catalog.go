@@ -0,0 +1,27 @@
+package i18n
+
+// Key identifies a message in the catalogs
+type Key string
+
+// Messages shown to users while their requests are processed
+const (
+	Analyzing     Key = "analyzing"       // Progress message of a new request
+	CallingTool   Key = "calling_tool"    // %s: tool name
+	StopPrompt    Key = "stop_prompt"     // Text next to the Stop button
+	StopButton    Key = "stop_button"     // Label of the Stop button
+	SetTokenFirst Key = "set_token_first" // %s: write tool that needs a personal token
+	MaxRounds     Key = "max_rounds"      // The conversation reached the round limit
+	QuotaExceeded Key = "quota_exceeded"  // %s: quota, %s: wait until it resets
+	Error         Key = "error"           // %s: error
+	ShuttingDown  Key = "shutting_down"   // A request arrived while shutting down
+	Interrupted   Key = "interrupted"     // A running request was interrupted by a shutdown
+	EventRetrying Key = "event_retrying"  // %d: attempt, %d: attempts, %s: event ID
+	EventKept     Key = "event_kept"      // %s: event ID of a failed message kept for admins to replay
+	EventFailed   Key = "event_failed"    // %s: event ID of a failed message that could not be kept
+)
+
+// catalogs holds the messages of each language
+var catalogs = map[Lang]map[Key]string{
+	English: english,
+	Chinese: chinese,
+}
@@ -0,0 +1,18 @@
+package i18n
+
+// english is the catalog of English messages, every key has one
+var english = map[Key]string{
+	Analyzing:     "⏳ Analyzing your request to determine the best way to help you...",
+	CallingTool:   "🔄 _Calling Tool *%s*_",
+	StopPrompt:    "Working on it. Reply `stop` in this thread to cancel.",
+	StopButton:    "Stop",
+	SetTokenFirst: "❌ Permission denied. You should set your personal token first to use `%s`",
+	MaxRounds:     "⚠️ Reached maximum number of steps. Providing partial response based on current progress...",
+	QuotaExceeded: "⏳ You've hit your quota of %s. Please try again in %s — if you need more, ask an admin.",
+	Error:         "❌ Something went wrong while processing your request. Please try again later or contact <@U0ZGB1ZLP> for help. ```Error: %s```",
+	ShuttingDown:  "🔄 Jira helper is restarting. Please send your message again in a moment.",
+	Interrupted:   "⚠️ Jira helper was restarted before it could finish. Steps shown above have already been applied — reply in this thread to continue from here.",
+	EventRetrying: "⚠️ I couldn't finish processing this message (attempt %d of %d). It will be retried automatically. Reference: `%s`",
+	EventKept:     "⚠️ I couldn't process this message. It has been kept for an admin to retry, you don't need to send it again. Reference: `%s`",
+	EventFailed:   "⚠️ I couldn't process this message, please send it again in a moment. If it keeps failing, give an admin this reference: `%s`",
+}
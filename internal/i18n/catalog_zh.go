@@ -0,0 +1,18 @@
+package i18n
+
+// chinese is the catalog of Simplified Chinese messages
+var chinese = map[Key]string{
+	Analyzing:     "⏳ 正在分析你的请求，确定最合适的处理方式...",
+	CallingTool:   "🔄 _正在调用工具 *%s*_",
+	StopPrompt:    "正在处理。在此线程中回复 `stop` 可以取消。",
+	StopButton:    "停止",
+	SetTokenFirst: "❌ 权限不足。请先设置个人 Token 才能使用 `%s`",
+	MaxRounds:     "⚠️ 已达到最大步骤数，将根据当前进度给出部分回复...",
+	QuotaExceeded: "⏳ 你已用完 %s 的配额，请在 %s 后重试。如需更多配额，请联系管理员。",
+	Error:         "❌ 处理请求时出错，请稍后重试或联系 <@U0ZGB1ZLP> 获取帮助。```Error: %s```",
+	ShuttingDown:  "🔄 Jira helper 正在重启，请稍后重新发送消息。",
+	Interrupted:   "⚠️ Jira helper 在完成前被重启。上方显示的步骤已经执行，在此线程中回复即可从这里继续。",
+	EventRetrying: "⚠️ 处理这条消息时出错（第 %d 次，共 %d 次），稍后会自动重试。参考编号：`%s`",
+	EventKept:     "⚠️ 无法处理这条消息，消息已保留等待管理员重试，无需重新发送。参考编号：`%s`",
+	EventFailed:   "⚠️ 无法处理这条消息，请稍后重新发送。如果仍然失败，请将参考编号告知管理员：`%s`",
+}
@@ -0,0 +1,97 @@
+// Package i18n detects the language users write in and holds the messages shown to them in
+// each supported language.
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Lang is a supported language, as an ISO 639-1 code
+type Lang string
+
+const (
+	English Lang = "en"
+	Chinese Lang = "zh"
+)
+
+// ignored matches Slack mentions, links and channel references, and issue keys, which are not
+// the user's words
+var ignored = regexp.MustCompile(`<[^>]*>|\b[A-Z][A-Z0-9_]+-\d+\b`)
+
+// Name returns the language's English name, which the model is told to reply in
+func (l Lang) Name() string {
+	switch l {
+	case Chinese:
+		return "Simplified Chinese"
+	default:
+		return "English"
+	}
+}
+
+// Parse returns the supported language of the ISO 639-1 code, and false for other codes
+func Parse(code string) (Lang, bool) {
+	switch lang := Lang(strings.ToLower(strings.TrimSpace(code))); lang {
+	case English, Chinese:
+		return lang, true
+	default:
+		return "", false
+	}
+}
+
+// Detect returns the language of the text, and false when it has too few words to tell, e.g.
+// only an issue key or a mention. Han characters are compared with Latin words, so names and
+// English terms mixed into a Chinese sentence do not outweigh it.
+func Detect(text string) (Lang, bool) {
+	var han, words int
+	inWord := false
+	for _, r := range ignored.ReplaceAllString(text, " ") {
+		isLatin := r < unicode.MaxASCII && unicode.IsLetter(r)
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case isLatin && !inWord:
+			words++
+		}
+		inWord = isLatin || (inWord && r == '\'')
+	}
+	switch {
+	case han >= 2 && han*2 >= words:
+		return Chinese, true
+	case words >= 2:
+		return English, true
+	default:
+		return English, false
+	}
+}
+
+type contextKey struct{}
+
+// WithLang returns a context carrying the language of the conversation
+func WithLang(ctx context.Context, lang Lang) context.Context {
+	return context.WithValue(ctx, contextKey{}, lang)
+}
+
+// FromContext returns the language of the conversation, English if none was detected
+func FromContext(ctx context.Context) Lang {
+	if lang, ok := ctx.Value(contextKey{}).(Lang); ok && lang != "" {
+		return lang
+	}
+	return English
+}
+
+// T returns the message in the language, formatted with args. Messages missing from a
+// catalog fall back to English.
+func T(lang Lang, key Key, args ...interface{}) string {
+	message, ok := catalogs[lang][key]
+	if !ok {
+		message = catalogs[English][key]
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
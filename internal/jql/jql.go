@@ -0,0 +1,227 @@
+package jql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Quote returns the value as a JQL string literal, escaping quotes and backslashes
+// so user text can never terminate the literal and inject clauses
+func Quote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ", "\r", " ")
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// QuoteList returns the values as a JQL list, e.g. ("A", "B")
+func QuoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = Quote(value)
+	}
+	return "(" + strings.Join(quoted, ", ") + ")"
+}
+
+// token is a lexical element of a JQL query
+type token struct {
+	text   string
+	quoted bool
+	depth  int // parenthesis depth the token appears at
+	offset int // byte offset of the token in the query
+}
+
+// tokenize splits a JQL query into words, operators and string literals
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	depth := 0
+	for i := 0; i < len(query); {
+		ch := query[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '"' || ch == '\'':
+			start := i
+			i++
+			for i < len(query) && query[i] != ch {
+				if query[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(query) {
+				return nil, fmt.Errorf("unterminated string literal at position %d", start)
+			}
+			i++
+			tokens = append(tokens, token{text: query[start:i], quoted: true, depth: depth, offset: start})
+		case ch == '(':
+			tokens = append(tokens, token{text: "(", depth: depth, offset: i})
+			depth++
+			i++
+		case ch == ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parenthesis at position %d", i)
+			}
+			tokens = append(tokens, token{text: ")", depth: depth, offset: i})
+			i++
+		default:
+			start := i
+			for i < len(query) && !strings.ContainsRune(" \t\n\r()\"'", rune(query[i])) {
+				i++
+			}
+			tokens = append(tokens, token{text: query[start:i], depth: depth, offset: start})
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parenthesis")
+	}
+	return tokens, nil
+}
+
+// isKeyword reports whether the unquoted token is the given JQL keyword
+func (t token) isKeyword(keyword string) bool {
+	return !t.quoted && strings.EqualFold(t.text, keyword)
+}
+
+// orderByIndex returns the index of the top-level ORDER BY clause, or -1
+func orderByIndex(tokens []token) int {
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i].depth == 0 && tokens[i].isKeyword("order") && tokens[i+1].isKeyword("by") {
+			return i
+		}
+	}
+	return -1
+}
+
+// ValidateFragment checks user-supplied text that will be combined with other JQL.
+// It rejects fragments that could change the meaning of the surrounding query:
+// unbalanced quotes or parentheses, ORDER BY clauses and top-level OR that would widen the scope.
+func ValidateFragment(fragment string) error {
+	tokens, err := tokenize(fragment)
+	if err != nil {
+		return err
+	}
+	for i, t := range tokens {
+		if t.isKeyword("order") && i+1 < len(tokens) && tokens[i+1].isKeyword("by") {
+			return fmt.Errorf("ORDER BY is not allowed in a JQL fragment")
+		}
+		if t.depth == 0 && t.isKeyword("or") {
+			return fmt.Errorf("top-level OR is not allowed in a JQL fragment, wrap alternatives in parentheses")
+		}
+	}
+	return nil
+}
+
+// Scope restricts a JQL query to the given projects. Any top-level ORDER BY clause is
+// kept at the end so it cannot be used to escape the project restriction.
+func Scope(query string, projects []string) (string, error) {
+	if len(projects) == 0 {
+		return query, nil
+	}
+	return restrict(query, "project in "+QuoteList(projects))
+}
+
+// Exclude keeps the given projects out of a JQL query, whatever projects, parents or links the
+// query names. Like Scope, any top-level ORDER BY clause is kept at the end.
+func Exclude(query string, projects []string) (string, error) {
+	if len(projects) == 0 {
+		return query, nil
+	}
+	return restrict(query, "project not in "+QuoteList(projects))
+}
+
+// restrict combines a JQL query with a clause every result must match
+func restrict(query, clause string) (string, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return "", err
+	}
+
+	where, order := strings.TrimSpace(query), ""
+	if i := orderByIndex(tokens); i >= 0 {
+		for _, t := range tokens[i:] {
+			if t.isKeyword("and") || t.isKeyword("or") || t.text == "(" {
+				return "", fmt.Errorf("unexpected clause after ORDER BY")
+			}
+		}
+		where = strings.TrimSpace(query[:tokens[i].offset])
+		order = " " + strings.TrimSpace(query[tokens[i].offset:])
+	}
+
+	scoped := clause
+	if where != "" {
+		scoped += " AND (" + where + ")"
+	}
+	return scoped + order, nil
+}
+
+// FieldNames returns the fields a JQL query refers to in its clauses and ORDER BY, in the
+// order they first appear. Quoted names are returned without their quotes.
+func FieldNames(query string) ([]string, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	add := func(t token) {
+		name := t.text
+		if t.quoted {
+			name = unquote(name)
+		} else if i := strings.IndexAny(name, "=!<>~,"); i >= 0 {
+			name = name[:i]
+		}
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	order := orderByIndex(tokens)
+	clauses := tokens
+	if order >= 0 {
+		clauses = tokens[:order]
+	}
+	expectField := true
+	for _, t := range clauses {
+		switch {
+		case t.isKeyword("and") || t.isKeyword("or"):
+			expectField = true
+		case !expectField:
+		case t.text == "(" || t.isKeyword("not"):
+			// A group or negation, the field follows
+		default:
+			add(t)
+			expectField = false
+		}
+	}
+
+	if order >= 0 {
+		// Sort keys are separated by commas and may be followed by ASC or DESC
+		expectField = true
+		for _, t := range tokens[order+2:] {
+			if t.quoted {
+				if expectField {
+					add(t)
+					expectField = false
+				}
+				continue
+			}
+			for i, part := range strings.Split(t.text, ",") {
+				if i > 0 {
+					expectField = true
+				}
+				if part != "" && expectField {
+					add(token{text: part})
+					expectField = false
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+// unquote returns the text of a string literal
+func unquote(literal string) string {
+	text := literal[1 : len(literal)-1]
+	return strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\'`, `'`).Replace(text)
+}
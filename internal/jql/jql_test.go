@@ -0,0 +1,147 @@
+package jql
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain", "PROJ", `"PROJ"`},
+		{"double quote", `a" OR project = "SEC`, `"a\" OR project = \"SEC"`},
+		{"backslash", `a\`, `"a\\"`},
+		{"escaped quote", `a\" OR x`, `"a\\\" OR x"`},
+		{"newline", "a\nb\rc", `"a b c"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Quote(tt.value)
+			if got != tt.want {
+				t.Errorf("Quote(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+			// The quoted value must be a single string literal whatever it contains
+			tokens, err := tokenize(got)
+			if err != nil || len(tokens) != 1 || !tokens[0].quoted {
+				t.Errorf("Quote(%q) = %s is not a single literal, tokens %v, err %v", tt.value, got, tokens, err)
+			}
+		})
+	}
+}
+
+func TestQuoteList(t *testing.T) {
+	if got, want := QuoteList([]string{"A", `B") OR ("C`}), `("A", "B\") OR (\"C")`; got != want {
+		t.Errorf("QuoteList() = %s, want %s", got, want)
+	}
+}
+
+func TestValidateFragment(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment string
+		wantErr  bool
+	}{
+		{"project key", "PROJ", false},
+		{"clause", `status = "In Progress" AND assignee = currentUser()`, false},
+		{"nested or", `project = A AND (status = Done OR status = Closed)`, false},
+		{"quoted or", `summary ~ "this OR that"`, false},
+		{"quoted order by", `summary ~ "order by"`, false},
+		{"top-level or", `project = A OR project = SEC`, true},
+		{"lowercase or", `project = A or project = SEC`, true},
+		{"order by", `project = A ORDER BY created`, true},
+		{"nested order by", `(project = A ORDER BY created)`, true},
+		{"unterminated quote", `summary ~ "open`, true},
+		{"single quoted or", `A' OR project = 'SEC`, false},
+		{"quote injection", `PROJ" OR project = "SEC"`, true},
+		{"single quote injection", `PROJ' OR project = 'SEC'`, true},
+		{"closing parenthesis", `A) OR (project = SEC`, true},
+		{"opening parenthesis", `(A`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFragment(tt.fragment)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFragment(%q) error = %v, wantErr %v", tt.fragment, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		projects []string
+		want     string
+		wantErr  bool
+	}{
+		{"no projects", "status = Done", nil, "status = Done", false},
+		{"empty query", "", []string{"A"}, `project in ("A")`, false},
+		{"top-level or", "project = SEC OR status = Done", []string{"A"}, `project in ("A") AND (project = SEC OR status = Done)`, false},
+		{"nested or", "(project = SEC OR (status = Done OR key = SEC-1))", []string{"A", "B"}, `project in ("A", "B") AND ((project = SEC OR (status = Done OR key = SEC-1)))`, false},
+		{"order by", "status = Done ORDER BY created DESC", []string{"A"}, `project in ("A") AND (status = Done) ORDER BY created DESC`, false},
+		{"only order by", "order by created", []string{"A"}, `project in ("A") order by created`, false},
+		{"quoted order by", `summary ~ "order by" OR project = SEC`, []string{"A"}, `project in ("A") AND (summary ~ "order by" OR project = SEC)`, false},
+		{"or after order by", "status = Done ORDER BY created OR project = SEC", []string{"A"}, "", true},
+		{"parenthesis after order by", "status = Done ORDER BY created (project = SEC)", []string{"A"}, "", true},
+		{"closing parenthesis", "status = Done) OR (project = SEC", []string{"A"}, "", true},
+		{"unterminated quote", `summary ~ "x) OR project = SEC`, []string{"A"}, "", true},
+		{"quoted project", "status = Done", []string{`A") OR project = ("SEC`}, `project in ("A\") OR project = (\"SEC") AND (status = Done)`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Scope(tt.query, tt.projects)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scope(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Scope(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExclude(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		projects []string
+		want     string
+		wantErr  bool
+	}{
+		{"no projects", "project = SEC", nil, "project = SEC", false},
+		{"names the project", "project = SEC", []string{"SEC"}, `project not in ("SEC") AND (project = SEC)`, false},
+		{"top-level or", "project = A OR parent = SEC-1", []string{"SEC"}, `project not in ("SEC") AND (project = A OR parent = SEC-1)`, false},
+		{"nested or", "status = Done AND (key = SEC-1 OR (issue in linkedIssues(SEC-2)))", []string{"SEC"}, `project not in ("SEC") AND (status = Done AND (key = SEC-1 OR (issue in linkedIssues(SEC-2))))`, false},
+		{"order by", "project = A ORDER BY created", []string{"SEC"}, `project not in ("SEC") AND (project = A) ORDER BY created`, false},
+		{"and after order by", "project = A ORDER BY created AND project = SEC", []string{"SEC"}, "", true},
+		{"unbalanced", "project = A) OR (project = SEC", []string{"SEC"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Exclude(tt.query, tt.projects)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Exclude(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Exclude(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldNames(t *testing.T) {
+	got, err := FieldNames(`project = A AND "Story Points" > 3 ORDER BY created`)
+	if err != nil {
+		t.Fatalf("FieldNames() error = %v", err)
+	}
+	want := []string{"project", "Story Points", "created"}
+	if len(got) != len(want) {
+		t.Fatalf("FieldNames() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("FieldNames()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package jqlbuilder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"jira_helper/internal/jql"
+	"jira_helper/internal/service/jira"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+)
+
+// maxAttempts bounds how often the model may correct a query Jira rejected
+const maxAttempts = 3
+
+// pseudoFields are JQL clauses that are not fields in Jira's field metadata
+var pseudoFields = []string{"text", "filter", "request", "savedfilter", "searchrequest", "category"}
+
+// buildPrompt instructs the model to answer with the query and its explanation
+const buildPrompt = `You convert search criteria into JQL for Jira Server / Data Center. Today is %s.
+
+Answer with a JSON object only, without a code block: {"jql": "...", "explanation": "..."}
+- explanation describes in one or two plain sentences which issues the query matches
+- Use only standard JQL operators and functions, e.g. currentUser(), startOfWeek(), openSprints()
+- Quote values and field names that contain spaces
+- Refer to custom fields by their name in double quotes
+%s`
+
+// Chatter completes a chat. The AI providers implement it.
+type Chatter interface {
+	Chat(ctx context.Context, messages []azopenai.ChatRequestMessageClassification) (string, error)
+}
+
+// Jira is the part of the Jira client queries are checked with. *jira.Client implements it.
+type Jira interface {
+	Fields(ctx context.Context) ([]jira.Field, error)
+	ValidateJQL(ctx context.Context, jql string) error
+}
+
+// Result is a query Jira accepted
+type Result struct {
+	JQL         string `json:"jql"`
+	Explanation string `json:"explanation"`
+}
+
+// Builder converts natural-language search criteria into JQL
+type Builder struct {
+	chat Chatter
+}
+
+// NewBuilder creates a new Builder instance
+func NewBuilder(chat Chatter) *Builder {
+	return &Builder{chat: chat}
+}
+
+// Build asks the model for a query matching the criteria, and checks its fields against the
+// field metadata and the query against Jira. Rejected queries are returned to the model to be
+// corrected. hint adds context to the prompt, e.g. the projects the user works in.
+func (b *Builder) Build(ctx context.Context, client Jira, criteria, hint string) (*Result, error) {
+	fields, err := client.Fields(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := knownFields(fields)
+
+	if hint != "" {
+		hint = "\n" + hint
+	}
+	messages := []azopenai.ChatRequestMessageClassification{
+		&azopenai.ChatRequestSystemMessage{
+			Content: azopenai.NewChatRequestSystemMessageContent(fmt.Sprintf(buildPrompt, time.Now().Format("2006-01-02 Monday"), hint)),
+		},
+		&azopenai.ChatRequestUserMessage{
+			Content: azopenai.NewChatRequestUserMessageContent(criteria),
+		},
+	}
+
+	var problem string
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		answer, err := b.chat.Chat(ctx, messages)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate JQL: %v", err)
+		}
+		result, err := parseAnswer(answer)
+		if err == nil {
+			problem, err = check(ctx, client, known, fields, result.JQL)
+			if err != nil {
+				return nil, err
+			}
+			if problem == "" {
+				return result, nil
+			}
+		} else {
+			problem = err.Error()
+		}
+
+		messages = append(messages,
+			&azopenai.ChatRequestAssistantMessage{Content: azopenai.NewChatRequestAssistantMessageContent(answer)},
+			&azopenai.ChatRequestUserMessage{
+				Content: azopenai.NewChatRequestUserMessageContent(fmt.Sprintf("%s\nFix the query and answer with the JSON object again.", problem)),
+			},
+		)
+	}
+	return nil, fmt.Errorf("could not build a valid query: %s", problem)
+}
+
+// check returns what is wrong with the query, or an empty string if Jira accepts it. Errors other
+// than Jira rejecting the query are returned as errors.
+func check(ctx context.Context, client Jira, known map[string]bool, fields []jira.Field, query string) (string, error) {
+	names, err := jql.FieldNames(query)
+	if err != nil {
+		return fmt.Sprintf("The query does not parse: %v.", err), nil
+	}
+	var problems []string
+	for _, name := range names {
+		if known[strings.ToLower(name)] {
+			continue
+		}
+		problem := fmt.Sprintf("The field %q does not exist.", name)
+		if similar := similarFields(fields, name); len(similar) > 0 {
+			problem += " Similar fields: " + strings.Join(similar, ", ") + "."
+		}
+		problems = append(problems, problem)
+	}
+	if len(problems) > 0 {
+		return strings.Join(problems, "\n"), nil
+	}
+
+	err = client.ValidateJQL(ctx, query)
+	var apiErr *jira.APIError
+	switch {
+	case err == nil:
+		return "", nil
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest:
+		return fmt.Sprintf("Jira rejected the query: %s.", strings.Join(apiErr.Messages, " ")), nil
+	default:
+		return "", err
+	}
+}
+
+// parseAnswer reads the JSON object of the model's answer
+func parseAnswer(answer string) (*Result, error) {
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the answer is not a JSON object")
+	}
+	var result Result
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &result); err != nil {
+		return nil, fmt.Errorf("the answer is not a valid JSON object: %v", err)
+	}
+	if strings.TrimSpace(result.JQL) == "" {
+		return nil, fmt.Errorf("the answer has no jql")
+	}
+	return &result, nil
+}
+
+// knownFields returns the lower case names JQL may refer to the fields by
+func knownFields(fields []jira.Field) map[string]bool {
+	known := map[string]bool{}
+	for _, name := range pseudoFields {
+		known[name] = true
+	}
+	for _, field := range fields {
+		known[strings.ToLower(field.ID)] = true
+		known[strings.ToLower(field.Name)] = true
+		for _, clause := range field.ClauseNames {
+			known[strings.ToLower(clause)] = true
+		}
+		if id, ok := strings.CutPrefix(field.ID, "customfield_"); ok {
+			known["cf["+id+"]"] = true
+		}
+	}
+	return known
+}
+
+// similarFields returns up to three searchable fields whose names contain the name or are
+// contained in it
+func similarFields(fields []jira.Field, name string) []string {
+	name = strings.ToLower(name)
+	var similar []string
+	for _, field := range fields {
+		candidate := strings.ToLower(field.Name)
+		if !field.Searchable || candidate == "" || !(strings.Contains(candidate, name) || strings.Contains(name, candidate)) {
+			continue
+		}
+		similar = append(similar, fmt.Sprintf("%q", field.Name))
+	}
+	sort.Strings(similar)
+	if len(similar) > 3 {
+		similar = similar[:3]
+	}
+	return similar
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-lambda-go/lambdacontext"
@@ -18,6 +20,8 @@ const (
 	sizeLimit = 240 * 1024 // CloudWatch log size limit
 	// request log type
 	requestType = "request"
+	// redacted replaces credentials in the request log
+	redacted = "REDACTED"
 )
 
 // TODO: @yy remove log for file upload
@@ -134,8 +138,11 @@ func (w respLogWriter) WriteString(s string) (int, error) {
 func initLogRecord(ctx *gin.Context) *logRecord {
 	var requestBody string
 	httpMethod := ctx.Request.Method
-	requestPath := ctx.Request.RequestURI
-	requestQuery := ctx.Request.URL.Query()
+	requestQuery := redactQuery(ctx.Request.URL.Query())
+	requestPath := ctx.Request.URL.Path
+	if len(requestQuery) > 0 {
+		requestPath += "?" + requestQuery.Encode()
+	}
 	requestBodyBytes, err := io.ReadAll(ctx.Request.Body)
 	if err != nil {
 
@@ -143,6 +150,9 @@ func initLogRecord(ctx *gin.Context) *logRecord {
 	// reattach request body for later use
 	ctx.Request.Body = io.NopCloser(bytes.NewBuffer(requestBodyBytes))
 	requestBody = string(requestBodyBytes)
+	if sensitiveBody(ctx.Request.URL.Path) {
+		requestBody = redacted
+	}
 
 	logRecord := &logRecord{
 		Timestamp:    time.Now().UnixNano() / 1e6,
@@ -151,8 +161,37 @@ func initLogRecord(ctx *gin.Context) *logRecord {
 		RequestQuery: requestQuery.Encode(),
 		RequestBody:  requestBody,
 		Type:         requestType,
-		Headers:      ctx.Request.Header,
+		Headers:      redactHeaders(ctx.Request.Header),
 	}
 
 	return logRecord
 }
+
+// sensitiveBody reports whether requests to the path may carry a personal access token in their
+// body: modal submissions, and the slash commands that set up or rotate a token
+func sensitiveBody(path string) bool {
+	last := path[strings.LastIndex(path, "/")+1:]
+	return last == "interactions" || strings.HasPrefix(last, "setup-") || strings.HasPrefix(last, "rotate-")
+}
+
+// redactQuery masks query parameters that carry credentials, such as the secret Jira Server
+// passes to /jira-webhook
+func redactQuery(query url.Values) url.Values {
+	for _, name := range []string{"secret", "token"} {
+		if _, ok := query[name]; ok {
+			query[name] = []string{redacted}
+		}
+	}
+	return query
+}
+
+// redactHeaders returns a copy of the headers with credentials masked
+func redactHeaders(headers http.Header) map[string][]string {
+	clone := headers.Clone()
+	for _, name := range []string{"Authorization", "X-Api-Key"} {
+		if _, ok := clone[name]; ok {
+			clone[name] = []string{redacted}
+		}
+	}
+	return clone
+}
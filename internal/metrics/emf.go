@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// emfMetrics are the request fields published as CloudWatch metrics, with their units
+var emfMetrics = []emfMetric{
+	{Name: "PromptTokens", Unit: "Count"},
+	{Name: "CompletionTokens", Unit: "Count"},
+	{Name: "ToolCalls", Unit: "Count"},
+	{Name: "ToolErrors", Unit: "Count"},
+	{Name: "Rounds", Unit: "Count"},
+	{Name: "Latency", Unit: "Milliseconds"},
+	{Name: "CostUSD", Unit: "None"},
+	{Name: "Failed", Unit: "Count"},
+}
+
+// emfMetric is a metric definition of the CloudWatch embedded metric format
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+// EMFSink writes requests to stdout in the CloudWatch embedded metric format, so Lambda's log
+// group turns them into metrics per team. User and channel are kept as searchable properties,
+// they are not dimensions to keep the metric count low.
+type EMFSink struct {
+	namespace string
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewEMFSink creates a new EMFSink instance writing metrics to the namespace
+func NewEMFSink(namespace string) *EMFSink {
+	return &EMFSink{namespace: namespace, out: os.Stdout}
+}
+
+// Record writes the request as one EMF log line
+func (s *EMFSink) Record(_ context.Context, request Request) error {
+	failed := 0
+	if request.Failed {
+		failed = 1
+	}
+	line := map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"Timestamp": request.Timestamp.UnixMilli(),
+			"CloudWatchMetrics": []map[string]interface{}{{
+				"Namespace":  s.namespace,
+				"Dimensions": [][]string{{"Team"}},
+				"Metrics":    emfMetrics,
+			}},
+		},
+		"Team":             request.Team,
+		"PromptTokens":     request.PromptTokens,
+		"CompletionTokens": request.CompletionTokens,
+		"ToolCalls":        request.ToolCalls,
+		"ToolErrors":       request.ToolErrors,
+		"Rounds":           request.Rounds,
+		"Latency":          request.LatencyMs,
+		"CostUSD":          request.CostUSD,
+		"Failed":           failed,
+		"conversation_id":  request.ConversationID,
+		"user_id":          request.UserID,
+		"channel_id":       request.ChannelID,
+	}
+	data, err := json.Marshal(line)
+	if err != nil {
+		return fmt.Errorf("failed to marshal EMF metrics: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = fmt.Fprintln(s.out, string(data))
+	return err
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// UnassignedTeam is the team of requests from channels without one
+const UnassignedTeam = "unassigned"
+
+// Request is the usage of one conversation run: a Slack query, a Step Functions round or an
+// approved write resuming a conversation
+type Request struct {
+	ConversationID   string    `json:"conversation_id"`
+	UserID           string    `json:"user_id"`
+	ChannelID        string    `json:"channel_id"`
+	Team             string    `json:"team"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	ToolCalls        int       `json:"tool_calls"`
+	ToolErrors       int       `json:"tool_errors"`
+	Rounds           int       `json:"rounds"`
+	LatencyMs        int64     `json:"latency_ms"`
+	CostUSD          float64   `json:"cost_usd,omitempty"` // Estimated from the configured token prices
+	Failed           bool      `json:"failed"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// TokenPrices are the prices of 1000 AI tokens in USD, used to estimate the cost of requests
+type TokenPrices struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// Sink receives the metrics of each request
+type Sink interface {
+	Record(ctx context.Context, request Request) error
+}
+
+// Recorder attributes requests to teams, estimates their cost and passes them to the sinks
+type Recorder struct {
+	teams  map[string]string // Channel ID -> team
+	prices TokenPrices
+	sinks  []Sink
+}
+
+// NewRecorder creates a new Recorder instance
+func NewRecorder(teams map[string]string, prices TokenPrices, sinks ...Sink) *Recorder {
+	return &Recorder{teams: teams, prices: prices, sinks: sinks}
+}
+
+// Record completes the request with its team and cost and sends it to every sink. A failing
+// sink is logged and does not keep the request from the others.
+func (r *Recorder) Record(ctx context.Context, request Request) {
+	if r == nil {
+		return
+	}
+	request.Team = r.teams[request.ChannelID]
+	if request.Team == "" {
+		request.Team = UnassignedTeam
+	}
+	request.CostUSD = (float64(request.PromptTokens)*r.prices.Prompt + float64(request.CompletionTokens)*r.prices.Completion) / 1000
+	if request.Timestamp.IsZero() {
+		request.Timestamp = time.Now().UTC()
+	}
+	for _, sink := range r.sinks {
+		if err := sink.Record(ctx, request); err != nil {
+			logger.GetLogger().Warn("failed to record request metrics", zap.String("conversation_id", request.ConversationID), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// teamTotals are the running totals of a team's requests
+type teamTotals struct {
+	Requests         int64
+	Failed           int64
+	PromptTokens     int64
+	CompletionTokens int64
+	ToolCalls        int64
+	ToolErrors       int64
+	Rounds           int64
+	LatencyMs        int64
+	CostUSD          float64
+}
+
+// Registry keeps totals per team since the process started and serves them in the Prometheus
+// text format, for local runs and long-running services
+type Registry struct {
+	mu         sync.Mutex
+	teams      map[string]*teamTotals
+	duplicates map[string]int64 // Duplicate Slack events skipped, by where they were recognized
+}
+
+// NewRegistry creates a new empty Registry
+func NewRegistry() *Registry {
+	return &Registry{teams: map[string]*teamTotals{}, duplicates: map[string]int64{}}
+}
+
+// RecordDuplicateEvent counts a duplicate Slack event that was skipped
+func (r *Registry) RecordDuplicateEvent(source string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.duplicates[source]++
+}
+
+// Record adds the request to its team's totals
+func (r *Registry) Record(_ context.Context, request Request) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	totals, ok := r.teams[request.Team]
+	if !ok {
+		totals = &teamTotals{}
+		r.teams[request.Team] = totals
+	}
+	totals.Requests++
+	if request.Failed {
+		totals.Failed++
+	}
+	totals.PromptTokens += int64(request.PromptTokens)
+	totals.CompletionTokens += int64(request.CompletionTokens)
+	totals.ToolCalls += int64(request.ToolCalls)
+	totals.ToolErrors += int64(request.ToolErrors)
+	totals.Rounds += int64(request.Rounds)
+	totals.LatencyMs += request.LatencyMs
+	totals.CostUSD += request.CostUSD
+	return nil
+}
+
+// WriteText writes the totals in the Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	teams := make([]string, 0, len(r.teams))
+	for team := range r.teams {
+		teams = append(teams, team)
+	}
+	sort.Strings(teams)
+
+	counters := []struct {
+		name  string
+		help  string
+		value func(t *teamTotals) string
+	}{
+		{"jira_helper_requests_total", "Conversation runs", func(t *teamTotals) string { return strconv.FormatInt(t.Requests, 10) }},
+		{"jira_helper_failed_requests_total", "Conversation runs that failed", func(t *teamTotals) string { return strconv.FormatInt(t.Failed, 10) }},
+		{"jira_helper_prompt_tokens_total", "AI prompt tokens", func(t *teamTotals) string { return strconv.FormatInt(t.PromptTokens, 10) }},
+		{"jira_helper_completion_tokens_total", "AI completion tokens", func(t *teamTotals) string { return strconv.FormatInt(t.CompletionTokens, 10) }},
+		{"jira_helper_tool_calls_total", "MCP tool calls", func(t *teamTotals) string { return strconv.FormatInt(t.ToolCalls, 10) }},
+		{"jira_helper_tool_errors_total", "MCP tool calls that failed", func(t *teamTotals) string { return strconv.FormatInt(t.ToolErrors, 10) }},
+		{"jira_helper_rounds_total", "AI rounds", func(t *teamTotals) string { return strconv.FormatInt(t.Rounds, 10) }},
+		{"jira_helper_latency_seconds_total", "Time spent in conversation runs", func(t *teamTotals) string {
+			return strconv.FormatFloat(float64(t.LatencyMs)/1000, 'f', -1, 64)
+		}},
+		{"jira_helper_cost_usd_total", "Estimated AI cost", func(t *teamTotals) string { return strconv.FormatFloat(t.CostUSD, 'f', -1, 64) }},
+	}
+	for _, counter := range counters {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", counter.name, counter.help, counter.name); err != nil {
+			return err
+		}
+		for _, team := range teams {
+			if _, err := fmt.Fprintf(w, "%s{team=%q} %s\n", counter.name, team, counter.value(r.teams[team])); err != nil {
+				return err
+			}
+		}
+	}
+
+	sources := make([]string, 0, len(r.duplicates))
+	for source := range r.duplicates {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	const duplicates = "jira_helper_duplicate_events_total"
+	if _, err := fmt.Fprintf(w, "# HELP %s Duplicate Slack events skipped\n# TYPE %s counter\n", duplicates, duplicates); err != nil {
+		return err
+	}
+	for _, source := range sources {
+		if _, err := fmt.Fprintf(w, "%s{source=%q} %d\n", duplicates, source, r.duplicates[source]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// usageLogPrefix keeps the usage log with the other usage data, so the usage retention purges it
+const usageLogPrefix = "usage/requests/"
+
+// S3Sink writes each request to the usage log in S3, one object per request partitioned by day
+// so the log can be queried with Athena
+type S3Sink struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3Sink creates a new S3Sink instance
+func NewS3Sink(client *s3.Client, bucketName string) *S3Sink {
+	return &S3Sink{client: client, bucketName: bucketName}
+}
+
+// Record stores the request in the usage log
+func (s *S3Sink) Record(ctx context.Context, request Request) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%sdt=%s/%s-%d.json", usageLogPrefix, request.Timestamp.Format("2006-01-02"), request.ConversationID, request.Timestamp.UnixNano())
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store usage log in S3: %v", err)
+	}
+	return nil
+}
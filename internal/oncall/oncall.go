@@ -0,0 +1,126 @@
+package oncall
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"jira_helper/internal/service/opsgenie"
+	"jira_helper/internal/service/pagerduty"
+)
+
+// Providers of on-call schedules
+const (
+	ProviderPagerDuty = "pagerduty"
+	ProviderOpsgenie  = "opsgenie"
+)
+
+// Schedule is the on-call schedule responsible for a Jira project
+type Schedule struct {
+	Provider string `json:"provider"` // pagerduty or opsgenie
+	Schedule string `json:"schedule"` // PagerDuty schedule ID, or Opsgenie schedule name or ID
+}
+
+// Schedules maps Jira project keys to their on-call schedule, "*" matches projects without their own
+type Schedules map[string]Schedule
+
+// Match returns the schedule of the project
+func (s Schedules) Match(project string) (Schedule, bool) {
+	if schedule, ok := s[project]; ok {
+		return schedule, true
+	}
+	schedule, ok := s["*"]
+	return schedule, ok
+}
+
+// Validate checks that every schedule names a known provider and a schedule
+func (s Schedules) Validate() error {
+	projects := make([]string, 0, len(s))
+	for project := range s {
+		projects = append(projects, project)
+	}
+	sort.Strings(projects)
+	for _, project := range projects {
+		schedule := s[project]
+		if schedule.Provider != ProviderPagerDuty && schedule.Provider != ProviderOpsgenie {
+			return fmt.Errorf("on-call schedule of %s has an unknown provider %q, use %s or %s", project, schedule.Provider, ProviderPagerDuty, ProviderOpsgenie)
+		}
+		if schedule.Schedule == "" {
+			return fmt.Errorf("on-call schedule of %s names no schedule", project)
+		}
+	}
+	return nil
+}
+
+// Uses reports whether any schedule is kept by the provider
+func (s Schedules) Uses(provider string) bool {
+	for _, schedule := range s {
+		if schedule.Provider == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// Responder is a person currently on call
+type Responder struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email"`
+}
+
+// Directory looks up who is on call for a Jira project in PagerDuty or Opsgenie
+type Directory struct {
+	schedules Schedules
+	pagerDuty *pagerduty.Client // Optional: looks up the PagerDuty schedules
+	opsgenie  *opsgenie.Client  // Optional: looks up the Opsgenie schedules
+}
+
+// NewDirectory creates a Directory. The clients of providers no schedule uses may be nil.
+func NewDirectory(schedules Schedules, pagerDuty *pagerduty.Client, opsgenie *opsgenie.Client) *Directory {
+	return &Directory{schedules: schedules, pagerDuty: pagerDuty, opsgenie: opsgenie}
+}
+
+// OnCall returns the project's schedule and the people currently on call in it, the primary first
+func (d *Directory) OnCall(ctx context.Context, project string) (Schedule, []Responder, error) {
+	schedule, ok := d.schedules.Match(project)
+	if !ok {
+		return schedule, nil, fmt.Errorf("no on-call schedule is configured for project %s", project)
+	}
+
+	var responders []Responder
+	switch {
+	case schedule.Provider == ProviderPagerDuty && d.pagerDuty != nil:
+		users, err := d.pagerDuty.OnCalls(ctx, schedule.Schedule)
+		if err != nil {
+			return schedule, nil, err
+		}
+		for _, user := range users {
+			name := user.Name
+			if name == "" {
+				name = user.Summary
+			}
+			responders = appendResponder(responders, Responder{Name: name, Email: user.Email})
+		}
+	case schedule.Provider == ProviderOpsgenie && d.opsgenie != nil:
+		emails, err := d.opsgenie.OnCallRecipients(ctx, schedule.Schedule)
+		if err != nil {
+			return schedule, nil, err
+		}
+		for _, email := range emails {
+			responders = appendResponder(responders, Responder{Email: email})
+		}
+	default:
+		return schedule, nil, fmt.Errorf("%s is not configured", schedule.Provider)
+	}
+	return schedule, responders, nil
+}
+
+// appendResponder adds the responder unless they are already listed, e.g. on call at two levels
+func appendResponder(responders []Responder, responder Responder) []Responder {
+	for _, listed := range responders {
+		if listed.Email == responder.Email {
+			return responders
+		}
+	}
+	return append(responders, responder)
+}
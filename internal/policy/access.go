@@ -0,0 +1,128 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Roles of Slack users, each allowed the tools of the roles before it
+const (
+	RoleViewer = "viewer" // Read tools only
+	RoleEditor = "editor" // Reads and writes, except the admin tools
+	RoleAdmin  = "admin"  // Every tool, and the admin commands
+)
+
+// roleRank orders the roles, a user in several groups gets the highest one
+var roleRank = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleAdmin: 3}
+
+// defaultAdminTools are the tools only admins may call unless the policy names others
+var defaultAdminTools = []string{"jira_delete_issue", "confluence_delete_page"}
+
+// AccessPolicy decides in which channels the bot answers and, through the Slack user groups a
+// user is in, which tools the user may call. An empty policy allows everything.
+type AccessPolicy struct {
+	AllowedChannels []string          `json:"allowed_channels,omitempty"` // Channel IDs the bot answers in, dm for direct messages, empty for all
+	DeniedChannels  []string          `json:"denied_channels,omitempty"`  // Channel IDs the bot never answers in, dm for direct messages
+	GroupRoles      map[string]string `json:"group_roles,omitempty"`      // Slack user group ID or handle -> role, enables the role checks
+	DefaultRole     string            `json:"default_role,omitempty"`     // Role of users in none of the groups, defaults to viewer
+	AdminTools      []string          `json:"admin_tools,omitempty"`      // Tools only admins may call, may use wildcards, defaults to the deletes
+}
+
+// ParseAccessPolicy parses a JSON access policy
+func ParseAccessPolicy(raw string) (*AccessPolicy, error) {
+	p := &AccessPolicy{}
+	if strings.TrimSpace(raw) == "" {
+		return p, nil
+	}
+	if err := json.Unmarshal([]byte(raw), p); err != nil {
+		return nil, fmt.Errorf("failed to parse access policy: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks the roles and tool patterns of the policy
+func (p *AccessPolicy) Validate() error {
+	for group, role := range p.GroupRoles {
+		if _, ok := roleRank[role]; !ok {
+			return fmt.Errorf("user group %s has unknown role %q, expected viewer, editor or admin", group, role)
+		}
+	}
+	if _, ok := roleRank[p.DefaultRole]; p.DefaultRole != "" && !ok {
+		return fmt.Errorf("default role %q is unknown, expected viewer, editor or admin", p.DefaultRole)
+	}
+	for _, pattern := range p.AdminTools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("admin tool pattern %q is invalid", pattern)
+		}
+	}
+	for _, channel := range p.AllowedChannels {
+		if slices.Contains(p.DeniedChannels, channel) {
+			return fmt.Errorf("channel %s is both allowed and denied", channel)
+		}
+	}
+	return nil
+}
+
+// ChannelAllowed reports whether the bot answers in the channel
+func (p *AccessPolicy) ChannelAllowed(channelID string) bool {
+	if p == nil {
+		return true
+	}
+	if listsChannel(p.DeniedChannels, channelID) {
+		return false
+	}
+	return len(p.AllowedChannels) == 0 || listsChannel(p.AllowedChannels, channelID)
+}
+
+// listsChannel reports whether the channel is in the list, DMs through dm
+func listsChannel(channels []string, channelID string) bool {
+	// Slack direct message channel IDs start with D
+	return slices.Contains(channels, channelID) || (strings.HasPrefix(channelID, "D") && slices.Contains(channels, DirectMessages))
+}
+
+// UsesRoles reports whether tools are restricted by role
+func (p *AccessPolicy) UsesRoles() bool {
+	return p != nil && len(p.GroupRoles) > 0
+}
+
+// RoleOf returns the highest role of the user groups, given by ID or handle, or the default role
+// for users in none of them
+func (p *AccessPolicy) RoleOf(groups []string) string {
+	role := ""
+	for _, group := range groups {
+		if r, ok := p.GroupRoles[group]; ok && roleRank[r] > roleRank[role] {
+			role = r
+		}
+	}
+	if role != "" {
+		return role
+	}
+	if p.DefaultRole != "" {
+		return p.DefaultRole
+	}
+	return RoleViewer
+}
+
+// CheckTool returns an error explaining the denial when the role may not call the tool
+func (p *AccessPolicy) CheckTool(role, tool string, isWrite bool) error {
+	if !p.UsesRoles() || role == RoleAdmin {
+		return nil
+	}
+	adminTools := p.AdminTools
+	if len(adminTools) == 0 {
+		adminTools = defaultAdminTools
+	}
+	if matchesAny(adminTools, tool) {
+		return fmt.Errorf("%s is restricted to admins, your role is %s", tool, role)
+	}
+	if isWrite && role == RoleViewer {
+		return fmt.Errorf("%s changes Jira, which your role %s does not allow", tool, role)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Sensitivity is the data classification of a Jira project
+type Sensitivity string
+
+const (
+	SensitivityPublic     Sensitivity = "public"
+	SensitivityInternal   Sensitivity = "internal"
+	SensitivityRestricted Sensitivity = "restricted"
+)
+
+// DirectMessages can be listed in AllowedChannels to permit a project in DMs with the bot
+const DirectMessages = "dm"
+
+// issueKeyPattern matches Jira issue keys such as PROJ-123
+var issueKeyPattern = regexp.MustCompile(`\b([A-Z][A-Z0-9_]+)-\d+\b`)
+
+// ProjectRule tags a Jira project with a sensitivity level and the channels allowed to see it
+type ProjectRule struct {
+	Project         string      `json:"project"`
+	Level           Sensitivity `json:"level"`
+	AllowedChannels []string    `json:"allowed_channels"`
+}
+
+// Boundaries enforces which channels may see data from which Jira projects
+type Boundaries struct {
+	rules map[string]ProjectRule
+}
+
+// ParseBoundaries parses the JSON list of project rules from configuration
+func ParseBoundaries(raw string) (*Boundaries, error) {
+	b := &Boundaries{rules: map[string]ProjectRule{}}
+	if strings.TrimSpace(raw) == "" {
+		return b, nil
+	}
+
+	var rules []ProjectRule
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse project sensitivity rules: %v", err)
+	}
+	for _, rule := range rules {
+		switch rule.Level {
+		case SensitivityPublic, SensitivityInternal, SensitivityRestricted:
+		default:
+			return nil, fmt.Errorf("project %s has unknown sensitivity level %q", rule.Project, rule.Level)
+		}
+		b.rules[strings.ToUpper(rule.Project)] = rule
+	}
+	return b, nil
+}
+
+// channelAllowed reports whether the project may be surfaced in the channel
+func (b *Boundaries) channelAllowed(project, channelID string) bool {
+	rule, ok := b.rules[project]
+	if !ok || rule.Level != SensitivityRestricted {
+		return true
+	}
+	if slices.Contains(rule.AllowedChannels, channelID) {
+		return true
+	}
+	// Slack direct message channel IDs start with D
+	return strings.HasPrefix(channelID, "D") && slices.Contains(rule.AllowedChannels, DirectMessages)
+}
+
+// Restricted returns the restricted projects the channel is not allowed to see, sorted by project key
+func (b *Boundaries) Restricted(channelID string) []string {
+	if b == nil {
+		return nil
+	}
+	var projects []string
+	for project := range b.rules {
+		if !b.channelAllowed(project, channelID) {
+			projects = append(projects, project)
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// Violations returns the restricted projects referenced in the text or the extra
+// project keys that the channel is not allowed to see, sorted by project key
+func (b *Boundaries) Violations(channelID string, text string, projectKeys ...string) []string {
+	if b == nil || len(b.rules) == 0 {
+		return nil
+	}
+
+	projects := map[string]bool{}
+	for _, match := range issueKeyPattern.FindAllStringSubmatch(text, -1) {
+		projects[match[1]] = true
+	}
+	for _, key := range projectKeys {
+		if key != "" {
+			projects[strings.ToUpper(key)] = true
+		}
+	}
+
+	var violations []string
+	for project := range projects {
+		if !b.channelAllowed(project, channelID) {
+			violations = append(violations, project)
+		}
+	}
+	sort.Strings(violations)
+	return violations
+}
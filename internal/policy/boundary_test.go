@@ -0,0 +1,94 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testRules = `[
+	{"project": "SEC", "level": "restricted", "allowed_channels": ["C0SEC", "dm"]},
+	{"project": "hr", "level": "restricted", "allowed_channels": ["C0HR"]},
+	{"project": "OPS", "level": "internal"}
+]`
+
+func TestParseBoundaries(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr bool
+	}{
+		{"empty", "  ", false},
+		{"rules", testRules, false},
+		{"unknown level", `[{"project": "SEC", "level": "secret"}]`, true},
+		{"invalid json", `{"project": "SEC"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBoundaries(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseBoundaries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBoundariesRestricted(t *testing.T) {
+	b, err := ParseBoundaries(testRules)
+	if err != nil {
+		t.Fatalf("ParseBoundaries() error = %v", err)
+	}
+	tests := []struct {
+		name      string
+		channelID string
+		want      []string
+	}{
+		{"public channel", "C0GEN", []string{"HR", "SEC"}},
+		{"allowed channel", "C0SEC", []string{"HR"}},
+		{"direct message", "D0123", []string{"HR"}},
+		{"other allowed channel", "C0HR", []string{"SEC"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.Restricted(tt.channelID); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Restricted(%s) = %v, want %v", tt.channelID, got, tt.want)
+			}
+		})
+	}
+
+	var none *Boundaries
+	if got := none.Restricted("C0GEN"); got != nil {
+		t.Errorf("nil Restricted() = %v, want nil", got)
+	}
+}
+
+func TestBoundariesViolations(t *testing.T) {
+	b, err := ParseBoundaries(testRules)
+	if err != nil {
+		t.Fatalf("ParseBoundaries() error = %v", err)
+	}
+	tests := []struct {
+		name        string
+		channelID   string
+		text        string
+		projectKeys []string
+		want        []string
+	}{
+		{"no references", "C0GEN", "how many bugs are open?", nil, nil},
+		{"issue key", "C0GEN", "what is the status of SEC-12?", nil, []string{"SEC"}},
+		{"several keys", "C0GEN", "compare HR-1 with SEC-2 and OPS-3", nil, []string{"HR", "SEC"}},
+		{"allowed channel", "C0SEC", "what is the status of SEC-12?", nil, nil},
+		{"direct message", "D0123", "SEC-12", nil, nil},
+		{"dm not allowed", "D0123", "HR-4", nil, []string{"HR"}},
+		{"project key", "C0GEN", "", []string{"sec"}, []string{"SEC"}},
+		{"internal project", "C0GEN", "OPS-3", []string{"OPS"}, nil},
+		{"key in a word", "C0GEN", "XSEC-12", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := b.Violations(tt.channelID, tt.text, tt.projectKeys...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Violations(%s, %q) = %v, want %v", tt.channelID, tt.text, got, tt.want)
+			}
+		})
+	}
+}
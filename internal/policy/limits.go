@@ -0,0 +1,85 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Default conversation limits
+const (
+	DefaultMaxRounds          = 20    // AI/tool rounds a request may take
+	DefaultHistoryPageSize    = 20    // Thread messages fetched per page
+	DefaultSummarizeThreshold = 2000  // Characters of a tool result before it is summarized
+	DefaultMessageLimit       = 40000 // Characters of a progress message, Slack's limit
+)
+
+// Limits bound a conversation. Zero values are taken from the default rule or the defaults.
+type Limits struct {
+	MaxRounds          int `json:"max_rounds,omitempty"`
+	HistoryPageSize    int `json:"history_page_size,omitempty"`
+	SummarizeThreshold int `json:"summarize_threshold,omitempty"`
+	MessageLimit       int `json:"message_limit,omitempty"`
+}
+
+// LimitPolicy is the conversation limits of each channel. Limits set per channel ID override
+// the "default" limits, which override the built-in defaults.
+type LimitPolicy struct {
+	limits map[string]Limits
+}
+
+// ParseLimitPolicy parses the JSON conversation limits from configuration, e.g.
+// {"default": {"max_rounds": 30}, "C0123": {"max_rounds": 10, "summarize_threshold": 4000}}
+func ParseLimitPolicy(raw string) (*LimitPolicy, error) {
+	p := &LimitPolicy{}
+	if strings.TrimSpace(raw) == "" {
+		return p, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &p.limits); err != nil {
+		return nil, fmt.Errorf("failed to parse conversation limits: %v", err)
+	}
+	for channel, limits := range p.limits {
+		if limits.MaxRounds < 0 || limits.HistoryPageSize < 0 || limits.SummarizeThreshold < 0 || limits.MessageLimit < 0 {
+			return nil, fmt.Errorf("conversation limits of %s must not be negative", channel)
+		}
+		if limits.HistoryPageSize > 1000 {
+			return nil, fmt.Errorf("conversation limits of %s: history_page_size must be at most 1000", channel)
+		}
+		if limits.MessageLimit > DefaultMessageLimit {
+			return nil, fmt.Errorf("conversation limits of %s: message_limit must be at most %d, Slack's limit", channel, DefaultMessageLimit)
+		}
+	}
+	return p, nil
+}
+
+// For returns the limits of the channel, with every limit set
+func (p *LimitPolicy) For(channelID string) Limits {
+	limits := Limits{
+		MaxRounds:          DefaultMaxRounds,
+		HistoryPageSize:    DefaultHistoryPageSize,
+		SummarizeThreshold: DefaultSummarizeThreshold,
+		MessageLimit:       DefaultMessageLimit,
+	}
+	if p == nil {
+		return limits
+	}
+	for _, key := range []string{defaultRuleKey, channelID} {
+		l, ok := p.limits[key]
+		if !ok {
+			continue
+		}
+		if l.MaxRounds > 0 {
+			limits.MaxRounds = l.MaxRounds
+		}
+		if l.HistoryPageSize > 0 {
+			limits.HistoryPageSize = l.HistoryPageSize
+		}
+		if l.SummarizeThreshold > 0 {
+			limits.SummarizeThreshold = l.SummarizeThreshold
+		}
+		if l.MessageLimit > 0 {
+			limits.MessageLimit = l.MessageLimit
+		}
+	}
+	return limits
+}
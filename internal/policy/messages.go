@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Who sees a message
+const (
+	VisibilityThread    = "thread"    // Posted in the thread for everyone
+	VisibilityEphemeral = "ephemeral" // Posted in the thread for the user who asked only
+	VisibilityHidden    = "hidden"    // Not posted
+)
+
+// defaultRuleKey is the key of the rule for channels without their own
+const defaultRuleKey = "default"
+
+// MessageRule decides who sees the messages a conversation posts besides its answer
+type MessageRule struct {
+	Progress string `json:"progress,omitempty"` // Progress, tool calls and their results
+	Notices  string `json:"notices,omitempty"`  // Denied tools, paused writes and Jira permission guidance, never hidden
+}
+
+// MessagePolicy is the message rule of each channel. Rules set per channel ID override the
+// "default" rule, and messages without a rule are posted in the thread.
+type MessagePolicy struct {
+	rules map[string]MessageRule
+}
+
+// ParseMessagePolicy parses the JSON message policy from configuration, e.g.
+// {"default": {"progress": "ephemeral"}, "C0123": {"progress": "hidden", "notices": "ephemeral"}}
+func ParseMessagePolicy(raw string) (*MessagePolicy, error) {
+	p := &MessagePolicy{}
+	if strings.TrimSpace(raw) == "" {
+		return p, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &p.rules); err != nil {
+		return nil, fmt.Errorf("failed to parse message policy: %v", err)
+	}
+	for channel, rule := range p.rules {
+		if err := checkVisibility(rule.Progress, true); err != nil {
+			return nil, fmt.Errorf("message policy of %s: progress %v", channel, err)
+		}
+		if err := checkVisibility(rule.Notices, false); err != nil {
+			return nil, fmt.Errorf("message policy of %s: notices %v", channel, err)
+		}
+	}
+	return p, nil
+}
+
+// For returns the rule of the channel, with every visibility set
+func (p *MessagePolicy) For(channelID string) MessageRule {
+	rule := MessageRule{Progress: VisibilityThread, Notices: VisibilityThread}
+	if p == nil {
+		return rule
+	}
+	for _, key := range []string{defaultRuleKey, channelID} {
+		if r, ok := p.rules[key]; ok {
+			if r.Progress != "" {
+				rule.Progress = r.Progress
+			}
+			if r.Notices != "" {
+				rule.Notices = r.Notices
+			}
+		}
+	}
+	return rule
+}
+
+// checkVisibility rejects unknown visibilities, and hiding messages that must be seen
+func checkVisibility(visibility string, mayHide bool) error {
+	switch visibility {
+	case "", VisibilityThread, VisibilityEphemeral:
+		return nil
+	case VisibilityHidden:
+		if mayHide {
+			return nil
+		}
+		return fmt.Errorf("cannot be hidden, use %s or %s", VisibilityThread, VisibilityEphemeral)
+	default:
+		return fmt.Errorf("has an unknown visibility %q, use %s, %s or %s", visibility, VisibilityThread, VisibilityEphemeral, VisibilityHidden)
+	}
+}
@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Rule effects
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// defaultWriteTools are the MCP tools that change Jira or Confluence
+var defaultWriteTools = []string{
+	"jira_create_issue",
+	"jira_batch_create_issues",
+	"jira_update_issue",
+	"jira_delete_issue",
+	"jira_add_comment",
+	"jira_add_worklog",
+	"jira_link_to_epic",
+	"jira_create_issue_link",
+	"jira_remove_issue_link",
+	"jira_transition_issue",
+	"jira_create_sprint",
+	"jira_update_sprint",
+	"page_oncall",
+	"jira_link_pull_request",
+	"confluence_add_label",
+	"confluence_create_page",
+	"confluence_update_page",
+	"confluence_delete_page",
+}
+
+// ToolRule allows or denies the tools it names to the channels and users it matches. Empty
+// channels or users match everyone, and args only match calls with these argument values.
+type ToolRule struct {
+	Effect   string            `json:"effect"`
+	Tools    []string          `json:"tools"`              // Tool names, may use shell-style wildcards such as jira_delete_*
+	Channels []string          `json:"channels,omitempty"` // Channel IDs, dm for direct messages
+	Users    []string          `json:"users,omitempty"`    // Slack user IDs
+	Args     map[string]string `json:"args,omitempty"`     // Argument name -> value, may use wildcards
+	Reason   string            `json:"reason,omitempty"`   // Shown to the user when the rule denies a call
+}
+
+// ToolPolicy decides which tools may be called where and by whom. The first matching rule
+// decides, and calls no rule matches are allowed.
+type ToolPolicy struct {
+	WriteTools []string   `json:"write_tools,omitempty"` // Tools that change data, defaults to the Jira and Confluence writes
+	Rules      []ToolRule `json:"rules"`
+}
+
+// ParseToolPolicy parses the JSON tool policy from configuration
+func ParseToolPolicy(raw string) (*ToolPolicy, error) {
+	p := &ToolPolicy{}
+	if strings.TrimSpace(raw) != "" {
+		if err := json.Unmarshal([]byte(raw), p); err != nil {
+			return nil, fmt.Errorf("failed to parse tool policy: %v", err)
+		}
+	}
+	if len(p.WriteTools) == 0 {
+		p.WriteTools = defaultWriteTools
+	}
+	for i, rule := range p.Rules {
+		if rule.Effect != EffectAllow && rule.Effect != EffectDeny {
+			return nil, fmt.Errorf("tool rule %d has unknown effect %q", i, rule.Effect)
+		}
+		if len(rule.Tools) == 0 {
+			return nil, fmt.Errorf("tool rule %d names no tools", i)
+		}
+		for _, pattern := range rule.Tools {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("tool rule %d has an invalid pattern %q", i, pattern)
+			}
+		}
+	}
+	return p, nil
+}
+
+// IsWrite reports whether the tool changes data
+func (p *ToolPolicy) IsWrite(tool string) bool {
+	if p == nil {
+		return slices.Contains(defaultWriteTools, tool)
+	}
+	return slices.Contains(p.WriteTools, tool)
+}
+
+// Check returns an error explaining the denial when the user may not call the tool in the channel
+func (p *ToolPolicy) Check(channelID, userID, tool string, args map[string]interface{}) error {
+	if p == nil {
+		return nil
+	}
+	for _, rule := range p.Rules {
+		if !rule.matches(channelID, userID, tool, args) {
+			continue
+		}
+		if rule.Effect == EffectAllow {
+			return nil
+		}
+		if rule.Reason != "" {
+			return fmt.Errorf("%s is not allowed: %s", tool, rule.Reason)
+		}
+		return fmt.Errorf("%s is not allowed here", tool)
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to the call
+func (r ToolRule) matches(channelID, userID, tool string, args map[string]interface{}) bool {
+	if !matchesAny(r.Tools, tool) {
+		return false
+	}
+	if len(r.Users) > 0 && !slices.Contains(r.Users, userID) {
+		return false
+	}
+	// Slack direct message channel IDs start with D
+	isDM := strings.HasPrefix(channelID, "D") && slices.Contains(r.Channels, DirectMessages)
+	if len(r.Channels) > 0 && !slices.Contains(r.Channels, channelID) && !isDM {
+		return false
+	}
+	for name, pattern := range r.Args {
+		value, ok := args[name]
+		if !ok || !matchesAny([]string{pattern}, fmt.Sprint(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAny reports whether the value matches one of the shell-style patterns
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, value); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package prompts
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Built-in response styles
+const (
+	StyleFormal  = "formal"
+	StyleTerse   = "terse"
+	StyleVerbose = "verbose"
+)
+
+// Style is a persona that sets the tone and verbosity of the answers
+type Style struct {
+	Description string `json:"description"` // Shown to users choosing a style
+	Instruction string `json:"instruction"` // Appended to the system prompt
+}
+
+// Styles are the response styles users can choose from, by name
+type Styles map[string]Style
+
+// builtInStyles are the styles available without configuration
+var builtInStyles = Styles{
+	StyleFormal: {
+		Description: "Professional tone, complete sentences, no emoji",
+		Instruction: "Use a formal, professional tone. Write complete sentences, avoid emoji, slang and casual " +
+			"phrasing, and structure longer answers with short headings or lists.",
+	},
+	StyleTerse: {
+		Description: "Just the facts, as short as possible",
+		Instruction: "Be as brief as possible. Answer in a few short lines or a compact list, without greetings, " +
+			"explanations or follow-up suggestions unless the user asks for them.",
+	},
+	StyleVerbose: {
+		Description: "Detailed answers that explain how they were found",
+		Instruction: "Explain your answers. Say how you found the information, e.g. the JQL you searched with, " +
+			"point out what stands out in the results and why it matters, and suggest useful next steps.",
+	},
+}
+
+// WithBuiltInStyles returns the built-in styles together with the custom ones, which replace
+// built-in styles of the same name
+func WithBuiltInStyles(custom Styles) Styles {
+	styles := make(Styles, len(builtInStyles)+len(custom))
+	for name, style := range builtInStyles {
+		styles[name] = style
+	}
+	for name, style := range custom {
+		styles[name] = style
+	}
+	return styles
+}
+
+// Names returns the names of the styles in alphabetical order
+func (s Styles) Names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that every style has an instruction
+func (s Styles) Validate() error {
+	for _, name := range s.Names() {
+		if s[name].Instruction == "" {
+			return fmt.Errorf("style %s has no instruction", name)
+		}
+	}
+	return nil
+}
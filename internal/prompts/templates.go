@@ -0,0 +1,172 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultName names the template used in channels without their own
+	DefaultName = "default"
+
+	// reloadInterval is how long templates from the source are used before they are loaded again,
+	// so an edited template reaches every instance without a deployment
+	reloadInterval = time.Minute
+)
+
+// funcs are the functions templates may call besides the built-in ones
+var funcs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// builtIn is the default template compiled in, used when no other default is configured
+var builtIn = template.Must(template.New(DefaultName).Funcs(funcs).Parse(defaultTemplate))
+
+// BuiltIn returns the built-in prompt without any values
+func BuiltIn() string {
+	var b strings.Builder
+	_ = builtIn.Execute(&b, Vars{})
+	return b.String()
+}
+
+// Vars are the values templates refer to, e.g. {{.JiraURL}}
+type Vars struct {
+	JiraURL   string   // Base URL of Jira, empty if not configured
+	TeamName  string   // Team the bot works for, empty if not configured
+	ChannelID string   // Channel the conversation runs in
+	Projects  []string // Jira projects the channel is scoped to
+	Date      string   // Today, e.g. 2024-05-31 Friday
+}
+
+// Source loads templates that can change at runtime. *storage.S3PromptStore implements it.
+type Source interface {
+	LoadPrompts(ctx context.Context) (map[string]string, error)
+}
+
+// Templates renders the system prompt of each channel. A channel's own template takes precedence
+// over the default one, and templates from the source over configured ones.
+type Templates struct {
+	configured map[string]*template.Template
+	source     Source
+
+	mu       sync.Mutex
+	loaded   map[string]*template.Template
+	loadedAt time.Time
+}
+
+// NewTemplates creates a new Templates instance from the configured template texts, keyed by
+// DefaultName or a channel ID. source is optional.
+func NewTemplates(configured map[string]string, source Source) (*Templates, error) {
+	parsed, errs := parse(configured)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid prompt templates: %s", strings.Join(errs, "; "))
+	}
+	return &Templates{configured: parsed, source: source}, nil
+}
+
+// Render returns the system prompt of the channel. A template that fails to render falls back to
+// the built-in one, so a broken template never stops conversations.
+func (t *Templates) Render(ctx context.Context, channelID string, vars Vars) (string, error) {
+	tmpl := t.lookup(ctx, channelID)
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		b.Reset()
+		if fallbackErr := builtIn.Execute(&b, vars); fallbackErr != nil {
+			return "", fmt.Errorf("failed to render built-in prompt: %v", fallbackErr)
+		}
+		return b.String(), fmt.Errorf("failed to render prompt template %s: %v", tmpl.Name(), err)
+	}
+	return b.String(), nil
+}
+
+// Reload loads the templates from the source now. It returns the names of the templates in use
+// and the problems of those that were skipped.
+func (t *Templates) Reload(ctx context.Context) ([]string, []string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	problems, err := t.reload(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := map[string]bool{}
+	var names []string
+	for _, set := range []map[string]*template.Template{t.loaded, t.configured} {
+		for name := range set {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, problems, nil
+}
+
+// lookup returns the template of the channel, loading the source's templates when they are due
+func (t *Templates) lookup(ctx context.Context, channelID string) *template.Template {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.source != nil && time.Since(t.loadedAt) > reloadInterval {
+		// Keep the previous templates when the source is unavailable
+		if problems, err := t.reload(ctx); err != nil {
+			logger.GetLogger().Warn("failed to load prompt templates, keeping the previous ones", zap.Error(err))
+		} else if len(problems) > 0 {
+			logger.GetLogger().Warn("skipped invalid prompt templates", zap.Strings("problems", problems))
+		}
+	}
+
+	for _, name := range []string{channelID, DefaultName} {
+		if tmpl, ok := t.loaded[name]; ok {
+			return tmpl
+		}
+		if tmpl, ok := t.configured[name]; ok {
+			return tmpl
+		}
+	}
+	return builtIn
+}
+
+// reload replaces the source's templates, skipping the ones that do not parse. t.mu must be held.
+func (t *Templates) reload(ctx context.Context) ([]string, error) {
+	t.loadedAt = time.Now()
+	if t.source == nil {
+		return nil, nil
+	}
+	texts, err := t.source.LoadPrompts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parsed, problems := parse(texts)
+	t.loaded = parsed
+	return problems, nil
+}
+
+// parse compiles the template texts. Templates that do not parse, or fail with empty values,
+// are left out and described in the returned problems.
+func parse(texts map[string]string) (map[string]*template.Template, []string) {
+	parsed := make(map[string]*template.Template, len(texts))
+	var problems []string
+	for name, text := range texts {
+		tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+		if err == nil {
+			err = tmpl.Execute(&strings.Builder{}, Vars{})
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		parsed[name] = tmpl
+	}
+	sort.Strings(problems)
+	return parsed, problems
+}
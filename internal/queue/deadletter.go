@@ -0,0 +1,126 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// maxReceiveBatch is the maximum number of messages SQS returns per ReceiveMessage call
+const maxReceiveBatch = 10
+
+// DeadLetter is an event that failed processing after all retries
+type DeadLetter struct {
+	MessageID string
+	Event     Event
+	Err       error     // Set when the message body is not a valid event
+	Reason    string    // Why processing failed, only known for stored failures
+	FailedAt  time.Time // When processing failed, only known for stored failures
+}
+
+// DeadLetterQueue reads events that the redrive policy moved out of the event queue and
+// sends them back for another attempt
+type DeadLetterQueue struct {
+	client    *sqs.Client
+	queueURL  string
+	publisher Publisher
+}
+
+// NewDeadLetterQueue creates a new DeadLetterQueue instance that replays into publisher
+func NewDeadLetterQueue(client *sqs.Client, queueURL string, publisher Publisher) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		client:    client,
+		queueURL:  queueURL,
+		publisher: publisher,
+	}
+}
+
+// Peek returns up to max dead letters without removing them
+func (q *DeadLetterQueue) Peek(ctx context.Context, max int) ([]DeadLetter, error) {
+	messages, err := q.receive(ctx, max, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	letters := make([]DeadLetter, 0, len(messages))
+	for _, msg := range messages {
+		letters = append(letters, decodeDeadLetter(msg))
+	}
+	return letters, nil
+}
+
+// Replay sends up to max dead letters back to the event queue and removes them from the
+// dead-letter queue. It returns the number of events replayed.
+func (q *DeadLetterQueue) Replay(ctx context.Context, max int) (int, error) {
+	// Hide the messages while they are replayed so concurrent replays do not send them twice
+	messages, err := q.receive(ctx, max, 60)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, msg := range messages {
+		letter := decodeDeadLetter(msg)
+		if letter.Err != nil {
+			// Leave undecodable messages for inspection
+			continue
+		}
+
+		letter.Event.Replays++
+		if err := q.publisher.Publish(ctx, letter.Event); err != nil {
+			return replayed, err
+		}
+		if _, err := q.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+			QueueUrl:      aws.String(q.queueURL),
+			ReceiptHandle: msg.ReceiptHandle,
+		}); err != nil {
+			return replayed, fmt.Errorf("failed to delete replayed message %s: %v", aws.ToString(msg.MessageId), err)
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// receive fetches up to max distinct messages, hiding them for visibilityTimeout seconds
+func (q *DeadLetterQueue) receive(ctx context.Context, max int, visibilityTimeout int32) ([]types.Message, error) {
+	var messages []types.Message
+	seen := map[string]bool{}
+	for len(messages) < max {
+		result, err := q.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(q.queueURL),
+			MaxNumberOfMessages: int32(min(max-len(messages), maxReceiveBatch)),
+			VisibilityTimeout:   visibilityTimeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive dead letters: %v", err)
+		}
+
+		added := 0
+		for _, msg := range result.Messages {
+			// Peeked messages stay visible and may be received again
+			if id := aws.ToString(msg.MessageId); !seen[id] {
+				seen[id] = true
+				messages = append(messages, msg)
+				added++
+			}
+		}
+		if added == 0 {
+			break
+		}
+	}
+	return messages, nil
+}
+
+// decodeDeadLetter parses the event in a dead-letter message
+func decodeDeadLetter(msg types.Message) DeadLetter {
+	letter := DeadLetter{MessageID: aws.ToString(msg.MessageId)}
+	if err := json.Unmarshal([]byte(aws.ToString(msg.Body)), &letter.Event); err != nil {
+		letter.Err = fmt.Errorf("invalid event: %v", err)
+	}
+	return letter
+}
@@ -0,0 +1,100 @@
+package queue
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Where a duplicate event was recognized
+const (
+	DuplicateMemory = "memory" // This instance saw the event recently
+	DuplicateLedger = "ledger" // Another instance, or this one before a restart, recorded the event
+)
+
+// EventLedger records processed event IDs so instances can share them
+type EventLedger interface {
+	// Record stores the event ID until the TTL passes and reports whether it was already recorded
+	Record(ctx context.Context, eventID string, ttl time.Duration) (bool, error)
+}
+
+// seenEvent is an event ID in the recently seen list
+type seenEvent struct {
+	id string
+	at time.Time
+}
+
+// Deduplicator remembers recently seen event IDs so deliveries Slack repeats, with or without a
+// retry header, are processed only once. The most recent IDs are kept in memory up to a limit,
+// and a ledger shares them between instances. If the ledger fails, the memory alone decides.
+type Deduplicator struct {
+	ttl    time.Duration
+	size   int
+	ledger EventLedger
+
+	mu     sync.Mutex
+	recent *list.List // Most recently seen first
+	seen   map[string]*list.Element
+}
+
+// NewDeduplicator creates a new Deduplicator instance keeping up to size event IDs in memory.
+// ledger may be nil.
+func NewDeduplicator(ttl time.Duration, size int, ledger EventLedger) *Deduplicator {
+	return &Deduplicator{
+		ttl:    ttl,
+		size:   size,
+		ledger: ledger,
+		recent: list.New(),
+		seen:   map[string]*list.Element{},
+	}
+}
+
+// Seen records the event ID and reports whether it was already seen within the TTL, and where.
+// A ledger error is returned along with the decision of the memory, so the event is not lost.
+func (d *Deduplicator) Seen(ctx context.Context, eventID string) (bool, string, error) {
+	if eventID == "" {
+		return false, "", nil
+	}
+	if d.remember(eventID) {
+		return true, DuplicateMemory, nil
+	}
+	if d.ledger == nil {
+		return false, "", nil
+	}
+	recorded, err := d.ledger.Record(ctx, eventID, d.ttl)
+	if err != nil {
+		return false, "", err
+	}
+	if recorded {
+		return true, DuplicateLedger, nil
+	}
+	return false, "", nil
+}
+
+// remember adds the event ID to the recently seen list and reports whether it was already there
+func (d *Deduplicator) remember(eventID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if element, ok := d.seen[eventID]; ok {
+		if now.Sub(element.Value.(seenEvent).at) <= d.ttl {
+			return true
+		}
+		d.recent.Remove(element)
+		delete(d.seen, eventID)
+	}
+	d.seen[eventID] = d.recent.PushFront(seenEvent{id: eventID, at: now})
+
+	// Drop the oldest IDs beyond the limit or the TTL
+	for oldest := d.recent.Back(); oldest != nil; oldest = d.recent.Back() {
+		event := oldest.Value.(seenEvent)
+		if d.recent.Len() <= d.size && now.Sub(event.at) <= d.ttl {
+			break
+		}
+		d.recent.Remove(oldest)
+		delete(d.seen, event.id)
+	}
+	return false
+}
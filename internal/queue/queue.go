@@ -0,0 +1,72 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// Event is a Slack event queued for asynchronous processing by the worker
+type Event struct {
+	EventID    string            `json:"event_id"`
+	ChannelID  string            `json:"channel_id,omitempty"`
+	ThreadTS   string            `json:"thread_ts,omitempty"`
+	ReceivedAt time.Time         `json:"received_at"`
+	Replays    int               `json:"replays,omitempty"` // Times the event was replayed from the dead-letter queue
+	Payload    json.RawMessage   `json:"payload"`           // Raw Events API request body
+	Trace      map[string]string `json:"trace,omitempty"`   // Trace context of the request that enqueued the event
+}
+
+// Publisher defines the interface for handing events to the worker
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// SQSPublisher implements Publisher using AWS SQS
+type SQSPublisher struct {
+	client   *sqs.Client
+	queueURL string
+	fifo     bool
+}
+
+// NewSQSPublisher creates a new SQSPublisher instance
+func NewSQSPublisher(client *sqs.Client, queueURL string) *SQSPublisher {
+	return &SQSPublisher{
+		client:   client,
+		queueURL: queueURL,
+		fifo:     strings.HasSuffix(queueURL, ".fifo"),
+	}
+}
+
+// Publish sends the event to the queue. On FIFO queues the Slack event ID is used for
+// deduplication and events of one channel are processed in order.
+func (p *SQSPublisher) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %v", err)
+	}
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(p.queueURL),
+		MessageBody: aws.String(string(body)),
+	}
+	if p.fifo {
+		groupID := event.ChannelID
+		if groupID == "" {
+			groupID = event.EventID
+		}
+		input.MessageGroupId = aws.String(groupID)
+		// A replay must not be dropped as a duplicate of the original delivery
+		input.MessageDeduplicationId = aws.String(fmt.Sprintf("%s-%d", event.EventID, event.Replays))
+	}
+
+	if _, err := p.client.SendMessage(ctx, input); err != nil {
+		return fmt.Errorf("failed to send event to SQS: %v", err)
+	}
+	return nil
+}
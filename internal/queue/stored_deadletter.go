@@ -0,0 +1,188 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// storedDeadLetterPrefix keeps failed events with the transcripts, as they hold the users'
+// messages, so the retention policy purges them
+const storedDeadLetterPrefix = "transcripts/dead-letters/"
+
+// storedDeadLetter is the stored form of a failed event
+type storedDeadLetter struct {
+	Event    Event     `json:"event"`
+	Reason   string    `json:"reason"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// S3DeadLetters keeps events that failed on the instance that received them, for deployments
+// without an event queue or events processed outside it. They are stored by event ID, which users
+// are given to quote.
+type S3DeadLetters struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3DeadLetters creates a new S3DeadLetters instance
+func NewS3DeadLetters(client *s3.Client, bucketName string) *S3DeadLetters {
+	return &S3DeadLetters{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// Add stores the failed event, replacing an earlier failure of the same event
+func (d *S3DeadLetters) Add(ctx context.Context, event Event, reason string) error {
+	data, err := json.Marshal(storedDeadLetter{Event: event, Reason: reason, FailedAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %v", err)
+	}
+	_, err = d.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(d.bucketName),
+		Key:         aws.String(d.key(event.EventID)),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store dead letter in S3: %v", err)
+	}
+	return nil
+}
+
+// Peek returns up to max stored failures, oldest first
+func (d *S3DeadLetters) Peek(ctx context.Context, max int) ([]DeadLetter, error) {
+	ids, err := d.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var letters []DeadLetter
+	for _, id := range ids {
+		letter, err := d.get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if letter != nil {
+			letters = append(letters, *letter)
+		}
+	}
+	sort.Slice(letters, func(i, j int) bool { return letters[i].FailedAt.Before(letters[j].FailedAt) })
+	if len(letters) > max {
+		letters = letters[:max]
+	}
+	return letters, nil
+}
+
+// Replay sends up to max stored failures, oldest first, to the publisher and removes them. It
+// returns the number of events replayed.
+func (d *S3DeadLetters) Replay(ctx context.Context, max int, publisher Publisher) (int, error) {
+	letters, err := d.Peek(ctx, max)
+	if err != nil {
+		return 0, err
+	}
+	replayed := 0
+	for _, letter := range letters {
+		if letter.Err != nil {
+			continue
+		}
+		if err := d.replay(ctx, letter, publisher); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// ReplayEvent sends the stored failure of the event to the publisher and removes it. It reports
+// false if no failure of the event is stored.
+func (d *S3DeadLetters) ReplayEvent(ctx context.Context, eventID string, publisher Publisher) (bool, error) {
+	letter, err := d.get(ctx, eventID)
+	if err != nil || letter == nil {
+		return false, err
+	}
+	if letter.Err != nil {
+		return true, letter.Err
+	}
+	return true, d.replay(ctx, *letter, publisher)
+}
+
+// replay removes the failure before publishing it, so a failure of the replay is stored again
+func (d *S3DeadLetters) replay(ctx context.Context, letter DeadLetter, publisher Publisher) error {
+	if _, err := d.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(d.bucketName),
+		Key:    aws.String(d.key(letter.MessageID)),
+	}); err != nil {
+		return fmt.Errorf("failed to delete dead letter %s: %v", letter.MessageID, err)
+	}
+	letter.Event.Replays++
+	if err := publisher.Publish(ctx, letter.Event); err != nil {
+		// Keep the event for another attempt
+		if addErr := d.Add(ctx, letter.Event, letter.Reason); addErr != nil {
+			return fmt.Errorf("%v, and the event could not be stored again: %v", err, addErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// list returns the event IDs of the stored failures
+func (d *S3DeadLetters) list(ctx context.Context) ([]string, error) {
+	var ids []string
+	paginator := s3.NewListObjectsV2Paginator(d.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(d.bucketName),
+		Prefix: aws.String(storedDeadLetterPrefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list dead letters in S3: %v", err)
+		}
+		for _, obj := range page.Contents {
+			name := strings.TrimPrefix(aws.ToString(obj.Key), storedDeadLetterPrefix)
+			if id, ok := strings.CutSuffix(name, ".json"); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// get returns the stored failure of the event, nil if there is none
+func (d *S3DeadLetters) get(ctx context.Context, eventID string) (*DeadLetter, error) {
+	result, err := d.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(d.bucketName),
+		Key:    aws.String(d.key(eventID)),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get dead letter from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	letter := DeadLetter{MessageID: eventID}
+	var stored storedDeadLetter
+	if err := json.NewDecoder(result.Body).Decode(&stored); err != nil {
+		letter.Err = fmt.Errorf("invalid event: %v", err)
+		return &letter, nil
+	}
+	letter.Event, letter.Reason, letter.FailedAt = stored.Event, stored.Reason, stored.FailedAt
+	return &letter, nil
+}
+
+// key returns the object key of the event's failure
+func (d *S3DeadLetters) key(eventID string) string {
+	return storedDeadLetterPrefix + eventID + ".json"
+}
@@ -0,0 +1,125 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"jira_helper/internal/storage"
+)
+
+// ExceededError reports that a user has used up a quota until ResetAt
+type ExceededError struct {
+	Quota   string // Description of the quota, e.g. "30 requests per hour"
+	ResetAt time.Time
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("quota of %s exceeded until %s", e.Quota, e.ResetAt.Format(time.RFC3339))
+}
+
+// Limiter enforces per-user quotas on the number of requests per hour and the number of AI tokens
+// per day. Usage is counted in a shared store, so the quotas hold across instances. Windows are
+// aligned to UTC hours and days.
+type Limiter struct {
+	store           storage.CounterStore
+	requestsPerHour int64
+	dailyTokens     int64
+}
+
+// NewLimiter creates a new Limiter instance. A limit of 0 disables that quota.
+func NewLimiter(store storage.CounterStore, requestsPerHour, dailyTokens int) *Limiter {
+	return &Limiter{
+		store:           store,
+		requestsPerHour: int64(requestsPerHour),
+		dailyTokens:     int64(dailyTokens),
+	}
+}
+
+// Allow counts a request by the user, or returns an *ExceededError if the user has used up a quota
+func (l *Limiter) Allow(ctx context.Context, userID string) error {
+	if l == nil || userID == "" {
+		return nil
+	}
+	now := time.Now().UTC()
+
+	if l.dailyTokens > 0 {
+		used, err := l.store.Get(ctx, tokensKey(userID, now))
+		if err != nil {
+			return err
+		}
+		if used >= l.dailyTokens {
+			return &ExceededError{Quota: fmt.Sprintf("%d AI tokens per day", l.dailyTokens), ResetAt: endOfDay(now)}
+		}
+	}
+
+	if l.requestsPerHour > 0 {
+		key := requestsKey(userID, now)
+		used, err := l.store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if used >= l.requestsPerHour {
+			return &ExceededError{Quota: fmt.Sprintf("%d requests per hour", l.requestsPerHour), ResetAt: endOfHour(now)}
+		}
+		if _, err := l.store.Add(ctx, key, 1, endOfHour(now)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddTokens counts AI tokens used on behalf of the user towards the daily budget
+func (l *Limiter) AddTokens(ctx context.Context, userID string, tokens int) error {
+	if l == nil || userID == "" || l.dailyTokens <= 0 || tokens <= 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	_, err := l.store.Add(ctx, tokensKey(userID, now), int64(tokens), endOfDay(now))
+	return err
+}
+
+// requestsKey is the counter of the user's requests in the current hour
+func requestsKey(userID string, now time.Time) string {
+	return "requests/" + userID + "/" + now.Format("2006010215")
+}
+
+// tokensKey is the counter of the user's AI tokens on the current day
+func tokensKey(userID string, now time.Time) string {
+	return "tokens/" + userID + "/" + now.Format("20060102")
+}
+
+func endOfHour(now time.Time) time.Time {
+	return now.Truncate(time.Hour).Add(time.Hour)
+}
+
+func endOfDay(now time.Time) time.Time {
+	year, month, day := now.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, time.UTC)
+}
+
+// Usage is a user's consumption of the quotas. A limit of 0 means the quota is disabled.
+type Usage struct {
+	Requests        int64 `json:"requests"`
+	RequestsPerHour int64 `json:"requests_per_hour"`
+	Tokens          int64 `json:"tokens"`
+	DailyTokens     int64 `json:"daily_tokens"`
+}
+
+// Usage returns what the user has used of the current hour's requests and the day's AI tokens
+func (l *Limiter) Usage(ctx context.Context, userID string) (Usage, error) {
+	usage := Usage{RequestsPerHour: l.requestsPerHour, DailyTokens: l.dailyTokens}
+	now := time.Now().UTC()
+	var err error
+	if l.requestsPerHour > 0 {
+		if usage.Requests, err = l.store.Get(ctx, requestsKey(userID, now)); err != nil {
+			return usage, err
+		}
+	}
+	if l.dailyTokens > 0 {
+		if usage.Tokens, err = l.store.Get(ctx, tokensKey(userID, now)); err != nil {
+			return usage, err
+		}
+	}
+	return usage, nil
+}
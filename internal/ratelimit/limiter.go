@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// maxIdleBuckets bounds the buckets kept before full ones are dropped, a full bucket behaves
+// like a new one
+const maxIdleBuckets = 10000
+
+// bucket holds the tokens of one key
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter is a token bucket per key. Each bucket holds up to burst tokens and refills at a steady
+// rate. State is kept in memory, so each instance enforces the limit independently.
+type Limiter struct {
+	perSecond float64
+	burst     float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewLimiter creates a limiter that allows count operations per interval, and bursts of up to burst
+func NewLimiter(count int, interval time.Duration, burst int) *Limiter {
+	return &Limiter{
+		perSecond: float64(count) / interval.Seconds(),
+		burst:     float64(burst),
+		buckets:   map[string]*bucket{},
+	}
+}
+
+// Reserve takes a token for the key if one is available. Otherwise it reports how long it takes
+// until the next token is.
+func (l *Limiter) Reserve(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refillLocked(key, time.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.perSecond * float64(time.Second))
+}
+
+// Penalize empties the key's bucket so the next token is only available after d, e.g. the
+// Retry-After of an API that reported the rate limit was hit
+func (l *Limiter) Penalize(key string, d time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refillLocked(key, time.Now())
+	b.tokens = 1 - d.Seconds()*l.perSecond
+}
+
+// refillLocked returns the key's bucket with the tokens earned since it was last used
+func (l *Limiter) refillLocked(key string, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.pruneLocked(now)
+		}
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+		return b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.perSecond)
+	b.updated = now
+	return b
+}
+
+// pruneLocked drops the buckets that have refilled completely
+func (l *Limiter) pruneLocked(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.perSecond >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
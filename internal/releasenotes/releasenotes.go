@@ -0,0 +1,152 @@
+package releasenotes
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"jira_helper/internal/jql"
+	"jira_helper/internal/service/jira"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+)
+
+// maxIssues bounds the issues of a release the notes are drafted from
+const maxIssues = 300
+
+// Groups of issues, in the order the notes list them
+const (
+	GroupFeatures = "Features"
+	GroupBugs     = "Bug fixes"
+	GroupTasks    = "Other changes"
+)
+
+// featureTypes are the issue types listed as features, other types than bugs are tasks
+var featureTypes = []string{"story", "new feature", "feature", "improvement", "epic"}
+
+// draftPrompt instructs the model how to write the notes
+const draftPrompt = `You write release notes for %s version %s from the resolved Jira issues the user lists.
+
+- Answer with the release notes in Markdown only, without a code block or any other text
+- Start with a one or two sentence overview of the release
+- Use a "## " heading per group, in the order given, and skip empty groups
+- Write one "- " bullet per issue in plain language for the people using the product, ending with the issue key in parentheses, e.g. (PROJ-123)
+- Merge issues that describe the same change into one bullet with all their keys
+- Only describe what the issues say, do not invent changes`
+
+// Chatter completes a chat. The AI providers implement it.
+type Chatter interface {
+	Chat(ctx context.Context, messages []azopenai.ChatRequestMessageClassification) (string, error)
+}
+
+// Jira is the part of the Jira client the issues are read with. *jira.Client implements it.
+type Jira interface {
+	Search(ctx context.Context, jql string, opts jira.SearchOptions) ([]jira.Issue, int, error)
+}
+
+// Issue is a resolved issue of the release
+type Issue struct {
+	Key     string
+	Summary string
+	Type    string
+}
+
+// Group is the issues of the release of one kind
+type Group struct {
+	Name   string
+	Issues []Issue
+}
+
+// Release is the resolved issues of a project's fix version, grouped by kind
+type Release struct {
+	Project string
+	Version string
+	Groups  []Group // Features, bug fixes and other changes, without empty groups
+	Issues  int     // Issues read
+	Total   int     // Issues resolved in the version, more than Issues when the release is too large
+}
+
+// Collect reads the issues resolved in the fix version of the project and groups them by type
+func Collect(ctx context.Context, client Jira, project, version string) (*Release, error) {
+	query := fmt.Sprintf("project = %s AND fixVersion = %s AND resolution IS NOT EMPTY ORDER BY issuetype, key",
+		jql.Quote(project), jql.Quote(version))
+	issues, total, err := client.Search(ctx, query, jira.SearchOptions{Fields: []string{"summary", "issuetype"}, Limit: maxIssues})
+	if err != nil {
+		return nil, err
+	}
+
+	release := &Release{Project: project, Version: version, Issues: len(issues), Total: total}
+	groups := map[string][]Issue{}
+	for _, issue := range issues {
+		item := Issue{Key: issue.Key, Summary: issue.Fields.Summary}
+		if issue.Fields.IssueType != nil {
+			item.Type = issue.Fields.IssueType.Name
+		}
+		group := groupOf(item.Type)
+		groups[group] = append(groups[group], item)
+	}
+	for _, name := range []string{GroupFeatures, GroupBugs, GroupTasks} {
+		if len(groups[name]) > 0 {
+			release.Groups = append(release.Groups, Group{Name: name, Issues: groups[name]})
+		}
+	}
+	return release, nil
+}
+
+// Draft has the model write the release notes in Markdown
+func Draft(ctx context.Context, chat Chatter, release *Release) (string, error) {
+	var issues strings.Builder
+	for _, group := range release.Groups {
+		fmt.Fprintf(&issues, "%s:\n", group.Name)
+		for _, issue := range group.Issues {
+			fmt.Fprintf(&issues, "- %s [%s] %s\n", issue.Key, issue.Type, issue.Summary)
+		}
+	}
+
+	notes, err := chat.Chat(ctx, []azopenai.ChatRequestMessageClassification{
+		&azopenai.ChatRequestSystemMessage{
+			Content: azopenai.NewChatRequestSystemMessageContent(fmt.Sprintf(draftPrompt, release.Project, release.Version)),
+		},
+		&azopenai.ChatRequestUserMessage{
+			Content: azopenai.NewChatRequestUserMessageContent(issues.String()),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to draft release notes: %v", err)
+	}
+	notes = strings.TrimSpace(notes)
+	if notes == "" {
+		return "", fmt.Errorf("the model returned empty release notes")
+	}
+	return notes, nil
+}
+
+// groupOf returns the group of an issue type
+func groupOf(issueType string) string {
+	issueType = strings.ToLower(issueType)
+	if issueType == "bug" || issueType == "defect" {
+		return GroupBugs
+	}
+	for _, feature := range featureTypes {
+		if issueType == feature {
+			return GroupFeatures
+		}
+	}
+	return GroupTasks
+}
+
+var (
+	headingPattern = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+	boldPattern    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	bulletPattern  = regexp.MustCompile(`(?m)^(\s*)[-*]\s+`)
+	keyPattern     = regexp.MustCompile(`\b[A-Z][A-Z0-9_]+-\d+\b`)
+)
+
+// Slack converts Markdown notes to Slack's mrkdwn, linking issue keys with link
+func Slack(notes string, link func(key string) string) string {
+	notes = bulletPattern.ReplaceAllString(notes, "$1• ")
+	notes = boldPattern.ReplaceAllString(notes, "*$1*")
+	notes = headingPattern.ReplaceAllString(notes, "*$1*")
+	return keyPattern.ReplaceAllStringFunc(notes, link)
+}
@@ -0,0 +1,240 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"jira_helper/internal/dates"
+	"jira_helper/internal/service/jira"
+	"jira_helper/internal/workflow"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	// maxListedIssues bounds the issues a list shows, Slack allows 50 blocks per message
+	maxListedIssues = 20
+
+	// maxDescriptionLength shortens the description an issue card quotes
+	maxDescriptionLength = 500
+
+	// maxSectionLength is the most text Slack accepts in a section block
+	maxSectionLength = 3000
+
+	// progressBarWidth is the number of blocks in a sprint's progress bar
+	progressBarWidth = 20
+)
+
+// Status categories, in the order summaries list them
+var statusCategories = []struct {
+	key   string
+	title string
+}{
+	{"indeterminate", "🔄 In progress"},
+	{"new", "📋 To do"},
+	{"done", "✅ Done"},
+}
+
+// Renderer renders Jira issues as Block Kit messages with fixed templates, so they look the same
+// whatever the model would have written
+type Renderer struct {
+	jiraURL string          // Base URL issues link to, keys are not linked without it
+	dates   dates.Formatter // Renders dates in the reader's time zone where Slack cannot
+}
+
+// New creates a Renderer linking issues to the Jira instance at jiraURL
+func New(jiraURL string) *Renderer {
+	return &Renderer{jiraURL: strings.TrimSuffix(jiraURL, "/")}
+}
+
+// In returns a copy of the Renderer showing dates in the IANA time zone
+func (r *Renderer) In(timezone string) *Renderer {
+	copied := *r
+	copied.dates = dates.In(timezone)
+	return &copied
+}
+
+// IssueCard renders an issue with its main fields and the start of its description
+func (r *Renderer) IssueCard(issue jira.Issue) []slack.Block {
+	f := issue.Fields
+	var fields []*slack.TextBlockObject
+	add := func(name, value string) {
+		if value != "" {
+			fields = append(fields, slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("*%s*\n%s", name, value), false, false))
+		}
+	}
+	add("Type", nameOf(f.IssueType))
+	if f.Status != nil {
+		add("Status", f.Status.Name)
+	}
+	add("Priority", nameOf(f.Priority))
+	add("Assignee", assigneeOf(issue))
+	if f.Reporter != nil {
+		add("Reporter", f.Reporter.DisplayName)
+	}
+	if !f.Updated.IsZero() {
+		add("Updated", slackDate(f.Updated.Unix(), r.dates.Date(f.Updated.Time)))
+	}
+	add("Due", f.DueDate)
+
+	blocks := []slack.Block{
+		slack.NewSectionBlock(markdown(fmt.Sprintf("*%s %s*", r.issueLink(issue.Key), escape(f.Summary))), fields, nil),
+	}
+	if description := strings.TrimSpace(f.Description); description != "" {
+		description = truncate(description, maxDescriptionLength)
+		blocks = append(blocks, slack.NewSectionBlock(markdown(">"+strings.ReplaceAll(escape(description), "\n", "\n>")), nil, nil))
+	}
+	if len(f.Labels) > 0 {
+		blocks = append(blocks, slack.NewContextBlock("", markdown("🏷️ "+escape(strings.Join(f.Labels, ", ")))))
+	}
+	return blocks
+}
+
+// SearchResults renders the issues found by a search, one line each, with how many there are in
+// total
+func (r *Renderer) SearchResults(issues []jira.Issue, total int) []slack.Block {
+	if total < len(issues) {
+		total = len(issues)
+	}
+	if len(issues) == 0 {
+		return []slack.Block{slack.NewSectionBlock(markdown("No issues found."), nil, nil)}
+	}
+	shown := issues
+	if len(shown) > maxListedIssues {
+		shown = shown[:maxListedIssues]
+	}
+	blocks := []slack.Block{
+		slack.NewSectionBlock(markdown(fmt.Sprintf("*Showing %d of %d issues*", len(shown), total)), nil, nil),
+	}
+	blocks = append(blocks, r.issueLines(shown)...)
+	return blocks
+}
+
+// SprintSummary renders a sprint's progress, counting its issues by status category, and lists
+// the issues of each category
+func (r *Renderer) SprintSummary(title string, issues []jira.Issue) []slack.Block {
+	if len(issues) == 0 {
+		return []slack.Block{slack.NewSectionBlock(markdown(fmt.Sprintf("*%s*\nThe sprint has no issues.", escape(title))), nil, nil)}
+	}
+	byCategory := map[string][]jira.Issue{}
+	for _, issue := range issues {
+		category := categoryOf(issue)
+		byCategory[category] = append(byCategory[category], issue)
+	}
+
+	counts := make([]string, 0, len(statusCategories))
+	for _, category := range statusCategories {
+		counts = append(counts, fmt.Sprintf("%s: %d", category.title, len(byCategory[category.key])))
+	}
+	done := float64(len(byCategory["done"])) / float64(len(issues))
+	blocks := []slack.Block{
+		slack.NewSectionBlock(markdown(fmt.Sprintf("*%s* — %d issues\n`%s`", escape(title), len(issues), workflow.ProgressBar(done, progressBarWidth))), nil, nil),
+		slack.NewContextBlock("", markdown(strings.Join(counts, "  ·  "))),
+	}
+
+	remaining := maxListedIssues
+	for _, category := range statusCategories {
+		listed := byCategory[category.key]
+		if len(listed) == 0 || remaining == 0 {
+			continue
+		}
+		if len(listed) > remaining {
+			listed = listed[:remaining]
+		}
+		remaining -= len(listed)
+		lines := make([]string, 0, len(listed))
+		for _, issue := range listed {
+			lines = append(lines, "• "+r.issueLine(issue))
+		}
+		blocks = append(blocks, slack.NewDividerBlock(),
+			slack.NewSectionBlock(markdown(truncate(fmt.Sprintf("*%s*\n%s", category.title, strings.Join(lines, "\n")), maxSectionLength)), nil, nil))
+	}
+	if remaining == 0 && len(issues) > maxListedIssues {
+		blocks = append(blocks, slack.NewContextBlock("", markdown(fmt.Sprintf("…and %d more issues", len(issues)-maxListedIssues))))
+	}
+	return blocks
+}
+
+// issueLines renders each issue as a section with a link, its summary and its main fields
+func (r *Renderer) issueLines(issues []jira.Issue) []slack.Block {
+	blocks := make([]slack.Block, 0, len(issues))
+	for _, issue := range issues {
+		blocks = append(blocks, slack.NewSectionBlock(markdown(r.issueLine(issue)), nil, nil))
+	}
+	return blocks
+}
+
+// issueLine renders an issue as a single line with a link, status and assignee
+func (r *Renderer) issueLine(issue jira.Issue) string {
+	details := []string{}
+	if name := nameOf(issue.Fields.IssueType); name != "" {
+		details = append(details, name)
+	}
+	if issue.Fields.Status != nil {
+		details = append(details, "*"+issue.Fields.Status.Name+"*")
+	}
+	if name := nameOf(issue.Fields.Priority); name != "" {
+		details = append(details, name)
+	}
+	details = append(details, assigneeOf(issue))
+	return fmt.Sprintf("%s %s — %s", r.issueLink(issue.Key), escape(issue.Fields.Summary), strings.Join(details, " · "))
+}
+
+// issueLink renders the issue key as a Slack link to the issue
+func (r *Renderer) issueLink(key string) string {
+	if r.jiraURL == "" {
+		return key
+	}
+	return fmt.Sprintf("<%s/browse/%s|%s>", r.jiraURL, key, key)
+}
+
+// categoryOf returns the key of the issue's status category, issues without one count as to do
+func categoryOf(issue jira.Issue) string {
+	if status := issue.Fields.Status; status != nil && status.Category != nil {
+		switch status.Category.Key {
+		case "indeterminate", "done":
+			return status.Category.Key
+		}
+	}
+	return "new"
+}
+
+// assigneeOf returns the name of the issue's assignee
+func assigneeOf(issue jira.Issue) string {
+	if issue.Fields.Assignee == nil || issue.Fields.Assignee.DisplayName == "" {
+		return "Unassigned"
+	}
+	return issue.Fields.Assignee.DisplayName
+}
+
+// nameOf returns the name of a field value, empty when it is not set
+func nameOf(value *jira.Named) string {
+	if value == nil {
+		return ""
+	}
+	return value.Name
+}
+
+// markdown is a text object with Slack markdown
+func markdown(text string) *slack.TextBlockObject {
+	return slack.NewTextBlockObject(slack.MarkdownType, text, false, false)
+}
+
+// slackDate renders a timestamp in the reader's time zone, with fallback for clients that cannot
+func slackDate(unix int64, fallback string) string {
+	return fmt.Sprintf("<!date^%d^{date_short}|%s>", unix, fallback)
+}
+
+// escape keeps text from Jira from being read as Slack markup
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// truncate shortens the text to at most n characters
+func truncate(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	return string([]rune(text)[:n-1]) + "…"
+}
@@ -0,0 +1,171 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"jira_helper/internal/service/jira"
+
+	"github.com/slack-go/slack"
+)
+
+// Templates the results of the Jira tools are rendered with
+const (
+	templateIssue  = "issue"
+	templateSearch = "search"
+	templateSprint = "sprint"
+)
+
+// toolTemplates maps the tools whose results are structured to their template
+var toolTemplates = map[string]string{
+	"jira_get_issue":          templateIssue,
+	"jira_search":             templateSearch,
+	"jira_get_project_issues": templateSearch,
+	"jira_get_epic_issues":    templateSearch,
+	"jira_get_board_issues":   templateSearch,
+	"jira_get_sprint_issues":  templateSprint,
+}
+
+// categoryKeys maps the status category names the MCP server returns to their keys
+var categoryKeys = map[string]string{
+	"to do":       "new",
+	"in progress": "indeterminate",
+	"done":        "done",
+}
+
+// Supports reports whether the tool's results are rendered with a template
+func Supports(tool string) bool {
+	_, ok := toolTemplates[tool]
+	return ok
+}
+
+// ToolResult renders the result of a Jira tool with its template. It reports false when the tool
+// has no template or the result is not the JSON the template expects, so the model's own
+// formatting is used instead.
+func (r *Renderer) ToolResult(tool string, args map[string]interface{}, result string) ([]slack.Block, bool) {
+	template, ok := toolTemplates[tool]
+	if !ok {
+		return nil, false
+	}
+	switch template {
+	case templateIssue:
+		var issue toolIssue
+		if err := json.Unmarshal([]byte(result), &issue); err != nil || issue.Key == "" {
+			return nil, false
+		}
+		return r.IssueCard(issue.Issue()), true
+	default:
+		var list struct {
+			Total  int         `json:"total"`
+			Issues []toolIssue `json:"issues"`
+		}
+		if err := json.Unmarshal([]byte(result), &list); err != nil || list.Issues == nil {
+			return nil, false
+		}
+		issues := make([]jira.Issue, 0, len(list.Issues))
+		for _, issue := range list.Issues {
+			issues = append(issues, issue.Issue())
+		}
+		if template == templateSprint {
+			title := "Sprint"
+			if id, ok := args["sprint_id"]; ok {
+				title = fmt.Sprintf("Sprint %v", id)
+			}
+			return r.SprintSummary(title, issues), true
+		}
+		return r.SearchResults(issues, list.Total), true
+	}
+}
+
+// toolIssue is an issue as the MCP server returns it, either simplified with the fields at the
+// top level or as the REST API returns it
+type toolIssue struct {
+	ID          string     `json:"id"`
+	Key         string     `json:"key"`
+	Summary     string     `json:"summary"`
+	Description string     `json:"description"`
+	Status      *toolNamed `json:"status"`
+	IssueType   *toolNamed `json:"issue_type"`
+	Priority    *toolNamed `json:"priority"`
+	Assignee    *toolUser  `json:"assignee"`
+	Reporter    *toolUser  `json:"reporter"`
+	Labels      []string   `json:"labels"`
+	Updated     string     `json:"updated"`
+	DueDate     string     `json:"duedate"`
+
+	Fields json.RawMessage `json:"fields"` // Set in the REST API's form
+}
+
+// toolNamed is a field value of a simplified issue, the category is only set for statuses
+type toolNamed struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// toolUser is a user of a simplified issue, which may also be given as just the name
+type toolUser struct {
+	DisplayName string `json:"display_name"`
+	Name        string `json:"name"`
+}
+
+// UnmarshalJSON accepts a user object or a name
+func (u *toolUser) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		u.DisplayName = name
+		return nil
+	}
+	type user toolUser
+	return json.Unmarshal(data, (*user)(u))
+}
+
+// Issue converts the issue to the Jira service's type
+func (t toolIssue) Issue() jira.Issue {
+	if len(t.Fields) > 0 && string(t.Fields) != "null" {
+		var issue jira.Issue
+		if err := json.Unmarshal(t.Fields, &issue.Fields); err == nil {
+			issue.ID, issue.Key = t.ID, t.Key
+			return issue
+		}
+	}
+
+	issue := jira.Issue{ID: t.ID, Key: t.Key}
+	f := &issue.Fields
+	f.Summary, f.Description, f.Labels, f.DueDate = t.Summary, t.Description, t.Labels, t.DueDate
+	if t.Status != nil {
+		f.Status = &jira.Status{Named: jira.Named{Name: t.Status.Name}}
+		if key, ok := categoryKeys[strings.ToLower(t.Status.Category)]; ok {
+			f.Status.Category = &jira.StatusCategory{Key: key, Name: t.Status.Category}
+		}
+	}
+	if t.IssueType != nil {
+		f.IssueType = &jira.Named{Name: t.IssueType.Name}
+	}
+	if t.Priority != nil {
+		f.Priority = &jira.Named{Name: t.Priority.Name}
+	}
+	if user := t.Assignee.user(); user != nil && user.DisplayName != "Unassigned" {
+		f.Assignee = user
+	}
+	f.Reporter = t.Reporter.user()
+	if t.Updated != "" {
+		_ = f.Updated.UnmarshalJSON([]byte(`"` + t.Updated + `"`))
+	}
+	return issue
+}
+
+// user converts the user to the Jira service's type, nil when there is none
+func (u *toolUser) user() *jira.User {
+	if u == nil {
+		return nil
+	}
+	name := u.DisplayName
+	if name == "" {
+		name = u.Name
+	}
+	if name == "" {
+		return nil
+	}
+	return &jira.User{Name: u.Name, DisplayName: name}
+}
@@ -0,0 +1,149 @@
+// Package replay records what the model answered and what the MCP tools returned during
+// conversations to a golden file, and replays the file in place of both, so the conversation loop
+// can be run deterministically without a model or a Jira instance.
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"jira_helper/internal/service/openai"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+)
+
+// Kinds of interaction
+const (
+	KindChat       = "chat"       // Model call without tools, e.g. a summary
+	KindCompletion = "completion" // Model call of a conversation round
+	KindToolCall   = "tool_call"  // MCP tool call
+)
+
+// Cassette is the content of a golden file
+type Cassette struct {
+	Tools        json.RawMessage `json:"tools,omitempty"` // ListTools result of the MCP server
+	Interactions []Interaction   `json:"interactions"`
+}
+
+// Interaction is a recorded model or tool call with its outcome
+type Interaction struct {
+	Kind   string `json:"kind"`
+	Thread string `json:"thread,omitempty"` // First user message of the conversation
+	Query  string `json:"query,omitempty"`  // Last user message of the conversation
+
+	// Model calls
+	Messages   []Message   `json:"messages,omitempty"` // Request without the system messages
+	Tools      []string    `json:"tools,omitempty"`    // Names of the tools offered to the model
+	Content    string      `json:"content,omitempty"`  // Answer of a chat call
+	Completion *Completion `json:"completion,omitempty"`
+
+	// Tool calls
+	Tool   string                 `json:"tool,omitempty"`
+	Args   map[string]interface{} `json:"args,omitempty"`
+	Result json.RawMessage        `json:"result,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// Message is a message sent to the model
+type Message struct {
+	Role       string `json:"role"`
+	Content    string `json:"content,omitempty"`
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	ToolCalls  []Call `json:"tool_calls,omitempty"`
+}
+
+// Call is a tool call of the model
+type Call struct {
+	ID   string                 `json:"id"`
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// Completion is the model's response to a conversation round
+type Completion struct {
+	Content          string `json:"content,omitempty"`
+	ToolCalls        []Call `json:"tool_calls,omitempty"`
+	IsComplete       bool   `json:"is_complete"`
+	PromptTokens     int    `json:"prompt_tokens,omitempty"`
+	CompletionTokens int    `json:"completion_tokens,omitempty"`
+}
+
+// Load reads a golden file
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read golden file: %v", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse golden file %s: %v", path, err)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to a golden file
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode golden file: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write golden file: %v", err)
+	}
+	return nil
+}
+
+// modelCall returns the interaction of a model call, without its outcome. System messages are
+// left out: they carry the date and time, which would change the request on every run.
+func modelCall(kind string, messages []azopenai.ChatRequestMessageClassification, tools []openai.Tool) (Interaction, error) {
+	converted, err := openai.Messages(messages)
+	if err != nil {
+		return Interaction{}, err
+	}
+	interaction := Interaction{Kind: kind}
+	for _, msg := range converted {
+		if msg.Role == "system" {
+			continue
+		}
+		if msg.Role == "user" {
+			if interaction.Thread == "" {
+				interaction.Thread = msg.Content
+			}
+			interaction.Query = msg.Content
+		}
+		interaction.Messages = append(interaction.Messages, Message{
+			Role:       msg.Role,
+			Content:    msg.Content,
+			ToolCallID: msg.ToolCallID,
+			ToolCalls:  calls(msg.ToolCalls),
+		})
+	}
+	for _, tool := range tools {
+		interaction.Tools = append(interaction.Tools, tool.Name)
+	}
+	return interaction, nil
+}
+
+// calls converts the tool calls of the model
+func calls(toolCalls []openai.ToolCall) []Call {
+	var converted []Call
+	for _, call := range toolCalls {
+		converted = append(converted, Call{ID: call.ID, Name: call.Name, Args: call.Args})
+	}
+	return converted
+}
+
+// key identifies the request of an interaction, replays answer the recorded interaction with the
+// same key
+func (i Interaction) key() string {
+	request := Interaction{Kind: i.Kind, Messages: i.Messages, Tools: i.Tools, Tool: i.Tool, Args: i.Args}
+	data, _ := json.Marshal(request) // Map keys are sorted, so equal requests encode the same
+	return string(data)
+}
+
+// final reports whether the interaction is the model's last answer of a conversation
+func (i Interaction) final() bool {
+	return i.Kind == KindCompletion && i.Error == "" && i.Completion != nil && len(i.Completion.ToolCalls) == 0
+}
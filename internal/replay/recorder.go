@@ -0,0 +1,148 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/openai"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+// Model is the chat model of the handler
+type Model interface {
+	Chat(ctx context.Context, messages []azopenai.ChatRequestMessageClassification) (string, error)
+	ChatWithTools(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []openai.Tool) (*openai.ChatResponse, error)
+}
+
+// Client is an MCP client of the handler
+type Client interface {
+	Initialize(context.Context, mcp.InitializeRequest) (*mcp.InitializeResult, error)
+	ListTools(context.Context, mcp.ListToolsRequest) (*mcp.ListToolsResult, error)
+	CallTool(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+	Close() error
+}
+
+// Recorder writes the model and tool calls of conversations to a golden file. The file is
+// rewritten after every call, so it is complete whenever the process stops.
+type Recorder struct {
+	path     string
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder creates a recorder writing to the golden file at path
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+// Model returns the model, recording its calls
+func (r *Recorder) Model(model Model) Model {
+	return &recordingModel{model: model, recorder: r}
+}
+
+// Client returns the MCP client, recording its tools and tool calls
+func (r *Recorder) Client(client Client) Client {
+	return &recordingClient{Client: client, recorder: r}
+}
+
+// add appends the interaction and saves the golden file
+func (r *Recorder) add(interaction Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.save()
+}
+
+// save writes the golden file, failures are logged since they must not break the conversation
+func (r *Recorder) save() {
+	if err := r.cassette.Save(r.path); err != nil {
+		logger.GetLogger().Error("failed to save recording", zap.String("path", r.path), zap.Error(err))
+	}
+}
+
+// recordingModel records the calls of a model
+type recordingModel struct {
+	model    Model
+	recorder *Recorder
+}
+
+// Chat calls the model and records the answer
+func (m *recordingModel) Chat(ctx context.Context, messages []azopenai.ChatRequestMessageClassification) (string, error) {
+	content, err := m.model.Chat(ctx, messages)
+	interaction, convErr := modelCall(KindChat, messages, nil)
+	if convErr != nil {
+		logger.GetLogger().Warn("failed to record model call", zap.Error(convErr))
+		return content, err
+	}
+	interaction.Content = content
+	if err != nil {
+		interaction.Error = err.Error()
+	}
+	m.recorder.add(interaction)
+	return content, err
+}
+
+// ChatWithTools calls the model and records its response
+func (m *recordingModel) ChatWithTools(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []openai.Tool) (*openai.ChatResponse, error) {
+	response, err := m.model.ChatWithTools(ctx, messages, tools)
+	interaction, convErr := modelCall(KindCompletion, messages, tools)
+	if convErr != nil {
+		logger.GetLogger().Warn("failed to record model call", zap.Error(convErr))
+		return response, err
+	}
+	if err != nil {
+		interaction.Error = err.Error()
+	} else if response != nil {
+		interaction.Completion = &Completion{
+			Content:          response.Content,
+			ToolCalls:        calls(response.ToolCalls),
+			IsComplete:       response.IsComplete,
+			PromptTokens:     response.PromptTokens,
+			CompletionTokens: response.CompletionTokens,
+		}
+	}
+	m.recorder.add(interaction)
+	return response, err
+}
+
+// recordingClient records the tools and tool calls of an MCP client
+type recordingClient struct {
+	Client
+	recorder *Recorder
+}
+
+// ListTools lists the tools of the server and records them
+func (c *recordingClient) ListTools(ctx context.Context, request mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	result, err := c.Client.ListTools(ctx, request)
+	if err != nil {
+		return result, err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		logger.GetLogger().Warn("failed to record tools", zap.Error(err))
+		return result, nil
+	}
+	c.recorder.mu.Lock()
+	defer c.recorder.mu.Unlock()
+	c.recorder.cassette.Tools = data
+	c.recorder.save()
+	return result, nil
+}
+
+// CallTool calls the tool and records its result
+func (c *recordingClient) CallTool(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	result, err := c.Client.CallTool(ctx, request)
+	interaction := Interaction{Kind: KindToolCall, Tool: request.Params.Name, Args: request.GetArguments()}
+	if err != nil {
+		interaction.Error = err.Error()
+	} else if data, marshalErr := json.Marshal(result); marshalErr == nil {
+		interaction.Result = data
+	}
+	c.recorder.add(interaction)
+	return result, err
+}
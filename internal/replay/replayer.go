@@ -0,0 +1,220 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"jira_helper/internal/service/openai"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// pollInterval is how often Wait checks whether a conversation has finished
+const pollInterval = 50 * time.Millisecond
+
+// Replayer answers model and tool calls from a golden file. A call is answered by the first
+// unused recorded interaction with the same request, so conversations that ran concurrently while
+// recording replay in any order. A call without one is a mismatch: the conversation loop sent
+// something it did not send when the file was recorded.
+type Replayer struct {
+	mu         sync.Mutex
+	cassette   *Cassette
+	used       []bool
+	mismatches []string
+}
+
+// NewReplayer creates a replayer of the cassette
+func NewReplayer(cassette *Cassette) *Replayer {
+	return &Replayer{cassette: cassette, used: make([]bool, len(cassette.Interactions))}
+}
+
+// Model returns a model answering from the golden file
+func (p *Replayer) Model() Model {
+	return replayModel{p}
+}
+
+// Client returns an MCP client answering from the golden file
+func (p *Replayer) Client() Client {
+	return replayClient{p}
+}
+
+// Threads returns the user messages of the recorded conversations, one list per thread, in the
+// order they were recorded
+func (p *Replayer) Threads() [][]string {
+	var threads [][]string
+	index := map[string]int{}
+	for _, interaction := range p.cassette.Interactions {
+		if interaction.Kind != KindCompletion || interaction.Thread == "" {
+			continue
+		}
+		i, ok := index[interaction.Thread]
+		if !ok {
+			i = len(threads)
+			index[interaction.Thread] = i
+			threads = append(threads, nil)
+		}
+		if queries := threads[i]; len(queries) == 0 || queries[len(queries)-1] != interaction.Query {
+			threads[i] = append(queries, interaction.Query)
+		}
+	}
+	return threads
+}
+
+// Wait waits until the model has given its final answer to the query in the thread, as
+// recorded, or a call did not match the golden file
+func (p *Replayer) Wait(ctx context.Context, thread, query string) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		p.mu.Lock()
+		mismatches := len(p.mismatches)
+		answered := false
+		for i, interaction := range p.cassette.Interactions {
+			if p.used[i] && interaction.final() && interaction.Thread == thread && interaction.Query == query {
+				answered = true
+			}
+		}
+		p.mu.Unlock()
+		if mismatches > 0 {
+			return fmt.Errorf("replay diverged from the recording")
+		}
+		if answered {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("conversation did not finish: %v", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Mismatches returns the calls that had no recorded interaction
+func (p *Replayer) Mismatches() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.mismatches...)
+}
+
+// Unused returns the number of recorded interactions that were not replayed
+func (p *Replayer) Unused() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	unused := 0
+	for _, used := range p.used {
+		if !used {
+			unused++
+		}
+	}
+	return unused
+}
+
+// take returns the first unused interaction with the request of call, marking it used
+func (p *Replayer) take(call Interaction) (Interaction, error) {
+	key := call.key()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, interaction := range p.cassette.Interactions {
+		if !p.used[i] && interaction.key() == key {
+			p.used[i] = true
+			return interaction, nil
+		}
+	}
+	p.mismatches = append(p.mismatches, key)
+	return Interaction{}, fmt.Errorf("no recorded %s matches the request: %s", call.Kind, key)
+}
+
+// replayModel is the model of a replay
+type replayModel struct {
+	replayer *Replayer
+}
+
+// Chat returns the recorded answer
+func (m replayModel) Chat(_ context.Context, messages []azopenai.ChatRequestMessageClassification) (string, error) {
+	call, err := modelCall(KindChat, messages, nil)
+	if err != nil {
+		return "", err
+	}
+	interaction, err := m.replayer.take(call)
+	if err != nil {
+		return "", err
+	}
+	if interaction.Error != "" {
+		return "", fmt.Errorf("%s", interaction.Error)
+	}
+	return interaction.Content, nil
+}
+
+// ChatWithTools returns the recorded response
+func (m replayModel) ChatWithTools(_ context.Context, messages []azopenai.ChatRequestMessageClassification, tools []openai.Tool) (*openai.ChatResponse, error) {
+	call, err := modelCall(KindCompletion, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	interaction, err := m.replayer.take(call)
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" || interaction.Completion == nil {
+		return nil, fmt.Errorf("%s", interaction.Error)
+	}
+
+	completion := interaction.Completion
+	response := &openai.ChatResponse{
+		Content:          completion.Content,
+		IsComplete:       completion.IsComplete,
+		PromptTokens:     completion.PromptTokens,
+		CompletionTokens: completion.CompletionTokens,
+	}
+	for _, toolCall := range completion.ToolCalls {
+		response.ToolCalls = append(response.ToolCalls, openai.ToolCall{ID: toolCall.ID, Name: toolCall.Name, Args: toolCall.Args})
+	}
+	return response, nil
+}
+
+// replayClient is the MCP client of a replay
+type replayClient struct {
+	replayer *Replayer
+}
+
+// Initialize succeeds, there is no server to connect to
+func (c replayClient) Initialize(context.Context, mcp.InitializeRequest) (*mcp.InitializeResult, error) {
+	return &mcp.InitializeResult{
+		ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+		ServerInfo:      mcp.Implementation{Name: "replay", Version: "1.0.0"},
+	}, nil
+}
+
+// ListTools returns the recorded tools
+func (c replayClient) ListTools(context.Context, mcp.ListToolsRequest) (*mcp.ListToolsResult, error) {
+	var result mcp.ListToolsResult
+	if len(c.replayer.cassette.Tools) == 0 {
+		return &result, nil
+	}
+	if err := json.Unmarshal(c.replayer.cassette.Tools, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse recorded tools: %v", err)
+	}
+	return &result, nil
+}
+
+// CallTool returns the recorded result of the tool call
+func (c replayClient) CallTool(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	interaction, err := c.replayer.take(Interaction{Kind: KindToolCall, Tool: request.Params.Name, Args: request.GetArguments()})
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" {
+		return nil, fmt.Errorf("%s", interaction.Error)
+	}
+	return mcp.ParseCallToolResult(&interaction.Result)
+}
+
+// Close does nothing, the client holds no connection
+func (c replayClient) Close() error {
+	return nil
+}
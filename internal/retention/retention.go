@@ -0,0 +1,158 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.uber.org/zap"
+)
+
+// categoryPrefixes maps each retention category to the S3 prefix its objects live under
+var categoryPrefixes = map[string]string{
+	"audit":       "audit/",
+	"transcripts": "transcripts/",
+	"feedback":    "feedback/",
+	"usage":       "usage/",
+	"email":       "inbound-email/",
+}
+
+// protectedSuffixes lists objects that are never purged because other data depends on them
+var protectedSuffixes = []string{
+	"head.json",
+	"checkpoint.json",
+}
+
+// maxDeleteBatch is the maximum number of keys S3 accepts in one DeleteObjects call
+const maxDeleteBatch = 1000
+
+// Policy maps a retention category to the number of days its data is kept
+type Policy map[string]int
+
+// Validate checks that every category is known and every period is positive
+func (p Policy) Validate() error {
+	for category, days := range p {
+		if _, ok := categoryPrefixes[category]; !ok {
+			return fmt.Errorf("unknown retention category %q", category)
+		}
+		if days <= 0 {
+			return fmt.Errorf("retention for %s must be at least 1 day", category)
+		}
+	}
+	return nil
+}
+
+// ChainPurger purges a hash chained category itself, so it can record where the chain starts
+// after its oldest entries are gone
+type ChainPurger interface {
+	PurgeBefore(ctx context.Context, cutoff time.Time) (int, error)
+}
+
+// Purger deletes objects that are older than their category's retention period
+type Purger struct {
+	client     *s3.Client
+	bucketName string
+	policy     Policy
+	auditTrail ChainPurger // Purges the audit category, which is refused without it
+}
+
+// NewPurger creates a new Purger instance
+func NewPurger(client *s3.Client, bucketName string, policy Policy, auditTrail ChainPurger) *Purger {
+	return &Purger{
+		client:     client,
+		bucketName: bucketName,
+		policy:     policy,
+		auditTrail: auditTrail,
+	}
+}
+
+// Purge enforces the retention policy and returns the number of objects deleted per category.
+// Objects still under S3 Object Lock cannot be deleted and are skipped.
+func (p *Purger) Purge(ctx context.Context) (map[string]int, error) {
+	categories := make([]string, 0, len(p.policy))
+	for category := range p.policy {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	deleted := map[string]int{}
+	for _, category := range categories {
+		cutoff := time.Now().AddDate(0, 0, -p.policy[category])
+		var count int
+		var err error
+		switch {
+		case category != "audit":
+			count, err = p.purgePrefix(ctx, categoryPrefixes[category], cutoff)
+		case p.auditTrail != nil:
+			count, err = p.auditTrail.PurgeBefore(ctx, cutoff)
+		default:
+			err = fmt.Errorf("audit entries can only be purged through the audit trail")
+		}
+		deleted[category] = count
+		if err != nil {
+			return deleted, fmt.Errorf("failed to purge %s: %v", category, err)
+		}
+		logger.GetLogger().Info("retention purge",
+			zap.String("category", category),
+			zap.Time("cutoff", cutoff),
+			zap.Int("deleted", count))
+	}
+	return deleted, nil
+}
+
+// purgePrefix deletes the objects under the prefix last modified before the cutoff
+func (p *Purger) purgePrefix(ctx context.Context, prefix string, cutoff time.Time) (int, error) {
+	var expired []types.ObjectIdentifier
+	paginator := s3.NewListObjectsV2Paginator(p.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(p.bucketName),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, obj := range page.Contents {
+			if obj.LastModified == nil || !obj.LastModified.Before(cutoff) || isProtected(aws.ToString(obj.Key)) {
+				continue
+			}
+			expired = append(expired, types.ObjectIdentifier{Key: obj.Key})
+		}
+	}
+
+	deleted := 0
+	for start := 0; start < len(expired); start += maxDeleteBatch {
+		end := min(start+maxDeleteBatch, len(expired))
+		result, err := p.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(p.bucketName),
+			Delete: &types.Delete{Objects: expired[start:end], Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return deleted, err
+		}
+		for _, e := range result.Errors {
+			logger.GetLogger().Warn("failed to purge object",
+				zap.String("key", aws.ToString(e.Key)),
+				zap.String("code", aws.ToString(e.Code)))
+		}
+		deleted += end - start - len(result.Errors)
+	}
+	return deleted, nil
+}
+
+// isProtected reports whether the object must never be purged
+func isProtected(key string) bool {
+	for _, suffix := range protectedSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,351 @@
+package anthropic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"jira_helper/internal/httpclient"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/openai"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+	"go.uber.org/zap"
+)
+
+const (
+	// apiURL is the base URL of the Anthropic API
+	apiURL = "https://api.anthropic.com"
+
+	// apiVersion is the version of the Messages API the requests are written for
+	apiVersion = "2023-06-01"
+
+	// maxTokens bounds the length of an answer, the Messages API requires a limit
+	maxTokens = 4096
+)
+
+// Client calls the Anthropic Messages API. It implements the same methods as *openai.Client, so
+// the conversation can run on Claude models.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new Client instance. baseURL may be empty to use the Anthropic API.
+func NewClient(baseURL, apiKey, model string) *Client {
+	if baseURL == "" {
+		baseURL = apiURL
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: httpclient.New(httpclient.AITimeout),
+	}
+}
+
+type messagesRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	System    string    `json:"system,omitempty"`
+	Messages  []message `json:"messages"`
+	Tools     []tool    `json:"tools,omitempty"`
+	Stream    bool      `json:"stream,omitempty"`
+}
+
+type message struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+// contentBlock is a text, tool_use or tool_result block
+type contentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type messagesResponse struct {
+	Content []contentBlock `json:"content"`
+	Usage   usage          `json:"usage"`
+}
+
+type usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Chat returns the model's answer to the messages
+func (c *Client) Chat(ctx context.Context, messages []azopenai.ChatRequestMessageClassification) (string, error) {
+	response, err := c.ChatWithTools(ctx, messages, nil)
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// ChatWithTools sends the messages with the tools the model may call
+func (c *Client) ChatWithTools(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []openai.Tool) (*openai.ChatResponse, error) {
+	request, err := c.newRequest(messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	logger.GetLogger().Debug("sending messages to Anthropic", zap.Any("request", request))
+
+	resp, err := c.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result messagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode anthropic response: %v", err)
+	}
+	logger.GetLogger().Debug("anthropic response", zap.Any("response", result))
+
+	response := &openai.ChatResponse{
+		PromptTokens:     result.Usage.InputTokens,
+		CompletionTokens: result.Usage.OutputTokens,
+	}
+	var texts []string
+	for _, block := range result.Content {
+		switch block.Type {
+		case "text":
+			texts = append(texts, block.Text)
+		case "tool_use":
+			call, err := toolCall(block.ID, block.Name, block.Input)
+			if err != nil {
+				return nil, err
+			}
+			response.ToolCalls = append(response.ToolCalls, call)
+		}
+	}
+	response.Content = strings.Join(texts, "")
+	response.IsComplete = len(response.ToolCalls) == 0
+	return response, nil
+}
+
+// streamEvent is a server-sent event of a streamed message
+type streamEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message struct {
+		Usage usage `json:"usage"`
+	} `json:"message"`
+	ContentBlock contentBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	Usage usage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// streamedToolUse is a tool_use block assembled from the deltas of a stream
+type streamedToolUse struct {
+	id    string
+	name  string
+	input strings.Builder
+}
+
+// ChatWithToolsStream works like ChatWithTools but streams the answer. onContent is called with
+// the text generated so far each time the model produces more of it.
+func (c *Client) ChatWithToolsStream(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []openai.Tool, onContent func(content string)) (*openai.ChatResponse, error) {
+	request, err := c.newRequest(messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	request.Stream = true
+	logger.GetLogger().Debug("streaming messages to Anthropic", zap.Any("request", request))
+
+	resp, err := c.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var calls []*streamedToolUse
+	blocks := map[int]*streamedToolUse{} // Block index -> tool_use block
+	response := &openai.ChatResponse{}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event streamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, fmt.Errorf("failed to decode anthropic stream event: %v", err)
+		}
+		switch event.Type {
+		case "message_start":
+			response.PromptTokens = event.Message.Usage.InputTokens
+		case "content_block_start":
+			if event.ContentBlock.Type == "tool_use" {
+				call := &streamedToolUse{id: event.ContentBlock.ID, name: event.ContentBlock.Name}
+				blocks[event.Index] = call
+				calls = append(calls, call)
+			}
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "text_delta":
+				content.WriteString(event.Delta.Text)
+				if onContent != nil {
+					onContent(content.String())
+				}
+			case "input_json_delta":
+				if call, ok := blocks[event.Index]; ok {
+					call.input.WriteString(event.Delta.PartialJSON)
+				}
+			}
+		case "message_delta":
+			response.CompletionTokens = event.Usage.OutputTokens
+		case "error":
+			return nil, fmt.Errorf("anthropic stream failed: %s: %s", event.Error.Type, event.Error.Message)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read anthropic stream: %v", err)
+	}
+
+	response.Content = content.String()
+	for _, call := range calls {
+		toolCall, err := toolCall(call.id, call.name, json.RawMessage(call.input.String()))
+		if err != nil {
+			return nil, err
+		}
+		response.ToolCalls = append(response.ToolCalls, toolCall)
+	}
+	response.IsComplete = len(response.ToolCalls) == 0
+	logger.GetLogger().Debug("anthropic stream response", zap.Any("response", response))
+	return response, nil
+}
+
+// newRequest converts the conversation to a Messages API request. System messages become the
+// system prompt, and tool results are sent as user messages as the API expects.
+func (c *Client) newRequest(messages []azopenai.ChatRequestMessageClassification, tools []openai.Tool) (*messagesRequest, error) {
+	converted, err := openai.Messages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &messagesRequest{Model: c.model, MaxTokens: maxTokens}
+	var system []string
+	for _, msg := range converted {
+		role, blocks := "user", []contentBlock{}
+		switch msg.Role {
+		case "system":
+			system = append(system, msg.Content)
+			continue
+		case "user":
+			blocks = appendText(blocks, msg.Content)
+		case "assistant":
+			role = "assistant"
+			blocks = appendText(blocks, msg.Content)
+			for _, call := range msg.ToolCalls {
+				input, err := json.Marshal(call.Args)
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal tool arguments: %v", err)
+				}
+				blocks = append(blocks, contentBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: input})
+			}
+		case "tool":
+			blocks = append(blocks, contentBlock{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: msg.Content})
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		// Consecutive messages of the same role, like the results of several tool calls, form one turn
+		if n := len(request.Messages); n > 0 && request.Messages[n-1].Role == role {
+			request.Messages[n-1].Content = append(request.Messages[n-1].Content, blocks...)
+			continue
+		}
+		request.Messages = append(request.Messages, message{Role: role, Content: blocks})
+	}
+	request.System = strings.Join(system, "\n\n")
+
+	for _, t := range tools {
+		schema := json.RawMessage(t.Parameters)
+		if len(schema) == 0 {
+			schema = json.RawMessage(`{"type":"object","properties":{}}`)
+		}
+		request.Tools = append(request.Tools, tool{Name: t.Name, Description: t.Description, InputSchema: schema})
+	}
+	return request, nil
+}
+
+// post sends a request to the Messages API and returns the successful response
+func (c *Client) post(ctx context.Context, request *messagesRequest) (*http.Response, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal anthropic request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/messages", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", apiVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call anthropic: %v", err)
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		var apiErr struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		return nil, fmt.Errorf("anthropic returned %d: %s: %s", resp.StatusCode, apiErr.Error.Type, apiErr.Error.Message)
+	}
+	return resp, nil
+}
+
+// appendText adds a text block unless the text is empty, which the API rejects
+func appendText(blocks []contentBlock, text string) []contentBlock {
+	if text == "" {
+		return blocks
+	}
+	return append(blocks, contentBlock{Type: "text", Text: text})
+}
+
+// toolCall converts a tool_use block to a tool call
+func toolCall(id, name string, input json.RawMessage) (openai.ToolCall, error) {
+	args := map[string]interface{}{}
+	if len(input) > 0 {
+		if err := json.Unmarshal(input, &args); err != nil {
+			return openai.ToolCall{}, fmt.Errorf("failed to parse tool arguments: %v", err)
+		}
+	}
+	return openai.ToolCall{ID: id, Name: name, Args: args}, nil
+}
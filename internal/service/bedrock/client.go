@@ -0,0 +1,270 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"jira_helper/internal/httpclient"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/service/openai"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"go.uber.org/zap"
+)
+
+// maxTokens bounds the length of an answer
+const maxTokens = 4096
+
+// Client calls the Bedrock Converse API, which works the same for every model that supports tool
+// use. It implements the same methods as *openai.Client except streaming.
+type Client struct {
+	credentials aws.CredentialsProvider
+	region      string
+	model       string // Model ID, inference profile ID or ARN
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+// NewClient creates a new Client instance using the credentials and region of the AWS config
+func NewClient(awsCfg aws.Config, model string) *Client {
+	return &Client{
+		credentials: awsCfg.Credentials,
+		region:      awsCfg.Region,
+		model:       model,
+		signer:      v4.NewSigner(),
+		httpClient:  httpclient.New(httpclient.AITimeout),
+	}
+}
+
+type converseRequest struct {
+	Messages        []message       `json:"messages"`
+	System          []contentBlock  `json:"system,omitempty"`
+	ToolConfig      *toolConfig     `json:"toolConfig,omitempty"`
+	InferenceConfig inferenceConfig `json:"inferenceConfig"`
+}
+
+type message struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+// contentBlock holds one of text, a tool use or a tool result
+type contentBlock struct {
+	Text       string      `json:"text,omitempty"`
+	ToolUse    *toolUse    `json:"toolUse,omitempty"`
+	ToolResult *toolResult `json:"toolResult,omitempty"`
+}
+
+type toolUse struct {
+	ToolUseID string          `json:"toolUseId"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+}
+
+type toolResult struct {
+	ToolUseID string         `json:"toolUseId"`
+	Content   []contentBlock `json:"content"`
+}
+
+type toolConfig struct {
+	Tools []toolSpecification `json:"tools"`
+}
+
+type toolSpecification struct {
+	ToolSpec struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		InputSchema struct {
+			JSON json.RawMessage `json:"json"`
+		} `json:"inputSchema"`
+	} `json:"toolSpec"`
+}
+
+type inferenceConfig struct {
+	MaxTokens int `json:"maxTokens"`
+}
+
+type converseResponse struct {
+	Output struct {
+		Message message `json:"message"`
+	} `json:"output"`
+	Usage struct {
+		InputTokens  int `json:"inputTokens"`
+		OutputTokens int `json:"outputTokens"`
+	} `json:"usage"`
+}
+
+// Chat returns the model's answer to the messages
+func (c *Client) Chat(ctx context.Context, messages []azopenai.ChatRequestMessageClassification) (string, error) {
+	response, err := c.ChatWithTools(ctx, messages, nil)
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// ChatWithTools sends the messages with the tools the model may call
+func (c *Client) ChatWithTools(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []openai.Tool) (*openai.ChatResponse, error) {
+	request, err := newRequest(messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	logger.GetLogger().Debug("sending messages to Bedrock", zap.Any("request", request))
+
+	var result converseResponse
+	if err := c.converse(ctx, request, &result); err != nil {
+		return nil, err
+	}
+	logger.GetLogger().Debug("bedrock response", zap.Any("response", result))
+
+	response := &openai.ChatResponse{
+		PromptTokens:     result.Usage.InputTokens,
+		CompletionTokens: result.Usage.OutputTokens,
+	}
+	var texts []string
+	for _, block := range result.Output.Message.Content {
+		if block.ToolUse == nil {
+			texts = append(texts, block.Text)
+			continue
+		}
+		args := map[string]interface{}{}
+		if len(block.ToolUse.Input) > 0 {
+			if err := json.Unmarshal(block.ToolUse.Input, &args); err != nil {
+				return nil, fmt.Errorf("failed to parse tool arguments: %v", err)
+			}
+		}
+		response.ToolCalls = append(response.ToolCalls, openai.ToolCall{ID: block.ToolUse.ToolUseID, Name: block.ToolUse.Name, Args: args})
+	}
+	response.Content = strings.Join(texts, "")
+	response.IsComplete = len(response.ToolCalls) == 0
+	return response, nil
+}
+
+// newRequest converts the conversation to a Converse request. System messages become the system
+// prompt, and tool results are sent as user messages as the API expects.
+func newRequest(messages []azopenai.ChatRequestMessageClassification, tools []openai.Tool) (*converseRequest, error) {
+	converted, err := openai.Messages(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &converseRequest{InferenceConfig: inferenceConfig{MaxTokens: maxTokens}}
+	for _, msg := range converted {
+		role, blocks := "user", []contentBlock{}
+		switch msg.Role {
+		case "system":
+			request.System = append(request.System, contentBlock{Text: msg.Content})
+			continue
+		case "user":
+			blocks = appendText(blocks, msg.Content)
+		case "assistant":
+			role = "assistant"
+			blocks = appendText(blocks, msg.Content)
+			for _, call := range msg.ToolCalls {
+				input, err := json.Marshal(call.Args)
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal tool arguments: %v", err)
+				}
+				blocks = append(blocks, contentBlock{ToolUse: &toolUse{ToolUseID: call.ID, Name: call.Name, Input: input}})
+			}
+		case "tool":
+			result := &toolResult{ToolUseID: msg.ToolCallID, Content: appendText(nil, msg.Content)}
+			if len(result.Content) == 0 {
+				result.Content = []contentBlock{{Text: "(empty)"}}
+			}
+			blocks = append(blocks, contentBlock{ToolResult: result})
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		// Consecutive messages of the same role, like the results of several tool calls, form one turn
+		if n := len(request.Messages); n > 0 && request.Messages[n-1].Role == role {
+			request.Messages[n-1].Content = append(request.Messages[n-1].Content, blocks...)
+			continue
+		}
+		request.Messages = append(request.Messages, message{Role: role, Content: blocks})
+	}
+
+	if len(tools) > 0 {
+		request.ToolConfig = &toolConfig{}
+		for _, t := range tools {
+			var spec toolSpecification
+			spec.ToolSpec.Name = t.Name
+			spec.ToolSpec.Description = t.Description
+			spec.ToolSpec.InputSchema.JSON = json.RawMessage(t.Parameters)
+			if len(spec.ToolSpec.InputSchema.JSON) == 0 {
+				spec.ToolSpec.InputSchema.JSON = json.RawMessage(`{"type":"object","properties":{}}`)
+			}
+			request.ToolConfig.Tools = append(request.ToolConfig.Tools, spec)
+		}
+	}
+	return request, nil
+}
+
+// converse sends a signed request to the Converse API and decodes the response into result
+func (c *Client) converse(ctx context.Context, request *converseRequest, result interface{}) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bedrock request: %v", err)
+	}
+	// Model ARNs contain slashes, which must stay within the path segment
+	endpoint := &url.URL{
+		Scheme:  "https",
+		Host:    fmt.Sprintf("bedrock-runtime.%s.amazonaws.com", c.region),
+		Path:    "/model/" + c.model + "/converse",
+		RawPath: "/model/" + url.PathEscape(c.model) + "/converse",
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "bedrock", c.region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign bedrock request: %v", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call bedrock: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(resp.Body)
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		return fmt.Errorf("bedrock returned %d: %s: %s", resp.StatusCode, resp.Header.Get("x-amzn-ErrorType"), apiErr.Message)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode bedrock response: %v", err)
+	}
+	return nil
+}
+
+// appendText adds a text block unless the text is empty, which the API rejects
+func appendText(blocks []contentBlock, text string) []contentBlock {
+	if text == "" {
+		return blocks
+	}
+	return append(blocks, contentBlock{Text: text})
+}
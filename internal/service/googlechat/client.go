@@ -0,0 +1,204 @@
+package googlechat
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"jira_helper/internal/httpclient"
+)
+
+const (
+	// chatAPI is the base URL of the Google Chat REST API
+	chatAPI = "https://chat.googleapis.com/v1/"
+
+	// chatBotScope lets the app post and update its own messages
+	chatBotScope = "https://www.googleapis.com/auth/chat.bot"
+
+	// refreshBefore is how long before expiry the access token is renewed
+	refreshBefore = 5 * time.Minute
+)
+
+// serviceAccount holds the fields of a service account key file the client needs
+type serviceAccount struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// Client posts and updates messages through the Google Chat API as the Chat app
+type Client struct {
+	account    serviceAccount
+	key        *rsa.PrivateKey
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewClient creates a new Client instance from a service account key file
+func NewClient(credentialsJSON []byte) (*Client, error) {
+	var account serviceAccount
+	if err := json.Unmarshal(credentialsJSON, &account); err != nil {
+		return nil, fmt.Errorf("invalid google chat credentials: %v", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, fmt.Errorf("google chat credentials must contain client_email and private_key")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	key, err := parsePrivateKey(account.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		account:    account,
+		key:        key,
+		httpClient: httpclient.New(httpclient.GoogleChatTimeout),
+	}, nil
+}
+
+// CreateMessage posts a message to the space and returns its resource name. Messages with a
+// thread are replied in that thread, or start a new one if it no longer exists.
+func (c *Client) CreateMessage(ctx context.Context, space string, message Message) (string, error) {
+	endpoint := chatAPI + space + "/messages"
+	if message.Thread != nil {
+		endpoint += "?messageReplyOption=REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD"
+	}
+	var created Message
+	if err := c.call(ctx, http.MethodPost, endpoint, message, &created); err != nil {
+		return "", err
+	}
+	return created.Name, nil
+}
+
+// UpdateMessageText replaces the text of a message the app posted
+func (c *Client) UpdateMessageText(ctx context.Context, name, text string) error {
+	endpoint := chatAPI + name + "?updateMask=text"
+	return c.call(ctx, http.MethodPatch, endpoint, Message{Text: text}, nil)
+}
+
+// PostMessage posts text to a space, in the given thread if one is set
+func (c *Client) PostMessage(space, thread, text string) (string, error) {
+	message := Message{Text: text}
+	if thread != "" {
+		message.Thread = &Thread{Name: thread}
+	}
+	return c.CreateMessage(context.Background(), space, message)
+}
+
+// UpdateMessage replaces the text of a message the app posted
+func (c *Client) UpdateMessage(_, name, text string) error {
+	return c.UpdateMessageText(context.Background(), name, text)
+}
+
+// call sends an authorized JSON request to the Chat API
+func (c *Client) call(ctx context.Context, method, endpoint string, body, result interface{}) error {
+	token, err := c.token(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call google chat: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error struct {
+				Status  string `json:"status"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(respBody, &apiErr)
+		return fmt.Errorf("google chat returned %d: %s %s", resp.StatusCode, apiErr.Error.Status, apiErr.Error.Message)
+	}
+	if result != nil {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return fmt.Errorf("failed to decode google chat response: %v", err)
+		}
+	}
+	return nil
+}
+
+// token returns a valid access token, exchanging a signed assertion for a new one when needed
+func (c *Client) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && time.Until(c.expiresAt) > refreshBefore {
+		return c.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := signJWT(c.key, c.account.PrivateKeyID, map[string]interface{}{
+		"iss":   c.account.ClientEmail,
+		"scope": chatBotScope,
+		"aud":   c.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain google access token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode google token response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		return "", fmt.Errorf("google token endpoint returned %d: %s %s", resp.StatusCode, result.Error, result.Description)
+	}
+
+	c.accessToken = result.AccessToken
+	c.expiresAt = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	return c.accessToken, nil
+}
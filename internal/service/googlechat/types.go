@@ -0,0 +1,92 @@
+package googlechat
+
+// Message is a Google Chat message
+type Message struct {
+	Name    string       `json:"name,omitempty"`
+	Text    string       `json:"text,omitempty"`
+	Thread  *Thread      `json:"thread,omitempty"`
+	CardsV2 []CardWithID `json:"cardsV2,omitempty"`
+}
+
+// Thread identifies the thread of a space a message belongs to
+type Thread struct {
+	Name string `json:"name"`
+}
+
+// CardWithID is a card attached to a message
+type CardWithID struct {
+	CardID string `json:"cardId"`
+	Card   Card   `json:"card"`
+}
+
+// Card is a Google Chat card
+type Card struct {
+	Header   *CardHeader `json:"header,omitempty"`
+	Sections []Section   `json:"sections"`
+}
+
+// CardHeader is the title area of a card
+type CardHeader struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
+}
+
+// Section groups the widgets of a card
+type Section struct {
+	Header  string   `json:"header,omitempty"`
+	Widgets []Widget `json:"widgets"`
+}
+
+// Widget is a single element of a card section
+type Widget struct {
+	DecoratedText *DecoratedText `json:"decoratedText,omitempty"`
+	TextParagraph *TextParagraph `json:"textParagraph,omitempty"`
+}
+
+// DecoratedText is a line of text with an optional label and button
+type DecoratedText struct {
+	TopLabel string  `json:"topLabel,omitempty"`
+	Text     string  `json:"text"`
+	Button   *Button `json:"button,omitempty"`
+}
+
+// TextParagraph is a paragraph of formatted text
+type TextParagraph struct {
+	Text string `json:"text"`
+}
+
+// Button is a card button
+type Button struct {
+	Text    string  `json:"text"`
+	OnClick OnClick `json:"onClick"`
+}
+
+// OnClick is the action of a button
+type OnClick struct {
+	OpenLink *OpenLink `json:"openLink,omitempty"`
+}
+
+// OpenLink opens a URL
+type OpenLink struct {
+	URL string `json:"url"`
+}
+
+// Event is an interaction event Google Chat sends to the app endpoint
+type Event struct {
+	Type    string `json:"type"`
+	Message struct {
+		Name         string `json:"name"`
+		Text         string `json:"text"`
+		ArgumentText string `json:"argumentText"`
+		Thread       Thread `json:"thread"`
+	} `json:"message"`
+	Space struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"space"`
+	User struct {
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+		Type        string `json:"type"`
+	} `json:"user"`
+}
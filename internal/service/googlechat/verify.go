@@ -0,0 +1,166 @@
+package googlechat
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"jira_helper/internal/httpclient"
+	"jira_helper/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// chatIssuer is the service account Google Chat signs its requests with
+	chatIssuer = "chat@system.gserviceaccount.com"
+
+	// chatCertsURL publishes the certificates of the Google Chat service account
+	chatCertsURL = "https://www.googleapis.com/service_accounts/v1/metadata/x509/" + chatIssuer
+
+	// certsTTL is how long the fetched certificates are reused
+	certsTTL = time.Hour
+)
+
+// chatClaims are the claims of the bearer token Google Chat sends with each request
+type chatClaims struct {
+	Issuer    string `json:"iss"`
+	Audience  string `json:"aud"`
+	ExpiresAt int64  `json:"exp"`
+	IssuedAt  int64  `json:"iat"`
+}
+
+// Verifier checks that requests were sent by Google Chat for this app
+type Verifier struct {
+	audience   string // Google Cloud project number of the Chat app
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewVerifier creates a new Verifier instance for the app's project number
+func NewVerifier(projectNumber string) *Verifier {
+	return &Verifier{
+		audience:   projectNumber,
+		httpClient: httpclient.New(httpclient.GoogleChatTimeout),
+	}
+}
+
+// Verify validates the bearer token of a Google Chat request
+func (v *Verifier) Verify(ctx context.Context, token string) error {
+	var claims chatClaims
+	header, signingInput, signature, err := parseJWT(token, &claims)
+	if err != nil {
+		return err
+	}
+	if header.Alg != "RS256" {
+		return fmt.Errorf("unexpected token algorithm %s", header.Alg)
+	}
+
+	key, err := v.publicKey(ctx, header.Kid)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(signingInput)
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], signature); err != nil {
+		return fmt.Errorf("invalid token signature")
+	}
+
+	if claims.Issuer != chatIssuer {
+		return fmt.Errorf("unexpected token issuer %s", claims.Issuer)
+	}
+	if claims.Audience != v.audience {
+		return fmt.Errorf("unexpected token audience %s", claims.Audience)
+	}
+	if time.Now().Unix() > claims.ExpiresAt {
+		return fmt.Errorf("token expired")
+	}
+	return nil
+}
+
+// publicKey returns the key with the given ID, refetching the certificates when it is unknown
+func (v *Verifier) publicKey(ctx context.Context, keyID string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, ok := v.keys[keyID]; ok && time.Since(v.fetchedAt) < certsTTL {
+		return key, nil
+	}
+	keys, err := v.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v.keys, v.fetchedAt = keys, time.Now()
+
+	key, ok := keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown token key %s", keyID)
+	}
+	return key, nil
+}
+
+// fetchKeys downloads the Google Chat certificates and extracts their public keys
+func (v *Verifier) fetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, chatCertsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch google chat certificates: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("google chat certificates returned %d", resp.StatusCode)
+	}
+
+	var certs map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&certs); err != nil {
+		return nil, fmt.Errorf("failed to decode google chat certificates: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(certs))
+	for keyID, certPEM := range certs {
+		block, _ := pem.Decode([]byte(certPEM))
+		if block == nil {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			keys[keyID] = key
+		}
+	}
+	return keys, nil
+}
+
+// RequireChatToken is a middleware that rejects requests not signed by Google Chat
+func RequireChatToken(v *Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+		if err := v.Verify(c.Request.Context(), token); err != nil {
+			logger.GetLogger().Warn("rejected google chat request", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid bearer token"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,111 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxSprintIssues is the number of issues the Agile API moves to a sprint per request
+const maxSprintIssues = 50
+
+// Board is a Jira Software board
+type Board struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"` // scrum or kanban
+	Location struct {
+		ProjectKey string `json:"projectKey"`
+	} `json:"location"`
+}
+
+// Sprint is a sprint of a scrum board
+type Sprint struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	State         string `json:"state"` // future, active or closed
+	OriginBoardID int    `json:"originBoardId"`
+	StartDate     Time   `json:"startDate"`
+	EndDate       Time   `json:"endDate"`
+	CompleteDate  Time   `json:"completeDate"`
+}
+
+// GetBoard returns the board with the given ID
+func (c *Client) GetBoard(ctx context.Context, boardID int) (*Board, error) {
+	var board Board
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/rest/agile/1.0/board/%d", boardID), nil, nil, &board); err != nil {
+		return nil, fmt.Errorf("failed to get board %d: %w", boardID, err)
+	}
+	return &board, nil
+}
+
+// Sprints returns the board's sprints in the given states, e.g. "closed", oldest first. No states
+// returns all sprints.
+func (c *Client) Sprints(ctx context.Context, boardID int, states ...string) ([]Sprint, error) {
+	var sprints []Sprint
+	for {
+		query := url.Values{"startAt": {strconv.Itoa(len(sprints))}, "maxResults": {"50"}}
+		if len(states) > 0 {
+			query.Set("state", strings.Join(states, ","))
+		}
+		var page struct {
+			IsLast bool     `json:"isLast"`
+			Values []Sprint `json:"values"`
+		}
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/rest/agile/1.0/board/%d/sprint", boardID), query, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to get sprints of board %d: %w", boardID, err)
+		}
+		sprints = append(sprints, page.Values...)
+		if page.IsLast || len(page.Values) == 0 {
+			return sprints, nil
+		}
+	}
+}
+
+// Backlog returns up to limit issues of the board's backlog in rank order
+func (c *Client) Backlog(ctx context.Context, boardID int, fields []string, limit int) ([]Issue, error) {
+	var issues []Issue
+	for len(issues) < limit {
+		query := url.Values{
+			"startAt":    {strconv.Itoa(len(issues))},
+			"maxResults": {strconv.Itoa(min(limit-len(issues), maxPageSize))},
+		}
+		if len(fields) > 0 {
+			query.Set("fields", strings.Join(fields, ","))
+		}
+		var page SearchResult
+		if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/rest/agile/1.0/board/%d/backlog", boardID), query, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to get backlog of board %d: %w", boardID, err)
+		}
+		issues = append(issues, page.Issues...)
+		if len(page.Issues) == 0 || len(issues) >= page.Total {
+			break
+		}
+	}
+	return issues, nil
+}
+
+// CreateSprint creates a future sprint on the board
+func (c *Client) CreateSprint(ctx context.Context, boardID int, name string) (*Sprint, error) {
+	body := map[string]interface{}{"name": name, "originBoardId": boardID}
+	var sprint Sprint
+	if err := c.do(ctx, http.MethodPost, "/rest/agile/1.0/sprint", nil, body, &sprint); err != nil {
+		return nil, fmt.Errorf("failed to create sprint %q: %w", name, err)
+	}
+	return &sprint, nil
+}
+
+// MoveToSprint moves the issues to the sprint, in requests of up to 50 issues
+func (c *Client) MoveToSprint(ctx context.Context, sprintID int, keys []string) error {
+	for start := 0; start < len(keys); start += maxSprintIssues {
+		batch := keys[start:min(start+maxSprintIssues, len(keys))]
+		body := map[string]interface{}{"issues": batch}
+		if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/rest/agile/1.0/sprint/%d/issue", sprintID), nil, body, nil); err != nil {
+			return fmt.Errorf("failed to move issues to sprint %d: %w", sprintID, err)
+		}
+	}
+	return nil
+}
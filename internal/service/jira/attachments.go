@@ -0,0 +1,53 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+)
+
+// Attachment is a file attached to an issue
+type Attachment struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Content  string `json:"content"` // Download URL
+}
+
+// AddAttachment attaches the file to the issue
+func (c *Client) AddAttachment(ctx context.Context, key, filename string, content io.Reader) (*Attachment, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		return nil, fmt.Errorf("failed to read attachment %s: %v", filename, err)
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/rest/api/2/issue/"+url.PathEscape(key)+"/attachments", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	// Jira rejects multipart uploads without this header as a CSRF protection
+	req.Header.Set("X-Atlassian-Token", "no-check")
+
+	var attachments []Attachment
+	if err := c.send(req, &attachments); err != nil {
+		return nil, fmt.Errorf("failed to attach %s to %s: %w", filename, key, err)
+	}
+	if len(attachments) == 0 {
+		return nil, fmt.Errorf("failed to attach %s to %s: empty response", filename, key)
+	}
+	return &attachments[0], nil
+}
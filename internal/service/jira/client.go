@@ -0,0 +1,93 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"jira_helper/internal/httpclient"
+)
+
+// Client calls the Jira Server / Data Center REST API with a personal access token
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new Client instance for the Jira at baseURL
+func NewClient(baseURL, token string) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid Jira URL %q", baseURL)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("a Jira personal access token is required")
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: httpclient.New(httpclient.JiraTimeout),
+	}, nil
+}
+
+// BaseURL returns the base URL of the Jira instance
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// do sends an authorized request to the REST API and decodes the JSON response into result
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, result)
+}
+
+// send authorizes and sends the request, and decodes the JSON response into result
+func (c *Client) send(req *http.Request, result interface{}) error {
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Jira: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Jira response: %v", err)
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return newAPIError(resp.StatusCode, respBody)
+	}
+	if result != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return fmt.Errorf("failed to decode Jira response: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Comments returns all comments on the issue, oldest first
+func (c *Client) Comments(ctx context.Context, key string) ([]Comment, error) {
+	var comments []Comment
+	for {
+		query := url.Values{"startAt": {strconv.Itoa(len(comments))}, "maxResults": {strconv.Itoa(maxPageSize)}}
+		var page struct {
+			Total    int       `json:"total"`
+			Comments []Comment `json:"comments"`
+		}
+		if err := c.do(ctx, http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(key)+"/comment", query, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to get comments of %s: %w", key, err)
+		}
+		comments = append(comments, page.Comments...)
+		if len(page.Comments) == 0 || len(comments) >= page.Total {
+			return comments, nil
+		}
+	}
+}
+
+// AddComment adds the comment to the issue and returns it
+func (c *Client) AddComment(ctx context.Context, key, body string) (*Comment, error) {
+	var comment Comment
+	if err := c.do(ctx, http.MethodPost, "/rest/api/2/issue/"+url.PathEscape(key)+"/comment", nil, map[string]string{"body": body}, &comment); err != nil {
+		return nil, fmt.Errorf("failed to comment on %s: %w", key, err)
+	}
+	return &comment, nil
+}
@@ -0,0 +1,140 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// Data types of the development information the dev-status API returns
+const (
+	devDataPullRequest = "pullrequest"
+	devDataRepository  = "repository"
+)
+
+// PullRequest is a pull or merge request a development tool linked to an issue
+type PullRequest struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	LastUpdate string `json:"lastUpdate"`
+	Author     struct {
+		Name string `json:"name"`
+	} `json:"author"`
+	Source struct {
+		Branch     string `json:"branch"`
+		Repository struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"repository"`
+	} `json:"source"`
+}
+
+// Commit is a commit a development tool linked to an issue
+type Commit struct {
+	ID        string `json:"id"`
+	DisplayID string `json:"displayId"`
+	Message   string `json:"message"`
+	URL       string `json:"url"`
+	Author    struct {
+		Name string `json:"name"`
+	} `json:"author"`
+	AuthorTimestamp string `json:"authorTimestamp"`
+}
+
+// Repository is a repository with the commits that reference an issue
+type Repository struct {
+	Name    string   `json:"name"`
+	URL     string   `json:"url"`
+	Commits []Commit `json:"commits"`
+}
+
+// DevStatus is the development information linked to an issue by GitHub, GitLab, Bitbucket and
+// other tools connected to Jira
+type DevStatus struct {
+	PullRequests []PullRequest
+	Repositories []Repository
+}
+
+// DevStatus returns the pull requests and commits linked to the issue, given by its ID, through
+// the dev-status API the issue's development panel uses. The API is not public, instances that
+// do not serve it report nothing rather than an error.
+func (c *Client) DevStatus(ctx context.Context, issueID string) (*DevStatus, error) {
+	var summary struct {
+		Summary map[string]struct {
+			ByInstanceType map[string]json.RawMessage `json:"byInstanceType"`
+		} `json:"summary"`
+	}
+	query := url.Values{"issueId": {issueID}}
+	err := c.do(ctx, http.MethodGet, "/rest/dev-status/latest/issue/summary", query, nil, &summary)
+	if errors.Is(err, ErrNotFound) {
+		return &DevStatus{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get development summary of issue %s: %w", issueID, err)
+	}
+
+	status := &DevStatus{}
+	for _, dataType := range []string{devDataPullRequest, devDataRepository} {
+		instanceTypes := make([]string, 0, len(summary.Summary[dataType].ByInstanceType))
+		for instanceType := range summary.Summary[dataType].ByInstanceType {
+			instanceTypes = append(instanceTypes, instanceType)
+		}
+		sort.Strings(instanceTypes)
+		for _, instanceType := range instanceTypes {
+			var detail struct {
+				Detail []struct {
+					PullRequests []PullRequest `json:"pullRequests"`
+					Repositories []Repository  `json:"repositories"`
+				} `json:"detail"`
+			}
+			query := url.Values{"issueId": {issueID}, "applicationType": {instanceType}, "dataType": {dataType}}
+			if err := c.do(ctx, http.MethodGet, "/rest/dev-status/latest/issue/detail", query, nil, &detail); err != nil {
+				return nil, fmt.Errorf("failed to get %s development details of issue %s: %w", instanceType, issueID, err)
+			}
+			for _, d := range detail.Detail {
+				status.PullRequests = append(status.PullRequests, d.PullRequests...)
+				status.Repositories = append(status.Repositories, d.Repositories...)
+			}
+		}
+	}
+	return status, nil
+}
+
+// RemoteLink is a link from an issue to an object in another application, e.g. a pull request
+type RemoteLink struct {
+	GlobalID     string // Identifies the object, adding a link with the same ID updates it
+	Application  string // Name of the application, e.g. GitHub
+	Relationship string // How the issue relates to the object, e.g. "mentioned in"
+	URL          string
+	Title        string
+	Summary      string
+}
+
+// AddRemoteLink links the issue to the remote object, updating the link with the same global ID
+// if there is one, and returns the link's ID
+func (c *Client) AddRemoteLink(ctx context.Context, key string, link RemoteLink) (int, error) {
+	object := map[string]interface{}{"url": link.URL, "title": link.Title}
+	if link.Summary != "" {
+		object["summary"] = link.Summary
+	}
+	body := map[string]interface{}{"globalId": link.GlobalID, "object": object}
+	if link.Application != "" {
+		body["application"] = map[string]string{"name": link.Application}
+	}
+	if link.Relationship != "" {
+		body["relationship"] = link.Relationship
+	}
+	var created struct {
+		ID int `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/rest/api/2/issue/"+url.PathEscape(key)+"/remotelink", nil, body, &created); err != nil {
+		return 0, fmt.Errorf("failed to link %s to %s: %w", key, link.URL, err)
+	}
+	return created.ID, nil
+}
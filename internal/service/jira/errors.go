@@ -0,0 +1,67 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Errors that APIError matches with errors.Is, by HTTP status
+var (
+	ErrUnauthorized = errors.New("jira: token is invalid or expired")
+	ErrForbidden    = errors.New("jira: permission denied")
+	ErrNotFound     = errors.New("jira: not found")
+	ErrRateLimited  = errors.New("jira: rate limited")
+)
+
+// APIError is an error response of the Jira REST API
+type APIError struct {
+	StatusCode int
+	Messages   []string          // errorMessages of the response
+	Fields     map[string]string // errors of the response, by field
+}
+
+// newAPIError parses the error response body
+func newAPIError(statusCode int, body []byte) *APIError {
+	var parsed struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	}
+	_ = json.Unmarshal(body, &parsed)
+	return &APIError{StatusCode: statusCode, Messages: parsed.ErrorMessages, Fields: parsed.Errors}
+}
+
+// Error describes the status and messages of the response
+func (e *APIError) Error() string {
+	details := append([]string{}, e.Messages...)
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		details = append(details, fmt.Sprintf("%s: %s", field, e.Fields[field]))
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("jira returned %d", e.StatusCode)
+	}
+	return fmt.Sprintf("jira returned %d: %s", e.StatusCode, strings.Join(details, "; "))
+}
+
+// Is matches the sentinel error of the response status
+func (e *APIError) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case http.StatusForbidden:
+		return target == ErrForbidden
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusTooManyRequests:
+		return target == ErrRateLimited
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Field is the metadata of a system or custom field
+type Field struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Custom      bool     `json:"custom"`
+	Searchable  bool     `json:"searchable"`
+	ClauseNames []string `json:"clauseNames"` // Names the field is referred to by in JQL
+}
+
+// Fields returns the metadata of all fields visible to the user
+func (c *Client) Fields(ctx context.Context) ([]Field, error) {
+	var fields []Field
+	if err := c.do(ctx, http.MethodGet, "/rest/api/2/field", nil, nil, &fields); err != nil {
+		return nil, fmt.Errorf("failed to get fields: %w", err)
+	}
+	return fields, nil
+}
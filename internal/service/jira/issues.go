@@ -0,0 +1,141 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// defaultPageSize is the number of issues requested per search page
+	defaultPageSize = 50
+
+	// maxPageSize is the largest page the REST API returns by default
+	maxPageSize = 100
+)
+
+// SearchOptions narrow a JQL search
+type SearchOptions struct {
+	Fields []string // Fields to return, all navigable fields when empty
+	Limit  int      // Maximum number of issues across all pages, 0 for no limit
+}
+
+// GetIssue returns the issue with the given key, limited to fields when any are given
+func (c *Client) GetIssue(ctx context.Context, key string, fields ...string) (*Issue, error) {
+	query := url.Values{}
+	if len(fields) > 0 {
+		query.Set("fields", strings.Join(fields, ","))
+	}
+	var issue Issue
+	if err := c.do(ctx, http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(key), query, nil, &issue); err != nil {
+		return nil, fmt.Errorf("failed to get issue %s: %w", key, err)
+	}
+	return &issue, nil
+}
+
+// SearchPage returns one page of the issues matching the JQL query
+func (c *Client) SearchPage(ctx context.Context, jql string, startAt, maxResults int, fields []string) (*SearchResult, error) {
+	body := map[string]interface{}{
+		"jql":        jql,
+		"startAt":    startAt,
+		"maxResults": maxResults,
+	}
+	if len(fields) > 0 {
+		body["fields"] = fields
+	}
+	var result SearchResult
+	if err := c.do(ctx, http.MethodPost, "/rest/api/2/search", nil, body, &result); err != nil {
+		return nil, fmt.Errorf("failed to search issues: %w", err)
+	}
+	return &result, nil
+}
+
+// Search returns the issues matching the JQL query, following pages until all matches or the
+// limit are returned. The total number of matches is returned as well.
+func (c *Client) Search(ctx context.Context, jql string, opts SearchOptions) ([]Issue, int, error) {
+	pageSize := defaultPageSize
+	if opts.Limit > 0 && opts.Limit < pageSize {
+		pageSize = opts.Limit
+	}
+
+	var issues []Issue
+	for {
+		page, err := c.SearchPage(ctx, jql, len(issues), min(pageSize, maxPageSize), opts.Fields)
+		if err != nil {
+			return nil, 0, err
+		}
+		issues = append(issues, page.Issues...)
+
+		if opts.Limit > 0 && len(issues) >= opts.Limit {
+			return issues[:opts.Limit], page.Total, nil
+		}
+		// The server may cap the page size below the requested one, so rely on the total
+		if len(page.Issues) == 0 || len(issues) >= page.Total {
+			return issues, page.Total, nil
+		}
+	}
+}
+
+// ValidateJQL checks that the JQL query parses and refers to existing fields and values,
+// without returning any issues. An invalid query returns an *APIError with Jira's messages.
+func (c *Client) ValidateJQL(ctx context.Context, jql string) error {
+	body := map[string]interface{}{
+		"jql":           jql,
+		"maxResults":    0,
+		"validateQuery": "strict",
+		"fields":        []string{"key"},
+	}
+	if err := c.do(ctx, http.MethodPost, "/rest/api/2/search", nil, body, nil); err != nil {
+		return fmt.Errorf("invalid JQL: %w", err)
+	}
+	return nil
+}
+
+// Myself returns the user the token belongs to, which also verifies the token
+func (c *Client) Myself(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodGet, "/rest/api/2/myself", nil, nil, &user); err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+	return &user, nil
+}
+
+// NewIssue holds the fields of an issue to create
+type NewIssue struct {
+	ProjectKey  string
+	IssueType   string
+	Summary     string
+	Description string
+}
+
+// CreateIssue creates the issue and returns its key
+func (c *Client) CreateIssue(ctx context.Context, issue NewIssue) (string, error) {
+	fields := map[string]interface{}{
+		"project":   map[string]string{"key": issue.ProjectKey},
+		"issuetype": map[string]string{"name": issue.IssueType},
+		"summary":   issue.Summary,
+	}
+	if issue.Description != "" {
+		fields["description"] = issue.Description
+	}
+	var created IssueLink
+	if err := c.do(ctx, http.MethodPost, "/rest/api/2/issue", nil, map[string]interface{}{"fields": fields}, &created); err != nil {
+		return "", fmt.Errorf("failed to create issue in %s: %w", issue.ProjectKey, err)
+	}
+	return created.Key, nil
+}
+
+// AssignIssue assigns the issue to the user, identified by account ID on Jira Cloud and by
+// username on Jira Server
+func (c *Client) AssignIssue(ctx context.Context, key string, user User) error {
+	body := map[string]string{"name": user.Name}
+	if user.AccountID != "" {
+		body = map[string]string{"accountId": user.AccountID}
+	}
+	if err := c.do(ctx, http.MethodPut, "/rest/api/2/issue/"+url.PathEscape(key)+"/assignee", nil, body, nil); err != nil {
+		return fmt.Errorf("failed to assign %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Transition moves an issue from its status to another in the issue's workflow
+type Transition struct {
+	ID   string  `json:"id"`
+	Name string  `json:"name"`
+	To   *Status `json:"to"`
+}
+
+// Transitions returns the transitions the token's user can take on the issue
+func (c *Client) Transitions(ctx context.Context, key string) ([]Transition, error) {
+	var result struct {
+		Transitions []Transition `json:"transitions"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(key)+"/transitions", nil, nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to get transitions of %s: %w", key, err)
+	}
+	return result.Transitions, nil
+}
+
+// TransitionIssue takes the transition on the issue, adding the comment when one is given
+func (c *Client) TransitionIssue(ctx context.Context, key, transitionID, comment string) error {
+	body := map[string]interface{}{"transition": map[string]string{"id": transitionID}}
+	if comment != "" {
+		body["update"] = map[string]interface{}{
+			"comment": []interface{}{map[string]interface{}{"add": map[string]string{"body": comment}}},
+		}
+	}
+	if err := c.do(ctx, http.MethodPost, "/rest/api/2/issue/"+url.PathEscape(key)+"/transitions", nil, body, nil); err != nil {
+		return fmt.Errorf("failed to transition %s: %w", key, err)
+	}
+	return nil
+}
+
+// FindTransition returns the transition named after the status or leading to it, nil if there is none
+func FindTransition(transitions []Transition, status string) *Transition {
+	for i, t := range transitions {
+		if strings.EqualFold(t.Name, status) || (t.To != nil && strings.EqualFold(t.To.Name, status)) {
+			return &transitions[i]
+		}
+	}
+	return nil
+}
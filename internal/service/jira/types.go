@@ -0,0 +1,159 @@
+package jira
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// timeLayout is how the REST API formats timestamps
+const timeLayout = "2006-01-02T15:04:05.000-0700"
+
+// Time is a timestamp in the REST API's format
+type Time struct {
+	time.Time
+}
+
+// UnmarshalJSON parses a REST API timestamp
+func (t *Time) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		return nil
+	}
+	parsed, err := time.Parse(timeLayout, value)
+	if err != nil {
+		// Accept RFC 3339 as well, as some endpoints and proxies return it
+		if parsed, err = time.Parse(time.RFC3339Nano, value); err != nil {
+			return err
+		}
+	}
+	t.Time = parsed
+	return nil
+}
+
+// MarshalJSON writes the timestamp in the REST API's format, or null when it is not set
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + t.Format(timeLayout) + `"`), nil
+}
+
+// Issue is a Jira issue
+type Issue struct {
+	ID     string      `json:"id"`
+	Key    string      `json:"key"`
+	Self   string      `json:"self"`
+	Fields IssueFields `json:"fields"`
+}
+
+// IssueFields are the commonly used fields of an issue
+type IssueFields struct {
+	Summary     string     `json:"summary"`
+	Description string     `json:"description"`
+	IssueType   *Named     `json:"issuetype"`
+	Status      *Status    `json:"status"`
+	Priority    *Named     `json:"priority"`
+	Resolution  *Named     `json:"resolution"`
+	Project     *Project   `json:"project"`
+	Assignee    *User      `json:"assignee"`
+	Reporter    *User      `json:"reporter"`
+	Labels      []string   `json:"labels"`
+	Components  []Named    `json:"components"`
+	FixVersions []Named    `json:"fixVersions"`
+	Created     Time       `json:"created"`
+	Updated     Time       `json:"updated"`
+	DueDate     string     `json:"duedate"`
+	Parent      *IssueLink `json:"parent"`
+
+	// Custom holds the values of custom fields by field ID, e.g. customfield_10002
+	Custom map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the common fields and keeps the custom ones in Custom
+func (f *IssueFields) UnmarshalJSON(data []byte) error {
+	type common IssueFields
+	if err := json.Unmarshal(data, (*common)(f)); err != nil {
+		return err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for id, value := range all {
+		if !strings.HasPrefix(id, "customfield_") || string(value) == "null" {
+			continue
+		}
+		if f.Custom == nil {
+			f.Custom = map[string]json.RawMessage{}
+		}
+		f.Custom[id] = value
+	}
+	return nil
+}
+
+// Number returns the value of a numeric custom field, false if it is not set
+func (f IssueFields) Number(id string) (float64, bool) {
+	var value float64
+	if err := json.Unmarshal(f.Custom[id], &value); err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// Named is a field value that is identified by its name, such as a status or priority
+type Named struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Status is the status of an issue and the category it belongs to
+type Status struct {
+	Named
+	Category *StatusCategory `json:"statusCategory"`
+}
+
+// StatusCategory groups statuses into to do, in progress and done
+type StatusCategory struct {
+	Key  string `json:"key"` // new, indeterminate or done
+	Name string `json:"name"`
+}
+
+// Project is the project an issue belongs to
+type Project struct {
+	ID   string `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// User is a Jira user
+type User struct {
+	Name         string `json:"name"`
+	Key          string `json:"key"`
+	AccountID    string `json:"accountId,omitempty"` // Jira Cloud only
+	DisplayName  string `json:"displayName"`
+	EmailAddress string `json:"emailAddress"`
+	Active       bool   `json:"active"`
+}
+
+// Comment is a comment on an issue
+type Comment struct {
+	ID      string `json:"id"`
+	Body    string `json:"body"`
+	Author  *User  `json:"author"`
+	Created Time   `json:"created"`
+}
+
+// IssueLink refers to another issue
+type IssueLink struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
+}
+
+// SearchResult is one page of a JQL search
+type SearchResult struct {
+	StartAt    int     `json:"startAt"`
+	MaxResults int     `json:"maxResults"`
+	Total      int     `json:"total"`
+	Issues     []Issue `json:"issues"`
+}
@@ -0,0 +1,18 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// FindUsers returns the active users whose username, name or email address matches the query
+func (c *Client) FindUsers(ctx context.Context, query string) ([]User, error) {
+	params := url.Values{"username": {query}, "maxResults": {"10"}}
+	var users []User
+	if err := c.do(ctx, http.MethodGet, "/rest/api/2/user/search", params, nil, &users); err != nil {
+		return nil, fmt.Errorf("failed to find users matching %s: %w", query, err)
+	}
+	return users, nil
+}
@@ -0,0 +1,81 @@
+package jira
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"jira_helper/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Webhook events that are turned into notifications
+const (
+	WebhookIssueCreated   = "jira:issue_created"
+	WebhookIssueUpdated   = "jira:issue_updated"
+	WebhookCommentCreated = "comment_created"
+)
+
+// WebhookEvent is the payload of a Jira webhook delivery
+type WebhookEvent struct {
+	WebhookEvent       string     `json:"webhookEvent"`
+	IssueEventTypeName string     `json:"issue_event_type_name"` // Such as issue_commented on Jira Server
+	Timestamp          int64      `json:"timestamp"`
+	User               *User      `json:"user"`
+	Issue              *Issue     `json:"issue"`
+	Comment            *Comment   `json:"comment"`
+	Changelog          *Changelog `json:"changelog"`
+}
+
+// Changelog lists the fields changed by an update
+type Changelog struct {
+	Items []ChangelogItem `json:"items"`
+}
+
+// ChangelogItem is the change of one field
+type ChangelogItem struct {
+	Field      string `json:"field"`
+	FromString string `json:"fromString"`
+	ToString   string `json:"toString"`
+}
+
+// VerifySignature reports whether the X-Hub-Signature header, sha256=<hex>, matches the body
+func VerifySignature(secret string, body []byte, header string) bool {
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(signature))
+}
+
+// RequireWebhookSecret rejects webhook deliveries that do not prove they know the secret. Jira
+// Cloud signs deliveries in X-Hub-Signature, Jira Server cannot sign and passes the secret in
+// the secret query parameter of the webhook URL instead.
+func RequireWebhookSecret(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		valid := hmac.Equal([]byte(c.Query("secret")), []byte(secret))
+		if signature := c.GetHeader("X-Hub-Signature"); signature != "" {
+			valid = VerifySignature(secret, body, signature)
+		}
+		if !valid {
+			logger.GetLogger().Warn("rejected jira webhook without a valid secret")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Worklog is time logged on an issue
+type Worklog struct {
+	ID               string `json:"id"`
+	Author           *User  `json:"author"`
+	Comment          string `json:"comment"`
+	Started          Time   `json:"started"`
+	TimeSpentSeconds int    `json:"timeSpentSeconds"`
+}
+
+// Worklogs returns all time logged on the issue
+func (c *Client) Worklogs(ctx context.Context, key string) ([]Worklog, error) {
+	var worklogs []Worklog
+	for {
+		query := url.Values{"startAt": {strconv.Itoa(len(worklogs))}, "maxResults": {strconv.Itoa(maxPageSize)}}
+		var page struct {
+			Total    int       `json:"total"`
+			Worklogs []Worklog `json:"worklogs"`
+		}
+		if err := c.do(ctx, http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(key)+"/worklog", query, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to get worklogs of %s: %w", key, err)
+		}
+		worklogs = append(worklogs, page.Worklogs...)
+		if len(page.Worklogs) == 0 || len(worklogs) >= page.Total {
+			return worklogs, nil
+		}
+	}
+}
+
+// GroupMembers returns the active members of the Jira group, up to limit
+func (c *Client) GroupMembers(ctx context.Context, group string, limit int) ([]User, error) {
+	var members []User
+	for start := 0; len(members) < limit; {
+		query := url.Values{
+			"groupname":  {group},
+			"startAt":    {strconv.Itoa(start)},
+			"maxResults": {"50"},
+		}
+		var page struct {
+			IsLast bool   `json:"isLast"`
+			Values []User `json:"values"`
+		}
+		if err := c.do(ctx, http.MethodGet, "/rest/api/2/group/member", query, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to get members of group %s: %w", group, err)
+		}
+		for _, user := range page.Values {
+			if user.Active && len(members) < limit {
+				members = append(members, user)
+			}
+		}
+		start += len(page.Values)
+		if page.IsLast || len(page.Values) == 0 {
+			break
+		}
+	}
+	return members, nil
+}
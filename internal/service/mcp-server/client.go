@@ -0,0 +1,106 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"jira_helper/internal/httpclient"
+)
+
+// Client calls the Confluence Server / Data Center REST API with a personal access token
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new Client instance for the Confluence at baseURL
+func NewClient(baseURL, token string) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid Confluence URL %q", baseURL)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("a Confluence personal access token is required")
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: httpclient.New(httpclient.ConfluenceTimeout),
+	}, nil
+}
+
+// do sends an authorized request to the REST API and decodes the JSON response into result
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, result interface{}) error {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Confluence: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read Confluence response: %v", err)
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return newAPIError(resp.StatusCode, respBody)
+	}
+	if result != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return fmt.Errorf("failed to decode Confluence response: %v", err)
+		}
+	}
+	return nil
+}
+
+// APIError is an error response of the Confluence REST API
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+// newAPIError parses the error response body
+func newAPIError(statusCode int, body []byte) *APIError {
+	var parsed struct {
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &parsed)
+	return &APIError{StatusCode: statusCode, Message: parsed.Message}
+}
+
+// Error describes the status and message of the response
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("confluence returned %d", e.StatusCode)
+	}
+	return fmt.Sprintf("confluence returned %d: %s", e.StatusCode, e.Message)
+}
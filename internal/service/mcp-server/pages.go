@@ -0,0 +1,175 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Page is a Confluence page
+type Page struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Space   *Space `json:"space,omitempty"`
+	Version *struct {
+		Number int `json:"number"`
+	} `json:"version,omitempty"`
+	Body *struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body,omitempty"`
+	Metadata *struct {
+		Labels struct {
+			Results []Label `json:"results"`
+		} `json:"labels"`
+	} `json:"metadata,omitempty"`
+	Links struct {
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+}
+
+// Space is the space a page belongs to
+type Space struct {
+	Key  string `json:"key"`
+	Name string `json:"name,omitempty"`
+}
+
+// Label is a label of a page
+type Label struct {
+	Prefix string `json:"prefix"`
+	Name   string `json:"name"`
+}
+
+// NewPage is a page to create
+type NewPage struct {
+	SpaceKey string
+	Title    string
+	Body     string // Storage format
+	ParentID string // Optional: page the new page is created under
+}
+
+// Search returns the pages matching a CQL query
+func (c *Client) Search(ctx context.Context, cql string, limit int) ([]Page, error) {
+	query := url.Values{}
+	query.Set("cql", cql)
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("expand", "space")
+	var result struct {
+		Results []Page `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/content/search", query, nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to search Confluence: %w", err)
+	}
+	return result.Results, nil
+}
+
+// GetPage returns the page with its storage format body, version and labels
+func (c *Client) GetPage(ctx context.Context, id string) (*Page, error) {
+	query := url.Values{}
+	query.Set("expand", "body.storage,version,space,metadata.labels")
+	var page Page
+	if err := c.do(ctx, http.MethodGet, "/rest/api/content/"+url.PathEscape(id), query, nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to get page %s: %w", id, err)
+	}
+	return &page, nil
+}
+
+// CreatePage creates a page and returns it
+func (c *Client) CreatePage(ctx context.Context, page NewPage) (*Page, error) {
+	body := map[string]interface{}{
+		"type":  "page",
+		"title": page.Title,
+		"space": map[string]string{"key": page.SpaceKey},
+		"body":  storageBody(page.Body),
+	}
+	if page.ParentID != "" {
+		body["ancestors"] = []map[string]string{{"id": page.ParentID}}
+	}
+	var created Page
+	if err := c.do(ctx, http.MethodPost, "/rest/api/content", nil, body, &created); err != nil {
+		return nil, fmt.Errorf("failed to create page in %s: %w", page.SpaceKey, err)
+	}
+	return &created, nil
+}
+
+// UpdatePage replaces the body of a page, and its title unless title is empty, as a new version
+func (c *Client) UpdatePage(ctx context.Context, id, title, storage, versionComment string) (*Page, error) {
+	current, err := c.GetPage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if title == "" {
+		title = current.Title
+	}
+	version := 1
+	if current.Version != nil {
+		version = current.Version.Number + 1
+	}
+	body := map[string]interface{}{
+		"id":      id,
+		"type":    "page",
+		"title":   title,
+		"version": map[string]interface{}{"number": version, "message": versionComment},
+		"body":    storageBody(storage),
+	}
+	var updated Page
+	if err := c.do(ctx, http.MethodPut, "/rest/api/content/"+url.PathEscape(id), nil, body, &updated); err != nil {
+		return nil, fmt.Errorf("failed to update page %s: %w", id, err)
+	}
+	return &updated, nil
+}
+
+// AddLabels adds global labels to a page and returns all of its labels
+func (c *Client) AddLabels(ctx context.Context, id string, names []string) ([]Label, error) {
+	labels := make([]Label, 0, len(names))
+	for _, name := range names {
+		labels = append(labels, Label{Prefix: "global", Name: name})
+	}
+	var result struct {
+		Results []Label `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/rest/api/content/"+url.PathEscape(id)+"/label", nil, labels, &result); err != nil {
+		return nil, fmt.Errorf("failed to label page %s: %w", id, err)
+	}
+	return result.Results, nil
+}
+
+// WebURL returns the link to the page in the browser
+func (c *Client) WebURL(page *Page) string {
+	if page.Links.WebUI == "" {
+		return ""
+	}
+	return c.baseURL + page.Links.WebUI
+}
+
+// storageBody returns the body of a page in storage format
+func storageBody(value string) map[string]interface{} {
+	return map[string]interface{}{
+		"storage": map[string]string{"value": value, "representation": "storage"},
+	}
+}
+
+// TextToStorage converts plain text to storage format, a paragraph per block of lines
+func TextToStorage(text string) string {
+	var b strings.Builder
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph == "" {
+			continue
+		}
+		lines := strings.Split(html.EscapeString(paragraph), "\n")
+		b.WriteString("<p>" + strings.Join(lines, "<br/>") + "</p>")
+	}
+	return b.String()
+}
+
+// TextQuery returns a CQL query for pages containing the text
+func TextQuery(text string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text)
+	return fmt.Sprintf(`type = page AND text ~ "%s"`, escaped)
+}
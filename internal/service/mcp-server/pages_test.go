@@ -0,0 +1,142 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		token   string
+		wantErr bool
+	}{
+		{"valid", "https://wiki.example.com/", "pat", false},
+		{"no scheme", "wiki.example.com", "pat", true},
+		{"no host", "https://", "pat", true},
+		{"no token", "https://wiki.example.com", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.baseURL, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewClient(%q) error = %v, wantErr %v", tt.baseURL, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTextToStorage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"empty", "  ", ""},
+		{"paragraphs", "first\n\nsecond", "<p>first</p><p>second</p>"},
+		{"line breaks", "a\nb", "<p>a<br/>b</p>"},
+		{"markup is escaped", `<script>"x" & y</script>`, "<p>&lt;script&gt;&#34;x&#34; &amp; y&lt;/script&gt;</p>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TextToStorage(tt.text); got != tt.want {
+				t.Errorf("TextToStorage(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"release notes", `type = page AND text ~ "release notes"`},
+		{`x" OR space = "SEC`, `type = page AND text ~ "x\" OR space = \"SEC"`},
+		{`a\`, `type = page AND text ~ "a\\"`},
+	}
+	for _, tt := range tests {
+		if got := TextQuery(tt.text); got != tt.want {
+			t.Errorf("TextQuery(%q) = %s, want %s", tt.text, got, tt.want)
+		}
+	}
+}
+
+// newTestClient returns a client for a fake Confluence serving handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := NewClient(srv.URL, "pat")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client
+}
+
+func TestSearch(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer pat" {
+			t.Errorf("Authorization = %q, want Bearer pat", got)
+		}
+		if r.URL.Path != "/rest/api/content/search" || r.URL.Query().Get("cql") != "space = DOCS" || r.URL.Query().Get("limit") != "5" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Write([]byte(`{"results":[{"id":"1","title":"Home","space":{"key":"DOCS"},"_links":{"webui":"/display/DOCS/Home"}}]}`))
+	})
+
+	pages, err := client.Search(context.Background(), "space = DOCS", 5)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(pages) != 1 || pages[0].ID != "1" || pages[0].Space.Key != "DOCS" {
+		t.Fatalf("Search() = %+v", pages)
+	}
+	if got, want := client.WebURL(&pages[0]), client.baseURL+"/display/DOCS/Home"; got != want {
+		t.Errorf("WebURL() = %q, want %q", got, want)
+	}
+}
+
+func TestUpdatePage(t *testing.T) {
+	var sent map[string]interface{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"id":"7","title":"Runbook","version":{"number":3}}`))
+		case http.MethodPut:
+			if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+				t.Errorf("failed to decode update: %v", err)
+			}
+			w.Write([]byte(`{"id":"7","title":"Runbook"}`))
+		}
+	})
+
+	if _, err := client.UpdatePage(context.Background(), "7", "", "<p>new</p>", "fix typo"); err != nil {
+		t.Fatalf("UpdatePage() error = %v", err)
+	}
+	version := sent["version"].(map[string]interface{})
+	if version["number"] != float64(4) || version["message"] != "fix typo" {
+		t.Errorf("version = %v, want number 4 with the comment", version)
+	}
+	if sent["title"] != "Runbook" {
+		t.Errorf("title = %v, want the current title", sent["title"])
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"not permitted"}`))
+	})
+
+	_, err := client.GetPage(context.Background(), "7")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden || apiErr.Message != "not permitted" {
+		t.Fatalf("GetPage() error = %v, want a 403 APIError", err)
+	}
+}
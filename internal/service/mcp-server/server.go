@@ -0,0 +1,211 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// defaultSearchLimit is how many pages a search returns unless it asks for another number
+	defaultSearchLimit = 10
+
+	// maxSearchLimit is the most pages a search returns
+	maxSearchLimit = 50
+)
+
+// cqlPattern recognizes queries that are already CQL rather than plain text
+var cqlPattern = regexp.MustCompile(`[=~]|\b(AND|OR|ORDER BY)\b`)
+
+// NewServer creates an MCP server with the Confluence tools, named like those of mcp-atlassian,
+// acting on Confluence through the client
+func NewServer(client *Client) *server.MCPServer {
+	s := server.NewMCPServer("jira-helper", "1.0.0", server.WithToolCapabilities(false))
+	t := tools{client: client}
+
+	s.AddTool(mcp.NewTool("confluence_search",
+		mcp.WithDescription("Search Confluence pages with CQL (Confluence Query Language) or plain text"),
+		mcp.WithString("query", mcp.Required(), mcp.Description("CQL query, e.g. space = DOCS AND title ~ \"release\", or text to search for")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of results (1-50)")),
+	), t.search)
+	s.AddTool(mcp.NewTool("confluence_get_page",
+		mcp.WithDescription("Get the content, version and labels of a Confluence page"),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Confluence page ID")),
+	), t.getPage)
+	s.AddTool(mcp.NewTool("confluence_create_page",
+		mcp.WithDescription("Create a new Confluence page"),
+		mcp.WithString("space_key", mcp.Required(), mcp.Description("Key of the space, e.g. DOCS")),
+		mcp.WithString("title", mcp.Required(), mcp.Description("Title of the page")),
+		mcp.WithString("content", mcp.Required(), mcp.Description("Content of the page")),
+		mcp.WithString("content_format", mcp.Description("text (default) or storage for Confluence storage format XHTML")),
+		mcp.WithString("parent_id", mcp.Description("ID of the page to create the page under")),
+	), t.createPage)
+	s.AddTool(mcp.NewTool("confluence_update_page",
+		mcp.WithDescription("Replace the content of an existing Confluence page"),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Confluence page ID")),
+		mcp.WithString("content", mcp.Required(), mcp.Description("New content of the page")),
+		mcp.WithString("content_format", mcp.Description("text (default) or storage for Confluence storage format XHTML")),
+		mcp.WithString("title", mcp.Description("New title, keeps the current title when empty")),
+		mcp.WithString("version_comment", mcp.Description("Comment of the new version")),
+	), t.updatePage)
+	s.AddTool(mcp.NewTool("confluence_add_label",
+		mcp.WithDescription("Add a label to a Confluence page"),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Confluence page ID")),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Label to add, comma-separated for several")),
+	), t.addLabel)
+	return s
+}
+
+// tools implements the tools with the Confluence client
+type tools struct {
+	client *Client
+}
+
+func (t tools) search(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := request.RequireString("query")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if !cqlPattern.MatchString(query) {
+		query = TextQuery(query)
+	}
+	limit := request.GetInt("limit", defaultSearchLimit)
+	if limit < 1 || limit > maxSearchLimit {
+		limit = defaultSearchLimit
+	}
+
+	pages, err := t.client.Search(ctx, query, limit)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	results := make([]map[string]string, 0, len(pages))
+	for i := range pages {
+		results = append(results, t.summary(&pages[i]))
+	}
+	return jsonResult(results), nil
+}
+
+func (t tools) getPage(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("page_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	page, err := t.client.GetPage(ctx, id)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	result := map[string]interface{}{"metadata": t.summary(page)}
+	if page.Version != nil {
+		result["version"] = page.Version.Number
+	}
+	if page.Body != nil {
+		result["content"] = page.Body.Storage.Value
+	}
+	if page.Metadata != nil {
+		labels := []string{}
+		for _, label := range page.Metadata.Labels.Results {
+			labels = append(labels, label.Name)
+		}
+		result["labels"] = labels
+	}
+	return jsonResult(result), nil
+}
+
+func (t tools) createPage(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := make(map[string]string)
+	for _, name := range []string{"space_key", "title", "content"} {
+		value, err := request.RequireString(name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		args[name] = value
+	}
+	page, err := t.client.CreatePage(ctx, NewPage{
+		SpaceKey: strings.ToUpper(args["space_key"]),
+		Title:    args["title"],
+		Body:     storageContent(request, args["content"]),
+		ParentID: request.GetString("parent_id", ""),
+	})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return jsonResult(map[string]interface{}{"message": "Page created successfully", "page": t.summary(page)}), nil
+}
+
+func (t tools) updatePage(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("page_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	content, err := request.RequireString("content")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	page, err := t.client.UpdatePage(ctx, id, request.GetString("title", ""), storageContent(request, content), request.GetString("version_comment", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return jsonResult(map[string]interface{}{"message": "Page updated successfully", "page": t.summary(page)}), nil
+}
+
+func (t tools) addLabel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("page_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	name, err := request.RequireString("name")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	var names []string
+	for _, label := range strings.Split(name, ",") {
+		// Confluence labels cannot contain spaces
+		if label = strings.ReplaceAll(strings.TrimSpace(label), " ", "-"); label != "" {
+			names = append(names, strings.ToLower(label))
+		}
+	}
+	if len(names) == 0 {
+		return mcp.NewToolResultError("name must contain a label"), nil
+	}
+
+	labels, err := t.client.AddLabels(ctx, id, names)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	all := []string{}
+	for _, label := range labels {
+		all = append(all, label.Name)
+	}
+	return jsonResult(map[string]interface{}{"page_id": id, "labels": all}), nil
+}
+
+// summary returns the fields identifying a page
+func (t tools) summary(page *Page) map[string]string {
+	summary := map[string]string{"id": page.ID, "title": page.Title, "url": t.client.WebURL(page)}
+	if page.Space != nil {
+		summary["space"] = page.Space.Key
+	}
+	return summary
+}
+
+// storageContent returns the content in storage format, converting it unless it is given in storage format
+func storageContent(request mcp.CallToolRequest, content string) string {
+	if strings.EqualFold(request.GetString("content_format", ""), "storage") {
+		return content
+	}
+	return TextToStorage(content)
+}
+
+// jsonResult returns the value as indented JSON text, like mcp-atlassian
+func jsonResult(value interface{}) *mcp.CallToolResult {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	return mcp.NewToolResultText(string(data))
+}
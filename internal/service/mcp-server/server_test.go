@@ -0,0 +1,127 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// callTool calls the tool handler with the arguments and returns the text of its result
+func callTool(t *testing.T, handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error), args map[string]any) (string, bool) {
+	t.Helper()
+	var request mcp.CallToolRequest
+	request.Params.Arguments = args
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("tool error = %v", err)
+	}
+	text, _ := result.Content[0].(mcp.TextContent)
+	return text.Text, result.IsError
+}
+
+func TestSearchTool(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]any
+		wantCQL string
+		wantErr bool
+	}{
+		{"cql", map[string]any{"query": `space = DOCS AND title ~ "release"`}, `space = DOCS AND title ~ "release"`, false},
+		{"plain text", map[string]any{"query": "release notes"}, `type = page AND text ~ "release notes"`, false},
+		{"missing query", map[string]any{}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotCQL string
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				gotCQL = r.URL.Query().Get("cql")
+				w.Write([]byte(`{"results":[]}`))
+			})
+			_, isError := callTool(t, tools{client: client}.search, tt.args)
+			if isError != tt.wantErr {
+				t.Fatalf("IsError = %v, want %v", isError, tt.wantErr)
+			}
+			if gotCQL != tt.wantCQL {
+				t.Errorf("cql = %q, want %q", gotCQL, tt.wantCQL)
+			}
+		})
+	}
+}
+
+func TestCreatePageTool(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     map[string]any
+		wantBody string
+		wantErr  bool
+	}{
+		{"text", map[string]any{"space_key": "docs", "title": "Notes", "content": "a & b"}, "<p>a &amp; b</p>", false},
+		{"storage", map[string]any{"space_key": "docs", "title": "Notes", "content": "<h1>x</h1>", "content_format": "storage"}, "<h1>x</h1>", false},
+		{"missing title", map[string]any{"space_key": "docs", "content": "x"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent struct {
+				Space struct {
+					Key string `json:"key"`
+				} `json:"space"`
+				Body struct {
+					Storage struct {
+						Value string `json:"value"`
+					} `json:"storage"`
+				} `json:"body"`
+			}
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&sent)
+				w.Write([]byte(`{"id":"9","title":"Notes"}`))
+			})
+			text, isError := callTool(t, tools{client: client}.createPage, tt.args)
+			if isError != tt.wantErr {
+				t.Fatalf("IsError = %v, want %v: %s", isError, tt.wantErr, text)
+			}
+			if tt.wantErr {
+				return
+			}
+			if sent.Space.Key != "DOCS" || sent.Body.Storage.Value != tt.wantBody {
+				t.Errorf("created in %q with body %q, want DOCS with %q", sent.Space.Key, sent.Body.Storage.Value, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestAddLabelTool(t *testing.T) {
+	tests := []struct {
+		name       string
+		label      string
+		wantLabels []string
+		wantErr    bool
+	}{
+		{"single", "Runbook", []string{"runbook"}, false},
+		{"several with spaces", "on call, Post Mortem", []string{"on-call", "post-mortem"}, false},
+		{"empty", " , ", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []Label
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&sent)
+				w.Write([]byte(`{"results":[]}`))
+			})
+			_, isError := callTool(t, tools{client: client}.addLabel, map[string]any{"page_id": "7", "name": tt.label})
+			if isError != tt.wantErr {
+				t.Fatalf("IsError = %v, want %v", isError, tt.wantErr)
+			}
+			var names []string
+			for _, label := range sent {
+				names = append(names, label.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantLabels, ",") {
+				t.Errorf("labels = %v, want %v", names, tt.wantLabels)
+			}
+		})
+	}
+}
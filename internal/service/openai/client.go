@@ -3,15 +3,26 @@ package openai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
+
+	"jira_helper/internal/httpclient"
 	"jira_helper/internal/logger"
+	"jira_helper/internal/tracing"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// openAIURL is the endpoint of the OpenAI API
+const openAIURL = "https://api.openai.com/v1"
+
 type Client struct {
 	client         *azopenai.Client
 	deploymentName string
@@ -19,7 +30,9 @@ type Client struct {
 
 func NewClient(endpoint, apiKey, deploymentName string) (*Client, error) {
 	keyCredential := azcore.NewKeyCredential(apiKey)
-	client, err := azopenai.NewClientWithKeyCredential(endpoint, keyCredential, nil)
+	client, err := azopenai.NewClientWithKeyCredential(endpoint, keyCredential, &azopenai.ClientOptions{
+		ClientOptions: azcore.ClientOptions{Transport: httpclient.New(httpclient.AITimeout)},
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -30,6 +43,25 @@ func NewClient(endpoint, apiKey, deploymentName string) (*Client, error) {
 	}, nil
 }
 
+// NewOpenAIClient creates a client of the OpenAI API, or of an OpenAI compatible API when baseURL
+// is set. model takes the place of the Azure deployment name.
+func NewOpenAIClient(baseURL, apiKey, model string) (*Client, error) {
+	if baseURL == "" {
+		baseURL = openAIURL
+	}
+	client, err := azopenai.NewClientForOpenAI(baseURL, azcore.NewKeyCredential(apiKey), &azopenai.ClientOptions{
+		ClientOptions: azcore.ClientOptions{Transport: httpclient.New(httpclient.AITimeout)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		client:         client,
+		deploymentName: model,
+	}, nil
+}
+
 func (c *Client) Chat(ctx context.Context, messages []azopenai.ChatRequestMessageClassification) (string, error) {
 	resp, err := c.client.GetChatCompletions(ctx, azopenai.ChatCompletionsOptions{
 		DeploymentName: to.Ptr(c.deploymentName),
@@ -61,13 +93,15 @@ type ToolCall struct {
 
 // ChatResponse 表示一次对话的完整响应
 type ChatResponse struct {
-	Content    string     // 文本内容
-	ToolCalls  []ToolCall // 工具调用列表
-	IsComplete bool       // 是否完成（不需要进一步的工具调用）
+	Content          string     // 文本内容
+	ToolCalls        []ToolCall // 工具调用列表
+	IsComplete       bool       // 是否完成（不需要进一步的工具调用）
+	PromptTokens     int        // 提示词消耗的 token 数
+	CompletionTokens int        // 生成内容消耗的 token 数
 }
 
-func (c *Client) ChatWithTools(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []Tool) (*ChatResponse, error) {
-	// Convert tools to Azure OpenAI ToolDefinition format
+// toolDefinitions converts tools to the Azure OpenAI ToolDefinition format
+func toolDefinitions(tools []Tool) []azopenai.ChatCompletionsToolDefinitionClassification {
 	var azureTools []azopenai.ChatCompletionsToolDefinitionClassification
 	for _, tool := range tools {
 		azureTools = append(azureTools, &azopenai.ChatCompletionsFunctionToolDefinition{
@@ -78,14 +112,24 @@ func (c *Client) ChatWithTools(ctx context.Context, messages []azopenai.ChatRequ
 			},
 		})
 	}
+	return azureTools
+}
+
+func (c *Client) ChatWithTools(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []Tool) (*ChatResponse, error) {
+	ctx, span := c.startChatSpan(ctx, messages, tools, false)
+	response, err := c.chatWithTools(ctx, messages, tools)
+	endChatSpan(span, response, err)
+	return response, err
+}
 
+func (c *Client) chatWithTools(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []Tool) (*ChatResponse, error) {
 	// Log message sent to AI
 	logger.GetLogger().Debug("sending messages to AI", zap.Any("messages", messages))
 	resp, err := c.client.GetChatCompletions(ctx, azopenai.ChatCompletionsOptions{
 		DeploymentName: to.Ptr(c.deploymentName),
 		Messages:       messages,
 		N:              to.Ptr[int32](1),
-		Tools:          azureTools,
+		Tools:          toolDefinitions(tools),
 	}, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chat completion: %v", err)
@@ -100,6 +144,7 @@ func (c *Client) ChatWithTools(ctx context.Context, messages []azopenai.ChatRequ
 	response := &ChatResponse{
 		IsComplete: true, // Default to complete
 	}
+	response.PromptTokens, response.CompletionTokens = tokenUsage(resp.Usage)
 	if choice.Message != nil && choice.Message.Content != nil {
 		response.Content = *choice.Message.Content
 	}
@@ -132,3 +177,132 @@ func (c *Client) ChatWithTools(ctx context.Context, messages []azopenai.ChatRequ
 
 	return response, nil
 }
+
+// ChatWithToolsStream works like ChatWithTools but streams the completion. onContent is called
+// with the text generated so far each time the model produces more of it, so callers can show
+// long answers while they are being written.
+func (c *Client) ChatWithToolsStream(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []Tool, onContent func(content string)) (*ChatResponse, error) {
+	ctx, span := c.startChatSpan(ctx, messages, tools, true)
+	response, err := c.chatWithToolsStream(ctx, messages, tools, onContent)
+	endChatSpan(span, response, err)
+	return response, err
+}
+
+func (c *Client) chatWithToolsStream(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []Tool, onContent func(content string)) (*ChatResponse, error) {
+	logger.GetLogger().Debug("streaming messages to AI", zap.Any("messages", messages))
+	resp, err := c.client.GetChatCompletionsStream(ctx, azopenai.ChatCompletionsStreamOptions{
+		DeploymentName: to.Ptr(c.deploymentName),
+		Messages:       messages,
+		N:              to.Ptr[int32](1),
+		Tools:          toolDefinitions(tools),
+		StreamOptions:  &azopenai.ChatCompletionStreamOptions{IncludeUsage: to.Ptr(true)},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat completion stream: %v", err)
+	}
+	defer resp.ChatCompletionsStream.Close()
+
+	var content strings.Builder
+	var calls []*streamedToolCall
+	var usage *azopenai.CompletionsUsage
+	for {
+		chunk, err := resp.ChatCompletionsStream.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chat completion stream: %v", err)
+		}
+		// The last chunk reports the usage of the whole completion
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta == nil {
+				continue
+			}
+			if choice.Delta.Content != nil && *choice.Delta.Content != "" {
+				content.WriteString(*choice.Delta.Content)
+				if onContent != nil {
+					onContent(content.String())
+				}
+			}
+			calls = appendToolCallDeltas(calls, choice.Delta.ToolCalls)
+		}
+	}
+
+	response := &ChatResponse{Content: content.String(), IsComplete: len(calls) == 0}
+	response.PromptTokens, response.CompletionTokens = tokenUsage(usage)
+	for _, call := range calls {
+		args := map[string]interface{}{}
+		if raw := call.arguments.String(); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &args); err != nil {
+				return nil, fmt.Errorf("failed to parse tool arguments: %v", err)
+			}
+		}
+		response.ToolCalls = append(response.ToolCalls, ToolCall{ID: call.id, Name: call.name, Args: args})
+	}
+	logger.GetLogger().Debug("chat completion stream response", zap.Any("response", response))
+	return response, nil
+}
+
+// startChatSpan starts the span of a chat completion
+func (c *Client) startChatSpan(ctx context.Context, messages []azopenai.ChatRequestMessageClassification, tools []Tool, stream bool) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "openai.chat_with_tools",
+		attribute.String("gen_ai.request.model", c.deploymentName),
+		attribute.Int("gen_ai.request.messages", len(messages)),
+		attribute.Int("gen_ai.request.tools", len(tools)),
+		attribute.Bool("gen_ai.request.stream", stream))
+}
+
+// endChatSpan adds the token usage and tool calls of the response to the span and ends it
+func endChatSpan(span trace.Span, response *ChatResponse, err error) {
+	if response != nil {
+		span.SetAttributes(
+			attribute.Int("gen_ai.usage.input_tokens", response.PromptTokens),
+			attribute.Int("gen_ai.usage.output_tokens", response.CompletionTokens),
+			attribute.Int("gen_ai.response.tool_calls", len(response.ToolCalls)))
+	}
+	tracing.End(span, err)
+}
+
+// streamedToolCall is a tool call assembled from the deltas of a stream
+type streamedToolCall struct {
+	id        string
+	name      string
+	arguments strings.Builder
+}
+
+// appendToolCallDeltas adds the tool call deltas of a stream chunk. A delta with an ID starts a
+// new tool call, the following deltas continue its arguments.
+func appendToolCallDeltas(calls []*streamedToolCall, deltas []azopenai.ChatCompletionsToolCallClassification) []*streamedToolCall {
+	for _, delta := range deltas {
+		v, ok := delta.(*azopenai.ChatCompletionsFunctionToolCall)
+		if !ok {
+			logger.GetLogger().Error("unknown tool call", zap.Any("tool", delta))
+			continue
+		}
+		if v.ID != nil && *v.ID != "" {
+			calls = append(calls, &streamedToolCall{id: *v.ID})
+		}
+		if len(calls) == 0 || v.Function == nil {
+			continue
+		}
+		call := calls[len(calls)-1]
+		if v.Function.Name != nil {
+			call.name += *v.Function.Name
+		}
+		if v.Function.Arguments != nil {
+			call.arguments.WriteString(*v.Function.Arguments)
+		}
+	}
+	return calls
+}
+
+// tokenUsage returns the prompt and completion tokens of a completion, 0 when not reported
+func tokenUsage(usage *azopenai.CompletionsUsage) (int, int) {
+	if usage == nil || usage.PromptTokens == nil || usage.CompletionTokens == nil {
+		return 0, 0
+	}
+	return int(*usage.PromptTokens), int(*usage.CompletionTokens)
+}
@@ -0,0 +1,39 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+)
+
+// Embed returns the embedding vector of each text, computed by the client's deployment or model.
+// dimensions shortens the vectors of models that support it, 0 keeps the model's default.
+func (c *Client) Embed(ctx context.Context, texts []string, dimensions int) ([][]float32, error) {
+	options := azopenai.EmbeddingsOptions{
+		Input:          texts,
+		DeploymentName: &c.deploymentName,
+	}
+	if dimensions > 0 {
+		d := int32(dimensions)
+		options.Dimensions = &d
+	}
+	resp, err := c.client.GetEmbeddings(ctx, options, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embeddings: %v", err)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range resp.Data {
+		if item.Index == nil || int(*item.Index) >= len(texts) {
+			continue
+		}
+		vectors[*item.Index] = item.Embedding
+	}
+	for i, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("no embedding returned for input %d", i)
+		}
+	}
+	return vectors, nil
+}
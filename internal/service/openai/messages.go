@@ -0,0 +1,82 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+)
+
+// Message is a chat message in a form other providers can translate from. The conversation is
+// kept as azopenai messages, providers that are not OpenAI convert them with Messages.
+type Message struct {
+	Role       string     // system, user, assistant or tool
+	Content    string     // Text of the message, the result for tool messages
+	ToolCallID string     // Call that a tool message answers
+	ToolCalls  []ToolCall // Tools called by an assistant message
+}
+
+// wireMessage is the OpenAI JSON form that azopenai messages marshal to
+type wireMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content"`
+	ToolCallID string          `json:"tool_call_id"`
+	ToolCalls  []struct {
+		ID       string `json:"id"`
+		Function struct {
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+		} `json:"function"`
+	} `json:"tool_calls"`
+}
+
+// Messages converts azopenai messages to Message
+func Messages(messages []azopenai.ChatRequestMessageClassification) ([]Message, error) {
+	data, err := json.Marshal(messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal messages: %v", err)
+	}
+	var wire []wireMessage
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return nil, fmt.Errorf("failed to decode messages: %v", err)
+	}
+
+	converted := make([]Message, 0, len(wire))
+	for _, msg := range wire {
+		message := Message{Role: msg.Role, Content: contentText(msg.Content), ToolCallID: msg.ToolCallID}
+		for _, call := range msg.ToolCalls {
+			args := map[string]interface{}{}
+			if call.Function.Arguments != "" {
+				if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+					return nil, fmt.Errorf("failed to parse tool arguments: %v", err)
+				}
+			}
+			message.ToolCalls = append(message.ToolCalls, ToolCall{ID: call.ID, Name: call.Function.Name, Args: args})
+		}
+		converted = append(converted, message)
+	}
+	return converted, nil
+}
+
+// contentText returns the text of message content, which is either a string or a list of parts
+func contentText(content json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(content, &parts); err != nil {
+		return ""
+	}
+	var texts []string
+	for _, part := range parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
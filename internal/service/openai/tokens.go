@@ -0,0 +1,17 @@
+package openai
+
+import "unicode/utf8"
+
+// EstimateTokens estimates the tokens of a text: about four characters per token for ASCII text,
+// and a token per character for other scripts such as Chinese
+func EstimateTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
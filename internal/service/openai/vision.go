@@ -0,0 +1,32 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/ai/azopenai"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+)
+
+// DescribeImage asks the client's deployment or model, which must accept images such as gpt-4o,
+// about the image following the instruction
+func (c *Client) DescribeImage(ctx context.Context, instruction, mimeType string, image []byte) (string, error) {
+	dataURL := fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(image))
+	message := &azopenai.ChatRequestUserMessage{
+		Content: azopenai.NewChatRequestUserMessageContent([]azopenai.ChatCompletionRequestMessageContentPartClassification{
+			&azopenai.ChatCompletionRequestMessageContentPartText{Text: to.Ptr(instruction)},
+			&azopenai.ChatCompletionRequestMessageContentPartImage{
+				ImageURL: &azopenai.ChatCompletionRequestMessageContentPartImageURL{
+					URL:    to.Ptr(dataURL),
+					Detail: to.Ptr(azopenai.ChatCompletionRequestMessageContentPartImageURLDetailHigh),
+				},
+			},
+		}),
+	}
+	description, err := c.Chat(ctx, []azopenai.ChatRequestMessageClassification{message})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe image: %v", err)
+	}
+	return description, nil
+}
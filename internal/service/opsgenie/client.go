@@ -0,0 +1,92 @@
+package opsgenie
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"jira_helper/internal/httpclient"
+)
+
+// DefaultAPIURL is the base URL of the Opsgenie REST API, accounts in the EU use
+// https://api.eu.opsgenie.com
+const DefaultAPIURL = "https://api.opsgenie.com"
+
+// Client calls the Opsgenie REST API
+type Client struct {
+	apiURL     string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient creates a new Client instance. apiURL defaults to DefaultAPIURL.
+func NewClient(apiURL, apiKey string) *Client {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	return &Client{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		apiKey:     apiKey,
+		httpClient: httpclient.New(httpclient.OpsgenieTimeout),
+	}
+}
+
+// OnCallRecipients returns the email addresses of the users currently on call in the schedule,
+// given by its name or ID
+func (c *Client) OnCallRecipients(ctx context.Context, schedule string) ([]string, error) {
+	identifierType := "name"
+	if isID(schedule) {
+		identifierType = "id"
+	}
+	params := url.Values{"scheduleIdentifierType": {identifierType}, "flat": {"true"}}
+	endpoint := fmt.Sprintf("%s/v2/schedules/%s/on-calls?%s", c.apiURL, url.PathEscape(schedule), params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "GenieKey "+c.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call opsgenie: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &apiErr)
+		return nil, fmt.Errorf("failed to get on-calls of schedule %s: opsgenie returned %d: %s", schedule, resp.StatusCode, apiErr.Message)
+	}
+
+	var result struct {
+		Data struct {
+			OnCallRecipients []string `json:"onCallRecipients"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode opsgenie response: %v", err)
+	}
+	return result.Data.OnCallRecipients, nil
+}
+
+// isID reports whether the schedule is given by its ID, a UUID, rather than its name
+func isID(schedule string) bool {
+	parts := strings.Split(schedule, "-")
+	if len(parts) != 5 || len(schedule) != 36 {
+		return false
+	}
+	for _, r := range strings.ReplaceAll(schedule, "-", "") {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
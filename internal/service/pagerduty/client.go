@@ -0,0 +1,112 @@
+package pagerduty
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"jira_helper/internal/httpclient"
+)
+
+// restAPI is the base URL of the PagerDuty REST API
+const restAPI = "https://api.pagerduty.com/"
+
+// Client calls the PagerDuty REST API
+type Client struct {
+	apiToken   string
+	fromEmail  string // Email of the PagerDuty user that writes are attributed to
+	httpClient *http.Client
+}
+
+// NewClient creates a new Client instance
+func NewClient(apiToken, fromEmail string) *Client {
+	return &Client{
+		apiToken:   apiToken,
+		fromEmail:  fromEmail,
+		httpClient: httpclient.New(httpclient.PagerDutyTimeout),
+	}
+}
+
+// AddNote adds a note to the incident's timeline
+func (c *Client) AddNote(ctx context.Context, incidentID, content string) error {
+	body := map[string]interface{}{"note": map[string]string{"content": content}}
+	return c.call(ctx, http.MethodPost, restAPI+"incidents/"+incidentID+"/notes", body, nil)
+}
+
+// OnCalls returns the users currently on call in the schedule, those of the first escalation
+// level first
+func (c *Client) OnCalls(ctx context.Context, scheduleID string) ([]User, error) {
+	params := url.Values{
+		"schedule_ids[]": {scheduleID},
+		"include[]":      {"users"},
+		"earliest":       {"true"},
+	}
+	var result struct {
+		OnCalls []struct {
+			EscalationLevel int  `json:"escalation_level"`
+			User            User `json:"user"`
+		} `json:"oncalls"`
+	}
+	if err := c.call(ctx, http.MethodGet, restAPI+"oncalls?"+params.Encode(), nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to get on-calls of schedule %s: %v", scheduleID, err)
+	}
+	sort.SliceStable(result.OnCalls, func(i, j int) bool {
+		return result.OnCalls[i].EscalationLevel < result.OnCalls[j].EscalationLevel
+	})
+	users := make([]User, 0, len(result.OnCalls))
+	for _, onCall := range result.OnCalls {
+		users = append(users, onCall.User)
+	}
+	return users, nil
+}
+
+// call sends an authorized JSON request to the REST API and decodes the response into out,
+// unless it is nil
+func (c *Client) call(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token token="+c.apiToken)
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("From", c.fromEmail)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call pagerduty: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(resp.Body)
+		var apiErr struct {
+			Error struct {
+				Message string   `json:"message"`
+				Errors  []string `json:"errors"`
+			} `json:"error"`
+		}
+		_ = json.Unmarshal(respBody, &apiErr)
+		return fmt.Errorf("pagerduty returned %d: %s %v", resp.StatusCode, apiErr.Error.Message, apiErr.Error.Errors)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode pagerduty response: %v", err)
+	}
+	return nil
+}
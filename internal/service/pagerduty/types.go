@@ -0,0 +1,73 @@
+package pagerduty
+
+import "time"
+
+// Event types of the incident lifecycle handled by the bridge
+const (
+	EventIncidentTriggered    = "incident.triggered"
+	EventIncidentAcknowledged = "incident.acknowledged"
+	EventIncidentResolved     = "incident.resolved"
+)
+
+// WebhookPayload is the body of a V3 webhook delivery
+type WebhookPayload struct {
+	Event Event `json:"event"`
+}
+
+// Event is a single webhook event
+type Event struct {
+	ID           string    `json:"id"`
+	EventType    string    `json:"event_type"`
+	ResourceType string    `json:"resource_type"`
+	OccurredAt   time.Time `json:"occurred_at"`
+	Agent        *Ref      `json:"agent"`
+	Data         Incident  `json:"data"`
+}
+
+// Incident is the incident an event refers to
+type Incident struct {
+	ID        string    `json:"id"`
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Status    string    `json:"status"`
+	Urgency   string    `json:"urgency"`
+	HTMLURL   string    `json:"html_url"`
+	CreatedAt time.Time `json:"created_at"`
+	Service   Ref       `json:"service"`
+	Priority  *Ref      `json:"priority"`
+	Assignees []Ref     `json:"assignees"`
+}
+
+// Ref is a reference to another PagerDuty resource
+type Ref struct {
+	ID      string `json:"id"`
+	Summary string `json:"summary"`
+	HTMLURL string `json:"html_url"`
+}
+
+// User is a PagerDuty user
+type User struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Summary string `json:"summary"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Route sends the incidents of a service to a Jira project and the Slack channel owning it
+type Route struct {
+	Channel string `json:"channel"`
+	Project string `json:"project"`
+}
+
+// Routes maps service IDs to their routes, "*" matches services without their own route
+type Routes map[string]Route
+
+// Match returns the route of the service
+func (r Routes) Match(serviceID string) (Route, bool) {
+	if route, ok := r[serviceID]; ok {
+		return route, true
+	}
+	route, ok := r["*"]
+	return route, ok
+}
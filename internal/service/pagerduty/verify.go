@@ -0,0 +1,48 @@
+package pagerduty
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"jira_helper/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifySignature reports whether one of the signatures in the X-PagerDuty-Signature header
+// matches the body. The header lists several signatures while a secret is being rotated.
+func VerifySignature(secret string, body []byte, header string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := "v1=" + hex.EncodeToString(mac.Sum(nil))
+	for _, signature := range strings.Split(header, ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(signature)), []byte(expected)) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireSignature rejects webhook deliveries that are not signed with the secret
+func RequireSignature(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if !VerifySignature(secret, body, c.GetHeader("X-PagerDuty-Signature")) {
+			logger.GetLogger().Warn("rejected pagerduty webhook with invalid signature")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+		c.Next()
+	}
+}
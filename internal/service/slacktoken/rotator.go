@@ -0,0 +1,171 @@
+package slacktoken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"jira_helper/internal/httpclient"
+	"jira_helper/internal/logger"
+	"jira_helper/internal/storage"
+
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+const (
+	// storeKey is the reserved TokenStore key holding the rotation state.
+	// Slack user IDs never start with an underscore so it cannot clash with personal tokens.
+	storeKey = "_slack_bot_token"
+
+	// refreshBefore is how long before expiry the access token must be refreshed, a failing refresh
+	// is reported as an error from then on
+	refreshBefore = 30 * time.Minute
+
+	// alarmBefore is how long before expiry a failing refresh is logged as an alarm. The token is
+	// already refreshed from then on, so the alarm leaves time to fix the app's credentials.
+	alarmBefore = 2 * time.Hour
+
+	// earlyRetryInterval is how often a failed refresh is tried again while the token is still valid
+	// for longer than refreshBefore
+	earlyRetryInterval = 10 * time.Minute
+)
+
+// state is the persisted rotation state
+type state struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Rotator keeps a rotating Slack bot token fresh and hands out a client for the current token.
+// The refresh token changes on every refresh, so the latest state is persisted in the token
+// store and survives cold starts.
+type Rotator struct {
+	clientID     string
+	clientSecret string
+	store        storage.TokenStore
+	httpClient   *http.Client
+	options      []slack.Option
+
+	mu          sync.Mutex
+	state       state
+	client      *slack.Client
+	lastAttempt time.Time // When the last early refresh was attempted
+}
+
+// NewRotator creates a Rotator, loading the persisted state or seeding it from the
+// configured refresh token on first use
+func NewRotator(clientID, clientSecret, refreshToken string, store storage.TokenStore, options ...slack.Option) (*Rotator, error) {
+	r := &Rotator{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		store:        store,
+		httpClient:   httpclient.New(httpclient.SlackTimeout),
+	}
+	// Web API clients share the tuned HTTP client unless the caller overrides it
+	r.options = append([]slack.Option{slack.OptionHTTPClient(r.httpClient)}, options...)
+
+	if raw, err := store.GetToken(storeKey); err == nil && raw != "" {
+		if err := json.Unmarshal([]byte(raw), &r.state); err != nil {
+			return nil, fmt.Errorf("failed to decode slack token state: %v", err)
+		}
+	} else {
+		logger.GetLogger().Info("no stored slack token state, seeding from configuration")
+		r.state.RefreshToken = refreshToken
+	}
+
+	if r.state.RefreshToken == "" {
+		return nil, fmt.Errorf("slack token rotation requires a refresh token")
+	}
+	if err := r.ensureFresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Client returns a Slack client for the current access token, refreshing it first if it is about to expire.
+// If the refresh fails the current client is returned so requests keep working until the token expires.
+func (r *Rotator) Client() *slack.Client {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := r.ensureFresh(ctx); err != nil {
+		logger.GetLogger().Error("failed to refresh slack token", zap.Error(err))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.client
+}
+
+// ExpiresAt returns when the current access token expires
+func (r *Rotator) ExpiresAt() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.ExpiresAt
+}
+
+// ensureFresh refreshes the access token when it is missing or close to expiry. Within
+// alarmBefore of the expiry the refresh is tried early, so a failing refresh raises the alarm
+// while the token still works; the failure is only returned once the token must be refreshed.
+func (r *Rotator) ensureFresh(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remaining := time.Until(r.state.ExpiresAt)
+	valid := r.state.AccessToken != "" && remaining > refreshBefore
+	if valid && r.client == nil {
+		r.client = slack.New(r.state.AccessToken, r.options...)
+	}
+	if valid && (remaining > alarmBefore || time.Since(r.lastAttempt) < earlyRetryInterval) {
+		return nil
+	}
+
+	r.lastAttempt = time.Now()
+	err := r.refresh(ctx)
+	if err == nil {
+		return nil
+	}
+	if r.state.AccessToken != "" && remaining < alarmBefore {
+		logger.GetLogger().Error("slack bot token is about to expire and cannot be refreshed",
+			zap.String("alarm", "slack_token_expiry"),
+			zap.Duration("remaining", remaining),
+			zap.Error(err))
+	}
+	if valid {
+		return nil
+	}
+	return err
+}
+
+// refresh exchanges the refresh token for a new token pair, persists it and swaps the client.
+// The caller must hold r.mu.
+func (r *Rotator) refresh(ctx context.Context) error {
+	resp, err := slack.RefreshOAuthV2TokenContext(ctx, r.httpClient, r.clientID, r.clientSecret, r.state.RefreshToken)
+	if err != nil {
+		return fmt.Errorf("failed to refresh slack token: %v", err)
+	}
+
+	next := state{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second),
+	}
+	data, err := json.Marshal(next)
+	if err != nil {
+		return fmt.Errorf("failed to encode slack token state: %v", err)
+	}
+	// Persist before switching, the old refresh token is no longer valid
+	if err := r.store.SetToken(storeKey, string(data)); err != nil {
+		logger.GetLogger().Error("failed to persist rotated slack token", zap.Error(err))
+	}
+
+	r.state = next
+	r.client = slack.New(next.AccessToken, r.options...)
+	logger.GetLogger().Info("rotated slack bot token", zap.Time("expires_at", next.ExpiresAt))
+	return nil
+}
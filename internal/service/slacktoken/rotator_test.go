@@ -0,0 +1,64 @@
+package slacktoken
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// failingTransport fails every request and counts them
+type failingTransport struct {
+	requests int
+}
+
+func (t *failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	t.requests++
+	return nil, errors.New("connection refused")
+}
+
+func TestEnsureFreshAlarmsBeforeRefreshIsDue(t *testing.T) {
+	core, logs := observer.New(zapcore.ErrorLevel)
+	previous := logger.Log
+	logger.Log = zap.New(core)
+	defer func() { logger.Log = previous }()
+
+	transport := &failingTransport{}
+	r := &Rotator{
+		httpClient: &http.Client{Transport: transport},
+		state: state{
+			AccessToken:  "xoxe.xoxb-current",
+			RefreshToken: "xoxe-refresh",
+			ExpiresAt:    time.Now().Add(time.Hour),
+		},
+	}
+
+	// The token is still valid for longer than refreshBefore, so the failure is not returned
+	if err := r.ensureFresh(context.Background()); err != nil {
+		t.Fatalf("ensureFresh() error = %v, want nil while the token is valid", err)
+	}
+	if transport.requests != 1 {
+		t.Errorf("refresh requests = %d, want 1", transport.requests)
+	}
+	if alarms := logs.FilterField(zap.String("alarm", "slack_token_expiry")).Len(); alarms != 1 {
+		t.Errorf("alarms = %d, want 1", alarms)
+	}
+	if r.client == nil {
+		t.Error("client is nil, want a client for the current token")
+	}
+
+	// A failed early refresh is not tried again right away
+	if err := r.ensureFresh(context.Background()); err != nil {
+		t.Fatalf("second ensureFresh() error = %v", err)
+	}
+	if transport.requests != 1 {
+		t.Errorf("refresh requests after retry = %d, want 1", transport.requests)
+	}
+}
@@ -0,0 +1,109 @@
+package stepfunctions
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"jira_helper/internal/httpclient"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// Client starts Step Functions executions through the AWS JSON API
+type Client struct {
+	awsCfg     aws.Config
+	signer     *v4.Signer
+	httpClient *http.Client
+}
+
+// NewClient creates a new Client instance
+func NewClient(awsCfg aws.Config) *Client {
+	return &Client{
+		awsCfg:     awsCfg,
+		signer:     v4.NewSigner(),
+		httpClient: httpclient.New(httpclient.AWSTimeout),
+	}
+}
+
+// StartExecution starts the state machine with the input marshalled as JSON and returns the execution ARN.
+// The name makes the start idempotent: a second execution with the same name is rejected.
+func (c *Client) StartExecution(ctx context.Context, stateMachineARN, name string, input interface{}) (string, error) {
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal execution input: %v", err)
+	}
+	body, err := json.Marshal(map[string]string{
+		"stateMachineArn": stateMachineARN,
+		"name":            name,
+		"input":           string(inputJSON),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	region := regionFromARN(stateMachineARN)
+	if region == "" {
+		region = c.awsCfg.Region
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://states.%s.amazonaws.com/", region), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", "AWSStepFunctions.StartExecution")
+
+	creds, err := c.awsCfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve AWS credentials: %v", err)
+	}
+	sum := sha256.Sum256(body)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "states", region, time.Now()); err != nil {
+		return "", fmt.Errorf("failed to sign request: %v", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call step functions: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(respBody, &apiErr)
+		return "", fmt.Errorf("step functions returned %d: %s %s", resp.StatusCode, apiErr.Type, apiErr.Message)
+	}
+
+	var result struct {
+		ExecutionArn string `json:"executionArn"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("failed to decode step functions response: %v", err)
+	}
+	return result.ExecutionArn, nil
+}
+
+// regionFromARN returns the region part of an ARN such as arn:aws:states:us-east-1:123456789012:stateMachine:name
+func regionFromARN(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 4 {
+		return ""
+	}
+	return parts[3]
+}
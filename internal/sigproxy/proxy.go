@@ -0,0 +1,128 @@
+package sigproxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/gin-gonic/gin"
+	"github.com/slack-go/slack"
+	"go.uber.org/zap"
+)
+
+// forwardedHeaders lists the request headers passed on to the bot
+var forwardedHeaders = []string{
+	"Content-Type",
+	"X-Slack-Request-Timestamp",
+	"X-Slack-Signature",
+	"X-Slack-Retry-Num",
+	"X-Slack-Retry-Reason",
+}
+
+// Proxy accepts requests from Slack, verifies their Slack signature and forwards them
+// SigV4 signed to a Function URL that uses AWS_IAM auth
+type Proxy struct {
+	target        *url.URL
+	region        string
+	signingSecret string
+	awsCfg        aws.Config
+	signer        *v4.Signer
+	client        *http.Client
+}
+
+// New creates a new Proxy instance
+func New(targetURL, signingSecret string, awsCfg aws.Config) (*Proxy, error) {
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target URL: %v", err)
+	}
+	if signingSecret == "" {
+		return nil, fmt.Errorf("a Slack signing secret is required")
+	}
+	return &Proxy{
+		target:        target,
+		region:        awsCfg.Region,
+		signingSecret: signingSecret,
+		awsCfg:        awsCfg,
+		signer:        v4.NewSigner(),
+		client:        &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// Handle verifies and forwards a single request
+func (p *Proxy) Handle(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+
+	if err := p.verifySlackSignature(c.Request.Header, body); err != nil {
+		logger.GetLogger().Warn("rejected request with invalid slack signature", zap.Error(err))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid slack signature"})
+		return
+	}
+
+	resp, err := p.forward(c.Request.Context(), c.Request, body)
+	if err != nil {
+		logger.GetLogger().Error("failed to forward request", zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to forward request"})
+		return
+	}
+	defer resp.Body.Close()
+
+	c.DataFromReader(resp.StatusCode, resp.ContentLength, resp.Header.Get("Content-Type"), resp.Body, nil)
+}
+
+// verifySlackSignature checks the request was sent by Slack
+func (p *Proxy) verifySlackSignature(header http.Header, body []byte) error {
+	verifier, err := slack.NewSecretsVerifier(header, p.signingSecret)
+	if err != nil {
+		return err
+	}
+	if _, err := verifier.Write(body); err != nil {
+		return err
+	}
+	return verifier.Ensure()
+}
+
+// forward signs the request with the proxy's AWS credentials and sends it to the target
+func (p *Proxy) forward(ctx context.Context, in *http.Request, body []byte) (*http.Response, error) {
+	target := *p.target
+	target.Path = strings.TrimSuffix(target.Path, "/") + in.URL.Path
+	target.RawQuery = in.URL.RawQuery
+
+	out, err := http.NewRequestWithContext(ctx, in.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range forwardedHeaders {
+		if value := in.Header.Get(name); value != "" {
+			out.Header.Set(name, value)
+		}
+	}
+
+	creds, err := p.awsCfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve AWS credentials: %v", err)
+	}
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	out.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if err := p.signer.SignHTTP(ctx, creds, out, payloadHash, "lambda", p.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign request: %v", err)
+	}
+
+	return p.client.Do(out)
+}
@@ -0,0 +1,249 @@
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"jira_helper/internal/httpclient"
+)
+
+const (
+	// BotUserID is the user ID the fake workspace gives the bot
+	BotUserID = "UJIRAHELPER"
+
+	// botID identifies the bot's own messages, so the handler ignores them
+	botID = "BJIRAHELPER"
+
+	// UserID is the user ID the fake workspace gives the person chatting
+	UserID = "USIMULATOR"
+)
+
+// Message is a message in the fake workspace
+type Message struct {
+	Channel  string `json:"channel"`
+	TS       string `json:"ts"`
+	ThreadTS string `json:"thread_ts,omitempty"`
+	User     string `json:"user,omitempty"`
+	BotID    string `json:"bot_id,omitempty"`
+	Text     string `json:"text"`
+	Edited   bool   `json:"edited,omitempty"`
+}
+
+// Server is a fake Slack workspace. It serves the parts of the Slack Web API the bot uses and
+// delivers the messages sent through it to the app as Events API callbacks.
+type Server struct {
+	appURL        string // Events API endpoint of the app
+	signingSecret string // Optional: signs deliveries like Slack does
+	httpClient    *http.Client
+
+	mu       sync.Mutex
+	messages []Message
+	lastTS   time.Time
+	onChange []func(Message)
+}
+
+// New creates a fake workspace delivering events to the app at appURL
+func New(appURL, signingSecret string) *Server {
+	return &Server{
+		appURL:        appURL,
+		signingSecret: signingSecret,
+		httpClient:    httpclient.New(httpclient.AITimeout),
+	}
+}
+
+// OnMessage registers fn to be called whenever the bot posts or updates a message
+func (s *Server) OnMessage(fn func(Message)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = append(s.onChange, fn)
+}
+
+// Messages returns the messages of the channel, or of one thread when threadTS is set
+func (s *Server) Messages(channel, threadTS string) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var messages []Message
+	for _, m := range s.messages {
+		if m.Channel != channel {
+			continue
+		}
+		if threadTS != "" && m.TS != threadTS && m.ThreadTS != threadTS {
+			continue
+		}
+		messages = append(messages, m)
+	}
+	return messages
+}
+
+// Send posts a message as the simulated user and delivers it to the app. Channels starting
+// with "D" are direct messages, elsewhere the bot must be mentioned to be notified.
+func (s *Server) Send(ctx context.Context, channel, threadTS, text string) (string, error) {
+	msg := s.add(Message{Channel: channel, ThreadTS: threadTS, User: UserID, Text: text})
+
+	event := map[string]interface{}{
+		"type":    "message",
+		"channel": channel,
+		"user":    UserID,
+		"text":    text,
+		"ts":      msg.TS,
+	}
+	if threadTS != "" {
+		event["thread_ts"] = threadTS
+	}
+	if strings.HasPrefix(channel, "D") {
+		event["channel_type"] = "im"
+	} else if strings.Contains(text, "<@"+BotUserID+">") {
+		event["type"] = "app_mention"
+	} else {
+		return msg.TS, nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"type":       "event_callback",
+		"team_id":    "TSIMULATOR",
+		"api_app_id": "ASIMULATOR",
+		"event_id":   "Ev" + strings.ReplaceAll(msg.TS, ".", ""),
+		"event":      event,
+	})
+	if err != nil {
+		return "", err
+	}
+	return msg.TS, s.deliver(ctx, body)
+}
+
+// deliver posts an event callback to the app, signed when a signing secret is set
+func (s *Server) deliver(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.appURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.signingSecret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(s.signingSecret))
+		fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+		req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+		req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver event to %s: %v", s.appURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("app returned %d for event", resp.StatusCode)
+	}
+	return nil
+}
+
+// add stores a new message with a unique timestamp
+func (s *Server) add(msg Message) Message {
+	s.mu.Lock()
+	now := time.Now()
+	if !now.After(s.lastTS) {
+		now = s.lastTS.Add(time.Microsecond)
+	}
+	s.lastTS = now
+	msg.TS = fmt.Sprintf("%d.%06d", now.Unix(), now.Nanosecond()/1000)
+	s.messages = append(s.messages, msg)
+	listeners := s.onChange
+	s.mu.Unlock()
+
+	if msg.BotID != "" {
+		for _, fn := range listeners {
+			fn(msg)
+		}
+	}
+	return msg
+}
+
+// update replaces the text of a message
+func (s *Server) update(channel, ts, text string) (Message, bool) {
+	s.mu.Lock()
+	var updated Message
+	found := false
+	for i := range s.messages {
+		if s.messages[i].Channel == channel && s.messages[i].TS == ts {
+			s.messages[i].Text = text
+			s.messages[i].Edited = true
+			updated, found = s.messages[i], true
+			break
+		}
+	}
+	listeners := s.onChange
+	s.mu.Unlock()
+
+	if found {
+		for _, fn := range listeners {
+			fn(updated)
+		}
+	}
+	return updated, found
+}
+
+// handleAPI serves a Slack Web API method
+func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
+	method := strings.TrimPrefix(r.URL.Path, "/api/")
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "invalid_form_data"})
+		return
+	}
+
+	switch method {
+	case "auth.test":
+		writeJSON(w, map[string]interface{}{
+			"ok": true, "url": "https://simulator.slack.local/", "team": "Simulator", "team_id": "TSIMULATOR",
+			"user": "jira-helper", "user_id": BotUserID, "bot_id": botID,
+		})
+	case "chat.postMessage", "chat.postEphemeral":
+		msg := s.add(Message{
+			Channel:  r.Form.Get("channel"),
+			ThreadTS: r.Form.Get("thread_ts"),
+			BotID:    botID,
+			Text:     r.Form.Get("text"),
+		})
+		writeJSON(w, map[string]interface{}{"ok": true, "channel": msg.Channel, "ts": msg.TS, "message_ts": msg.TS})
+	case "chat.update":
+		msg, ok := s.update(r.Form.Get("channel"), r.Form.Get("ts"), r.Form.Get("text"))
+		if !ok {
+			writeJSON(w, map[string]interface{}{"ok": false, "error": "message_not_found"})
+			return
+		}
+		writeJSON(w, map[string]interface{}{"ok": true, "channel": msg.Channel, "ts": msg.TS, "text": msg.Text})
+	case "chat.getPermalink":
+		channel, ts := r.Form.Get("channel"), r.Form.Get("message_ts")
+		writeJSON(w, map[string]interface{}{
+			"ok": true, "channel": channel,
+			"permalink": fmt.Sprintf("https://simulator.slack.local/archives/%s/p%s", channel, strings.ReplaceAll(ts, ".", "")),
+		})
+	case "conversations.replies":
+		var messages []map[string]interface{}
+		for _, m := range s.Messages(r.Form.Get("channel"), r.Form.Get("ts")) {
+			messages = append(messages, map[string]interface{}{
+				"type": "message", "ts": m.TS, "thread_ts": m.ThreadTS, "user": m.User, "bot_id": m.BotID, "text": m.Text,
+			})
+		}
+		writeJSON(w, map[string]interface{}{"ok": true, "messages": messages, "has_more": false})
+	default:
+		// Methods the simulator does not model succeed without effect
+		writeJSON(w, map[string]interface{}{"ok": true})
+	}
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
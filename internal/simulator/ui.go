@@ -0,0 +1,139 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"jira_helper/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// deliveryTimeout bounds the delivery of one event, the app answers it before responding
+const deliveryTimeout = 5 * time.Minute
+
+// sendRequest is a message typed in the web chat
+type sendRequest struct {
+	Channel  string `json:"channel"`
+	ThreadTS string `json:"thread_ts"`
+	Text     string `json:"text"`
+}
+
+// Handler serves the fake Slack Web API under /api/ and the web chat under /
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", s.handleAPI)
+	mux.HandleFunc("/sim/messages", s.handleMessages)
+	mux.HandleFunc("/sim/send", s.handleSend)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(chatPage))
+	})
+	return mux
+}
+
+// handleMessages lists the messages of a channel for the web chat
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	messages := s.Messages(r.URL.Query().Get("channel"), "")
+	if messages == nil {
+		messages = []Message{}
+	}
+	writeJSON(w, messages)
+}
+
+// handleSend posts a message typed in the web chat. The event is delivered in the background,
+// since the app only responds once it has answered.
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	var req sendRequest
+	if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&req) != nil || req.Channel == "" || req.Text == "" {
+		http.Error(w, "expected a JSON body with channel and text", http.StatusBadRequest)
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		defer cancel()
+		if _, err := s.Send(ctx, req.Channel, req.ThreadTS, req.Text); err != nil {
+			logger.GetLogger().Error("failed to deliver simulated message", zap.Error(err))
+		}
+	}()
+	writeJSON(w, map[string]interface{}{"ok": true})
+}
+
+// chatPage is the web chat. It polls the channel's messages and groups them into threads.
+const chatPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Jira Helper Simulator</title>
+<style>
+body { font-family: sans-serif; margin: 0; display: flex; flex-direction: column; height: 100vh; }
+header { padding: 8px 16px; background: #3f0e40; color: #fff; }
+#log { flex: 1; overflow-y: auto; padding: 16px; }
+.thread { border-left: 3px solid #ddd; margin: 8px 0 16px; padding-left: 12px; }
+.msg { white-space: pre-wrap; margin: 6px 0; }
+.bot { color: #1d1c1d; background: #f4f4f4; padding: 6px; border-radius: 4px; }
+.meta { color: #888; font-size: 12px; }
+form { display: flex; padding: 8px 16px; gap: 8px; border-top: 1px solid #ddd; }
+#text { flex: 1; }
+</style>
+</head>
+<body>
+<header>Jira Helper Simulator — channel <input id="channel" value="D0SIMULATOR" size="14"> <span class="meta">Channels starting with D are DMs, elsewhere mention &lt;@UJIRAHELPER&gt;</span></header>
+<div id="log"></div>
+<form id="form">
+<select id="thread"><option value="">New thread</option></select>
+<input id="text" autocomplete="off" placeholder="Ask about Jira issues...">
+<button>Send</button>
+</form>
+<script>
+const log = document.getElementById('log'), thread = document.getElementById('thread');
+async function refresh() {
+  const channel = document.getElementById('channel').value;
+  const messages = await (await fetch('/sim/messages?channel=' + encodeURIComponent(channel))).json();
+  const threads = new Map();
+  for (const m of messages) {
+    const root = m.thread_ts || m.ts;
+    if (!threads.has(root)) threads.set(root, []);
+    threads.get(root).push(m);
+  }
+  log.innerHTML = '';
+  const selected = thread.value;
+  thread.innerHTML = '<option value="">New thread</option>';
+  for (const [root, items] of threads) {
+    const div = document.createElement('div');
+    div.className = 'thread';
+    for (const m of items) {
+      const p = document.createElement('div');
+      p.className = 'msg' + (m.bot_id ? ' bot' : '');
+      p.textContent = (m.bot_id ? '🤖 ' : '🧑 ') + m.text + (m.edited ? ' (edited)' : '');
+      div.appendChild(p);
+    }
+    log.appendChild(div);
+    const option = document.createElement('option');
+    option.value = root;
+    option.textContent = 'Thread: ' + items[0].text.slice(0, 40);
+    thread.appendChild(option);
+  }
+  thread.value = selected;
+}
+document.getElementById('form').onsubmit = async (e) => {
+  e.preventDefault();
+  const text = document.getElementById('text');
+  await fetch('/sim/send', { method: 'POST', body: JSON.stringify({
+    channel: document.getElementById('channel').value, thread_ts: thread.value, text: text.value }) });
+  text.value = '';
+  refresh();
+};
+setInterval(refresh, 1000);
+refresh();
+</script>
+</body>
+</html>
+`
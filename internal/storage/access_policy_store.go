@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// accessPolicyKey is the object holding the access policy set through the admin API
+const accessPolicyKey = "config/access.json"
+
+// AccessPolicyStore defines the interface for storing the access policy edited at runtime
+type AccessPolicyStore interface {
+	// Load returns the JSON policy, nil if none was set yet
+	Load(ctx context.Context) ([]byte, error)
+	Save(ctx context.Context, policy []byte) error
+}
+
+// S3AccessPolicyStore implements AccessPolicyStore with a single object in AWS S3
+type S3AccessPolicyStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3AccessPolicyStore creates a new S3AccessPolicyStore instance
+func NewS3AccessPolicyStore(client *s3.Client, bucketName string) *S3AccessPolicyStore {
+	return &S3AccessPolicyStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// Load retrieves the policy, nil if none was set yet
+func (s *S3AccessPolicyStore) Load(ctx context.Context) ([]byte, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(accessPolicyKey),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get access policy from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read access policy: %v", err)
+	}
+	return data, nil
+}
+
+// Save stores the policy, replacing the previous one
+func (s *S3AccessPolicyStore) Save(ctx context.Context, policy []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(accessPolicyKey),
+		Body:        bytes.NewReader(policy),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store access policy in S3: %v", err)
+	}
+	return nil
+}
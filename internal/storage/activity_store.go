@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// activityPrefix keeps user activity with the usage data, so the retention policy purges it
+	activityPrefix = "usage/activity/"
+
+	// maxRecentQueries is how many queries are kept per user
+	maxRecentQueries = 10
+)
+
+// QueryRecord is a query a user asked the bot
+type QueryRecord struct {
+	Text      string    `json:"text"`
+	ChannelID string    `json:"channel_id"`
+	AskedAt   time.Time `json:"asked_at"`
+}
+
+// ActivityStore defines the interface for storing the recent queries of each user
+type ActivityStore interface {
+	AddQuery(ctx context.Context, userID string, query QueryRecord) error
+	// RecentQueries returns the user's latest queries, the most recent first
+	RecentQueries(ctx context.Context, userID string) ([]QueryRecord, error)
+	DeleteQueries(ctx context.Context, userID string) error
+}
+
+// S3ActivityStore implements ActivityStore using AWS S3
+type S3ActivityStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3ActivityStore creates a new S3ActivityStore instance
+func NewS3ActivityStore(client *s3.Client, bucketName string) *S3ActivityStore {
+	return &S3ActivityStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// AddQuery records a query, dropping the oldest beyond the ones kept
+func (s *S3ActivityStore) AddQuery(ctx context.Context, userID string, query QueryRecord) error {
+	queries, err := s.RecentQueries(ctx, userID)
+	if err != nil {
+		return err
+	}
+	queries = append([]QueryRecord{query}, queries...)
+	if len(queries) > maxRecentQueries {
+		queries = queries[:maxRecentQueries]
+	}
+
+	data, err := json.Marshal(queries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queries: %v", err)
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(activityPrefix + userID + ".json"),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store queries in S3: %v", err)
+	}
+	return nil
+}
+
+// RecentQueries retrieves the user's queries, returning none for an unknown user
+func (s *S3ActivityStore) RecentQueries(ctx context.Context, userID string) ([]QueryRecord, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(activityPrefix + userID + ".json"),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get queries from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	var queries []QueryRecord
+	if err := json.NewDecoder(result.Body).Decode(&queries); err != nil {
+		return nil, fmt.Errorf("failed to decode queries: %v", err)
+	}
+	return queries, nil
+}
+
+// DeleteQueries removes the user's recent queries
+func (s *S3ActivityStore) DeleteQueries(ctx context.Context, userID string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(activityPrefix + userID + ".json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete queries from S3: %v", err)
+	}
+	return nil
+}
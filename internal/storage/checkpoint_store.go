@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// checkpointPrefix keeps checkpoints with the transcripts, so the retention policy purges stale ones
+const checkpointPrefix = "transcripts/checkpoints/"
+
+// CheckpointStore defines the interface for storing conversation checkpoints
+type CheckpointStore interface {
+	Save(ctx context.Context, id string, data []byte) error
+	Load(ctx context.Context, id string) ([]byte, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// S3CheckpointStore implements CheckpointStore using AWS S3
+type S3CheckpointStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3CheckpointStore creates a new S3CheckpointStore instance
+func NewS3CheckpointStore(client *s3.Client, bucketName string) *S3CheckpointStore {
+	return &S3CheckpointStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// Save stores the checkpoint, replacing any previous one
+func (s *S3CheckpointStore) Save(ctx context.Context, id string, data []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(checkpointPrefix + id + ".json"),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store checkpoint in S3: %v", err)
+	}
+	return nil
+}
+
+// Load retrieves the checkpoint
+func (s *S3CheckpointStore) Load(ctx context.Context, id string) ([]byte, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(checkpointPrefix + id + ".json"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkpoint from S3: %v", err)
+	}
+	defer result.Body.Close()
+	return io.ReadAll(result.Body)
+}
+
+// Delete removes the checkpoint
+func (s *S3CheckpointStore) Delete(ctx context.Context, id string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(checkpointPrefix + id + ".json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete checkpoint from S3: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// conversationPrefix keeps conversations with the transcripts, so the retention policy purges them
+	conversationPrefix = "transcripts/conversations/"
+
+	// conversationTTL is how long DynamoDB keeps a conversation after its last turn
+	conversationTTL = 30 * 24 * time.Hour
+)
+
+// ConversationStore defines the interface for storing the messages exchanged with the model in a thread
+type ConversationStore interface {
+	// Load returns the stored conversation of the thread, or nil if there is none
+	Load(ctx context.Context, channelID, threadTS string) ([]byte, error)
+	Save(ctx context.Context, channelID, threadTS string, data []byte) error
+}
+
+// S3ConversationStore implements ConversationStore using AWS S3
+type S3ConversationStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3ConversationStore creates a new S3ConversationStore instance
+func NewS3ConversationStore(client *s3.Client, bucketName string) *S3ConversationStore {
+	return &S3ConversationStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// Load retrieves the thread's conversation
+func (s *S3ConversationStore) Load(ctx context.Context, channelID, threadTS string) ([]byte, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(conversationKey(channelID, threadTS)),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get conversation from S3: %v", err)
+	}
+	defer result.Body.Close()
+	return io.ReadAll(result.Body)
+}
+
+// Save stores the thread's conversation, replacing the previous one
+func (s *S3ConversationStore) Save(ctx context.Context, channelID, threadTS string, data []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(conversationKey(channelID, threadTS)),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store conversation in S3: %v", err)
+	}
+	return nil
+}
+
+// conversationKey is the object key of a thread's conversation
+func conversationKey(channelID, threadTS string) string {
+	return conversationPrefix + channelID + "/" + threadTS + ".json"
+}
+
+// DynamoDBConversationStore implements ConversationStore using a DynamoDB table whose partition key
+// is the string attribute thread_key. Enable TTL on the expires_at attribute to purge old threads.
+type DynamoDBConversationStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBConversationStore creates a new DynamoDBConversationStore instance
+func NewDynamoDBConversationStore(client *dynamodb.Client, tableName string) *DynamoDBConversationStore {
+	return &DynamoDBConversationStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+// Load retrieves the thread's conversation
+func (s *DynamoDBConversationStore) Load(ctx context.Context, channelID, threadTS string) ([]byte, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]dynamodbtypes.AttributeValue{
+			"thread_key": &dynamodbtypes.AttributeValueMemberS{Value: channelID + "/" + threadTS},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation from DynamoDB: %v", err)
+	}
+	attr, ok := result.Item["conversation"].(*dynamodbtypes.AttributeValueMemberS)
+	if !ok {
+		return nil, nil
+	}
+	return []byte(attr.Value), nil
+}
+
+// Save stores the thread's conversation, replacing the previous one
+func (s *DynamoDBConversationStore) Save(ctx context.Context, channelID, threadTS string, data []byte) error {
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item: map[string]dynamodbtypes.AttributeValue{
+			"thread_key":   &dynamodbtypes.AttributeValueMemberS{Value: channelID + "/" + threadTS},
+			"conversation": &dynamodbtypes.AttributeValueMemberS{Value: string(data)},
+			"expires_at":   &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(conversationTTL).Unix(), 10)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store conversation in DynamoDB: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// counterPrefix is where S3CounterStore keeps its counters
+const counterPrefix = "quota/"
+
+// CounterStore keeps counters that expire, such as the usage of a quota window
+type CounterStore interface {
+	// Get returns the counter's value, 0 if it does not exist or has expired
+	Get(ctx context.Context, key string) (int64, error)
+	// Add adds delta to the counter and returns its new value. A new counter expires at expiresAt.
+	Add(ctx context.Context, key string, delta int64, expiresAt time.Time) (int64, error)
+}
+
+// DynamoDBCounterStore implements CounterStore using a DynamoDB table whose partition key is the
+// string attribute counter_key. Enable TTL on the expires_at attribute to purge old counters.
+type DynamoDBCounterStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBCounterStore creates a new DynamoDBCounterStore instance
+func NewDynamoDBCounterStore(client *dynamodb.Client, tableName string) *DynamoDBCounterStore {
+	return &DynamoDBCounterStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+// Get retrieves the counter's value
+func (s *DynamoDBCounterStore) Get(ctx context.Context, key string) (int64, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]dynamodbtypes.AttributeValue{
+			"counter_key": &dynamodbtypes.AttributeValueMemberS{Value: key},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get counter from DynamoDB: %v", err)
+	}
+	return counterValue(result.Item)
+}
+
+// Add increments the counter atomically
+func (s *DynamoDBCounterStore) Add(ctx context.Context, key string, delta int64, expiresAt time.Time) (int64, error) {
+	result, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]dynamodbtypes.AttributeValue{
+			"counter_key": &dynamodbtypes.AttributeValueMemberS{Value: key},
+		},
+		UpdateExpression: aws.String("ADD #count :delta SET expires_at = if_not_exists(expires_at, :expires_at)"),
+		ExpressionAttributeNames: map[string]string{
+			"#count": "count",
+		},
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":delta":      &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(delta, 10)},
+			":expires_at": &dynamodbtypes.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt.Unix(), 10)},
+		},
+		ReturnValues: dynamodbtypes.ReturnValueAllNew,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update counter in DynamoDB: %v", err)
+	}
+	return counterValue(result.Attributes)
+}
+
+// counterValue reads the count attribute of a counter item
+func counterValue(item map[string]dynamodbtypes.AttributeValue) (int64, error) {
+	attr, ok := item["count"].(*dynamodbtypes.AttributeValueMemberN)
+	if !ok {
+		return 0, nil
+	}
+	return strconv.ParseInt(attr.Value, 10, 64)
+}
+
+// S3CounterStore implements CounterStore using AWS S3. Increments are not atomic, so concurrent
+// requests of the same user may be undercounted slightly.
+type S3CounterStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3CounterStore creates a new S3CounterStore instance
+func NewS3CounterStore(client *s3.Client, bucketName string) *S3CounterStore {
+	return &S3CounterStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// storedCounter is the stored form of a counter
+type storedCounter struct {
+	Count     int64     `json:"count"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Get retrieves the counter's value
+func (s *S3CounterStore) Get(ctx context.Context, key string) (int64, error) {
+	counter, err := s.load(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return counter.Count, nil
+}
+
+// Add increments the counter
+func (s *S3CounterStore) Add(ctx context.Context, key string, delta int64, expiresAt time.Time) (int64, error) {
+	counter, err := s.load(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if counter.ExpiresAt.IsZero() {
+		counter.ExpiresAt = expiresAt
+	}
+	counter.Count += delta
+
+	data, err := json.Marshal(counter)
+	if err != nil {
+		return 0, err
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(counterPrefix + key + ".json"),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to store counter in S3: %v", err)
+	}
+	return counter.Count, nil
+}
+
+// load returns the stored counter, or an empty one if it does not exist or has expired
+func (s *S3CounterStore) load(ctx context.Context, key string) (storedCounter, error) {
+	var counter storedCounter
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(counterPrefix + key + ".json"),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return counter, nil
+		}
+		return counter, fmt.Errorf("failed to get counter from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return counter, fmt.Errorf("failed to read counter: %v", err)
+	}
+	if err := json.Unmarshal(data, &counter); err != nil {
+		return counter, fmt.Errorf("failed to decode counter: %v", err)
+	}
+	if !counter.ExpiresAt.IsZero() && time.Now().After(counter.ExpiresAt) {
+		return storedCounter{}, nil
+	}
+	return counter, nil
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBTokenStore implements TokenStore using a DynamoDB table whose partition key is the
+// string attribute user_id. Tokens are encrypted with KMS like in S3TokenStore.
+type DynamoDBTokenStore struct {
+	client    *dynamodb.Client
+	tableName string
+	envelope  *KMSEnvelope // Encrypts tokens with KMS data keys
+}
+
+// NewDynamoDBTokenStore creates a new DynamoDBTokenStore instance
+func NewDynamoDBTokenStore(client *dynamodb.Client, tableName string, envelope *KMSEnvelope) *DynamoDBTokenStore {
+	return &DynamoDBTokenStore{
+		client:    client,
+		tableName: tableName,
+		envelope:  envelope,
+	}
+}
+
+// GetToken retrieves and decrypts a token for the given user ID
+func (s *DynamoDBTokenStore) GetToken(userID string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Second)
+	defer cancel()
+
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: userID},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get token from DynamoDB: %v", err)
+	}
+	attr, ok := result.Item["token"].(*types.AttributeValueMemberS)
+	if !ok {
+		return "", nil
+	}
+
+	var dataKey string
+	if key, ok := result.Item["data_key"].(*types.AttributeValueMemberS); ok {
+		dataKey = key.Value
+	}
+
+	decryptedToken, err := decryptStoredToken(ctx, s.envelope, userID, attr.Value, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt token: %v", err)
+	}
+	return decryptedToken, nil
+}
+
+// SetToken encrypts and stores a token for the given user ID
+func (s *DynamoDBTokenStore) SetToken(userID, token string) error {
+	encryptedToken, dataKey, err := encryptStoredToken(context.TODO(), s.envelope, userID, token)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt token: %v", err)
+	}
+
+	item := map[string]types.AttributeValue{
+		"user_id":    &types.AttributeValueMemberS{Value: userID},
+		"token":      &types.AttributeValueMemberS{Value: encryptedToken},
+		"updated_at": &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339)},
+	}
+	if dataKey != "" {
+		item["data_key"] = &types.AttributeValueMemberS{Value: dataKey}
+	}
+	_, err = s.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store token in DynamoDB: %v", err)
+	}
+	return nil
+}
+
+// DeleteToken removes the token of the given user ID
+func (s *DynamoDBTokenStore) DeleteToken(userID string) error {
+	_, err := s.client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete token from DynamoDB: %v", err)
+	}
+	return nil
+}
+
+// ListUsers returns the IDs of the users with a token item
+func (s *DynamoDBTokenStore) ListUsers() ([]string, error) {
+	var users []string
+	paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+		TableName:            aws.String(s.tableName),
+		ProjectionExpression: aws.String("user_id"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tokens in DynamoDB: %v", err)
+		}
+		for _, item := range page.Items {
+			attr, ok := item["user_id"].(*types.AttributeValueMemberS)
+			if ok && !strings.HasPrefix(attr.Value, reservedKeyPrefix) {
+				users = append(users, attr.Value)
+			}
+		}
+	}
+	return users, nil
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// embeddingPrefix is where the embedding index of each project is kept
+const embeddingPrefix = "embeddings/"
+
+// Vector is an embedding vector. It is stored as base64 encoded float32 values, which is a
+// fraction of the size of a JSON number list.
+type Vector []float32
+
+// MarshalJSON encodes the vector as base64 of its little-endian float32 values
+func (v Vector) MarshalJSON() ([]byte, error) {
+	raw := make([]byte, 4*len(v))
+	for i, value := range v {
+		binary.LittleEndian.PutUint32(raw[4*i:], math.Float32bits(value))
+	}
+	return json.Marshal(base64.StdEncoding.EncodeToString(raw))
+}
+
+// UnmarshalJSON decodes a vector encoded by MarshalJSON
+func (v *Vector) UnmarshalJSON(data []byte) error {
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+	if len(raw)%4 != 0 {
+		return fmt.Errorf("invalid vector length %d", len(raw))
+	}
+	*v = make(Vector, len(raw)/4)
+	for i := range *v {
+		(*v)[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[4*i:]))
+	}
+	return nil
+}
+
+// IssueEmbedding is the embedding of an issue's summary and description
+type IssueEmbedding struct {
+	Key     string    `json:"key"`
+	Summary string    `json:"summary"`
+	Status  string    `json:"status,omitempty"`
+	Updated time.Time `json:"updated"` // When the issue was last changed, it is embedded again after changes
+	Vector  Vector    `json:"vector"`
+}
+
+// EmbeddingIndex holds the embeddings of a project's issues
+type EmbeddingIndex struct {
+	Project   string           `json:"project"`
+	Model     string           `json:"model"` // Vectors of different models cannot be compared
+	IndexedAt time.Time        `json:"indexed_at"`
+	Issues    []IssueEmbedding `json:"issues"`
+}
+
+// EmbeddingStore defines the interface for keeping the embedding index of each project
+type EmbeddingStore interface {
+	// GetIndex returns the project's index, or nil if it was not indexed yet
+	GetIndex(ctx context.Context, project string) (*EmbeddingIndex, error)
+	SaveIndex(ctx context.Context, index *EmbeddingIndex) error
+}
+
+// S3EmbeddingStore implements EmbeddingStore using AWS S3
+type S3EmbeddingStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3EmbeddingStore creates a new S3EmbeddingStore instance
+func NewS3EmbeddingStore(client *s3.Client, bucketName string) *S3EmbeddingStore {
+	return &S3EmbeddingStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// GetIndex loads the project's index from S3
+func (s *S3EmbeddingStore) GetIndex(ctx context.Context, project string) (*EmbeddingIndex, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(embeddingPrefix + project + ".json"),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get embedding index from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	var index EmbeddingIndex
+	if err := json.NewDecoder(result.Body).Decode(&index); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding index: %v", err)
+	}
+	return &index, nil
+}
+
+// SaveIndex stores the project's index in S3
+func (s *S3EmbeddingStore) SaveIndex(ctx context.Context, index *EmbeddingIndex) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to marshal embedding index: %v", err)
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(embeddingPrefix + index.Project + ".json"),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store embedding index in S3: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// KMSEnvelope encrypts tokens with envelope encryption: every token gets its own AES-256 data key
+// generated by KMS, and only the data key encrypted under the KMS key is stored with the token.
+// The user ID is bound to the data key as encryption context, so a token copied to another user
+// cannot be decrypted.
+type KMSEnvelope struct {
+	client *kms.Client
+	keyID  string
+}
+
+// NewKMSEnvelope creates a new KMSEnvelope using the KMS key with the given ID or ARN
+func NewKMSEnvelope(client *kms.Client, keyID string) *KMSEnvelope {
+	return &KMSEnvelope{
+		client: client,
+		keyID:  keyID,
+	}
+}
+
+// Encrypt encrypts the user's token with a new data key. It returns the ciphertext and the
+// encrypted data key, both base64 encoded.
+func (e *KMSEnvelope) Encrypt(ctx context.Context, userID, plaintext string) (string, string, error) {
+	result, err := e.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:             aws.String(e.keyID),
+		KeySpec:           kmstypes.DataKeySpecAes256,
+		EncryptionContext: encryptionContext(userID),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate data key: %v", err)
+	}
+	defer clear(result.Plaintext)
+
+	ciphertext, err := encryptToken(result.Plaintext, plaintext)
+	if err != nil {
+		return "", "", err
+	}
+	return ciphertext, base64.StdEncoding.EncodeToString(result.CiphertextBlob), nil
+}
+
+// Decrypt decrypts the data key with KMS and the user's token with the data key
+func (e *KMSEnvelope) Decrypt(ctx context.Context, userID, ciphertext, dataKey string) (string, error) {
+	blob, err := base64.StdEncoding.DecodeString(dataKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid data key: %v", err)
+	}
+	result, err := e.client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:             aws.String(e.keyID),
+		CiphertextBlob:    blob,
+		EncryptionContext: encryptionContext(userID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt data key: %v", err)
+	}
+	defer clear(result.Plaintext)
+
+	return decryptToken(result.Plaintext, ciphertext)
+}
+
+// encryptionContext binds a data key to the user whose token it encrypts
+func encryptionContext(userID string) map[string]string {
+	return map[string]string{"user_id": userID}
+}
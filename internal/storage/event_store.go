@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBEventStore records processed Slack event IDs in a DynamoDB table whose partition key is
+// the string attribute event_id, so every instance skips events another one already processed.
+// Enable TTL on the expires_at attribute to purge old events.
+type DynamoDBEventStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBEventStore creates a new DynamoDBEventStore instance
+func NewDynamoDBEventStore(client *dynamodb.Client, tableName string) *DynamoDBEventStore {
+	return &DynamoDBEventStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+// Record stores the event ID unless it is already stored and has not expired, which it reports.
+// DynamoDB deletes expired items late, so an expired item is overwritten.
+func (s *DynamoDBEventStore) Record(ctx context.Context, eventID string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item: map[string]types.AttributeValue{
+			"event_id":    &types.AttributeValueMemberS{Value: eventID},
+			"recorded_at": &types.AttributeValueMemberS{Value: now.UTC().Format(time.RFC3339)},
+			"expires_at":  &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(ttl).Unix(), 10)},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(event_id) OR expires_at <= :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return true, nil
+		}
+		return false, fmt.Errorf("failed to record event in DynamoDB: %v", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// feedbackAnswerPrefix keeps the answers feedback may be given on, under the feedback
+	// retention category
+	feedbackAnswerPrefix = "feedback/answers/"
+
+	// feedbackRatingPrefix keeps the ratings, grouped by the day the answer was posted
+	feedbackRatingPrefix = "feedback/ratings/"
+)
+
+// Ratings of an answer
+const (
+	RatingUp   = "up"
+	RatingDown = "down"
+)
+
+// Answer is an answer the bot posted, with the query it answered
+type Answer struct {
+	ChannelID string    `json:"channel_id"`
+	MessageTS string    `json:"message_ts"`
+	ThreadTS  string    `json:"thread_ts"`
+	UserID    string    `json:"user_id"` // User who asked
+	Query     string    `json:"query"`
+	Answer    string    `json:"answer"`
+	PostedAt  time.Time `json:"posted_at"`
+}
+
+// Feedback is a user's rating of an answer
+type Feedback struct {
+	Answer
+	Rating  string    `json:"rating"` // RatingUp or RatingDown
+	RatedBy string    `json:"rated_by"`
+	RatedAt time.Time `json:"rated_at"`
+}
+
+// FeedbackStore defines the interface for storing answers and the feedback given on them
+type FeedbackStore interface {
+	SaveAnswer(ctx context.Context, answer Answer) error
+	// GetAnswer returns the answer posted as the message, nil if it is not an answer
+	GetAnswer(ctx context.Context, channelID, messageTS string) (*Answer, error)
+	// SaveFeedback stores the rating, replacing an earlier rating of the answer by the same user
+	SaveFeedback(ctx context.Context, feedback Feedback) error
+	// ListFeedback returns the ratings of the answers posted since the given time
+	ListFeedback(ctx context.Context, since time.Time) ([]Feedback, error)
+}
+
+// S3FeedbackStore implements FeedbackStore using AWS S3
+type S3FeedbackStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3FeedbackStore creates a new S3FeedbackStore instance
+func NewS3FeedbackStore(client *s3.Client, bucketName string) *S3FeedbackStore {
+	return &S3FeedbackStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// SaveAnswer stores the answer so feedback on it can be recorded with the query
+func (s *S3FeedbackStore) SaveAnswer(ctx context.Context, answer Answer) error {
+	return s.put(ctx, feedbackAnswerPrefix+answer.ChannelID+"/"+answer.MessageTS+".json", answer, "answer")
+}
+
+// GetAnswer retrieves the answer posted as the message
+func (s *S3FeedbackStore) GetAnswer(ctx context.Context, channelID, messageTS string) (*Answer, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(feedbackAnswerPrefix + channelID + "/" + messageTS + ".json"),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get answer from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	var answer Answer
+	if err := json.NewDecoder(result.Body).Decode(&answer); err != nil {
+		return nil, fmt.Errorf("failed to decode answer: %v", err)
+	}
+	return &answer, nil
+}
+
+// SaveFeedback stores the rating under the day the answer was posted
+func (s *S3FeedbackStore) SaveFeedback(ctx context.Context, feedback Feedback) error {
+	key := fmt.Sprintf("%s%s/%s-%s-%s.json", feedbackRatingPrefix, feedback.PostedAt.UTC().Format("2006-01-02"),
+		feedback.ChannelID, feedback.MessageTS, feedback.RatedBy)
+	return s.put(ctx, key, feedback, "feedback")
+}
+
+// ListFeedback reads the ratings of every day since the given time
+func (s *S3FeedbackStore) ListFeedback(ctx context.Context, since time.Time) ([]Feedback, error) {
+	var feedback []Feedback
+	for day := since.UTC().Truncate(24 * time.Hour); !day.After(time.Now().UTC()); day = day.AddDate(0, 0, 1) {
+		paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+			Bucket: aws.String(s.bucketName),
+			Prefix: aws.String(feedbackRatingPrefix + day.Format("2006-01-02") + "/"),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list feedback in S3: %v", err)
+			}
+			for _, object := range page.Contents {
+				item, err := s.getFeedback(ctx, aws.ToString(object.Key))
+				if err != nil {
+					return nil, err
+				}
+				if !item.PostedAt.Before(since) {
+					feedback = append(feedback, item)
+				}
+			}
+		}
+	}
+	return feedback, nil
+}
+
+// getFeedback reads one rating
+func (s *S3FeedbackStore) getFeedback(ctx context.Context, key string) (Feedback, error) {
+	var feedback Feedback
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return feedback, fmt.Errorf("failed to get feedback from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	if err := json.NewDecoder(result.Body).Decode(&feedback); err != nil {
+		return feedback, fmt.Errorf("failed to decode feedback: %v", err)
+	}
+	return feedback, nil
+}
+
+// put stores the value as JSON under the key
+func (s *S3FeedbackStore) put(ctx context.Context, key string, value interface{}, what string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %v", what, err)
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store %s in S3: %v", what, err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// flagKey is the object holding the feature flags set through the admin API
+const flagKey = "config/flags.json"
+
+// FlagStore defines the interface for storing the feature flags toggled at runtime
+type FlagStore interface {
+	// Load returns the flags that were set, keyed by feature name
+	Load(ctx context.Context) (map[string]bool, error)
+	Save(ctx context.Context, flags map[string]bool) error
+}
+
+// S3FlagStore implements FlagStore with a single object in AWS S3
+type S3FlagStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3FlagStore creates a new S3FlagStore instance
+func NewS3FlagStore(client *s3.Client, bucketName string) *S3FlagStore {
+	return &S3FlagStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// Load retrieves the flags, an empty set if none were set yet
+func (s *S3FlagStore) Load(ctx context.Context) (map[string]bool, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(flagKey),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return map[string]bool{}, nil
+		}
+		return nil, fmt.Errorf("failed to get feature flags from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	flags := map[string]bool{}
+	if err := json.NewDecoder(result.Body).Decode(&flags); err != nil {
+		return nil, fmt.Errorf("failed to decode feature flags: %v", err)
+	}
+	return flags, nil
+}
+
+// Save stores the flags, replacing the previous ones
+func (s *S3FlagStore) Save(ctx context.Context, flags map[string]bool) error {
+	data, err := json.Marshal(flags)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(flagKey),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store feature flags in S3: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// identityPrefix is where the Jira identity of each Slack user is stored
+const identityPrefix = "identities/"
+
+// How an identity was established
+const (
+	IdentitySourceEmail  = "email"  // The Slack and Jira profiles share the email address
+	IdentitySourceLinked = "linked" // The user linked the account with /link-jira-account
+	IdentitySourceToken  = "token"  // The user's personal token belongs to the account
+)
+
+// Identity maps a Slack user to their Jira account
+type Identity struct {
+	SlackUserID  string    `json:"slack_user_id"`
+	JiraUsername string    `json:"jira_username"`
+	JiraKey      string    `json:"jira_key,omitempty"`
+	AccountID    string    `json:"account_id,omitempty"` // Jira Cloud account ID
+	DisplayName  string    `json:"display_name"`
+	Source       string    `json:"source"`
+	LinkedAt     time.Time `json:"linked_at"`
+}
+
+// IdentityStore defines the interface for storing which Jira account each Slack user has
+type IdentityStore interface {
+	// GetIdentity returns the identity of the Slack user, or nil if it is unknown
+	GetIdentity(ctx context.Context, slackUserID string) (*Identity, error)
+	SaveIdentity(ctx context.Context, identity Identity) error
+	DeleteIdentity(ctx context.Context, slackUserID string) error
+}
+
+// S3IdentityStore implements IdentityStore using AWS S3
+type S3IdentityStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3IdentityStore creates a new S3IdentityStore instance
+func NewS3IdentityStore(client *s3.Client, bucketName string) *S3IdentityStore {
+	return &S3IdentityStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// GetIdentity retrieves the identity of the Slack user
+func (s *S3IdentityStore) GetIdentity(ctx context.Context, slackUserID string) (*Identity, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(identityPrefix + slackUserID + ".json"),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get identity from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	var identity Identity
+	if err := json.NewDecoder(result.Body).Decode(&identity); err != nil {
+		return nil, fmt.Errorf("failed to decode identity: %v", err)
+	}
+	return &identity, nil
+}
+
+// SaveIdentity stores the identity, replacing the user's previous one
+func (s *S3IdentityStore) SaveIdentity(ctx context.Context, identity Identity) error {
+	data, err := json.Marshal(identity)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(identityPrefix + identity.SlackUserID + ".json"),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store identity in S3: %v", err)
+	}
+	return nil
+}
+
+// DeleteIdentity removes the identity of the Slack user
+func (s *S3IdentityStore) DeleteIdentity(ctx context.Context, slackUserID string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(identityPrefix + slackUserID + ".json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete identity from S3: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// incidentPrefix is where the bridged PagerDuty incidents are stored
+const incidentPrefix = "pagerduty-incidents/"
+
+// Incident links a PagerDuty incident to its Jira ticket and Slack thread
+type Incident struct {
+	IncidentID string    `json:"incident_id"`
+	ChannelID  string    `json:"channel_id"`
+	ThreadTS   string    `json:"thread_ts"`
+	Project    string    `json:"project"`
+	IssueKey   string    `json:"issue_key,omitempty"`
+	Status     string    `json:"status"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// IncidentStore defines the interface for storing bridged incidents
+type IncidentStore interface {
+	// Load returns the incident, or nil if it was never bridged
+	Load(ctx context.Context, incidentID string) (*Incident, error)
+	Save(ctx context.Context, incident *Incident) error
+}
+
+// S3IncidentStore implements IncidentStore using AWS S3
+type S3IncidentStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3IncidentStore creates a new S3IncidentStore instance
+func NewS3IncidentStore(client *s3.Client, bucketName string) *S3IncidentStore {
+	return &S3IncidentStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// Load retrieves a bridged incident
+func (s *S3IncidentStore) Load(ctx context.Context, incidentID string) (*Incident, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(incidentPrefix + incidentID + ".json"),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get incident from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	var incident Incident
+	if err := json.NewDecoder(result.Body).Decode(&incident); err != nil {
+		return nil, fmt.Errorf("failed to decode incident: %v", err)
+	}
+	return &incident, nil
+}
+
+// Save stores a bridged incident
+func (s *S3IncidentStore) Save(ctx context.Context, incident *Incident) error {
+	incident.UpdatedAt = time.Now().UTC()
+	data, err := json.Marshal(incident)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(incidentPrefix + incident.IncidentID + ".json"),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store incident in S3: %v", err)
+	}
+	return nil
+}
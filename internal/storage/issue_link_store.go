@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// issueLinkPrefix is where the links of each Jira issue are stored
+const issueLinkPrefix = "issue-links/"
+
+// ThreadRef identifies a Slack thread that discussed an issue
+type ThreadRef struct {
+	ChannelID string    `json:"channel_id"`
+	ThreadTS  string    `json:"thread_ts"`
+	LinkedAt  time.Time `json:"linked_at"`
+}
+
+// PullRequestRef is a pull request that references an issue
+type PullRequestRef struct {
+	Repository string    `json:"repository"`
+	Number     int       `json:"number"`
+	Title      string    `json:"title"`
+	URL        string    `json:"url"`
+	State      string    `json:"state"` // opened, reopened, merged or closed
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// IssueLinks are the Slack threads and pull requests linked to a Jira issue
+type IssueLinks struct {
+	Threads      []ThreadRef      `json:"threads"`
+	PullRequests []PullRequestRef `json:"pull_requests"`
+}
+
+// IssueLinkStore defines the interface for storing what is linked to Jira issues
+type IssueLinkStore interface {
+	Load(ctx context.Context, issueKey string) (IssueLinks, error)
+	Save(ctx context.Context, issueKey string, links IssueLinks) error
+}
+
+// S3IssueLinkStore implements IssueLinkStore using AWS S3
+type S3IssueLinkStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3IssueLinkStore creates a new S3IssueLinkStore instance
+func NewS3IssueLinkStore(client *s3.Client, bucketName string) *S3IssueLinkStore {
+	return &S3IssueLinkStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// Load retrieves the links of the issue, returning empty links for an unknown issue
+func (s *S3IssueLinkStore) Load(ctx context.Context, issueKey string) (IssueLinks, error) {
+	var links IssueLinks
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(issueLinkPrefix + issueKey + ".json"),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return links, nil
+		}
+		return links, fmt.Errorf("failed to get issue links from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	if err := json.NewDecoder(result.Body).Decode(&links); err != nil {
+		return links, fmt.Errorf("failed to decode issue links: %v", err)
+	}
+	return links, nil
+}
+
+// Save stores the links of the issue, replacing the previous ones
+func (s *S3IssueLinkStore) Save(ctx context.Context, issueKey string, links IssueLinks) error {
+	data, err := json.Marshal(links)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(issueLinkPrefix + issueKey + ".json"),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store issue links in S3: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// preferencePrefix is where the preferences of each Slack user are stored
+const preferencePrefix = "preferences/"
+
+// Preferences are the settings a Slack user chose for their conversations with the bot
+type Preferences struct {
+	SlackUserID       string    `json:"slack_user_id"`
+	Style             string    `json:"style,omitempty"`              // Response style, the team's default if empty
+	DefaultProject    string    `json:"default_project,omitempty"`    // Project key assumed when the user names none
+	DefaultBoard      int       `json:"default_board,omitempty"`      // Agile board ID assumed when the user names none
+	Timezone          string    `json:"timezone,omitempty"`           // IANA time zone, e.g. Asia/Shanghai
+	Language          string    `json:"language,omitempty"`           // ISO 639-1 code of the reply language, detected if empty
+	MuteNotifications bool      `json:"mute_notifications,omitempty"` // Jira notifications to the user's direct messages are paused
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// PreferenceStore defines the interface for storing the preferences of each Slack user
+type PreferenceStore interface {
+	// GetPreferences returns the preferences of the Slack user, or nil if they have not set any
+	GetPreferences(ctx context.Context, slackUserID string) (*Preferences, error)
+	SavePreferences(ctx context.Context, preferences Preferences) error
+	DeletePreferences(ctx context.Context, slackUserID string) error
+}
+
+// S3PreferenceStore implements PreferenceStore using AWS S3
+type S3PreferenceStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3PreferenceStore creates a new S3PreferenceStore instance
+func NewS3PreferenceStore(client *s3.Client, bucketName string) *S3PreferenceStore {
+	return &S3PreferenceStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// GetPreferences retrieves the preferences of the Slack user
+func (s *S3PreferenceStore) GetPreferences(ctx context.Context, slackUserID string) (*Preferences, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(preferencePrefix + slackUserID + ".json"),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get preferences from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	var preferences Preferences
+	if err := json.NewDecoder(result.Body).Decode(&preferences); err != nil {
+		return nil, fmt.Errorf("failed to decode preferences: %v", err)
+	}
+	return &preferences, nil
+}
+
+// SavePreferences stores the preferences, replacing the user's previous ones
+func (s *S3PreferenceStore) SavePreferences(ctx context.Context, preferences Preferences) error {
+	data, err := json.Marshal(preferences)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(preferencePrefix + preferences.SlackUserID + ".json"),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store preferences in S3: %v", err)
+	}
+	return nil
+}
+
+// DeletePreferences removes the preferences of the Slack user
+func (s *S3PreferenceStore) DeletePreferences(ctx context.Context, slackUserID string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(preferencePrefix + slackUserID + ".json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete preferences from S3: %v", err)
+	}
+	return nil
+}
+
+// DynamoDBPreferenceStore implements PreferenceStore using a DynamoDB table whose partition key
+// is the string attribute slack_user_id
+type DynamoDBPreferenceStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBPreferenceStore creates a new DynamoDBPreferenceStore instance
+func NewDynamoDBPreferenceStore(client *dynamodb.Client, tableName string) *DynamoDBPreferenceStore {
+	return &DynamoDBPreferenceStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+// GetPreferences retrieves the preferences of the Slack user
+func (s *DynamoDBPreferenceStore) GetPreferences(ctx context.Context, slackUserID string) (*Preferences, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]dynamodbtypes.AttributeValue{
+			"slack_user_id": &dynamodbtypes.AttributeValueMemberS{Value: slackUserID},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences from DynamoDB: %v", err)
+	}
+	attr, ok := result.Item["preferences"].(*dynamodbtypes.AttributeValueMemberS)
+	if !ok {
+		return nil, nil
+	}
+
+	var preferences Preferences
+	if err := json.Unmarshal([]byte(attr.Value), &preferences); err != nil {
+		return nil, fmt.Errorf("failed to decode preferences: %v", err)
+	}
+	return &preferences, nil
+}
+
+// SavePreferences stores the preferences, replacing the user's previous ones
+func (s *DynamoDBPreferenceStore) SavePreferences(ctx context.Context, preferences Preferences) error {
+	data, err := json.Marshal(preferences)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item: map[string]dynamodbtypes.AttributeValue{
+			"slack_user_id": &dynamodbtypes.AttributeValueMemberS{Value: preferences.SlackUserID},
+			"preferences":   &dynamodbtypes.AttributeValueMemberS{Value: string(data)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store preferences in DynamoDB: %v", err)
+	}
+	return nil
+}
+
+// DeletePreferences removes the preferences of the Slack user
+func (s *DynamoDBPreferenceStore) DeletePreferences(ctx context.Context, slackUserID string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]dynamodbtypes.AttributeValue{
+			"slack_user_id": &dynamodbtypes.AttributeValueMemberS{Value: slackUserID},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete preferences from DynamoDB: %v", err)
+	}
+	return nil
+}
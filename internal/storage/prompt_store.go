@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// promptPrefix is where the system prompt templates are kept, one object per template, e.g.
+// config/prompts/default.tmpl or config/prompts/C0123ABCD.tmpl for a channel
+const promptPrefix = "config/prompts/"
+
+// promptSuffix is the extension of the template objects
+const promptSuffix = ".tmpl"
+
+// PromptStore defines the interface for loading the system prompt templates edited at runtime
+type PromptStore interface {
+	// LoadPrompts returns the template texts keyed by name, "default" or a channel ID
+	LoadPrompts(ctx context.Context) (map[string]string, error)
+}
+
+// S3PromptStore implements PromptStore with template objects in AWS S3
+type S3PromptStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3PromptStore creates a new S3PromptStore instance
+func NewS3PromptStore(client *s3.Client, bucketName string) *S3PromptStore {
+	return &S3PromptStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// LoadPrompts reads every template object, an empty set if there are none
+func (s *S3PromptStore) LoadPrompts(ctx context.Context) (map[string]string, error) {
+	prompts := map[string]string{}
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(promptPrefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list prompt templates in S3: %v", err)
+		}
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			name, ok := strings.CutSuffix(strings.TrimPrefix(key, promptPrefix), promptSuffix)
+			if !ok || name == "" || strings.Contains(name, "/") {
+				continue
+			}
+			text, err := s.get(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			prompts[name] = text
+		}
+	}
+	return prompts, nil
+}
+
+// get reads a template object
+func (s *S3PromptStore) get(ctx context.Context, key string) (string, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get prompt template %s from S3: %v", key, err)
+	}
+	defer result.Body.Close()
+
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt template %s: %v", key, err)
+	}
+	return string(data), nil
+}
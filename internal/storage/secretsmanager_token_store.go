@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+// SecretsManagerTokenStore implements TokenStore with one Secrets Manager secret per user, named
+// after the user ID under a prefix. Secrets Manager encrypts the tokens with KMS.
+type SecretsManagerTokenStore struct {
+	client *secretsmanager.Client
+	prefix string
+}
+
+// NewSecretsManagerTokenStore creates a new SecretsManagerTokenStore instance
+func NewSecretsManagerTokenStore(client *secretsmanager.Client, prefix string) *SecretsManagerTokenStore {
+	return &SecretsManagerTokenStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// GetToken retrieves the token for the given user ID
+func (s *SecretsManagerTokenStore) GetToken(userID string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Second)
+	defer cancel()
+
+	result, err := s.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(s.prefix + userID),
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get token from Secrets Manager: %v", err)
+	}
+
+	var data tokenData
+	if err := json.Unmarshal([]byte(aws.ToString(result.SecretString)), &data); err != nil {
+		return "", fmt.Errorf("failed to decode token data: %v", err)
+	}
+	return data.Token, nil
+}
+
+// SetToken stores the token for the given user ID, creating the user's secret on first use
+func (s *SecretsManagerTokenStore) SetToken(userID, token string) error {
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Second)
+	defer cancel()
+
+	jsonData, err := json.Marshal(tokenData{Token: token})
+	if err != nil {
+		return fmt.Errorf("failed to marshal token data: %v", err)
+	}
+
+	_, err = s.client.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(s.prefix + userID),
+		SecretString: aws.String(string(jsonData)),
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		_, err = s.client.CreateSecret(ctx, &secretsmanager.CreateSecretInput{
+			Name:         aws.String(s.prefix + userID),
+			Description:  aws.String("Personal Jira token of Slack user " + userID),
+			SecretString: aws.String(string(jsonData)),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store token in Secrets Manager: %v", err)
+	}
+	return nil
+}
+
+// DeleteToken deletes the user's secret at once, without the recovery window, so a revoked
+// token cannot be restored
+func (s *SecretsManagerTokenStore) DeleteToken(userID string) error {
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Second)
+	defer cancel()
+
+	_, err := s.client.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+		SecretId:                   aws.String(s.prefix + userID),
+		ForceDeleteWithoutRecovery: aws.Bool(true),
+	})
+	var notFound *types.ResourceNotFoundException
+	if err != nil && !errors.As(err, &notFound) {
+		return fmt.Errorf("failed to delete token from Secrets Manager: %v", err)
+	}
+	return nil
+}
+
+// ListUsers returns the IDs of the users with a secret under the prefix
+func (s *SecretsManagerTokenStore) ListUsers() ([]string, error) {
+	var users []string
+	paginator := secretsmanager.NewListSecretsPaginator(s.client, &secretsmanager.ListSecretsInput{
+		Filters: []types.Filter{{Key: types.FilterNameStringTypeName, Values: []string{s.prefix}}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tokens in Secrets Manager: %v", err)
+		}
+		for _, secret := range page.SecretList {
+			// The name filter matches prefixes of words, keep only the secrets under the prefix
+			userID, ok := strings.CutPrefix(aws.ToString(secret.Name), s.prefix)
+			if ok && userID != "" && !strings.HasPrefix(userID, reservedKeyPrefix) {
+				users = append(users, userID)
+			}
+		}
+	}
+	return users, nil
+}
@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// subscriptionsKey is the object holding all subscriptions in S3
+const subscriptionsKey = "subscriptions/jira.json"
+
+// Subscription sends notifications about the issues of a project, or about a single issue, to a
+// Slack channel or user
+type Subscription struct {
+	Scope     string    `json:"scope"`            // Project key or issue key
+	Target    string    `json:"target"`           // Slack channel or user ID
+	Events    []string  `json:"events,omitempty"` // created, updated and commented, all when empty
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Notifies reports whether the subscription wants the event
+func (s Subscription) Notifies(event string) bool {
+	return len(s.Events) == 0 || slices.Contains(s.Events, event)
+}
+
+// SubscriptionStore defines the interface for storing Jira notification subscriptions
+type SubscriptionStore interface {
+	// Find returns the subscriptions to any of the scopes
+	Find(ctx context.Context, scopes ...string) ([]Subscription, error)
+	// List returns the subscriptions that notify the target
+	List(ctx context.Context, target string) ([]Subscription, error)
+	// Add stores the subscription, replacing one with the same scope and target
+	Add(ctx context.Context, subscription Subscription) error
+	// Remove deletes the subscription of the target to the scope, reporting whether it existed
+	Remove(ctx context.Context, scope, target string) (bool, error)
+}
+
+// S3SubscriptionStore implements SubscriptionStore using a single AWS S3 object
+type S3SubscriptionStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3SubscriptionStore creates a new S3SubscriptionStore instance
+func NewS3SubscriptionStore(client *s3.Client, bucketName string) *S3SubscriptionStore {
+	return &S3SubscriptionStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// Find returns the subscriptions to any of the scopes
+func (s *S3SubscriptionStore) Find(ctx context.Context, scopes ...string) ([]Subscription, error) {
+	all, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(all, func(sub Subscription) bool { return !slices.Contains(scopes, sub.Scope) }), nil
+}
+
+// List returns the subscriptions that notify the target
+func (s *S3SubscriptionStore) List(ctx context.Context, target string) ([]Subscription, error) {
+	all, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(all, func(sub Subscription) bool { return sub.Target != target }), nil
+}
+
+// Add stores the subscription, replacing one with the same scope and target
+func (s *S3SubscriptionStore) Add(ctx context.Context, subscription Subscription) error {
+	all, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	all = slices.DeleteFunc(all, func(sub Subscription) bool {
+		return sub.Scope == subscription.Scope && sub.Target == subscription.Target
+	})
+	return s.save(ctx, append(all, subscription))
+}
+
+// Remove deletes the subscription of the target to the scope
+func (s *S3SubscriptionStore) Remove(ctx context.Context, scope, target string) (bool, error) {
+	all, err := s.load(ctx)
+	if err != nil {
+		return false, err
+	}
+	remaining := slices.DeleteFunc(slices.Clone(all), func(sub Subscription) bool {
+		return sub.Scope == scope && sub.Target == target
+	})
+	if len(remaining) == len(all) {
+		return false, nil
+	}
+	return true, s.save(ctx, remaining)
+}
+
+// load reads all subscriptions, none when the object does not exist yet
+func (s *S3SubscriptionStore) load(ctx context.Context) ([]Subscription, error) {
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(subscriptionsKey),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get subscriptions from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	var subscriptions []Subscription
+	if err := json.NewDecoder(result.Body).Decode(&subscriptions); err != nil {
+		return nil, fmt.Errorf("failed to decode subscriptions: %v", err)
+	}
+	return subscriptions, nil
+}
+
+// save replaces all subscriptions
+func (s *S3SubscriptionStore) save(ctx context.Context, subscriptions []Subscription) error {
+	data, err := json.Marshal(subscriptions)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(subscriptionsKey),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store subscriptions in S3: %v", err)
+	}
+	return nil
+}
+
+// DynamoDBSubscriptionStore implements SubscriptionStore using a DynamoDB table whose partition
+// key is the string attribute scope and whose sort key is the string attribute target
+type DynamoDBSubscriptionStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBSubscriptionStore creates a new DynamoDBSubscriptionStore instance
+func NewDynamoDBSubscriptionStore(client *dynamodb.Client, tableName string) *DynamoDBSubscriptionStore {
+	return &DynamoDBSubscriptionStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+// Find queries the subscriptions of each scope
+func (s *DynamoDBSubscriptionStore) Find(ctx context.Context, scopes ...string) ([]Subscription, error) {
+	var subscriptions []Subscription
+	for _, scope := range scopes {
+		paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+			TableName:              aws.String(s.tableName),
+			KeyConditionExpression: aws.String("#scope = :scope"),
+			ExpressionAttributeNames: map[string]string{
+				"#scope": "scope",
+			},
+			ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+				":scope": &dynamodbtypes.AttributeValueMemberS{Value: scope},
+			},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to query subscriptions from DynamoDB: %v", err)
+			}
+			subscriptions = append(subscriptions, decodeSubscriptions(page.Items)...)
+		}
+	}
+	return subscriptions, nil
+}
+
+// List scans for the subscriptions of the target, a table of subscriptions stays small
+func (s *DynamoDBSubscriptionStore) List(ctx context.Context, target string) ([]Subscription, error) {
+	var subscriptions []Subscription
+	paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+		TableName:        aws.String(s.tableName),
+		FilterExpression: aws.String("#target = :target"),
+		ExpressionAttributeNames: map[string]string{
+			"#target": "target",
+		},
+		ExpressionAttributeValues: map[string]dynamodbtypes.AttributeValue{
+			":target": &dynamodbtypes.AttributeValueMemberS{Value: target},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscriptions from DynamoDB: %v", err)
+		}
+		subscriptions = append(subscriptions, decodeSubscriptions(page.Items)...)
+	}
+	return subscriptions, nil
+}
+
+// Add stores the subscription, replacing one with the same scope and target
+func (s *DynamoDBSubscriptionStore) Add(ctx context.Context, subscription Subscription) error {
+	data, err := json.Marshal(subscription)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item: map[string]dynamodbtypes.AttributeValue{
+			"scope":        &dynamodbtypes.AttributeValueMemberS{Value: subscription.Scope},
+			"target":       &dynamodbtypes.AttributeValueMemberS{Value: subscription.Target},
+			"subscription": &dynamodbtypes.AttributeValueMemberS{Value: string(data)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store subscription in DynamoDB: %v", err)
+	}
+	return nil
+}
+
+// Remove deletes the subscription of the target to the scope
+func (s *DynamoDBSubscriptionStore) Remove(ctx context.Context, scope, target string) (bool, error) {
+	result, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]dynamodbtypes.AttributeValue{
+			"scope":  &dynamodbtypes.AttributeValueMemberS{Value: scope},
+			"target": &dynamodbtypes.AttributeValueMemberS{Value: target},
+		},
+		ReturnValues: dynamodbtypes.ReturnValueAllOld,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete subscription from DynamoDB: %v", err)
+	}
+	return len(result.Attributes) > 0, nil
+}
+
+// decodeSubscriptions decodes the subscription attribute of the items, skipping invalid ones
+func decodeSubscriptions(items []map[string]dynamodbtypes.AttributeValue) []Subscription {
+	var subscriptions []Subscription
+	for _, item := range items {
+		attr, ok := item["subscription"].(*dynamodbtypes.AttributeValueMemberS)
+		if !ok {
+			continue
+		}
+		var subscription Subscription
+		if err := json.Unmarshal([]byte(attr.Value), &subscription); err == nil {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions
+}
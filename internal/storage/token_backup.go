@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// tokenBackupPrefix is where token backups are kept in S3
+const tokenBackupPrefix = "backups/tokens/"
+
+// TokenBackup is an export of the personal tokens. Every token is encrypted with its own KMS data
+// key bound to its user, so the backup is useless without decrypt access to the KMS key.
+type TokenBackup struct {
+	CreatedAt time.Time     `json:"created_at"`
+	CreatedBy string        `json:"created_by"`
+	Tokens    []BackupToken `json:"tokens"`
+}
+
+// BackupToken is the encrypted token of one user
+type BackupToken struct {
+	UserID  string `json:"user_id"`
+	Token   string `json:"token"`    // Ciphertext, base64 encoded
+	DataKey string `json:"data_key"` // Encrypted KMS data key, base64 encoded
+}
+
+// TokenBackupStore exports the personal tokens to encrypted backups and restores them
+type TokenBackupStore interface {
+	// Backup exports every stored token and returns the key of the backup and how many tokens it holds
+	Backup(ctx context.Context, tokens TokenStore, createdBy string) (string, int, error)
+	// Restore stores the tokens of the backup again, returning how many were restored
+	Restore(ctx context.Context, tokens TokenStore, key string) (int, error)
+}
+
+// S3TokenBackups implements TokenBackupStore with one S3 object per backup
+type S3TokenBackups struct {
+	client     *s3.Client
+	bucketName string
+	envelope   *KMSEnvelope
+}
+
+// NewS3TokenBackups creates a new S3TokenBackups instance encrypting tokens with the envelope
+func NewS3TokenBackups(client *s3.Client, bucketName string, envelope *KMSEnvelope) *S3TokenBackups {
+	return &S3TokenBackups{
+		client:     client,
+		bucketName: bucketName,
+		envelope:   envelope,
+	}
+}
+
+// Backup encrypts every stored token and writes them to a new backup object
+func (b *S3TokenBackups) Backup(ctx context.Context, tokens TokenStore, createdBy string) (string, int, error) {
+	users, err := tokens.ListUsers()
+	if err != nil {
+		return "", 0, err
+	}
+	backup := TokenBackup{CreatedAt: time.Now().UTC(), CreatedBy: createdBy, Tokens: []BackupToken{}}
+	for _, userID := range users {
+		token, err := tokens.GetToken(userID)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to read the token of %s: %v", userID, err)
+		}
+		if token == "" {
+			continue
+		}
+		ciphertext, dataKey, err := b.envelope.Encrypt(ctx, userID, token)
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to encrypt the token of %s: %v", userID, err)
+		}
+		backup.Tokens = append(backup.Tokens, BackupToken{UserID: userID, Token: ciphertext, DataKey: dataKey})
+	}
+
+	data, err := json.Marshal(backup)
+	if err != nil {
+		return "", 0, err
+	}
+	key := tokenBackupPrefix + backup.CreatedAt.Format("20060102T150405Z") + ".json"
+	_, err = b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(b.bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to store token backup in S3: %v", err)
+	}
+	return key, len(backup.Tokens), nil
+}
+
+// Restore decrypts the tokens of a backup and stores them, replacing the users' current tokens
+func (b *S3TokenBackups) Restore(ctx context.Context, tokens TokenStore, key string) (int, error) {
+	if !strings.HasPrefix(key, tokenBackupPrefix) {
+		return 0, fmt.Errorf("%s is not a token backup", key)
+	}
+	result, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return 0, fmt.Errorf("token backup %s does not exist", key)
+		}
+		return 0, fmt.Errorf("failed to get token backup from S3: %v", err)
+	}
+	defer result.Body.Close()
+	data, err := io.ReadAll(result.Body)
+	if err != nil {
+		return 0, err
+	}
+	var backup TokenBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return 0, fmt.Errorf("failed to decode token backup: %v", err)
+	}
+
+	for i, saved := range backup.Tokens {
+		token, err := b.envelope.Decrypt(ctx, saved.UserID, saved.Token, saved.DataKey)
+		if err != nil {
+			return i, fmt.Errorf("failed to decrypt the token of %s: %v", saved.UserID, err)
+		}
+		if err := tokens.SetToken(saved.UserID, token); err != nil {
+			return i, fmt.Errorf("failed to restore the token of %s: %v", saved.UserID, err)
+		}
+	}
+	return len(backup.Tokens), nil
+}
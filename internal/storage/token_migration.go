@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ErrLegacyToken is returned for a token that is still encrypted with the retired static key
+var ErrLegacyToken = errors.New("token is encrypted with the retired static key, run the migrate-token-encryption job")
+
+// LegacyTokenMigrator is implemented by the token stores that once encrypted tokens with a static key
+type LegacyTokenMigrator interface {
+	// MigrateLegacyTokens re-encrypts with KMS every token encrypted with legacyKey and returns
+	// the keys of the migrated tokens
+	MigrateLegacyTokens(ctx context.Context, legacyKey []byte) ([]string, error)
+}
+
+// MigrateLegacyTokens re-encrypts with KMS the token objects stored without a data key
+func (s *S3TokenStore) MigrateLegacyTokens(ctx context.Context, legacyKey []byte) ([]string, error) {
+	if s.envelope == nil {
+		return nil, fmt.Errorf("migrating tokens requires a KMS key")
+	}
+	var migrated []string
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String("tokens/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to list tokens in S3: %v", err)
+		}
+		for _, obj := range page.Contents {
+			name := strings.TrimPrefix(aws.ToString(obj.Key), "tokens/")
+			userID, ok := strings.CutSuffix(name, ".json")
+			if !ok || strings.Contains(userID, "/") {
+				continue
+			}
+			data, err := s.readTokenData(ctx, aws.ToString(obj.Key))
+			if err != nil {
+				return migrated, err
+			}
+			if data.DataKey != "" {
+				continue
+			}
+			token, err := decryptToken(legacyKey, data.Token)
+			if err != nil {
+				return migrated, fmt.Errorf("failed to decrypt legacy token of %s: %v", userID, err)
+			}
+			if err := s.SetToken(userID, token); err != nil {
+				return migrated, err
+			}
+			migrated = append(migrated, userID)
+		}
+	}
+	return migrated, nil
+}
+
+// readTokenData reads the token object stored under the key
+func (s *S3TokenStore) readTokenData(ctx context.Context, key string) (tokenData, error) {
+	var data tokenData
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return data, fmt.Errorf("failed to get token from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	if err := json.NewDecoder(result.Body).Decode(&data); err != nil {
+		return data, fmt.Errorf("failed to decode token data: %v", err)
+	}
+	return data, nil
+}
+
+// MigrateLegacyTokens re-encrypts with KMS the token items stored without a data key
+func (s *DynamoDBTokenStore) MigrateLegacyTokens(ctx context.Context, legacyKey []byte) ([]string, error) {
+	if s.envelope == nil {
+		return nil, fmt.Errorf("migrating tokens requires a KMS key")
+	}
+	var migrated []string
+	paginator := dynamodb.NewScanPaginator(s.client, &dynamodb.ScanInput{
+		TableName:        aws.String(s.tableName),
+		FilterExpression: aws.String("attribute_not_exists(data_key)"),
+		ConsistentRead:   aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to scan tokens in DynamoDB: %v", err)
+		}
+		for _, item := range page.Items {
+			userID, ok := item["user_id"].(*dynamodbtypes.AttributeValueMemberS)
+			if !ok {
+				continue
+			}
+			attr, ok := item["token"].(*dynamodbtypes.AttributeValueMemberS)
+			if !ok {
+				continue
+			}
+			token, err := decryptToken(legacyKey, attr.Value)
+			if err != nil {
+				return migrated, fmt.Errorf("failed to decrypt legacy token of %s: %v", userID.Value, err)
+			}
+			if err := s.SetToken(userID.Value, token); err != nil {
+				return migrated, err
+			}
+			migrated = append(migrated, userID.Value)
+		}
+	}
+	return migrated, nil
+}
@@ -8,37 +8,49 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
 // TokenStore defines the interface for token storage operations
 type TokenStore interface {
+	// GetToken returns the user's token, or "" if the user has none
 	GetToken(userID string) (string, error)
 	SetToken(userID, token string) error
+	DeleteToken(userID string) error
+	// ListUsers returns the IDs of the users with a stored token
+	ListUsers() ([]string, error)
 }
 
-// S3TokenStore implements TokenStore using AWS S3
+// reservedKeyPrefix marks TokenStore keys that hold the bot's own state instead of a user's token
+const reservedKeyPrefix = "_"
+
+// S3TokenStore implements TokenStore using AWS S3. Tokens are encrypted with KMS data keys.
+// Tokens stored with the retired static key are refused until MigrateLegacyTokens re-encrypts them.
 type S3TokenStore struct {
 	client     *s3.Client
 	bucketName string
-	encryptKey []byte // 32-byte key for AES-256
+	envelope   *KMSEnvelope // Encrypts tokens with KMS data keys
 }
 
 type tokenData struct {
-	Token string `json:"token"`
+	Token   string `json:"token"`
+	DataKey string `json:"data_key,omitempty"` // Encrypted KMS data key, empty for the retired static key
 }
 
 // NewS3TokenStore creates a new S3TokenStore instance
-func NewS3TokenStore(client *s3.Client, bucketName string, encryptKey []byte) *S3TokenStore {
+func NewS3TokenStore(client *s3.Client, bucketName string, envelope *KMSEnvelope) *S3TokenStore {
 	return &S3TokenStore{
 		client:     client,
 		bucketName: bucketName,
-		encryptKey: encryptKey,
+		envelope:   envelope,
 	}
 }
 
@@ -53,6 +65,10 @@ func (s *S3TokenStore) GetToken(userID string) (string, error) {
 		Key:    aws.String(key),
 	})
 	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return "", nil
+		}
 		return "", fmt.Errorf("failed to get token from S3: %v", err)
 	}
 	defer result.Body.Close()
@@ -63,7 +79,7 @@ func (s *S3TokenStore) GetToken(userID string) (string, error) {
 	}
 
 	// Decrypt the token
-	decryptedToken, err := s.decrypt(data.Token)
+	decryptedToken, err := decryptStoredToken(ctx, s.envelope, userID, data.Token, data.DataKey)
 	if err != nil {
 		return "", fmt.Errorf("failed to decrypt token: %v", err)
 	}
@@ -76,12 +92,12 @@ func (s *S3TokenStore) SetToken(userID, token string) error {
 	key := s.getKey(userID)
 
 	// Encrypt the token
-	encryptedToken, err := s.encrypt(token)
+	encryptedToken, dataKey, err := encryptStoredToken(context.TODO(), s.envelope, userID, token)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt token: %v", err)
 	}
 
-	data := tokenData{Token: encryptedToken}
+	data := tokenData{Token: encryptedToken, DataKey: dataKey}
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal token data: %v", err)
@@ -99,9 +115,64 @@ func (s *S3TokenStore) SetToken(userID, token string) error {
 	return nil
 }
 
-// encrypt encrypts the token using AES-GCM
-func (s *S3TokenStore) encrypt(plaintext string) (string, error) {
-	block, err := aes.NewCipher(s.encryptKey)
+// DeleteToken removes the token of the given user ID
+func (s *S3TokenStore) DeleteToken(userID string) error {
+	_, err := s.client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(s.getKey(userID)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete token from S3: %v", err)
+	}
+	return nil
+}
+
+// ListUsers returns the IDs of the users with a token object
+func (s *S3TokenStore) ListUsers() ([]string, error) {
+	var users []string
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String("tokens/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tokens in S3: %v", err)
+		}
+		for _, obj := range page.Contents {
+			name := strings.TrimPrefix(aws.ToString(obj.Key), "tokens/")
+			userID, ok := strings.CutSuffix(name, ".json")
+			if ok && !strings.Contains(userID, "/") && !strings.HasPrefix(userID, reservedKeyPrefix) {
+				users = append(users, userID)
+			}
+		}
+	}
+	return users, nil
+}
+
+// encryptStoredToken encrypts the token with a KMS data key, returning the ciphertext and the
+// encrypted data key
+func encryptStoredToken(ctx context.Context, envelope *KMSEnvelope, userID, plaintext string) (string, string, error) {
+	if envelope == nil {
+		return "", "", fmt.Errorf("tokens can only be stored with a KMS key")
+	}
+	return envelope.Encrypt(ctx, userID, plaintext)
+}
+
+// decryptStoredToken decrypts a token encrypted by encryptStoredToken
+func decryptStoredToken(ctx context.Context, envelope *KMSEnvelope, userID, ciphertext, dataKey string) (string, error) {
+	if dataKey == "" {
+		return "", ErrLegacyToken
+	}
+	if envelope == nil {
+		return "", fmt.Errorf("token is encrypted with KMS but no KMS key is configured")
+	}
+	return envelope.Decrypt(ctx, userID, ciphertext, dataKey)
+}
+
+// encryptToken encrypts the token with the 32-byte key using AES-GCM
+func encryptToken(encryptKey []byte, plaintext string) (string, error) {
+	block, err := aes.NewCipher(encryptKey)
 	if err != nil {
 		return "", err
 	}
@@ -124,15 +195,15 @@ func (s *S3TokenStore) encrypt(plaintext string) (string, error) {
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-// decrypt decrypts the token using AES-GCM
-func (s *S3TokenStore) decrypt(encryptedText string) (string, error) {
+// decryptToken decrypts a token encrypted by encryptToken
+func decryptToken(encryptKey []byte, encryptedText string) (string, error) {
 	// Decode the base64 string
 	ciphertext, err := base64.StdEncoding.DecodeString(encryptedText)
 	if err != nil {
 		return "", err
 	}
 
-	block, err := aes.NewCipher(s.encryptKey)
+	block, err := aes.NewCipher(encryptKey)
 	if err != nil {
 		return "", err
 	}
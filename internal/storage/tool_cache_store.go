@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ToolCacheStore defines the interface for sharing cached tool results between instances
+type ToolCacheStore interface {
+	// Get returns the cached result, or nil if there is none or it has expired
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte, ttl time.Duration) error
+}
+
+// DynamoDBToolCacheStore implements ToolCacheStore using a DynamoDB table whose partition key is
+// the string attribute cache_key. Enable TTL on the expires_at attribute to purge old results.
+type DynamoDBToolCacheStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBToolCacheStore creates a new DynamoDBToolCacheStore instance
+func NewDynamoDBToolCacheStore(client *dynamodb.Client, tableName string) *DynamoDBToolCacheStore {
+	return &DynamoDBToolCacheStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+// Get retrieves a cached result. DynamoDB deletes expired items late, so the expiry is checked here.
+func (s *DynamoDBToolCacheStore) Get(ctx context.Context, key string) ([]byte, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"cache_key": &types.AttributeValueMemberS{Value: key},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached tool result from DynamoDB: %v", err)
+	}
+	data, ok := result.Item["result"].(*types.AttributeValueMemberS)
+	if !ok {
+		return nil, nil
+	}
+	if expires, ok := result.Item["expires_at"].(*types.AttributeValueMemberN); ok {
+		if at, err := strconv.ParseInt(expires.Value, 10, 64); err == nil && time.Now().Unix() >= at {
+			return nil, nil
+		}
+	}
+	return []byte(data.Value), nil
+}
+
+// Put caches a result until the TTL has passed
+func (s *DynamoDBToolCacheStore) Put(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item: map[string]types.AttributeValue{
+			"cache_key":  &types.AttributeValueMemberS{Value: key},
+			"result":     &types.AttributeValueMemberS{Value: string(data)},
+			"expires_at": &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cache tool result in DynamoDB: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// workspaceKeyPrefix is the reserved TokenStore key prefix of installed workspaces
+const workspaceKeyPrefix = reservedKeyPrefix + "workspace_"
+
+// Workspace is a Slack workspace the app was installed into through OAuth
+type Workspace struct {
+	TeamID      string    `json:"team_id"`
+	TeamName    string    `json:"team_name"`
+	BotToken    string    `json:"bot_token"`
+	BotUserID   string    `json:"bot_user_id"`
+	InstalledBy string    `json:"installed_by"` // Slack user who installed the app
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// WorkspaceStore defines the interface for keeping the installed workspaces
+type WorkspaceStore interface {
+	// GetWorkspace returns the workspace, or nil if the app is not installed in it
+	GetWorkspace(teamID string) (*Workspace, error)
+	SaveWorkspace(workspace *Workspace) error
+	DeleteWorkspace(teamID string) error
+}
+
+// TokenWorkspaceStore implements WorkspaceStore on top of the token store, so the bot tokens
+// of the workspaces are encrypted like the personal tokens
+type TokenWorkspaceStore struct {
+	tokens TokenStore
+}
+
+// NewTokenWorkspaceStore creates a new TokenWorkspaceStore instance
+func NewTokenWorkspaceStore(tokens TokenStore) *TokenWorkspaceStore {
+	return &TokenWorkspaceStore{tokens: tokens}
+}
+
+// GetWorkspace loads the workspace of the team
+func (s *TokenWorkspaceStore) GetWorkspace(teamID string) (*Workspace, error) {
+	raw, err := s.tokens.GetToken(workspaceKeyPrefix + teamID)
+	if err != nil {
+		return nil, err
+	}
+	if raw == "" {
+		return nil, nil
+	}
+	var workspace Workspace
+	if err := json.Unmarshal([]byte(raw), &workspace); err != nil {
+		return nil, fmt.Errorf("failed to decode workspace %s: %v", teamID, err)
+	}
+	return &workspace, nil
+}
+
+// SaveWorkspace stores the workspace, replacing an earlier installation
+func (s *TokenWorkspaceStore) SaveWorkspace(workspace *Workspace) error {
+	data, err := json.Marshal(workspace)
+	if err != nil {
+		return fmt.Errorf("failed to encode workspace: %v", err)
+	}
+	return s.tokens.SetToken(workspaceKeyPrefix+workspace.TeamID, string(data))
+}
+
+// DeleteWorkspace removes the workspace and its bot token
+func (s *TokenWorkspaceStore) DeleteWorkspace(teamID string) error {
+	return s.tokens.DeleteToken(workspaceKeyPrefix + teamID)
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// writePausePrefix is where S3WritePauseStore keeps the state of each subject
+const writePausePrefix = "anomaly/writes/"
+
+// WritePause is the burst state of a user or project, shared by every instance
+type WritePause struct {
+	PausedSince time.Time `json:"paused_since,omitempty"` // When writes were paused, zero while they are allowed
+	ApprovedAt  time.Time `json:"approved_at,omitempty"`  // When an admin last lifted a pause
+}
+
+// WritePauseStore defines the interface for storing which subjects' writes are paused
+type WritePauseStore interface {
+	// Load returns the subject's state, empty if it was never paused
+	Load(ctx context.Context, subject string) (WritePause, error)
+	Save(ctx context.Context, subject string, pause WritePause) error
+	// List returns the state of every subject that was ever paused
+	List(ctx context.Context) (map[string]WritePause, error)
+}
+
+// S3WritePauseStore implements WritePauseStore with an object per subject in AWS S3
+type S3WritePauseStore struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3WritePauseStore creates a new S3WritePauseStore instance
+func NewS3WritePauseStore(client *s3.Client, bucketName string) *S3WritePauseStore {
+	return &S3WritePauseStore{
+		client:     client,
+		bucketName: bucketName,
+	}
+}
+
+// Load retrieves the subject's state
+func (s *S3WritePauseStore) Load(ctx context.Context, subject string) (WritePause, error) {
+	return s.load(ctx, writePausePrefix+subject+".json")
+}
+
+// load reads the state stored under the key
+func (s *S3WritePauseStore) load(ctx context.Context, key string) (WritePause, error) {
+	var pause WritePause
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return pause, nil
+		}
+		return pause, fmt.Errorf("failed to get write pause from S3: %v", err)
+	}
+	defer result.Body.Close()
+
+	if err := json.NewDecoder(result.Body).Decode(&pause); err != nil {
+		return pause, fmt.Errorf("failed to decode write pause %s: %v", key, err)
+	}
+	return pause, nil
+}
+
+// Save stores the subject's state, replacing the previous one
+func (s *S3WritePauseStore) Save(ctx context.Context, subject string, pause WritePause) error {
+	data, err := json.Marshal(pause)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(writePausePrefix + subject + ".json"),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store write pause in S3: %v", err)
+	}
+	return nil
+}
+
+// List retrieves the state of every subject
+func (s *S3WritePauseStore) List(ctx context.Context) (map[string]WritePause, error) {
+	pauses := map[string]WritePause{}
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(writePausePrefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list write pauses: %v", err)
+		}
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			pause, err := s.load(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			pauses[strings.TrimSuffix(strings.TrimPrefix(key, writePausePrefix), ".json")] = pause
+		}
+	}
+	return pauses, nil
+}
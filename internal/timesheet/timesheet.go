@@ -0,0 +1,186 @@
+package timesheet
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"jira_helper/internal/jql"
+	"jira_helper/internal/service/jira"
+)
+
+const (
+	// MaxDays bounds the days of a report
+	MaxDays = 31
+
+	// maxIssues bounds the issues whose worklogs are read
+	maxIssues = 500
+
+	// dateLayout is how days are written in JQL, reports and exports
+	dateLayout = "2006-01-02"
+)
+
+// Source reads issues and their worklogs. *jira.Client implements it.
+type Source interface {
+	Search(ctx context.Context, jql string, opts jira.SearchOptions) ([]jira.Issue, int, error)
+	Worklogs(ctx context.Context, key string) ([]jira.Worklog, error)
+}
+
+// Row is the time an author logged per day of the report
+type Row struct {
+	Author  string // Jira username
+	Name    string // Display name
+	Seconds []int  // Logged per day of the report
+	Total   int
+}
+
+// Report is the time logged by a set of authors per day. It is computed from the worklogs
+// alone, without the model, so the same range always gives the same numbers.
+type Report struct {
+	Days   []time.Time
+	Rows   []Row // By name
+	Totals []int // Logged per day by all authors
+	Total  int
+	Issues int // Issues with time logged in the range
+}
+
+// Build aggregates the worklogs the authors started from the first to the last day, both
+// included. Days are the dates worklogs were started on in the author's time zone.
+func Build(ctx context.Context, client Source, authors []string, from, to time.Time) (*Report, error) {
+	from, to = day(from), day(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf("the report ends before it starts")
+	}
+	days := int(to.Sub(from).Hours()/24) + 1
+	if days > MaxDays {
+		return nil, fmt.Errorf("a report covers at most %d days", MaxDays)
+	}
+	if len(authors) == 0 {
+		return nil, fmt.Errorf("no authors to report on")
+	}
+
+	report := &Report{Totals: make([]int, days)}
+	for i := 0; i < days; i++ {
+		report.Days = append(report.Days, from.AddDate(0, 0, i))
+	}
+
+	query := fmt.Sprintf("worklogAuthor in %s AND worklogDate >= %s AND worklogDate <= %s",
+		jql.QuoteList(authors), jql.Quote(from.Format(dateLayout)), jql.Quote(to.Format(dateLayout)))
+	issues, _, err := client.Search(ctx, query, jira.SearchOptions{Fields: []string{"summary"}, Limit: maxIssues})
+	if err != nil {
+		return nil, err
+	}
+	report.Issues = len(issues)
+
+	wanted := map[string]bool{}
+	for _, author := range authors {
+		wanted[strings.ToLower(author)] = true
+	}
+	rows := map[string]*Row{}
+	for _, issue := range issues {
+		worklogs, err := client.Worklogs(ctx, issue.Key)
+		if err != nil {
+			return nil, err
+		}
+		for _, worklog := range worklogs {
+			if worklog.Author == nil || !wanted[strings.ToLower(worklog.Author.Name)] {
+				continue
+			}
+			started := day(worklog.Started.Time)
+			if started.Before(from) || started.After(to) {
+				continue
+			}
+			index := int(started.Sub(from).Hours() / 24)
+			row, ok := rows[worklog.Author.Name]
+			if !ok {
+				row = &Row{Author: worklog.Author.Name, Name: worklog.Author.DisplayName, Seconds: make([]int, days)}
+				rows[worklog.Author.Name] = row
+			}
+			row.Seconds[index] += worklog.TimeSpentSeconds
+			row.Total += worklog.TimeSpentSeconds
+			report.Totals[index] += worklog.TimeSpentSeconds
+			report.Total += worklog.TimeSpentSeconds
+		}
+	}
+
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool { return report.Rows[i].Name < report.Rows[j].Name })
+	return report, nil
+}
+
+// Table renders the report as a fixed-width table of hours, one row per author
+func (r *Report) Table() string {
+	var b strings.Builder
+	table := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	header := []string{"Author"}
+	for _, d := range r.Days {
+		header = append(header, d.Format("Mon 01-02"))
+	}
+	fmt.Fprintln(table, strings.Join(append(header, "Total"), "\t")+"\t")
+	for _, row := range r.Rows {
+		fmt.Fprintln(table, strings.Join(append(append([]string{row.Name}, cells(row.Seconds)...), Hours(row.Total)), "\t")+"\t")
+	}
+	if len(r.Rows) > 1 {
+		fmt.Fprintln(table, strings.Join(append(append([]string{"All"}, cells(r.Totals)...), Hours(r.Total)), "\t")+"\t")
+	}
+	_ = table.Flush()
+	return b.String()
+}
+
+// CSV exports the report with one row per author and the hours of each day
+func (r *Report) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := []string{"author", "name"}
+	for _, d := range r.Days {
+		header = append(header, d.Format(dateLayout))
+	}
+	records := [][]string{append(header, "total")}
+	for _, row := range r.Rows {
+		record := []string{row.Author, row.Name}
+		for _, seconds := range row.Seconds {
+			record = append(record, hours(seconds))
+		}
+		records = append(records, append(record, hours(row.Total)))
+	}
+	if err := w.WriteAll(records); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Hours renders seconds as hours, e.g. 7.5h
+func Hours(seconds int) string {
+	return hours(seconds) + "h"
+}
+
+// hours renders seconds as hours rounded to two decimals
+func hours(seconds int) string {
+	return strconv.FormatFloat(math.Round(float64(seconds)/36)/100, 'f', -1, 64)
+}
+
+// cells renders the hours of each day, with a dash for days without time logged
+func cells(seconds []int) []string {
+	cells := make([]string, len(seconds))
+	for i, s := range seconds {
+		cells[i] = "-"
+		if s > 0 {
+			cells[i] = Hours(s)
+		}
+	}
+	return cells
+}
+
+// day returns midnight of the date in UTC, so dates in different time zones compare by calendar day
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,76 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+)
+
+// Pool runs work with bounded concurrency. Work sharing a key, such as a Slack thread,
+// runs one at a time, while different keys run in parallel.
+type Pool struct {
+	slots chan struct{}
+
+	mu   sync.Mutex
+	keys map[string]*keyLock
+}
+
+// keyLock serializes work for one key and is dropped once nobody waits for it
+type keyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// New creates a new Pool instance running at most size tasks at once
+func New(size int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	return &Pool{
+		slots: make(chan struct{}, size),
+		keys:  map[string]*keyLock{},
+	}
+}
+
+// Do runs fn once no other work for the key is active and a slot is free, and returns its error
+func (p *Pool) Do(ctx context.Context, key string, fn func() error) error {
+	lock := p.acquire(key)
+	defer p.release(key, lock)
+
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+
+	// Take a slot only after the key is ours, so queued work for a busy thread does not block others
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-p.slots }()
+
+	return fn()
+}
+
+// acquire returns the lock for the key, creating it if needed
+func (p *Pool) acquire(key string) *keyLock {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lock, ok := p.keys[key]
+	if !ok {
+		lock = &keyLock{}
+		p.keys[key] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+// release drops the key's lock when no work references it any more
+func (p *Pool) release(key string, lock *keyLock) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(p.keys, key)
+	}
+}
@@ -0,0 +1,195 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"jira_helper/internal/jql"
+	"jira_helper/internal/service/jira"
+)
+
+const (
+	// maxEpicIssues bounds the issues counted per epic
+	maxEpicIssues = 1000
+
+	// epicLinkField is the name of the field linking issues to their epic in Jira Software
+	epicLinkField = "epic link"
+
+	// Status categories, in the order work moves through them
+	categoryToDo       = "new"
+	categoryInProgress = "indeterminate"
+	categoryDone       = "done"
+)
+
+// categoryOrder sorts statuses from to do to done
+var categoryOrder = map[string]int{categoryToDo: 0, categoryInProgress: 1, categoryDone: 2}
+
+// EpicJira is the part of the Jira client the epic report uses. *jira.Client implements it.
+type EpicJira interface {
+	GetIssue(ctx context.Context, key string, fields ...string) (*jira.Issue, error)
+	Search(ctx context.Context, jql string, opts jira.SearchOptions) ([]jira.Issue, int, error)
+	Fields(ctx context.Context) ([]jira.Field, error)
+}
+
+// EpicStatus is the work of an epic in one status
+type EpicStatus struct {
+	Name     string  `json:"name"`
+	Category string  `json:"category"` // new, indeterminate or done
+	Issues   int     `json:"issues"`
+	Points   float64 `json:"points,omitempty"`
+}
+
+// EpicProgress is how far the issues of an epic are from done
+type EpicProgress struct {
+	Key         string       `json:"key"`
+	Summary     string       `json:"summary"`
+	Status      string       `json:"status"`
+	Unit        string       `json:"unit"` // UnitPoints, or UnitIssues when the issues are not estimated
+	Statuses    []EpicStatus `json:"statuses"`
+	Issues      int          `json:"issues"`
+	DoneIssues  int          `json:"done_issues"`
+	Points      float64      `json:"points,omitempty"`
+	DonePoints  float64      `json:"done_points,omitempty"`
+	Unestimated int          `json:"unestimated,omitempty"` // Issues counted without story points
+	Counted     int          `json:"counted"`               // Issues read, fewer than Issues when the epic is too large
+}
+
+// Remaining returns the work left, in the report's unit
+func (p *EpicProgress) Remaining() float64 {
+	if p.Unit == UnitPoints {
+		return p.Points - p.DonePoints
+	}
+	return float64(p.Issues - p.DoneIssues)
+}
+
+// Done returns the share of the work that is done, from 0 to 1
+func (p *EpicProgress) Done() float64 {
+	if p.Unit == UnitPoints {
+		if p.Points == 0 {
+			return 0
+		}
+		return p.DonePoints / p.Points
+	}
+	if p.Counted == 0 {
+		return 0
+	}
+	return float64(p.DoneIssues) / float64(p.Counted)
+}
+
+// EpicReport counts the issues of an epic by status, and the story points done and remaining.
+// Issues belong to the epic through the Epic Link field or as its children.
+func EpicReport(ctx context.Context, client EpicJira, key string) (*EpicProgress, error) {
+	progress := &EpicProgress{Key: key, Unit: UnitIssues}
+	var linkField, pointsField string
+	var issues []jira.Issue
+
+	err := Run(ctx,
+		Step{Name: "read epic", Run: func(ctx context.Context) error {
+			epic, err := client.GetIssue(ctx, key, "summary", "status", "issuetype")
+			if err != nil {
+				return err
+			}
+			if epic.Fields.IssueType != nil && !strings.EqualFold(epic.Fields.IssueType.Name, "epic") {
+				return fmt.Errorf("%s is a %s, not an epic", key, epic.Fields.IssueType.Name)
+			}
+			progress.Summary = epic.Fields.Summary
+			if epic.Fields.Status != nil {
+				progress.Status = epic.Fields.Status.Name
+			}
+			return nil
+		}},
+		Step{Name: "find fields", Run: func(ctx context.Context) error {
+			fields, err := client.Fields(ctx)
+			if err != nil {
+				return err
+			}
+			pointsField = estimateField(fields)
+			for _, field := range fields {
+				if field.Custom && strings.EqualFold(field.Name, epicLinkField) {
+					linkField = field.Name
+				}
+			}
+			return nil
+		}},
+		Step{Name: "read issues", Run: func(ctx context.Context) error {
+			query := "parent = " + jql.Quote(key)
+			if linkField != "" {
+				query = fmt.Sprintf("%s = %s OR %s", jql.Quote(linkField), jql.Quote(key), query)
+			}
+			fields := []string{"status"}
+			if pointsField != "" {
+				fields = append(fields, pointsField)
+			}
+			var err error
+			issues, progress.Issues, err = client.Search(ctx, query, jira.SearchOptions{Fields: fields, Limit: maxEpicIssues})
+			return err
+		}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	countEpicIssues(progress, issues, pointsField)
+	return progress, nil
+}
+
+// countEpicIssues adds up the issues and points per status. Points are reported when any issue
+// is estimated.
+func countEpicIssues(progress *EpicProgress, issues []jira.Issue, pointsField string) {
+	statuses := map[string]*EpicStatus{}
+	for _, issue := range issues {
+		name, category := "Unknown", categoryToDo
+		if status := issue.Fields.Status; status != nil {
+			name = status.Name
+			if status.Category != nil {
+				category = status.Category.Key
+			}
+		}
+		status, ok := statuses[name]
+		if !ok {
+			status = &EpicStatus{Name: name, Category: category}
+			statuses[name] = status
+		}
+		status.Issues++
+
+		points, estimated := 0.0, false
+		if pointsField != "" {
+			points, estimated = issue.Fields.Number(pointsField)
+		}
+		if !estimated {
+			progress.Unestimated++
+		}
+		status.Points += points
+		progress.Points += points
+		if category == categoryDone {
+			progress.DoneIssues++
+			progress.DonePoints += points
+		}
+	}
+	progress.Counted = len(issues)
+	if progress.Points > 0 {
+		progress.Unit = UnitPoints
+	} else {
+		progress.Unestimated = 0
+	}
+
+	for _, status := range statuses {
+		progress.Statuses = append(progress.Statuses, *status)
+	}
+	sort.Slice(progress.Statuses, func(i, j int) bool {
+		a, b := progress.Statuses[i], progress.Statuses[j]
+		if categoryOrder[a.Category] != categoryOrder[b.Category] {
+			return categoryOrder[a.Category] < categoryOrder[b.Category]
+		}
+		return a.Name < b.Name
+	})
+}
+
+// ProgressBar renders a share from 0 to 1 as a bar of the given width, e.g. ▓▓▓▓▓░░░░░ 50%
+func ProgressBar(done float64, width int) string {
+	done = math.Max(0, math.Min(1, done))
+	filled := int(math.Round(done * float64(width)))
+	return fmt.Sprintf("%s%s %d%%", strings.Repeat("▓", filled), strings.Repeat("░", width-filled), int(math.Round(done*100)))
+}
@@ -0,0 +1,281 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"jira_helper/internal/service/jira"
+)
+
+const (
+	// velocitySprints is the number of closed sprints the capacity is averaged over
+	velocitySprints = 3
+
+	// maxBacklogIssues bounds the backlog issues considered for the next sprint
+	maxBacklogIssues = 200
+
+	// maxSprintIssues bounds the issues counted per closed sprint
+	maxSprintIssues = 500
+
+	// UnitPoints and UnitIssues are what capacity is measured in
+	UnitPoints = "points"
+	UnitIssues = "issues"
+)
+
+// storyPointFields are the names of the estimate field in Jira Software
+var storyPointFields = []string{"story points", "story point estimate"}
+
+// sprintNumber matches the number a sprint name ends with, e.g. "Team Sprint 14"
+var sprintNumber = regexp.MustCompile(`^(.*?)(\d+)\s*$`)
+
+// SprintJira is the part of the Jira client sprint planning uses. *jira.Client implements it.
+type SprintJira interface {
+	GetBoard(ctx context.Context, boardID int) (*jira.Board, error)
+	Sprints(ctx context.Context, boardID int, states ...string) ([]jira.Sprint, error)
+	Backlog(ctx context.Context, boardID int, fields []string, limit int) ([]jira.Issue, error)
+	Search(ctx context.Context, jql string, opts jira.SearchOptions) ([]jira.Issue, int, error)
+	Fields(ctx context.Context) ([]jira.Field, error)
+	CreateSprint(ctx context.Context, boardID int, name string) (*jira.Sprint, error)
+	MoveToSprint(ctx context.Context, sprintID int, keys []string) error
+}
+
+// SprintVelocity is the work completed in a closed sprint
+type SprintVelocity struct {
+	Name      string
+	Completed float64
+}
+
+// PlannedIssue is a backlog issue considered for the sprint
+type PlannedIssue struct {
+	Key      string
+	Summary  string
+	Type     string
+	Estimate float64 // Story points, 0 when capacity is counted in issues
+}
+
+// SprintPlan is the proposed scope of a board's next sprint
+type SprintPlan struct {
+	BoardID     int
+	BoardName   string
+	ProjectKey  string
+	SprintName  string
+	Unit        string           // UnitPoints, or UnitIssues when the board's issues are not estimated
+	Capacity    float64          // Work the team is expected to complete
+	Velocity    []SprintVelocity // The closed sprints the capacity was measured on, oldest first
+	Scope       []PlannedIssue   // Backlog issues proposed for the sprint, in rank order
+	Planned     float64          // Work of the proposed issues
+	Unestimated []PlannedIssue   // Issues ranked within the scope but left out without an estimate
+	Remaining   int              // Backlog issues after the scope
+}
+
+// Keys returns the keys of the proposed issues
+func (p *SprintPlan) Keys() []string {
+	keys := make([]string, len(p.Scope))
+	for i, issue := range p.Scope {
+		keys[i] = issue.Key
+	}
+	return keys
+}
+
+// PlanSprint proposes the next sprint of a scrum board: it measures the capacity on the last
+// closed sprints, unless one is given, and fills it with backlog issues in rank order. Nothing
+// is changed in Jira.
+func PlanSprint(ctx context.Context, client SprintJira, boardID int, capacity float64) (*SprintPlan, error) {
+	plan := &SprintPlan{BoardID: boardID, Unit: UnitIssues, Capacity: capacity}
+	var pointsField string
+	var backlog []jira.Issue
+
+	err := Run(ctx,
+		Step{Name: "read board", Run: func(ctx context.Context) error {
+			board, err := client.GetBoard(ctx, boardID)
+			if err != nil {
+				return err
+			}
+			if board.Type != "scrum" {
+				return fmt.Errorf("board %s is a %s board, only scrum boards have sprints", board.Name, board.Type)
+			}
+			plan.BoardName, plan.ProjectKey = board.Name, board.Location.ProjectKey
+			return nil
+		}},
+		Step{Name: "find estimate field", Run: func(ctx context.Context) error {
+			fields, err := client.Fields(ctx)
+			if err != nil {
+				return err
+			}
+			pointsField = estimateField(fields)
+			if pointsField != "" {
+				plan.Unit = UnitPoints
+			}
+			return nil
+		}},
+		Step{Name: "measure velocity", Run: func(ctx context.Context) error {
+			sprints, err := client.Sprints(ctx, boardID)
+			if err != nil {
+				return err
+			}
+			plan.SprintName = nextSprintName(plan.BoardName, sprints)
+			pointsField, err = measureVelocity(ctx, client, plan, sprints, pointsField)
+			return err
+		}},
+		Step{Name: "read backlog", Run: func(ctx context.Context) error {
+			fields := []string{"summary", "issuetype", "status"}
+			if pointsField != "" {
+				fields = append(fields, pointsField)
+			}
+			var err error
+			backlog, err = client.Backlog(ctx, boardID, fields, maxBacklogIssues)
+			return err
+		}},
+		Step{Name: "propose scope", Run: func(context.Context) error {
+			proposeScope(plan, backlog, pointsField)
+			return nil
+		}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// ApplySprintPlan creates the sprint on the board and moves the issues into it
+func ApplySprintPlan(ctx context.Context, client SprintJira, boardID int, name string, keys []string) (*jira.Sprint, error) {
+	var sprint *jira.Sprint
+	err := Run(ctx,
+		Step{Name: "create sprint", Run: func(ctx context.Context) error {
+			var err error
+			sprint, err = client.CreateSprint(ctx, boardID, name)
+			return err
+		}},
+		Step{Name: "move issues", Run: func(ctx context.Context) error {
+			return client.MoveToSprint(ctx, sprint.ID, keys)
+		}},
+	)
+	return sprint, err
+}
+
+// measureVelocity sets the capacity to the average work completed in the last closed sprints,
+// unless a capacity was given. Teams that completed issues without estimating them are measured
+// in issues, and the returned field is empty then.
+func measureVelocity(ctx context.Context, client SprintJira, plan *SprintPlan, sprints []jira.Sprint, pointsField string) (string, error) {
+	var closed []jira.Sprint
+	for _, sprint := range sprints {
+		if sprint.State == "closed" && sprint.OriginBoardID == plan.BoardID {
+			closed = append(closed, sprint)
+		}
+	}
+	sort.Slice(closed, func(i, j int) bool { return closed[i].CompleteDate.Before(closed[j].CompleteDate.Time) })
+	if len(closed) > velocitySprints {
+		closed = closed[len(closed)-velocitySprints:]
+	}
+	if len(closed) == 0 {
+		if plan.Capacity <= 0 {
+			return "", fmt.Errorf("board %s has no closed sprints to measure its velocity on, give the capacity instead", plan.BoardName)
+		}
+		return pointsField, nil
+	}
+
+	fields := []string{"summary"}
+	if pointsField != "" {
+		fields = append(fields, pointsField)
+	}
+	completed := make([][]jira.Issue, len(closed))
+	points := 0.0
+	for i, sprint := range closed {
+		issues, _, err := client.Search(ctx, fmt.Sprintf("sprint = %d AND statusCategory = Done", sprint.ID), jira.SearchOptions{Fields: fields, Limit: maxSprintIssues})
+		if err != nil {
+			return "", err
+		}
+		completed[i] = issues
+		for _, issue := range issues {
+			points += work(issue, pointsField)
+		}
+	}
+	if pointsField != "" && points == 0 {
+		pointsField, plan.Unit = "", UnitIssues
+	}
+
+	total := 0.0
+	for i, sprint := range closed {
+		done := 0.0
+		for _, issue := range completed[i] {
+			done += work(issue, pointsField)
+		}
+		plan.Velocity = append(plan.Velocity, SprintVelocity{Name: sprint.Name, Completed: done})
+		total += done
+	}
+	if plan.Capacity <= 0 {
+		plan.Capacity = math.Round(total/float64(len(closed))*10) / 10
+	}
+	return pointsField, nil
+}
+
+// proposeScope adds backlog issues in rank order until the next one would exceed the capacity.
+// Issues without an estimate are left out and listed, so they can be estimated first.
+func proposeScope(plan *SprintPlan, backlog []jira.Issue, pointsField string) {
+	for i, issue := range backlog {
+		planned := PlannedIssue{Key: issue.Key, Summary: issue.Fields.Summary}
+		if issue.Fields.IssueType != nil {
+			planned.Type = issue.Fields.IssueType.Name
+		}
+		if pointsField != "" {
+			estimate, ok := issue.Fields.Number(pointsField)
+			if !ok {
+				plan.Unestimated = append(plan.Unestimated, planned)
+				continue
+			}
+			planned.Estimate = estimate
+		}
+		effort := work(issue, pointsField)
+		if plan.Planned+effort > plan.Capacity {
+			plan.Remaining = len(backlog) - i
+			return
+		}
+		plan.Scope = append(plan.Scope, planned)
+		plan.Planned += effort
+	}
+}
+
+// work returns the story points of the issue, or 1 when issues are counted
+func work(issue jira.Issue, pointsField string) float64 {
+	if pointsField == "" {
+		return 1
+	}
+	points, _ := issue.Fields.Number(pointsField)
+	return points
+}
+
+// estimateField returns the ID of the story points field, empty if Jira has none
+func estimateField(fields []jira.Field) string {
+	for _, name := range storyPointFields {
+		for _, field := range fields {
+			if field.Custom && strings.EqualFold(field.Name, name) {
+				return field.ID
+			}
+		}
+	}
+	return ""
+}
+
+// nextSprintName numbers the sprint after the board's latest one, e.g. "Team Sprint 15" after
+// "Team Sprint 14"
+func nextSprintName(boardName string, sprints []jira.Sprint) string {
+	// Sprint IDs increase, the highest one was created last
+	latest := -1
+	for i, sprint := range sprints {
+		if latest < 0 || sprint.ID > sprints[latest].ID {
+			latest = i
+		}
+	}
+	if latest >= 0 {
+		if match := sprintNumber.FindStringSubmatch(sprints[latest].Name); match != nil {
+			number, _ := strconv.Atoi(match[2])
+			return match[1] + strconv.Itoa(number+1)
+		}
+	}
+	return fmt.Sprintf("%s Sprint %d", boardName, len(sprints)+1)
+}
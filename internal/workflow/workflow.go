@@ -0,0 +1,26 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+)
+
+// Step is one stage of a workflow
+type Step struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Run runs the steps in order and stops at the first failure. The error names the step, and
+// wraps the step's error so callers can still match Jira errors.
+func Run(ctx context.Context, steps ...Step) error {
+	for _, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := step.Run(ctx); err != nil {
+			return fmt.Errorf("%s: %w", step.Name, err)
+		}
+	}
+	return nil
+}